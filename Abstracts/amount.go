@@ -0,0 +1,264 @@
+package Abstracts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxAmountCents bounds amounts so that cents never overflow int64, far above any M-Pesa limit.
+const maxAmountCents = math.MaxInt64 / 100
+
+// Amount is a sum of Kenyan shillings held as a whole number of cents, so that it is never
+// rounded the way float64 amounts are. The zero value is KES 0.
+//
+// M-Pesa APIs differ in the form they take amounts in: most only accept whole shillings,
+// while reversals and Bill Manager invoices take decimals. Amount converts to each form with
+// WholeShillings, WholeString and String, reporting amounts that cannot be sent as they are
+// instead of rounding them silently.
+//
+// Example:
+//
+//	amount, err := Abstracts.FromString("1,500.50")
+//	if err != nil || !amount.Positive() {
+//	    return errors.New("invalid amount")
+//	}
+//	stkService.SetAmountValue(amount.Round()) // sends 1501
+type Amount struct {
+	cents int64
+}
+
+// FromKES returns an amount of whole shillings.
+//
+// Parameters:
+//   - shillings: The amount in KES, e.g. 1500
+//
+// Returns:
+//   - Amount: The amount
+func FromKES(shillings int) Amount {
+	return Amount{cents: int64(shillings) * 100}
+}
+
+// FromCents returns an amount given in cents, e.g. 150050 for KES 1,500.50.
+func FromCents(cents int64) Amount {
+	return Amount{cents: cents}
+}
+
+// FromFloat returns the amount f is closest to as a decimal, e.g. 1500.5 as KES 1,500.50.
+// Amounts with more than two decimal places, NaN and infinities are rejected.
+//
+// Parameters:
+//   - f: The amount in KES
+//
+// Returns:
+//   - Amount: The amount
+//   - error: An error if f is not a number or has a fraction of a cent
+func FromFloat(f float64) (Amount, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Amount{}, fmt.Errorf("amount %v is not a valid number", f)
+	}
+	// The shortest decimal that parses back to f is "1500.5" for 1500.5, but
+	// "0.30000000000000004" for 0.1+0.2, which is rejected rather than rounded.
+	return FromString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// FromString parses a decimal amount such as "1500", "1,500.50", "1 500.5" or "-20". Thousands
+// separators and surrounding spaces are ignored; more than two decimal places are rejected
+// unless they are zeros.
+//
+// Parameters:
+//   - s: The amount in KES
+//
+// Returns:
+//   - Amount: The amount
+//   - error: An error if s is not a decimal number or has a fraction of a cent
+//
+// Example:
+//
+//	amount, err := Abstracts.FromString(invoice.Total) // "1,500.50" is 150050 cents
+func FromString(s string) (Amount, error) {
+	trimmed := strings.NewReplacer(",", "", " ", "").Replace(strings.TrimSpace(s))
+	negative := strings.HasPrefix(trimmed, "-")
+	if negative || strings.HasPrefix(trimmed, "+") {
+		trimmed = trimmed[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(trimmed, ".")
+	if (whole == "" && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return Amount{}, fmt.Errorf("invalid amount %q: not a decimal number", s)
+	}
+	if len(strings.TrimRight(fraction, "0")) > 2 {
+		return Amount{}, fmt.Errorf("invalid amount %q: more precise than a cent", s)
+	}
+	fraction = (fraction + "00")[:2]
+
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole) > 16 {
+		return Amount{}, fmt.Errorf("invalid amount %q: too large", s)
+	}
+	var shillings int64
+	if whole != "" {
+		shillings, _ = strconv.ParseInt(whole, 10, 64)
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+	total := shillings*100 + cents
+	if total > maxAmountCents {
+		return Amount{}, fmt.Errorf("invalid amount %q: too large", s)
+	}
+	if negative {
+		total = -total
+	}
+	return Amount{cents: total}, nil
+}
+
+// isDigits reports whether s only holds ASCII digits; the empty string does.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Cents returns the amount in cents.
+func (a Amount) Cents() int64 {
+	return a.cents
+}
+
+// Float64 returns the amount in KES as a float64, e.g. for APIs of other libraries. Amounts
+// with cents may not be exactly representable.
+func (a Amount) Float64() float64 {
+	return float64(a.cents) / 100
+}
+
+// IsWhole reports whether the amount is a whole number of shillings.
+func (a Amount) IsWhole() bool {
+	return a.cents%100 == 0
+}
+
+// WholeShillings returns the amount in whole shillings, the form most M-Pesa APIs take, or an
+// error when it has cents; use Round or Truncate first to drop them.
+//
+// Returns:
+//   - int64: The amount in KES
+//   - error: An error if the amount has a fractional part
+func (a Amount) WholeShillings() (int64, error) {
+	if !a.IsWhole() {
+		return 0, fmt.Errorf("amount %s has a fractional part; M-Pesa only accepts whole shillings", a)
+	}
+	return a.cents / 100, nil
+}
+
+// WholeString returns the amount in whole shillings as a decimal string, e.g. "1500", or an
+// error when it has cents.
+func (a Amount) WholeString() (string, error) {
+	shillings, err := a.WholeShillings()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(shillings, 10), nil
+}
+
+// String returns the amount as a decimal without trailing zeros, e.g. "1500", "1500.5" or
+// "150.75", the form of reversal and Bill Manager amounts.
+func (a Amount) String() string {
+	sign, cents := "", a.cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	s := sign + strconv.FormatInt(cents/100, 10)
+	if frac := cents % 100; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%02d", frac), "0")
+	}
+	return s
+}
+
+// Format returns the amount for display with thousands separators and two decimal places,
+// e.g. "KES 1,500.50".
+func (a Amount) Format() string {
+	sign, cents := "", a.cents
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	digits := strconv.FormatInt(cents/100, 10)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+	return fmt.Sprintf("KES %s%s.%02d", sign, grouped.String(), cents%100)
+}
+
+// Round returns the amount rounded to the nearest shilling, with halves rounded away from zero
+// (150.50 becomes 151).
+func (a Amount) Round() Amount {
+	if a.cents < 0 {
+		return Amount{cents: -Amount{cents: -a.cents}.Round().cents}
+	}
+	return Amount{cents: (a.cents + 50) / 100 * 100}
+}
+
+// Truncate returns the amount without its cents (150.99 becomes 150).
+func (a Amount) Truncate() Amount {
+	return Amount{cents: a.cents / 100 * 100}
+}
+
+// Add returns the sum of a and b.
+func (a Amount) Add(b Amount) Amount {
+	return Amount{cents: a.cents + b.cents}
+}
+
+// Sub returns a minus b.
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{cents: a.cents - b.cents}
+}
+
+// Cmp compares a and b, returning -1 when a is less than b, 0 when they are equal and +1 when
+// a is greater.
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.cents < b.cents:
+		return -1
+	case a.cents > b.cents:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether the amount is KES 0.
+func (a Amount) IsZero() bool {
+	return a.cents == 0
+}
+
+// Positive reports whether the amount is greater than KES 0, as every M-Pesa payment must be.
+func (a Amount) Positive() bool {
+	return a.cents > 0
+}
+
+// WithinLimits reports whether the amount is between min and max inclusive. As with the
+// SetAmountLimits methods of the services, a zero limit disables that bound.
+//
+// Parameters:
+//   - min: The minimum amount, or the zero Amount for no minimum
+//   - max: The maximum amount, or the zero Amount for no maximum
+//
+// Returns:
+//   - bool: true when the amount is within the limits
+//
+// Example:
+//
+//	amount.WithinLimits(Abstracts.FromKES(10), Abstracts.FromKES(150000)) // B2C limits
+func (a Amount) WithinLimits(min, max Amount) bool {
+	if !min.IsZero() && a.Cmp(min) < 0 {
+		return false
+	}
+	if !max.IsZero() && a.Cmp(max) > 0 {
+		return false
+	}
+	return true
+}
@@ -53,7 +53,7 @@ func NewApiClient(config *MpesaConfig) *ApiClient {
 //
 //	data := map[string]any{
 //	    "BusinessShortCode": "174379",
-//	    "Amount": "100",
+//	    "Amount": 100,
 //	    "PhoneNumber": "254711223344",
 //	    "CallBackURL": "https://example.com/callback",
 //	}
@@ -135,23 +135,20 @@ func (client *ApiClient) sendRequest(payload any, endpoint, token string, isRetr
 
 	var response map[string]any
 	if err := json.Unmarshal(body, &response); err != nil {
-		fmt.Println(string(body))
+		fmt.Println(maskMSISDNsIn(string(body), client.Config.GetDefaultCountryCode()))
 		return nil, fmt.Errorf("response decode error: %w", err)
 	}
 
 	if resp.StatusCode >= 400 {
-		msg := "Unknown error"
-		if val, ok := response["errorMessage"]; ok {
-			msg = fmt.Sprint(val)
-		}
+		apiErr := newAPIError(resp.StatusCode, response)
 
 		// Handle "The transaction is being processed" specially (for STK Push Query)
-		if msg == "The transaction is being processed" {
+		if apiErr.ErrorMessage == "The transaction is being processed" {
 			// Return response without error to allow caller to handle this state
 			return response, nil
 		}
 
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, msg)
+		return nil, apiErr
 	}
 
 	return response, nil
@@ -0,0 +1,44 @@
+package Abstracts
+
+import "fmt"
+
+// APIError is returned when the M-Pesa API answers with an error status or error body.
+// Callers can inspect it with errors.As to branch on the status or Daraja error code.
+//
+// Example:
+//
+//	var apiErr *Abstracts.APIError
+//	if errors.As(err, &apiErr) && apiErr.ErrorCode == "404.001.03" {
+//	    // invalid access token
+//	}
+type APIError struct {
+	StatusCode   int            // HTTP status code of the response
+	RequestID    string         // Daraja requestId, if present
+	ErrorCode    string         // Daraja errorCode (or ResponseCode), if present
+	ErrorMessage string         // Daraja errorMessage (or ResponseDescription)
+	Body         map[string]any // The decoded response body
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := e.ErrorMessage
+	if msg == "" {
+		msg = "Unknown error"
+	}
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, msg)
+}
+
+// newAPIError builds an APIError from a decoded error response body.
+func newAPIError(statusCode int, body map[string]any) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: body}
+	if v, ok := body["requestId"]; ok && v != nil {
+		e.RequestID = fmt.Sprint(v)
+	}
+	if v, ok := body["errorCode"]; ok && v != nil {
+		e.ErrorCode = fmt.Sprint(v)
+	}
+	if v, ok := body["errorMessage"]; ok && v != nil {
+		e.ErrorMessage = fmt.Sprint(v)
+	}
+	return e
+}
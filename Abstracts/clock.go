@@ -0,0 +1,66 @@
+package Abstracts
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the SDK the current time. Token expiry, request timestamps and passwords, and
+// the expiry of remembered callbacks and results are computed from it, so that tests can
+// control time with a FakeClock instead of sleeping. SystemClock is used by default.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// SystemClock is the Clock of the system, backed by time.Now.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock is a Clock for tests that only moves when told to. It is safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a FakeClock stopped at now.
+//
+// Parameters:
+//   - now: The time the clock starts at
+//
+// Returns:
+//   - *FakeClock: The clock, to pass to the SetClock methods or WithClock
+//
+// Example:
+//
+//	clock := Abstracts.NewFakeClock(time.Date(2024, 8, 12, 14, 30, 22, 0, time.UTC))
+//	client.TokenManager.SetClock(clock)
+//	clock.Advance(time.Hour) // the cached token has now expired
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time the clock is stopped at.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to now.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
@@ -0,0 +1,444 @@
+package Abstracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mpesaLocation is the timezone of the timestamps M-Pesa sends (EAT, UTC+3).
+var mpesaLocation = time.FixedZone("EAT", 3*60*60)
+
+// timeLayouts are the timestamp formats DecodeInto accepts for time.Time fields. The first two
+// are in EAT.
+var timeLayouts = []string{"20060102150405", "02.01.2006 15:04:05", time.RFC3339Nano}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// DecodeError reports a value DecodeInto could not decode, with the path of the value in the
+// input, e.g. "Result.ResultParameters.Amount" or "Items[2].Value".
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("decode: %v", e.Err)
+	}
+	return fmt.Sprintf("decode %s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeInto decodes a decoded JSON object, such as an ExecuteRequest result or a callback
+// payload, into a value of type T. Decoding is weakly typed, since Daraja is not consistent
+// about value types: strings and numbers convert into each other, booleans also accept "true",
+// "Y" or 1, and time.Time fields accept the compact "yyyyMMddHHmmss" and "dd.MM.yyyy HH:mm:ss"
+// timestamps (EAT) as well as RFC 3339. Nested objects decode into structs, maps or pointers,
+// arrays into slices, and types implementing json.Unmarshaler decode themselves.
+//
+// Struct fields are matched by the name in their mpesa tag, then their json tag, then the field
+// name; keys are matched exactly first and then case-insensitively, and null values are
+// skipped. A tag may list alternative keys separated by "|", and the "kv" option flattens a
+// list of {"Key": ..., "Value": ...} (or Name/Value) items, optionally wrapped in an object
+// such as {"ResultParameter": [...]}, into an object first. Embedded structs are decoded from
+// the same object, and fields tagged "-" are skipped:
+//
+//	type Result struct {
+//	    ConversationID string
+//	    OriginatorID   string `mpesa:"OriginatorConversationID|OriginatorCoversationID"`
+//	    Parameters     struct {
+//	        Amount float64 `mpesa:"TransactionAmount"`
+//	    } `mpesa:"ResultParameters,kv"`
+//	}
+//
+// Values that cannot be decoded are reported as *DecodeError values joined with errors.Join;
+// the other fields are still decoded, so the returned value is usable on error.
+//
+// Parameters:
+//   - m: The decoded JSON object
+//
+// Returns:
+//   - T: The decoded value
+//   - error: The values that could not be decoded, if any
+//
+// Example:
+//
+//	type stkQuery struct {
+//	    ResultCode string
+//	    ResultDesc string
+//	}
+//	resp, err := client.ExecuteRequest(payload, endpoint)
+//	if err != nil {
+//	    return err
+//	}
+//	query, err := Abstracts.DecodeInto[stkQuery](resp)
+func DecodeInto[T any](m map[string]any) (T, error) {
+	var out T
+	d := &mapDecoder{}
+	if m != nil {
+		d.decode(reflect.ValueOf(&out).Elem(), m, "")
+	}
+	return out, errors.Join(d.errs...)
+}
+
+// mapDecoder collects the errors of one DecodeInto call.
+type mapDecoder struct {
+	errs []error
+}
+
+// fail records an error for the value at path.
+func (d *mapDecoder) fail(path string, err error) {
+	d.errs = append(d.errs, &DecodeError{Path: path, Err: err})
+}
+
+// decode stores in into v. Null values leave v alone.
+func (d *mapDecoder) decode(v reflect.Value, in any, path string) {
+	if in == nil {
+		return
+	}
+	if v.Type() == timeType {
+		d.decodeTime(v, in, path)
+		return
+	}
+	if v.Kind() != reflect.Pointer && v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		d.decodeUnmarshaler(v.Addr().Interface().(json.Unmarshaler), in, path)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		d.decode(elem.Elem(), in, path)
+		v.Set(elem)
+	case reflect.Interface:
+		value := reflect.ValueOf(in)
+		if !value.Type().AssignableTo(v.Type()) {
+			d.fail(path, fmt.Errorf("cannot decode %T into %s", in, v.Type()))
+			return
+		}
+		v.Set(value)
+	case reflect.Struct:
+		object, ok := in.(map[string]any)
+		if !ok {
+			d.fail(path, fmt.Errorf("expected an object, got %T", in))
+			return
+		}
+		d.decodeStruct(v, object, path)
+	case reflect.Map:
+		d.decodeMap(v, in, path)
+	case reflect.Slice:
+		d.decodeSlice(v, in, path)
+	case reflect.String:
+		s, ok := scalarString(in)
+		if !ok {
+			d.fail(path, fmt.Errorf("cannot decode %T into string", in))
+			return
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := weakBool(in)
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := weakFloat(in)
+		if err == nil && (f != math.Trunc(f) || v.OverflowInt(int64(f))) {
+			err = fmt.Errorf("%v does not fit in %s", f, v.Type())
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		v.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := weakFloat(in)
+		if err == nil && (f < 0 || f != math.Trunc(f) || v.OverflowUint(uint64(f))) {
+			err = fmt.Errorf("%v does not fit in %s", f, v.Type())
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		v.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		f, err := weakFloat(in)
+		if err == nil && v.OverflowFloat(f) {
+			err = fmt.Errorf("%v does not fit in %s", f, v.Type())
+		}
+		if err != nil {
+			d.fail(path, err)
+			return
+		}
+		v.SetFloat(f)
+	default:
+		d.fail(path, fmt.Errorf("unsupported type %s", v.Type()))
+	}
+}
+
+// decodeStruct decodes the fields of v from object.
+func (d *mapDecoder) decodeStruct(v reflect.Value, object map[string]any, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		names, kv := fieldKeys(field)
+		if names == nil {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("mpesa") == "" && field.Tag.Get("json") == "" {
+			d.decodeStruct(v.Field(i), object, path)
+			continue
+		}
+
+		key, value, ok := lookupKey(object, names)
+		if !ok {
+			continue
+		}
+		if kv {
+			value = flattenKeyValues(value)
+		}
+		d.decode(v.Field(i), value, joinPath(path, key))
+	}
+}
+
+// decodeMap decodes an object into a map with string keys.
+func (d *mapDecoder) decodeMap(v reflect.Value, in any, path string) {
+	if v.Type().Key().Kind() != reflect.String {
+		d.fail(path, fmt.Errorf("unsupported map key type %s", v.Type().Key()))
+		return
+	}
+	object, ok := in.(map[string]any)
+	if !ok {
+		d.fail(path, fmt.Errorf("expected an object, got %T", in))
+		return
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(object)))
+	}
+	for key, value := range object {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		d.decode(elem, value, joinPath(path, key))
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+}
+
+// decodeSlice decodes an array into a slice. A single value decodes into a slice of one.
+func (d *mapDecoder) decodeSlice(v reflect.Value, in any, path string) {
+	items, ok := in.([]any)
+	if !ok {
+		items = []any{in}
+	}
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		d.decode(slice.Index(i), item, fmt.Sprintf("%s[%d]", path, i))
+	}
+	v.Set(slice)
+}
+
+// decodeTime decodes a timestamp string or compact timestamp number.
+func (d *mapDecoder) decodeTime(v reflect.Value, in any, path string) {
+	s, ok := scalarString(in)
+	if !ok {
+		d.fail(path, fmt.Errorf("cannot decode %T into time.Time", in))
+		return
+	}
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, mpesaLocation); err == nil {
+			v.Set(reflect.ValueOf(t))
+			return
+		}
+	}
+	d.fail(path, fmt.Errorf("cannot parse %q as a time", s))
+}
+
+// decodeUnmarshaler passes the JSON encoding of in to u.
+func (d *mapDecoder) decodeUnmarshaler(u json.Unmarshaler, in any, path string) {
+	data, err := json.Marshal(in)
+	if err == nil {
+		err = u.UnmarshalJSON(data)
+	}
+	if err != nil {
+		d.fail(path, err)
+	}
+}
+
+// fieldKeys returns the keys a struct field is decoded from and whether it has the kv option.
+// names is nil for skipped fields.
+func fieldKeys(field reflect.StructField) (names []string, kv bool) {
+	tag, ok := field.Tag.Lookup("mpesa")
+	if !ok {
+		tag = strings.Split(field.Tag.Get("json"), ",")[0]
+	}
+	if tag == "-" {
+		return nil, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		kv = kv || option == "kv"
+	}
+	if name == "" {
+		return []string{field.Name}, kv
+	}
+	return strings.Split(name, "|"), kv
+}
+
+// lookupKey returns the first of names present in object with a non-null value, matching keys
+// exactly and then case-insensitively.
+func lookupKey(object map[string]any, names []string) (string, any, bool) {
+	for _, name := range names {
+		if v, ok := object[name]; ok && v != nil {
+			return name, v, true
+		}
+	}
+	for _, name := range names {
+		for key, v := range object {
+			if v != nil && strings.EqualFold(strings.TrimSpace(key), name) {
+				return key, v, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// flattenKeyValues converts a list of Key/Value (or Name/Value) items into an object. The list
+// may be a single item, and may be wrapped in an object with a single key such as
+// "ResultParameter". Values that are not item lists are returned unchanged.
+func flattenKeyValues(in any) any {
+	if object, ok := in.(map[string]any); ok && len(object) == 1 {
+		if _, isItem := itemKey(object); !isItem {
+			for _, inner := range object {
+				in = inner
+			}
+		}
+	}
+
+	var items []any
+	switch v := in.(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		if _, ok := itemKey(v); !ok {
+			return in
+		}
+		items = []any{v}
+	default:
+		return in
+	}
+
+	out := make(map[string]any, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			if key, ok := itemKey(object); ok {
+				out[key] = object["Value"]
+			}
+		}
+	}
+	return out
+}
+
+// itemKey returns the Key or Name of a Key/Value item.
+func itemKey(item map[string]any) (string, bool) {
+	for _, name := range []string{"Key", "Name"} {
+		if v, ok := item[name]; ok && v != nil {
+			if key, ok := scalarString(v); ok && key != "" {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// joinPath appends key to path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// scalarString converts a string, number or boolean to its string form. Whole numbers are
+// formatted without a decimal point.
+func scalarString(in any) (string, bool) {
+	switch v := in.(type) {
+	case string:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e18 {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// weakFloat converts a number, numeric string or boolean to float64. Thousands separators in
+// strings are ignored.
+func weakFloat(in any) (float64, error) {
+	switch v := in.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	s, ok := scalarString(in)
+	if !ok {
+		return 0, fmt.Errorf("cannot decode %T into a number", in)
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot convert %q to a number", s)
+	}
+	return f, nil
+}
+
+// weakBool converts a boolean, number or string such as "true", "Y" or "0" to bool.
+func weakBool(in any) (bool, error) {
+	switch v := in.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no", "":
+			return false, nil
+		}
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, nil
+		}
+		return false, fmt.Errorf("cannot convert %q to a bool", v)
+	}
+	f, err := weakFloat(in)
+	if err != nil {
+		return false, fmt.Errorf("cannot decode %T into a bool", in)
+	}
+	return f != 0, nil
+}
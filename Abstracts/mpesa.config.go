@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 )
 
@@ -25,18 +26,29 @@ const (
 	Production Environment = "live"
 )
 
+// DefaultCountryCode is the country code services use for local phone numbers, such as
+// 0712345678, until MpesaConfig.SetDefaultCountryCode changes it: 254, for Kenya.
+const DefaultCountryCode = "254"
+
+// countryCodePattern matches the 1 to 3 digits of an ITU country calling code.
+var countryCodePattern = regexp.MustCompile(`^\d{1,3}$`)
+
 // MpesaConfig holds all configuration settings required for M-Pesa API operations.
 // This includes credentials, environment settings, URLs, and security parameters.
 type MpesaConfig struct {
-	consumerKey        string      // Consumer key from Safaricom Developer Portal
-	consumerSecret     string      // Consumer secret from Safaricom Developer Portal
-	environment        Environment // Target environment (sandbox or production)
-	baseURL            string      // Base URL for M-Pesa API endpoints
-	businessCode       string      // Business shortcode for transactions
-	passKey            string      // Lipa na M-Pesa Online passkey
-	securityCredential string      // Security credential for B2C and other operations
-	queueTimeoutURL    string      // URL for queue timeout notifications
-	resultURL          string      // URL for transaction result notifications
+	consumerKey        string         // Consumer key from Safaricom Developer Portal
+	consumerSecret     string         // Consumer secret from Safaricom Developer Portal
+	environment        Environment    // Target environment (sandbox or production)
+	baseURL            string         // Base URL for M-Pesa API endpoints
+	businessCode       string         // Business shortcode for transactions
+	passKey            string         // Lipa na M-Pesa Online passkey
+	securityCredential string         // Security credential for B2C and other operations
+	queueTimeoutURL    string         // URL for queue timeout notifications
+	resultURL          string         // URL for transaction result notifications
+	defaultCountryCode string         // Country code services prepend to local phone numbers
+	phoneValidator     PhoneValidator // Custom phone number check run by the services, or nil
+
+	Endpoints Endpoints // API endpoint paths used by the services; override individual paths as needed
 }
 
 // NewMpesaConfig creates a new M-Pesa configuration with the provided parameters.
@@ -99,6 +111,8 @@ func NewMpesaConfig(
 		securityCredential: getOrDefault(securityCredential, ""),
 		queueTimeoutURL:    getOrDefault(queueTimeoutURL, ""),
 		resultURL:          getOrDefault(resultURL, ""),
+		defaultCountryCode: DefaultCountryCode,
+		Endpoints:          DefaultEndpoints(),
 	}
 
 	return cfg, nil
@@ -183,6 +197,18 @@ func (cfg *MpesaConfig) GetResultURL() string {
 	return cfg.resultURL
 }
 
+// GetDefaultCountryCode returns the country code services use to convert local phone numbers
+// to international format.
+//
+// Returns:
+//   - string: The country code without a leading "+", DefaultCountryCode unless changed
+func (cfg *MpesaConfig) GetDefaultCountryCode() string {
+	if cfg.defaultCountryCode == "" {
+		return DefaultCountryCode
+	}
+	return cfg.defaultCountryCode
+}
+
 // Setters
 
 // SetBusinessCode sets the business shortcode for M-Pesa transactions.
@@ -212,6 +238,20 @@ func (cfg *MpesaConfig) SetPassKey(key string) {
 	cfg.passKey = key
 }
 
+// SetBaseURL overrides the base URL the API endpoints are resolved against, e.g. to point
+// the SDK at a local simulator such as mpesatest.NewServer. Trailing slashes are removed.
+// A TokenManager copies the base URL when it is created; Mpesa.SetBaseURL updates both.
+//
+// Parameters:
+//   - url: The base URL, without an endpoint path
+//
+// Example:
+//
+//	cfg.SetBaseURL(server.BaseURL())
+func (cfg *MpesaConfig) SetBaseURL(url string) {
+	cfg.baseURL = strings.TrimRight(url, "/")
+}
+
 // SetQueueTimeoutURL sets the URL where M-Pesa will send queue timeout notifications.
 //
 // Parameters:
@@ -236,6 +276,59 @@ func (cfg *MpesaConfig) SetResultURL(url string) {
 	cfg.resultURL = url
 }
 
+// GetPhoneValidator returns the custom phone number check set with SetPhoneValidator.
+//
+// Returns:
+//   - PhoneValidator: The validator, or nil if not set
+func (cfg *MpesaConfig) GetPhoneValidator() PhoneValidator {
+	return cfg.phoneValidator
+}
+
+// SetDefaultCountryCode sets the country code every service uses to convert local phone
+// numbers, such as 0712345678 or 712345678, to international format. It defaults to
+// DefaultCountryCode (254, Kenya); set it when using M-Pesa in another country, e.g. "255" for
+// Tanzania. BaseService.CleanPhoneNumber still takes an explicit country code for one-off
+// conversions.
+//
+// Parameters:
+//   - cc: The country calling code, 1 to 3 digits, with or without a leading "+"
+//
+// Returns:
+//   - error: An error if cc is not a country code; the setting is left unchanged
+//
+// Example:
+//
+//	if err := cfg.SetDefaultCountryCode("255"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (cfg *MpesaConfig) SetDefaultCountryCode(cc string) error {
+	code := strings.TrimPrefix(strings.TrimSpace(cc), "+")
+	if !countryCodePattern.MatchString(code) {
+		return fmt.Errorf("invalid country code %q: must be 1 to 3 digits", cc)
+	}
+	cfg.defaultCountryCode = code
+	return nil
+}
+
+// SetPhoneValidator sets a custom check that the STK Push, B2C and C2B services run on every
+// phone number after normalizing it; a service's own SetPhoneValidator takes precedence. Use
+// ChainPhoneValidators to run several. Pass nil to remove it.
+//
+// Parameters:
+//   - validator: The check to run, e.g. a denylist lookup
+//
+// Example:
+//
+//	cfg.SetPhoneValidator(Abstracts.PhoneValidatorFunc(func(msisdn string) error {
+//	    if denylist[msisdn] {
+//	        return fmt.Errorf("%s is on the denylist", msisdn)
+//	    }
+//	    return nil
+//	}))
+func (cfg *MpesaConfig) SetPhoneValidator(validator PhoneValidator) {
+	cfg.phoneValidator = validator
+}
+
 // SetSecurityCredential encrypts an initiator password and sets it as the security credential.
 // This credential is required for B2C transactions, reversals, and other operations that
 // require initiator authentication. The password is encrypted using AES-256-CBC encryption.
@@ -253,15 +346,34 @@ func (cfg *MpesaConfig) SetResultURL(url string) {
 //	    log.Printf("Failed to set security credential: %v", err)
 //	}
 func (cfg *MpesaConfig) SetSecurityCredential(initiatorPassword string) error {
+	credential, err := cfg.EncryptSecurityCredential(initiatorPassword)
+	if err != nil {
+		return err
+	}
+	cfg.securityCredential = credential
+	return nil
+}
+
+// EncryptSecurityCredential encrypts an initiator password the same way as SetSecurityCredential,
+// but returns the credential instead of storing it on the config. Services use it to hold a
+// credential of their own without affecting other services sharing the config.
+//
+// Parameters:
+//   - initiatorPassword: The plain text initiator password
+//
+// Returns:
+//   - string: The encrypted security credential
+//   - error: An error if encryption fails
+func (cfg *MpesaConfig) EncryptSecurityCredential(initiatorPassword string) (string, error) {
 	encryptionKey := []byte("mypasswordmypasswordmypassword12") // 32 bytes for AES-256
 	block, err := aes.NewCipher(encryptionKey)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	iv := make([]byte, aes.BlockSize)
 	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
-		return err
+		return "", err
 	}
 
 	encrypter := cipher.NewCBCEncrypter(block, iv)
@@ -271,9 +383,7 @@ func (cfg *MpesaConfig) SetSecurityCredential(initiatorPassword string) error {
 	encrypter.CryptBlocks(ciphertext, plaintext)
 
 	combined := append(iv, ciphertext...)
-	cfg.securityCredential = base64.StdEncoding.EncodeToString(combined)
-
-	return nil
+	return base64.StdEncoding.EncodeToString(combined), nil
 }
 
 func (cfg *MpesaConfig) OverrideSecurityCredential(credential string) {
@@ -0,0 +1,58 @@
+package Abstracts
+
+// Endpoints holds the API paths used by the SDK services, relative to the configured base URL.
+// Every MpesaConfig starts with DefaultEndpoints(); individual paths can be overridden when
+// Safaricom publishes a new API version or the sandbox differs from production.
+//
+// Example:
+//
+//	cfg.Endpoints.StkPush = "/mpesa/stkpush/v3/processrequest"
+type Endpoints struct {
+	OAuth             string // OAuth token generation
+	StkPush           string // Lipa na M-Pesa Online (STK Push) request
+	StkQuery          string // STK Push status query
+	C2BRegisterURL    string // C2B validation/confirmation URL registration
+	C2BSimulate       string // C2B payment simulation (sandbox only)
+	B2CPayment        string // Business to Customer payment request
+	B2BPayment        string // Business to Business payment request (PayBill, BuyGoods)
+	TaxRemittance     string // Tax remittance to KRA
+	AccountBalance    string // Account balance query
+	TransactionStatus string // Transaction status query
+	Reversal          string // Transaction reversal request
+	DynamicQR         string // Dynamic QR code generation
+	PullRegister      string // Pull Transactions shortcode registration
+	PullQuery         string // Pull Transactions query
+	BillManagerOptIn  string // Bill Manager onboarding
+	BillManagerBulk   string // Bill Manager bulk invoicing
+	BillManagerCancel string // Bill Manager single invoice cancellation
+	BillManagerUpdate string // Bill Manager single invoice update
+	BillManagerRecon  string // Bill Manager payment reconciliation
+}
+
+// DefaultEndpoints returns the endpoint paths for the current Daraja API versions.
+//
+// Returns:
+//   - Endpoints: The default endpoint paths
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		OAuth:             "/oauth/v1/generate?grant_type=client_credentials",
+		StkPush:           "/mpesa/stkpush/v1/processrequest",
+		StkQuery:          "/mpesa/stkpushquery/v1/query",
+		C2BRegisterURL:    "/mpesa/c2b/v1/registerurl",
+		C2BSimulate:       "/mpesa/c2b/v1/simulate",
+		B2CPayment:        "/mpesa/b2c/v1/paymentrequest",
+		B2BPayment:        "/mpesa/b2b/v1/paymentrequest",
+		TaxRemittance:     "/mpesa/b2b/v1/remittax",
+		AccountBalance:    "/mpesa/accountbalance/v1/query",
+		TransactionStatus: "/mpesa/transactionstatus/v1/query",
+		Reversal:          "/mpesa/reversal/v1/request",
+		DynamicQR:         "/mpesa/qrcode/v1/generate",
+		PullRegister:      "/pulltransactions/v1/register",
+		PullQuery:         "/pulltransactions/v1/query",
+		BillManagerOptIn:  "/v1/billmanager-invoice/optin",
+		BillManagerBulk:   "/v1/billmanager-invoice/bulk-invoicing",
+		BillManagerCancel: "/v1/billmanager-invoice/cancel-single-invoice",
+		BillManagerUpdate: "/v1/billmanager-invoice/update-single-invoice",
+		BillManagerRecon:  "/v1/billmanager-invoice/reconciliation",
+	}
+}
@@ -19,7 +19,7 @@ type MpesaInterface interface {
 	//
 	//	data := map[string]any{
 	//	    "BusinessShortCode": "174379",
-	//	    "Amount": "100",
+	//	    "Amount": 100,
 	//	    "PhoneNumber": "254711223344",
 	//	}
 	//	response, err := client.ExecuteRequest(data, "/mpesa/stkpush/v1/processrequest")
@@ -0,0 +1,271 @@
+package Abstracts
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// msisdnSeparators are the characters people write phone numbers with that are dropped
+	// when normalizing, e.g. in "0711 223 344" or "(0711) 223-344".
+	msisdnSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+	// digitRuns matches the runs of digits maskMSISDNsIn looks at.
+	digitRuns = regexp.MustCompile(`\d+`)
+
+	// kenyanMSISDNPattern matches Kenyan mobile numbers in the 2547XXXXXXXX / 2541XXXXXXXX format.
+	kenyanMSISDNPattern = regexp.MustCompile(`^254[17]\d{8}$`)
+)
+
+// Errors wrapped by NormalizeMSISDN and ValidateMSISDN, so callers can tell the failures apart
+// with errors.Is, e.g. to ask the user to retype the number or only to remove a letter from it.
+var (
+	ErrMSISDNEmpty            = errors.New("phone number cannot be empty")
+	ErrMSISDNInvalidCharacter = errors.New("phone number contains an invalid character")
+	ErrMSISDNTooShort         = errors.New("phone number is too short")
+	ErrMSISDNLength           = errors.New("phone number has the wrong number of digits")
+)
+
+// ErrUnsupportedPrefix is wrapped by the error ValidateMSISDNStrict returns for a phone number
+// outside the allowed prefixes, e.g. a foreign number, so errors.Is identifies it.
+var ErrUnsupportedPrefix = errors.New("unsupported phone number prefix")
+
+// DefaultMSISDNPrefixes are the prefixes ValidateMSISDNStrict allows when none are given: the
+// Safaricom mobile ranges 2547XXXXXXXX (07xx numbers) and 2541XXXXXXXX (01xx numbers).
+var DefaultMSISDNPrefixes = []string{"2547", "2541"}
+
+// errKenyanMSISDN reports a number starting with 254 that is not a Kenyan mobile number.
+var errKenyanMSISDN = errors.New("phone number must be a Kenyan mobile number in the format 2547XXXXXXXX or 2541XXXXXXXX")
+
+// Bounds on the length of an MSISDN in international format; E.164 numbers have at most 15 digits.
+const (
+	minMSISDNLength = 10
+	maxMSISDNLength = 15
+)
+
+// subscriberLength is the length of a Kenyan phone number without the country code or the
+// leading 0, e.g. 712345678.
+const subscriberLength = 9
+
+// NormalizeMSISDN converts a phone number to the international format M-Pesa APIs take, digits
+// only and without a leading "+". Spaces, dashes, dots and parentheses are dropped first, so
+// "(0711) 223-344" and " (254)711223344" are read as 0711223344 and 254711223344; any other
+// character, letters and non-ASCII digits included, is an error.
+//
+// Numbers starting with "+" or "00" are international. Other numbers are national and must be
+// one of: 10 digits starting with "0" (0712345678), 9 digits with the 0 left out (712345678;
+// for 254 they must start with 7 or 1, the Kenyan mobile ranges), or countryCode followed by 9
+// digits (254712345678); countryCode replaces the 0 or is prepended. However it was written, a
+// number in countryCode has 9 digits after it, as Kenyan numbers do.
+//
+// NormalizeMSISDN only formats the number; use ValidateMSISDN to check the result. Its errors
+// leave the number out, so they can be logged. It has no state and is safe for concurrent use.
+//
+// Parameters:
+//   - phone: The phone number in various formats (0711223344, 254711223344, +254 711 223 344)
+//   - countryCode: The country code to use for local numbers (e.g., "254" for Kenya)
+//
+// Returns:
+//   - string: The phone number in international format
+//   - error: An error wrapping ErrMSISDNEmpty, ErrMSISDNInvalidCharacter, ErrMSISDNTooShort or
+//     ErrMSISDNLength
+//
+// Example:
+//
+//	msisdn, err := Abstracts.NormalizeMSISDN("0711 223 344", "254") // Returns: "254711223344"
+//	if err == nil {
+//	    err = Abstracts.ValidateMSISDN(msisdn)
+//	}
+func NormalizeMSISDN(phone, countryCode string) (string, error) {
+	trimmed := strings.TrimSpace(phone)
+	if trimmed == "" {
+		return "", ErrMSISDNEmpty
+	}
+
+	international := strings.HasPrefix(trimmed, "+")
+	digits := msisdnSeparators.Replace(strings.TrimPrefix(trimmed, "+"))
+	if i := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(digits[i:])
+		return "", fmt.Errorf("%w %q", ErrMSISDNInvalidCharacter, r)
+	}
+	if len(digits) < subscriberLength {
+		return "", fmt.Errorf("%w: %d digits", ErrMSISDNTooShort, len(digits))
+	}
+
+	countryCode = strings.TrimPrefix(countryCode, "+")
+	var msisdn string
+	switch {
+	case international:
+		msisdn = digits
+	case strings.HasPrefix(digits, "00"):
+		// The international call prefix, as in 00254711223344.
+		msisdn = digits[2:]
+	case len(digits) == subscriberLength && isSubscriberStart(digits[0], countryCode):
+		// The 0 left out, as in 712345678.
+		msisdn = countryCode + digits
+	case len(digits) == subscriberLength+1 && digits[0] == '0':
+		msisdn = countryCode + digits[1:]
+	case countryCode != "" && len(digits) == len(countryCode)+subscriberLength && strings.HasPrefix(digits, countryCode):
+		msisdn = digits
+	default:
+		return "", fmt.Errorf("%w: the number has %d digits; expected 0XXXXXXXXX, 7XXXXXXXX, 1XXXXXXXX or %sXXXXXXXXX",
+			ErrMSISDNLength, len(digits), countryCode)
+	}
+
+	// The final length check: a number in countryCode is countryCode and 9 digits, 12 for 254.
+	if want := len(countryCode) + subscriberLength; countryCode != "" && strings.HasPrefix(msisdn, countryCode) && len(msisdn) != want {
+		return "", fmt.Errorf("%w: the number is %d digits long, %d digits after the country code %s; expected %d",
+			ErrMSISDNLength, len(msisdn), len(msisdn)-len(countryCode), countryCode, want)
+	}
+	return msisdn, nil
+}
+
+// isSubscriberStart reports whether a 9-digit number starting with first is a subscriber number
+// in countryCode with the 0 left out.
+func isSubscriberStart(first byte, countryCode string) bool {
+	if countryCode == DefaultCountryCode {
+		return first == '7' || first == '1'
+	}
+	return first != '0'
+}
+
+// ValidateMSISDN checks that msisdn is a phone number in the international format
+// NormalizeMSISDN returns: digits only, without a leading 0, and 10 to 15 digits long. Kenyan
+// numbers, starting with 254, must also be mobile numbers (2547XXXXXXXX or 2541XXXXXXXX).
+// Its errors leave the number out. It has no state and is safe for concurrent use.
+//
+// Parameters:
+//   - msisdn: The phone number in international format, e.g. "254711223344"
+//
+// Returns:
+//   - error: An error describing why the number is invalid, or nil
+func ValidateMSISDN(msisdn string) error {
+	switch {
+	case msisdn == "":
+		return ErrMSISDNEmpty
+	case !isDigits(msisdn):
+		return fmt.Errorf("%w: the number must only contain digits", ErrMSISDNInvalidCharacter)
+	case strings.HasPrefix(msisdn, "0"):
+		return errors.New("phone number must be in international format, without a leading 0")
+	case len(msisdn) < minMSISDNLength || len(msisdn) > maxMSISDNLength:
+		return fmt.Errorf("%w: the number must be between %d and %d digits", ErrMSISDNLength, minMSISDNLength, maxMSISDNLength)
+	case strings.HasPrefix(msisdn, "254") && !kenyanMSISDNPattern.MatchString(msisdn):
+		return errKenyanMSISDN
+	}
+	return nil
+}
+
+// FormatE164 formats a normalized phone number in E.164 form, with a leading "+", e.g. for
+// storage. msisdn is checked with ValidateMSISDN first, so numbers that have not been through
+// NormalizeMSISDN, such as 0712345678, are rejected rather than formatted.
+//
+// Parameters:
+//   - msisdn: The phone number in international format, e.g. "254712345678"
+//
+// Returns:
+//   - string: The number in E.164 form, e.g. "+254712345678"
+//   - error: The ValidateMSISDN error if msisdn is not a normalized phone number
+func FormatE164(msisdn string) (string, error) {
+	if err := ValidateMSISDN(msisdn); err != nil {
+		return "", err
+	}
+	return "+" + msisdn, nil
+}
+
+// FormatDisplay formats a normalized phone number for people to read: the 3-digit country code
+// and the 9-digit subscriber number in groups of three, e.g. "+254 712 345 678", as for the
+// East African M-Pesa markets. Numbers of any other length, whose country code cannot be told
+// apart, are shown in E.164 form without grouping. msisdn is checked with ValidateMSISDN first, as for FormatE164.
+//
+// Parameters:
+//   - msisdn: The phone number in international format, e.g. "254712345678"
+//
+// Returns:
+//   - string: The number for display, e.g. "+254 712 345 678"
+//   - error: The ValidateMSISDN error if msisdn is not a normalized phone number
+//
+// Example:
+//
+//	display, err := Abstracts.FormatDisplay("254111844429") // "+254 111 844 429"
+func FormatDisplay(msisdn string) (string, error) {
+	e164, err := FormatE164(msisdn)
+	if err != nil {
+		return "", err
+	}
+	if len(msisdn) != len(DefaultCountryCode)+subscriberLength {
+		return e164, nil
+	}
+	return fmt.Sprintf("+%s %s %s %s", msisdn[:3], msisdn[3:6], msisdn[6:9], msisdn[9:]), nil
+}
+
+// MaskMSISDN hides the middle of a phone number so that it can be logged or printed on a
+// receipt: the country code and the first digit of the subscriber number stay, the next 4
+// digits become "*" and the last 4 stay, e.g. "2547****4312". Input that is not a normalized
+// phone number, including one that is already masked, is returned as it is. The SDK masks phone
+// numbers in its own log output the same way.
+//
+// Parameters:
+//   - msisdn: The phone number in international format, e.g. "254712344312"
+//
+// Returns:
+//   - string: The masked number, or msisdn unchanged if it is not a valid phone number
+//
+// Example:
+//
+//	log.Printf("paying %s", Abstracts.MaskMSISDN("254712344312")) // paying 2547****4312
+func MaskMSISDN(msisdn string) string {
+	if ValidateMSISDN(msisdn) != nil {
+		return msisdn
+	}
+	// The last 8 digits are the subscriber number without its first digit.
+	end := len(msisdn) - 4
+	return msisdn[:end-4] + "****" + msisdn[end:]
+}
+
+// maskMSISDNsIn masks, with MaskMSISDN, the phone numbers in countryCode that appear in text,
+// such as a response body about to be logged.
+func maskMSISDNsIn(text, countryCode string) string {
+	return digitRuns.ReplaceAllStringFunc(text, func(digits string) string {
+		if len(digits) != len(countryCode)+subscriberLength || !strings.HasPrefix(digits, countryCode) {
+			return digits
+		}
+		return MaskMSISDN(digits)
+	})
+}
+
+// ValidateMSISDNStrict checks msisdn like ValidateMSISDN and also requires it to start with
+// one of prefixes, or DefaultMSISDNPrefixes when none are given, so that numbers a system
+// cannot pay are rejected before a request is made. The prefix error shows the number masked
+// with MaskMSISDN. It has no state and is safe for concurrent use.
+//
+// Parameters:
+//   - msisdn: The phone number in international format, e.g. "254111844429"
+//   - prefixes: The allowed prefixes in international format, e.g. "2547" or "255"
+//
+// Returns:
+//   - error: An error describing why the number is invalid, wrapping ErrUnsupportedPrefix when
+//     it has none of the prefixes, or nil
+//
+// Example:
+//
+//	err := Abstracts.ValidateMSISDNStrict("255712345678")
+//	errors.Is(err, Abstracts.ErrUnsupportedPrefix) // true: not a Safaricom number
+//	err = Abstracts.ValidateMSISDNStrict("255712345678", "2547", "2541", "255")
+//	// err == nil
+func ValidateMSISDNStrict(msisdn string, prefixes ...string) error {
+	if err := ValidateMSISDN(msisdn); err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		prefixes = DefaultMSISDNPrefixes
+	}
+	for _, prefix := range prefixes {
+		if prefix = strings.TrimPrefix(prefix, "+"); prefix != "" && strings.HasPrefix(msisdn, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s must start with one of %s", ErrUnsupportedPrefix, MaskMSISDN(msisdn), strings.Join(prefixes, ", "))
+}
@@ -0,0 +1,63 @@
+package Abstracts
+
+// PhoneValidator checks a phone number before the SDK accepts it, e.g. against a denylist or
+// rules about which customers may be paid. Services normalize the number first, so Validate
+// receives it in international format (254712345678); its error is returned from the Push,
+// Send or Simulate call the number was set for. Set one for every service with
+// MpesaConfig.SetPhoneValidator or for a single service with its SetPhoneValidator method.
+type PhoneValidator interface {
+	// Validate returns an error if msisdn must not be used.
+	Validate(msisdn string) error
+}
+
+// PhoneValidatorFunc adapts a function to the PhoneValidator interface.
+//
+// Example:
+//
+//	noLandlines := Abstracts.PhoneValidatorFunc(func(msisdn string) error {
+//	    if strings.HasPrefix(msisdn, "2542") {
+//	        return errors.New("landlines cannot receive payments")
+//	    }
+//	    return nil
+//	})
+type PhoneValidatorFunc func(msisdn string) error
+
+// Validate calls f(msisdn).
+func (f PhoneValidatorFunc) Validate(msisdn string) error {
+	return f(msisdn)
+}
+
+// phoneValidatorChain runs validators in order and stops at the first error.
+type phoneValidatorChain []PhoneValidator
+
+// Validate returns the error of the first validator that rejects msisdn.
+func (c phoneValidatorChain) Validate(msisdn string) error {
+	for _, validator := range c {
+		if err := validator.Validate(msisdn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChainPhoneValidators combines validators into one that runs them in order and returns the
+// first error, so a number must pass all of them. Nil validators are skipped.
+//
+// Parameters:
+//   - validators: The validators to run, in order
+//
+// Returns:
+//   - PhoneValidator: A validator that runs all of them
+//
+// Example:
+//
+//	cfg.SetPhoneValidator(Abstracts.ChainPhoneValidators(denylist, noLandlines))
+func ChainPhoneValidators(validators ...PhoneValidator) PhoneValidator {
+	chain := make(phoneValidatorChain, 0, len(validators))
+	for _, validator := range validators {
+		if validator != nil {
+			chain = append(chain, validator)
+		}
+	}
+	return chain
+}
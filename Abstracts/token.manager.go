@@ -21,11 +21,13 @@ type TokenManager struct {
 	ConsumerKey    string // Consumer key for OAuth authentication
 	ConsumerSecret string // Consumer secret for OAuth authentication
 	BaseURL        string // Base URL for M-Pesa API
-	TokenURL       string // OAuth token endpoint path
+	TokenURL       string // OAuth token endpoint path, overriding the config's Endpoints.OAuth when set
 	CachePath      string // File path for token cache storage
 
-	mu       sync.Mutex  // protects memCache + file operations
-	memCache *tokenCache // in-memory cache to avoid frequent FS reads / duplicate requests
+	mu       sync.Mutex   // protects memCache + file operations
+	memCache *tokenCache  // in-memory cache to avoid frequent FS reads / duplicate requests
+	clock    Clock        // source of the current time for expiry checks
+	config   *MpesaConfig // configuration the OAuth endpoint path is read from
 }
 
 // tokenCache represents the structure for storing cached tokens.
@@ -42,7 +44,9 @@ type tokenResponse struct {
 }
 
 // NewTokenManager creates a new token manager instance from the provided configuration.
-// The token manager handles OAuth authentication and token caching automatically.
+// The token manager handles OAuth authentication and token caching automatically. The OAuth
+// endpoint path is read from cfg.Endpoints.OAuth whenever a token is requested, so overriding
+// it after construction takes effect.
 //
 // Parameters:
 //   - cfg: M-Pesa configuration containing consumer credentials and environment settings
@@ -59,8 +63,9 @@ func NewTokenManager(cfg *MpesaConfig) *TokenManager {
 		ConsumerKey:    cfg.GetConsumerKey(),
 		ConsumerSecret: cfg.GetConsumerSecret(),
 		BaseURL:        cfg.GetBaseURL(),
-		TokenURL:       "/oauth/v1/generate?grant_type=client_credentials",
 		CachePath:      filepath.Join(os.TempDir(), "mpesa_api_token_cache.json"),
+		clock:          SystemClock{},
+		config:         cfg,
 	}
 	manager.CachePath = filepath.Join(os.TempDir(), manager.EncryptedCacheFileName())
 	return manager
@@ -116,6 +121,29 @@ func (tm *TokenManager) SetCachePath(path string) *TokenManager {
 	return tm
 }
 
+// SetClock sets the clock token expiry is computed and checked with, e.g. a FakeClock in
+// tests. A nil clock restores the system clock.
+//
+// Parameters:
+//   - clock: The clock to use
+//
+// Returns:
+//   - *TokenManager: The token manager instance for method chaining
+//
+// Example:
+//
+//	clock := Abstracts.NewFakeClock(time.Now())
+//	tokenManager.SetClock(clock)
+func (tm *TokenManager) SetClock(clock Clock) *TokenManager {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	tm.clock = clock
+	return tm
+}
+
 // GetToken returns a valid OAuth access token. Uses in-memory cache first and
 // falls back to file cache. Serializes requests to avoid duplicate token calls.
 func (tm *TokenManager) GetToken() (string, error) {
@@ -123,7 +151,7 @@ func (tm *TokenManager) GetToken() (string, error) {
 	defer tm.mu.Unlock()
 
 	// check in-memory cache
-	if tm.memCache != nil && time.Now().Unix() < tm.memCache.ExpiresAt {
+	if tm.memCache != nil && tm.clock.Now().Unix() < tm.memCache.ExpiresAt {
 		return tm.memCache.Token, nil
 	}
 
@@ -153,7 +181,7 @@ func (tm *TokenManager) getCachedToken() string {
 		return ""
 	}
 
-	if time.Now().Unix() > cached.ExpiresAt {
+	if tm.clock.Now().Unix() > cached.ExpiresAt {
 		return ""
 	}
 
@@ -162,9 +190,18 @@ func (tm *TokenManager) getCachedToken() string {
 	return cached.Token
 }
 
+// tokenPath returns the OAuth endpoint path: TokenURL when set, otherwise the current
+// Endpoints.OAuth of the config.
+func (tm *TokenManager) tokenPath() string {
+	if tm.TokenURL != "" || tm.config == nil {
+		return tm.TokenURL
+	}
+	return tm.config.Endpoints.OAuth
+}
+
 // requestNewToken requests a new token and caches it
 func (tm *TokenManager) requestNewToken() (string, error) {
-	url := tm.BaseURL + tm.TokenURL
+	url := tm.BaseURL + tm.tokenPath()
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return "", err
@@ -220,13 +257,14 @@ func (tm *TokenManager) requestNewToken() (string, error) {
 		}
 	}
 
-	expiresAt := time.Now().Unix() + effectiveExpires
+	now := tm.clock.Now().Unix()
+	expiresAt := now + effectiveExpires
 
 	// update memory cache first then persist
 	tm.memCache = &tokenCache{
 		Token:     tokenResp.AccessToken,
 		ExpiresAt: expiresAt,
-		CreatedAt: time.Now().Unix(),
+		CreatedAt: now,
 	}
 	tm.cacheToken(tokenResp.AccessToken, expiresAt)
 
@@ -238,7 +276,7 @@ func (tm *TokenManager) cacheToken(token string, expiresAt int64) {
 	cache := tokenCache{
 		Token:     token,
 		ExpiresAt: expiresAt,
-		CreatedAt: time.Now().Unix(),
+		CreatedAt: tm.clock.Now().Unix(),
 	}
 
 	data, _ := json.Marshal(cache)
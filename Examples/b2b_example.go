@@ -11,29 +11,21 @@ import (
 	"log"
 	"net/http"
 
-	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Mpesa"
 	"github.com/venomous-maker/go-mpesa/Services"
 )
 
 func main() {
-	// Create config (replace placeholders with real credentials for real runs)
-	cfg, err := Abstracts.NewMpesaConfig(
-		"YOUR_CONSUMER_KEY",
-		"YOUR_CONSUMER_SECRET",
-		Abstracts.Sandbox,
-		ptr("174379"), // business shortcode (sandbox example)
-		nil,           // passKey not required for B2B
-		nil,           // security credential will be set per-initiator below
-		nil, nil,
-	)
+	// Create the facade (replace placeholders with real credentials for real runs).
+	// Every service obtained from it shares one API client, so the access token is cached once.
+	mpesa, err := Mpesa.New("YOUR_CONSUMER_KEY", "YOUR_CONSUMER_SECRET", "sandbox")
 	if err != nil {
-		log.Fatalf("failed to create config: %v", err)
+		log.Fatalf("failed to create mpesa client: %v", err)
 	}
-
-	client := Abstracts.NewApiClient(cfg)
+	mpesa.SetBusinessCode("174379") // business shortcode (sandbox example)
 
 	// Create BusinessBuyGoods service
-	buy := Services.NewBusinessBuyGoodsService(cfg, client)
+	buy := mpesa.B2BuyGoods()
 
 	// Set initiator and security credential (encrypts internally)
 	buy.SetInitiator("API_Username")
@@ -60,12 +52,14 @@ func main() {
 		fmt.Printf("B2B request response: %+v\n", resp)
 	*/
 
-	// Start webhook server for callbacks (ParseB2BCallback will be used inside handler)
-	svc := buy // reuse service instance for parsing callbacks
-	http.HandleFunc("/webhook/b2b", Services.B2PayBillCallbackHandler(&Services.BusinessToPayBillService{Config: cfg, Client: client}))
+	// Start webhook server for callbacks (ParseB2BCallback is used inside the handler)
+	http.Handle("/webhook/b2b", Services.B2BCallbackHandler(
+		func(res *Services.B2BCallbackResult) {
+			fmt.Printf("B2B result %s: %s\n", res.ResultCode, res.ResultDesc)
+		},
+		Services.WithErrorHandler(func(err error, r *http.Request) { log.Printf("b2b callback: %v", err) }),
+	))
 
 	fmt.Println("Example server listening on :8080 (webhook route /webhook/b2b)")
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
-
-func ptr(s string) *string { return &s }
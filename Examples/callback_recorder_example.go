@@ -0,0 +1,59 @@
+//go:build ignore
+// +build ignore
+
+// Example: how to receive sandbox callbacks with mpesatest.CallbackRecorder in an integration test.
+// This file is marked with a build ignore tag so it's not compiled with `go test` or `go build`.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Mpesa"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func main() {
+	// Start the recorder; it listens on a local port that a tunnel of your own (e.g. ngrok or
+	// cloudflared) forwards to, so that the sandbox can reach it.
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	fmt.Printf("Forward your tunnel to %s\n", recorder.BaseURL())
+	recorder.SetPublicURL(os.Getenv("TUNNEL_URL")) // e.g. https://abc123.ngrok.app
+
+	mpesa, err := Mpesa.New(os.Getenv("MPESA_CONSUMER_KEY"), os.Getenv("MPESA_CONSUMER_SECRET"), "sandbox")
+	if err != nil {
+		log.Fatalf("failed to create mpesa client: %v", err)
+	}
+	mpesa.SetBusinessCode("174379")
+	mpesa.SetPassKey(os.Getenv("MPESA_PASSKEY"))
+
+	stk := mpesa.STK()
+	stk.SetAmount("1")
+	if _, err := stk.SetPhoneNumber(os.Getenv("MPESA_TEST_PHONE")); err != nil {
+		log.Fatalf("invalid phone number: %v", err)
+	}
+	stk.SetCallbackUrl(recorder.URL(Services.CallbackSTK))
+	stk.SetAccountReference("INV-1001")
+	stk.SetTransactionDesc("Integration test")
+
+	if _, err := stk.Push(); err != nil {
+		log.Fatalf("STK push failed: %v", err)
+	}
+	checkoutID, _ := stk.GetCheckoutRequestID()
+
+	// Wait for the customer to answer the prompt on the test phone.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	cb, err := recorder.WaitForStkCallback(ctx, checkoutID)
+	if err != nil {
+		log.Fatalf("no callback: %v", err)
+	}
+	fmt.Printf("STK callback: success=%v code=%s receipt=%s\n", cb.Success, cb.ResultCode, cb.MpesaReceiptNumber)
+	fmt.Printf("Callbacks received: %d\n", recorder.Count(Services.CallbackSTK))
+}
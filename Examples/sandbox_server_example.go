@@ -0,0 +1,67 @@
+//go:build ignore
+// +build ignore
+
+// Example: how to run an STK Push flow against mpesatest.NewServer, a local simulator of the
+// Daraja API, with callbacks received by an mpesatest.CallbackRecorder.
+// This file is marked with a build ignore tag so it's not compiled with `go test` or `go build`.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Mpesa"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func main() {
+	// The simulator answers like the sandbox and delivers callbacks after a second.
+	server := mpesatest.NewServer(mpesatest.WithCallbackDelay(time.Second))
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	// Any credentials are accepted by the simulator.
+	mpesa, err := Mpesa.New("test_key", "test_secret", "sandbox")
+	if err != nil {
+		log.Fatalf("failed to create mpesa client: %v", err)
+	}
+	mpesa.SetBaseURL(server.BaseURL())
+	mpesa.SetBusinessCode("174379")
+	mpesa.SetPassKey("bfb279f9aa9bdbcf158e97dd71a467cd2e0c893059b10f78e6b72ada1ed2c919")
+
+	// Script the second push to be cancelled by the customer.
+	server.Script(mpesatest.OpStkPush, mpesatest.Scenario{}, mpesatest.UserCancelled())
+
+	for i := 0; i < 2; i++ {
+		stk := mpesa.STK()
+		stk.SetTransactionType("CustomerPayBillOnline")
+		stk.SetAmount("100")
+		if _, err := stk.SetPhoneNumber("254712345678"); err != nil {
+			log.Fatalf("invalid phone number: %v", err)
+		}
+		stk.SetCallbackUrl(recorder.URL(Services.CallbackSTK))
+		if _, err := stk.Push(); err != nil {
+			log.Fatalf("STK push failed: %v", err)
+		}
+		checkoutID, _ := stk.GetCheckoutRequestID()
+
+		status, _ := stk.Query()
+		fmt.Printf("Query before the callback: %v\n", status["errorMessage"])
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		cb, err := recorder.WaitForStkCallback(ctx, checkoutID)
+		cancel()
+		if err != nil {
+			log.Fatalf("no callback: %v", err)
+		}
+		fmt.Printf("STK callback: success=%v code=%s receipt=%s\n", cb.Success, cb.ResultCode, cb.MpesaReceiptNumber)
+
+		status, _ = stk.Query()
+		fmt.Printf("Query after the callback: ResultCode=%v\n", status["ResultCode"])
+	}
+}
@@ -112,6 +112,40 @@ func (m *Mpesa) SetPassKey(passkey string) {
 	m.Config.SetPassKey(passkey)
 }
 
+// SetDefaultCountryCode sets the country code the services use to convert local phone
+// numbers to international format; it defaults to 254, for Kenya.
+//
+// Parameters:
+//   - cc: The country calling code, 1 to 3 digits, e.g. "255" for Tanzania
+//
+// Returns:
+//   - error: An error if cc is not a country code
+//
+// Example:
+//
+//	if err := mpesa.SetDefaultCountryCode("255"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (m *Mpesa) SetDefaultCountryCode(cc string) error {
+	return m.Config.SetDefaultCountryCode(cc)
+}
+
+// SetBaseURL points the client at another base URL, such as a local simulator started with
+// mpesatest.NewServer. Both the API requests and the token requests use the new URL.
+//
+// Parameters:
+//   - url: The base URL, without an endpoint path
+//
+// Example:
+//
+//	server := mpesatest.NewServer()
+//	defer server.Close()
+//	mpesa.SetBaseURL(server.BaseURL())
+func (m *Mpesa) SetBaseURL(url string) {
+	m.Config.SetBaseURL(url)
+	m.Client.TokenManager.BaseURL = m.Config.GetBaseURL()
+}
+
 // STK creates and returns a new STK Push service instance.
 // STK Push allows initiating M-Pesa payments directly from a customer's phone.
 //
@@ -175,7 +209,7 @@ func (m *Mpesa) C2B() *Services.CustomerToBusinessService {
 //
 //	balanceService := mpesa.AccountBalance()
 //	balance, err := balanceService.
-//	    SetCommandID("AccountBalance").
+//	    SetInitiator("testapi").
 //	    SetRemarks("Balance inquiry").
 //	    Query()
 func (m *Mpesa) AccountBalance() *Services.AccountBalanceService {
@@ -271,7 +305,108 @@ func (m *Mpesa) B2PayBill() *Services.BusinessToPayBillService {
 //	    SetQueueTimeoutURL("https://example.com/timeout").
 //	    SetResultURL("https://example.com/result").
 //	    Send()
-
 func (m *Mpesa) B2BuyGoods() *Services.BusinessBuyGoodsService {
 	return Services.NewBusinessBuyGoodsService(m.Config, m.Client)
 }
+
+// B2CTopUp creates and returns a new B2C account top up service instance.
+// This service moves funds from a business's working or MMF account to its B2C utility account.
+//
+// Returns:
+//   - *Services.B2CAccountTopUpService: A configured service for B2C account top ups
+//
+// Example:
+//
+//	topUpService := mpesa.B2CTopUp()
+//	_ = topUpService.SetSecurityCredential("initiator_password")
+//	response, err := topUpService.
+//	    SetInitiator("testapi").
+//	    SetAmount(50000).
+//	    SetPartyA("600979").
+//	    SetPartyB("600000").
+//	    SetAccountReference("Payroll float").
+//	    SetRemarks("B2C top up").
+//	    Send()
+func (m *Mpesa) B2CTopUp() *Services.B2CAccountTopUpService {
+	return Services.NewB2CAccountTopUpService(m.Config, m.Client)
+}
+
+// TaxRemittance creates and returns a new tax remittance service instance.
+// This service remits taxes to the Kenya Revenue Authority (KRA) against a payment registration number.
+//
+// Returns:
+//   - *Services.TaxRemittanceService: A configured service for KRA tax remittances
+//
+// Example:
+//
+//	taxService := mpesa.TaxRemittance()
+//	_ = taxService.SetSecurityCredential("initiator_password")
+//	response, err := taxService.
+//	    SetInitiator("testapi").
+//	    SetAmount(2390).
+//	    SetPartyA("600979").
+//	    SetPRN("353353").
+//	    SetRemarks("VAT for March").
+//	    Send()
+func (m *Mpesa) TaxRemittance() *Services.TaxRemittanceService {
+	return Services.NewTaxRemittanceService(m.Config, m.Client)
+}
+
+// DynamicQR creates and returns a new dynamic QR service instance.
+// This service generates M-Pesa QR codes that customers scan to pay a till, paybill, agent or phone number.
+//
+// Returns:
+//   - *Services.DynamicQRService: A configured service for QR code generation
+//
+// Example:
+//
+//	qrService := mpesa.DynamicQR()
+//	response, err := qrService.
+//	    SetMerchantName("TEST SUPERMARKET").
+//	    SetRefNo("Invoice Test").
+//	    SetAmount(1).
+//	    SetTrxCode(Services.TrxCodeBuyGoods).
+//	    SetCPI("373132").
+//	    SetSize("300").
+//	    Generate()
+func (m *Mpesa) DynamicQR() *Services.DynamicQRService {
+	return Services.NewDynamicQRService(m.Config, m.Client)
+}
+
+// PullTransactions creates and returns a new pull transactions service instance.
+// This service registers a shortcode for the Pull Transactions API, used for reconciliation.
+//
+// Returns:
+//   - *Services.PullTransactionsService: A configured service for pull transaction registration
+//
+// Example:
+//
+//	pullService := mpesa.PullTransactions()
+//	response, err := pullService.
+//	    SetShortCode("600000").
+//	    SetNominatedNumber("0722000000").
+//	    SetCallbackURL("https://yourdomain.com/mpesa/pull").
+//	    RegisterTyped()
+func (m *Mpesa) PullTransactions() *Services.PullTransactionsService {
+	return Services.NewPullTransactionsService(m.Config, m.Client)
+}
+
+// BillManager creates and returns a new Bill Manager service instance.
+// This service onboards a paybill to Bill Manager, which sends e-invoices to customers.
+//
+// Returns:
+//   - *Services.BillManagerService: A configured service for Bill Manager operations
+//
+// Example:
+//
+//	billManager := mpesa.BillManager()
+//	response, err := billManager.Onboard(Services.OnboardParams{
+//	    ShortCode:       "718003",
+//	    Email:           "billing@example.com",
+//	    OfficialContact: "0710000000",
+//	    SendReminders:   true,
+//	    CallbackURL:     "https://yourdomain.com/mpesa/billmanager",
+//	})
+func (m *Mpesa) BillManager() *Services.BillManagerService {
+	return Services.NewBillManagerService(m.Config, m.Client)
+}
@@ -0,0 +1,34 @@
+package Services
+
+import abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+
+// AccountBalanceResponse is the synchronous acknowledgement returned by the account balance API.
+// The balances themselves are delivered asynchronously to the ResultURL.
+type AccountBalanceResponse struct {
+	ConversationID           string // Unique ID assigned by M-Pesa to the request
+	OriginatorConversationID string `mpesa:"OriginatorConversationID|OriginatorCoversationID"` // Unique ID of the request as seen by the originator
+	ResponseCode             string // "0" when the request was accepted for processing
+	ResponseDescription      string // Human readable description of the response code
+}
+
+// NewAccountBalanceResponse decodes an account balance acknowledgement from a raw API response.
+// Values are accepted as strings or numbers, and key casing and the spelling of
+// OriginatorConversationID are tolerated.
+//
+// Parameters:
+//   - resp: The raw response map returned by the API client
+//
+// Returns:
+//   - *AccountBalanceResponse: The decoded acknowledgement (never nil)
+func NewAccountBalanceResponse(resp map[string]any) *AccountBalanceResponse {
+	r, _ := abstracts.DecodeInto[AccountBalanceResponse](resp)
+	return &r
+}
+
+// Accepted reports whether M-Pesa accepted the balance inquiry for processing.
+//
+// Returns:
+//   - bool: true when ResponseCode is "0"
+func (r *AccountBalanceResponse) Accepted() bool {
+	return r != nil && r.ResponseCode == "0"
+}
@@ -0,0 +1,190 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Account names used in the AccountBalance result parameter.
+const (
+	WorkingAccountName = "Working Account"
+	UtilityAccountName = "Utility Account"
+	FloatAccountName   = "Float Account"
+)
+
+// AccountBalanceResult represents a parsed account balance result callback delivered to the ResultURL.
+type AccountBalanceResult struct {
+	ResultCode               string `json:"ResultCode"`
+	ResultDesc               string `json:"ResultDesc"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ConversationID           string `json:"ConversationID"`
+	TransactionID            string `json:"TransactionID"`
+
+	Accounts        []AccountBalanceEntry `json:"AccountBalance"`  // One entry per account, in the order sent by M-Pesa
+	BOCompletedTime time.Time             `json:"BOCompletedTime"` // Completion time (EAT)
+
+	ResultParameters map[string]string `json:"ResultParameters"` // All result parameters as sent by M-Pesa
+	ReferenceData    map[string]string `json:"ReferenceData"`    // Reference items as sent by M-Pesa
+	Raw              map[string]any    `json:"-"`                // The original payload
+	Success          bool              `json:"Success"`          // true when ResultCode is 0
+}
+
+// ParseAccountBalanceResult parses an account balance result callback payload into a typed
+// AccountBalanceResult. It builds on ParseResultEnvelope for the common Result envelope, then
+// splits the "&" separated AccountBalance parameter into one AccountBalanceEntry per account.
+// Malformed account segments are skipped; the original string remains available in
+// ResultParameters["AccountBalance"]. json.Unmarshal into an AccountBalanceResult decodes the
+// raw body the same way.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *AccountBalanceResult: The parsed result
+//   - error: An error if the payload has no Result node
+//
+// Example:
+//
+//	result, err := Services.ParseAccountBalanceResult(payload)
+//	if err == nil && result.Success {
+//	    if utility, ok := result.UtilityAccount(); ok {
+//	        fmt.Printf("Utility balance: %.2f %s", utility.Available, utility.Currency)
+//	    }
+//	}
+func ParseAccountBalanceResult(payload map[string]any) (*AccountBalanceResult, error) {
+	envelope, err := ParseResultEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountBalanceResult(envelope), nil
+}
+
+// MarshalJSON encodes the result in the schema SchemaAccountBalanceResult.
+func (r AccountBalanceResult) MarshalJSON() ([]byte, error) {
+	type plain AccountBalanceResult
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaAccountBalanceResult, plain(r), marshaledRaw(r.Raw)})
+}
+
+// UnmarshalJSON decodes the raw body of an account balance result callback, as
+// ParseAccountBalanceResult does, or the JSON written by MarshalJSON.
+func (r *AccountBalanceResult) UnmarshalJSON(data []byte) error {
+	type plain AccountBalanceResult
+	if ok, err := decodeNormalized(data, SchemaAccountBalanceResult, (*plain)(r), &r.Raw); ok {
+		r.BOCompletedTime = inEAT(r.BOCompletedTime)
+		return err
+	}
+
+	var envelope ResultEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	*r = *newAccountBalanceResult(&envelope)
+	return nil
+}
+
+// newAccountBalanceResult converts the balance specific result parameters of envelope.
+func newAccountBalanceResult(envelope *ResultEnvelope) *AccountBalanceResult {
+	params := envelope.ResultParameters
+	res := &AccountBalanceResult{
+		ResultCode:               envelope.ResultCode,
+		ResultDesc:               envelope.ResultDesc,
+		OriginatorConversationID: envelope.OriginatorConversationID,
+		ConversationID:           envelope.ConversationID,
+		TransactionID:            envelope.TransactionID,
+		ResultParameters:         params,
+		ReferenceData:            envelope.ReferenceData,
+		Raw:                      envelope.Raw,
+		Success:                  envelope.Success,
+	}
+
+	res.Accounts = parseAccountBalances(params["AccountBalance"])
+	res.BOCompletedTime = parseCompactTime(params["BOCompletedTime"])
+
+	return res
+}
+
+// AccountBalanceResultHandler returns an http.HandlerFunc for the account balance ResultURL and
+// QueueTimeOutURL. Result callbacks are parsed with ParseAccountBalanceResult and passed to
+// onResult; queue timeout notifications (see IsQueueTimeout) are passed to onTimeout as-is.
+// Either callback may be nil. Every accepted callback is acknowledged with the JSON body M-Pesa
+// expects. Only POST requests are accepted, and bodies are limited in size and read time; see
+// WithMaxBodyBytes, WithReadTimeout and WithErrorHandler.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - onTimeout: Called with the raw body of each queue timeout notification
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/balance/result", Services.AccountBalanceResultHandler(
+//	    func(res *Services.AccountBalanceResult) {
+//	        if utility, ok := res.UtilityAccount(); ok {
+//	            metrics.SetBalance(utility.Available)
+//	        }
+//	    },
+//	    nil,
+//	    Services.WithErrorHandler(func(err error, r *http.Request) { log.Print(err) }),
+//	))
+func AccountBalanceResultHandler(onResult func(*AccountBalanceResult), onTimeout func(raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return AccountBalanceResultHandlerContext(ignoreContext(onResult), ignoreContext(onTimeout), opts...)
+}
+
+// AccountBalanceResultHandlerContext is AccountBalanceResultHandler with functions that also receive the context of the
+// request, bounded by the handler timeout; see WithTimeout.
+func AccountBalanceResultHandlerContext(onResult func(context.Context, *AccountBalanceResult), onTimeout func(ctx context.Context, raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return resultHandler(newHandlerOptions(opts), CallbackBalanceResult, CallbackBalanceTimeout, ParseAccountBalanceResult, onResult, onTimeout)
+}
+
+// Account returns the entry for the named account, e.g. "Utility Account".
+// Names are compared case-insensitively.
+func (r *AccountBalanceResult) Account(name string) (*AccountBalanceEntry, bool) {
+	for i := range r.Accounts {
+		if strings.EqualFold(r.Accounts[i].Account, name) {
+			return &r.Accounts[i], true
+		}
+	}
+	return nil, false
+}
+
+// WorkingAccount returns the entry for the working account.
+func (r *AccountBalanceResult) WorkingAccount() (*AccountBalanceEntry, bool) {
+	return r.Account(WorkingAccountName)
+}
+
+// UtilityAccount returns the entry for the utility account.
+func (r *AccountBalanceResult) UtilityAccount() (*AccountBalanceEntry, bool) {
+	return r.Account(UtilityAccountName)
+}
+
+// FloatAccount returns the entry for the float account.
+func (r *AccountBalanceResult) FloatAccount() (*AccountBalanceEntry, bool) {
+	return r.Account(FloatAccountName)
+}
+
+// parseAccountBalances parses an "&" separated list of account balances. Segments that do not
+// have all six "|" separated fields or have no account name are skipped.
+func parseAccountBalances(v string) []AccountBalanceEntry {
+	var entries []AccountBalanceEntry
+	for _, segment := range strings.Split(v, "&") {
+		if strings.Count(segment, "|") != 5 {
+			continue
+		}
+		entry := parseAccountBalanceEntry(segment)
+		if entry.Account == "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
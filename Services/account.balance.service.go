@@ -1,18 +1,28 @@
 package Services
 
 import (
+	"context"
 	"errors"
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
+// DefaultAccountBalanceRemarks is sent as Remarks when SetRemarks has not been called,
+// since Daraja rejects empty remarks on some shortcodes.
+const DefaultAccountBalanceRemarks = "Balance inquiry"
+
 // AccountBalanceService handles account balance inquiry operations.
 // This service allows businesses to check their M-Pesa account balance programmatically.
 type AccountBalanceService struct {
-	Config         *abstracts.MpesaConfig   // M-Pesa configuration containing credentials and settings
-	Client         abstracts.MpesaInterface // HTTP client interface for making API requests
-	initiator      string                   // Username of the M-Pesa API operator
-	identifierType string                   // Type of organization receiving the transaction
-	remarks        string                   // Comments that are sent along with the transaction
+	Config          *abstracts.MpesaConfig   // M-Pesa configuration containing credentials and settings
+	Client          abstracts.MpesaInterface // HTTP client interface for making API requests
+	initiator       string                   // Username of the M-Pesa API operator
+	identifierType  string                   // Type of organization receiving the transaction (defaults to "4", shortcode)
+	remarks         string                   // Comments that are sent along with the transaction (defaults to DefaultAccountBalanceRemarks)
+	queueTimeoutURL string                   // Queue timeout URL for this service; defaults to the config value
+	resultURL       string                   // Result URL for this service; defaults to the config value
+	response        map[string]any           // Response from the last API call
+	typedResponse   *AccountBalanceResponse  // Decoded response from the last API call
+	waiter          resultWaiter             // SendAndWait settings
 }
 
 // NewAccountBalanceService creates a new account balance service instance with the provided configuration and client.
@@ -32,8 +42,9 @@ type AccountBalanceService struct {
 //	balanceService := NewAccountBalanceService(cfg, client)
 func NewAccountBalanceService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *AccountBalanceService {
 	return &AccountBalanceService{
-		Config: cfg,
-		Client: client,
+		Config:         cfg,
+		Client:         client,
+		identifierType: string(IdentifierTypeShortcode),
 	}
 }
 
@@ -55,7 +66,8 @@ func (s *AccountBalanceService) SetInitiator(initiator string) *AccountBalanceSe
 }
 
 // SetIdentifierType sets the type of organization receiving the transaction.
-// This identifies the type of shortcode being queried for balance.
+// This identifies the type of shortcode being queried for balance and defaults to "4"
+// (IdentifierTypeShortcode), so it rarely needs to be called.
 //
 // Parameters:
 //   - identifierType: The identifier type (e.g., "4" for organization shortcode)
@@ -63,14 +75,14 @@ func (s *AccountBalanceService) SetInitiator(initiator string) *AccountBalanceSe
 // Returns:
 //   - *AccountBalanceService: Returns self for method chaining
 //
-// Common Identifier Types:
-//   - "1": MSISDN
-//   - "2": Till Number
-//   - "4": Organization shortcode
+// Common Identifier Types (see the IdentifierType constants):
+//   - "1": MSISDN (IdentifierTypeMSISDN)
+//   - "2": Till Number (IdentifierTypeTillNumber)
+//   - "4": Organization shortcode (IdentifierTypeShortcode)
 //
 // Example:
 //
-//	balanceService.SetIdentifierType("4")  // For organization shortcode
+//	balanceService.SetIdentifierType(string(Services.IdentifierTypeShortcode))
 func (s *AccountBalanceService) SetIdentifierType(identifierType string) *AccountBalanceService {
 	s.identifierType = identifierType
 	return s
@@ -78,6 +90,7 @@ func (s *AccountBalanceService) SetIdentifierType(identifierType string) *Accoun
 
 // SetRemarks sets additional information to be associated with the balance inquiry.
 // This helps identify the purpose of the balance check in transaction records.
+// When unset or empty, DefaultAccountBalanceRemarks is sent.
 //
 // Parameters:
 //   - remarks: A descriptive string for the balance inquiry
@@ -94,8 +107,35 @@ func (s *AccountBalanceService) SetRemarks(remarks string) *AccountBalanceServic
 	return s
 }
 
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa calls when the request times out in the queue
+//
+// Returns:
+//   - *AccountBalanceService: Returns self for method chaining
+func (s *AccountBalanceService) SetQueueTimeoutURL(url string) *AccountBalanceService {
+	s.queueTimeoutURL = url
+	return s
+}
+
+// SetResultURL sets the result URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa posts the balance result to
+//
+// Returns:
+//   - *AccountBalanceService: Returns self for method chaining
+func (s *AccountBalanceService) SetResultURL(url string) *AccountBalanceService {
+	s.resultURL = url
+	return s
+}
+
 // Query initiates an account balance inquiry to check the current account balance.
 // This method validates all required parameters and sends the balance request to M-Pesa.
+// Required fields: Initiator, SecurityCredential, PartyA (Business Short Code), IdentifierType,
+// QueueTimeOutURL, ResultURL. The balance itself is delivered asynchronously to the ResultURL;
+// see ParseAccountBalanceResult.
 //
 // Returns:
 //   - map[string]any: The response from the M-Pesa API containing balance information
@@ -105,7 +145,6 @@ func (s *AccountBalanceService) SetRemarks(remarks string) *AccountBalanceServic
 //
 //	response, err := balanceService.
 //	    SetInitiator("testapi").
-//	    SetIdentifierType("4").
 //	    SetRemarks("Balance inquiry").
 //	    Query()
 //	if err != nil {
@@ -121,17 +160,131 @@ func (s *AccountBalanceService) Query() (map[string]any, error) {
 	if s.identifierType == "" {
 		return nil, errors.New("identifier type is required")
 	}
+	if err := validateIdentifierType("identifier type", s.identifierType); err != nil {
+		return nil, err
+	}
+	partyA := s.Config.GetBusinessCode()
+	if partyA == "" {
+		return nil, errors.New("business shortcode (PartyA) is required; call SetBusinessCode on mpesa config")
+	}
+	queueTimeoutURL := chooseString(s.queueTimeoutURL, s.Config.GetQueueTimeoutURL())
+	if queueTimeoutURL == "" {
+		return nil, errors.New("queue timeout URL is required; call SetQueueTimeoutURL on the service or config")
+	}
+	resultURL := chooseString(s.resultURL, s.Config.GetResultURL())
+	if resultURL == "" {
+		return nil, errors.New("result URL is required; call SetResultURL on the service or config")
+	}
+	securityCredential := s.Config.GetSecurityCredential()
+	if securityCredential == "" {
+		return nil, errors.New("security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config")
+	}
 
 	data := map[string]any{
 		"Initiator":          s.initiator,
-		"SecurityCredential": s.Config.GetSecurityCredential(),
+		"SecurityCredential": securityCredential,
 		"CommandID":          "AccountBalance",
-		"PartyA":             s.Config.GetBusinessCode(),
+		"PartyA":             partyA,
 		"IdentifierType":     s.identifierType,
-		"Remarks":            s.remarks,
-		"QueueTimeOutURL":    s.Config.GetQueueTimeoutURL(),
-		"ResultURL":          s.Config.GetResultURL(),
+		"Remarks":            chooseString(s.remarks, DefaultAccountBalanceRemarks),
+		"QueueTimeOutURL":    queueTimeoutURL,
+		"ResultURL":          resultURL,
+	}
+
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.AccountBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = response
+	s.typedResponse = NewAccountBalanceResponse(response)
+	return response, nil
+}
+
+// QueryTyped sends the balance inquiry like Query and returns the decoded acknowledgement.
+//
+// Returns:
+//   - *AccountBalanceResponse: The decoded acknowledgement
+//   - error: An error if validation fails or the API request encounters issues
+//
+// Example:
+//
+//	resp, err := balanceService.SetInitiator("testapi").QueryTyped()
+//	if err == nil && resp.Accepted() {
+//	    saveBalanceRequest(resp.ConversationID)
+//	}
+func (s *AccountBalanceService) QueryTyped() (*AccountBalanceResponse, error) {
+	if _, err := s.Query(); err != nil {
+		return nil, err
 	}
+	return s.typedResponse, nil
+}
+
+// GetResponse returns the response from the last balance inquiry.
+//
+// Returns:
+//   - map[string]any: The response data, or nil if no inquiry has been made
+func (s *AccountBalanceService) GetResponse() map[string]any {
+	return s.response
+}
+
+// GetTypedResponse returns the decoded acknowledgement from the last balance inquiry.
+//
+// Returns:
+//   - *AccountBalanceResponse: The decoded response, or nil if no inquiry has been made
+func (s *AccountBalanceService) GetTypedResponse() *AccountBalanceResponse {
+	return s.typedResponse
+}
+
+// GetConversationID returns the ConversationID from the last balance inquiry.
+// Persist it to match the asynchronous result delivered to the ResultURL.
+//
+// Returns:
+//   - string: The ConversationID
+//   - error: An error if no response is available or it has no ConversationID
+func (s *AccountBalanceService) GetConversationID() (string, error) {
+	if s.typedResponse == nil {
+		return "", errors.New("no account balance response available")
+	}
+	if s.typedResponse.ConversationID == "" {
+		return "", errors.New("ConversationID not found in response")
+	}
+	return s.typedResponse.ConversationID, nil
+}
 
-	return s.Client.ExecuteRequest(data, "/mpesa/accountbalance/v1/query")
+// SetResultCorrelator sets the correlator SendAndWait waits for the result with. The handler
+// of the ResultURL and QueueTimeOutURL must resolve results in it; see WithResultCorrelator.
+//
+// Parameters:
+//   - correlator: The correlator shared with the result handler
+//
+// Returns:
+//   - *AccountBalanceService: Returns self for method chaining
+func (s *AccountBalanceService) SetResultCorrelator(correlator ResultCorrelator) *AccountBalanceService {
+	s.waiter.correlator = correlator
+	return s
+}
+
+// SendAndWait sends the request with Query and waits until its result arrives on the ResultURL
+// or QueueTimeOutURL, or ctx is done.
+//
+// Parameters:
+//   - ctx: Bounds the wait for the result
+//
+// Returns:
+//   - *CorrelatedResult: The result, with Result set to a *AccountBalanceResult, or the queue timeout
+//   - error: An error if the request fails or no result arrives in time (ErrResultTimeout)
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+//	defer cancel()
+//	res, err := balanceService.SetResultCorrelator(correlator).SendAndWait(ctx)
+//	if err == nil && res.Success {
+//	    for _, account := range res.Result.(*Services.AccountBalanceResult).Accounts {
+//	        fmt.Printf("%+v\n", account)
+//	    }
+//	}
+func (s *AccountBalanceService) SendAndWait(ctx context.Context) (*CorrelatedResult, error) {
+	return s.waiter.sendAndWait(ctx, "", s.Query)
 }
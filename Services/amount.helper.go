@@ -0,0 +1,124 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// amountForm is the JSON representation of the amount field of a request.
+type amountForm int
+
+const (
+	// wholeNumber sends whole shillings as a JSON number, e.g. 1500. Daraja documents its
+	// payment amounts as numeric and does not take cents, so amounts with cents are refused
+	// (or rounded under a RoundingPolicy) before anything is sent.
+	wholeNumber amountForm = iota
+
+	// decimalString sends a decimal string without trailing zeros, e.g. "150.75".
+	decimalString
+)
+
+// The form of the amount field of each API. Every request builds its amount with amountField
+// and one of these, so that a change to what an API accepts is made here.
+const (
+	// STK Push, C2B simulations, B2C and Dynamic QR are documented as numeric.
+	stkAmountForm       = wholeNumber
+	c2bAmountForm       = wholeNumber
+	b2cAmountForm       = wholeNumber
+	dynamicQRAmountForm = wholeNumber
+	// B2B covers PayBill, BuyGoods, B2C account top up and tax remittance.
+	b2bAmountForm = wholeNumber
+	// A reversal must name the amount of the original transaction, which may carry cents.
+	reversalAmountForm = decimalString
+	// Bill Manager invoices and payments are itemised in shillings and cents.
+	billManagerAmountForm = decimalString
+)
+
+// amountField returns amount in the given form, or an error when it cannot be sent in that
+// form unchanged.
+func amountField(amount abstracts.Amount, form amountForm) (any, error) {
+	switch form {
+	case wholeNumber:
+		shillings, err := amount.WholeShillings()
+		if err != nil {
+			return nil, err
+		}
+		return int(shillings), nil
+	case decimalString:
+		return amount.String(), nil
+	default:
+		return nil, fmt.Errorf("unknown amount form %d", form)
+	}
+}
+
+// RoundingPolicy controls how fractional amounts are converted to the whole shillings
+// that Daraja expects in the Amount field.
+type RoundingPolicy int
+
+const (
+	// RoundStrict rejects amounts with a fractional part. It is the default policy, so
+	// amounts are never silently changed.
+	RoundStrict RoundingPolicy = iota
+
+	// RoundHalfUp rounds to the nearest shilling, with halves rounded up (150.50 becomes 151).
+	RoundHalfUp
+
+	// RoundDown drops the fractional part (150.99 becomes 150).
+	RoundDown
+)
+
+// parseAmountString parses a decimal amount such as "1,500.50" or "1 500" into a float64.
+func parseAmountString(v string) (float64, error) {
+	trimmed := strings.NewReplacer(",", "", " ", "").Replace(strings.TrimSpace(v))
+	if trimmed == "" {
+		return 0, errors.New("amount is empty")
+	}
+	f, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("amount %q is not a valid number", v)
+	}
+	return f, nil
+}
+
+// roundAmount converts an amount to whole shillings according to the rounding policy.
+func roundAmount(amount float64, policy RoundingPolicy) (int, error) {
+	switch policy {
+	case RoundHalfUp:
+		return int(math.Floor(amount + 0.5)), nil
+	case RoundDown:
+		return int(math.Floor(amount)), nil
+	case RoundStrict:
+		if amount != math.Trunc(amount) {
+			return 0, fmt.Errorf("amount %v has a fractional part; set a rounding policy to round it", amount)
+		}
+		return int(amount), nil
+	default:
+		return 0, fmt.Errorf("unknown rounding policy %d", policy)
+	}
+}
+
+// canonicalAmount formats a positive amount without rounding, e.g. 150.75 as "150.75" and 200 as "200".
+func canonicalAmount(amount float64) (string, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0 {
+		return "", errors.New("amount must be greater than 0")
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64), nil
+}
+
+// billManagerAmount checks that a Bill Manager amount is positive and returns it in the form of
+// the Bill Manager amount fields, a decimal string keeping cents.
+func billManagerAmount(amount float64) (any, error) {
+	if _, err := canonicalAmount(amount); err != nil {
+		return nil, err
+	}
+	value, err := abstracts.FromFloat(amount)
+	if err != nil {
+		return nil, err
+	}
+	return amountField(value, billManagerAmountForm)
+}
@@ -0,0 +1,70 @@
+package Services
+
+import (
+	"context"
+	"net/http"
+)
+
+// B2BCallbackHandler returns an http.HandlerFunc for the ResultURL of the B2B services
+// (BusinessPayBill, BusinessBuyGoods, TaxRemittance and B2C account top ups).
+// Callbacks are parsed with ParseB2BCallback and passed to onResult, which may be nil.
+// It is B2BResultHandler without a timeout callback: queue timeout notifications are
+// acknowledged and otherwise ignored.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/b2b/result", Services.B2BCallbackHandler(
+//	    func(res *Services.B2BCallbackResult) {
+//	        markPayment(res.OriginatorConversationID, res.Success, res.TransactionID)
+//	    },
+//	    Services.WithErrorHandler(func(err error, r *http.Request) { log.Print(err) }),
+//	))
+func B2BCallbackHandler(onResult func(*B2BCallbackResult), opts ...HandlerOption) http.HandlerFunc {
+	return B2BResultHandler(onResult, nil, opts...)
+}
+
+// B2BResultHandler returns an http.HandlerFunc for the ResultURL and QueueTimeOutURL of the B2B
+// services. Result callbacks are parsed with ParseB2BCallback and passed to onResult; queue
+// timeout notifications (see IsQueueTimeout) are passed to onTimeout as-is. Either callback may
+// be nil. Every callback is acknowledged with {"ResultCode":0,"ResultDesc":"Accepted"},
+// including payloads that cannot be parsed: those are passed to the error handler, if any, so
+// that M-Pesa does not keep redelivering them (see WithAckPolicy). Only POST requests are
+// accepted, and bodies are limited in size and read time; see WithMaxBodyBytes, WithReadTimeout
+// and WithErrorHandler.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - onTimeout: Called with the raw body of each queue timeout notification
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/b2b", Services.B2BResultHandler(
+//	    func(res *Services.B2BCallbackResult) {
+//	        markPayment(res.OriginatorConversationID, res.Success, res.TransactionID)
+//	    },
+//	    func(raw map[string]any) {
+//	        if timeout, err := Services.ParseQueueTimeout(raw); err == nil {
+//	            retryPayment(timeout.OriginatorConversationID)
+//	        }
+//	    },
+//	))
+func B2BResultHandler(onResult func(*B2BCallbackResult), onTimeout func(raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return B2BResultHandlerContext(ignoreContext(onResult), ignoreContext(onTimeout), opts...)
+}
+
+// B2BResultHandlerContext is B2BResultHandler with functions that also receive the context of the
+// request, bounded by the handler timeout; see WithTimeout.
+func B2BResultHandlerContext(onResult func(context.Context, *B2BCallbackResult), onTimeout func(ctx context.Context, raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return resultHandler(newHandlerOptions(opts), CallbackB2BResult, CallbackB2BTimeout, ParseB2BCallback, onResult, onTimeout)
+}
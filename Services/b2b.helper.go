@@ -1,34 +1,46 @@
 package Services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
-	"strconv"
 
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
+// MaxB2BAccountReferenceLength is the maximum length of the AccountReference sent with B2B payments.
+const MaxB2BAccountReferenceLength = 13
+
 // B2BRequest represents a generic B2B payment request.
 type B2BRequest struct {
-	Initiator              string
-	SecurityCredential     string
-	CommandID              string
-	SenderIdentifierType   string
-	RecieverIdentifierType string
-	Amount                 float64
-	PartyA                 string
-	PartyB                 string
-	AccountReference       string
-	Requester              string
-	Remarks                string
-	QueueTimeOutURL        string
-	ResultURL              string
-	Occasion               string
+	OriginatorConversationID string // Unique ID of the request, echoed in the result; optional
+	Initiator                string
+	SecurityCredential       string
+	CommandID                string
+	SenderIdentifierType     string
+	RecieverIdentifierType   string
+	Amount                   float64
+	Rounding                 RoundingPolicy // How a fractional Amount is handled; fractions are rejected by default
+	PartyA                   string
+	PartyB                   string
+	AccountReference         string
+	Requester                string
+	Remarks                  string
+	QueueTimeOutURL          string
+	ResultURL                string
+	Occasion                 string
 }
 
 // ExecuteB2BRequest builds the request payload from B2BRequest and executes the API call.
 func ExecuteB2BRequest(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface, req B2BRequest) (map[string]any, error) {
+	if cfg == nil {
+		return nil, errors.New("cfg and client are required")
+	}
+	return executeB2BRequest(cfg, client, req, cfg.Endpoints.B2BPayment)
+}
+
+// executeB2BRequest validates req, builds the payload and posts it to endpoint.
+func executeB2BRequest(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface, req B2BRequest, endpoint string) (map[string]any, error) {
 	if cfg == nil || client == nil {
 		return nil, errors.New("cfg and client are required")
 	}
@@ -42,12 +54,32 @@ func ExecuteB2BRequest(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterfa
 	if req.Amount <= 0 {
 		return nil, errors.New("amount must be greater than 0")
 	}
+	amount, err := roundAmount(req.Amount, req.Rounding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
 	if req.PartyA == "" && cfg.GetBusinessCode() == "" {
 		return nil, errors.New("partyA (business shortcode) is required")
 	}
 	if req.PartyB == "" {
 		return nil, errors.New("partyB (destination shortcode) is required")
 	}
+	queueTimeoutURL := chooseString(req.QueueTimeOutURL, cfg.GetQueueTimeoutURL())
+	if err := validateCallbackURL("queue timeout URL", queueTimeoutURL, "set B2BRequest.QueueTimeOutURL or call SetQueueTimeoutURL on config", cfg.GetEnvironment()); err != nil {
+		return nil, err
+	}
+	resultURL := chooseString(req.ResultURL, cfg.GetResultURL())
+	if err := validateCallbackURL("result URL", resultURL, "set B2BRequest.ResultURL or call SetResultURL on config", cfg.GetEnvironment()); err != nil {
+		return nil, err
+	}
+
+	amountValue, err := amountField(abstracts.FromKES(amount), b2bAmountForm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
 
 	payload := map[string]any{
 		"Initiator":              req.Initiator,
@@ -55,18 +87,28 @@ func ExecuteB2BRequest(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterfa
 		"CommandID":              req.CommandID,
 		"SenderIdentifierType":   req.SenderIdentifierType,
 		"RecieverIdentifierType": req.RecieverIdentifierType,
-		"Amount":                 math.Round(req.Amount),
+		"Amount":                 amountValue, // whole shillings as a number, see b2bAmountForm
 		"PartyA":                 choosePartyA(req.PartyA, cfg),
 		"PartyB":                 req.PartyB,
-		"AccountReference":       req.AccountReference,
-		"Requester":              req.Requester,
 		"Remarks":                req.Remarks,
-		"QueueTimeOutURL":        chooseString(req.QueueTimeOutURL, cfg.GetQueueTimeoutURL()),
-		"ResultURL":              chooseString(req.ResultURL, cfg.GetResultURL()),
-		"Occasion":               req.Occasion,
+		"QueueTimeOutURL":        queueTimeoutURL,
+		"ResultURL":              resultURL,
+	}
+	// Optional fields are left out when unset; Daraja rejects some of them when sent empty.
+	if req.OriginatorConversationID != "" {
+		payload["OriginatorConversationID"] = req.OriginatorConversationID
+	}
+	if req.AccountReference != "" {
+		payload["AccountReference"] = req.AccountReference
+	}
+	if req.Requester != "" {
+		payload["Requester"] = req.Requester
+	}
+	if req.Occasion != "" {
+		payload["Occasion"] = req.Occasion
 	}
 
-	return client.ExecuteRequest(payload, "/mpesa/b2b/v1/paymentrequest")
+	return client.ExecuteRequest(payload, endpoint)
 }
 
 func choosePartyA(partyA string, cfg *abstracts.MpesaConfig) string {
@@ -76,6 +118,15 @@ func choosePartyA(partyA string, cfg *abstracts.MpesaConfig) string {
 	return cfg.GetBusinessCode()
 }
 
+// truncateRunes shortens s to at most max characters.
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
 func chooseString(incoming, fallback string) string {
 	if incoming != "" {
 		return incoming
@@ -85,197 +136,63 @@ func chooseString(incoming, fallback string) string {
 
 // B2BCallbackResult represents a parsed B2B callback payload shared across B2B services.
 type B2BCallbackResult struct {
-	ResultCode               string
-	ResultDesc               string
-	TransactionID            string
-	OriginatorConversationID string
-	ConversationID           string
-	ResultParameters         map[string]string
-	ReferenceData            map[string]string
-	Raw                      map[string]any
-	Success                  bool
+	ResultCode               string            `json:"ResultCode"`
+	ResultDesc               string            `json:"ResultDesc"`
+	TransactionID            string            `json:"TransactionID"`
+	OriginatorConversationID string            `json:"OriginatorConversationID"`
+	ConversationID           string            `json:"ConversationID"`
+	ResultParameters         map[string]string `json:"ResultParameters"`
+	ReferenceData            map[string]string `json:"ReferenceData"`
+	Raw                      map[string]any    `json:"-"`
+	Success                  bool              `json:"Success"`
 }
 
 // ParseB2BCallback parses a generic B2B callback payload (PayBill, BuyGoods, etc.).
 func ParseB2BCallback(payload map[string]any) (*B2BCallbackResult, error) {
-	res := &B2BCallbackResult{
-		ResultParameters: make(map[string]string),
-		ReferenceData:    make(map[string]string),
-		Raw:              payload,
-	}
-
-	var resultNode any
-	if v, ok := payload["Result"]; ok {
-		resultNode = v
-	} else if v, ok := payload["result"]; ok {
-		resultNode = v
-	} else {
-		return nil, errors.New("payload missing Result node")
-	}
-
-	resultMap, ok := resultNode.(map[string]any)
-	if !ok {
-		if m2, ok2 := resultNode.(map[string]interface{}); ok2 {
-			resultMap = make(map[string]any)
-			for k, v := range m2 {
-				resultMap[k] = v
-			}
-		} else {
-			return nil, errors.New("Result node is not an object")
-		}
+	env, err := ParseResultEnvelope(payload)
+	if err != nil {
+		return nil, err
 	}
-
-	res.ResultCode = toString(resultMap["ResultCode"]) // may be string or number
-	res.ResultDesc = toString(resultMap["ResultDesc"])
-	res.TransactionID = toString(resultMap["TransactionID"])
-	res.OriginatorConversationID = toString(resultMap["OriginatorConversationID"])
-	res.ConversationID = toString(resultMap["ConversationID"])
-
-	// Parse ResultParameters -> ResultParameter (array or single)
-	if rpRaw, ok := resultMap["ResultParameters"]; ok {
-		switch rp := rpRaw.(type) {
-		case map[string]any:
-			parseResultParameterArray(rp["ResultParameter"], res.ResultParameters)
-		default:
-			parseResultParameterArray(rpRaw, res.ResultParameters)
-		}
-	}
-
-	// Parse ReferenceData -> ReferenceItem
-	if rdRaw, ok := resultMap["ReferenceData"]; ok {
-		switch rd := rdRaw.(type) {
-		case map[string]any:
-			parseReferenceItem(rd["ReferenceItem"], res.ReferenceData)
-		default:
-			parseReferenceItem(rdRaw, res.ReferenceData)
-		}
-	}
-
-	if i, err := strconv.Atoi(res.ResultCode); err == nil {
-		res.Success = i == 0
-	} else {
-		res.Success = res.ResultCode == "0"
-	}
-
-	return res, nil
+	return newB2BCallbackResult(env), nil
 }
 
-// helpers (copied from previous implementation)
-func toString(v any) string {
-	switch t := v.(type) {
-	case string:
-		return t
-	case float64:
-		if t == math.Trunc(t) {
-			return strconv.FormatInt(int64(t), 10)
-		}
-		return strconv.FormatFloat(t, 'f', -1, 64)
-	case int:
-		return strconv.Itoa(t)
-	case int64:
-		return strconv.FormatInt(t, 10)
-	case nil:
-		return ""
-	default:
-		return fmt.Sprint(t)
-	}
+// MarshalJSON encodes the result in the schema SchemaB2BResult.
+func (r B2BCallbackResult) MarshalJSON() ([]byte, error) {
+	type plain B2BCallbackResult
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaB2BResult, plain(r), marshaledRaw(r.Raw)})
 }
 
-func parseResultParameterArray(input any, out map[string]string) {
-	if input == nil {
-		return
-	}
-
-	if arr, ok := input.([]any); ok {
-		for _, item := range arr {
-			if m, ok := item.(map[string]any); ok {
-				k := toString(m["Key"])
-				v := toString(m["Value"])
-				if k != "" {
-					out[k] = v
-				}
-			}
-		}
-		return
+// UnmarshalJSON decodes the raw body of a B2B callback, as ParseB2BCallback does, or the JSON
+// written by MarshalJSON.
+func (r *B2BCallbackResult) UnmarshalJSON(data []byte) error {
+	type plain B2BCallbackResult
+	if ok, err := decodeNormalized(data, SchemaB2BResult, (*plain)(r), &r.Raw); ok {
+		return err
 	}
 
-	if arr2, ok := input.([]interface{}); ok {
-		for _, item := range arr2 {
-			if m, ok := item.(map[string]interface{}); ok {
-				k := toString(m["Key"])
-				v := toString(m["Value"])
-				if k != "" {
-					out[k] = v
-				}
-			}
-		}
-		return
-	}
-
-	if m, ok := input.(map[string]any); ok {
-		k := toString(m["Key"])
-		v := toString(m["Value"])
-		if k != "" {
-			out[k] = v
-		}
-		return
-	}
-	if m2, ok := input.(map[string]interface{}); ok {
-		k := toString(m2["Key"])
-		v := toString(m2["Value"])
-		if k != "" {
-			out[k] = v
-		}
-		return
+	var env ResultEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
 	}
+	*r = *newB2BCallbackResult(&env)
+	return nil
 }
 
-func parseReferenceItem(input any, out map[string]string) {
-	if input == nil {
-		return
-	}
-
-	if arr, ok := input.([]any); ok {
-		for _, item := range arr {
-			if m, ok := item.(map[string]any); ok {
-				k := toString(m["Key"])
-				v := toString(m["Value"])
-				if k != "" {
-					out[k] = v
-				}
-			}
-		}
-		return
-	}
-
-	if arr2, ok := input.([]interface{}); ok {
-		for _, item := range arr2 {
-			if m, ok := item.(map[string]interface{}); ok {
-				k := toString(m["Key"])
-				v := toString(m["Value"])
-				if k != "" {
-					out[k] = v
-				}
-			}
-		}
-		return
-	}
-
-	if m, ok := input.(map[string]any); ok {
-		k := toString(m["Key"])
-		v := toString(m["Value"])
-		if k != "" {
-			out[k] = v
-		}
-		return
-	}
-
-	if m2, ok := input.(map[string]interface{}); ok {
-		k := toString(m2["Key"])
-		v := toString(m2["Value"])
-		if k != "" {
-			out[k] = v
-		}
-		return
+// newB2BCallbackResult copies envelope into a B2BCallbackResult.
+func newB2BCallbackResult(env *ResultEnvelope) *B2BCallbackResult {
+	return &B2BCallbackResult{
+		ResultCode:               env.ResultCode,
+		ResultDesc:               env.ResultDesc,
+		TransactionID:            env.TransactionID,
+		OriginatorConversationID: env.OriginatorConversationID,
+		ConversationID:           env.ConversationID,
+		ResultParameters:         env.ResultParameters,
+		ReferenceData:            env.ReferenceData,
+		Raw:                      env.Raw,
+		Success:                  env.Success,
 	}
 }
@@ -0,0 +1,28 @@
+package Services
+
+import abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+
+// B2BSendResponse is the synchronous acknowledgement returned by the B2B payment APIs.
+// The actual payment outcome is delivered asynchronously to the ResultURL.
+type B2BSendResponse struct {
+	ConversationID           string // Unique ID assigned by M-Pesa to the request
+	OriginatorConversationID string `mpesa:"OriginatorConversationID|OriginatorCoversationID"` // Unique ID of the request as seen by the originator
+	ResponseCode             string // "0" when the request was accepted for processing
+	ResponseDescription      string // Human readable description of the response code
+	RequestID                string `mpesa:"requestId"`    // Set when Daraja answers with an error envelope
+	ErrorCode                string `mpesa:"errorCode"`    // Set when Daraja answers with an error envelope
+	ErrorMessage             string `mpesa:"errorMessage"` // Set when Daraja answers with an error envelope
+}
+
+// NewB2BSendResponse decodes a B2B acknowledgement from a raw API response.
+// Values are accepted as strings or numbers, and key casing and the spelling of
+// OriginatorConversationID are tolerated. Error envelopes decode into the Error fields.
+func NewB2BSendResponse(resp map[string]any) *B2BSendResponse {
+	r, _ := abstracts.DecodeInto[B2BSendResponse](resp)
+	return &r
+}
+
+// Accepted reports whether M-Pesa accepted the request for processing (ResponseCode "0").
+func (r *B2BSendResponse) Accepted() bool {
+	return r != nil && r.ResponseCode == "0"
+}
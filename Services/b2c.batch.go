@@ -0,0 +1,158 @@
+package Services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// B2CRecipient describes a single payment in a batch disbursement.
+type B2CRecipient struct {
+	Phone    string  // Customer phone number, normalized like SetPhoneNumber
+	Amount   float64 // Amount in KES, converted using the service's rounding policy
+	Remarks  string  // Optional; defaults to the service's remarks
+	Occasion string  // Optional; defaults to the service's occasion
+
+	IdempotencyKey string // Optional; used with the service's idempotency store
+}
+
+// B2CBatchOptions controls how a batch disbursement is executed.
+type B2CBatchOptions struct {
+	Concurrency int           // Maximum number of requests in flight (defaults to 1)
+	Delay       time.Duration // Optional pause each worker takes after a request, to stay within TPS limits
+
+	// OnProgress, if set, is called after each recipient is processed with the number of
+	// completed recipients, the batch size and the recipient's result. Calls are serialized.
+	OnProgress func(done, total int, result B2CBatchResult)
+}
+
+// B2CBatchResult is the outcome of a single payment in a batch disbursement.
+type B2CBatchResult struct {
+	Index                    int            // Position of the recipient in the input slice
+	Recipient                B2CRecipient   // The recipient as given
+	OriginatorConversationID string         // ID sent with the request, for reconciliation with the result callback
+	Response                 *B2CResponse   // Decoded acknowledgement, nil if the request failed
+	Raw                      map[string]any // Raw response, nil if the request failed
+	Err                      error          // Validation, transport or cancellation error
+}
+
+// SendBatch sends a B2C payment to each recipient using a bounded worker pool.
+// The service acts as a template: initiator, command ID, URLs and rounding policy are taken
+// from it, while phone number, amount, remarks and occasion come from each recipient.
+// Every payment gets its own OriginatorConversationID. A failed payment does not abort the
+// batch; when ctx is cancelled, recipients that were not yet sent fail with ctx.Err().
+//
+// Parameters:
+//   - ctx: Context used to cancel the remainder of the batch
+//   - recipients: The payments to make
+//   - opts: Concurrency, delay and progress reporting options
+//
+// Returns:
+//   - []B2CBatchResult: One result per recipient, in input order
+//
+// Example:
+//
+//	results := b2cService.
+//	    SetInitiatorName("testapi").
+//	    SetCommandID(Services.CommandSalaryPayment).
+//	    SendBatch(ctx, recipients, Services.B2CBatchOptions{Concurrency: 5, Delay: 200 * time.Millisecond})
+//	for _, r := range results {
+//	    if r.Err != nil {
+//	        log.Printf("payment to %s failed: %v", r.Recipient.Phone, r.Err)
+//	    }
+//	}
+func (s *BusinessToCustomerService) SendBatch(ctx context.Context, recipients []B2CRecipient, opts B2CBatchOptions) []B2CBatchResult {
+	results := make([]B2CBatchResult, len(recipients))
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	var (
+		wg         sync.WaitGroup
+		progressMu sync.Mutex
+		done       int
+	)
+	report := func(result B2CBatchResult) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		done++
+		if opts.OnProgress != nil {
+			opts.OnProgress(done, len(recipients), result)
+		}
+	}
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					results[i] = B2CBatchResult{Index: i, Recipient: recipients[i], Err: err}
+				} else {
+					results[i] = s.sendToRecipient(i, recipients[i])
+				}
+				report(results[i])
+
+				if opts.Delay > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(opts.Delay):
+					}
+				}
+			}
+		}()
+	}
+
+	for i := range recipients {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(recipients); j++ {
+				results[j] = B2CBatchResult{Index: j, Recipient: recipients[j], Err: ctx.Err()}
+				report(results[j])
+			}
+			close(jobs)
+			wg.Wait()
+			return results
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// sendToRecipient sends a single batch payment from a copy of the template service.
+func (s *BusinessToCustomerService) sendToRecipient(index int, recipient B2CRecipient) B2CBatchResult {
+	result := B2CBatchResult{Index: index, Recipient: recipient}
+
+	payment := *s
+	payment.originatorID = ""
+	payment.response = nil
+	payment.typedResponse = nil
+	payment.idempotencyKey = recipient.IdempotencyKey
+	payment.SetPhoneNumber(recipient.Phone).SetAmountFloat(recipient.Amount)
+	if recipient.Remarks != "" {
+		payment.SetRemarks(recipient.Remarks)
+	}
+	if recipient.Occasion != "" {
+		payment.SetOccasion(recipient.Occasion)
+	}
+
+	id, err := payment.ensureOriginatorConversationID()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.OriginatorConversationID = id
+
+	raw, err := payment.Send()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Raw = raw
+	result.Response = payment.GetTypedResponse()
+	return result
+}
@@ -0,0 +1,174 @@
+package Services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// RowError reports why a row of an imported file was rejected.
+type RowError struct {
+	Line int   // 1-based line number of the row in the file
+	Err  error // Reason the row was rejected
+}
+
+// Error implements the error interface.
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// csvColumns maps the recognised header names to the column they describe.
+var csvColumns = map[string]string{
+	"phone":        "phone",
+	"phonenumber":  "phone",
+	"phone number": "phone",
+	"msisdn":       "phone",
+	"amount":       "amount",
+	"reference":    "reference",
+	"ref":          "reference",
+}
+
+// LoadB2CRecipientsCSV reads B2C recipients from CSV with phone, amount and reference columns,
+// ready to pass to SendBatch. A header row is optional; when present its column names decide
+// the column order, otherwise columns are read in that order. Phone numbers are normalized like
+// SetPhoneNumber, with the country code of cfg, and amounts may be KES formatted, e.g. "1,500.00". The reference is optional;
+// it becomes the recipient's Occasion and IdempotencyKey, so it must be unique within the file.
+//
+// Invalid rows do not fail the whole file: each one is reported as a RowError with its line
+// number and left out of the recipients. A read error stops the import and is reported last.
+//
+// Parameters:
+//   - cfg: M-Pesa configuration whose default country code local phone numbers are converted with
+//   - r: The CSV data
+//
+// Returns:
+//   - []B2CRecipient: The valid recipients, in file order
+//   - []RowError: One error per rejected row
+//
+// Example:
+//
+//	recipients, rowErrs := Services.LoadB2CRecipientsCSV(cfg, file)
+//	for _, e := range rowErrs {
+//	    log.Printf("skipped %v", e)
+//	}
+//	results := b2cService.SendBatch(ctx, recipients, Services.B2CBatchOptions{Concurrency: 5})
+func LoadB2CRecipientsCSV(cfg *abstracts.MpesaConfig, r io.Reader) ([]B2CRecipient, []RowError) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var (
+		recipients []B2CRecipient
+		rowErrs    []RowError
+		columns    = map[string]int{"phone": 0, "amount": 1, "reference": 2}
+		seen       = map[string]int{}
+		first      = true
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrs = append(rowErrs, RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			rowErrs = append(rowErrs, RowError{Line: line, Err: err})
+			break
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			if header, ok := csvHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+
+		recipient, err := csvRecipient(record, columns, cfg.GetDefaultCountryCode())
+		if err == nil && recipient.IdempotencyKey != "" {
+			if previous, dup := seen[recipient.IdempotencyKey]; dup {
+				err = fmt.Errorf("duplicate reference %q (first used on line %d)", recipient.IdempotencyKey, previous)
+			} else {
+				seen[recipient.IdempotencyKey] = line
+			}
+		}
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Line: line, Err: err})
+			continue
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, rowErrs
+}
+
+// csvHeader reports whether record is a header row and, if so, returns the index of each column.
+func csvHeader(record []string) (map[string]int, bool) {
+	columns := map[string]int{}
+	for i, name := range record {
+		if column, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[column] = i
+		}
+	}
+	if len(columns) == 0 {
+		return nil, false
+	}
+	if _, ok := columns["reference"]; !ok {
+		columns["reference"] = -1
+	}
+	return columns, true
+}
+
+// csvRecipient validates a data row and converts it to a B2CRecipient, normalizing the phone
+// number with countryCode.
+func csvRecipient(record []string, columns map[string]int, countryCode string) (B2CRecipient, error) {
+	field := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	phone := field("phone")
+	if phone == "" {
+		return B2CRecipient{}, errors.New("phone number is required")
+	}
+	normalized, err := normalizePhone(phone, countryCode)
+	if err != nil {
+		return B2CRecipient{}, fmt.Errorf("invalid phone number: %w", err)
+	}
+
+	amount, err := parseAmountString(field("amount"))
+	if err != nil {
+		return B2CRecipient{}, fmt.Errorf("invalid amount: %w", err)
+	}
+	if amount <= 0 {
+		return B2CRecipient{}, fmt.Errorf("invalid amount %q: must be greater than 0", field("amount"))
+	}
+
+	reference := field("reference")
+	if err := validateLength("reference", reference, 0, MaxB2COccasionLength); err != nil {
+		return B2CRecipient{}, err
+	}
+
+	return B2CRecipient{
+		Phone:          normalized,
+		Amount:         amount,
+		Occasion:       reference,
+		IdempotencyKey: reference,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package Services
+
+import (
+	"context"
+	"net/http"
+)
+
+// B2CResultHandler returns an http.HandlerFunc for the B2C ResultURL and QueueTimeOutURL.
+// Result callbacks are parsed with ParseB2CResult and passed to onResult; queue timeout
+// notifications (see IsQueueTimeout) are passed to onTimeout as-is. Either callback may be nil.
+// Every accepted callback is acknowledged with the JSON body M-Pesa expects. Only POST requests
+// are accepted, and bodies are limited in size and read time; see WithMaxBodyBytes,
+// WithReadTimeout and WithErrorHandler.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - onTimeout: Called with the raw body of each queue timeout notification
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/b2c/result", Services.B2CResultHandler(
+//	    func(res *Services.B2CResult) {
+//	        markPayout(res.OriginatorConversationID, res.Success, res.TransactionReceipt)
+//	    },
+//	    func(raw map[string]any) {
+//	        log.Printf("B2C request timed out in queue: %v", raw)
+//	    },
+//	))
+func B2CResultHandler(onResult func(*B2CResult), onTimeout func(raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return B2CResultHandlerContext(ignoreContext(onResult), ignoreContext(onTimeout), opts...)
+}
+
+// B2CResultHandlerContext is B2CResultHandler with functions that also receive the context of the
+// request, bounded by the handler timeout; see WithTimeout.
+func B2CResultHandlerContext(onResult func(context.Context, *B2CResult), onTimeout func(ctx context.Context, raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return resultHandler(newHandlerOptions(opts), CallbackB2CResult, CallbackB2CTimeout, ParseB2CResult, onResult, onTimeout)
+}
@@ -0,0 +1,33 @@
+package Services
+
+import abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+
+// B2CResponse is the synchronous acknowledgement returned by the B2C payment request API.
+// The actual payment outcome is delivered asynchronously to the ResultURL.
+type B2CResponse struct {
+	ConversationID           string // Unique ID assigned by M-Pesa to the request
+	OriginatorConversationID string `mpesa:"OriginatorConversationID|OriginatorCoversationID"` // Unique ID of the request as seen by the originator
+	ResponseCode             string // "0" when the request was accepted for processing
+	ResponseDescription      string // Human readable description of the response code
+}
+
+// NewB2CResponse decodes a B2C acknowledgement from a raw API response.
+// Values are accepted as strings or numbers, and key casing is ignored.
+//
+// Parameters:
+//   - resp: The raw response map returned by the API client
+//
+// Returns:
+//   - *B2CResponse: The decoded acknowledgement (never nil)
+func NewB2CResponse(resp map[string]any) *B2CResponse {
+	r, _ := abstracts.DecodeInto[B2CResponse](resp)
+	return &r
+}
+
+// Accepted reports whether M-Pesa accepted the request for processing.
+//
+// Returns:
+//   - bool: true when ResponseCode is "0"
+func (r *B2CResponse) Accepted() bool {
+	return r != nil && r.ResponseCode == "0"
+}
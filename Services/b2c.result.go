@@ -0,0 +1,135 @@
+package Services
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mpesaLocation is the timezone M-Pesa uses for the timestamps in its callbacks (EAT, UTC+3).
+var mpesaLocation = time.FixedZone("EAT", 3*60*60)
+
+// B2CResult represents a parsed B2C result callback delivered to the ResultURL.
+type B2CResult struct {
+	ResultCode               string `json:"ResultCode"`
+	ResultDesc               string `json:"ResultDesc"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ConversationID           string `json:"ConversationID"`
+	TransactionID            string `json:"TransactionID"`
+
+	TransactionAmount                   float64   `json:"TransactionAmount"`                   // Amount sent to the customer
+	TransactionReceipt                  string    `json:"TransactionReceipt"`                  // M-Pesa receipt number
+	ReceiverPartyPublicName             string    `json:"ReceiverPartyPublicName"`             // e.g. "254708374149 - John Doe"
+	TransactionCompletedDateTime        time.Time `json:"TransactionCompletedDateTime"`        // Completion time (EAT)
+	B2CUtilityAccountAvailableFunds     float64   `json:"B2CUtilityAccountAvailableFunds"`     // Utility account balance after the payment
+	B2CWorkingAccountAvailableFunds     float64   `json:"B2CWorkingAccountAvailableFunds"`     // Working account balance after the payment
+	B2CChargesPaidAccountAvailableFunds float64   `json:"B2CChargesPaidAccountAvailableFunds"` // Charges paid account balance after the payment
+	B2CRecipientIsRegisteredCustomer    bool      `json:"B2CRecipientIsRegisteredCustomer"`    // Whether the recipient is a registered M-Pesa customer
+
+	ResultParameters map[string]string `json:"ResultParameters"` // All result parameters as sent by M-Pesa
+	ReferenceData    map[string]string `json:"ReferenceData"`    // Reference items as sent by M-Pesa
+	Raw              map[string]any    `json:"-"`                // The original payload
+	Success          bool              `json:"Success"`          // true when ResultCode is 0
+}
+
+// ParseB2CResult parses a B2C result callback payload into a typed B2CResult.
+// It builds on ParseResultEnvelope for the common Result envelope, then converts the
+// B2C specific result parameters. Parameters that are missing or malformed are left
+// at their zero value; the original strings remain available in ResultParameters.
+// json.Unmarshal into a B2CResult decodes the raw body the same way.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *B2CResult: The parsed result
+//   - error: An error if the payload has no Result node
+//
+// Example:
+//
+//	var payload map[string]any
+//	_ = json.NewDecoder(r.Body).Decode(&payload)
+//	result, err := Services.ParseB2CResult(payload)
+//	if err == nil && result.Success {
+//	    fmt.Printf("Paid %.2f, receipt %s", result.TransactionAmount, result.TransactionReceipt)
+//	}
+func ParseB2CResult(payload map[string]any) (*B2CResult, error) {
+	envelope, err := ParseResultEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	return newB2CResult(envelope), nil
+}
+
+// MarshalJSON encodes the result in the schema SchemaB2CResult.
+func (r B2CResult) MarshalJSON() ([]byte, error) {
+	type plain B2CResult
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaB2CResult, plain(r), marshaledRaw(r.Raw)})
+}
+
+// UnmarshalJSON decodes the raw body of a B2C result callback, as ParseB2CResult does, or the
+// JSON written by MarshalJSON.
+func (r *B2CResult) UnmarshalJSON(data []byte) error {
+	type plain B2CResult
+	if ok, err := decodeNormalized(data, SchemaB2CResult, (*plain)(r), &r.Raw); ok {
+		r.TransactionCompletedDateTime = inEAT(r.TransactionCompletedDateTime)
+		return err
+	}
+
+	var envelope ResultEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	*r = *newB2CResult(&envelope)
+	return nil
+}
+
+// newB2CResult converts the B2C specific result parameters of envelope.
+func newB2CResult(envelope *ResultEnvelope) *B2CResult {
+	params := envelope.ResultParameters
+	res := &B2CResult{
+		ResultCode:               envelope.ResultCode,
+		ResultDesc:               envelope.ResultDesc,
+		OriginatorConversationID: envelope.OriginatorConversationID,
+		ConversationID:           envelope.ConversationID,
+		TransactionID:            envelope.TransactionID,
+		ResultParameters:         params,
+		ReferenceData:            envelope.ReferenceData,
+		Raw:                      envelope.Raw,
+		Success:                  envelope.Success,
+	}
+
+	res.TransactionAmount = parseAmount(params["TransactionAmount"])
+	res.TransactionReceipt = params["TransactionReceipt"]
+	res.ReceiverPartyPublicName = params["ReceiverPartyPublicName"]
+	res.TransactionCompletedDateTime = parseB2CTime(params["TransactionCompletedDateTime"])
+	res.B2CUtilityAccountAvailableFunds = parseAmount(params["B2CUtilityAccountAvailableFunds"])
+	res.B2CWorkingAccountAvailableFunds = parseAmount(params["B2CWorkingAccountAvailableFunds"])
+	res.B2CChargesPaidAccountAvailableFunds = parseAmount(params["B2CChargesPaidAccountAvailableFunds"])
+	res.B2CRecipientIsRegisteredCustomer = strings.EqualFold(params["B2CRecipientIsRegisteredCustomer"], "Y")
+
+	return res
+}
+
+// parseAmount converts an amount parameter to float64, returning 0 when it is missing or malformed.
+func parseAmount(v string) float64 {
+	f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v), ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// parseB2CTime parses the "dd.MM.yyyy HH:mm:ss" timestamps used in B2C results.
+func parseB2CTime(v string) time.Time {
+	t, err := time.ParseInLocation("02.01.2006 15:04:05", strings.TrimSpace(v), mpesaLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
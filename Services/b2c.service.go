@@ -1,22 +1,82 @@
 package Services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
+// B2C command IDs accepted by the payment request API.
+//
+// Each command ID is billed on its own tariff, so the same amount can attract a different
+// charge depending on the command used; confirm the rates for your shortcode with Safaricom.
+const (
+	// CommandSalaryPayment pays salaries. It supports both registered and unregistered
+	// M-Pesa customers and is typically charged at the salary disbursement tariff.
+	CommandSalaryPayment = "SalaryPayment"
+
+	// CommandBusinessPayment is a normal business to customer payment. It only supports
+	// registered M-Pesa customers and is charged at the standard B2C tariff.
+	CommandBusinessPayment = "BusinessPayment"
+
+	// CommandPromotionPayment pays promotional rewards or bonuses. It only supports registered
+	// M-Pesa customers, who receive a congratulatory message, and is charged at the standard B2C tariff.
+	CommandPromotionPayment = "PromotionPayment"
+)
+
+// Default B2C per-transaction amount limits in KES. Limits may differ per agreement with
+// Safaricom; see SetAmountLimits.
+const (
+	DefaultB2CMinAmount = 10
+	DefaultB2CMaxAmount = 150000
+)
+
+// Maximum lengths of the free text B2C fields accepted by Daraja.
+const (
+	MaxB2CRemarksLength  = 100
+	MaxB2COccasionLength = 100
+)
+
+// b2cCommandIDs lists the command IDs accepted by SetCommandID, in documentation order.
+var b2cCommandIDs = []string{CommandSalaryPayment, CommandBusinessPayment, CommandPromotionPayment}
+
 // BusinessToCustomerService handles Business to Customer (B2C) payment operations.
 // B2C allows businesses to send money directly to customer M-Pesa accounts.
 // This service supports various payment types including salary payments, business payments, and promotional payments.
 type BusinessToCustomerService struct {
-	Config        *abstracts.MpesaConfig   // M-Pesa configuration containing credentials and settings
-	Client        abstracts.MpesaInterface // HTTP client interface for making API requests
-	initiatorName string                   // Username of the M-Pesa API operator
-	commandID     string                   // Type of B2C payment (SalaryPayment, BusinessPayment, etc.)
-	remarks       string                   // Transaction remarks/description
-	occasion      string                   // Occasion for the payment
-	amount        int                      // Amount to be sent to the customer
-	phoneNumber   string                   // Customer's phone number
+	Config           *abstracts.MpesaConfig   // M-Pesa configuration containing credentials and settings
+	Client           abstracts.MpesaInterface // HTTP client interface for making API requests
+	initiatorName    string                   // Username of the M-Pesa API operator
+	credential       string                   // Per-service security credential (overrides the config value)
+	commandID        string                   // Type of B2C payment (SalaryPayment, BusinessPayment, etc.)
+	rawCommandID     bool                     // Skip command ID validation (set by SetRawCommandID)
+	remarks          string                   // Transaction remarks/description
+	defaultRemarks   string                   // Remarks used when none are set for a payment
+	occasion         string                   // Occasion for the payment
+	amount           float64                  // Amount to be sent to the customer
+	amountErr        error                    // Parse error from the last SetAmountString call
+	rounding         RoundingPolicy           // How fractional amounts are converted to whole shillings
+	minAmount        int                      // Minimum amount per transaction (0 disables the check)
+	maxAmount        int                      // Maximum amount per transaction (0 disables the check)
+	phoneNumber      string                   // Customer's phone number
+	phoneErr         error                    // Validation error from the last SetPhoneNumber call
+	rawPhone         bool                     // Skip strict phone validation (set by SetRawPhoneNumber)
+	phonePrefixes    []string                 // Allowed phone prefixes, nil unless SetStrictPhonePrefixes was called
+	phoneValidator   abstracts.PhoneValidator // Custom phone check (overrides the config validator)
+	resultURL        string                   // Per-service result URL (overrides the config value)
+	timeoutURL       string                   // Per-service queue timeout URL (overrides the config value)
+	partyA           string                   // Per-service sending short code (overrides the config business code)
+	originatorID     string                   // OriginatorConversationID for the next request, cleared once it is accepted
+	idempotencyKey   string                   // Caller-provided key identifying this payment
+	idempotencyStore IdempotencyStore         // Store consulted to reject duplicate submissions
+	idempotencyTTL   time.Duration            // How long idempotency keys are remembered
+	response         map[string]any           // Raw response from the last payment request
+	typedResponse    *B2CResponse             // Decoded response from the last payment request
+	waiter           resultWaiter             // SendAndWait settings
 }
 
 // NewBusinessToCustomerService creates a new B2C service instance with the provided configuration and client.
@@ -36,8 +96,10 @@ type BusinessToCustomerService struct {
 //	b2cService := NewBusinessToCustomerService(cfg, client)
 func NewBusinessToCustomerService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *BusinessToCustomerService {
 	return &BusinessToCustomerService{
-		Config: cfg,
-		Client: client,
+		Config:    cfg,
+		Client:    client,
+		minAmount: DefaultB2CMinAmount,
+		maxAmount: DefaultB2CMaxAmount,
 	}
 }
 
@@ -58,8 +120,46 @@ func (s *BusinessToCustomerService) SetInitiatorName(name string) *BusinessToCus
 	return s
 }
 
+// SetInitiatorPassword encrypts the initiator password and uses the result as the security
+// credential for this service, overriding the config value. The shared config is not modified.
+//
+// Parameters:
+//   - password: The initiator password
+//
+// Returns:
+//   - error: An error if the password cannot be encrypted with the configured certificate
+//
+// Example:
+//
+//	if err := b2cService.SetInitiatorPassword("Safaricom999!*!"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *BusinessToCustomerService) SetInitiatorPassword(password string) error {
+	credential, err := s.Config.EncryptSecurityCredential(password)
+	if err != nil {
+		return err
+	}
+	s.credential = credential
+	return nil
+}
+
+// SetEncryptedSecurityCredential sets an already encrypted security credential for this service,
+// overriding the config value.
+//
+// Parameters:
+//   - credential: The encrypted security credential
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+func (s *BusinessToCustomerService) SetEncryptedSecurityCredential(credential string) *BusinessToCustomerService {
+	s.credential = credential
+	return s
+}
+
 // SetCommandID sets the type of B2C payment being made.
 // Different command IDs are used for different types of payments.
+// The value is validated when the payment is sent; use SetRawCommandID for command IDs
+// this package does not know about yet.
 //
 // Parameters:
 //   - cmd: The command ID for the payment type
@@ -67,17 +167,36 @@ func (s *BusinessToCustomerService) SetInitiatorName(name string) *BusinessToCus
 // Returns:
 //   - *BusinessToCustomerService: Returns self for method chaining
 //
-// Common Command IDs:
-//   - "SalaryPayment": For salary disbursements
-//   - "BusinessPayment": For general business payments
-//   - "PromotionPayment": For promotional payments and rewards
+// Valid Command IDs:
+//   - CommandSalaryPayment ("SalaryPayment"): For salary disbursements
+//   - CommandBusinessPayment ("BusinessPayment"): For general business payments
+//   - CommandPromotionPayment ("PromotionPayment"): For promotional payments and rewards
 //
 // Example:
 //
-//	b2cService.SetCommandID("SalaryPayment")
-//	b2cService.SetCommandID("BusinessPayment")
+//	b2cService.SetCommandID(Services.CommandSalaryPayment)
+//	b2cService.SetCommandID(Services.CommandBusinessPayment)
 func (s *BusinessToCustomerService) SetCommandID(cmd string) *BusinessToCustomerService {
 	s.commandID = cmd
+	s.rawCommandID = false
+	return s
+}
+
+// SetRawCommandID sets the command ID verbatim, without validating it against the known
+// B2C command IDs. Use it only for command IDs introduced by Safaricom after this release.
+//
+// Parameters:
+//   - cmd: The command ID to send as-is
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetRawCommandID("NewPaymentType")
+func (s *BusinessToCustomerService) SetRawCommandID(cmd string) *BusinessToCustomerService {
+	s.commandID = cmd
+	s.rawCommandID = true
 	return s
 }
 
@@ -99,6 +218,73 @@ func (s *BusinessToCustomerService) SetRemarks(remarks string) *BusinessToCustom
 	return s
 }
 
+// SetDefaultRemarks sets the remarks used when a payment has none of its own.
+// Remarks are required by Daraja in production, so this is convenient for batch jobs
+// where most payments share the same description.
+//
+// Parameters:
+//   - remarks: The fallback remarks, at most MaxB2CRemarksLength characters
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetDefaultRemarks("December payroll")
+func (s *BusinessToCustomerService) SetDefaultRemarks(remarks string) *BusinessToCustomerService {
+	s.defaultRemarks = remarks
+	return s
+}
+
+// SetIdempotencyStore enables duplicate detection for payments sent with an idempotency key.
+// Share one store between services (or use a distributed store) so that a retry from any
+// of them is detected.
+//
+// Parameters:
+//   - store: The store recording used keys, e.g. NewMemoryIdempotencyStore()
+//   - ttl: How long keys are remembered; DefaultIdempotencyTTL when zero or negative
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	store := Services.NewMemoryIdempotencyStore()
+//	b2cService.SetIdempotencyStore(store, 48*time.Hour)
+func (s *BusinessToCustomerService) SetIdempotencyStore(store IdempotencyStore, ttl time.Duration) *BusinessToCustomerService {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	s.idempotencyStore = store
+	s.idempotencyTTL = ttl
+	return s
+}
+
+// SetIdempotencyKey sets a key that uniquely identifies this payment, such as a payroll line ID.
+// When an idempotency store is configured, Send refuses to submit a key that was already used
+// and returns ErrDuplicateSubmission with the previously recorded response, if available.
+// The key stays remembered when the request fails in transit or with a server error, since
+// it may still have been processed by M-Pesa. When M-Pesa rejects the request with a 4xx
+// *Abstracts.APIError, no payment was created and the key is released in stores implementing
+// IdempotencyReleaser, so that the corrected payment can be sent with the same key.
+//
+// Parameters:
+//   - key: The idempotency key
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	response, err := b2cService.SetIdempotencyKey("payroll-2024-12-line-42").Send()
+//	if errors.Is(err, Services.ErrDuplicateSubmission) {
+//	    log.Printf("already paid: %v", response)
+//	}
+func (s *BusinessToCustomerService) SetIdempotencyKey(key string) *BusinessToCustomerService {
+	s.idempotencyKey = key
+	return s
+}
+
 // SetOccasion sets the occasion or reason for the B2C payment.
 // This provides additional context for the transaction.
 //
@@ -131,31 +317,275 @@ func (s *BusinessToCustomerService) SetOccasion(occasion string) *BusinessToCust
 //	b2cService.SetAmount(50000)  // Send KES 50,000
 //	b2cService.SetAmount(1000)   // Send KES 1,000
 func (s *BusinessToCustomerService) SetAmount(amount int) *BusinessToCustomerService {
+	s.amount = float64(amount)
+	s.amountErr = nil
+	return s
+}
+
+// SetAmountFloat sets a decimal amount to be sent to the customer.
+// Daraja only accepts whole shillings, so the amount is converted using the service's
+// rounding policy when the payment is sent. By default, amounts with a fractional part
+// are rejected; see SetRoundingPolicy.
+//
+// Parameters:
+//   - amount: The amount in KES, e.g. 1500.00
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetAmountFloat(1500.00)
+func (s *BusinessToCustomerService) SetAmountFloat(amount float64) *BusinessToCustomerService {
 	s.amount = amount
+	s.amountErr = nil
 	return s
 }
 
-// SetPhoneNumber sets the customer's phone number for the B2C payment.
-// The phone number should be in international format.
+// SetAmountString sets a decimal amount given as a string, as produced by decimal libraries.
+// Thousands separators are ignored. Parse errors are reported when the payment is sent,
+// and the amount is converted using the service's rounding policy.
 //
 // Parameters:
-//   - phone: The customer's phone number (e.g., "254711223344")
+//   - amount: The amount in KES, e.g. "1,500.50"
 //
 // Returns:
 //   - *BusinessToCustomerService: Returns self for method chaining
 //
 // Example:
 //
-//	b2cService.SetPhoneNumber("254711223344")
-//	b2cService.SetPhoneNumber("254722000000")
+//	b2cService.SetAmountString(payslip.Net.String())
+func (s *BusinessToCustomerService) SetAmountString(amount string) *BusinessToCustomerService {
+	s.amount, s.amountErr = parseAmountString(amount)
+	return s
+}
+
+// SetAmountValue sets the amount to be sent to the customer. An amount with cents is
+// converted using the service's rounding policy when the payment is sent.
+//
+// Parameters:
+//   - amount: The amount in KES
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetAmountValue(Abstracts.FromKES(1500))
+func (s *BusinessToCustomerService) SetAmountValue(amount abstracts.Amount) *BusinessToCustomerService {
+	s.amount = amount.Float64()
+	s.amountErr = nil
+	return s
+}
+
+// SetAmountLimits sets the per-transaction amount limits enforced before a payment is sent.
+// M-Pesa rejects amounts outside the limits only in the asynchronous result, so checking them
+// up front avoids promising a payment that will fail. The defaults are DefaultB2CMinAmount and
+// DefaultB2CMaxAmount; a limit of zero disables that bound.
+//
+// Parameters:
+//   - min: The minimum amount in KES, or 0 for no minimum
+//   - max: The maximum amount in KES, or 0 for no maximum
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetAmountLimits(10, 250000) // Raised maximum under a custom agreement
+func (s *BusinessToCustomerService) SetAmountLimits(min, max int) *BusinessToCustomerService {
+	s.minAmount = min
+	s.maxAmount = max
+	return s
+}
+
+// SetRoundingPolicy sets how fractional amounts are converted to whole shillings.
+// The default, RoundStrict, rejects amounts with a fractional part.
+//
+// Parameters:
+//   - policy: RoundStrict or RoundHalfUp
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetRoundingPolicy(Services.RoundHalfUp).SetAmountString("150.50") // sends 151
+func (s *BusinessToCustomerService) SetRoundingPolicy(policy RoundingPolicy) *BusinessToCustomerService {
+	s.rounding = policy
+	return s
+}
+
+// SetPhoneNumber sets and normalizes the customer's phone number for the B2C payment.
+// The number is cleaned with Abstracts.NormalizeMSISDN, as for STK Push, so local (07...),
+// international (+254...), bare (254...) and 9-digit (7XXXXXXXX) formats are all converted to
+// 2547XXXXXXXX, and checked with Abstracts.ValidateMSISDN. An invalid number is reported by
+// Send or PaymentRequest.
+//
+// Parameters:
+//   - phone: The customer's phone number (e.g., "0711223344", "+254711223344", "254711223344")
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetPhoneNumber("0711223344")    // Stored as "254711223344"
+//	b2cService.SetPhoneNumber("+254722000000") // Stored as "254722000000"
 func (s *BusinessToCustomerService) SetPhoneNumber(phone string) *BusinessToCustomerService {
+	cleaned, err := abstracts.NormalizeMSISDN(phone, s.Config.GetDefaultCountryCode())
+	if err == nil {
+		err = abstracts.ValidateMSISDN(cleaned)
+	}
+	if err != nil {
+		s.phoneNumber = ""
+		s.phoneErr = err
+		return s
+	}
+	s.phoneNumber = cleaned
+	s.phoneErr = nil
+	s.rawPhone = false
+	return s
+}
+
+// SetRawPhoneNumber sets the customer's phone number verbatim, bypassing normalization.
+// Use this only when the number is already in the exact format Daraja expects.
+//
+// Parameters:
+//   - phone: The phone number to send as PartyB
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+func (s *BusinessToCustomerService) SetRawPhoneNumber(phone string) *BusinessToCustomerService {
 	s.phoneNumber = phone
+	s.phoneErr = nil
+	s.rawPhone = true
+	return s
+}
+
+// SetStrictPhonePrefixes turns on strict phone validation: Send rejects a phone number
+// that does not start with one of prefixes, or Abstracts.DefaultMSISDNPrefixes (the Safaricom
+// 2547 and 2541 ranges) when none are given, with an error wrapping Abstracts.ErrUnsupportedPrefix. Numbers set with SetRawPhoneNumber
+// are not checked.
+//
+// Parameters:
+//   - prefixes: The allowed prefixes in international format, e.g. "2547"
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetStrictPhonePrefixes()                      // Safaricom numbers only
+//	b2cService.SetStrictPhonePrefixes("2547", "2541", "255") // also Tanzanian numbers
+func (s *BusinessToCustomerService) SetStrictPhonePrefixes(prefixes ...string) *BusinessToCustomerService {
+	s.phonePrefixes = strictPhonePrefixes(prefixes)
 	return s
 }
 
+// SetPhoneValidator sets a custom check, e.g. a denylist lookup, that Send runs on the phone
+// number after normalizing it, in place of the one set with MpesaConfig.SetPhoneValidator.
+// Numbers set with SetRawPhoneNumber are checked as given. Its error is returned wrapped in
+// "invalid phone number".
+//
+// Parameters:
+//   - validator: The check to run; nil falls back to the config's
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+func (s *BusinessToCustomerService) SetPhoneValidator(validator abstracts.PhoneValidator) *BusinessToCustomerService {
+	s.phoneValidator = validator
+	return s
+}
+
+// SetResultURL sets the URL where M-Pesa will send the result of this service's payments.
+// The value takes precedence over the config's result URL and does not modify the config,
+// so other services sharing the same config are unaffected.
+//
+// Parameters:
+//   - url: The fully qualified result URL
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetResultURL("https://yourdomain.com/mpesa/b2c/result")
+func (s *BusinessToCustomerService) SetResultURL(url string) *BusinessToCustomerService {
+	s.resultURL = url
+	return s
+}
+
+// SetQueueTimeoutURL sets the URL where M-Pesa will send queue timeout notifications for this
+// service's payments. The value takes precedence over the config's queue timeout URL and does
+// not modify the config.
+//
+// Parameters:
+//   - url: The fully qualified queue timeout URL
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetQueueTimeoutURL("https://yourdomain.com/mpesa/b2c/timeout")
+func (s *BusinessToCustomerService) SetQueueTimeoutURL(url string) *BusinessToCustomerService {
+	s.timeoutURL = url
+	return s
+}
+
+// PaymentRequestOpts holds the optional overrides accepted by PaymentRequestWithOpts.
+// Zero values leave the corresponding service field unchanged.
+type PaymentRequestOpts struct {
+	InitiatorName     string // Initiator username
+	InitiatorPassword string // Initiator password, encrypted into the security credential of this service
+	CommandID         string // Command ID, e.g. CommandSalaryPayment
+	Amount            int    // Amount in KES
+	PartyA            string // Business short code sending the payment
+	PhoneNumber       string // Customer's phone number
+	Remarks           string // Transaction remarks
+	QueueTimeoutURL   string // URL for queue timeout notifications
+	ResultURL         string // URL for result notifications
+	Occasion          string // Occasion for the transaction
+}
+
+// PaymentRequestWithOpts sends a business to customer payment request to the M-Pesa API,
+// applying the non-zero fields of opts to the service first. It validates and sends the
+// request exactly like Send.
+//
+// Parameters:
+//   - opts: Optional overrides for the service fields
+//
+// Returns:
+//   - map[string]any: The response from the M-Pesa API
+//   - error: An error if validation fails or the API request encounters issues
+//
+// Example:
+//
+//	response, err := b2cService.PaymentRequestWithOpts(Services.PaymentRequestOpts{
+//	    InitiatorName: "testapi",
+//	    CommandID:     Services.CommandSalaryPayment,
+//	    Amount:        5000,
+//	    PhoneNumber:   "254711223344",
+//	    Remarks:       "Monthly salary",
+//	    ResultURL:     "https://example.com/result",
+//	})
+//	if err != nil {
+//	    log.Printf("Payment request failed: %v", err)
+//	    return
+//	}
+func (s *BusinessToCustomerService) PaymentRequestWithOpts(opts PaymentRequestOpts) (map[string]any, error) {
+	if err := s.applyOpts(opts); err != nil {
+		return nil, err
+	}
+	return s.Send()
+}
+
 // PaymentRequest sends a business to customer payment request to the M-Pesa API.
 // All parameters are optional. If provided, they override the existing fields.
 //
+// Deprecated: Use PaymentRequestWithOpts, whose named fields cannot be swapped by accident.
+//
 // Parameters:
 //   - initiatorName: Optional initiator username
 //   - initiatorPassword: Optional initiator password for security credential
@@ -171,88 +601,69 @@ func (s *BusinessToCustomerService) SetPhoneNumber(phone string) *BusinessToCust
 // Returns:
 //   - map[string]interface{}: The response from the M-Pesa API
 //   - error: An error if validation fails or the API request encounters issues
-//
-// Example:
-//
-//	response, err := b2cService.PaymentRequest(
-//	    abstracts.String("testapi"),
-//	    abstracts.String("password"),
-//	    abstracts.String("SalaryPayment"),
-//	    abstracts.Int(5000),
-//	    abstracts.String("123456"),
-//	    abstracts.String("254711223344"),
-//	    abstracts.String("Monthly salary"),
-//	    abstracts.String("https://example.com/timeout"),
-//	    abstracts.String("https://example.com/result"),
-//	    abstracts.String("December Salary"),
-//	)
-//	if err != nil {
-//	    log.Printf("Payment request failed: %v", err)
-//	    return
-//	}
-//	fmt.Printf("Payment request sent: %+v", response)
 func (s *BusinessToCustomerService) PaymentRequest(
 	initiatorName, initiatorPassword, commandID *string,
 	amount *int,
 	partyA, phoneNumber, remarks, queueTimeoutURL, resultURL, occasion *string,
 ) (map[string]interface{}, error) {
-	if initiatorName != nil {
-		s.SetInitiatorName(*initiatorName)
+	deref := func(v *string) string {
+		if v == nil {
+			return ""
+		}
+		return *v
 	}
-	if commandID != nil {
-		s.SetCommandID(*commandID)
+	opts := PaymentRequestOpts{
+		InitiatorName:     deref(initiatorName),
+		InitiatorPassword: deref(initiatorPassword),
+		CommandID:         deref(commandID),
+		PartyA:            deref(partyA),
+		PhoneNumber:       deref(phoneNumber),
+		Remarks:           deref(remarks),
+		QueueTimeoutURL:   deref(queueTimeoutURL),
+		ResultURL:         deref(resultURL),
+		Occasion:          deref(occasion),
 	}
 	if amount != nil {
-		s.SetAmount(*amount)
+		opts.Amount = *amount
 	}
-	if partyA != nil {
-		s.Config.SetBusinessCode(*partyA)
+	return s.PaymentRequestWithOpts(opts)
+}
+
+// applyOpts copies the non-zero fields of opts onto the service.
+func (s *BusinessToCustomerService) applyOpts(opts PaymentRequestOpts) error {
+	if opts.InitiatorName != "" {
+		s.SetInitiatorName(opts.InitiatorName)
 	}
-	if phoneNumber != nil {
-		s.SetPhoneNumber(*phoneNumber)
+	if opts.CommandID != "" {
+		s.SetCommandID(opts.CommandID)
 	}
-	if remarks != nil {
-		s.SetRemarks(*remarks)
+	if opts.Amount != 0 {
+		s.SetAmount(opts.Amount)
 	}
-	if queueTimeoutURL != nil {
-		s.Config.SetQueueTimeoutURL(*queueTimeoutURL)
+	if opts.PartyA != "" {
+		s.partyA = opts.PartyA
 	}
-	if resultURL != nil {
-		s.Config.SetResultURL(*resultURL)
+	if opts.PhoneNumber != "" {
+		s.SetPhoneNumber(opts.PhoneNumber)
 	}
-	if occasion != nil {
-		s.SetOccasion(*occasion)
+	if opts.Remarks != "" {
+		s.SetRemarks(opts.Remarks)
 	}
-	if initiatorPassword != nil {
-		if err := s.Config.SetSecurityCredential(*initiatorPassword); err != nil {
-			return nil, err
-		}
+	if opts.QueueTimeoutURL != "" {
+		s.SetQueueTimeoutURL(opts.QueueTimeoutURL)
 	}
-
-	// Validate required fields
-	if s.initiatorName == "" || s.commandID == "" || s.amount == 0 || s.phoneNumber == "" || s.Config.GetBusinessCode() == "" {
-		return nil, errors.New("initiator name, command ID, amount, phone number, and business code are required")
+	if opts.ResultURL != "" {
+		s.SetResultURL(opts.ResultURL)
 	}
-
-	requestData := map[string]interface{}{
-		"InitiatorName":      s.initiatorName,
-		"SecurityCredential": s.Config.GetSecurityCredential(),
-		"CommandID":          s.commandID,
-		"Amount":             s.amount,
-		"PartyA":             s.Config.GetBusinessCode(),
-		"PartyB":             s.phoneNumber,
-		"Remarks":            s.remarks,
-		"QueueTimeOutURL":    s.Config.GetQueueTimeoutURL(),
-		"ResultURL":          s.Config.GetResultURL(),
-		"Occassion":          s.occasion,
+	if opts.Occasion != "" {
+		s.SetOccasion(opts.Occasion)
 	}
-
-	response, err := s.Client.ExecuteRequest(requestData, "/mpesa/b2c/v1/paymentrequest")
-	if err != nil {
-		return nil, err
+	if opts.InitiatorPassword != "" {
+		if err := s.SetInitiatorPassword(opts.InitiatorPassword); err != nil {
+			return err
+		}
 	}
-
-	return response, nil
+	return nil
 }
 
 // Send initiates the B2C payment to the customer.
@@ -285,25 +696,307 @@ func (s *BusinessToCustomerService) Send() (map[string]any, error) {
 	if s.commandID == "" {
 		return nil, errors.New("command ID is required")
 	}
-	if s.amount <= 0 {
-		return nil, errors.New("amount must be greater than 0")
+	if err := s.validateCommandID(); err != nil {
+		return nil, err
+	}
+	amountValue, err := s.resolveAmount()
+	if err != nil {
+		return nil, err
+	}
+	if s.phoneErr != nil {
+		return nil, fmt.Errorf("invalid phone number: %w", s.phoneErr)
 	}
 	if s.phoneNumber == "" {
 		return nil, errors.New("phone number is required")
 	}
+	if !s.rawPhone {
+		if err := checkPhonePrefixes(s.phoneNumber, s.phonePrefixes); err != nil {
+			return nil, fmt.Errorf("invalid phone number: %w", err)
+		}
+	}
+	if err := checkPhoneValidator(s.phoneNumber, s.Config, s.phoneValidator); err != nil {
+		return nil, fmt.Errorf("invalid phone number: %w", err)
+	}
+	if s.getPartyA() == "" {
+		return nil, errors.New("business shortcode (PartyA) is required; call SetBusinessCode on mpesa config")
+	}
+	if s.getSecurityCredential() == "" {
+		return nil, errors.New("security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config")
+	}
+	if s.getQueueTimeoutURL() == "" {
+		return nil, errors.New("queue timeout URL is required; call SetQueueTimeoutURL on the service or config")
+	}
+	if s.getResultURL() == "" {
+		return nil, errors.New("result URL is required; call SetResultURL on the service or config")
+	}
+	remarks := chooseString(s.remarks, s.defaultRemarks)
+	if err := validateLength("remarks", remarks, 1, MaxB2CRemarksLength); err != nil {
+		return nil, err
+	}
+	if err := validateLength("occasion", s.occasion, 0, MaxB2COccasionLength); err != nil {
+		return nil, err
+	}
+
+	originatorID, err := s.ensureOriginatorConversationID()
+	if err != nil {
+		return nil, err
+	}
+	s.response, s.typedResponse = nil, nil
 
 	data := map[string]any{
-		"InitiatorName":      s.initiatorName,
-		"SecurityCredential": s.Config.GetSecurityCredential(),
-		"CommandID":          s.commandID,
-		"Amount":             s.amount,
-		"PartyA":             s.Config.GetBusinessCode(),
-		"PartyB":             s.phoneNumber,
-		"Remarks":            s.remarks,
-		"QueueTimeOutURL":    s.Config.GetQueueTimeoutURL(),
-		"ResultURL":          s.Config.GetResultURL(),
-		"Occasion":           s.occasion,
-	}
-
-	return s.Client.ExecuteRequest(data, "/mpesa/b2c/v1/paymentrequest")
+		"OriginatorConversationID": originatorID,
+		"InitiatorName":            s.initiatorName,
+		"SecurityCredential":       s.getSecurityCredential(),
+		"CommandID":                s.commandID,
+		"Amount":                   amountValue, // whole shillings as a number, see b2cAmountForm
+		"PartyA":                   s.getPartyA(),
+		"PartyB":                   s.phoneNumber,
+		"Remarks":                  remarks,
+		"QueueTimeOutURL":          s.getQueueTimeoutURL(),
+		"ResultURL":                s.getResultURL(),
+		"Occasion":                 s.occasion,
+	}
+
+	guarded := s.idempotencyStore != nil && s.idempotencyKey != ""
+	if guarded && !claimIdempotencyKey(s.idempotencyStore, s.idempotencyKey, s.idempotencyTTL) {
+		if responses, ok := s.idempotencyStore.(IdempotencyResponseStore); ok {
+			if previous, found := responses.Response(s.idempotencyKey); found {
+				return previous, ErrDuplicateSubmission
+			}
+		}
+		return nil, ErrDuplicateSubmission
+	}
+
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.B2CPayment)
+	if err != nil {
+		if guarded {
+			releaseIdempotencyKey(s.idempotencyStore, s.idempotencyKey, err)
+		}
+		// The ID is kept, so that a retry is recognised by M-Pesa's duplicate detection
+		// when the failed request did reach it.
+		return nil, err
+	}
+	// Each payment gets its own ID, so that the result callbacks can tell them apart.
+	s.originatorID = ""
+
+	if guarded {
+		if responses, ok := s.idempotencyStore.(IdempotencyResponseStore); ok {
+			responses.RememberResponse(s.idempotencyKey, response)
+		}
+	}
+	s.setResponse(response)
+	return response, nil
+}
+
+// GetResponse returns the raw response map from the last payment request.
+//
+// Returns:
+//   - map[string]any: The response data, or nil if no request has been made
+func (s *BusinessToCustomerService) GetResponse() map[string]any {
+	return s.response
+}
+
+// GetTypedResponse returns the decoded acknowledgement from the last payment request.
+//
+// Returns:
+//   - *B2CResponse: The decoded response, or nil if no request has been made
+//
+// Example:
+//
+//	if _, err := b2cService.Send(); err == nil && b2cService.GetTypedResponse().Accepted() {
+//	    fmt.Println("Payment accepted for processing")
+//	}
+func (s *BusinessToCustomerService) GetTypedResponse() *B2CResponse {
+	return s.typedResponse
+}
+
+// GetConversationID returns the ConversationID from the last payment request.
+// Persist it to match the asynchronous result delivered to the ResultURL.
+//
+// Returns:
+//   - string: The ConversationID
+//   - error: An error if no response is available or it has no ConversationID
+func (s *BusinessToCustomerService) GetConversationID() (string, error) {
+	if s.typedResponse == nil {
+		return "", errors.New("no B2C response available")
+	}
+	if s.typedResponse.ConversationID == "" {
+		return "", errors.New("ConversationID not found in response")
+	}
+	return s.typedResponse.ConversationID, nil
+}
+
+// SetOriginatorConversationID sets the OriginatorConversationID sent with the payment request.
+// M-Pesa uses it for duplicate detection and echoes it in the result callback, so it can be
+// used to correlate the asynchronous result with the request. The ID applies until a request
+// with it is answered by M-Pesa: a Send that fails keeps it, so that retrying is safe, and the
+// request after a successful one gets a new random UUID unless this is called again. When it
+// is not set, a random UUID is generated for each request.
+//
+// Parameters:
+//   - id: A unique ID for the request, e.g. your own payout reference
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+//
+// Example:
+//
+//	b2cService.SetOriginatorConversationID("payout-2024-12-0001")
+func (s *BusinessToCustomerService) SetOriginatorConversationID(id string) *BusinessToCustomerService {
+	s.originatorID = id
+	return s
+}
+
+// GetOriginatorConversationID returns the OriginatorConversationID the next Send uses,
+// generating one if necessary, so that it can be persisted before the request goes out. After
+// a failed Send it is still the ID of that request, which a retry sends again; after a
+// successful one it is a new ID for the next payment. The ID of an answered request is echoed
+// in GetTypedResponse.
+//
+// Returns:
+//   - string: The OriginatorConversationID
+//   - error: An error if an ID could not be generated
+//
+// Example:
+//
+//	id, _ := b2cService.GetOriginatorConversationID()
+//	savePayout(id)
+//	response, err := b2cService.Send()
+func (s *BusinessToCustomerService) GetOriginatorConversationID() (string, error) {
+	return s.ensureOriginatorConversationID()
+}
+
+// ensureOriginatorConversationID returns the next request's OriginatorConversationID,
+// generating one if unset.
+func (s *BusinessToCustomerService) ensureOriginatorConversationID() (string, error) {
+	if s.originatorID == "" {
+		id, err := newUUID()
+		if err != nil {
+			return "", err
+		}
+		s.originatorID = id
+	}
+	return s.originatorID, nil
+}
+
+// resolveAmount converts the configured amount to whole shillings using the rounding policy,
+// checks that the result is positive and within the amount limits, and returns it in the form
+// of the B2C Amount field.
+func (s *BusinessToCustomerService) resolveAmount() (any, error) {
+	if s.amountErr != nil {
+		return 0, fmt.Errorf("invalid amount: %w", s.amountErr)
+	}
+	amount, err := roundAmount(s.amount, s.rounding)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %w", err)
+	}
+	if amount <= 0 {
+		return 0, errors.New("amount must be greater than 0")
+	}
+	if s.minAmount > 0 && amount < s.minAmount {
+		return 0, fmt.Errorf("amount %d is below the minimum B2C amount of %d", amount, s.minAmount)
+	}
+	if s.maxAmount > 0 && amount > s.maxAmount {
+		return 0, fmt.Errorf("amount %d is above the maximum B2C amount of %d", amount, s.maxAmount)
+	}
+	return amountField(abstracts.FromKES(amount), b2cAmountForm)
+}
+
+// validateCommandID checks the command ID against the known B2C command IDs,
+// unless it was set with SetRawCommandID.
+func (s *BusinessToCustomerService) validateCommandID() error {
+	if s.rawCommandID {
+		return nil
+	}
+	for _, cmd := range b2cCommandIDs {
+		if s.commandID == cmd {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid command ID %q: must be one of %s", s.commandID, strings.Join(b2cCommandIDs, ", "))
+}
+
+// getPartyA returns the per-service sending short code, falling back to the config business code.
+func (s *BusinessToCustomerService) getPartyA() string {
+	return chooseString(s.partyA, s.Config.GetBusinessCode())
+}
+
+// getSecurityCredential returns the per-service security credential, falling back to the config value.
+func (s *BusinessToCustomerService) getSecurityCredential() string {
+	return chooseString(s.credential, s.Config.GetSecurityCredential())
+}
+
+// getResultURL returns the per-service result URL, falling back to the config value.
+func (s *BusinessToCustomerService) getResultURL() string {
+	return chooseString(s.resultURL, s.Config.GetResultURL())
+}
+
+// getQueueTimeoutURL returns the per-service queue timeout URL, falling back to the config value.
+func (s *BusinessToCustomerService) getQueueTimeoutURL() string {
+	return chooseString(s.timeoutURL, s.Config.GetQueueTimeoutURL())
+}
+
+// setResponse stores the raw and decoded response of the last payment request.
+func (s *BusinessToCustomerService) setResponse(resp map[string]any) {
+	s.response = resp
+	s.typedResponse = NewB2CResponse(resp)
+}
+
+// SetResultCorrelator sets the correlator SendAndWait waits for the result with. The handler
+// of the ResultURL and QueueTimeOutURL must resolve results in it; see WithResultCorrelator.
+//
+// Parameters:
+//   - correlator: The correlator shared with the result handler
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+func (s *BusinessToCustomerService) SetResultCorrelator(correlator ResultCorrelator) *BusinessToCustomerService {
+	s.waiter.correlator = correlator
+	return s
+}
+
+// SetStatusFallback makes SendAndWait query the transaction status with status when the result
+// does not arrive before its context expires, and wait up to wait for the status result
+// (DefaultStatusFallbackWait when zero or negative). status must be configured with an
+// initiator and identifier type, and its results must be resolved in the same correlator.
+//
+// Parameters:
+//   - status: The service used for the status query
+//   - wait: How long to wait for the status result
+//
+// Returns:
+//   - *BusinessToCustomerService: Returns self for method chaining
+func (s *BusinessToCustomerService) SetStatusFallback(status *TransactionStatusService, wait time.Duration) *BusinessToCustomerService {
+	s.waiter.setStatusFallback(status, wait)
+	return s
+}
+
+// SendAndWait sends the request with Send and waits until its result arrives on the ResultURL
+// or QueueTimeOutURL, or ctx is done. The OriginatorConversationID is registered before the
+// request is sent, so a result that arrives before the acknowledgement is matched too.
+//
+// Parameters:
+//   - ctx: Bounds the wait for the result
+//
+// Returns:
+//   - *CorrelatedResult: The result, with Result set to a *B2CResult, or the queue timeout
+//   - error: An error if the request fails or no result arrives in time (ErrResultTimeout)
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+//	defer cancel()
+//	id, _ := b2cService.GetOriginatorConversationID()
+//	res, err := b2cService.SetResultCorrelator(correlator).SendAndWait(ctx)
+//	if errors.Is(err, Services.ErrResultTimeout) {
+//	    schedulePayoutCheck(id)
+//	} else if err == nil {
+//	    markPayout(res.OriginatorConversationID, res.Success, res.TransactionID)
+//	}
+func (s *BusinessToCustomerService) SendAndWait(ctx context.Context) (*CorrelatedResult, error) {
+	originatorID, err := s.ensureOriginatorConversationID()
+	if err != nil {
+		return nil, err
+	}
+	return s.waiter.sendAndWait(ctx, originatorID, s.Send)
 }
@@ -0,0 +1,193 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// B2CAccountTopUpService loads a B2C utility account from a business's working or MMF account.
+// It uses the B2B payment request API with the "BusinessPayToBulk" command.
+type B2CAccountTopUpService struct {
+	Config                  *abstracts.MpesaConfig
+	Client                  abstracts.MpesaInterface
+	initiator               string
+	securityCredential      string
+	commandID               string
+	senderIdentifierType    string
+	recipientIdentifierType string
+	amount                  float64
+	rounding                RoundingPolicy
+	partyA                  string
+	partyB                  string
+	accountReference        string
+	requester               string
+	remarks                 string
+	queueTimeoutURL         string
+	resultURL               string
+	response                map[string]any
+}
+
+// NewB2CAccountTopUpService creates a new B2C account top up service instance.
+func NewB2CAccountTopUpService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *B2CAccountTopUpService {
+	return &B2CAccountTopUpService{
+		Config:                  cfg,
+		Client:                  client,
+		commandID:               "BusinessPayToBulk",
+		senderIdentifierType:    "4",
+		recipientIdentifierType: "4",
+	}
+}
+
+// SetInitiator sets the initiator (operator username) for the transaction.
+func (s *B2CAccountTopUpService) SetInitiator(name string) *B2CAccountTopUpService {
+	s.initiator = name
+	return s
+}
+
+// SetSecurityCredential encrypts the initiator password and uses it for this service.
+// It is equivalent to SetInitiatorPassword; the shared config is not modified.
+func (s *B2CAccountTopUpService) SetSecurityCredential(password string) error {
+	return s.SetInitiatorPassword(password)
+}
+
+// SetInitiatorPassword encrypts the initiator password and uses the result as the security
+// credential for this service, overriding the config value.
+func (s *B2CAccountTopUpService) SetInitiatorPassword(password string) error {
+	credential, err := s.Config.EncryptSecurityCredential(password)
+	if err != nil {
+		return err
+	}
+	s.securityCredential = credential
+	return nil
+}
+
+// SetEncryptedSecurityCredential sets an already encrypted security credential for this service,
+// overriding the config value.
+func (s *B2CAccountTopUpService) SetEncryptedSecurityCredential(credential string) *B2CAccountTopUpService {
+	s.securityCredential = credential
+	return s
+}
+
+// SetAmount sets the amount in KES to move to the B2C account.
+func (s *B2CAccountTopUpService) SetAmount(amount float64) *B2CAccountTopUpService {
+	s.amount = amount
+	return s
+}
+
+// SetAmountValue sets the amount to move to the B2C account. Cents are handled by the rounding policy.
+func (s *B2CAccountTopUpService) SetAmountValue(amount abstracts.Amount) *B2CAccountTopUpService {
+	s.amount = amount.Float64()
+	return s
+}
+
+// SetRoundingPolicy sets how a fractional amount is converted to whole shillings. By default it is rejected.
+func (s *B2CAccountTopUpService) SetRoundingPolicy(policy RoundingPolicy) *B2CAccountTopUpService {
+	s.rounding = policy
+	return s
+}
+
+// SetPartyA sets the shortcode from which money will be deducted. It defaults to the config business code.
+func (s *B2CAccountTopUpService) SetPartyA(code string) *B2CAccountTopUpService {
+	s.partyA = code
+	return s
+}
+
+// SetPartyB sets the B2C shortcode whose utility account will be credited.
+func (s *B2CAccountTopUpService) SetPartyB(code string) *B2CAccountTopUpService {
+	s.partyB = code
+	return s
+}
+
+// SetAccountReference sets an account/reference associated with the top up.
+func (s *B2CAccountTopUpService) SetAccountReference(ref string) *B2CAccountTopUpService {
+	s.accountReference = ref
+	return s
+}
+
+// SetRequester sets the optional mobile number of the person requesting the top up.
+func (s *B2CAccountTopUpService) SetRequester(msisdn string) *B2CAccountTopUpService {
+	s.requester = msisdn
+	return s
+}
+
+// SetRemarks sets transaction remarks.
+func (s *B2CAccountTopUpService) SetRemarks(r string) *B2CAccountTopUpService {
+	s.remarks = r
+	return s
+}
+
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
+func (s *B2CAccountTopUpService) SetQueueTimeoutURL(url string) *B2CAccountTopUpService {
+	s.queueTimeoutURL = url
+	return s
+}
+
+// SetResultURL sets the result URL for this service, overriding the config value.
+func (s *B2CAccountTopUpService) SetResultURL(url string) *B2CAccountTopUpService {
+	s.resultURL = url
+	return s
+}
+
+// Send constructs and sends the BusinessPayToBulk request to M-Pesa using the shared B2B helper.
+func (s *B2CAccountTopUpService) Send() (map[string]any, error) {
+	// Validate required fields
+	if s.initiator == "" {
+		return nil, errors.New("initiator is required")
+	}
+	if s.getSecurityCredential() == "" {
+		return nil, errors.New("security credential is required; call SetInitiatorPassword or SetEncryptedSecurityCredential")
+	}
+	if s.amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	if _, err := roundAmount(s.amount, s.rounding); err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if s.partyA == "" && s.Config.GetBusinessCode() == "" {
+		return nil, errors.New("partyA (business shortcode) is required")
+	}
+	if s.partyB == "" {
+		return nil, errors.New("partyB (B2C shortcode) is required")
+	}
+
+	req := B2BRequest{
+		Initiator:              s.initiator,
+		SecurityCredential:     s.getSecurityCredential(),
+		CommandID:              s.commandID,
+		SenderIdentifierType:   s.senderIdentifierType,
+		RecieverIdentifierType: s.recipientIdentifierType,
+		Amount:                 s.amount,
+		Rounding:               s.rounding,
+		PartyA:                 s.partyA,
+		PartyB:                 s.partyB,
+		AccountReference:       s.accountReference,
+		Requester:              s.requester,
+		Remarks:                s.remarks,
+		QueueTimeOutURL:        s.queueTimeoutURL,
+		ResultURL:              s.resultURL,
+	}
+
+	resp, err := ExecuteB2BRequest(s.Config, s.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	return resp, nil
+}
+
+// ParseCallback parses a received callback payload using the shared ParseB2BCallback helper.
+func (s *B2CAccountTopUpService) ParseCallback(payload map[string]any) (*B2BCallbackResult, error) {
+	return ParseB2BCallback(payload)
+}
+
+func (s *B2CAccountTopUpService) getSecurityCredential() string {
+	return chooseString(s.securityCredential, s.Config.GetSecurityCredential())
+}
+
+// GetResponse returns the last API response stored by the service.
+func (s *B2CAccountTopUpService) GetResponse() map[string]any {
+	return s.response
+}
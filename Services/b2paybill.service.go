@@ -1,7 +1,10 @@
 package Services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
@@ -12,17 +15,25 @@ type BusinessToPayBillService struct {
 	Config                  *abstracts.MpesaConfig
 	Client                  abstracts.MpesaInterface
 	initiator               string
+	securityCredential      string
 	commandID               string
 	senderIdentifierType    string
 	recipientIdentifierType string
 	amount                  float64
+	rounding                RoundingPolicy
 	partyA                  string
 	partyB                  string
 	accountReference        string
+	truncateRef             bool
 	requester               string
 	remarks                 string
 	occasion                string
+	queueTimeoutURL         string
+	resultURL               string
 	response                map[string]any
+	typedResponse           *B2BSendResponse
+	originatorID            string // OriginatorConversationID for the next request, cleared once it is accepted
+	waiter                  resultWaiter
 }
 
 // NewBusinessToPayBillService creates a new B2B PayBill service instance.
@@ -31,8 +42,8 @@ func NewBusinessToPayBillService(cfg *abstracts.MpesaConfig, client abstracts.Mp
 		Config:                  cfg,
 		Client:                  client,
 		commandID:               "BusinessPayBill",
-		senderIdentifierType:    "4",
-		recipientIdentifierType: "4",
+		senderIdentifierType:    string(IdentifierTypeShortcode),
+		recipientIdentifierType: string(IdentifierTypeShortcode),
 	}
 }
 
@@ -42,9 +53,28 @@ func (s *BusinessToPayBillService) SetInitiator(name string) *BusinessToPayBillS
 	return s
 }
 
-// SetSecurityCredential encrypts and sets the security credential (initiator password).
+// SetSecurityCredential encrypts the initiator password and uses it for this service.
+// It is equivalent to SetInitiatorPassword; the shared config is not modified.
 func (s *BusinessToPayBillService) SetSecurityCredential(password string) error {
-	return s.Config.SetSecurityCredential(password)
+	return s.SetInitiatorPassword(password)
+}
+
+// SetInitiatorPassword encrypts the initiator password and uses the result as the security
+// credential for this service, overriding the config value.
+func (s *BusinessToPayBillService) SetInitiatorPassword(password string) error {
+	credential, err := s.Config.EncryptSecurityCredential(password)
+	if err != nil {
+		return err
+	}
+	s.securityCredential = credential
+	return nil
+}
+
+// SetEncryptedSecurityCredential sets an already encrypted security credential for this service,
+// overriding the config value.
+func (s *BusinessToPayBillService) SetEncryptedSecurityCredential(credential string) *BusinessToPayBillService {
+	s.securityCredential = credential
+	return s
 }
 
 // SetAmount sets the transaction amount in KES.
@@ -53,10 +83,33 @@ func (s *BusinessToPayBillService) SetAmount(amount float64) *BusinessToPayBillS
 	return s
 }
 
-// SetPartyA sets the shortcode from which money will be deducted.
+// SetAmountValue sets the transaction amount. Cents are handled by the rounding policy.
+func (s *BusinessToPayBillService) SetAmountValue(amount abstracts.Amount) *BusinessToPayBillService {
+	s.amount = amount.Float64()
+	return s
+}
+
+// SetRoundingPolicy sets how a fractional amount is converted to whole shillings. By default it is rejected.
+func (s *BusinessToPayBillService) SetRoundingPolicy(policy RoundingPolicy) *BusinessToPayBillService {
+	s.rounding = policy
+	return s
+}
+
+// SetSenderIdentifierType sets the type of PartyA. It defaults to IdentifierTypeShortcode.
+func (s *BusinessToPayBillService) SetSenderIdentifierType(t IdentifierType) *BusinessToPayBillService {
+	s.senderIdentifierType = string(t)
+	return s
+}
+
+// SetReceiverIdentifierType sets the type of PartyB. It defaults to IdentifierTypeShortcode.
+func (s *BusinessToPayBillService) SetReceiverIdentifierType(t IdentifierType) *BusinessToPayBillService {
+	s.recipientIdentifierType = string(t)
+	return s
+}
+
+// SetPartyA sets the shortcode from which money will be deducted. It defaults to the config business code.
 func (s *BusinessToPayBillService) SetPartyA(code string) *BusinessToPayBillService {
 	s.partyA = code
-	s.Config.SetBusinessCode(code)
 	return s
 }
 
@@ -67,11 +120,19 @@ func (s *BusinessToPayBillService) SetPartyB(code string) *BusinessToPayBillServ
 }
 
 // SetAccountReference sets an account/reference associated with the payment.
+// It is required and limited to MaxB2BAccountReferenceLength characters.
 func (s *BusinessToPayBillService) SetAccountReference(ref string) *BusinessToPayBillService {
 	s.accountReference = ref
 	return s
 }
 
+// TruncateAccountReference shortens a longer account reference to MaxB2BAccountReferenceLength
+// characters instead of rejecting it.
+func (s *BusinessToPayBillService) TruncateAccountReference() *BusinessToPayBillService {
+	s.truncateRef = true
+	return s
+}
+
 // SetRequester sets the optional consumer mobile number on whose behalf the payment is made.
 func (s *BusinessToPayBillService) SetRequester(msisdn string) *BusinessToPayBillService {
 	s.requester = msisdn
@@ -90,15 +151,15 @@ func (s *BusinessToPayBillService) SetOccasion(o string) *BusinessToPayBillServi
 	return s
 }
 
-// SetQueueTimeoutURL updates the config queue timeout URL.
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
 func (s *BusinessToPayBillService) SetQueueTimeoutURL(url string) *BusinessToPayBillService {
-	s.Config.SetQueueTimeoutURL(url)
+	s.queueTimeoutURL = url
 	return s
 }
 
-// SetResultURL updates the config result URL.
+// SetResultURL sets the result URL for this service, overriding the config value.
 func (s *BusinessToPayBillService) SetResultURL(url string) *BusinessToPayBillService {
-	s.Config.SetResultURL(url)
+	s.resultURL = url
 	return s
 }
 
@@ -108,45 +169,82 @@ func (s *BusinessToPayBillService) Send() (map[string]any, error) {
 	if s.initiator == "" {
 		return nil, errors.New("initiator is required")
 	}
-	if s.Config.GetSecurityCredential() == "" {
-		return nil, errors.New("security credential is required; call SetSecurityCredential")
+	if s.getSecurityCredential() == "" {
+		return nil, errors.New("security credential is required; call SetInitiatorPassword or SetEncryptedSecurityCredential")
 	}
 	if s.amount <= 0 {
 		return nil, errors.New("amount must be greater than 0")
 	}
+	if _, err := roundAmount(s.amount, s.rounding); err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
 	if s.partyA == "" && s.Config.GetBusinessCode() == "" {
 		return nil, errors.New("partyA (business shortcode) is required")
 	}
 	if s.partyB == "" {
 		return nil, errors.New("partyB (destination shortcode/paybill) is required")
 	}
+	if err := validateLength("account reference", s.getAccountReference(), 1, MaxB2BAccountReferenceLength); err != nil {
+		return nil, err
+	}
+	if err := validateIdentifierType("sender identifier type", s.senderIdentifierType); err != nil {
+		return nil, err
+	}
+	if err := validateIdentifierType("receiver identifier type", s.recipientIdentifierType); err != nil {
+		return nil, err
+	}
+
+	if err := validateCallbackURL("queue timeout URL", s.getQueueTimeoutURL(), "call SetQueueTimeoutURL on the service or config", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+	if err := validateCallbackURL("result URL", s.getResultURL(), "call SetResultURL on the service or config", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+
+	originatorID, err := s.ensureOriginatorConversationID()
+	if err != nil {
+		return nil, err
+	}
 
 	req := B2BRequest{
-		Initiator:              s.initiator,
-		SecurityCredential:     s.Config.GetSecurityCredential(),
-		CommandID:              s.commandID,
-		SenderIdentifierType:   s.senderIdentifierType,
-		RecieverIdentifierType: s.recipientIdentifierType,
-		Amount:                 s.amount,
-		PartyA:                 s.getPartyA(),
-		PartyB:                 s.partyB,
-		AccountReference:       s.accountReference,
-		Requester:              s.requester,
-		Remarks:                s.remarks,
-		QueueTimeOutURL:        s.Config.GetQueueTimeoutURL(),
-		ResultURL:              s.Config.GetResultURL(),
-		Occasion:               s.occasion,
+		OriginatorConversationID: originatorID,
+		Initiator:                s.initiator,
+		SecurityCredential:       s.getSecurityCredential(),
+		CommandID:                s.commandID,
+		SenderIdentifierType:     s.senderIdentifierType,
+		RecieverIdentifierType:   s.recipientIdentifierType,
+		Amount:                   s.amount,
+		Rounding:                 s.rounding,
+		PartyA:                   s.getPartyA(),
+		PartyB:                   s.partyB,
+		AccountReference:         s.getAccountReference(),
+		Requester:                s.requester,
+		Remarks:                  s.remarks,
+		QueueTimeOutURL:          s.queueTimeoutURL,
+		ResultURL:                s.resultURL,
+		Occasion:                 s.occasion,
 	}
 
 	resp, err := ExecuteB2BRequest(s.Config, s.Client, req)
 	if err != nil {
+		// The ID is kept, so that a retry carries the ID of the request that may have reached M-Pesa.
 		return nil, err
 	}
+	s.originatorID = ""
 
 	s.response = resp
+	s.typedResponse = NewB2BSendResponse(resp)
 	return resp, nil
 }
 
+// SendTyped sends the payment like Send and returns the decoded acknowledgement.
+func (s *BusinessToPayBillService) SendTyped() (*B2BSendResponse, error) {
+	if _, err := s.Send(); err != nil {
+		return nil, err
+	}
+	return s.typedResponse, nil
+}
+
 // ParseCallback parses a received callback payload using the shared ParseB2BCallback helper.
 func (s *BusinessToPayBillService) ParseCallback(payload map[string]any) (*B2BCallbackResult, error) {
 	return ParseB2BCallback(payload)
@@ -159,7 +257,81 @@ func (s *BusinessToPayBillService) getPartyA() string {
 	return s.Config.GetBusinessCode()
 }
 
+func (s *BusinessToPayBillService) getQueueTimeoutURL() string {
+	return chooseString(s.queueTimeoutURL, s.Config.GetQueueTimeoutURL())
+}
+
+func (s *BusinessToPayBillService) getResultURL() string {
+	return chooseString(s.resultURL, s.Config.GetResultURL())
+}
+
+func (s *BusinessToPayBillService) getSecurityCredential() string {
+	return chooseString(s.securityCredential, s.Config.GetSecurityCredential())
+}
+
+func (s *BusinessToPayBillService) getAccountReference() string {
+	if s.truncateRef {
+		return truncateRunes(s.accountReference, MaxB2BAccountReferenceLength)
+	}
+	return s.accountReference
+}
+
+// SetOriginatorConversationID sets the OriginatorConversationID sent with the next payment request, which
+// M-Pesa echoes in the result. It applies until a request with it is answered; when unset, a random UUID is
+// generated for each request.
+func (s *BusinessToPayBillService) SetOriginatorConversationID(id string) *BusinessToPayBillService {
+	s.originatorID = id
+	return s
+}
+
+// GetOriginatorConversationID returns the OriginatorConversationID the next Send uses, generating one if
+// necessary, so that it can be persisted before the request goes out.
+func (s *BusinessToPayBillService) GetOriginatorConversationID() (string, error) {
+	return s.ensureOriginatorConversationID()
+}
+
+// ensureOriginatorConversationID returns the next request's OriginatorConversationID, generating one if unset.
+func (s *BusinessToPayBillService) ensureOriginatorConversationID() (string, error) {
+	if s.originatorID == "" {
+		id, err := newUUID()
+		if err != nil {
+			return "", err
+		}
+		s.originatorID = id
+	}
+	return s.originatorID, nil
+}
+
 // GetResponse returns the last API response stored by the service.
 func (s *BusinessToPayBillService) GetResponse() map[string]any {
 	return s.response
 }
+
+// GetTypedResponse returns the decoded acknowledgement from the last request, or nil.
+func (s *BusinessToPayBillService) GetTypedResponse() *B2BSendResponse {
+	return s.typedResponse
+}
+
+// SetResultCorrelator sets the correlator SendAndWait waits for the result with; see WithResultCorrelator.
+func (s *BusinessToPayBillService) SetResultCorrelator(correlator ResultCorrelator) *BusinessToPayBillService {
+	s.waiter.correlator = correlator
+	return s
+}
+
+// SetStatusFallback makes SendAndWait query the transaction status with status when the result does not
+// arrive in time, waiting up to wait for the status result (DefaultStatusFallbackWait when zero or negative).
+func (s *BusinessToPayBillService) SetStatusFallback(status *TransactionStatusService, wait time.Duration) *BusinessToPayBillService {
+	s.waiter.setStatusFallback(status, wait)
+	return s
+}
+
+// SendAndWait sends the payment with Send and waits until its result arrives or ctx is done. Result is set to
+// a *B2BCallbackResult; ErrResultTimeout is returned when no result arrives in time. The
+// OriginatorConversationID is registered before the request is sent, and is what the status fallback queries.
+func (s *BusinessToPayBillService) SendAndWait(ctx context.Context) (*CorrelatedResult, error) {
+	originatorID, err := s.ensureOriginatorConversationID()
+	if err != nil {
+		return nil, err
+	}
+	return s.waiter.sendAndWait(ctx, originatorID, s.Send)
+}
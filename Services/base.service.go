@@ -3,11 +3,9 @@ package Services
 import (
 	"encoding/base64"
 	"errors"
-	_ "fmt"
+	"fmt"
 	"github.com/venomous-maker/go-mpesa/Abstracts"
-	"regexp"
 	"strings"
-	"time"
 )
 
 // BaseService provides common functionality shared across all M-Pesa service implementations.
@@ -15,6 +13,8 @@ import (
 type BaseService struct {
 	Config *Abstracts.MpesaConfig   // M-Pesa configuration containing credentials and settings
 	Client Abstracts.MpesaInterface // HTTP client interface for making API requests
+
+	clock Abstracts.Clock // Source of the current time for timestamps and passwords
 }
 
 // NewBaseService creates a new base service instance with the provided configuration and client.
@@ -36,7 +36,30 @@ func NewBaseService(cfg *Abstracts.MpesaConfig, client Abstracts.MpesaInterface)
 	return &BaseService{
 		Config: cfg,
 		Client: client,
+		clock:  Abstracts.SystemClock{},
+	}
+}
+
+// SetClock sets the clock timestamps and passwords are generated from, e.g. an
+// Abstracts.FakeClock in tests. A nil clock restores the system clock.
+//
+// Parameters:
+//   - clock: The clock to use
+//
+// Returns:
+//   - *BaseService: Returns self for method chaining
+//
+// Example:
+//
+//	clock := Abstracts.NewFakeClock(time.Date(2024, 8, 12, 14, 30, 22, 0, time.Local))
+//	baseService.SetClock(clock)
+//	timestamp := baseService.GenerateTimestamp() // "20240812143022"
+func (b *BaseService) SetClock(clock Abstracts.Clock) *BaseService {
+	if clock == nil {
+		clock = Abstracts.SystemClock{}
 	}
+	b.clock = clock
+	return b
 }
 
 // GenerateTimestamp returns the current timestamp in M-Pesa required format.
@@ -51,7 +74,7 @@ func NewBaseService(cfg *Abstracts.MpesaConfig, client Abstracts.MpesaInterface)
 //	timestamp := baseService.GenerateTimestamp()
 //	// Returns: "20240812143022" (for Aug 12, 2024 at 14:30:22)
 func (b *BaseService) GenerateTimestamp() string {
-	return time.Now().Format("20060102150405")
+	return b.clock.Now().Format("20060102150405")
 }
 
 // GeneratePassword creates a base64-encoded password for M-Pesa API authentication.
@@ -73,7 +96,7 @@ func (b *BaseService) GeneratePassword() string {
 
 // CleanPhoneNumber formats and validates a phone number for M-Pesa API compatibility.
 // The method accepts various phone number formats and converts them to the standard
-// international format required by M-Pesa APIs.
+// international format required by M-Pesa APIs. It wraps Abstracts.NormalizeMSISDN.
 //
 // Parameters:
 //   - phone: The phone number in various formats (0711223344, 254711223344, +254711223344)
@@ -81,7 +104,8 @@ func (b *BaseService) GeneratePassword() string {
 //
 // Returns:
 //   - string: The cleaned and formatted phone number in international format
-//   - error: An error if the phone number is invalid or cannot be formatted
+//   - error: An error if the phone number is invalid or cannot be formatted, wrapping one of
+//     the Abstracts.ErrMSISDN errors
 //
 // Example:
 //
@@ -89,32 +113,63 @@ func (b *BaseService) GeneratePassword() string {
 //	cleaned, err := baseService.CleanPhoneNumber("0711223344", "254")    // Returns: "254711223344"
 //	cleaned, err := baseService.CleanPhoneNumber("254711223344", "254")  // Returns: "254711223344"
 //	cleaned, err := baseService.CleanPhoneNumber("+254711223344", "254") // Returns: "254711223344"
+//	cleaned, err := baseService.CleanPhoneNumber("711223344", "254")     // Returns: "254711223344"
+//	cleaned, err := baseService.CleanPhoneNumber("71122334", "254")      // Error: too short
 //
 //	if err != nil {
 //	    log.Printf("Invalid phone number: %v", err)
 //	    return
 //	}
 func (b *BaseService) CleanPhoneNumber(phone, countryCode string) (string, error) {
-	if strings.TrimSpace(phone) == "" {
-		return "", errors.New("phone number cannot be empty")
-	}
-
-	phone = strings.TrimSpace(phone)
+	return Abstracts.NormalizeMSISDN(phone, countryCode)
+}
 
-	if len(phone) < 9 {
-		return "", errors.New("phone number is too short")
+// normalizePhone normalizes a phone number with Abstracts.NormalizeMSISDN and checks that the
+// result is a mobile number in countryCode, the config's default country code: for 254, a
+// Kenyan mobile number.
+func normalizePhone(phone, countryCode string) (string, error) {
+	cleaned, err := Abstracts.NormalizeMSISDN(phone, countryCode)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(cleaned, countryCode) {
+		if countryCode == Abstracts.DefaultCountryCode {
+			return "", errors.New("phone number must be a Kenyan mobile number in the format 2547XXXXXXXX or 2541XXXXXXXX")
+		}
+		return "", fmt.Errorf("phone number must be in country code %s", countryCode)
+	}
+	if err := Abstracts.ValidateMSISDN(cleaned); err != nil {
+		return "", err
 	}
+	return cleaned, nil
+}
 
-	if strings.HasPrefix(phone, "+") {
-		// Remove leading '+' and non-digit characters
-		return regexp.MustCompile(`\D`).ReplaceAllString(phone[1:], ""), nil
+// checkPhonePrefixes applies the strict validation enabled by the SetStrictPhonePrefixes
+// methods; prefixes is nil while it is off.
+func checkPhonePrefixes(phone string, prefixes []string) error {
+	if prefixes == nil {
+		return nil
 	}
+	return Abstracts.ValidateMSISDNStrict(phone, prefixes...)
+}
 
-	if strings.HasPrefix(phone, "0") {
-		// Replace leading 0 with country code
-		return countryCode + regexp.MustCompile(`\D`).ReplaceAllString(phone[1:], ""), nil
+// checkPhoneValidator runs the custom check set with a service's SetPhoneValidator, or else
+// the one set on the config, if any.
+func checkPhoneValidator(phone string, cfg *Abstracts.MpesaConfig, validator Abstracts.PhoneValidator) error {
+	if validator == nil {
+		validator = cfg.GetPhoneValidator()
+	}
+	if validator == nil {
+		return nil
 	}
+	return validator.Validate(phone)
+}
 
-	// Clean all non-digit characters
-	return regexp.MustCompile(`\D`).ReplaceAllString(phone, ""), nil
+// strictPhonePrefixes returns the prefixes for SetStrictPhonePrefixes, defaulting to
+// Abstracts.DefaultMSISDNPrefixes.
+func strictPhonePrefixes(prefixes []string) []string {
+	if len(prefixes) == 0 {
+		prefixes = Abstracts.DefaultMSISDNPrefixes
+	}
+	return append([]string(nil), prefixes...)
 }
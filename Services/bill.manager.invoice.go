@@ -0,0 +1,235 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxInvoicesPerRequest is the largest number of invoices Daraja accepts in one bulk invoicing
+// request. SendInvoices splits longer lists into several requests.
+const MaxInvoicesPerRequest = 1000
+
+// invoiceDateLayout is the format of the dueDate invoice field.
+const invoiceDateLayout = "2006-01-02 15:04:05.00"
+
+// ErrInvalidInvoice is wrapped by every validation error reported by SendInvoices, so
+// errors.Is(err, ErrInvalidInvoice) identifies a batch rejected before anything was sent.
+var ErrInvalidInvoice = errors.New("invalid invoice")
+
+// ErrInvoiceNotFound is returned by CancelInvoice and UpdateInvoice when Bill Manager has no
+// open invoice with the given external reference.
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// invoiceNotFoundResCode is the rescode Bill Manager returns for an unknown external reference.
+const invoiceNotFoundResCode = "404"
+
+// Invoice is an e-invoice sent to a customer through Bill Manager.
+type Invoice struct {
+	ExternalReference string        // Unique reference of the invoice in your system
+	BilledFullName    string        // Name of the customer
+	BilledPhoneNumber string        // Phone number that receives the invoice
+	BilledPeriod      string        // Period being billed, e.g. "August 2021"
+	InvoiceName       string        // Descriptive name of the invoice
+	DueDate           time.Time     // Date the invoice is due
+	AccountReference  string        // Account number the customer pays to
+	Amount            float64       // Total amount in KES
+	InvoiceItems      []InvoiceItem // Optional line items
+}
+
+// InvoiceItem is a line item of an Invoice.
+type InvoiceItem struct {
+	ItemName string
+	Amount   float64
+}
+
+// InvoiceUpdate holds the changes made to an open invoice by UpdateInvoice.
+// Zero fields are left unchanged.
+type InvoiceUpdate struct {
+	Amount  float64   // New total amount in KES
+	DueDate time.Time // New due date
+}
+
+// SendInvoices sends invoices to /v1/billmanager-invoice/bulk-invoicing, split into requests of
+// at most MaxInvoicesPerRequest invoices. Every invoice is validated before anything is sent;
+// all problems are returned together, each prefixed with the index of the invoice. Sending stops
+// at the first chunk that fails or is rejected, or when ctx is cancelled between chunks; the
+// responses received so far are returned along with the error.
+func (s *BillManagerService) SendInvoices(ctx context.Context, invoices []Invoice) ([]*BillManagerResponse, error) {
+	if len(invoices) == 0 {
+		return nil, errors.New("at least one invoice is required")
+	}
+	payloads, err := invoicePayloads(invoices, s.Config.GetDefaultCountryCode())
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*BillManagerResponse
+	for first := 0; first < len(payloads); first += MaxInvoicesPerRequest {
+		if err := ctx.Err(); err != nil {
+			return responses, err
+		}
+		last := min(first+MaxInvoicesPerRequest, len(payloads))
+		chunk := first/MaxInvoicesPerRequest + 1
+
+		resp, err := s.send(payloads[first:last], s.Config.Endpoints.BillManagerBulk)
+		if err != nil {
+			return responses, fmt.Errorf("invoice chunk %d (invoices %d-%d): %w", chunk, first, last-1, err)
+		}
+		responses = append(responses, resp)
+		if !resp.Succeeded() {
+			return responses, fmt.Errorf("invoice chunk %d (invoices %d-%d) was rejected: rescode %s: %s", chunk, first, last-1, resp.ResCode, resp.ResMsg)
+		}
+	}
+	return responses, nil
+}
+
+// invoicePayloads validates every invoice and converts them to request payloads, reading
+// local billed phone numbers in countryCode.
+func invoicePayloads(invoices []Invoice, countryCode string) ([]any, error) {
+	var errs []error
+	payloads := make([]any, len(invoices))
+	references := make(map[string]int, len(invoices))
+	for i, inv := range invoices {
+		payload, fieldErrs := inv.payload(countryCode)
+		for _, err := range fieldErrs {
+			errs = append(errs, fmt.Errorf("invoice %d: %w", i, err))
+		}
+		if len(fieldErrs) > 0 {
+			continue
+		}
+		if j, ok := references[inv.ExternalReference]; ok {
+			errs = append(errs, fmt.Errorf("invoice %d: %w: external reference %q is also used by invoice %d", i, ErrInvalidInvoice, inv.ExternalReference, j))
+			continue
+		}
+		references[inv.ExternalReference] = i
+		payloads[i] = payload
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return payloads, nil
+}
+
+// payload validates the invoice and returns its request payload, or every field error.
+func (inv Invoice) payload(countryCode string) (map[string]any, []error) {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidInvoice}, args...)...))
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"external reference", inv.ExternalReference},
+		{"billed full name", inv.BilledFullName},
+		{"billed period", inv.BilledPeriod},
+		{"invoice name", inv.InvoiceName},
+		{"account reference", inv.AccountReference},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			invalid("%s is required", f.name)
+		}
+	}
+	phone, err := normalizePhone(inv.BilledPhoneNumber, countryCode)
+	if err != nil {
+		invalid("billed phone number: %v", err)
+	}
+	if inv.DueDate.IsZero() {
+		invalid("due date is required")
+	}
+	amount, err := billManagerAmount(inv.Amount)
+	if err != nil {
+		invalid("%v", err)
+	}
+
+	items := make([]map[string]any, 0, len(inv.InvoiceItems))
+	for j, item := range inv.InvoiceItems {
+		if strings.TrimSpace(item.ItemName) == "" {
+			invalid("item %d: item name is required", j)
+		}
+		itemAmount, err := billManagerAmount(item.Amount)
+		if err != nil {
+			invalid("item %d: %v", j, err)
+		}
+		items = append(items, map[string]any{"itemName": item.ItemName, "amount": itemAmount})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	payload := map[string]any{
+		"externalReference": inv.ExternalReference,
+		"billedFullName":    inv.BilledFullName,
+		"billedPhoneNumber": phone,
+		"billedPeriod":      inv.BilledPeriod,
+		"invoiceName":       inv.InvoiceName,
+		"dueDate":           inv.DueDate.In(mpesaLocation).Format(invoiceDateLayout),
+		"accountReference":  inv.AccountReference,
+		"amount":            amount,
+	}
+	if len(items) > 0 {
+		payload["invoiceItems"] = items
+	}
+	return payload, nil
+}
+
+// CancelInvoice cancels the open invoice with the given external reference. ErrInvoiceNotFound
+// is returned, along with the response, when Bill Manager does not know the reference.
+func (s *BillManagerService) CancelInvoice(externalReference string) (*BillManagerResponse, error) {
+	externalReference = strings.TrimSpace(externalReference)
+	if externalReference == "" {
+		return nil, errors.New("external reference is required")
+	}
+
+	data := map[string]any{
+		"externalReference": externalReference,
+	}
+
+	resp, err := s.send(data, s.Config.Endpoints.BillManagerCancel)
+	if err != nil {
+		return nil, err
+	}
+	return resp, invoiceResponseError(resp, externalReference)
+}
+
+// UpdateInvoice changes the amount and/or due date of the open invoice with the given external
+// reference. ErrInvoiceNotFound is returned, along with the response, when Bill Manager does not
+// know the reference.
+func (s *BillManagerService) UpdateInvoice(externalReference string, changes InvoiceUpdate) (*BillManagerResponse, error) {
+	externalReference = strings.TrimSpace(externalReference)
+	if externalReference == "" {
+		return nil, errors.New("external reference is required")
+	}
+	if changes.Amount == 0 && changes.DueDate.IsZero() {
+		return nil, errors.New("no changes to apply; set InvoiceUpdate.Amount or InvoiceUpdate.DueDate")
+	}
+
+	data := map[string]any{
+		"externalReference": externalReference,
+	}
+	if changes.Amount != 0 {
+		amount, err := billManagerAmount(changes.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount: %w", err)
+		}
+		data["amount"] = amount
+	}
+	if !changes.DueDate.IsZero() {
+		data["dueDate"] = changes.DueDate.In(mpesaLocation).Format(invoiceDateLayout)
+	}
+
+	resp, err := s.send(data, s.Config.Endpoints.BillManagerUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return resp, invoiceResponseError(resp, externalReference)
+}
+
+// invoiceResponseError maps a not-found rescode to ErrInvoiceNotFound.
+func invoiceResponseError(resp *BillManagerResponse, externalReference string) error {
+	if resp.ResCode == invoiceNotFoundResCode {
+		return fmt.Errorf("%w: %q", ErrInvoiceNotFound, externalReference)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// billManagerAck is the acknowledgement Bill Manager expects from the payment callback URL.
+var billManagerAck = []byte(`{"rescode":"200","resmsg":"Success"}`)
+
+// CallbackBillManagerPayment identifies Bill Manager payment notifications, which are not
+// mounted by CallbackMux, e.g. to the raw persist hook.
+const CallbackBillManagerPayment CallbackType = "bill_manager_payment"
+
+// BillManagerPayment is a payment notification posted by Bill Manager to the callback URL
+// registered with Onboard when a customer pays an invoice.
+type BillManagerPayment struct {
+	TransactionID    string    // M-Pesa receipt number
+	PaidAmount       float64   // Amount paid
+	Msisdn           string    // Phone number of the payer
+	DateCreated      time.Time // Payment date (EAT)
+	AccountReference string    // Account reference of the paid invoice
+	ShortCode        string    // Paybill that received the payment
+
+	// Optional details sent back by AcknowledgePayment; not part of the notification.
+	FullName          string // Name of the payer
+	InvoiceName       string // Name of the paid invoice
+	ExternalReference string // External reference of the paid invoice
+
+	Raw map[string]any // The original payload
+}
+
+// ParseBillManagerPayment parses a Bill Manager payment notification. Values may be strings
+// or numbers and keys are matched case-insensitively; malformed amounts and dates are left at
+// their zero value.
+func ParseBillManagerPayment(payload map[string]any) (*BillManagerPayment, error) {
+	p := &BillManagerPayment{
+		TransactionID:    responseString(payload, "transactionId"),
+		PaidAmount:       parseAmount(responseString(payload, "paidAmount")),
+		Msisdn:           responseString(payload, "msisdn"),
+		DateCreated:      parseBillManagerDate(responseString(payload, "dateCreated")),
+		AccountReference: responseString(payload, "accountReference"),
+		ShortCode:        responseString(payload, "shortCode"),
+		Raw:              payload,
+	}
+	if p.TransactionID == "" {
+		return nil, errors.New("invalid payment notification: transactionId is missing")
+	}
+	return p, nil
+}
+
+// BillManagerPaymentHandler returns an http.HandlerFunc for the Bill Manager callback URL.
+// Notifications are parsed with ParseBillManagerPayment, passed to onPayment and acknowledged
+// with {"rescode":"200","resmsg":"Success"}. Only POST requests are accepted, and bodies are
+// limited in size and read time; see WithMaxBodyBytes, WithReadTimeout and WithErrorHandler.
+// Acknowledging the payment to Bill Manager with AcknowledgePayment is left to onPayment.
+func BillManagerPaymentHandler(onPayment func(*BillManagerPayment), opts ...HandlerOption) http.HandlerFunc {
+	return BillManagerPaymentHandlerContext(ignoreContext(onPayment), opts...)
+}
+
+// BillManagerPaymentHandlerContext is BillManagerPaymentHandler with a function that also
+// receives the context of the request, bounded by the handler timeout; see WithTimeout.
+func BillManagerPaymentHandlerContext(onPayment func(context.Context, *BillManagerPayment), opts ...HandlerOption) http.HandlerFunc {
+	o := newHandlerOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, raw, rejected, err := o.readWebhookPayload(w, r, CallbackBillManagerPayment, "")
+		if rejected {
+			return
+		}
+		if err != nil {
+			o.fail(w, r, CallbackBillManagerPayment, raw, err, billManagerAck)
+			return
+		}
+
+		payment, err := ParseBillManagerPayment(payload)
+		if err != nil {
+			o.fail(w, r, CallbackBillManagerPayment, raw, err, billManagerAck)
+			return
+		}
+		if onPayment != nil {
+			if err := o.call(r, func(ctx context.Context) { onPayment(ctx, payment) }); err != nil {
+				o.failCallback(w, r, err, billManagerAck)
+				return
+			}
+		}
+		o.ack(w, billManagerAck)
+	}
+}
+
+// AcknowledgePayment confirms a payment to Bill Manager through the reconciliation API, so the
+// invoice is marked as paid and the customer receives a receipt.
+func (s *BillManagerService) AcknowledgePayment(p BillManagerPayment) (*BillManagerResponse, error) {
+	// Validate required fields
+	if p.TransactionID == "" {
+		return nil, errors.New("transaction ID is required")
+	}
+	if p.AccountReference == "" {
+		return nil, errors.New("account reference is required")
+	}
+	amount, err := billManagerAmount(p.PaidAmount)
+	if err != nil {
+		return nil, err
+	}
+	if p.DateCreated.IsZero() {
+		return nil, errors.New("payment date is required")
+	}
+
+	data := map[string]any{
+		"transactionId":     p.TransactionID,
+		"paidAmount":        amount,
+		"phoneNumber":       p.Msisdn,
+		"paymentDate":       p.DateCreated.In(mpesaLocation).Format("2006-01-02"),
+		"accountReference":  p.AccountReference,
+		"fullName":          p.FullName,
+		"invoiceName":       p.InvoiceName,
+		"externalReference": p.ExternalReference,
+	}
+
+	return s.send(data, s.Config.Endpoints.BillManagerRecon)
+}
+
+// parseBillManagerDate parses the "yyyy-MM-dd" dates, optionally with a time, used by Bill Manager.
+func parseBillManagerDate(v string) time.Time {
+	v = strings.TrimSpace(v)
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, v, mpesaLocation); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
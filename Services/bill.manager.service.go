@@ -0,0 +1,117 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// BillManagerService talks to the Bill Manager APIs, which send e-invoices to customers and
+// reconcile their payments. A shortcode must be onboarded with Onboard before invoicing.
+type BillManagerService struct {
+	Config        *abstracts.MpesaConfig
+	Client        abstracts.MpesaInterface
+	response      map[string]any
+	typedResponse *BillManagerResponse
+}
+
+// OnboardParams holds the details sent when opting a shortcode in to Bill Manager.
+type OnboardParams struct {
+	ShortCode       string // Paybill to onboard; defaults to the config business code
+	Email           string // Official contact email shown on invoices
+	OfficialContact string // Official contact phone number shown on invoices
+	SendReminders   bool   // Whether M-Pesa sends payment reminders for unpaid invoices
+	Logo            string // Logo shown on invoices (image URL or data)
+	CallbackURL     string // URL that receives payment notifications
+}
+
+// BillManagerResponse is the response of the Bill Manager APIs.
+type BillManagerResponse struct {
+	AppKey  string `mpesa:"app_key|appKey"`  // app_key issued on onboarding; identifies the biller in later calls
+	ResMsg  string `mpesa:"resmsg|resMsg"`   // resmsg, e.g. "Success"
+	ResCode string `mpesa:"rescode|resCode"` // rescode, "200" on success
+}
+
+// NewBillManagerResponse decodes a Bill Manager response from a raw API response.
+func NewBillManagerResponse(resp map[string]any) *BillManagerResponse {
+	r, _ := abstracts.DecodeInto[BillManagerResponse](resp)
+	return &r
+}
+
+// Succeeded reports whether Bill Manager accepted the request (rescode "200").
+func (r *BillManagerResponse) Succeeded() bool {
+	return r != nil && r.ResCode == "200"
+}
+
+// NewBillManagerService creates a new Bill Manager service instance.
+func NewBillManagerService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *BillManagerService {
+	return &BillManagerService{
+		Config: cfg,
+		Client: client,
+	}
+}
+
+// Onboard opts a shortcode in to Bill Manager. It only needs to be called once per shortcode.
+func (s *BillManagerService) Onboard(params OnboardParams) (*BillManagerResponse, error) {
+	// Validate required fields
+	shortCode := chooseString(strings.TrimSpace(params.ShortCode), s.Config.GetBusinessCode())
+	if shortCode == "" {
+		return nil, errors.New("shortcode is required; set OnboardParams.ShortCode or call SetBusinessCode on mpesa config")
+	}
+	if !numericPattern.MatchString(shortCode) {
+		return nil, fmt.Errorf("invalid shortcode %q: must be numeric", shortCode)
+	}
+	if err := validateEmail("email", params.Email); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(params.OfficialContact) == "" {
+		return nil, errors.New("official contact is required; set OnboardParams.OfficialContact")
+	}
+	contact, err := normalizePhone(params.OfficialContact, s.Config.GetDefaultCountryCode())
+	if err != nil {
+		return nil, fmt.Errorf("invalid official contact: %w", err)
+	}
+	if err := validateCallbackURL("callback URL", params.CallbackURL, "set OnboardParams.CallbackURL", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+
+	sendReminders := "0"
+	if params.SendReminders {
+		sendReminders = "1"
+	}
+
+	data := map[string]any{
+		"shortcode":       shortCode,
+		"email":           strings.TrimSpace(params.Email),
+		"officialContact": contact,
+		"sendReminders":   sendReminders,
+		"logo":            params.Logo,
+		"callbackurl":     params.CallbackURL,
+	}
+
+	return s.send(data, s.Config.Endpoints.BillManagerOptIn)
+}
+
+// GetResponse returns the last API response stored by the service.
+func (s *BillManagerService) GetResponse() map[string]any {
+	return s.response
+}
+
+// GetTypedResponse returns the decoded response from the last request, or nil.
+func (s *BillManagerService) GetTypedResponse() *BillManagerResponse {
+	return s.typedResponse
+}
+
+// send posts data to endpoint and stores the raw and decoded responses.
+func (s *BillManagerService) send(data any, endpoint string) (*BillManagerResponse, error) {
+	resp, err := s.Client.ExecuteRequest(data, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	s.typedResponse = NewBillManagerResponse(resp)
+	return s.typedResponse, nil
+}
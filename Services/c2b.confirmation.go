@@ -0,0 +1,156 @@
+package Services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hashedMSISDNPattern matches the SHA-256 hex digest Safaricom now sends instead of the clear MSISDN.
+var hashedMSISDNPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// C2BConfirmation is the payload M-Pesa posts to the C2B confirmation (and validation) URL.
+type C2BConfirmation struct {
+	TransactionType   string    `json:"TransactionType"`   // e.g. "Pay Bill" or "Buy Goods"
+	TransID           string    `json:"TransID"`           // M-Pesa receipt number
+	TransTime         time.Time `json:"TransTime"`         // Transaction time (EAT)
+	TransAmount       float64   `json:"TransAmount"`       // Amount paid
+	BusinessShortCode string    `json:"BusinessShortCode"` // Shortcode that received the payment
+	BillRefNumber     string    `json:"BillRefNumber"`     // Account number entered by the customer
+	InvoiceNumber     string    `json:"InvoiceNumber"`     // Invoice number, if any
+	OrgAccountBalance float64   `json:"OrgAccountBalance"` // Organization balance after the payment (confirmation only)
+	ThirdPartyTransID string    `json:"ThirdPartyTransID"` // ID returned by the validation response, if any
+	MSISDN            string    `json:"MSISDN"`            // Customer phone number, or its hash (see MSISDNHashed)
+	MSISDNHashed      bool      `json:"MSISDNHashed"`      // true when MSISDN is a SHA-256 hash rather than a phone number
+	FirstName         string    `json:"FirstName"`
+	MiddleName        string    `json:"MiddleName"`
+	LastName          string    `json:"LastName"`
+
+	Raw map[string]any `json:"-"` // The original payload
+}
+
+// ParseC2BConfirmation decodes a C2B confirmation or validation request body.
+// Amounts are accepted as strings or numbers, TransTime is parsed from the yyyyMMddHHmmss
+// format in EAT, and hashed MSISDNs are flagged with MSISDNHashed. json.Unmarshal into a
+// C2BConfirmation decodes the body the same way.
+//
+// Parameters:
+//   - r: The request body
+//
+// Returns:
+//   - *C2BConfirmation: The parsed payload
+//   - error: An error if the body is not a JSON object or has no TransID
+//
+// Example:
+//
+//	func confirmation(w http.ResponseWriter, r *http.Request) {
+//	    c, err := Services.ParseC2BConfirmation(r.Body)
+//	    if err != nil {
+//	        log.Printf("bad confirmation: %v", err)
+//	    }
+//	    // ...
+//	}
+func ParseC2BConfirmation(r io.Reader) (*C2BConfirmation, error) {
+	var payload map[string]any
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid C2B payload: %w", err)
+	}
+	return newC2BConfirmation(payload)
+}
+
+// MarshalJSON encodes the confirmation in the schema SchemaC2BConfirmation.
+func (c C2BConfirmation) MarshalJSON() ([]byte, error) {
+	type plain C2BConfirmation
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaC2BConfirmation, plain(c), marshaledRaw(c.Raw)})
+}
+
+// UnmarshalJSON decodes a C2B confirmation or validation request body as ParseC2BConfirmation
+// does, converting amounts and TransTime and flagging hashed MSISDNs, or the JSON written by
+// MarshalJSON.
+func (c *C2BConfirmation) UnmarshalJSON(data []byte) error {
+	type plain C2BConfirmation
+	if ok, err := decodeNormalized(data, SchemaC2BConfirmation, (*plain)(c), &c.Raw); ok {
+		c.TransTime = inEAT(c.TransTime)
+		return err
+	}
+
+	if err := c.decode(data); err != nil {
+		return err
+	}
+	c.Raw = rawPayload(data)
+	return nil
+}
+
+// newC2BConfirmation converts a decoded payload into a C2BConfirmation.
+func newC2BConfirmation(payload map[string]any) (*C2BConfirmation, error) {
+	if payload == nil {
+		return nil, errors.New("invalid C2B payload: empty body")
+	}
+	c := &C2BConfirmation{}
+	if err := decodePayload(payload, c.decode); err != nil {
+		return nil, err
+	}
+	c.Raw = payload
+	return c, nil
+}
+
+// decode fills every field but Raw from a C2B request body.
+func (c *C2BConfirmation) decode(data []byte) error {
+	var payload struct {
+		TransactionType   flexString
+		TransID           flexString
+		TransTime         flexString
+		TransAmount       flexString
+		BusinessShortCode flexString
+		BillRefNumber     flexString
+		InvoiceNumber     flexString
+		OrgAccountBalance flexString
+		ThirdPartyTransID flexString
+		MSISDN            flexString
+		FirstName         flexString
+		MiddleName        flexString
+		LastName          flexString
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("invalid C2B payload: %w", err)
+	}
+
+	*c = C2BConfirmation{
+		TransactionType:   string(payload.TransactionType),
+		TransID:           string(payload.TransID),
+		TransAmount:       parseAmount(string(payload.TransAmount)),
+		BusinessShortCode: string(payload.BusinessShortCode),
+		BillRefNumber:     string(payload.BillRefNumber),
+		InvoiceNumber:     string(payload.InvoiceNumber),
+		OrgAccountBalance: parseAmount(string(payload.OrgAccountBalance)),
+		ThirdPartyTransID: string(payload.ThirdPartyTransID),
+		MSISDN:            strings.TrimSpace(string(payload.MSISDN)),
+		FirstName:         string(payload.FirstName),
+		MiddleName:        string(payload.MiddleName),
+		LastName:          string(payload.LastName),
+	}
+	if c.TransID == "" {
+		return errors.New("invalid C2B payload: TransID is missing")
+	}
+	c.MSISDNHashed = hashedMSISDNPattern.MatchString(c.MSISDN)
+
+	transTime := strings.TrimSpace(string(payload.TransTime))
+	if transTime != "" {
+		t, err := time.ParseInLocation("20060102150405", transTime, mpesaLocation)
+		if err != nil {
+			return fmt.Errorf("invalid C2B payload: TransTime %q: %w", transTime, err)
+		}
+		c.TransTime = t
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package Services
+
+// C2BCommandID identifies the type of a simulated C2B transaction.
+type C2BCommandID string
+
+// C2B command IDs accepted by the simulate API.
+const (
+	CommandCustomerPayBillOnline  C2BCommandID = "CustomerPayBillOnline"  // Payment to a paybill number
+	CommandCustomerBuyGoodsOnline C2BCommandID = "CustomerBuyGoodsOnline" // Payment to a till number
+)
+
+// C2BResponseType tells M-Pesa what to do when the validation URL cannot be reached.
+type C2BResponseType string
+
+// C2B response types accepted by the register URL API.
+const (
+	ResponseTypeCompleted C2BResponseType = "Completed" // Complete the transaction
+	ResponseTypeCancelled C2BResponseType = "Cancelled" // Cancel the transaction
+)
+
+// c2bCommandIDs lists the valid C2B command IDs.
+var c2bCommandIDs = []C2BCommandID{CommandCustomerPayBillOnline, CommandCustomerBuyGoodsOnline}
+
+// c2bResponseTypes lists the valid C2B response types.
+var c2bResponseTypes = []C2BResponseType{ResponseTypeCompleted, ResponseTypeCancelled}
@@ -0,0 +1,162 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// c2bConfirmationAck is the acknowledgement M-Pesa expects from the confirmation URL.
+var c2bConfirmationAck = []byte(`{"ResultCode":0,"ResultDesc":"Success"}`)
+
+// C2BValidationHandler returns an http.HandlerFunc for the C2B validation URL. It parses the
+// request, calls fn and writes the returned C2BValidationResponse. If the body cannot be
+// parsed, fn panics or fn does not return within the handler timeout, the payment is rejected
+// with RejectOtherError and the cause is passed to the error handler, if any; internal error
+// text is never sent to M-Pesa. Under StrictAck such requests are answered with 400 or 500
+// instead. Only POST requests are accepted, and bodies are limited in size and read time; see
+// WithMaxBodyBytes, WithReadTimeout, WithTimeout and WithErrorHandler.
+//
+// Parameters:
+//   - fn: Business logic deciding whether to accept the payment
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/c2b/validation", Services.C2BValidationHandler(
+//	    func(c *Services.C2BConfirmation) Services.C2BValidationResponse {
+//	        if !invoiceExists(c.BillRefNumber) {
+//	            return Services.Reject(Services.RejectInvalidAccountNumber)
+//	        }
+//	        return Services.AcceptC2BValidation()
+//	    },
+//	))
+func C2BValidationHandler(fn func(*C2BConfirmation) C2BValidationResponse, opts ...HandlerOption) http.HandlerFunc {
+	return C2BValidationHandlerContext(func(_ context.Context, c *C2BConfirmation) C2BValidationResponse {
+		return fn(c)
+	}, opts...)
+}
+
+// C2BValidationHandlerContext is C2BValidationHandler with a function that also receives the
+// context of the request, bounded by the handler timeout; see WithTimeout. When it is
+// cancelled the payment is rejected, so fn can stop, e.g., an account lookup.
+func C2BValidationHandlerContext(fn func(context.Context, *C2BConfirmation) C2BValidationResponse, opts ...HandlerOption) http.HandlerFunc {
+	o := newHandlerOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, raw, rejected, err := o.readWebhookPayload(w, r, CallbackC2BValidation, "")
+		if rejected {
+			return
+		}
+
+		resp := Reject(RejectOtherError)
+		status := decodeStatus(err)
+		var validation *C2BConfirmation
+		if err == nil {
+			validation, err = o.parseC2BConfirmation(payload)
+		}
+		if err != nil {
+			o.parseFailed(r, CallbackC2BValidation, raw, err)
+		} else {
+			var result C2BValidationResponse
+			var completed bool
+			status = http.StatusInternalServerError
+			completed, err = o.invoke(r, func(ctx context.Context) error {
+				result = fn(ctx, validation)
+				return nil
+			})
+			if completed && err == nil {
+				resp = result
+			}
+		}
+		if err != nil && o.rejectStrict(w, r, err, status) {
+			return
+		}
+
+		body, _ := json.Marshal(resp)
+		writeWebhookBody(w, body)
+	}
+}
+
+// C2BConfirmationHandler returns an http.HandlerFunc for the C2B confirmation URL. It parses
+// the request, calls fn and acknowledges with {"ResultCode":0,"ResultDesc":"Success"}, at the
+// latest when the handler timeout elapses (fn then keeps running in the background). Parse
+// errors, errors returned by fn, panics and timeouts are passed to the error handler, if any,
+// and never sent to M-Pesa; the callback is still acknowledged unless StrictAck is set. Only
+// POST requests are accepted, and bodies are limited in size and read time; see
+// WithMaxBodyBytes, WithReadTimeout, WithTimeout and WithErrorHandler. Redelivered
+// confirmations can be deduplicated by TransID with WithProcessedStore.
+//
+// Parameters:
+//   - fn: Business logic recording the payment
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/c2b/confirmation", Services.C2BConfirmationHandler(
+//	    func(c *Services.C2BConfirmation) error {
+//	        return payments.Record(c.TransID, c.BillRefNumber, c.TransAmount)
+//	    },
+//	    Services.WithErrorHandler(func(err error, r *http.Request) { log.Print(err) }),
+//	))
+func C2BConfirmationHandler(fn func(*C2BConfirmation) error, opts ...HandlerOption) http.HandlerFunc {
+	return C2BConfirmationHandlerContext(func(_ context.Context, c *C2BConfirmation) error {
+		return fn(c)
+	}, opts...)
+}
+
+// C2BConfirmationHandlerContext is C2BConfirmationHandler with a function that also receives
+// the context of the request, bounded by the handler timeout; see WithTimeout.
+func C2BConfirmationHandlerContext(fn func(context.Context, *C2BConfirmation) error, opts ...HandlerOption) http.HandlerFunc {
+	o := newHandlerOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, raw, rejected, err := o.readWebhookPayload(w, r, CallbackC2BConfirmation, "")
+		if rejected {
+			return
+		}
+
+		if err != nil {
+			o.fail(w, r, CallbackC2BConfirmation, raw, err, c2bConfirmationAck)
+			return
+		}
+
+		confirmation, err := o.parseC2BConfirmation(payload)
+		if err != nil {
+			o.fail(w, r, CallbackC2BConfirmation, raw, err, c2bConfirmationAck)
+			return
+		}
+		if err := o.handleConfirmation(r, confirmation, fn); err != nil {
+			o.failCallback(w, r, err, c2bConfirmationAck)
+			return
+		}
+		o.ack(w, c2bConfirmationAck)
+	}
+}
+
+// handleConfirmation invokes fn for a confirmation, skipping transactions already recorded
+// in the processed store. A transaction is forgotten again when fn fails, so that a
+// redelivery is retried. Confirmations without a TransID cannot be told apart and are always
+// passed to fn.
+func (o *handlerOptions) handleConfirmation(r *http.Request, c *C2BConfirmation, fn func(context.Context, *C2BConfirmation) error) error {
+	dedupe := o.processed != nil && strings.TrimSpace(c.TransID) != ""
+	if dedupe && !o.processed.MarkProcessed(c.TransID, o.processedTTL) {
+		if o.onDuplicate != nil {
+			o.onDuplicate(c.TransID)
+		}
+		return nil
+	}
+
+	completed, err := o.invoke(r, func(ctx context.Context) error {
+		return fn(ctx, c)
+	})
+	if completed && err != nil && dedupe {
+		o.processed.Forget(c.TransID)
+	}
+	return err
+}
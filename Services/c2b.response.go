@@ -0,0 +1,67 @@
+package Services
+
+import (
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// C2BRegisterResponse is the response returned by the C2B register URL API.
+type C2BRegisterResponse struct {
+	OriginatorCoversationID string `mpesa:"OriginatorCoversationID|OriginatorConversationID"` // Unique request ID (Daraja spells the key without the "n")
+	ResponseCode            string // "0" on success
+	ResponseDescription     string // "Success" on success
+}
+
+// NewC2BRegisterResponse decodes a register URL response from a raw API response.
+//
+// Parameters:
+//   - resp: The raw response map returned by the API client
+//
+// Returns:
+//   - *C2BRegisterResponse: The decoded response (never nil)
+func NewC2BRegisterResponse(resp map[string]any) *C2BRegisterResponse {
+	r, _ := abstracts.DecodeInto[C2BRegisterResponse](resp)
+	return &r
+}
+
+// Success reports whether the URLs were registered.
+//
+// Returns:
+//   - bool: true when ResponseCode is "0" or ResponseDescription is "Success"
+func (r *C2BRegisterResponse) Success() bool {
+	if r == nil {
+		return false
+	}
+	return r.ResponseCode == "0" || strings.EqualFold(strings.TrimSpace(r.ResponseDescription), "success")
+}
+
+// C2BSimulateResponse is the response returned by the C2B simulate API.
+type C2BSimulateResponse struct {
+	ConversationID          string // Unique ID assigned by M-Pesa to the request
+	OriginatorCoversationID string `mpesa:"OriginatorCoversationID|OriginatorConversationID"` // Unique request ID (Daraja spells the key without the "n")
+	ResponseCode            string // "0" when the simulation was accepted
+	ResponseDescription     string // Human readable description of the response code
+}
+
+// NewC2BSimulateResponse decodes a simulate response from a raw API response.
+// Values are accepted as strings or numbers, and key casing and the spelling of
+// OriginatorCoversationID are tolerated.
+//
+// Parameters:
+//   - resp: The raw response map returned by the API client
+//
+// Returns:
+//   - *C2BSimulateResponse: The decoded response (never nil)
+func NewC2BSimulateResponse(resp map[string]any) *C2BSimulateResponse {
+	r, _ := abstracts.DecodeInto[C2BSimulateResponse](resp)
+	return &r
+}
+
+// Accepted reports whether M-Pesa accepted the simulation request.
+//
+// Returns:
+//   - bool: true when ResponseCode is "0"
+func (r *C2BSimulateResponse) Accepted() bool {
+	return r != nil && r.ResponseCode == "0"
+}
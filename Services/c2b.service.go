@@ -3,9 +3,37 @@ package Services
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
+// urlsAlreadyRegisteredCode is the Daraja error code returned when URLs are already registered for a shortcode.
+const urlsAlreadyRegisteredCode = "500.003.1001"
+
+// ErrURLsAlreadyRegistered is returned by RegisterURLs when Daraja reports that URLs are already
+// registered for the shortcode. Many callers treat it as success:
+//
+//	if err := c2bService.RegisterURLs(); err != nil && !errors.Is(err, Services.ErrURLsAlreadyRegistered) {
+//	    return err
+//	}
+var ErrURLsAlreadyRegistered = errors.New("C2B URLs are already registered")
+
+// shortCodePattern matches M-Pesa paybill and till numbers (5 to 7 digits).
+var shortCodePattern = regexp.MustCompile(`^\d{5,7}$`)
+
+// Default C2B amount bounds in KES enforced by Simulate; see SetAmountLimits.
+const (
+	DefaultC2BMinAmount = 1
+	DefaultC2BMaxAmount = 250000
+)
+
+// ErrSandboxOnly is returned by Simulate when the config targets the production environment,
+// where the C2B simulate API does not exist. See AllowInProduction.
+var ErrSandboxOnly = errors.New("C2B simulate is only available in the sandbox environment")
+
 // CustomerToBusinessService handles Customer to Business (C2B) payment operations.
 // C2B allows customers to make payments to businesses and enables businesses to register
 // validation and confirmation URLs for payment notifications.
@@ -20,6 +48,18 @@ type CustomerToBusinessService struct {
 	Amount          string                   // Amount for the payment simulation
 	PhoneNumber     string                   // Customer's phone number for payment simulation
 	Response        map[string]interface{}   // Response from the last API call
+	registerResp    *C2BRegisterResponse     // Decoded response from the last RegisterURLs call
+	allowProduction bool                     // Allow Simulate against production (see AllowInProduction)
+	phoneErr        error                    // Validation error from the last SetPhoneNumber call
+	rawPhone        bool                     // Skip strict phone validation (set by SetRawPhoneNumber)
+	phonePrefixes   []string                 // Allowed phone prefixes, nil unless SetStrictPhonePrefixes was called
+	phoneValidator  abstracts.PhoneValidator // Custom phone check (overrides the config validator)
+	shortCode       string                   // Per-service shortcode (overrides the config business code)
+	shortCodeErr    error                    // Validation error from the last SetShortCode call
+	rawCommandID    bool                     // Skip command ID validation (set by SetRawCommandID)
+	rawResponseType bool                     // Skip response type validation (set by SetRawResponseType)
+	minAmount       int                      // Minimum simulated amount (0 disables the check)
+	maxAmount       int                      // Maximum simulated amount (0 disables the check)
 }
 
 // NewCustomerToBusinessService creates a new C2B service instance with the provided configuration and client.
@@ -39,11 +79,36 @@ type CustomerToBusinessService struct {
 //	c2bService := NewCustomerToBusinessService(cfg, client)
 func NewCustomerToBusinessService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *CustomerToBusinessService {
 	return &CustomerToBusinessService{
-		Config: cfg,
-		Client: client,
+		Config:    cfg,
+		Client:    client,
+		minAmount: DefaultC2BMinAmount,
+		maxAmount: DefaultC2BMaxAmount,
 	}
 }
 
+// SetShortCode sets the shortcode used by RegisterURLs and Simulate, overriding the config's
+// business code without modifying it. This allows one process to operate several paybills.
+// The shortcode must be 5 to 7 digits; invalid values are reported by RegisterURLs and Simulate.
+//
+// Parameters:
+//   - code: The paybill or till number
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	err := c2bService.SetShortCode("600999").RegisterURLs()
+func (s *CustomerToBusinessService) SetShortCode(code string) *CustomerToBusinessService {
+	code = strings.TrimSpace(code)
+	s.shortCode = code
+	s.shortCodeErr = nil
+	if !shortCodePattern.MatchString(code) {
+		s.shortCodeErr = fmt.Errorf("invalid shortcode %q: must be 5 to 7 digits", code)
+	}
+	return s
+}
+
 // SetConfirmationURL sets the URL where M-Pesa will send payment confirmation notifications.
 // This URL will receive POST requests when payments are successfully completed.
 //
@@ -79,7 +144,9 @@ func (s *CustomerToBusinessService) SetValidationURL(url string) *CustomerToBusi
 }
 
 // SetResponseType sets the response type for URL registration.
-// This determines how M-Pesa handles the validation response.
+// This determines how M-Pesa handles the transaction when the validation URL is unreachable.
+// The value is validated by RegisterURLs; use SetRawResponseType for values this package
+// does not know about.
 //
 // Parameters:
 //   - t: The response type ("Completed" or "Cancelled")
@@ -88,8 +155,8 @@ func (s *CustomerToBusinessService) SetValidationURL(url string) *CustomerToBusi
 //   - *CustomerToBusinessService: Returns self for method chaining
 //
 // Response Types:
-//   - "Completed": Default response - completes the transaction
-//   - "Cancelled": Cancels the transaction if validation fails
+//   - "Completed" (ResponseTypeCompleted): Default response - completes the transaction
+//   - "Cancelled" (ResponseTypeCancelled): Cancels the transaction
 //
 // Example:
 //
@@ -97,14 +164,45 @@ func (s *CustomerToBusinessService) SetValidationURL(url string) *CustomerToBusi
 //	c2bService.SetResponseType("Cancelled")
 func (s *CustomerToBusinessService) SetResponseType(t string) *CustomerToBusinessService {
 	s.ResponseType = t
+	s.rawResponseType = false
+	return s
+}
+
+// SetTypedResponseType sets the response type for URL registration like SetResponseType,
+// taking one of the C2BResponseType constants.
+//
+// Parameters:
+//   - t: The response type (ResponseTypeCompleted or ResponseTypeCancelled)
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.SetTypedResponseType(Services.ResponseTypeCancelled)
+func (s *CustomerToBusinessService) SetTypedResponseType(t C2BResponseType) *CustomerToBusinessService {
+	return s.SetResponseType(string(t))
+}
+
+// SetRawResponseType sets the response type verbatim, without validation.
+//
+// Parameters:
+//   - t: The response type to send as-is
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetRawResponseType(t string) *CustomerToBusinessService {
+	s.ResponseType = t
+	s.rawResponseType = true
 	return s
 }
 
 // SetCommandID sets the command ID for C2B transactions.
-// This identifies the type of transaction being performed.
+// This identifies the type of transaction being performed. The value is validated by
+// Simulate; use SetRawCommandID for command IDs this package does not know about.
 //
 // Parameters:
-//   - cmd: The command ID (typically "CustomerPayBillOnline" or "CustomerBuyGoodsOnline")
+//   - cmd: The command ID ("CustomerPayBillOnline" or "CustomerBuyGoodsOnline")
 //
 // Returns:
 //   - *CustomerToBusinessService: Returns self for method chaining
@@ -115,6 +213,36 @@ func (s *CustomerToBusinessService) SetResponseType(t string) *CustomerToBusines
 //	c2bService.SetCommandID("CustomerBuyGoodsOnline")
 func (s *CustomerToBusinessService) SetCommandID(cmd string) *CustomerToBusinessService {
 	s.CommandID = cmd
+	s.rawCommandID = false
+	return s
+}
+
+// SetTypedCommandID sets the command ID for C2B transactions like SetCommandID, taking one
+// of the C2BCommandID constants.
+//
+// Parameters:
+//   - cmd: The command ID (CommandCustomerPayBillOnline or CommandCustomerBuyGoodsOnline)
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.SetTypedCommandID(Services.CommandCustomerBuyGoodsOnline)
+func (s *CustomerToBusinessService) SetTypedCommandID(cmd C2BCommandID) *CustomerToBusinessService {
+	return s.SetCommandID(string(cmd))
+}
+
+// SetRawCommandID sets the command ID verbatim, without validation.
+//
+// Parameters:
+//   - cmd: The command ID to send as-is
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetRawCommandID(cmd string) *CustomerToBusinessService {
+	s.CommandID = cmd
+	s.rawCommandID = true
 	return s
 }
 
@@ -137,7 +265,7 @@ func (s *CustomerToBusinessService) SetBillRefNumber(ref string) *CustomerToBusi
 }
 
 // SetAmount sets the amount for C2B payment simulation.
-// The amount should be in Kenyan Shillings.
+// The amount should be in whole Kenyan Shillings; Simulate reports an amount with cents.
 //
 // Parameters:
 //   - amount: The amount as a string
@@ -154,25 +282,139 @@ func (s *CustomerToBusinessService) SetAmount(amount string) *CustomerToBusiness
 	return s
 }
 
+// SetAmountInt sets the amount for C2B payment simulation from an integer.
+//
+// Parameters:
+//   - amount: The amount in KES
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetAmountInt(amount int) *CustomerToBusinessService {
+	s.Amount = strconv.Itoa(amount)
+	return s
+}
+
+// SetAmountFloat sets the amount for C2B payment simulation from a float.
+//
+// Parameters:
+//   - amount: The amount in KES
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetAmountFloat(amount float64) *CustomerToBusinessService {
+	s.Amount = strconv.FormatFloat(amount, 'f', -1, 64)
+	return s
+}
+
+// SetAmountValue sets the amount for C2B payment simulation.
+//
+// Parameters:
+//   - amount: The amount in KES
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.SetAmountValue(Abstracts.FromKES(100))
+func (s *CustomerToBusinessService) SetAmountValue(amount abstracts.Amount) *CustomerToBusinessService {
+	s.Amount = amount.String()
+	return s
+}
+
+// SetAmountLimits sets the bounds Simulate enforces on the amount. The defaults are
+// DefaultC2BMinAmount and DefaultC2BMaxAmount; a limit of zero disables that bound.
+//
+// Parameters:
+//   - min: The minimum amount in KES, or 0 for no minimum
+//   - max: The maximum amount in KES, or 0 for no maximum
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.SetAmountLimits(10, 70000)
+func (s *CustomerToBusinessService) SetAmountLimits(min, max int) *CustomerToBusinessService {
+	s.minAmount = min
+	s.maxAmount = max
+	return s
+}
+
 // SetPhoneNumber sets the customer's phone number for payment simulation.
-// The phone number should be in international format.
+// The number is normalized to the 2547XXXXXXXX / 2541XXXXXXXX format with
+// Abstracts.NormalizeMSISDN and Abstracts.ValidateMSISDN; invalid numbers are reported by
+// Simulate before any request is made.
 //
 // Parameters:
-//   - phone: The customer's phone number (e.g., "254711223344")
+//   - phone: The customer's phone number (e.g., "0711223344", "+254711223344" or "711223344")
 //
 // Returns:
 //   - *CustomerToBusinessService: Returns self for method chaining
 //
 // Example:
 //
-//	c2bService.SetPhoneNumber("254711223344")
+//	c2bService.SetPhoneNumber("0711223344") // Stored as "254711223344"
 func (s *CustomerToBusinessService) SetPhoneNumber(phone string) *CustomerToBusinessService {
+	s.PhoneNumber, s.phoneErr = normalizePhone(phone, s.Config.GetDefaultCountryCode())
+	s.rawPhone = false
+	return s
+}
+
+// SetRawPhoneNumber sets the phone number verbatim, bypassing normalization and validation.
+// Use it for hashed or non-Kenyan test values.
+//
+// Parameters:
+//   - phone: The value to send as Msisdn
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetRawPhoneNumber(phone string) *CustomerToBusinessService {
 	s.PhoneNumber = phone
+	s.phoneErr = nil
+	s.rawPhone = true
+	return s
+}
+
+// SetStrictPhonePrefixes turns on strict phone validation: Simulate rejects a phone number
+// that does not start with one of prefixes, or Abstracts.DefaultMSISDNPrefixes (the Safaricom
+// 2547 and 2541 ranges) when none are given, with an error wrapping Abstracts.ErrUnsupportedPrefix. Numbers set with SetRawPhoneNumber
+// are not checked.
+//
+// Parameters:
+//   - prefixes: The allowed prefixes in international format, e.g. "2547"
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.SetStrictPhonePrefixes()       // Safaricom numbers only
+//	c2bService.SetStrictPhonePrefixes("2547") // 07xx numbers only
+func (s *CustomerToBusinessService) SetStrictPhonePrefixes(prefixes ...string) *CustomerToBusinessService {
+	s.phonePrefixes = strictPhonePrefixes(prefixes)
+	return s
+}
+
+// SetPhoneValidator sets a custom check, e.g. a denylist lookup, that Simulate runs on the phone
+// number after normalizing it, in place of the one set with MpesaConfig.SetPhoneValidator.
+// Numbers set with SetRawPhoneNumber are checked as given. Its error is returned wrapped in
+// "invalid phone number".
+//
+// Parameters:
+//   - validator: The check to run; nil falls back to the config's
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+func (s *CustomerToBusinessService) SetPhoneValidator(validator abstracts.PhoneValidator) *CustomerToBusinessService {
+	s.phoneValidator = validator
 	return s
 }
 
 // RegisterURLs registers the validation and confirmation URLs with M-Pesa.
 // This must be done before customers can make C2B payments to your business.
+// The response is checked: ErrURLsAlreadyRegistered is returned when the URLs are already
+// registered, and an *Abstracts.APIError for any other unsuccessful response.
 //
 // Returns:
 //   - error: An error if URL registration fails
@@ -191,25 +433,124 @@ func (s *CustomerToBusinessService) RegisterURLs() error {
 	if s.ConfirmationURL == "" {
 		return errors.New("confirmation URL is required")
 	}
+	if s.shortCodeErr != nil {
+		return s.shortCodeErr
+	}
+	if err := s.validateResponseType(); err != nil {
+		return err
+	}
 
 	data := map[string]interface{}{
-		"ShortCode":       s.Config.GetBusinessCode(),
+		"ShortCode":       s.getShortCode(),
 		"ResponseType":    s.getResponseType(),
 		"ConfirmationURL": s.ConfirmationURL,
 		"ValidationURL":   s.ValidationURL,
 	}
 
-	response, err := s.Client.ExecuteRequest(data, "/mpesa/c2b/v1/registerurl")
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.C2BRegisterURL)
 	if err != nil {
+		var apiErr *abstracts.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == urlsAlreadyRegisteredCode {
+			return fmt.Errorf("URL registration failed: %w: %w", ErrURLsAlreadyRegistered, err)
+		}
 		return fmt.Errorf("URL registration failed: %w", err)
 	}
 
 	s.Response = response
-	return nil
+	s.registerResp = NewC2BRegisterResponse(response)
+	if s.registerResp.Success() {
+		return nil
+	}
+
+	apiErr := &abstracts.APIError{
+		StatusCode:   200,
+		RequestID:    responseString(response, "requestId"),
+		ErrorCode:    chooseString(responseString(response, "errorCode"), s.registerResp.ResponseCode),
+		ErrorMessage: chooseString(responseString(response, "errorMessage"), s.registerResp.ResponseDescription),
+		Body:         response,
+	}
+	if apiErr.ErrorCode == urlsAlreadyRegisteredCode {
+		return fmt.Errorf("URL registration failed: %w: %w", ErrURLsAlreadyRegistered, apiErr)
+	}
+	return fmt.Errorf("URL registration failed: %w", apiErr)
+}
+
+// C2BRegistration describes the outcome of EnsureURLsRegistered.
+type C2BRegistration struct {
+	Registered      bool                 // true when this call registered the URLs
+	Skipped         bool                 // true when Daraja reported the URLs as already registered
+	ShortCode       string               // Shortcode the URLs were registered for
+	ConfirmationURL string               // Confirmation URL sent in the request
+	ValidationURL   string               // Validation URL sent in the request
+	ResponseType    string               // Response type sent in the request
+	Response        *C2BRegisterResponse // Decoded register response, nil when skipped
+}
+
+// EnsureURLsRegistered registers the validation and confirmation URLs, treating
+// ErrURLsAlreadyRegistered as success. It is safe to call on every deployment.
+// Note that when registration is skipped, the URLs on record at Safaricom are the ones
+// registered earlier; Daraja does not report them, so a changed URL is not applied.
+//
+// Returns:
+//   - *C2BRegistration: What was requested and whether registration happened or was skipped
+//   - error: Any registration error other than ErrURLsAlreadyRegistered
+//
+// Example:
+//
+//	state, err := c2bService.EnsureURLsRegistered()
+//	if err != nil {
+//	    log.Fatalf("C2B URL registration failed: %v", err)
+//	}
+//	if state.Skipped {
+//	    log.Printf("C2B URLs already registered for %s", state.ShortCode)
+//	}
+func (s *CustomerToBusinessService) EnsureURLsRegistered() (*C2BRegistration, error) {
+	state := &C2BRegistration{
+		ShortCode:       s.getShortCode(),
+		ConfirmationURL: s.ConfirmationURL,
+		ValidationURL:   s.ValidationURL,
+		ResponseType:    s.getResponseType(),
+	}
+
+	err := s.RegisterURLs()
+	switch {
+	case err == nil:
+		state.Registered = true
+		state.Response = s.registerResp
+		return state, nil
+	case errors.Is(err, ErrURLsAlreadyRegistered):
+		state.Skipped = true
+		return state, nil
+	default:
+		return state, err
+	}
+}
+
+// GetRegisterResponse returns the decoded response from the last RegisterURLs call.
+//
+// Returns:
+//   - *C2BRegisterResponse: The decoded response, or nil if RegisterURLs has not returned a response
+func (s *CustomerToBusinessService) GetRegisterResponse() *C2BRegisterResponse {
+	return s.registerResp
+}
+
+// AllowInProduction lets Simulate run against the production environment. Only use it if
+// Safaricom has enabled simulation for your shortcode.
+//
+// Returns:
+//   - *CustomerToBusinessService: Returns self for method chaining
+//
+// Example:
+//
+//	c2bService.AllowInProduction().Simulate()
+func (s *CustomerToBusinessService) AllowInProduction() *CustomerToBusinessService {
+	s.allowProduction = true
+	return s
 }
 
 // Simulate simulates a C2B payment for testing purposes.
 // This is useful for testing your C2B integration in sandbox environment.
+// In production it fails immediately with ErrSandboxOnly unless AllowInProduction was called.
 //
 // Returns:
 //   - map[string]interface{}: The simulation response from M-Pesa
@@ -229,25 +570,49 @@ func (s *CustomerToBusinessService) RegisterURLs() error {
 //	}
 //	fmt.Printf("Simulation response: %+v", response)
 func (s *CustomerToBusinessService) Simulate() (map[string]interface{}, error) {
+	if s.Config.GetEnvironment() == abstracts.Production && !s.allowProduction {
+		return nil, ErrSandboxOnly
+	}
 	if s.CommandID == "" {
 		return nil, errors.New("command ID is required")
 	}
+	if err := s.validateCommandID(); err != nil {
+		return nil, err
+	}
 	if s.Amount == "" {
 		return nil, errors.New("amount is required")
 	}
+	amount, err := s.normalizeAmount()
+	if err != nil {
+		return nil, err
+	}
+	if s.shortCodeErr != nil {
+		return nil, s.shortCodeErr
+	}
+	if s.phoneErr != nil {
+		return nil, fmt.Errorf("invalid phone number: %w", s.phoneErr)
+	}
 	if s.PhoneNumber == "" {
 		return nil, errors.New("phone number is required")
 	}
+	if !s.rawPhone {
+		if err := checkPhonePrefixes(s.PhoneNumber, s.phonePrefixes); err != nil {
+			return nil, fmt.Errorf("invalid phone number: %w", err)
+		}
+	}
+	if err := checkPhoneValidator(s.PhoneNumber, s.Config, s.phoneValidator); err != nil {
+		return nil, fmt.Errorf("invalid phone number: %w", err)
+	}
 
 	data := map[string]interface{}{
-		"ShortCode":     s.Config.GetBusinessCode(),
+		"ShortCode":     s.getShortCode(),
 		"CommandID":     s.CommandID,
-		"Amount":        s.Amount,
+		"Amount":        amount, // whole shillings as a number, see c2bAmountForm
 		"Msisdn":        s.PhoneNumber,
 		"BillRefNumber": s.getBillRefNumber(),
 	}
 
-	response, err := s.Client.ExecuteRequest(data, "/mpesa/c2b/v1/simulate")
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.C2BSimulate)
 	if err != nil {
 		return nil, fmt.Errorf("C2B simulation failed: %w", err)
 	}
@@ -256,6 +621,27 @@ func (s *CustomerToBusinessService) Simulate() (map[string]interface{}, error) {
 	return response, nil
 }
 
+// SimulateTyped simulates a C2B payment like Simulate and returns the decoded response.
+// The raw response is still stored in the Response field.
+//
+// Returns:
+//   - *C2BSimulateResponse: The decoded simulation response
+//   - error: An error if validation or the simulation fails
+//
+// Example:
+//
+//	resp, err := c2bService.SimulateTyped()
+//	if err == nil && resp.Accepted() {
+//	    log.Printf("simulated, conversation %s", resp.ConversationID)
+//	}
+func (s *CustomerToBusinessService) SimulateTyped() (*C2BSimulateResponse, error) {
+	response, err := s.Simulate()
+	if err != nil {
+		return nil, err
+	}
+	return NewC2BSimulateResponse(response), nil
+}
+
 // GetResponse returns the response from the last API call.
 //
 // Returns:
@@ -264,10 +650,65 @@ func (s *CustomerToBusinessService) GetResponse() map[string]interface{} {
 	return s.Response
 }
 
+// normalizeAmount parses the amount, checks it against the amount limits and converts it to
+// the form of the C2B Amount field.
+func (s *CustomerToBusinessService) normalizeAmount() (any, error) {
+	amount, err := abstracts.FromString(s.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.Positive() {
+		return nil, fmt.Errorf("invalid amount %q: must be greater than 0", s.Amount)
+	}
+	if s.minAmount > 0 && amount.Cmp(abstracts.FromKES(s.minAmount)) < 0 {
+		return nil, fmt.Errorf("invalid amount %q: below the minimum of %d", s.Amount, s.minAmount)
+	}
+	if s.maxAmount > 0 && amount.Cmp(abstracts.FromKES(s.maxAmount)) > 0 {
+		return nil, fmt.Errorf("invalid amount %q: above the maximum of %d", s.Amount, s.maxAmount)
+	}
+	field, err := amountField(amount, c2bAmountForm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q: %w", s.Amount, err)
+	}
+	return field, nil
+}
+
+// validateCommandID checks the command ID unless it was set with SetRawCommandID.
+func (s *CustomerToBusinessService) validateCommandID() error {
+	if s.rawCommandID {
+		return nil
+	}
+	for _, cmd := range c2bCommandIDs {
+		if s.CommandID == string(cmd) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid command ID %q: must be one of %s, %s", s.CommandID, CommandCustomerPayBillOnline, CommandCustomerBuyGoodsOnline)
+}
+
+// validateResponseType checks the response type unless it was set with SetRawResponseType.
+func (s *CustomerToBusinessService) validateResponseType() error {
+	if s.rawResponseType {
+		return nil
+	}
+	responseType := s.getResponseType()
+	for _, t := range c2bResponseTypes {
+		if responseType == string(t) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid response type %q: must be one of %s, %s", responseType, ResponseTypeCompleted, ResponseTypeCancelled)
+}
+
+// getShortCode returns the per-service shortcode, falling back to the config business code.
+func (s *CustomerToBusinessService) getShortCode() string {
+	return chooseString(s.shortCode, s.Config.GetBusinessCode())
+}
+
 // getResponseType returns the response type, defaulting to "Completed" if not set.
 func (s *CustomerToBusinessService) getResponseType() string {
 	if s.ResponseType == "" {
-		return "Completed"
+		return string(ResponseTypeCompleted)
 	}
 	return s.ResponseType
 }
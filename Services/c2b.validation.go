@@ -0,0 +1,60 @@
+package Services
+
+import "io"
+
+// RejectionCode is a result code used to reject a C2B validation request.
+type RejectionCode string
+
+// Rejection codes documented for the C2B validation response.
+const (
+	RejectInvalidMSISDN        RejectionCode = "C2B00011" // Invalid MSISDN
+	RejectInvalidAccountNumber RejectionCode = "C2B00012" // Invalid account number
+	RejectInvalidAmount        RejectionCode = "C2B00013" // Invalid amount
+	RejectInvalidKYCDetails    RejectionCode = "C2B00014" // Invalid KYC details
+	RejectInvalidShortcode     RejectionCode = "C2B00015" // Invalid shortcode
+	RejectOtherError           RejectionCode = "C2B00016" // Other error
+)
+
+// C2BValidationResponse is the body returned to M-Pesa from the C2B validation URL.
+// Use AcceptC2BValidation, AcceptWithThirdPartyID or Reject to build it.
+type C2BValidationResponse struct {
+	ResultCode        string `json:"ResultCode"`                  // "0" to accept, or a RejectionCode
+	ResultDesc        string `json:"ResultDesc"`                  // "Accepted" or "Rejected"
+	ThirdPartyTransID string `json:"ThirdPartyTransID,omitempty"` // Optional ID echoed in the confirmation
+}
+
+// ParseC2BValidation decodes a C2B validation request body. Validation requests share the
+// confirmation payload shape; see ParseC2BConfirmation.
+//
+// Parameters:
+//   - r: The request body
+//
+// Returns:
+//   - *C2BConfirmation: The parsed payload
+//   - error: An error if the body cannot be parsed
+func ParseC2BValidation(r io.Reader) (*C2BConfirmation, error) {
+	return ParseC2BConfirmation(r)
+}
+
+// AcceptC2BValidation builds a response that accepts the payment.
+func AcceptC2BValidation() C2BValidationResponse {
+	return C2BValidationResponse{ResultCode: "0", ResultDesc: "Accepted"}
+}
+
+// AcceptWithThirdPartyID builds a response that accepts the payment and attaches your own
+// transaction ID, which M-Pesa echoes back as ThirdPartyTransID in the confirmation.
+func AcceptWithThirdPartyID(id string) C2BValidationResponse {
+	resp := AcceptC2BValidation()
+	resp.ThirdPartyTransID = id
+	return resp
+}
+
+// Reject builds a response that rejects the payment with the given code.
+func Reject(code RejectionCode) C2BValidationResponse {
+	return C2BValidationResponse{ResultCode: string(code), ResultDesc: "Rejected"}
+}
+
+// Accepted reports whether the response accepts the payment.
+func (r C2BValidationResponse) Accepted() bool {
+	return r.ResultCode == "0"
+}
@@ -0,0 +1,272 @@
+package Services
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// Default paths a Dispatcher mounts its callback routes on.
+const (
+	DefaultSTKCallbackPath     = "/mpesa/stk"
+	DefaultC2BConfirmationPath = "/mpesa/c2b/confirmation"
+	DefaultB2CResultPath       = "/mpesa/b2c/result"
+)
+
+// QueuePolicy decides what an asynchronous Dispatcher does with an event when its queue is full.
+type QueuePolicy int
+
+const (
+	// BlockWhenFull waits for room in the queue. The callback is still acknowledged when the
+	// handler timeout expires first, so the wait is bounded.
+	BlockWhenFull QueuePolicy = iota
+
+	// DropWhenFull discards the event and passes its type to the WithOnDropped function.
+	DropWhenFull
+)
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithAsyncDelivery delivers events from a queue of queueSize events on a background
+// goroutine, in the order they arrived, so callbacks are acknowledged without waiting for the
+// subscribers. policy decides what happens when the queue is full. Panics of subscribers are
+// recovered and passed to the WithOnDeliveryError function as a *CallbackPanicError. Delivery
+// is synchronous by default, within the handler timeout and panic recovery.
+func WithAsyncDelivery(queueSize int, policy QueuePolicy) DispatcherOption {
+	return func(d *Dispatcher) {
+		if queueSize < 1 {
+			queueSize = 1
+		}
+		d.queue = make(chan dispatchedEvent, queueSize)
+		d.policy = policy
+	}
+}
+
+// WithDispatcherPath mounts the route of callback type t on path instead of its default.
+// Only CallbackSTK, CallbackC2BConfirmation and CallbackB2CResult are served.
+func WithDispatcherPath(t CallbackType, path string) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.paths[t] = path
+	}
+}
+
+// WithDispatcherHandlerOptions applies opts to the handlers of every route, e.g.
+// WithErrorHandler or WithProcessedStore.
+func WithDispatcherHandlerOptions(opts ...HandlerOption) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.handlerOpts = append(d.handlerOpts, opts...)
+	}
+}
+
+// WithOnDropped calls fn with the type of each event that was not delivered, because the queue
+// was full under DropWhenFull or because the Dispatcher was closed.
+func WithOnDropped(fn func(t CallbackType)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.onDropped = fn
+	}
+}
+
+// WithOnDeliveryError calls fn with the event type and a *CallbackPanicError when a subscriber
+// panics during asynchronous delivery. Failures of synchronous delivery are reported by the
+// handlers; see WithDispatcherHandlerOptions and WithErrorHandler.
+func WithOnDeliveryError(fn func(t CallbackType, err error)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.onDeliveryError = fn
+	}
+}
+
+// Dispatcher delivers payment callbacks to any number of subscribers per event, so that a
+// service mounts one handler instead of wiring each callback route. Subscribers of an event
+// are called in the order they subscribed.
+type Dispatcher struct {
+	paths           map[CallbackType]string
+	handlerOpts     []HandlerOption
+	onDropped       func(t CallbackType)
+	onDeliveryError func(t CallbackType, err error)
+
+	subMu          sync.RWMutex
+	onSTK          []func(*STKCallback)
+	onConfirmation []func(*C2BConfirmation)
+	onB2CResult    []func(*B2CResult)
+
+	queue   chan dispatchedEvent
+	policy  QueuePolicy
+	queueMu sync.RWMutex // Held for writing while closing, so no event is queued afterwards
+	closed  bool
+	drained chan struct{}
+}
+
+// dispatchedEvent is an event waiting in the queue of an asynchronous Dispatcher.
+type dispatchedEvent struct {
+	callback CallbackType
+	deliver  func()
+}
+
+// NewDispatcher creates a Dispatcher for STK Push, C2B confirmation and B2C result callbacks,
+// mounted on DefaultSTKCallbackPath, DefaultC2BConfirmationPath and DefaultB2CResultPath.
+//
+// Parameters:
+//   - opts: Optional delivery, path and handler settings
+//
+// Returns:
+//   - *Dispatcher: The dispatcher; call Close on shutdown when delivery is asynchronous
+//
+// Example:
+//
+//	events := Services.NewDispatcher(Services.WithAsyncDelivery(1024, Services.BlockWhenFull))
+//	defer events.Close()
+//	events.OnStkResult(func(cb *Services.STKCallback) { orders.MarkPaid(cb.CheckoutRequestID, cb.Success) })
+//	events.OnStkResult(func(cb *Services.STKCallback) { metrics.CountPayment(cb.Success) })
+//	events.OnC2BConfirmation(func(c *Services.C2BConfirmation) { ledger.Record(c.TransID, c.TransAmount) })
+//	http.Handle("/mpesa/", events.Handler())
+func NewDispatcher(opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{paths: map[CallbackType]string{
+		CallbackSTK:             DefaultSTKCallbackPath,
+		CallbackC2BConfirmation: DefaultC2BConfirmationPath,
+		CallbackB2CResult:       DefaultB2CResultPath,
+	}}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.queue != nil {
+		d.drained = make(chan struct{})
+		go d.run()
+	}
+	return d
+}
+
+// OnStkResult subscribes fn to STK Push callbacks.
+func (d *Dispatcher) OnStkResult(fn func(*STKCallback)) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	d.onSTK = append(d.onSTK, fn)
+}
+
+// OnC2BConfirmation subscribes fn to C2B confirmations.
+func (d *Dispatcher) OnC2BConfirmation(fn func(*C2BConfirmation)) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	d.onConfirmation = append(d.onConfirmation, fn)
+}
+
+// OnB2CResult subscribes fn to B2C results.
+func (d *Dispatcher) OnB2CResult(fn func(*B2CResult)) {
+	d.subMu.Lock()
+	defer d.subMu.Unlock()
+	d.onB2CResult = append(d.onB2CResult, fn)
+}
+
+// Handler returns the handler serving the callback routes of the dispatcher, built on a
+// CallbackMux. Like http.ServeMux.Handle, it panics when the configured paths are invalid.
+func (d *Dispatcher) Handler() http.Handler {
+	mux, err := NewCallbackMux(CallbackMuxConfig{
+		STKPath: d.paths[CallbackSTK],
+		OnSTK: func(cb *STKCallback) {
+			d.subMu.RLock()
+			subs := d.onSTK
+			d.subMu.RUnlock()
+			d.dispatch(CallbackSTK, func() {
+				for _, fn := range subs {
+					fn(cb)
+				}
+			})
+		},
+		C2BConfirmationPath: d.paths[CallbackC2BConfirmation],
+		OnC2BConfirmation: func(c *C2BConfirmation) error {
+			d.subMu.RLock()
+			subs := d.onConfirmation
+			d.subMu.RUnlock()
+			d.dispatch(CallbackC2BConfirmation, func() {
+				for _, fn := range subs {
+					fn(c)
+				}
+			})
+			return nil
+		},
+		B2CResultPath: d.paths[CallbackB2CResult],
+		OnB2CResult: func(res *B2CResult) {
+			d.subMu.RLock()
+			subs := d.onB2CResult
+			d.subMu.RUnlock()
+			d.dispatch(CallbackB2CResult, func() {
+				for _, fn := range subs {
+					fn(res)
+				}
+			})
+		},
+		Options: d.handlerOpts,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("mpesa: invalid dispatcher routes: %v", err))
+	}
+	return mux
+}
+
+// dispatch delivers an event through deliver, directly or through the queue.
+func (d *Dispatcher) dispatch(t CallbackType, deliver func()) {
+	if d.queue == nil {
+		deliver()
+		return
+	}
+
+	d.queueMu.RLock()
+	defer d.queueMu.RUnlock()
+	if d.closed {
+		d.dropped(t)
+		return
+	}
+	event := dispatchedEvent{callback: t, deliver: deliver}
+	if d.policy == DropWhenFull {
+		select {
+		case d.queue <- event:
+		default:
+			d.dropped(t)
+		}
+		return
+	}
+	d.queue <- event
+}
+
+// run delivers queued events until the queue is closed and empty.
+func (d *Dispatcher) run() {
+	defer close(d.drained)
+	for event := range d.queue {
+		d.deliver(event)
+	}
+}
+
+// deliver runs a queued event, recovering a panic of its subscribers.
+func (d *Dispatcher) deliver(event dispatchedEvent) {
+	defer func() {
+		if p := recover(); p != nil && d.onDeliveryError != nil {
+			d.onDeliveryError(event.callback, &CallbackPanicError{Value: p, Stack: debug.Stack()})
+		}
+	}()
+	event.deliver()
+}
+
+// dropped reports an event that was not delivered.
+func (d *Dispatcher) dropped(t CallbackType) {
+	if d.onDropped != nil {
+		d.onDropped(t)
+	}
+}
+
+// Close stops accepting events and waits until the queued events have been delivered.
+// Callbacks received afterwards are still acknowledged, but their events are dropped.
+// Close does nothing for a synchronous Dispatcher and when called again.
+func (d *Dispatcher) Close() error {
+	if d.queue == nil {
+		return nil
+	}
+	d.queueMu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.queueMu.Unlock()
+	<-d.drained
+	return nil
+}
@@ -0,0 +1,125 @@
+package Services
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// flexString is a JSON value decoded to its string form like toString, so that codes and
+// amounts sent as strings or numbers decode the same way.
+type flexString string
+
+// UnmarshalJSON accepts a string, number, boolean or null.
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = flexString(toString(v))
+	return nil
+}
+
+// decodePayload encodes an already decoded payload and passes it to decode, so that the Parse
+// functions share the decoding of the UnmarshalJSON methods.
+func decodePayload(payload map[string]any, decode func(data []byte) error) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return decode(data)
+}
+
+// rawPayload decodes data into the map kept in the Raw field of the callback types. It is nil
+// when data is not a JSON object.
+func rawPayload(data []byte) map[string]any {
+	var payload map[string]any
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil
+	}
+	return payload
+}
+
+// isJSONObject reports whether raw holds a JSON object.
+func isJSONObject(raw json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(raw)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// decodeNode decodes an optional node such as ResultParameters into a generic value; it is nil
+// when raw is empty or malformed.
+func decodeNode(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// keyValues decodes a Key/Value item list such as ReferenceData into a map. The list may be
+// wrapped in an object under key, and may be a single item or an array of items.
+func keyValues(raw json.RawMessage, key string) map[string]string {
+	out := make(map[string]string)
+	parseKeyValueItems(unwrapNode(decodeNode(raw), key), out)
+	return out
+}
+
+// MarshalCallbackRaw makes the MarshalJSON methods of the callback types include the original
+// payload under "Raw". It is off by default so that re-emitted callbacks stay small; set it
+// once at start-up.
+var MarshalCallbackRaw = false
+
+// Schemas of the JSON written by the MarshalJSON methods of the callback types. The JSON is an
+// object holding the schema under "Schema", every other field of the type under its json tag
+// name, times in RFC 3339 and, when MarshalCallbackRaw is set, the original payload under
+// "Raw". The UnmarshalJSON methods accept it as well as the raw callback bodies, so that
+// Marshal followed by Unmarshal restores every field; a new version is added rather than the
+// schema changed.
+const (
+	SchemaSTKCallback             = "mpesa.stk_callback.v1"
+	SchemaC2BConfirmation         = "mpesa.c2b_confirmation.v1"
+	SchemaB2CResult               = "mpesa.b2c_result.v1"
+	SchemaB2BResult               = "mpesa.b2b_result.v1"
+	SchemaReversalResult          = "mpesa.reversal_result.v1"
+	SchemaAccountBalanceResult    = "mpesa.account_balance_result.v1"
+	SchemaTransactionStatusResult = "mpesa.transaction_status_result.v1"
+)
+
+// marshaledRaw returns the Raw field to write, depending on MarshalCallbackRaw.
+func marshaledRaw(raw map[string]any) map[string]any {
+	if !MarshalCallbackRaw {
+		return nil
+	}
+	return raw
+}
+
+// decodeNormalized decodes data into v and raw when it was written by MarshalJSON with schema.
+// It reports false, leaving v alone, for anything else, such as a raw callback body.
+func decodeNormalized[T any](data []byte, schema string, v *T, raw *map[string]any) (bool, error) {
+	var header struct {
+		Schema string
+		Raw    map[string]any
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.Schema != schema {
+		return false, nil
+	}
+	var decoded T
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return true, err
+	}
+	*v = decoded
+	*raw = header.Raw
+	return true, nil
+}
+
+// inEAT returns t in the M-Pesa timezone, as the callback parsers produce it. The zero time is
+// kept as is.
+func inEAT(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(mpesaLocation)
+}
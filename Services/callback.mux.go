@@ -0,0 +1,185 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CallbackType identifies a callback route mounted by a CallbackMux.
+type CallbackType string
+
+// Callback routes supported by NewCallbackMux.
+const (
+	CallbackSTK             CallbackType = "stk"
+	CallbackC2BValidation   CallbackType = "c2b_validation"
+	CallbackC2BConfirmation CallbackType = "c2b_confirmation"
+	CallbackB2CResult       CallbackType = "b2c_result"
+	CallbackB2CTimeout      CallbackType = "b2c_timeout"
+	CallbackB2BResult       CallbackType = "b2b_result"
+	CallbackB2BTimeout      CallbackType = "b2b_timeout"
+	CallbackReversalResult  CallbackType = "reversal_result"
+	CallbackReversalTimeout CallbackType = "reversal_timeout"
+	CallbackBalanceResult   CallbackType = "balance_result"
+	CallbackBalanceTimeout  CallbackType = "balance_timeout"
+	CallbackStatusResult    CallbackType = "status_result"
+	CallbackStatusTimeout   CallbackType = "status_timeout"
+)
+
+// CallbackMuxConfig lists the callback routes to mount. A route is mounted only when its path
+// is set; paths must start with "/". The result and timeout routes of an API may share a path,
+// since their handler tells the two apart. The user functions may be nil, except for the C2B
+// validation and confirmation functions whose return value is needed to answer M-Pesa.
+type CallbackMuxConfig struct {
+	STKPath string
+	OnSTK   func(*STKCallback)
+
+	C2BValidationPath   string
+	OnC2BValidation     func(*C2BConfirmation) C2BValidationResponse
+	C2BConfirmationPath string
+	OnC2BConfirmation   func(*C2BConfirmation) error
+
+	B2CResultPath  string
+	B2CTimeoutPath string
+	OnB2CResult    func(*B2CResult)
+	OnB2CTimeout   func(raw map[string]any)
+
+	B2BResultPath  string // Shared by BusinessPayBill, BusinessBuyGoods, TaxRemittance and B2C top ups
+	B2BTimeoutPath string
+	OnB2BResult    func(*B2BCallbackResult)
+	OnB2BTimeout   func(raw map[string]any)
+
+	ReversalResultPath  string
+	ReversalTimeoutPath string
+	OnReversalResult    func(*ReversalResult)
+	OnReversalTimeout   func(raw map[string]any)
+
+	BalanceResultPath  string
+	BalanceTimeoutPath string
+	OnBalanceResult    func(*AccountBalanceResult)
+	OnBalanceTimeout   func(raw map[string]any)
+
+	StatusResultPath  string
+	StatusTimeoutPath string
+	OnStatusResult    func(*TransactionStatusResult)
+	OnStatusTimeout   func(raw map[string]any)
+
+	Options []HandlerOption // Applied to every handler, e.g. WithMaxBodyBytes, WithReadTimeout and WithErrorHandler
+}
+
+// CallbackMux routes M-Pesa callbacks to the typed handlers configured in a CallbackMuxConfig.
+type CallbackMux struct {
+	mux   *http.ServeMux
+	paths map[CallbackType]string
+}
+
+// NewCallbackMux mounts every configured callback route on a new mux, so the handlers and the
+// URLs registered with Daraja are kept in one place. Use Paths or URL to derive the URLs.
+//
+// Parameters:
+//   - cfg: The routes to mount and the options shared by their handlers
+//
+// Returns:
+//   - *CallbackMux: The mux, ready to be served or mounted under a prefix
+//   - error: An error if a path is invalid, used twice or lacks a required function
+//
+// Example:
+//
+//	mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{
+//	    STKPath:       "/mpesa/stk",
+//	    OnSTK:         func(cb *Services.STKCallback) { markOrder(cb.CheckoutRequestID, cb.Success) },
+//	    B2CResultPath: "/mpesa/b2c/result",
+//	    OnB2CResult:   func(res *Services.B2CResult) { markPayout(res.OriginatorConversationID, res.Success) },
+//	    Options:       []Services.HandlerOption{Services.WithErrorHandler(logCallbackError)},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	stk.SetCallbackUrl(mux.URL("https://example.com", Services.CallbackSTK))
+//	log.Fatal(http.ListenAndServe(":8080", mux))
+func NewCallbackMux(cfg CallbackMuxConfig) (*CallbackMux, error) {
+	if cfg.C2BValidationPath != "" && cfg.OnC2BValidation == nil {
+		return nil, errors.New("C2B validation function is required when C2BValidationPath is set")
+	}
+	if cfg.C2BConfirmationPath != "" && cfg.OnC2BConfirmation == nil {
+		return nil, errors.New("C2B confirmation function is required when C2BConfirmationPath is set")
+	}
+
+	opts := cfg.Options
+	apis := []struct {
+		name    string
+		handler http.Handler
+		routes  []callbackRoute
+	}{
+		{"STK", STKCallbackHandler(cfg.OnSTK, opts...),
+			[]callbackRoute{{CallbackSTK, cfg.STKPath}}},
+		{"C2B validation", C2BValidationHandler(cfg.OnC2BValidation, opts...),
+			[]callbackRoute{{CallbackC2BValidation, cfg.C2BValidationPath}}},
+		{"C2B confirmation", C2BConfirmationHandler(cfg.OnC2BConfirmation, opts...),
+			[]callbackRoute{{CallbackC2BConfirmation, cfg.C2BConfirmationPath}}},
+		{"B2C", B2CResultHandler(cfg.OnB2CResult, cfg.OnB2CTimeout, opts...),
+			[]callbackRoute{{CallbackB2CResult, cfg.B2CResultPath}, {CallbackB2CTimeout, cfg.B2CTimeoutPath}}},
+		{"B2B", B2BResultHandler(cfg.OnB2BResult, cfg.OnB2BTimeout, opts...),
+			[]callbackRoute{{CallbackB2BResult, cfg.B2BResultPath}, {CallbackB2BTimeout, cfg.B2BTimeoutPath}}},
+		{"reversal", ReversalResultHandler(cfg.OnReversalResult, cfg.OnReversalTimeout, opts...),
+			[]callbackRoute{{CallbackReversalResult, cfg.ReversalResultPath}, {CallbackReversalTimeout, cfg.ReversalTimeoutPath}}},
+		{"account balance", AccountBalanceResultHandler(cfg.OnBalanceResult, cfg.OnBalanceTimeout, opts...),
+			[]callbackRoute{{CallbackBalanceResult, cfg.BalanceResultPath}, {CallbackBalanceTimeout, cfg.BalanceTimeoutPath}}},
+		{"transaction status", TransactionStatusResultHandler(cfg.OnStatusResult, cfg.OnStatusTimeout, opts...),
+			[]callbackRoute{{CallbackStatusResult, cfg.StatusResultPath}, {CallbackStatusTimeout, cfg.StatusTimeoutPath}}},
+	}
+
+	m := &CallbackMux{mux: http.NewServeMux(), paths: make(map[CallbackType]string)}
+	owners := make(map[string]string)
+	for _, api := range apis {
+		for _, route := range api.routes {
+			if route.path == "" {
+				continue
+			}
+			if !strings.HasPrefix(route.path, "/") {
+				return nil, fmt.Errorf("invalid %s path %q: must start with \"/\"", route.callback, route.path)
+			}
+			m.paths[route.callback] = route.path
+			if owner, taken := owners[route.path]; taken {
+				if owner == api.name {
+					continue // result and timeout routes of the same API share the handler
+				}
+				return nil, fmt.Errorf("path %q is used by both %s and %s callbacks", route.path, owner, api.name)
+			}
+			owners[route.path] = api.name
+			m.mux.Handle(route.path, api.handler)
+		}
+	}
+	return m, nil
+}
+
+// callbackRoute pairs a callback route with its configured path.
+type callbackRoute struct {
+	callback CallbackType
+	path     string
+}
+
+// ServeHTTP dispatches the request to the handler of its callback route.
+func (m *CallbackMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// Paths returns the path of every mounted callback route.
+func (m *CallbackMux) Paths() map[CallbackType]string {
+	paths := make(map[CallbackType]string, len(m.paths))
+	for t, p := range m.paths {
+		paths[t] = p
+	}
+	return paths
+}
+
+// URL returns the URL to register with Daraja for the given route, built from the public base
+// URL the mux is served on, e.g. "https://example.com". It returns "" if the route is not mounted.
+func (m *CallbackMux) URL(baseURL string, t CallbackType) string {
+	path, ok := m.paths[t]
+	if !ok {
+		return ""
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
@@ -0,0 +1,112 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrRawPersistFailed is reported when the raw persist hook fails to store a callback.
+var ErrRawPersistFailed = errors.New("failed to persist raw callback")
+
+// RawPersistHook stores the exact bytes of a callback, e.g. to settle disputes with what
+// Safaricom actually sent. callbackType is one of the CallbackType values, such as "stk" or
+// "b2c_result". raw and headers must not be modified.
+type RawPersistHook func(ctx context.Context, callbackType string, raw []byte, headers http.Header) error
+
+// WithRawPersistHook passes the unmodified body and headers of every callback to hook before
+// the callback is parsed, including bodies that turn out to be malformed. Bodies rejected for
+// their method, URL token or size are not persisted. The hook runs under the handler timeout
+// and panic recovery; failures are reported wrapped in ErrRawPersistFailed and, unless
+// WithRawPersistRequired is set, the callback is still handled and acknowledged.
+func WithRawPersistHook(hook RawPersistHook) HandlerOption {
+	return func(o *handlerOptions) {
+		o.rawPersist = hook
+	}
+}
+
+// WithRawPersistRequired controls whether a callback is handled when the raw persist hook
+// fails. When required, such callbacks are answered with 500 Internal Server Error, whatever
+// the ack policy, so that M-Pesa delivers them again. Not required by default.
+func WithRawPersistRequired(required bool) HandlerOption {
+	return func(o *handlerOptions) {
+		o.rawRequired = required
+	}
+}
+
+// receivedAtKey is the context key of the time a callback was received.
+type receivedAtKey struct{}
+
+// CallbackReceivedAt returns the time a callback was received, as told by the clock of the
+// handler (see WithClock), from the context a RawPersistHook is called with. ok is false for
+// contexts that do not come from a handler.
+func CallbackReceivedAt(ctx context.Context) (t time.Time, ok bool) {
+	t, ok = ctx.Value(receivedAtKey{}).(time.Time)
+	return t, ok
+}
+
+// persistRaw passes raw, received at the given time, to the persist hook. It returns false
+// when the hook failed and the callback must not be handled.
+func (o *handlerOptions) persistRaw(r *http.Request, callback CallbackType, raw []byte, received time.Time) bool {
+	_, err := o.invoke(r, func(ctx context.Context) error {
+		ctx = context.WithValue(ctx, receivedAtKey{}, received)
+		return o.rawPersist(ctx, string(callback), raw, r.Header.Clone())
+	})
+	if err == nil {
+		return true
+	}
+	o.report(fmt.Errorf("%w: %w", ErrRawPersistFailed, err), r)
+	return !o.rawRequired
+}
+
+// FileRawPersistHook returns a RawPersistHook that writes each callback to its own file in dir,
+// as a reference for hooks backed by other storage. The body is written as-is to a file named
+// after the receive time (see CallbackReceivedAt) and callback type, e.g. "20240115T103000.123456789Z-stk-1234.json",
+// and the headers in HTTP wire format to a ".headers" file of the same name. dir is created
+// if needed.
+//
+// Parameters:
+//   - dir: The directory to write callbacks to
+//
+// Returns:
+//   - RawPersistHook: The hook, for WithRawPersistHook
+//
+// Example:
+//
+//	http.Handle("/mpesa/stk", Services.STKCallbackHandler(onSTK,
+//	    Services.WithRawPersistHook(Services.FileRawPersistHook("/var/lib/mpesa/callbacks")),
+//	))
+func FileRawPersistHook(dir string) RawPersistHook {
+	return func(ctx context.Context, callbackType string, raw []byte, headers http.Header) error {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+		received, ok := CallbackReceivedAt(ctx)
+		if !ok {
+			received = time.Now()
+		}
+		stamp := received.UTC().Format("20060102T150405.000000000Z")
+		f, err := os.CreateTemp(dir, stamp+"-"+callbackType+"-*.json")
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(raw); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		var head strings.Builder
+		if err := headers.Write(&head); err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(f.Name(), ".json") + ".headers"
+		return os.WriteFile(name, []byte(head.String()), 0o600)
+	}
+}
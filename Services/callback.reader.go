@@ -0,0 +1,186 @@
+package Services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrCallbackTooLarge is returned by the FromReader/FromRequest parsers when the body exceeds
+// the size limit (DefaultMaxWebhookBodyBytes unless set with WithMaxBodyBytes).
+var ErrCallbackTooLarge = errors.New("callback body too large")
+
+// ErrUnsupportedContentType is returned when a callback body is not a JSON object and the
+// request declares a content type other than application/json.
+var ErrUnsupportedContentType = errors.New("callback content type must be application/json")
+
+// utf8BOM is the byte order mark some senders put in front of UTF-8 bodies.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ParseB2BCallbackFromReader decodes a B2B callback from r and parses it like ParseB2BCallback.
+// The body is limited to DefaultMaxWebhookBodyBytes unless WithMaxBodyBytes is passed.
+func ParseB2BCallbackFromReader(r io.Reader, opts ...HandlerOption) (*B2BCallbackResult, error) {
+	payload, err := decodeCallbackBody(r, newHandlerOptions(opts).maxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ParseB2BCallback(payload)
+}
+
+// ParseB2BCallbackFromRequest decodes and parses a B2B callback from an incoming request.
+// Bodies holding a JSON object are accepted whatever the Content-Type, since Safaricom and
+// proxies in front of it are not consistent about it; other bodies need an application/json
+// Content-Type, or the form field set with WithFormField. The body is size limited as in
+// ParseB2BCallbackFromReader and closed afterwards.
+func ParseB2BCallbackFromRequest(r *http.Request, opts ...HandlerOption) (*B2BCallbackResult, error) {
+	payload, err := decodeCallbackRequest(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ParseB2BCallback(payload)
+}
+
+// ParseB2CResultFromReader decodes a B2C result callback from r and parses it like ParseB2CResult.
+// The body is limited to DefaultMaxWebhookBodyBytes unless WithMaxBodyBytes is passed.
+func ParseB2CResultFromReader(r io.Reader, opts ...HandlerOption) (*B2CResult, error) {
+	payload, err := decodeCallbackBody(r, newHandlerOptions(opts).maxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ParseB2CResult(payload)
+}
+
+// ParseB2CResultFromRequest decodes and parses a B2C result callback from an incoming request,
+// with the same content type and size checks as ParseB2BCallbackFromRequest.
+func ParseB2CResultFromRequest(r *http.Request, opts ...HandlerOption) (*B2CResult, error) {
+	payload, err := decodeCallbackRequest(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ParseB2CResult(payload)
+}
+
+// decodeCallbackRequest decodes the callback in the size limited body of a request.
+func decodeCallbackRequest(r *http.Request, opts []HandlerOption) (map[string]any, error) {
+	if r == nil || r.Body == nil {
+		return nil, errors.New("invalid callback body: empty request")
+	}
+	defer r.Body.Close()
+
+	o := newHandlerOptions(opts)
+	return o.decodeCallback(cappedBody(r.Body, o.maxBodyBytes), r.Header.Get("Content-Type"))
+}
+
+// decodeCallbackBody stream-decodes a JSON object from r, reading at most maxBytes.
+func decodeCallbackBody(r io.Reader, maxBytes int64) (map[string]any, error) {
+	return newHandlerOptions(nil).decodeCallback(cappedBody(r, maxBytes), "")
+}
+
+// cappedBody limits r to maxBytes, or to DefaultMaxWebhookBodyBytes when maxBytes is not positive.
+func cappedBody(r io.Reader, maxBytes int64) io.Reader {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxWebhookBodyBytes
+	}
+	return &cappedReader{r: r, remaining: maxBytes}
+}
+
+// decodeCallback decodes the callback in body, tolerating the content types Safaricom uses.
+// A body holding a JSON object is stream-decoded whatever contentType says, so data after the
+// object is not read. Otherwise a form body is searched for the form field set with
+// WithFormField, and any other body must be declared as JSON (or not declared at all).
+// Read errors, such as ErrCallbackTooLarge, are returned as they are.
+func (o *handlerOptions) decodeCallback(body io.Reader, contentType string) (map[string]any, error) {
+	br := bufio.NewReader(body)
+	if err := skipBOMAndSpace(br); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if next, err := br.Peek(1); err == nil && next[0] == '{' {
+		return decodeJSONObject(br)
+	}
+
+	mediaType := ""
+	if contentType != "" {
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+	}
+	if o.formField != "" && (mediaType == "application/x-www-form-urlencoded" || mediaType == "") {
+		raw, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(raw))
+		if err != nil || !form.Has(o.formField) {
+			return nil, fmt.Errorf("invalid callback body: form field %q not found", o.formField)
+		}
+		return decodeJSONObject(strings.NewReader(form.Get(o.formField)))
+	}
+	if contentType != "" && mediaType != "application/json" {
+		return nil, fmt.Errorf("%w, got %q", ErrUnsupportedContentType, contentType)
+	}
+	return decodeJSONObject(br)
+}
+
+// skipBOMAndSpace discards a UTF-8 byte order mark and leading whitespace from br.
+func skipBOMAndSpace(br *bufio.Reader) error {
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return br.UnreadByte()
+		}
+	}
+}
+
+// decodeJSONObject decodes a single JSON object from r.
+func decodeJSONObject(r io.Reader) (map[string]any, error) {
+	var payload map[string]any
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		if errors.Is(err, ErrCallbackTooLarge) {
+			return nil, err
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid callback body: %w", err)
+	}
+	if payload == nil {
+		return nil, errors.New("invalid callback body: not a JSON object")
+	}
+	return payload, nil
+}
+
+// cappedReader reads up to remaining bytes from r and fails with ErrCallbackTooLarge if r
+// holds more data than that.
+type cappedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		var probe [1]byte
+		n, err := c.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrCallbackTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
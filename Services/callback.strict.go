@@ -0,0 +1,235 @@
+package Services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrInvalidCallback is wrapped by every field error reported by the strict callback parsers,
+// so errors.Is(err, ErrInvalidCallback) identifies a payload that parsed but is incomplete.
+var ErrInvalidCallback = errors.New("invalid callback")
+
+// WithStrictParsing makes handlers parse callbacks with the checks of the strict parsers:
+// ParseSTKCallbackStrict for STK Push, ParseC2BConfirmationStrict for C2B validation and
+// confirmation, and ParseResultEnvelopeStrict for the result callbacks of the asynchronous APIs.
+// A callback failing them is handled like one that cannot be parsed: it is passed to the error
+// handler and the parse error hook, and answered according to the ack policy. Queue timeout
+// notifications and Bill Manager payments are parsed as usual.
+func WithStrictParsing() HandlerOption {
+	return func(o *handlerOptions) {
+		o.strict = true
+	}
+}
+
+// parseSTKCallback parses an STK Push callback for a handler, strictly under WithStrictParsing.
+func (o *handlerOptions) parseSTKCallback(payload map[string]any) (*STKCallback, error) {
+	if o.strict {
+		return ParseSTKCallbackStrict(payload)
+	}
+	return ParseSTKCallback(payload)
+}
+
+// parseC2BConfirmation parses a C2B validation or confirmation body for a handler, strictly
+// under WithStrictParsing.
+func (o *handlerOptions) parseC2BConfirmation(payload map[string]any) (*C2BConfirmation, error) {
+	if o.strict {
+		if err := checkCallbackFields(payload, c2bConfirmationFields); err != nil {
+			return nil, err
+		}
+	}
+	return newC2BConfirmation(payload)
+}
+
+// callbackField describes a field checked by the strict parsers.
+type callbackField struct {
+	name     string
+	required bool // Must be present and non-empty
+	numeric  bool // Numbers are accepted as well as strings
+}
+
+// resultEnvelopeFields are the Result node fields checked by ParseResultEnvelopeStrict.
+var resultEnvelopeFields = []callbackField{
+	{name: "ResultType", numeric: true},
+	{name: "ResultCode", required: true, numeric: true},
+	{name: "ResultDesc", required: true},
+	{name: "OriginatorConversationID", required: true},
+	{name: "ConversationID", required: true},
+	{name: "TransactionID"},
+}
+
+// stkCallbackFields are the Body.stkCallback fields checked by ParseSTKCallbackStrict.
+var stkCallbackFields = []callbackField{
+	{name: "MerchantRequestID", required: true},
+	{name: "CheckoutRequestID", required: true},
+	{name: "ResultCode", required: true, numeric: true},
+	{name: "ResultDesc", required: true},
+}
+
+// stkPaymentMetadata are the CallbackMetadata items ParseSTKCallbackStrict requires of a
+// successful payment.
+var stkPaymentMetadata = []string{"Amount", "MpesaReceiptNumber", "TransactionDate", "PhoneNumber"}
+
+// c2bConfirmationFields are the fields checked by ParseC2BConfirmationStrict.
+var c2bConfirmationFields = []callbackField{
+	{name: "TransactionType", required: true},
+	{name: "TransID", required: true},
+	{name: "TransTime", required: true, numeric: true},
+	{name: "TransAmount", required: true, numeric: true},
+	{name: "BusinessShortCode", required: true, numeric: true},
+	{name: "BillRefNumber", numeric: true},
+	{name: "MSISDN", required: true, numeric: true},
+}
+
+// checkCallbackFields reports every missing, empty or wrongly typed field of node as one
+// joined error, or nil when all fields are valid.
+func checkCallbackFields(node map[string]any, fields []callbackField) error {
+	var errs []error
+	for _, f := range fields {
+		v, ok := lookupKey(node, f.name)
+		if !ok || v == nil {
+			if f.required {
+				errs = append(errs, fmt.Errorf("%w: %s is missing", ErrInvalidCallback, f.name))
+			}
+			continue
+		}
+		switch t := v.(type) {
+		case string:
+			if f.required && strings.TrimSpace(t) == "" {
+				errs = append(errs, fmt.Errorf("%w: %s is empty", ErrInvalidCallback, f.name))
+			}
+		case float64, int, int64, json.Number:
+			if !f.numeric {
+				errs = append(errs, fmt.Errorf("%w: %s has unexpected type number", ErrInvalidCallback, f.name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%w: %s has unexpected type %T", ErrInvalidCallback, f.name, v))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkKeyValueNode reports a ResultParameters/ReferenceData node, or an element of the item
+// list wrapped in it under wrapper, whose type is unexpected. null is accepted at any level,
+// as no parameters.
+func checkKeyValueNode(node map[string]any, name, wrapper string) error {
+	v, _ := lookupKey(node, name)
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		if inner, ok := lookupKey(t, wrapper); ok {
+			_, err := keyValueItems(inner, name+"."+wrapper)
+			return err
+		}
+		return nil
+	case []any:
+		_, err := keyValueItems(t, name)
+		return err
+	default:
+		return fmt.Errorf("%w: %s has unexpected type %T", ErrInvalidCallback, name, v)
+	}
+}
+
+// ParseResultEnvelopeStrict parses a result callback like ParseResultEnvelope, but fails when
+// ResultCode, ResultDesc, ConversationID or OriginatorConversationID is missing or empty, or
+// when a known field, or an element of ResultParameters or ReferenceData, has an unexpected
+// type. null parameter nodes, lists and elements are accepted as no parameters. All problems are reported in one joined error
+// whose parts wrap ErrInvalidCallback.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *ResultEnvelope: The parsed envelope, or nil on error
+//   - error: An error listing every invalid field
+func ParseResultEnvelopeStrict(payload map[string]any) (*ResultEnvelope, error) {
+	result, err := resultObject(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.Join(
+		checkCallbackFields(result, resultEnvelopeFields),
+		checkKeyValueNode(result, "ResultParameters", "ResultParameter"),
+		checkKeyValueNode(result, "ReferenceData", "ReferenceItem"),
+	); err != nil {
+		return nil, err
+	}
+	return ParseResultEnvelope(payload)
+}
+
+// ParseB2BCallbackStrict parses a B2B callback like ParseB2BCallback, with the checks of
+// ParseResultEnvelopeStrict.
+func ParseB2BCallbackStrict(payload map[string]any) (*B2BCallbackResult, error) {
+	if _, err := ParseResultEnvelopeStrict(payload); err != nil {
+		return nil, err
+	}
+	return ParseB2BCallback(payload)
+}
+
+// ParseB2CResultStrict parses a B2C result callback like ParseB2CResult, with the checks of
+// ParseResultEnvelopeStrict.
+func ParseB2CResultStrict(payload map[string]any) (*B2CResult, error) {
+	if _, err := ParseResultEnvelopeStrict(payload); err != nil {
+		return nil, err
+	}
+	return ParseB2CResult(payload)
+}
+
+// ParseSTKCallbackStrict parses an STK Push callback like ParseSTKCallback, but fails when
+// MerchantRequestID, CheckoutRequestID, ResultCode or ResultDesc is missing or empty, when a
+// known field or CallbackMetadata has an unexpected type, or when a successful payment lacks
+// the Amount, MpesaReceiptNumber, TransactionDate or PhoneNumber metadata item. All problems
+// are reported in one joined error whose parts wrap ErrInvalidCallback.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the CallBackURL
+//
+// Returns:
+//   - *STKCallback: The parsed callback, or nil on error
+//   - error: An error listing every invalid field
+func ParseSTKCallbackStrict(payload map[string]any) (*STKCallback, error) {
+	cb, err := ParseSTKCallback(payload)
+	if err != nil {
+		return nil, err
+	}
+	body, _ := lookupKey(payload, "Body")
+	node, _ := unwrapNode(body, "stkCallback").(map[string]any)
+
+	errs := []error{checkCallbackFields(node, stkCallbackFields)}
+	switch metadata, _ := lookupKey(node, "CallbackMetadata"); metadata.(type) {
+	case nil, map[string]any:
+	default:
+		errs = append(errs, fmt.Errorf("%w: CallbackMetadata has unexpected type %T", ErrInvalidCallback, metadata))
+	}
+	if cb.Success {
+		for _, name := range stkPaymentMetadata {
+			if strings.TrimSpace(cb.Metadata[name]) == "" {
+				errs = append(errs, fmt.Errorf("%w: CallbackMetadata item %s is missing", ErrInvalidCallback, name))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+// ParseC2BConfirmationStrict decodes a C2B confirmation or validation body like
+// ParseC2BConfirmation, but fails when TransactionType, TransID, TransTime, TransAmount,
+// BusinessShortCode or MSISDN is missing or empty, or when a known field has an
+// unexpected type. All problems are reported in one joined error.
+func ParseC2BConfirmationStrict(r io.Reader) (*C2BConfirmation, error) {
+	var payload map[string]any
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid C2B payload: %w", err)
+	}
+	if err := checkCallbackFields(payload, c2bConfirmationFields); err != nil {
+		return nil, err
+	}
+	return newC2BConfirmation(payload)
+}
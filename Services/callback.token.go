@@ -0,0 +1,76 @@
+package Services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CallbackTokenParam is the query parameter that carries the callback URL token.
+const CallbackTokenParam = "token"
+
+// callbackTokenBytes is the number of random bytes in a generated callback token.
+const callbackTokenBytes = 32
+
+// ErrInvalidCallbackToken is reported when a callback arrives without a valid URL token.
+var ErrInvalidCallbackToken = errors.New("callback URL token is missing or invalid")
+
+// GenerateCallbackToken returns a random, URL-safe token to append to registered callback URLs.
+// Daraja does not sign callbacks, so an unguessable token in the URL is what tells genuine
+// callbacks apart from forged ones.
+func GenerateCallbackToken() (string, error) {
+	b := make([]byte, callbackTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate callback token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AppendCallbackToken returns callbackURL with token added as the CallbackTokenParam query
+// parameter, replacing any token already present.
+func AppendCallbackToken(callbackURL, token string) (string, error) {
+	if token == "" {
+		return "", errors.New("callback token is required")
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid callback URL %q: %w", callbackURL, err)
+	}
+	query := u.Query()
+	query.Set(CallbackTokenParam, token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// VerifyURLToken rejects callbacks whose CallbackTokenParam query parameter does not match one
+// of the valid tokens with 403 Forbidden, before the body is read, and reports
+// ErrInvalidCallbackToken to the error handler. Pass the old and the new token while rotating,
+// until the URLs registered with Daraja have been updated. Empty tokens are ignored; if none
+// remain, every callback is rejected.
+func VerifyURLToken(valid ...string) HandlerOption {
+	tokens := make([][]byte, 0, len(valid))
+	for _, token := range valid {
+		if token != "" {
+			tokens = append(tokens, []byte(token))
+		}
+	}
+	return func(o *handlerOptions) {
+		o.urlTokens = tokens
+		o.verifyToken = true
+	}
+}
+
+// hasValidToken reports whether the request carries one of the configured URL tokens.
+// Every token is compared in constant time, so the response time does not reveal a match.
+func (o *handlerOptions) hasValidToken(r *http.Request) bool {
+	got := []byte(r.URL.Query().Get(CallbackTokenParam))
+	match := 0
+	for _, token := range o.urlTokens {
+		match |= subtle.ConstantTimeCompare(got, token)
+	}
+	return match == 1
+}
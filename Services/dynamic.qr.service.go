@@ -0,0 +1,227 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// TrxCode is the transaction type encoded in a dynamic M-Pesa QR code.
+type TrxCode string
+
+// Transaction types accepted by the Dynamic QR API.
+const (
+	TrxCodeBuyGoods       TrxCode = "BG" // Pay a merchant till (buy goods)
+	TrxCodeWithdrawAgent  TrxCode = "WA" // Withdraw cash at an agent
+	TrxCodePayBill        TrxCode = "PB" // Pay a paybill account
+	TrxCodeSendMoney      TrxCode = "SM" // Send money to a phone number
+	TrxCodeSendToBusiness TrxCode = "SB" // Send money to a business number
+)
+
+// Limits of the QR image size in pixels.
+const (
+	MinQRSize = 1
+	MaxQRSize = 500
+)
+
+// trxCodes lists the valid transaction types, in documentation order.
+var trxCodes = []TrxCode{TrxCodeBuyGoods, TrxCodeWithdrawAgent, TrxCodePayBill, TrxCodeSendMoney, TrxCodeSendToBusiness}
+
+// numericPattern matches identifiers made of digits only, such as tills and paybills.
+var numericPattern = regexp.MustCompile(`^\d+$`)
+
+// DynamicQRService generates dynamic M-Pesa QR codes that customers scan to pay.
+type DynamicQRService struct {
+	Config       *abstracts.MpesaConfig
+	Client       abstracts.MpesaInterface
+	merchantName string
+	refNo        string
+	amount       float64
+	trxCode      TrxCode
+	trxCodeErr   error
+	cpi          string
+	size         string
+	sizeErr      error
+	response     map[string]any
+}
+
+// NewDynamicQRService creates a new dynamic QR service instance.
+func NewDynamicQRService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *DynamicQRService {
+	return &DynamicQRService{
+		Config: cfg,
+		Client: client,
+		size:   "300",
+	}
+}
+
+// SetMerchantName sets the name shown to the customer when scanning the code.
+func (s *DynamicQRService) SetMerchantName(name string) *DynamicQRService {
+	s.merchantName = name
+	return s
+}
+
+// SetRefNo sets the transaction reference.
+func (s *DynamicQRService) SetRefNo(ref string) *DynamicQRService {
+	s.refNo = ref
+	return s
+}
+
+// SetAmount sets the amount in KES. Fractional amounts are rejected.
+func (s *DynamicQRService) SetAmount(amount float64) *DynamicQRService {
+	s.amount = amount
+	return s
+}
+
+// SetAmountValue sets the amount. Amounts with cents are rejected.
+func (s *DynamicQRService) SetAmountValue(amount abstracts.Amount) *DynamicQRService {
+	s.amount = amount.Float64()
+	return s
+}
+
+// SetTrxCode sets the transaction type; it must be one of the TrxCode constants.
+func (s *DynamicQRService) SetTrxCode(code TrxCode) *DynamicQRService {
+	s.trxCode = code
+	s.trxCodeErr = validateTrxCode(code)
+	return s
+}
+
+// SetCPI sets the credit party identifier. Its format depends on the TrxCode: a till for BG,
+// an agent number for WA, "paybill|account" for PB, a phone number for SM and a business
+// number for SB.
+func (s *DynamicQRService) SetCPI(cpi string) *DynamicQRService {
+	s.cpi = strings.TrimSpace(cpi)
+	return s
+}
+
+// SetSize sets the size of the QR image in pixels, between MinQRSize and MaxQRSize. It defaults to "300".
+func (s *DynamicQRService) SetSize(size string) *DynamicQRService {
+	s.size = strings.TrimSpace(size)
+	s.sizeErr = nil
+	if n, err := strconv.Atoi(s.size); err != nil || n < MinQRSize || n > MaxQRSize {
+		s.sizeErr = fmt.Errorf("invalid Size %q: must be a number between %d and %d", size, MinQRSize, MaxQRSize)
+	}
+	return s
+}
+
+// Generate validates the request and asks M-Pesa for a QR code.
+func (s *DynamicQRService) Generate() (map[string]any, error) {
+	// Validate required fields
+	if s.merchantName == "" {
+		return nil, errors.New("merchant name is required; call SetMerchantName")
+	}
+	if s.refNo == "" {
+		return nil, errors.New("reference number is required; call SetRefNo")
+	}
+	if s.amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	rounded, err := roundAmount(s.amount, RoundStrict)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	amount, err := amountField(abstracts.FromKES(rounded), dynamicQRAmountForm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if s.trxCode == "" {
+		return nil, errors.New("TrxCode is required; call SetTrxCode")
+	}
+	if s.trxCodeErr != nil {
+		return nil, s.trxCodeErr
+	}
+	if s.sizeErr != nil {
+		return nil, s.sizeErr
+	}
+	cpi, err := validateCPI(s.trxCode, s.cpi, s.Config.GetDefaultCountryCode())
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{
+		"MerchantName": s.merchantName,
+		"RefNo":        s.refNo,
+		"Amount":       amount, // whole shillings as a number, see dynamicQRAmountForm
+		"TrxCode":      string(s.trxCode),
+		"CPI":          cpi,
+		"Size":         s.size,
+	}
+
+	resp, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.DynamicQR)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	return resp, nil
+}
+
+// GetQRCode returns the base64 encoded QR image from the last response.
+func (s *DynamicQRService) GetQRCode() (string, error) {
+	if s.response == nil {
+		return "", errors.New("no QR response available")
+	}
+	code := responseString(s.response, "QRCode")
+	if code == "" {
+		return "", errors.New("QRCode not found in response")
+	}
+	return code, nil
+}
+
+// GetResponse returns the last API response stored by the service.
+func (s *DynamicQRService) GetResponse() map[string]any {
+	return s.response
+}
+
+// validateTrxCode checks that code is a known transaction type.
+func validateTrxCode(code TrxCode) error {
+	names := make([]string, len(trxCodes))
+	for i, c := range trxCodes {
+		if code == c {
+			return nil
+		}
+		names[i] = string(c)
+	}
+	return fmt.Errorf("invalid TrxCode %q: must be one of %s", code, strings.Join(names, ", "))
+}
+
+// validateCPI checks the credit party identifier against the format required by code and
+// returns the value to send; phone numbers are normalised for SM, local ones in countryCode.
+func validateCPI(code TrxCode, cpi, countryCode string) (string, error) {
+	if cpi == "" {
+		return "", fmt.Errorf("CPI is required for TrxCode %s; call SetCPI", code)
+	}
+	switch code {
+	case TrxCodeBuyGoods, TrxCodeWithdrawAgent, TrxCodeSendToBusiness:
+		if !numericPattern.MatchString(cpi) {
+			return "", fmt.Errorf("invalid CPI %q for TrxCode %s: must be a numeric %s", cpi, code, cpiDescription(code))
+		}
+	case TrxCodePayBill:
+		paybill, account, ok := strings.Cut(cpi, "|")
+		if !ok || !numericPattern.MatchString(paybill) || strings.TrimSpace(account) == "" {
+			return "", fmt.Errorf("invalid CPI %q for TrxCode PB: must be formatted as \"paybill|account\", e.g. \"12345|account\"", cpi)
+		}
+	case TrxCodeSendMoney:
+		phone, err := normalizePhone(cpi, countryCode)
+		if err != nil {
+			return "", fmt.Errorf("invalid CPI for TrxCode SM: %w", err)
+		}
+		return phone, nil
+	}
+	return cpi, nil
+}
+
+// cpiDescription names the credit party expected for the numeric transaction types.
+func cpiDescription(code TrxCode) string {
+	switch code {
+	case TrxCodeBuyGoods:
+		return "till number"
+	case TrxCodeWithdrawAgent:
+		return "agent number"
+	default:
+		return "business number"
+	}
+}
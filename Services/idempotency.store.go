@@ -0,0 +1,200 @@
+package Services
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// DefaultIdempotencyTTL is how long idempotency keys are remembered when no TTL is given.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// idempotencySweepInterval is how often MemoryIdempotencyStore drops expired keys.
+const idempotencySweepInterval = time.Minute
+
+// ErrDuplicateSubmission is returned when a payment is sent with an idempotency key that
+// was already used. The previously recorded response is returned alongside it when available.
+var ErrDuplicateSubmission = errors.New("duplicate submission: idempotency key already used")
+
+// IdempotencyStore records idempotency keys so that a payment is submitted at most once per key.
+// Implementations backed by shared storage (e.g. Redis) protect against duplicates across processes.
+type IdempotencyStore interface {
+	// Seen reports whether key was remembered and has not expired.
+	Seen(key string) bool
+	// Remember records key for ttl.
+	Remember(key string, ttl time.Duration)
+}
+
+// IdempotencyClaimer is an optional interface for stores that can check and remember a key
+// atomically. Stores that implement it are safe against concurrent submissions of the same key.
+type IdempotencyClaimer interface {
+	// Claim remembers key for ttl and returns true, or returns false if key was already remembered.
+	Claim(key string, ttl time.Duration) bool
+}
+
+// IdempotencyReleaser is an optional interface for stores that can release a key, so that a
+// payment M-Pesa definitely rejected can be corrected and sent again with the same key.
+type IdempotencyReleaser interface {
+	// Release forgets key.
+	Release(key string)
+}
+
+// IdempotencyResponseStore is an optional interface for stores that can keep the response of
+// the first submission, so duplicates can be answered with it.
+type IdempotencyResponseStore interface {
+	// RememberResponse records the response for a remembered key.
+	RememberResponse(key string, response map[string]any)
+	// Response returns the recorded response for key, if any.
+	Response(key string) (map[string]any, bool)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore with per-key expiry. Expired keys
+// are dropped when keys are remembered, at most once every minute. It implements
+// IdempotencyClaimer, IdempotencyReleaser and IdempotencyResponseStore and is safe for
+// concurrent use.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	clock     Abstracts.Clock
+	entries   map[string]idempotencyEntry
+	nextSweep time.Time // when expired keys are next dropped
+}
+
+// idempotencyEntry is a remembered key and the response of its submission.
+type idempotencyEntry struct {
+	expires  time.Time
+	response map[string]any
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory idempotency store.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{clock: Abstracts.SystemClock{}, entries: make(map[string]idempotencyEntry)}
+}
+
+// SetClock sets the clock keys expire by, e.g. an Abstracts.FakeClock in tests. A nil clock
+// restores the system clock.
+func (m *MemoryIdempotencyStore) SetClock(clock Abstracts.Clock) *MemoryIdempotencyStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if clock == nil {
+		clock = Abstracts.SystemClock{}
+	}
+	m.clock = clock
+	return m
+}
+
+// Seen reports whether key was remembered and has not expired.
+func (m *MemoryIdempotencyStore) Seen(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.lookup(key)
+	return ok
+}
+
+// Remember records key for ttl.
+func (m *MemoryIdempotencyStore) Remember(key string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, ttl)
+}
+
+// Claim atomically remembers key for ttl, returning false if it was already remembered.
+func (m *MemoryIdempotencyStore) Claim(key string, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lookup(key); ok {
+		return false
+	}
+	m.store(key, ttl)
+	return true
+}
+
+// Release forgets key, so that it can be claimed again.
+func (m *MemoryIdempotencyStore) Release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+// Len returns the number of keys held, including expired ones that have not been dropped yet.
+func (m *MemoryIdempotencyStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// RememberResponse records the response for a remembered key.
+func (m *MemoryIdempotencyStore) RememberResponse(key string, response map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if entry, ok := m.lookup(key); ok {
+		entry.response = response
+		m.entries[key] = entry
+	}
+}
+
+// Response returns the recorded response for key, if any.
+func (m *MemoryIdempotencyStore) Response(key string) (map[string]any, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.lookup(key)
+	if !ok || entry.response == nil {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// lookup returns the live entry for key, dropping it if it has expired. The caller must hold m.mu.
+func (m *MemoryIdempotencyStore) lookup(key string) (idempotencyEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return idempotencyEntry{}, false
+	}
+	if !m.clock.Now().Before(entry.expires) {
+		delete(m.entries, key)
+		return idempotencyEntry{}, false
+	}
+	return entry, true
+}
+
+// store remembers key for ttl, first dropping expired keys if they are due. The caller must
+// hold m.mu.
+func (m *MemoryIdempotencyStore) store(key string, ttl time.Duration) {
+	now := m.clock.Now()
+	if !now.Before(m.nextSweep) {
+		for k, entry := range m.entries {
+			if !now.Before(entry.expires) {
+				delete(m.entries, k)
+			}
+		}
+		m.nextSweep = now.Add(idempotencySweepInterval)
+	}
+	m.entries[key] = idempotencyEntry{expires: now.Add(ttl)}
+}
+
+// claimIdempotencyKey claims key in store, atomically when the store supports it.
+func claimIdempotencyKey(store IdempotencyStore, key string, ttl time.Duration) bool {
+	if claimer, ok := store.(IdempotencyClaimer); ok {
+		return claimer.Claim(key, ttl)
+	}
+	if store.Seen(key) {
+		return false
+	}
+	store.Remember(key, ttl)
+	return true
+}
+
+// releaseIdempotencyKey releases key in store after err, if the store supports it and err shows
+// that M-Pesa rejected the request without creating a payment: an API error with a 4xx status.
+// Transport errors and 5xx responses leave the outcome unknown, so the key stays claimed.
+func releaseIdempotencyKey(store IdempotencyStore, key string, err error) {
+	var apiErr *Abstracts.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode < http.StatusBadRequest || apiErr.StatusCode >= http.StatusInternalServerError {
+		return
+	}
+	if releaser, ok := store.(IdempotencyReleaser); ok {
+		releaser.Release(key)
+	}
+}
@@ -0,0 +1,33 @@
+package Services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IdentifierType identifies the kind of party (phone number, till, shortcode or paybill)
+// in the SenderIdentifierType and RecieverIdentifierType fields.
+type IdentifierType string
+
+// Identifier types accepted by the Daraja APIs.
+const (
+	IdentifierTypeMSISDN     IdentifierType = "1"  // Customer phone number
+	IdentifierTypeTillNumber IdentifierType = "2"  // Buy goods till number
+	IdentifierTypeShortcode  IdentifierType = "4"  // Organization shortcode
+	IdentifierTypePaybill    IdentifierType = "11" // Paybill number
+)
+
+// identifierTypes lists the valid identifier types.
+var identifierTypes = []IdentifierType{IdentifierTypeMSISDN, IdentifierTypeTillNumber, IdentifierTypeShortcode, IdentifierTypePaybill}
+
+// validateIdentifierType checks that value is a known identifier type; field names it in the error.
+func validateIdentifierType(field, value string) error {
+	names := make([]string, len(identifierTypes))
+	for i, t := range identifierTypes {
+		if value == string(t) {
+			return nil
+		}
+		names[i] = string(t)
+	}
+	return fmt.Errorf("invalid %s %q: must be one of %s", field, value, strings.Join(names, ", "))
+}
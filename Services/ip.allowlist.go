@@ -0,0 +1,186 @@
+package Services
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// DefaultSafaricomCIDRs are the addresses Safaricom publishes as the origin of Daraja callbacks.
+// Check the Daraja documentation for changes and replace them with IPAllowlist.SetCIDRs.
+var DefaultSafaricomCIDRs = []string{
+	"196.201.214.200/32",
+	"196.201.214.206/32",
+	"196.201.213.114/32",
+	"196.201.214.207/32",
+	"196.201.214.208/32",
+	"196.201.213.44/32",
+	"196.201.212.127/32",
+	"196.201.212.138/32",
+	"196.201.212.129/32",
+	"196.201.212.136/32",
+	"196.201.212.74/32",
+	"196.201.212.69/32",
+}
+
+// IPAllowlist is a list of CIDR ranges that can be replaced while it is in use.
+type IPAllowlist struct {
+	mu       sync.RWMutex
+	prefixes []netip.Prefix
+}
+
+// NewIPAllowlist creates an allowlist of the given CIDR ranges. Single addresses without a
+// prefix length are accepted too.
+func NewIPAllowlist(cidrs []string) (*IPAllowlist, error) {
+	l := &IPAllowlist{}
+	if err := l.SetCIDRs(cidrs); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetCIDRs replaces the allowed ranges. The list is left unchanged if any entry is invalid.
+func (l *IPAllowlist) SetCIDRs(cidrs []string) error {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	l.mu.Lock()
+	l.prefixes = prefixes
+	l.mu.Unlock()
+	return nil
+}
+
+// Contains reports whether ip is in one of the allowed ranges.
+func (l *IPAllowlist) Contains(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, prefix := range l.prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowlistOption configures IPAllowlistMiddleware.
+type AllowlistOption func(*allowlistOptions)
+
+// allowlistOptions holds the settings of IPAllowlistMiddleware.
+type allowlistOptions struct {
+	allowlist   *IPAllowlist
+	trustedHops int
+	onRejected  func(ip string, r *http.Request)
+}
+
+// WithAllowlist checks callers against list instead of DefaultSafaricomCIDRs. Keep a reference
+// to list to replace its ranges at runtime.
+func WithAllowlist(list *IPAllowlist) AllowlistOption {
+	return func(o *allowlistOptions) {
+		o.allowlist = list
+	}
+}
+
+// WithTrustedProxyHops takes the caller's address from the X-Forwarded-For header when the
+// service runs behind n proxies or load balancers that each append the address they received
+// the request from. The caller is then the n-th address from the right; addresses further
+// left can be set by the caller and are ignored. The default, 0, uses RemoteAddr only.
+func WithTrustedProxyHops(n int) AllowlistOption {
+	return func(o *allowlistOptions) {
+		o.trustedHops = n
+	}
+}
+
+// WithOnRejected calls fn with the caller's address for each rejected request, e.g. to raise
+// an alert. ip is empty when the address could not be determined.
+func WithOnRejected(fn func(ip string, r *http.Request)) AllowlistOption {
+	return func(o *allowlistOptions) {
+		o.onRejected = fn
+	}
+}
+
+// IPAllowlistMiddleware rejects requests that do not come from an allowed address with
+// 403 Forbidden before they reach next. By default only DefaultSafaricomCIDRs are allowed
+// and the caller's address is taken from RemoteAddr; see WithAllowlist and
+// WithTrustedProxyHops.
+//
+// Parameters:
+//   - next: The callback handler to protect
+//   - opts: Optional allowlist settings
+//
+// Returns:
+//   - http.Handler: The protected handler
+//
+// Example:
+//
+//	allowlist, _ := Services.NewIPAllowlist(Services.DefaultSafaricomCIDRs)
+//	http.Handle("/mpesa/stk", Services.IPAllowlistMiddleware(
+//	    Services.STKCallbackHandler(onSTK),
+//	    Services.WithAllowlist(allowlist),
+//	    Services.WithTrustedProxyHops(1),
+//	    Services.WithOnRejected(func(ip string, r *http.Request) { alert("callback from %s", ip) }),
+//	))
+func IPAllowlistMiddleware(next http.Handler, opts ...AllowlistOption) http.Handler {
+	o := &allowlistOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.allowlist == nil {
+		// The default ranges are valid, so this cannot fail.
+		o.allowlist, _ = NewIPAllowlist(DefaultSafaricomCIDRs)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, ok := o.clientIP(r)
+		if !ok || !o.allowlist.Contains(ip) {
+			if o.onRejected != nil {
+				addr := ""
+				if ip.IsValid() {
+					addr = ip.String()
+				}
+				o.onRejected(addr, r)
+			}
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the caller's address according to the trusted proxy hops.
+func (o *allowlistOptions) clientIP(r *http.Request) (netip.Addr, bool) {
+	if o.trustedHops <= 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip, err := netip.ParseAddr(host)
+		return ip, err == nil
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	if len(hops) < o.trustedHops {
+		return netip.Addr{}, false
+	}
+	ip, err := netip.ParseAddr(hops[len(hops)-o.trustedHops])
+	return ip, err == nil
+}
@@ -0,0 +1,84 @@
+package Services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// DefaultProcessedTTL is how long processed callback IDs are remembered when no TTL is given.
+const DefaultProcessedTTL = 72 * time.Hour
+
+// processedSweepInterval is how often MemoryProcessedStore drops expired IDs.
+const processedSweepInterval = time.Minute
+
+// ProcessedStore records the IDs of callbacks that have been processed, so that callbacks
+// redelivered by M-Pesa are only handled once. Implementations must be safe for concurrent use.
+type ProcessedStore interface {
+	// MarkProcessed atomically records id for ttl and returns true, or returns false if id
+	// was already recorded.
+	MarkProcessed(id string, ttl time.Duration) bool
+	// Forget removes id, so that a later delivery is processed again.
+	Forget(id string)
+}
+
+// MemoryProcessedStore is an in-memory ProcessedStore with per-ID expiry. Expired IDs are
+// dropped by MarkProcessed at most once every minute.
+type MemoryProcessedStore struct {
+	mu        sync.Mutex
+	clock     Abstracts.Clock
+	expires   map[string]time.Time
+	nextSweep time.Time // when MarkProcessed next drops expired IDs
+}
+
+// NewMemoryProcessedStore creates an empty in-memory processed store.
+func NewMemoryProcessedStore() *MemoryProcessedStore {
+	return &MemoryProcessedStore{clock: Abstracts.SystemClock{}, expires: make(map[string]time.Time)}
+}
+
+// SetClock sets the clock IDs expire by, e.g. an Abstracts.FakeClock in tests. A nil clock
+// restores the system clock.
+func (m *MemoryProcessedStore) SetClock(clock Abstracts.Clock) *MemoryProcessedStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if clock == nil {
+		clock = Abstracts.SystemClock{}
+	}
+	m.clock = clock
+	return m
+}
+
+// MarkProcessed atomically records id for ttl, returning false if it was already recorded.
+func (m *MemoryProcessedStore) MarkProcessed(id string, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	if !now.Before(m.nextSweep) {
+		for key, exp := range m.expires {
+			if !now.Before(exp) {
+				delete(m.expires, key)
+			}
+		}
+		m.nextSweep = now.Add(processedSweepInterval)
+	}
+	if exp, ok := m.expires[id]; ok && now.Before(exp) {
+		return false
+	}
+	m.expires[id] = now.Add(ttl)
+	return true
+}
+
+// Forget removes id.
+func (m *MemoryProcessedStore) Forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.expires, id)
+}
+
+// Len returns the number of IDs held, including expired ones that have not been dropped yet.
+func (m *MemoryProcessedStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.expires)
+}
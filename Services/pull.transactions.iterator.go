@@ -0,0 +1,131 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPullDone is returned by PullIterator.Next when all transactions have been read.
+var ErrPullDone = errors.New("no more pulled transactions")
+
+// PullIterator pages through Pull Transactions query results. Create one with
+// PullTransactionsService.Iterate.
+type PullIterator struct {
+	service     *PullTransactionsService
+	ctx         context.Context
+	windowStart time.Time
+	end         time.Time
+	offset      int
+	page        []PulledTransaction
+	lastPage    bool
+	done        bool
+	boundary    map[string]bool // IDs of the current page at its latest timestamp
+	skip        map[string]bool // IDs of the previous page at its latest timestamp
+}
+
+// Iterate returns an iterator over all transactions between start and end. Windows longer than
+// MaxPullWindow are split into consecutive windows, and the offset is advanced until a query
+// returns fewer than PullPageSize records. Transactions that two consecutive queries overlap
+// on, those at the latest timestamp of a page, are yielded once; only the IDs of that overlap
+// are kept, so memory use does not grow with the number of transactions.
+func (s *PullTransactionsService) Iterate(ctx context.Context, start, end time.Time) *PullIterator {
+	return &PullIterator{
+		service:     s,
+		ctx:         ctx,
+		windowStart: start,
+		end:         end,
+	}
+}
+
+// Next returns the next transaction, or ErrPullDone when there are no more. A failed query
+// or a cancelled context is returned as an error without advancing the cursor, so transactions
+// already returned stay valid and calling Next again retries the same page.
+func (it *PullIterator) Next() (*PulledTransaction, error) {
+	for {
+		if err := it.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(it.page) > 0 {
+			tx := it.page[0]
+			it.page = it.page[1:]
+			if tx.TransactionID != "" && it.skip[tx.TransactionID] {
+				continue
+			}
+			return &tx, nil
+		}
+		if it.done {
+			return nil, ErrPullDone
+		}
+		if err := it.fetch(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ForEach calls fn for every remaining transaction. It stops at the first error returned by
+// fn or by the query, and returns nil once all transactions have been read.
+func (it *PullIterator) ForEach(fn func(*PulledTransaction) error) error {
+	for {
+		tx, err := it.Next()
+		if errors.Is(err, ErrPullDone) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+}
+
+// fetch loads the next page, moving on to the next window when the current one is exhausted.
+func (it *PullIterator) fetch() error {
+	if it.lastPage {
+		it.windowStart = it.windowEnd()
+		it.offset = 0
+		it.lastPage = false
+		if !it.windowStart.Before(it.end) {
+			it.done = true
+			return nil
+		}
+	}
+
+	res, err := it.service.Query(it.windowStart, it.windowEnd(), it.offset)
+	if err != nil {
+		return err
+	}
+
+	it.page = res.Transactions
+	it.skip, it.boundary = it.boundary, boundaryIDs(res.Transactions)
+	it.offset += len(res.Transactions)
+	it.lastPage = len(res.Transactions) < PullPageSize
+	return nil
+}
+
+// windowEnd returns the end of the current query window.
+func (it *PullIterator) windowEnd() time.Time {
+	if end := it.windowStart.Add(MaxPullWindow); end.Before(it.end) {
+		return end
+	}
+	return it.end
+}
+
+// boundaryIDs returns the IDs of the transactions at the latest timestamp of page, which the
+// following query can return again.
+func boundaryIDs(page []PulledTransaction) map[string]bool {
+	var latest time.Time
+	for _, tx := range page {
+		if tx.TrxDate.After(latest) {
+			latest = tx.TrxDate
+		}
+	}
+	ids := make(map[string]bool)
+	for _, tx := range page {
+		if tx.TransactionID != "" && tx.TrxDate.Equal(latest) {
+			ids[tx.TransactionID] = true
+		}
+	}
+	return ids
+}
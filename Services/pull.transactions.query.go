@@ -0,0 +1,136 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxPullWindow is the longest date window Daraja accepts in a single pull query.
+const MaxPullWindow = 48 * time.Hour
+
+// PullPageSize is the maximum number of transactions returned by a single pull query.
+const PullPageSize = 100
+
+// pullDateLayout is the date format of the StartDate and EndDate query fields.
+const pullDateLayout = "2006-01-02 15:04:05"
+
+// PullResult is the decoded response of a Pull Transactions query.
+type PullResult struct {
+	RequestID       string              // ResponseRefID assigned by M-Pesa
+	ResponseCode    string              // "1000" on success
+	ResponseMessage string              // Human readable description of the response code
+	Transactions    []PulledTransaction // Transactions in the requested window and page
+	Raw             map[string]any      // The original response
+}
+
+// PulledTransaction is one transaction returned by a Pull Transactions query.
+type PulledTransaction struct {
+	TransactionID    string
+	TrxDate          time.Time // Transaction time (EAT)
+	Msisdn           string
+	Sender           string
+	TransactionType  string // e.g. "c2b-pay-bill-debit"
+	BillReference    string
+	Amount           float64
+	OrganizationName string
+}
+
+// NewPullResult decodes a pull query response. The transactions are usually nested as
+// Response[][] but a flat Response[] is accepted too; values may be strings or numbers and
+// keys are matched case-insensitively. Items that are not objects are skipped.
+func NewPullResult(resp map[string]any) *PullResult {
+	res := &PullResult{
+		RequestID:       responseString(resp, "ResponseRefID", "RequestID", "requestId"),
+		ResponseCode:    responseString(resp, "ResponseCode"),
+		ResponseMessage: responseString(resp, "ResponseMessage"),
+		Raw:             resp,
+	}
+	collectPulledTransactions(resp["Response"], &res.Transactions)
+	return res
+}
+
+// Query pulls the transactions between start and end, skipping offset records.
+// The window must not exceed MaxPullWindow; at most PullPageSize records are returned per call.
+func (s *PullTransactionsService) Query(start, end time.Time, offset int) (*PullResult, error) {
+	// Validate required fields
+	shortCode := chooseString(s.shortCode, s.Config.GetBusinessCode())
+	if shortCode == "" {
+		return nil, errors.New("shortcode is required; call SetShortCode or SetBusinessCode on mpesa config")
+	}
+	if err := validatePullWindow(start, end); err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative, got %d", offset)
+	}
+
+	data := map[string]any{
+		"ShortCode":   shortCode,
+		"StartDate":   start.In(mpesaLocation).Format(pullDateLayout),
+		"EndDate":     end.In(mpesaLocation).Format(pullDateLayout),
+		"OffSetValue": fmt.Sprint(offset),
+	}
+
+	resp, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.PullQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	return NewPullResult(resp), nil
+}
+
+// validatePullWindow checks that start and end are set, ordered and at most MaxPullWindow apart.
+func validatePullWindow(start, end time.Time) error {
+	if start.IsZero() || end.IsZero() {
+		return errors.New("start and end dates are required")
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end date %s must be after start date %s", end.Format(pullDateLayout), start.Format(pullDateLayout))
+	}
+	if window := end.Sub(start); window > MaxPullWindow {
+		return fmt.Errorf("date window must not exceed %s, got %s", MaxPullWindow, window)
+	}
+	return nil
+}
+
+// collectPulledTransactions appends the transactions found in node, descending into nested arrays.
+func collectPulledTransactions(node any, out *[]PulledTransaction) {
+	switch v := node.(type) {
+	case []any:
+		for _, item := range v {
+			collectPulledTransactions(item, out)
+		}
+	case map[string]any:
+		*out = append(*out, newPulledTransaction(v))
+	}
+}
+
+// newPulledTransaction decodes a single pulled transaction.
+func newPulledTransaction(item map[string]any) PulledTransaction {
+	return PulledTransaction{
+		TransactionID:    responseString(item, "transactionId"),
+		TrxDate:          parsePullTime(responseString(item, "trxDate")),
+		Msisdn:           responseString(item, "msisdn"),
+		Sender:           responseString(item, "sender"),
+		TransactionType:  responseString(item, "transactiontype"),
+		BillReference:    responseString(item, "billreference"),
+		Amount:           parseAmount(responseString(item, "amount")),
+		OrganizationName: responseString(item, "organizationname"),
+	}
+}
+
+// parsePullTime parses the trxDate of a pulled transaction, sent either as RFC 3339 or as
+// "yyyy-MM-dd HH:mm:ss" in EAT. Malformed values yield the zero time.
+func parsePullTime(v string) time.Time {
+	v = strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation(pullDateLayout, v, mpesaLocation); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,171 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLedgerDone is returned by a LedgerIterator when all ledger entries have been read.
+var ErrLedgerDone = errors.New("no more ledger entries")
+
+// LedgerLookup reports whether a transaction is recorded in the caller's ledger.
+type LedgerLookup interface {
+	Exists(transactionID string) (bool, error)
+}
+
+// LedgerIterator walks the transaction IDs recorded in the caller's ledger. Next returns
+// ErrLedgerDone once all entries have been read.
+type LedgerIterator interface {
+	Next() (transactionID string, err error)
+}
+
+// ReconciliationReport is the outcome of reconciling pulled transactions against a ledger.
+// The counts are always set; the lists are only collected for results that have no callback
+// on the Reconciler.
+type ReconciliationReport struct {
+	MatchedCount    int                 // Number of pulled transactions found in the ledger
+	MissingCount    int                 // Number of pulled transactions not found in the ledger
+	LedgerOnlyCount int                 // Number of ledger entries M-Pesa did not return
+	Matched         []PulledTransaction // Pulled transactions found in the ledger, unless OnMatched is set
+	Missing         []PulledTransaction // Pulled transactions not found in the ledger, unless OnMissing is set
+	LedgerOnly      []string            // Ledger entries M-Pesa did not return, unless OnLedgerOnly is set; only set with a ledger iterator
+	Errors          []error             // Ledger lookups that failed; the transaction is in neither list
+}
+
+// Reconciler checks pulled M-Pesa transactions against the caller's ledger.
+type Reconciler struct {
+	ledger       LedgerLookup
+	entries      LedgerIterator
+	onMatched    func(*PulledTransaction) error
+	onMissing    func(*PulledTransaction) error
+	onLedgerOnly func(transactionID string) error
+}
+
+// NewReconciler creates a reconciler that looks up each pulled transaction in ledger.
+func NewReconciler(ledger LedgerLookup) *Reconciler {
+	return &Reconciler{ledger: ledger}
+}
+
+// SetLedgerIterator also reconciles in the opposite direction: after the pulled transactions
+// have been checked, every ledger entry M-Pesa did not return is reported in LedgerOnly. This
+// direction does not stream: the ID of every pulled transaction is held in memory until the
+// ledger has been read, so use it for periods whose IDs fit in memory.
+func (r *Reconciler) SetLedgerIterator(entries LedgerIterator) *Reconciler {
+	r.entries = entries
+	return r
+}
+
+// OnMatched streams the pulled transactions found in the ledger to fn instead of collecting
+// them in the report. An error from fn stops reconciliation and is returned.
+func (r *Reconciler) OnMatched(fn func(tx *PulledTransaction) error) *Reconciler {
+	r.onMatched = fn
+	return r
+}
+
+// OnMissing streams the pulled transactions not found in the ledger to fn instead of
+// collecting them in the report. An error from fn stops reconciliation and is returned.
+//
+// Example:
+//
+//	reconciler := Services.NewReconciler(ledger).OnMissing(func(tx *Services.PulledTransaction) error {
+//	    return csvWriter.Write([]string{tx.TransactionID, fmt.Sprint(tx.Amount)})
+//	})
+func (r *Reconciler) OnMissing(fn func(tx *PulledTransaction) error) *Reconciler {
+	r.onMissing = fn
+	return r
+}
+
+// OnLedgerOnly streams the ledger entries M-Pesa did not return to fn instead of collecting
+// them in the report. An error from fn stops reconciliation and is returned.
+func (r *Reconciler) OnLedgerOnly(fn func(transactionID string) error) *Reconciler {
+	r.onLedgerOnly = fn
+	return r
+}
+
+// Reconcile reads the transactions from it one at a time and looks each one up in the ledger.
+// With OnMatched and OnMissing set, and no ledger iterator, it streams: results go to the
+// callbacks as they are found and only the counts are kept. Otherwise the transactions without
+// a callback are collected in the report. A failed lookup is recorded in the report and
+// reconciliation continues; a failed query, a failed ledger iterator, an error from a callback
+// or a cancelled context stops it and is returned together with the partial report.
+//
+// Example:
+//
+//	it := pullService.Iterate(ctx, start, end)
+//	report, err := Services.NewReconciler(ledger).Reconcile(ctx, it)
+//	for _, tx := range report.Missing {
+//	    log.Printf("not in ledger: %s %.2f", tx.TransactionID, tx.Amount)
+//	}
+func (r *Reconciler) Reconcile(ctx context.Context, it *PullIterator) (*ReconciliationReport, error) {
+	if r.ledger == nil {
+		return nil, errors.New("ledger lookup is required; pass it to NewReconciler")
+	}
+
+	report := &ReconciliationReport{}
+	var pulled map[string]bool
+	if r.entries != nil {
+		pulled = make(map[string]bool)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		tx, err := it.Next()
+		if errors.Is(err, ErrPullDone) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+		if pulled != nil {
+			pulled[tx.TransactionID] = true
+		}
+
+		found, err := r.ledger.Exists(tx.TransactionID)
+		switch {
+		case err != nil:
+			report.Errors = append(report.Errors, fmt.Errorf("ledger lookup of %s: %w", tx.TransactionID, err))
+		case found:
+			report.MatchedCount++
+			if r.onMatched == nil {
+				report.Matched = append(report.Matched, *tx)
+			} else if err := r.onMatched(tx); err != nil {
+				return report, err
+			}
+		default:
+			report.MissingCount++
+			if r.onMissing == nil {
+				report.Missing = append(report.Missing, *tx)
+			} else if err := r.onMissing(tx); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	if r.entries == nil {
+		return report, nil
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		id, err := r.entries.Next()
+		if errors.Is(err, ErrLedgerDone) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if pulled[id] {
+			continue
+		}
+		report.LedgerOnlyCount++
+		if r.onLedgerOnly == nil {
+			report.LedgerOnly = append(report.LedgerOnly, id)
+		} else if err := r.onLedgerOnly(id); err != nil {
+			return report, err
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package Services
+
+import (
+	"errors"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// PullTransactionsService registers a shortcode for the Pull Transactions API and queries it,
+// which lets the organisation pull its own transaction history for reconciliation.
+type PullTransactionsService struct {
+	Config          *abstracts.MpesaConfig
+	Client          abstracts.MpesaInterface
+	shortCode       string
+	nominatedNumber string
+	nominatedErr    error
+	callbackURL     string
+	response        map[string]any
+	typedResponse   *PullRegisterResponse
+}
+
+// PullRegisterResponse is the response of the Pull Transactions register API.
+type PullRegisterResponse struct {
+	ResponseRefID       string // Unique ID assigned by M-Pesa to the request
+	ResponseStatus      string `mpesa:"ResponseStatus|Response Status"` // Status code of the registration
+	ShortCode           string // The registered shortcode
+	ResponseDescription string `mpesa:"ResponseDescription|Response Description"` // Human readable description of the status
+}
+
+// NewPullRegisterResponse decodes a register response from a raw API response.
+// Values are accepted as strings or numbers, and key casing and the spaced keys
+// ("Response Status") Daraja sometimes uses are tolerated.
+func NewPullRegisterResponse(resp map[string]any) *PullRegisterResponse {
+	r, _ := abstracts.DecodeInto[PullRegisterResponse](resp)
+	return &r
+}
+
+// NewPullTransactionsService creates a new pull transactions service instance.
+func NewPullTransactionsService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *PullTransactionsService {
+	return &PullTransactionsService{
+		Config: cfg,
+		Client: client,
+	}
+}
+
+// SetShortCode sets the shortcode to register. It defaults to the config business code.
+func (s *PullTransactionsService) SetShortCode(code string) *PullTransactionsService {
+	s.shortCode = code
+	return s
+}
+
+// SetNominatedNumber sets the Safaricom number that receives the registration notifications.
+// It is normalised to the 2547XXXXXXXX format; invalid numbers are reported by Register.
+func (s *PullTransactionsService) SetNominatedNumber(phone string) *PullTransactionsService {
+	s.nominatedNumber, s.nominatedErr = normalizePhone(phone, s.Config.GetDefaultCountryCode())
+	return s
+}
+
+// SetCallbackURL sets the URL that receives pulled transactions.
+func (s *PullTransactionsService) SetCallbackURL(url string) *PullTransactionsService {
+	s.callbackURL = url
+	return s
+}
+
+// Register validates the request and registers the shortcode for transaction pulling.
+func (s *PullTransactionsService) Register() (map[string]any, error) {
+	// Validate required fields
+	shortCode := chooseString(s.shortCode, s.Config.GetBusinessCode())
+	if shortCode == "" {
+		return nil, errors.New("shortcode is required; call SetShortCode or SetBusinessCode on mpesa config")
+	}
+	if s.nominatedErr != nil {
+		return nil, s.nominatedErr
+	}
+	if s.nominatedNumber == "" {
+		return nil, errors.New("nominated number is required; call SetNominatedNumber")
+	}
+	if err := validateCallbackURL("callback URL", s.callbackURL, "call SetCallbackURL", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{
+		"ShortCode":       shortCode,
+		"RequestType":     "Pull",
+		"NominatedNumber": s.nominatedNumber,
+		"CallBackURL":     s.callbackURL,
+	}
+
+	resp, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.PullRegister)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	s.typedResponse = NewPullRegisterResponse(resp)
+	return resp, nil
+}
+
+// RegisterTyped registers the shortcode like Register and returns the decoded response.
+func (s *PullTransactionsService) RegisterTyped() (*PullRegisterResponse, error) {
+	if _, err := s.Register(); err != nil {
+		return nil, err
+	}
+	return s.typedResponse, nil
+}
+
+// GetResponse returns the last API response stored by the service.
+func (s *PullTransactionsService) GetResponse() map[string]any {
+	return s.response
+}
+
+// GetTypedResponse returns the decoded response from the last request, or nil.
+func (s *PullTransactionsService) GetTypedResponse() *PullRegisterResponse {
+	return s.typedResponse
+}
@@ -0,0 +1,101 @@
+package Services
+
+import "errors"
+
+// queueTimeoutResultCodes are the result codes of timeout notifications that M-Pesa wraps in a
+// Result node like a regular result.
+var queueTimeoutResultCodes = map[string]bool{
+	"SFC_IC0003": true, // The service request timed out
+}
+
+// queueTimeoutKeys identify a timeout notification without a Result node; a payload with none
+// of them is not a notification at all.
+var queueTimeoutKeys = []string{"OriginatorConversationID", "ConversationID", "requestId", "errorCode"}
+
+// queueTimeoutRequestKeys are identifiers of the original request that some timeout
+// notifications echo.
+var queueTimeoutRequestKeys = []string{
+	"CommandID", "Initiator", "InitiatorName", "PartyA", "PartyB", "Amount", "AccountReference", "Occasion",
+}
+
+// QueueTimeoutResult represents a notification delivered to the QueueTimeOutURL when a request
+// timed out in the M-Pesa queue before it was processed. The request may be retried.
+type QueueTimeoutResult struct {
+	OriginatorConversationID string
+	ConversationID           string
+	TransactionID            string
+	RequestID                string            // requestId, in the error shaped notifications
+	ResultCode               string            // ResultCode or errorCode
+	ResultDesc               string            // ResultDesc or errorMessage
+	Request                  map[string]string // Identifiers of the original request echoed in the payload, e.g. CommandID
+	ReferenceData            map[string]string // Reference items as sent by M-Pesa
+	Raw                      map[string]any    // The original payload
+}
+
+// IsQueueTimeout reports whether payload is a queue timeout notification rather than a result.
+// Notifications either have no Result node, in which case they carry the conversation IDs or
+// an error code at the top level, or a Result node with a timeout result code. The combined
+// result handlers use it to route payloads to their onTimeout callback.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL or QueueTimeOutURL
+//
+// Returns:
+//   - bool: true if payload is a queue timeout notification
+func IsQueueTimeout(payload map[string]any) bool {
+	if result, err := resultObject(payload); err == nil {
+		code, _ := lookupKey(result, "ResultCode")
+		return queueTimeoutResultCodes[toString(code)]
+	}
+	for _, key := range queueTimeoutKeys {
+		if _, ok := payload[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseQueueTimeout parses a queue timeout notification into a QueueTimeoutResult.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the QueueTimeOutURL
+//
+// Returns:
+//   - *QueueTimeoutResult: The parsed notification
+//   - error: An error if payload is not a queue timeout notification; see IsQueueTimeout
+//
+// Example:
+//
+//	timeout, err := Services.ParseQueueTimeout(payload)
+//	if err == nil {
+//	    retryPayout(timeout.OriginatorConversationID)
+//	}
+func ParseQueueTimeout(payload map[string]any) (*QueueTimeoutResult, error) {
+	if !IsQueueTimeout(payload) {
+		return nil, errors.New("payload is not a queue timeout notification")
+	}
+
+	node := payload
+	if result, err := resultObject(payload); err == nil {
+		node = result
+	}
+	t := &QueueTimeoutResult{
+		OriginatorConversationID: responseString(node, "OriginatorConversationID"),
+		ConversationID:           responseString(node, "ConversationID"),
+		TransactionID:            responseString(node, "TransactionID"),
+		RequestID:                responseString(node, "requestId"),
+		ResultCode:               responseString(node, "ResultCode", "errorCode"),
+		ResultDesc:               responseString(node, "ResultDesc", "errorMessage"),
+		Request:                  make(map[string]string),
+		ReferenceData:            make(map[string]string),
+		Raw:                      payload,
+	}
+	for _, key := range queueTimeoutRequestKeys {
+		if v, ok := node[key]; ok && v != nil {
+			t.Request[key] = toString(v)
+		}
+	}
+	reference, _ := lookupKey(node, "ReferenceData")
+	parseKeyValueItems(unwrapNode(reference, "ReferenceItem"), t.ReferenceData)
+	return t, nil
+}
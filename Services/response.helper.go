@@ -0,0 +1,52 @@
+package Services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// responseString returns the value stored under the first matching key, converted to a string.
+// Daraja is inconsistent about both value types (string or number) and key spelling/casing,
+// so keys are tried in order and then matched case-insensitively.
+func responseString(resp map[string]any, keys ...string) string {
+	if resp == nil {
+		return ""
+	}
+	for _, key := range keys {
+		if v, ok := resp[key]; ok && v != nil {
+			return toString(v)
+		}
+	}
+	for _, key := range keys {
+		for k, v := range resp {
+			if v != nil && strings.EqualFold(strings.TrimSpace(k), key) {
+				return toString(v)
+			}
+		}
+	}
+	return ""
+}
+
+// toString converts a JSON value (string, number or nil) to its string form.
+// Whole numbers are formatted without a decimal point.
+func toString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		if t == math.Trunc(t) {
+			return strconv.FormatInt(int64(t), 10)
+		}
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,272 @@
+package Services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// DefaultPendingResultTTL is how long results that arrive before anyone waits for them are
+// kept when no TTL is given.
+const DefaultPendingResultTTL = 10 * time.Minute
+
+// DefaultStatusFallbackWait is how long the status fallback waits for the transaction status
+// result when no wait is given.
+const DefaultStatusFallbackWait = 30 * time.Second
+
+// ErrResultTimeout is returned by the SendAndWait methods when no result callback arrived
+// before the context deadline, and the status fallback did not produce a result either.
+var ErrResultTimeout = errors.New("timed out waiting for the result callback")
+
+// CorrelatedResult is the outcome of an asynchronous request as delivered to its ResultURL or
+// QueueTimeOutURL, matched to the request by a ResultCorrelator.
+type CorrelatedResult struct {
+	Type                     CallbackType // The callback route the result arrived on, e.g. CallbackB2CResult
+	ConversationID           string
+	OriginatorConversationID string
+	ResultCode               string
+	ResultDesc               string
+	TransactionID            string
+	Success                  bool           // true when ResultCode is 0
+	QueueTimeout             bool           // true when the request timed out in the M-Pesa queue
+	Result                   any            // The typed result, e.g. *B2CResult, or a *QueueTimeoutResult
+	Raw                      map[string]any // The original payload
+}
+
+// ResultCorrelator matches result callbacks to the requests waiting for them by their
+// ConversationID or OriginatorConversationID. Handlers configured with WithResultCorrelator
+// resolve the results they receive, and the SendAndWait methods register the IDs of the
+// requests they send. Implementations backed by shared storage or a message bus let the
+// callback arrive at a different process than the one waiting; they must be safe for
+// concurrent use.
+type ResultCorrelator interface {
+	// Register returns a channel that receives the result for id. A result resolved before
+	// Register is called is delivered too, as long as it is still remembered.
+	Register(id string) <-chan CorrelatedResult
+	// Resolve delivers result to the waiter registered for id, or remembers it for a later
+	// Register.
+	Resolve(id string, result CorrelatedResult)
+	// Cancel removes the registration for id, e.g. when the waiter gave up.
+	Cancel(id string)
+}
+
+// MemoryResultCorrelator is an in-memory ResultCorrelator for callbacks received by the same
+// process that sent the request. It is safe for concurrent use.
+type MemoryResultCorrelator struct {
+	mu         sync.Mutex
+	clock      Abstracts.Clock
+	pendingTTL time.Duration
+	waiters    map[string]chan CorrelatedResult
+	pending    map[string]pendingResult
+}
+
+// pendingResult is a result that was resolved before anyone registered for it.
+type pendingResult struct {
+	result  CorrelatedResult
+	expires time.Time
+}
+
+// NewMemoryResultCorrelator creates an empty in-memory correlator. Results nobody is waiting
+// for yet are kept for pendingTTL (DefaultPendingResultTTL when zero or negative), which
+// covers callbacks that arrive before the acknowledgement of their request.
+func NewMemoryResultCorrelator(pendingTTL time.Duration) *MemoryResultCorrelator {
+	if pendingTTL <= 0 {
+		pendingTTL = DefaultPendingResultTTL
+	}
+	return &MemoryResultCorrelator{
+		clock:      Abstracts.SystemClock{},
+		pendingTTL: pendingTTL,
+		waiters:    make(map[string]chan CorrelatedResult),
+		pending:    make(map[string]pendingResult),
+	}
+}
+
+// SetClock sets the clock results nobody is waiting for expire by, e.g. an
+// Abstracts.FakeClock in tests. A nil clock restores the system clock.
+func (m *MemoryResultCorrelator) SetClock(clock Abstracts.Clock) *MemoryResultCorrelator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if clock == nil {
+		clock = Abstracts.SystemClock{}
+	}
+	m.clock = clock
+	return m
+}
+
+// Register returns a channel that receives the result for id.
+func (m *MemoryResultCorrelator) Register(id string) <-chan CorrelatedResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := make(chan CorrelatedResult, 1)
+	if p, ok := m.pending[id]; ok && m.clock.Now().Before(p.expires) {
+		delete(m.pending, id)
+		ch <- p.result
+		return ch
+	}
+	m.waiters[id] = ch
+	return ch
+}
+
+// Resolve delivers result to the waiter for id, or keeps it for a later Register.
+func (m *MemoryResultCorrelator) Resolve(id string, result CorrelatedResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ch, ok := m.waiters[id]; ok {
+		delete(m.waiters, id)
+		ch <- result
+		return
+	}
+
+	now := m.clock.Now()
+	for key, p := range m.pending {
+		if !now.Before(p.expires) {
+			delete(m.pending, key)
+		}
+	}
+	m.pending[id] = pendingResult{result: result, expires: now.Add(m.pendingTTL)}
+}
+
+// Cancel removes the registration for id.
+func (m *MemoryResultCorrelator) Cancel(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.waiters, id)
+}
+
+// WithResultCorrelator resolves every result and queue timeout received by the handler in
+// correlator, under both its ConversationID and its OriginatorConversationID, before the
+// callback function is called. Supported by the B2C, B2B, reversal, account balance and
+// transaction status handlers.
+func WithResultCorrelator(correlator ResultCorrelator) HandlerOption {
+	return func(o *handlerOptions) {
+		o.correlator = correlator
+	}
+}
+
+// resolveResult resolves result in the configured correlator, if any.
+func (o *handlerOptions) resolveResult(result CorrelatedResult) {
+	if o.correlator == nil {
+		return
+	}
+	if result.ConversationID != "" {
+		o.correlator.Resolve(result.ConversationID, result)
+	}
+	if result.OriginatorConversationID != "" && result.OriginatorConversationID != result.ConversationID {
+		o.correlator.Resolve(result.OriginatorConversationID, result)
+	}
+}
+
+// correlatedResult builds the CorrelatedResult of a parsed result callback.
+func correlatedResult(callback CallbackType, payload map[string]any, result any) CorrelatedResult {
+	res := CorrelatedResult{Type: callback, Result: result, Raw: payload}
+	if env, err := ParseResultEnvelope(payload); err == nil {
+		res.ConversationID = env.ConversationID
+		res.OriginatorConversationID = env.OriginatorConversationID
+		res.ResultCode = env.ResultCode
+		res.ResultDesc = env.ResultDesc
+		res.TransactionID = env.TransactionID
+		res.Success = env.Success
+	}
+	return res
+}
+
+// correlatedTimeout builds the CorrelatedResult of a queue timeout notification.
+func correlatedTimeout(callback CallbackType, payload map[string]any) CorrelatedResult {
+	res := CorrelatedResult{Type: callback, QueueTimeout: true, Raw: payload}
+	if t, err := ParseQueueTimeout(payload); err == nil {
+		res.Result = t
+		res.ConversationID = t.ConversationID
+		res.OriginatorConversationID = t.OriginatorConversationID
+		res.ResultCode = t.ResultCode
+		res.ResultDesc = t.ResultDesc
+		res.TransactionID = t.TransactionID
+	}
+	return res
+}
+
+// resultWaiter holds the SendAndWait settings shared by the asynchronous services.
+type resultWaiter struct {
+	correlator   ResultCorrelator
+	fallback     *TransactionStatusService
+	fallbackWait time.Duration
+}
+
+// setStatusFallback configures the status fallback, defaulting the wait.
+func (rw *resultWaiter) setStatusFallback(status *TransactionStatusService, wait time.Duration) {
+	if wait <= 0 {
+		wait = DefaultStatusFallbackWait
+	}
+	rw.fallback = status
+	rw.fallbackWait = wait
+}
+
+// sendAndWait sends a request with send and waits for its result callback. originatorID, when
+// known before sending, is registered before the request goes out; the IDs from the
+// acknowledgement are registered after it. When ctx expires first and a status fallback is
+// configured, the transaction status is queried by the request's OriginatorConversationID.
+func (rw resultWaiter) sendAndWait(ctx context.Context, originatorID string, send func() (map[string]any, error)) (*CorrelatedResult, error) {
+	if rw.correlator == nil {
+		return nil, errors.New("result correlator is required; call SetResultCorrelator")
+	}
+
+	var byOriginator, byConversation <-chan CorrelatedResult
+	var registered []string
+	register := func(id string) <-chan CorrelatedResult {
+		registered = append(registered, id)
+		return rw.correlator.Register(id)
+	}
+	defer func() {
+		for _, id := range registered {
+			rw.correlator.Cancel(id)
+		}
+	}()
+
+	if originatorID != "" {
+		byOriginator = register(originatorID)
+	}
+	response, err := send()
+	if err != nil {
+		return nil, err
+	}
+	if id := responseString(response, "ConversationID"); id != "" {
+		byConversation = register(id)
+	}
+	if id := responseString(response, "OriginatorConversationID"); originatorID == "" && id != "" {
+		originatorID = id
+		byOriginator = register(id)
+	}
+	if byOriginator == nil && byConversation == nil {
+		return nil, errors.New("response has no ConversationID to wait for")
+	}
+
+	select {
+	case res := <-byOriginator:
+		return &res, nil
+	case res := <-byConversation:
+		return &res, nil
+	case <-ctx.Done():
+	}
+	if rw.fallback == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) || originatorID == "" {
+		return nil, fmt.Errorf("%w: %w", ErrResultTimeout, ctx.Err())
+	}
+	return rw.queryStatus(originatorID)
+}
+
+// queryStatus queries the status of the request with originatorID and waits for the result
+// of the query for the fallback wait. The query is built on a copy of the fallback service,
+// which may be shared by concurrent waits.
+func (rw resultWaiter) queryStatus(originatorID string) (*CorrelatedResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rw.fallbackWait)
+	defer cancel()
+
+	status := resultWaiter{correlator: rw.correlator}
+	res, err := status.sendAndWait(ctx, "", rw.fallback.queryFor(originatorID).Query)
+	if err != nil {
+		return nil, fmt.Errorf("%w; status fallback failed: %w", ErrResultTimeout, err)
+	}
+	return res, nil
+}
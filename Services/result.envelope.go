@@ -0,0 +1,184 @@
+package Services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResultEnvelope is the common Result node M-Pesa posts to the ResultURL of the asynchronous
+// APIs (B2C, B2B, reversal, account balance and transaction status).
+type ResultEnvelope struct {
+	ResultType               string            `json:"ResultType"`
+	ResultCode               string            `json:"ResultCode"`
+	ResultDesc               string            `json:"ResultDesc"`
+	OriginatorConversationID string            `json:"OriginatorConversationID"`
+	ConversationID           string            `json:"ConversationID"`
+	TransactionID            string            `json:"TransactionID"`
+	ResultParameters         map[string]string `json:"ResultParameters"` // ResultParameters.ResultParameter as key/value pairs
+	ReferenceData            map[string]string `json:"ReferenceData"`    // ReferenceData.ReferenceItem as key/value pairs
+	Raw                      map[string]any    `json:"-"`                // The original payload
+	Success                  bool              `json:"-"`                // true when ResultCode is 0
+}
+
+// ParseResultEnvelope parses the Result node of an asynchronous result callback.
+// Values may be strings or numbers, and ResultParameter/ReferenceItem may be a single
+// object or an array; missing or null parameter nodes, lists and items yield empty maps, and
+// values of an unexpected type are skipped (see keyValueItems). Keys are matched ignoring
+// case and surrounding whitespace (see lookupKey), since some intermediaries rewrite them. It decodes payload exactly
+// like json.Unmarshal into a ResultEnvelope.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *ResultEnvelope: The parsed envelope
+//   - error: An error if the payload has no Result object
+func ParseResultEnvelope(payload map[string]any) (*ResultEnvelope, error) {
+	env := &ResultEnvelope{}
+	if err := decodePayload(payload, env.decode); err != nil {
+		return nil, err
+	}
+	env.Raw = payload
+	return env, nil
+}
+
+// UnmarshalJSON decodes the raw body of a result callback, as ParseResultEnvelope does.
+func (e *ResultEnvelope) UnmarshalJSON(data []byte) error {
+	if err := e.decode(data); err != nil {
+		return err
+	}
+	e.Raw = rawPayload(data)
+	return nil
+}
+
+// decode fills every field but Raw from the body of a result callback.
+func (e *ResultEnvelope) decode(data []byte) error {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	node, ok := lookupKey(payload, "Result")
+	if !ok {
+		return errors.New("payload missing Result node")
+	}
+	if !isJSONObject(node) {
+		return errors.New("Result node is not an object")
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(node, &result); err != nil {
+		return err
+	}
+
+	// Values may be strings or numbers, e.g. ResultCode.
+	field := func(key string) string {
+		var s flexString
+		if raw, ok := lookupKey(result, key); ok {
+			_ = json.Unmarshal(raw, &s)
+		}
+		return string(s)
+	}
+	parameters, _ := lookupKey(result, "ResultParameters")
+	reference, _ := lookupKey(result, "ReferenceData")
+	*e = ResultEnvelope{
+		ResultType:               field("ResultType"),
+		ResultCode:               field("ResultCode"),
+		ResultDesc:               field("ResultDesc"),
+		OriginatorConversationID: field("OriginatorConversationID"),
+		ConversationID:           field("ConversationID"),
+		TransactionID:            field("TransactionID"),
+		ResultParameters:         keyValues(parameters, "ResultParameter"),
+		ReferenceData:            keyValues(reference, "ReferenceItem"),
+	}
+	if i, err := strconv.Atoi(e.ResultCode); err == nil {
+		e.Success = i == 0
+	} else {
+		e.Success = e.ResultCode == "0"
+	}
+	return nil
+}
+
+// lookupKey returns the value of key in node. An exact match is preferred; otherwise keys are
+// compared ignoring case and surrounding whitespace, so that "resultCode" and " ResultCode "
+// both match "ResultCode".
+func lookupKey[V any](node map[string]V, key string) (V, bool) {
+	if v, ok := node[key]; ok {
+		return v, true
+	}
+	for k, v := range node {
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// resultObject returns the Result node of payload, whatever the case of its key.
+func resultObject(payload map[string]any) (map[string]any, error) {
+	resultNode, ok := lookupKey(payload, "Result")
+	if !ok {
+		return nil, errors.New("payload missing Result node")
+	}
+
+	result, ok := resultNode.(map[string]any)
+	if !ok {
+		return nil, errors.New("Result node is not an object")
+	}
+	return result, nil
+}
+
+// unwrapNode returns node[key] when node is an object wrapping the item list
+// (e.g. {"ResultParameter": [...]}), and node itself otherwise.
+func unwrapNode(node any, key string) any {
+	if m, ok := node.(map[string]any); ok {
+		if inner, ok := lookupKey(m, key); ok {
+			return inner
+		}
+	}
+	return node
+}
+
+// parseKeyValueItems copies {"Key": ..., "Value": ...} items into out. input may be a single
+// item or an array of items, normalized by keyValueItems; items without a key are skipped and a
+// null value is copied as "".
+func parseKeyValueItems(input any, out map[string]string) {
+	items, _ := keyValueItems(input, "")
+	for _, item := range items {
+		key, _ := lookupKey(item, "Key")
+		if k := toString(key); k != "" {
+			value, _ := lookupKey(item, "Value")
+			out[k] = toString(value)
+		}
+	}
+}
+
+// keyValueItems audits an item list such as ResultParameter and returns its items. Payloads
+// vary between senders, so the list is normalized rather than trusted:
+//   - null, at any level, means no items: a null list or a null element is skipped;
+//   - an object is a single item, and nested arrays are flattened;
+//   - any other value, such as a number where an item is expected, is skipped and reported
+//     in the returned error, at path (e.g. "ResultParameters.ResultParameter[1]").
+//
+// The lenient parsers ignore the error; the strict parsers report it.
+func keyValueItems(list any, path string) ([]map[string]any, error) {
+	switch v := list.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return []map[string]any{v}, nil
+	case []any:
+		var items []map[string]any
+		var errs []error
+		for i, element := range v {
+			inner, err := keyValueItems(element, fmt.Sprintf("%s[%d]", path, i))
+			items = append(items, inner...)
+			errs = append(errs, err)
+		}
+		return items, errors.Join(errs...)
+	default:
+		return nil, fmt.Errorf("%w: %s has unexpected type %T", ErrInvalidCallback, path, v)
+	}
+}
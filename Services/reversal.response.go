@@ -0,0 +1,34 @@
+package Services
+
+import abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+
+// ReversalResponse is the synchronous acknowledgement returned by the reversal API.
+// The actual outcome is delivered asynchronously to the ResultURL.
+type ReversalResponse struct {
+	ConversationID           string // Unique ID assigned by M-Pesa to the request
+	OriginatorConversationID string `mpesa:"OriginatorConversationID|OriginatorCoversationID"` // Unique ID of the request as seen by the originator
+	ResponseCode             string // "0" when the request was accepted for processing
+	ResponseDescription      string // Human readable description of the response code
+}
+
+// NewReversalResponse decodes a reversal acknowledgement from a raw API response.
+// Values are accepted as strings or numbers, and key casing and the spelling of
+// OriginatorConversationID are tolerated.
+//
+// Parameters:
+//   - resp: The raw response map returned by the API client
+//
+// Returns:
+//   - *ReversalResponse: The decoded acknowledgement (never nil)
+func NewReversalResponse(resp map[string]any) *ReversalResponse {
+	r, _ := abstracts.DecodeInto[ReversalResponse](resp)
+	return &r
+}
+
+// Accepted reports whether M-Pesa accepted the reversal request for processing.
+//
+// Returns:
+//   - bool: true when ResponseCode is "0"
+func (r *ReversalResponse) Accepted() bool {
+	return r != nil && r.ResponseCode == "0"
+}
@@ -0,0 +1,175 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AccountBalanceEntry is one account of a balance string such as
+// "Utility Account|KES|51661.00|51661.00|0.00|0.00", as sent in result callbacks.
+type AccountBalanceEntry struct {
+	Account   string  `json:"Account"`   // Account name, e.g. "Utility Account"
+	Currency  string  `json:"Currency"`  // Currency code, e.g. "KES"
+	Current   float64 `json:"Current"`   // Current balance
+	Available float64 `json:"Available"` // Available balance
+	Reserved  float64 `json:"Reserved"`  // Reserved amount
+	Uncleared float64 `json:"Uncleared"` // Uncleared balance
+}
+
+// ReversalResult represents a parsed reversal result callback delivered to the ResultURL.
+type ReversalResult struct {
+	ResultCode               string `json:"ResultCode"`
+	ResultDesc               string `json:"ResultDesc"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ConversationID           string `json:"ConversationID"`
+	TransactionID            string `json:"TransactionID"`
+
+	Amount                float64             `json:"Amount"`                // Amount reversed
+	OriginalTransactionID string              `json:"OriginalTransactionID"` // Receipt number of the reversed transaction
+	Charge                float64             `json:"Charge"`                // Charge applied to the reversal
+	CreditPartyPublicName string              `json:"CreditPartyPublicName"` // e.g. "254708374149 - John Doe"
+	DebitPartyPublicName  string              `json:"DebitPartyPublicName"`  // e.g. "600610 - Safaricom333"
+	DebitAccountBalance   AccountBalanceEntry `json:"DebitAccountBalance"`   // Balance of the debited account after the reversal
+	TransCompletedTime    time.Time           `json:"TransCompletedTime"`    // Completion time (EAT)
+
+	ResultParameters map[string]string `json:"ResultParameters"` // All result parameters as sent by M-Pesa
+	ReferenceData    map[string]string `json:"ReferenceData"`    // Reference items as sent by M-Pesa
+	Raw              map[string]any    `json:"-"`                // The original payload
+	Success          bool              `json:"Success"`          // true when ResultCode is 0
+}
+
+// ParseReversalResult parses a reversal result callback payload into a typed ReversalResult.
+// It builds on ParseResultEnvelope for the common Result envelope, then converts the
+// reversal specific result parameters. Parameters that are missing or malformed are left
+// at their zero value; the original strings remain available in ResultParameters.
+// json.Unmarshal into a ReversalResult decodes the raw body the same way.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *ReversalResult: The parsed result
+//   - error: An error if the payload has no Result node
+//
+// Example:
+//
+//	result, err := Services.ParseReversalResult(payload)
+//	if err == nil && !result.Success {
+//	    log.Printf("reversal of %s failed: %s", result.OriginalTransactionID, result.ResultDesc)
+//	}
+func ParseReversalResult(payload map[string]any) (*ReversalResult, error) {
+	envelope, err := ParseResultEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	return newReversalResult(envelope), nil
+}
+
+// MarshalJSON encodes the result in the schema SchemaReversalResult.
+func (r ReversalResult) MarshalJSON() ([]byte, error) {
+	type plain ReversalResult
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaReversalResult, plain(r), marshaledRaw(r.Raw)})
+}
+
+// UnmarshalJSON decodes the raw body of a reversal result callback, as ParseReversalResult does,
+// or the JSON written by MarshalJSON.
+func (r *ReversalResult) UnmarshalJSON(data []byte) error {
+	type plain ReversalResult
+	if ok, err := decodeNormalized(data, SchemaReversalResult, (*plain)(r), &r.Raw); ok {
+		r.TransCompletedTime = inEAT(r.TransCompletedTime)
+		return err
+	}
+
+	var envelope ResultEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	*r = *newReversalResult(&envelope)
+	return nil
+}
+
+// newReversalResult converts the reversal specific result parameters of envelope.
+func newReversalResult(envelope *ResultEnvelope) *ReversalResult {
+	params := envelope.ResultParameters
+	res := &ReversalResult{
+		ResultCode:               envelope.ResultCode,
+		ResultDesc:               envelope.ResultDesc,
+		OriginatorConversationID: envelope.OriginatorConversationID,
+		ConversationID:           envelope.ConversationID,
+		TransactionID:            envelope.TransactionID,
+		ResultParameters:         params,
+		ReferenceData:            envelope.ReferenceData,
+		Raw:                      envelope.Raw,
+		Success:                  envelope.Success,
+	}
+
+	res.Amount = parseAmount(params["Amount"])
+	res.OriginalTransactionID = params["OriginalTransactionID"]
+	res.Charge = parseAmount(params["Charge"])
+	res.CreditPartyPublicName = params["CreditPartyPublicName"]
+	res.DebitPartyPublicName = params["DebitPartyPublicName"]
+	res.DebitAccountBalance = parseAccountBalanceEntry(params["DebitAccountBalance"])
+	res.TransCompletedTime = parseCompactTime(params["TransCompletedTime"])
+
+	return res
+}
+
+// ReversalResultHandler returns an http.HandlerFunc for the reversal ResultURL and
+// QueueTimeOutURL. Result callbacks are parsed with ParseReversalResult and passed to onResult;
+// queue timeout notifications (see IsQueueTimeout) are passed to onTimeout as-is. Either
+// callback may be nil. Every accepted callback is acknowledged with the JSON body M-Pesa
+// expects. Only POST requests are accepted, and bodies are limited in size and read time; see
+// WithMaxBodyBytes, WithReadTimeout and WithErrorHandler.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - onTimeout: Called with the raw body of each queue timeout notification
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+func ReversalResultHandler(onResult func(*ReversalResult), onTimeout func(raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return ReversalResultHandlerContext(ignoreContext(onResult), ignoreContext(onTimeout), opts...)
+}
+
+// ReversalResultHandlerContext is ReversalResultHandler with functions that also receive the context of the
+// request, bounded by the handler timeout; see WithTimeout.
+func ReversalResultHandlerContext(onResult func(context.Context, *ReversalResult), onTimeout func(ctx context.Context, raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return resultHandler(newHandlerOptions(opts), CallbackReversalResult, CallbackReversalTimeout, ParseReversalResult, onResult, onTimeout)
+}
+
+// parseAccountBalanceEntry parses a "name|currency|current|available|reserved|uncleared" balance.
+// Missing parts are left at their zero value.
+func parseAccountBalanceEntry(v string) AccountBalanceEntry {
+	parts := strings.Split(strings.TrimSpace(v), "|")
+	part := func(i int) string {
+		if i < len(parts) {
+			return strings.TrimSpace(parts[i])
+		}
+		return ""
+	}
+	return AccountBalanceEntry{
+		Account:   part(0),
+		Currency:  part(1),
+		Current:   parseAmount(part(2)),
+		Available: parseAmount(part(3)),
+		Reserved:  parseAmount(part(4)),
+		Uncleared: parseAmount(part(5)),
+	}
+}
+
+// parseCompactTime parses the "yyyyMMddHHmmss" timestamps used in result callbacks.
+func parseCompactTime(v string) time.Time {
+	t, err := time.ParseInLocation("20060102150405", strings.TrimSpace(v), mpesaLocation)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -1,9 +1,13 @@
 package Services
 
 import (
+	"context"
 	"errors"
-	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"fmt"
 	"strconv" // added for int to string conversion of amount
+	"time"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
 // ReversalService handles M-Pesa transaction reversal operations.
@@ -15,12 +19,26 @@ type ReversalService struct {
 	Initiator              string                   // Username of the M-Pesa API operator
 	TransactionID          string                   // ID of the transaction to be reversed
 	Amount                 int                      // Original transaction amount to reverse
-	ReceiverIdentifierType string                   // Type of identifier for the transaction receiver (e.g. 11 for Paybill)
+	ReceiverIdentifierType string                   // Type of identifier for the transaction receiver (defaults to "11", Paybill)
 	Remarks                string                   // Comments for the reversal transaction (2-100 chars, required)
 	Occasion               string                   // Occasion or reason for the reversal (optional)
 	Response               map[string]interface{}   // Response from the last API call
+	typedResponse          *ReversalResponse        // Decoded response from the last API call
+	receiverParty          string                   // Shortcode that received the original transaction; defaults to the config business code
+	queueTimeoutURL        string                   // Queue timeout URL for this service; defaults to the config value
+	resultURL              string                   // Result URL for this service; defaults to the config value
+	amountText             string                   // Canonical amount set via SetAmountFloat or SetAmountString
+	amountErr              error                    // Validation error from SetAmountFloat or SetAmountString
+	waiter                 resultWaiter             // SendAndWait settings
 }
 
+// Length limits of the free text reversal fields accepted by Daraja.
+const (
+	MinReversalRemarksLength  = 2
+	MaxReversalRemarksLength  = 100
+	MaxReversalOccasionLength = 100
+)
+
 // NewReversalService creates a new reversal service instance with the provided configuration and client.
 // This is the constructor for creating reversal service instances that can be used to reverse transactions.
 //
@@ -38,8 +56,9 @@ type ReversalService struct {
 //	reversalService := NewReversalService(cfg, client)
 func NewReversalService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *ReversalService {
 	return &ReversalService{
-		Config: cfg,
-		Client: client,
+		Config:                 cfg,
+		Client:                 client,
+		ReceiverIdentifierType: string(IdentifierTypePaybill),
 	}
 }
 
@@ -78,15 +97,76 @@ func (s *ReversalService) SetTransactionID(txID string) *ReversalService {
 //   - *ReversalService: Returns self for method chaining
 func (s *ReversalService) SetAmount(amount int) *ReversalService {
 	s.Amount = amount
+	s.amountText = ""
+	s.amountErr = nil
+	return s
+}
+
+// SetAmountFloat sets the original transaction amount, including any cents.
+// The amount is sent exactly as given, without rounding; a non-positive amount or
+// a fraction of a cent is reported when the reversal is sent.
+//
+// Parameters:
+//   - amount: The amount of the original transaction, e.g. 150.75
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetAmountFloat(amount float64) *ReversalService {
+	s.amountText, s.amountErr = canonicalAmount(amount)
+	return s
+}
+
+// SetAmountString sets the original transaction amount from a decimal string such as "150.75"
+// or "1,500". The amount is sent exactly as given, without rounding; invalid or non-positive
+// amounts are reported when the reversal is sent.
+//
+// Parameters:
+//   - amount: The amount of the original transaction
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+//
+// Example:
+//
+//	reversalService.SetAmountString("150.75")
+func (s *ReversalService) SetAmountString(amount string) *ReversalService {
+	f, err := parseAmountString(amount)
+	if err != nil {
+		s.amountText, s.amountErr = "", err
+		return s
+	}
+	s.amountText, s.amountErr = canonicalAmount(f)
+	return s
+}
+
+// SetAmountValue sets the original transaction amount, including any cents. The amount is
+// sent exactly as given; a non-positive amount is reported when the reversal is sent.
+//
+// Parameters:
+//   - amount: The amount of the original transaction
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+//
+// Example:
+//
+//	reversalService.SetAmountValue(Abstracts.FromCents(15075)) // "150.75"
+func (s *ReversalService) SetAmountValue(amount abstracts.Amount) *ReversalService {
+	if !amount.Positive() {
+		s.amountText, s.amountErr = "", errors.New("amount must be greater than 0")
+		return s
+	}
+	s.amountText, s.amountErr = amount.String(), nil
 	return s
 }
 
 // SetReceiverIdentifierType sets the type of identifier for the transaction receiver.
 // This identifies the type of account that received the original transaction.
-// For reversals, Safaricom docs indicate Paybill reversals use identifier type "11".
+// It defaults to IdentifierTypePaybill ("11"), which Safaricom docs specify for Paybill reversals;
+// Reverse rejects values other than the IdentifierType constants (1, 2, 4 or 11).
 //
 // Parameters:
-//   - identifierType: The identifier type for the receiver
+//   - identifierType: The identifier type for the receiver, e.g. string(Services.IdentifierTypeTillNumber)
 //
 // Returns:
 //   - *ReversalService: Returns self for method chaining
@@ -95,11 +175,48 @@ func (s *ReversalService) SetReceiverIdentifierType(identifierType string) *Reve
 	return s
 }
 
+// SetReceiverParty sets the shortcode that received the original transaction.
+// It overrides the config business code for this service only.
+//
+// Parameters:
+//   - code: The paybill or till shortcode
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetReceiverParty(code string) *ReversalService {
+	s.receiverParty = code
+	return s
+}
+
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa calls when the request times out in the queue
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetQueueTimeoutURL(url string) *ReversalService {
+	s.queueTimeoutURL = url
+	return s
+}
+
+// SetResultURL sets the result URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa posts the reversal result to
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetResultURL(url string) *ReversalService {
+	s.resultURL = url
+	return s
+}
+
 // SetRemarks sets comments or additional information for the reversal transaction.
 // This helps identify the reason for the reversal in transaction records.
 //
 // Parameters:
-//   - remarks: A descriptive string for the reversal (2-100 characters)
+//   - remarks: A descriptive string for the reversal, MinReversalRemarksLength to MaxReversalRemarksLength characters
 //
 // Returns:
 //   - *ReversalService: Returns self for method chaining
@@ -112,7 +229,7 @@ func (s *ReversalService) SetRemarks(remarks string) *ReversalService {
 // This provides additional context for the reversal operation (optional).
 //
 // Parameters:
-//   - occasion: A string describing the occasion for the reversal
+//   - occasion: A string describing the occasion for the reversal, at most MaxReversalOccasionLength characters
 //
 // Returns:
 //   - *ReversalService: Returns self for method chaining
@@ -138,23 +255,36 @@ func (s *ReversalService) Reverse() (map[string]interface{}, error) {
 	if s.TransactionID == "" {
 		return nil, errors.New("transaction ID is required")
 	}
-	if s.Amount <= 0 {
-		return nil, errors.New("amount must be greater than 0")
+	amount, err := s.resolveAmount()
+	if err != nil {
+		return nil, err
 	}
 	if s.ReceiverIdentifierType == "" {
 		return nil, errors.New("receiver identifier type is required")
 	}
+	if err := validateIdentifierType("receiver identifier type", s.ReceiverIdentifierType); err != nil {
+		return nil, err
+	}
 	if s.Remarks == "" {
 		return nil, errors.New("remarks are required")
 	}
-	if s.Config.GetBusinessCode() == "" {
-		return nil, errors.New("business shortcode (ReceiverParty) is required; call SetBusinessCode on mpesa config")
+	if err := validateLength("remarks", s.Remarks, MinReversalRemarksLength, MaxReversalRemarksLength); err != nil {
+		return nil, err
+	}
+	if err := validateLength("occasion", s.Occasion, 0, MaxReversalOccasionLength); err != nil {
+		return nil, err
 	}
-	if s.Config.GetQueueTimeoutURL() == "" {
-		return nil, errors.New("queue timeout URL is required; call SetQueueTimeoutURL on config")
+	receiverParty := chooseString(s.receiverParty, s.Config.GetBusinessCode())
+	if receiverParty == "" {
+		return nil, errors.New("business shortcode (ReceiverParty) is required; call SetReceiverParty or SetBusinessCode on mpesa config")
 	}
-	if s.Config.GetResultURL() == "" {
-		return nil, errors.New("result URL is required; call SetResultURL on config")
+	queueTimeoutURL := chooseString(s.queueTimeoutURL, s.Config.GetQueueTimeoutURL())
+	if queueTimeoutURL == "" {
+		return nil, errors.New("queue timeout URL is required; call SetQueueTimeoutURL on the service or config")
+	}
+	resultURL := chooseString(s.resultURL, s.Config.GetResultURL())
+	if resultURL == "" {
+		return nil, errors.New("result URL is required; call SetResultURL on the service or config")
 	}
 	if s.Config.GetSecurityCredential() == "" {
 		return nil, errors.New("security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config")
@@ -165,21 +295,22 @@ func (s *ReversalService) Reverse() (map[string]interface{}, error) {
 		"SecurityCredential":     s.Config.GetSecurityCredential(),
 		"CommandID":              "TransactionReversal",
 		"TransactionID":          s.TransactionID,
-		"Amount":                 strconv.Itoa(s.Amount),
-		"ReceiverParty":          s.Config.GetBusinessCode(),
+		"Amount":                 amount, // a decimal string keeping cents, see reversalAmountForm
+		"ReceiverParty":          receiverParty,
 		"RecieverIdentifierType": s.ReceiverIdentifierType,
 		"Remarks":                s.Remarks,
-		"QueueTimeOutURL":        s.Config.GetQueueTimeoutURL(),
-		"ResultURL":              s.Config.GetResultURL(),
+		"QueueTimeOutURL":        queueTimeoutURL,
+		"ResultURL":              resultURL,
 		"Occasion":               s.Occasion,
 	}
 
-	response, err := s.Client.ExecuteRequest(data, "/mpesa/reversal/v1/request")
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.Reversal)
 	if err != nil {
 		return nil, err
 	}
 
 	s.Response = response
+	s.typedResponse = NewReversalResponse(response)
 	return response, nil
 }
 
@@ -190,3 +321,122 @@ func (s *ReversalService) Reverse() (map[string]interface{}, error) {
 func (s *ReversalService) GetResponse() map[string]interface{} {
 	return s.Response
 }
+
+// GetTypedResponse returns the decoded acknowledgement from the last reversal request.
+//
+// Returns:
+//   - *ReversalResponse: The decoded response, or nil if no reversal has been made
+//
+// Example:
+//
+//	if _, err := reversalService.Reverse(); err == nil && reversalService.GetTypedResponse().Accepted() {
+//	    fmt.Println("Reversal accepted for processing")
+//	}
+func (s *ReversalService) GetTypedResponse() *ReversalResponse {
+	return s.typedResponse
+}
+
+// GetConversationID returns the ConversationID from the last reversal request.
+// Persist it to match the asynchronous result delivered to the ResultURL.
+//
+// Returns:
+//   - string: The ConversationID
+//   - error: An error if no response is available or it has no ConversationID
+func (s *ReversalService) GetConversationID() (string, error) {
+	if s.typedResponse == nil {
+		return "", errors.New("no reversal response available")
+	}
+	if s.typedResponse.ConversationID == "" {
+		return "", errors.New("ConversationID not found in response")
+	}
+	return s.typedResponse.ConversationID, nil
+}
+
+// GetOriginatorConversationID returns the OriginatorConversationID from the last reversal request.
+//
+// Returns:
+//   - string: The OriginatorConversationID
+//   - error: An error if no response is available or it has no OriginatorConversationID
+func (s *ReversalService) GetOriginatorConversationID() (string, error) {
+	if s.typedResponse == nil {
+		return "", errors.New("no reversal response available")
+	}
+	if s.typedResponse.OriginatorConversationID == "" {
+		return "", errors.New("OriginatorConversationID not found in response")
+	}
+	return s.typedResponse.OriginatorConversationID, nil
+}
+
+// resolveAmount returns the amount to send, preferring a value set via SetAmountFloat or
+// SetAmountString, in the form of the reversal Amount field.
+func (s *ReversalService) resolveAmount() (any, error) {
+	if s.amountErr != nil {
+		return nil, fmt.Errorf("invalid amount: %w", s.amountErr)
+	}
+	text := s.amountText
+	if text == "" {
+		if s.Amount <= 0 {
+			return nil, errors.New("amount must be greater than 0")
+		}
+		text = strconv.Itoa(s.Amount)
+	}
+	amount, err := abstracts.FromString(text)
+	if err != nil {
+		return nil, err
+	}
+	return amountField(amount, reversalAmountForm)
+}
+
+// SetResultCorrelator sets the correlator SendAndWait waits for the result with. The handler
+// of the ResultURL and QueueTimeOutURL must resolve results in it; see WithResultCorrelator.
+//
+// Parameters:
+//   - correlator: The correlator shared with the result handler
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetResultCorrelator(correlator ResultCorrelator) *ReversalService {
+	s.waiter.correlator = correlator
+	return s
+}
+
+// SetStatusFallback makes SendAndWait query the transaction status with status when the result
+// does not arrive before its context expires, and wait up to wait for the status result
+// (DefaultStatusFallbackWait when zero or negative). status must be configured with an
+// initiator and identifier type, and its results must be resolved in the same correlator.
+//
+// Parameters:
+//   - status: The service used for the status query
+//   - wait: How long to wait for the status result
+//
+// Returns:
+//   - *ReversalService: Returns self for method chaining
+func (s *ReversalService) SetStatusFallback(status *TransactionStatusService, wait time.Duration) *ReversalService {
+	s.waiter.setStatusFallback(status, wait)
+	return s
+}
+
+// SendAndWait sends the request with Reverse and waits until its result arrives on the ResultURL
+// or QueueTimeOutURL, or ctx is done.
+//
+// Parameters:
+//   - ctx: Bounds the wait for the result
+//
+// Returns:
+//   - *CorrelatedResult: The result, with Result set to a *ReversalResult, or the queue timeout
+//   - error: An error if the request fails or no result arrives in time (ErrResultTimeout)
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+//	defer cancel()
+//	res, err := reversalService.
+//	    SetResultCorrelator(correlator).
+//	    SetStatusFallback(statusService, 30*time.Second).
+//	    SendAndWait(ctx)
+//	if err == nil && res.Success {
+//	    markRefunded(res.Result.(*Services.ReversalResult).OriginalTransactionID)
+//	}
+func (s *ReversalService) SendAndWait(ctx context.Context) (*CorrelatedResult, error) {
+	return s.waiter.sendAndWait(ctx, "", s.Reverse)
+}
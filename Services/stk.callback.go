@@ -0,0 +1,204 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// STKCallback represents a parsed STK Push callback delivered to the CallBackURL.
+type STKCallback struct {
+	MerchantRequestID string `json:"MerchantRequestID"`
+	CheckoutRequestID string `json:"CheckoutRequestID"`
+	ResultCode        string `json:"ResultCode"`
+	ResultDesc        string `json:"ResultDesc"`
+
+	Amount             float64   `json:"Amount"`             // Amount paid
+	MpesaReceiptNumber string    `json:"MpesaReceiptNumber"` // M-Pesa receipt number
+	TransactionDate    time.Time `json:"TransactionDate"`    // Payment time (EAT)
+	PhoneNumber        string    `json:"PhoneNumber"`        // Phone number that paid, e.g. "254708374149"
+
+	Metadata map[string]string `json:"CallbackMetadata"` // CallbackMetadata items as sent by M-Pesa; empty when the payment failed
+	Raw      map[string]any    `json:"-"`                // The original payload
+	Success  bool              `json:"Success"`          // true when ResultCode is 0
+}
+
+// ParseSTKCallback parses an STK Push callback payload into a typed STKCallback.
+// Values may be strings or numbers, and CallbackMetadata items without a value (such as
+// Balance) are kept as empty strings. Metadata that is missing or malformed is left at its
+// zero value; cancelled and failed payments carry no metadata at all. json.Unmarshal into an
+// STKCallback decodes the raw body the same way.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the CallBackURL
+//
+// Returns:
+//   - *STKCallback: The parsed callback
+//   - error: An error if the payload has no Body.stkCallback object
+//
+// Example:
+//
+//	callback, err := Services.ParseSTKCallback(payload)
+//	if err == nil && callback.Success {
+//	    fmt.Printf("Paid %.2f, receipt %s", callback.Amount, callback.MpesaReceiptNumber)
+//	}
+func ParseSTKCallback(payload map[string]any) (*STKCallback, error) {
+	cb := &STKCallback{}
+	if err := decodePayload(payload, cb.decode); err != nil {
+		return nil, err
+	}
+	cb.Raw = payload
+	return cb, nil
+}
+
+// MarshalJSON encodes the callback in the schema SchemaSTKCallback.
+func (cb STKCallback) MarshalJSON() ([]byte, error) {
+	type plain STKCallback
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaSTKCallback, plain(cb), marshaledRaw(cb.Raw)})
+}
+
+// UnmarshalJSON decodes the raw body of an STK Push callback, as ParseSTKCallback does, or the
+// JSON written by MarshalJSON.
+func (cb *STKCallback) UnmarshalJSON(data []byte) error {
+	type plain STKCallback
+	if ok, err := decodeNormalized(data, SchemaSTKCallback, (*plain)(cb), &cb.Raw); ok {
+		cb.TransactionDate = inEAT(cb.TransactionDate)
+		return err
+	}
+
+	if err := cb.decode(data); err != nil {
+		return err
+	}
+	cb.Raw = rawPayload(data)
+	return nil
+}
+
+// decode fills every field but Raw from the body of an STK Push callback.
+func (cb *STKCallback) decode(data []byte) error {
+	var payload struct {
+		Body json.RawMessage
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+	if !isJSONObject(payload.Body) {
+		return errors.New("payload missing Body object")
+	}
+	var body struct {
+		StkCallback json.RawMessage `json:"stkCallback"`
+	}
+	if err := json.Unmarshal(payload.Body, &body); err != nil {
+		return err
+	}
+	if !isJSONObject(body.StkCallback) {
+		return errors.New("payload missing Body.stkCallback object")
+	}
+	var node struct {
+		MerchantRequestID flexString
+		CheckoutRequestID flexString
+		ResultCode        flexString // may be string or number
+		ResultDesc        flexString
+		CallbackMetadata  json.RawMessage
+	}
+	if err := json.Unmarshal(body.StkCallback, &node); err != nil {
+		return err
+	}
+
+	*cb = STKCallback{
+		MerchantRequestID: string(node.MerchantRequestID),
+		CheckoutRequestID: string(node.CheckoutRequestID),
+		ResultCode:        string(node.ResultCode),
+		ResultDesc:        string(node.ResultDesc),
+		Metadata:          make(map[string]string),
+	}
+	if i, err := strconv.Atoi(cb.ResultCode); err == nil {
+		cb.Success = i == 0
+	}
+
+	if items, ok := unwrapNode(decodeNode(node.CallbackMetadata), "Item").([]any); ok {
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				if name := toString(m["Name"]); name != "" {
+					cb.Metadata[name] = toString(m["Value"])
+				}
+			}
+		}
+	}
+
+	cb.Amount = parseAmount(cb.Metadata["Amount"])
+	cb.MpesaReceiptNumber = cb.Metadata["MpesaReceiptNumber"]
+	cb.TransactionDate = parseCompactTime(cb.Metadata["TransactionDate"])
+	cb.PhoneNumber = cb.Metadata["PhoneNumber"]
+
+	return nil
+}
+
+// STKCallbackHandler returns an http.HandlerFunc for the STK Push CallBackURL. Callbacks are
+// parsed with ParseSTKCallback and passed to onCallback, which may be nil. Every accepted
+// callback is acknowledged with the JSON body M-Pesa expects. Only POST requests are accepted,
+// and bodies are limited in size and read time; see WithMaxBodyBytes, WithReadTimeout and
+// WithErrorHandler.
+//
+// Parameters:
+//   - onCallback: Called with each parsed callback
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/stk/callback", Services.STKCallbackHandler(
+//	    func(cb *Services.STKCallback) {
+//	        markOrder(cb.CheckoutRequestID, cb.Success, cb.MpesaReceiptNumber)
+//	    },
+//	))
+func STKCallbackHandler(onCallback func(*STKCallback), opts ...HandlerOption) http.HandlerFunc {
+	return STKCallbackHandlerContext(ignoreContext(onCallback), opts...)
+}
+
+// STKCallbackHandlerContext is STKCallbackHandler with a function that also receives the
+// context of the request, bounded by the handler timeout. The context is cancelled when the
+// handler stops waiting for the function, because the timeout expired or the client went
+// away, so that slow work such as a database write can be abandoned; see WithTimeout.
+//
+// Example:
+//
+//	http.Handle("/mpesa/stk/callback", Services.STKCallbackHandlerContext(
+//	    func(ctx context.Context, cb *Services.STKCallback) {
+//	        orders.MarkPaid(ctx, cb.CheckoutRequestID, cb.Success)
+//	    },
+//	))
+func STKCallbackHandlerContext(onCallback func(context.Context, *STKCallback), opts ...HandlerOption) http.HandlerFunc {
+	o := newHandlerOptions(opts)
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, raw, rejected, err := o.readWebhookPayload(w, r, CallbackSTK, "")
+		if rejected {
+			return
+		}
+		if err != nil {
+			o.fail(w, r, CallbackSTK, raw, err, webhookAck)
+			return
+		}
+
+		callback, err := o.parseSTKCallback(payload)
+		if err != nil {
+			o.fail(w, r, CallbackSTK, raw, err, webhookAck)
+			return
+		}
+		if onCallback != nil {
+			if err := o.call(r, func(ctx context.Context) { onCallback(ctx, callback) }); err != nil {
+				o.failCallback(w, r, err, webhookAck)
+				return
+			}
+		}
+		o.ack(w, webhookAck)
+	}
+}
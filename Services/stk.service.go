@@ -14,12 +14,15 @@ import (
 type StkService struct {
 	*BaseService
 
-	transactionType  string // The type of transaction (e.g., "CustomerPayBillOnline")
-	amount           string // The amount to be charged from the customer
-	phoneNumber      string // The customer's mobile phone number
-	callbackUrl      string // URL to receive payment notifications
-	accountReference string // Reference for the account being paid
-	transactionDesc  string // Description of the transaction
+	transactionType  string                   // The type of transaction (e.g., "CustomerPayBillOnline")
+	amount           string                   // The amount to be charged from the customer
+	phoneNumber      string                   // The customer's mobile phone number
+	phonePrefixes    []string                 // Allowed phone prefixes, nil unless SetStrictPhonePrefixes was called
+	phoneValidator   Abstracts.PhoneValidator // Custom phone check (overrides the config validator)
+	callbackUrl      string                   // URL to receive payment notifications
+	callbackUrlErr   error                    // Error from SetCallbackUrlWithToken, surfaced by Push
+	accountReference string                   // Reference for the account being paid
+	transactionDesc  string                   // Description of the transaction
 
 	response map[string]any // Response from the last STK push request
 }
@@ -45,6 +48,19 @@ func NewStkService(cfg *Abstracts.MpesaConfig, client Abstracts.MpesaInterface)
 	}
 }
 
+// SetClock sets the clock the timestamp and password of requests are generated from, e.g. an
+// Abstracts.FakeClock in tests.
+//
+// Parameters:
+//   - clock: The clock to use; nil restores the system clock
+//
+// Returns:
+//   - *StkService: Returns self for method chaining
+func (s *StkService) SetClock(clock Abstracts.Clock) *StkService {
+	s.BaseService.SetClock(clock)
+	return s
+}
+
 // SetTransactionType sets the type of STK Push transaction.
 // Common transaction types include "CustomerPayBillOnline" for pay bill transactions
 // and "CustomerBuyGoodsOnline" for buy goods transactions.
@@ -65,10 +81,11 @@ func (s *StkService) SetTransactionType(t string) *StkService {
 }
 
 // SetAmount sets the amount to be charged from the customer's M-Pesa account.
-// The method accepts various numeric types and converts them to the required string format.
+// The method accepts various numeric types; STK Push only accepts whole shillings, so Push
+// reports an amount with cents instead of sending it.
 //
 // Parameters:
-//   - a: The amount as int, int64, string, float64, or any other type
+//   - a: The amount as int, int64, string, float64, Abstracts.Amount, or any other type
 //
 // Returns:
 //   - *StkService: Returns self for method chaining
@@ -76,11 +93,13 @@ func (s *StkService) SetTransactionType(t string) *StkService {
 // Example:
 //
 //	stkService.SetAmount(100)        // int
-//	stkService.SetAmount("250.50")   // string
-//	stkService.SetAmount(99.99)      // float64
+//	stkService.SetAmount("1,500")    // string
+//	stkService.SetAmount(250.0)      // float64
 //	stkService.SetAmount(int64(500)) // int64
 func (s *StkService) SetAmount(a any) *StkService {
 	switch v := a.(type) {
+	case Abstracts.Amount:
+		return s.SetAmountValue(v)
 	case int:
 		s.amount = strconv.Itoa(v)
 	case int64:
@@ -93,6 +112,24 @@ func (s *StkService) SetAmount(a any) *StkService {
 	return s
 }
 
+// SetAmountValue sets the amount to be charged from the customer. STK Push only accepts whole
+// shillings, so an amount with cents is reported by Push; round it first with Round or
+// Truncate.
+//
+// Parameters:
+//   - amount: The amount in KES
+//
+// Returns:
+//   - *StkService: Returns self for method chaining
+//
+// Example:
+//
+//	stkService.SetAmountValue(Abstracts.FromKES(100))
+func (s *StkService) SetAmountValue(amount Abstracts.Amount) *StkService {
+	s.amount = amount.String()
+	return s
+}
+
 // SetPhoneNumber sets and validates the customer's phone number for the STK Push.
 // The method automatically formats the phone number to the correct international format
 // and validates its length and format.
@@ -109,8 +146,9 @@ func (s *StkService) SetAmount(a any) *StkService {
 //	stkService, err := stkService.SetPhoneNumber("254711223344")  // With country code
 //	stkService, err := stkService.SetPhoneNumber("0711223344")    // Without country code
 //	stkService, err := stkService.SetPhoneNumber("+254711223344") // International format
+//	stkService, err := stkService.SetPhoneNumber("711223344")     // Without the leading 0
 func (s *StkService) SetPhoneNumber(phone string) (*StkService, error) {
-	cleaned, err := s.CleanPhoneNumber(phone, "254")
+	cleaned, err := s.CleanPhoneNumber(phone, s.Config.GetDefaultCountryCode())
 	if err != nil {
 		return s, err
 	}
@@ -118,6 +156,39 @@ func (s *StkService) SetPhoneNumber(phone string) (*StkService, error) {
 	return s, nil
 }
 
+// SetStrictPhonePrefixes turns on strict phone validation: Push rejects a phone number
+// that does not start with one of prefixes, or Abstracts.DefaultMSISDNPrefixes (the Safaricom
+// 2547 and 2541 ranges) when none are given, with an error wrapping Abstracts.ErrUnsupportedPrefix.
+//
+// Parameters:
+//   - prefixes: The allowed prefixes in international format, e.g. "2547"
+//
+// Returns:
+//   - *StkService: Returns self for method chaining
+//
+// Example:
+//
+//	stkService.SetStrictPhonePrefixes()                      // Safaricom numbers only
+//	stkService.SetStrictPhonePrefixes("2547", "2541", "255") // also Tanzanian numbers
+func (s *StkService) SetStrictPhonePrefixes(prefixes ...string) *StkService {
+	s.phonePrefixes = strictPhonePrefixes(prefixes)
+	return s
+}
+
+// SetPhoneValidator sets a custom check, e.g. a denylist lookup, that Push runs on the phone
+// number after normalizing it, in place of the one set with MpesaConfig.SetPhoneValidator.
+// Its error is returned wrapped in "invalid phone number".
+//
+// Parameters:
+//   - validator: The check to run; nil falls back to the config's
+//
+// Returns:
+//   - *StkService: Returns self for method chaining
+func (s *StkService) SetPhoneValidator(validator Abstracts.PhoneValidator) *StkService {
+	s.phoneValidator = validator
+	return s
+}
+
 // SetCallbackUrl sets the URL where M-Pesa will send payment notifications.
 // This URL will receive POST requests with the payment status and details.
 // The callback URL must be publicly accessible and properly configured to handle M-Pesa callbacks.
@@ -134,6 +205,20 @@ func (s *StkService) SetPhoneNumber(phone string) (*StkService, error) {
 //	stkService.SetCallbackUrl("https://api.example.com/webhooks/mpesa")
 func (s *StkService) SetCallbackUrl(url string) *StkService {
 	s.callbackUrl = url
+	s.callbackUrlErr = nil
+	return s
+}
+
+// SetCallbackUrlWithToken sets the callback URL to base with token appended as the
+// CallbackTokenParam query parameter; verify it in the callback handler with VerifyURLToken.
+// An invalid URL or empty token is reported by Push.
+//
+// Example:
+//
+//	token, _ := Services.GenerateCallbackToken()
+//	stkService.SetCallbackUrlWithToken("https://yourdomain.com/mpesa/callback", token)
+func (s *StkService) SetCallbackUrlWithToken(base, token string) *StkService {
+	s.callbackUrl, s.callbackUrlErr = AppendCallbackToken(base, token)
 	return s
 }
 
@@ -196,12 +281,37 @@ func (s *StkService) validatePushParams() error {
 	if s.phoneNumber == "" {
 		return errors.New("phone number is required")
 	}
+	if err := checkPhonePrefixes(s.phoneNumber, s.phonePrefixes); err != nil {
+		return fmt.Errorf("invalid phone number: %w", err)
+	}
+	if err := checkPhoneValidator(s.phoneNumber, s.Config, s.phoneValidator); err != nil {
+		return fmt.Errorf("invalid phone number: %w", err)
+	}
+	if s.callbackUrlErr != nil {
+		return s.callbackUrlErr
+	}
 	if s.callbackUrl == "" {
 		return errors.New("callback URL is required")
 	}
 	return nil
 }
 
+// resolveAmount parses the amount and converts it to the form of the STK Push Amount field.
+func (s *StkService) resolveAmount() (any, error) {
+	amount, err := Abstracts.FromString(s.amount)
+	if err != nil {
+		return nil, err
+	}
+	if !amount.Positive() {
+		return nil, fmt.Errorf("invalid amount %q: must be greater than 0", s.amount)
+	}
+	field, err := amountField(amount, stkAmountForm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	return field, nil
+}
+
 // Push initiates an STK Push request to the customer's mobile phone.
 // This method sends a payment request that will appear as a popup on the customer's phone,
 // allowing them to authorize the payment using their M-Pesa PIN.
@@ -231,13 +341,17 @@ func (s *StkService) Push() (*StkService, error) {
 	if err := s.validatePushParams(); err != nil {
 		return s, err
 	}
+	amount, err := s.resolveAmount()
+	if err != nil {
+		return s, err
+	}
 
 	data := map[string]any{
 		"BusinessShortCode": s.Config.GetBusinessCode(),
 		"Password":          s.GeneratePassword(),
 		"Timestamp":         s.GenerateTimestamp(),
 		"TransactionType":   s.transactionType,
-		"Amount":            s.amount,
+		"Amount":            amount, // whole shillings as a number, see stkAmountForm
 		"PartyA":            s.phoneNumber,
 		"PartyB":            s.Config.GetBusinessCode(),
 		"PhoneNumber":       s.phoneNumber,
@@ -254,7 +368,7 @@ func (s *StkService) Push() (*StkService, error) {
 		data["TransactionDesc"] = "Transaction"
 	}
 
-	resp, err := s.Client.ExecuteRequest(data, "/mpesa/stkpush/v1/processrequest")
+	resp, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.StkPush)
 	if err != nil {
 		return s, err
 	}
@@ -356,7 +470,7 @@ func (s *StkService) Query(checkoutRequestId ...string) (map[string]any, error)
 		"CheckoutRequestID": reqID,
 	}
 
-	return s.Client.ExecuteRequest(data, "/mpesa/stkpushquery/v1/query")
+	return s.Client.ExecuteRequest(data, s.Config.Endpoints.StkQuery)
 }
 
 // GetResponse returns the raw response map from the last STK Push operation.
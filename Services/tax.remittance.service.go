@@ -0,0 +1,190 @@
+package Services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// KRAShortCode is the shortcode that receives tax remittances on behalf of the Kenya Revenue Authority.
+const KRAShortCode = "572572"
+
+// TaxRemittanceService remits taxes to KRA using the "PayTaxToKRA" command.
+// The payment registration number (PRN) issued by KRA is sent as the account reference.
+type TaxRemittanceService struct {
+	Config                  *abstracts.MpesaConfig
+	Client                  abstracts.MpesaInterface
+	initiator               string
+	securityCredential      string
+	commandID               string
+	senderIdentifierType    string
+	recipientIdentifierType string
+	amount                  float64
+	rounding                RoundingPolicy
+	partyA                  string
+	partyB                  string
+	prn                     string
+	remarks                 string
+	queueTimeoutURL         string
+	resultURL               string
+	response                map[string]any
+}
+
+// NewTaxRemittanceService creates a new tax remittance service instance.
+func NewTaxRemittanceService(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *TaxRemittanceService {
+	return &TaxRemittanceService{
+		Config:                  cfg,
+		Client:                  client,
+		commandID:               "PayTaxToKRA",
+		senderIdentifierType:    "4",
+		recipientIdentifierType: "4",
+		partyB:                  KRAShortCode,
+	}
+}
+
+// SetInitiator sets the initiator (operator username) for the transaction.
+func (s *TaxRemittanceService) SetInitiator(name string) *TaxRemittanceService {
+	s.initiator = name
+	return s
+}
+
+// SetSecurityCredential encrypts the initiator password and uses it for this service.
+// It is equivalent to SetInitiatorPassword; the shared config is not modified.
+func (s *TaxRemittanceService) SetSecurityCredential(password string) error {
+	return s.SetInitiatorPassword(password)
+}
+
+// SetInitiatorPassword encrypts the initiator password and uses the result as the security
+// credential for this service, overriding the config value.
+func (s *TaxRemittanceService) SetInitiatorPassword(password string) error {
+	credential, err := s.Config.EncryptSecurityCredential(password)
+	if err != nil {
+		return err
+	}
+	s.securityCredential = credential
+	return nil
+}
+
+// SetEncryptedSecurityCredential sets an already encrypted security credential for this service,
+// overriding the config value.
+func (s *TaxRemittanceService) SetEncryptedSecurityCredential(credential string) *TaxRemittanceService {
+	s.securityCredential = credential
+	return s
+}
+
+// SetAmount sets the tax amount in KES.
+func (s *TaxRemittanceService) SetAmount(amount float64) *TaxRemittanceService {
+	s.amount = amount
+	return s
+}
+
+// SetAmountValue sets the tax amount. Cents are handled by the rounding policy.
+func (s *TaxRemittanceService) SetAmountValue(amount abstracts.Amount) *TaxRemittanceService {
+	s.amount = amount.Float64()
+	return s
+}
+
+// SetRoundingPolicy sets how a fractional amount is converted to whole shillings. By default it is rejected.
+func (s *TaxRemittanceService) SetRoundingPolicy(policy RoundingPolicy) *TaxRemittanceService {
+	s.rounding = policy
+	return s
+}
+
+// SetPartyA sets the shortcode from which the tax will be deducted. It defaults to the config business code.
+func (s *TaxRemittanceService) SetPartyA(code string) *TaxRemittanceService {
+	s.partyA = code
+	return s
+}
+
+// SetPRN sets the payment registration number issued by KRA, sent as the account reference.
+func (s *TaxRemittanceService) SetPRN(prn string) *TaxRemittanceService {
+	s.prn = strings.TrimSpace(prn)
+	return s
+}
+
+// SetRemarks sets transaction remarks.
+func (s *TaxRemittanceService) SetRemarks(r string) *TaxRemittanceService {
+	s.remarks = r
+	return s
+}
+
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
+func (s *TaxRemittanceService) SetQueueTimeoutURL(url string) *TaxRemittanceService {
+	s.queueTimeoutURL = url
+	return s
+}
+
+// SetResultURL sets the result URL for this service, overriding the config value.
+func (s *TaxRemittanceService) SetResultURL(url string) *TaxRemittanceService {
+	s.resultURL = url
+	return s
+}
+
+// Send constructs and sends the PayTaxToKRA request to M-Pesa using the shared B2B helper.
+func (s *TaxRemittanceService) Send() (map[string]any, error) {
+	// Validate required fields
+	if s.initiator == "" {
+		return nil, errors.New("initiator is required")
+	}
+	if s.getSecurityCredential() == "" {
+		return nil, errors.New("security credential is required; call SetInitiatorPassword or SetEncryptedSecurityCredential")
+	}
+	if s.amount <= 0 {
+		return nil, errors.New("amount must be greater than 0")
+	}
+	if _, err := roundAmount(s.amount, s.rounding); err != nil {
+		return nil, fmt.Errorf("invalid amount: %w", err)
+	}
+	if s.partyA == "" && s.Config.GetBusinessCode() == "" {
+		return nil, errors.New("partyA (business shortcode) is required")
+	}
+	if s.prn == "" {
+		return nil, errors.New("payment registration number (PRN) is required; call SetPRN")
+	}
+	if err := validateCallbackURL("queue timeout URL", chooseString(s.queueTimeoutURL, s.Config.GetQueueTimeoutURL()), "call SetQueueTimeoutURL on the service or config", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+	if err := validateCallbackURL("result URL", chooseString(s.resultURL, s.Config.GetResultURL()), "call SetResultURL on the service or config", s.Config.GetEnvironment()); err != nil {
+		return nil, err
+	}
+
+	req := B2BRequest{
+		Initiator:              s.initiator,
+		SecurityCredential:     s.getSecurityCredential(),
+		CommandID:              s.commandID,
+		SenderIdentifierType:   s.senderIdentifierType,
+		RecieverIdentifierType: s.recipientIdentifierType,
+		Amount:                 s.amount,
+		Rounding:               s.rounding,
+		PartyA:                 s.partyA,
+		PartyB:                 s.partyB,
+		AccountReference:       s.prn,
+		Remarks:                s.remarks,
+		QueueTimeOutURL:        s.queueTimeoutURL,
+		ResultURL:              s.resultURL,
+	}
+
+	resp, err := executeB2BRequest(s.Config, s.Client, req, s.Config.Endpoints.TaxRemittance)
+	if err != nil {
+		return nil, err
+	}
+
+	s.response = resp
+	return resp, nil
+}
+
+// ParseCallback parses a received callback payload using the shared ParseB2BCallback helper.
+func (s *TaxRemittanceService) ParseCallback(payload map[string]any) (*B2BCallbackResult, error) {
+	return ParseB2BCallback(payload)
+}
+
+func (s *TaxRemittanceService) getSecurityCredential() string {
+	return chooseString(s.securityCredential, s.Config.GetSecurityCredential())
+}
+
+// GetResponse returns the last API response stored by the service.
+func (s *TaxRemittanceService) GetResponse() map[string]any {
+	return s.response
+}
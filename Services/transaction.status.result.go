@@ -0,0 +1,211 @@
+package Services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TransactionState is the normalised state of a transaction reported by a status result.
+type TransactionState string
+
+// Transaction states derived by ParseTransactionStatusResult.
+const (
+	TransactionStateCompleted TransactionState = "Completed"
+	TransactionStatePending   TransactionState = "Pending"
+	TransactionStateFailed    TransactionState = "Failed"
+	TransactionStateReversed  TransactionState = "Reversed"
+	TransactionStateUnknown   TransactionState = "Unknown" // The query failed or the status was not recognised
+)
+
+// TransactionStatusResult represents a parsed transaction status result callback delivered to the ResultURL.
+type TransactionStatusResult struct {
+	ResultCode               string `json:"ResultCode"`
+	ResultDesc               string `json:"ResultDesc"`
+	OriginatorConversationID string `json:"OriginatorConversationID"`
+	ConversationID           string `json:"ConversationID"`
+	TransactionID            string `json:"TransactionID"`
+
+	DebitPartyName    string           `json:"DebitPartyName"`    // e.g. "600310 - Safaricom333"
+	CreditPartyName   string           `json:"CreditPartyName"`   // e.g. "254708374149 - John Doe"
+	DebitAccountType  string           `json:"DebitAccountType"`  // e.g. "Utility Account"
+	Amount            float64          `json:"Amount"`            // Transaction amount
+	ReceiptNo         string           `json:"ReceiptNo"`         // M-Pesa receipt number of the queried transaction
+	TransactionStatus string           `json:"TransactionStatus"` // Status as sent by M-Pesa, e.g. "Completed"
+	ReasonType        string           `json:"ReasonType"`        // e.g. "Business Payment to Customer via API"
+	InitiatedTime     time.Time        `json:"InitiatedTime"`     // Time the transaction was initiated (EAT)
+	FinalisedTime     time.Time        `json:"FinalisedTime"`     // Time the transaction was finalised (EAT)
+	Status            TransactionState `json:"Status"`            // Normalised state derived from TransactionStatus and ResultCode
+
+	ResultParameters map[string]string `json:"ResultParameters"` // All result parameters as sent by M-Pesa
+	ReferenceData    map[string]string `json:"ReferenceData"`    // Reference items as sent by M-Pesa
+	Raw              map[string]any    `json:"-"`                // The original payload
+	Success          bool              `json:"Success"`          // true when ResultCode is 0, i.e. the query itself succeeded
+}
+
+// ParseTransactionStatusResult parses a transaction status result callback payload into a typed
+// TransactionStatusResult. It builds on ParseResultEnvelope for the common Result envelope, then
+// converts the status specific result parameters. Parameters that are missing or malformed are
+// left at their zero value; the original strings remain available in ResultParameters.
+// Status is TransactionStateUnknown when the query failed, e.g. because the transaction was not found.
+// json.Unmarshal into a TransactionStatusResult decodes the raw body the same way.
+//
+// Parameters:
+//   - payload: The decoded JSON body received on the ResultURL
+//
+// Returns:
+//   - *TransactionStatusResult: The parsed result
+//   - error: An error if the payload has no Result node
+//
+// Example:
+//
+//	result, err := Services.ParseTransactionStatusResult(payload)
+//	if err == nil && result.Status == Services.TransactionStateCompleted {
+//	    fmt.Printf("Receipt %s completed at %s", result.ReceiptNo, result.FinalisedTime)
+//	}
+func ParseTransactionStatusResult(payload map[string]any) (*TransactionStatusResult, error) {
+	envelope, err := ParseResultEnvelope(payload)
+	if err != nil {
+		return nil, err
+	}
+	return newTransactionStatusResult(envelope), nil
+}
+
+// MarshalJSON encodes the result in the schema SchemaTransactionStatusResult.
+func (r TransactionStatusResult) MarshalJSON() ([]byte, error) {
+	type plain TransactionStatusResult
+	return json.Marshal(struct {
+		Schema string `json:"Schema"`
+		plain
+		Raw map[string]any `json:"Raw,omitempty"`
+	}{SchemaTransactionStatusResult, plain(r), marshaledRaw(r.Raw)})
+}
+
+// UnmarshalJSON decodes the raw body of a transaction status result callback, as
+// ParseTransactionStatusResult does, or the JSON written by MarshalJSON.
+func (r *TransactionStatusResult) UnmarshalJSON(data []byte) error {
+	type plain TransactionStatusResult
+	if ok, err := decodeNormalized(data, SchemaTransactionStatusResult, (*plain)(r), &r.Raw); ok {
+		r.InitiatedTime = inEAT(r.InitiatedTime)
+		r.FinalisedTime = inEAT(r.FinalisedTime)
+		return err
+	}
+
+	var envelope ResultEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	*r = *newTransactionStatusResult(&envelope)
+	return nil
+}
+
+// newTransactionStatusResult converts the status specific result parameters of envelope.
+func newTransactionStatusResult(envelope *ResultEnvelope) *TransactionStatusResult {
+	params := envelope.ResultParameters
+	res := &TransactionStatusResult{
+		ResultCode:               envelope.ResultCode,
+		ResultDesc:               envelope.ResultDesc,
+		OriginatorConversationID: chooseString(envelope.OriginatorConversationID, params["OriginatorConversationID"]),
+		ConversationID:           chooseString(envelope.ConversationID, params["ConversationID"]),
+		TransactionID:            envelope.TransactionID,
+		ResultParameters:         params,
+		ReferenceData:            envelope.ReferenceData,
+		Raw:                      envelope.Raw,
+		Success:                  envelope.Success,
+	}
+
+	res.DebitPartyName = params["DebitPartyName"]
+	res.CreditPartyName = params["CreditPartyName"]
+	res.DebitAccountType = params["DebitAccountType"]
+	res.Amount = parseAmount(params["Amount"])
+	res.ReceiptNo = params["ReceiptNo"]
+	res.TransactionStatus = params["TransactionStatus"]
+	res.ReasonType = params["ReasonType"]
+	res.InitiatedTime = parseCompactTime(params["InitiatedTime"])
+	res.FinalisedTime = parseCompactTime(params["FinalisedTime"])
+	res.Status = transactionState(res.Success, res.TransactionStatus)
+
+	return res
+}
+
+// TransactionStatusResultHandler returns an http.HandlerFunc for the transaction status
+// ResultURL and QueueTimeOutURL. Result callbacks are parsed with ParseTransactionStatusResult
+// and passed to onResult; queue timeout notifications (see IsQueueTimeout) are passed to
+// onTimeout as-is. Either callback may be nil. Every accepted callback is acknowledged with the
+// JSON body M-Pesa expects. Only POST requests are accepted, and bodies are limited in size and
+// read time; see WithMaxBodyBytes, WithReadTimeout and WithErrorHandler.
+//
+// Parameters:
+//   - onResult: Called with each parsed result callback
+//   - onTimeout: Called with the raw body of each queue timeout notification
+//   - opts: Optional handler settings
+//
+// Returns:
+//   - http.HandlerFunc: The webhook handler
+//
+// Example:
+//
+//	http.Handle("/mpesa/status/result", Services.TransactionStatusResultHandler(
+//	    func(res *Services.TransactionStatusResult) {
+//	        if res.IsTerminal() {
+//	            poller.Stop(res.OriginatorConversationID)
+//	        }
+//	    },
+//	    nil,
+//	))
+func TransactionStatusResultHandler(onResult func(*TransactionStatusResult), onTimeout func(raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return TransactionStatusResultHandlerContext(ignoreContext(onResult), ignoreContext(onTimeout), opts...)
+}
+
+// TransactionStatusResultHandlerContext is TransactionStatusResultHandler with functions that also receive the context of the
+// request, bounded by the handler timeout; see WithTimeout.
+func TransactionStatusResultHandlerContext(onResult func(context.Context, *TransactionStatusResult), onTimeout func(ctx context.Context, raw map[string]any), opts ...HandlerOption) http.HandlerFunc {
+	return resultHandler(newHandlerOptions(opts), CallbackStatusResult, CallbackStatusTimeout, ParseTransactionStatusResult, onResult, onTimeout)
+}
+
+// IsTerminal reports whether the transaction reached a final state (completed, failed or
+// reversed), so there is no point in polling its status again. Pending transactions and
+// failed or unrecognised queries are not terminal.
+func (r *TransactionStatusResult) IsTerminal() bool {
+	switch r.Status {
+	case TransactionStateCompleted, TransactionStateFailed, TransactionStateReversed:
+		return true
+	}
+	return false
+}
+
+// IsReversed reports whether the transaction has been reversed.
+func (r *TransactionStatusResult) IsReversed() bool {
+	return r.Status == TransactionStateReversed
+}
+
+// IsNotFound reports whether the query failed because M-Pesa does not know the transaction,
+// e.g. because the transaction ID is wrong or has not propagated yet.
+func (r *TransactionStatusResult) IsNotFound() bool {
+	if r.Success {
+		return false
+	}
+	desc := strings.ToLower(r.ResultDesc)
+	return strings.Contains(desc, "does not exist") || strings.Contains(desc, "not found")
+}
+
+// transactionState maps the TransactionStatus parameter to a TransactionState.
+func transactionState(success bool, status string) TransactionState {
+	if !success {
+		return TransactionStateUnknown
+	}
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "completed":
+		return TransactionStateCompleted
+	case "pending":
+		return TransactionStatePending
+	case "failed", "declined", "cancelled", "expired":
+		return TransactionStateFailed
+	case "reversed":
+		return TransactionStateReversed
+	default:
+		return TransactionStateUnknown
+	}
+}
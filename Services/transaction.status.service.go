@@ -1,21 +1,31 @@
 package Services
 
 import (
+	"context"
 	"errors"
+
 	"github.com/venomous-maker/go-mpesa/Abstracts"
 )
 
+// DefaultTransactionStatusRemarks is sent as Remarks when SetRemarks has not been called.
+const DefaultTransactionStatusRemarks = "TransactionStatusQuery"
+
 // TransactionStatusService handles transaction status inquiry operations.
 // This service allows businesses to check the status of any M-Pesa transaction
 // using the transaction ID to get detailed information about the transaction.
 type TransactionStatusService struct {
 	*AbstractService
 
-	initiator      string // Username of the M-Pesa API operator
-	transactionID  string // ID of the transaction to check status for
-	identifierType string // Type of organization checking the transaction
-	remarks        string // Comments for the status inquiry
-	occasion       string // Occasion or reason for the status check
+	initiator       string // Username of the M-Pesa API operator
+	transactionID   string // ID of the transaction to check status for
+	originalConvID  string // OriginatorConversationID of the request to check, instead of the transaction ID
+	identifierType  string // Type of organization checking the transaction
+	remarks         string // Comments for the status inquiry (defaults to DefaultTransactionStatusRemarks)
+	occasion        string // Occasion or reason for the status check
+	partyA          string // Shortcode checking the transaction; defaults to the config business code
+	queueTimeoutURL string // Queue timeout URL for this service; defaults to the config value
+	resultURL       string // Result URL for this service; defaults to the config value
+	waiter          resultWaiter
 }
 
 // NewTransactionStatusService creates a new transaction status service instance with the provided configuration and client.
@@ -74,6 +84,24 @@ func (s *TransactionStatusService) SetTransactionID(id string) *TransactionStatu
 	return s
 }
 
+// SetOriginalConversationID checks the request with the given OriginatorConversationID instead
+// of a transaction ID, e.g. a B2C payment whose result never arrived. When set, the
+// transaction ID is optional.
+//
+// Parameters:
+//   - id: The OriginatorConversationID of the request to check
+//
+// Returns:
+//   - *TransactionStatusService: Returns self for method chaining
+//
+// Example:
+//
+//	statusService.SetOriginalConversationID("AG_20191219_00005797af5d7d75f652")
+func (s *TransactionStatusService) SetOriginalConversationID(id string) *TransactionStatusService {
+	s.originalConvID = id
+	return s
+}
+
 // SetIdentifierType sets the type of organization checking the transaction status.
 // This identifies the type of shortcode making the inquiry.
 //
@@ -83,15 +111,15 @@ func (s *TransactionStatusService) SetTransactionID(id string) *TransactionStatu
 // Returns:
 //   - *TransactionStatusService: Returns self for method chaining
 //
-// Common Identifier Types:
-//   - "1": MSISDN (phone number)
-//   - "2": Till Number
-//   - "4": Organization shortcode
-//   - "11": Paybill
+// Common Identifier Types (see the IdentifierType constants):
+//   - "1": MSISDN (phone number, IdentifierTypeMSISDN)
+//   - "2": Till Number (IdentifierTypeTillNumber)
+//   - "4": Organization shortcode (IdentifierTypeShortcode)
+//   - "11": Paybill (IdentifierTypePaybill)
 //
 // Example:
 //
-//	statusService.SetIdentifierType("4")  // For organization shortcode
+//	statusService.SetIdentifierType(string(Services.IdentifierTypeShortcode))
 func (s *TransactionStatusService) SetIdentifierType(idType string) *TransactionStatusService {
 	s.identifierType = idType
 	return s
@@ -99,6 +127,7 @@ func (s *TransactionStatusService) SetIdentifierType(idType string) *Transaction
 
 // SetRemarks sets comments or additional information for the status inquiry.
 // This helps identify the purpose of the status check in transaction records.
+// When unset or empty, DefaultTransactionStatusRemarks is sent.
 //
 // Parameters:
 //   - remarks: A descriptive string for the status inquiry
@@ -133,8 +162,52 @@ func (s *TransactionStatusService) SetOccasion(occasion string) *TransactionStat
 	return s
 }
 
+// SetPartyA sets the shortcode checking the transaction for this service only.
+// It overrides the config business code without modifying the config.
+//
+// Parameters:
+//   - partyA: The shortcode, till number or MSISDN matching the identifier type
+//
+// Returns:
+//   - *TransactionStatusService: Returns self for method chaining
+//
+// Example:
+//
+//	statusService.SetPartyA("600997")
+func (s *TransactionStatusService) SetPartyA(partyA string) *TransactionStatusService {
+	s.partyA = partyA
+	return s
+}
+
+// SetQueueTimeoutURL sets the queue timeout URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa calls when the request times out in the queue
+//
+// Returns:
+//   - *TransactionStatusService: Returns self for method chaining
+func (s *TransactionStatusService) SetQueueTimeoutURL(url string) *TransactionStatusService {
+	s.queueTimeoutURL = url
+	return s
+}
+
+// SetResultURL sets the result URL for this service, overriding the config value.
+//
+// Parameters:
+//   - url: The URL M-Pesa posts the status result to
+//
+// Returns:
+//   - *TransactionStatusService: Returns self for method chaining
+func (s *TransactionStatusService) SetResultURL(url string) *TransactionStatusService {
+	s.resultURL = url
+	return s
+}
+
 // Query initiates a transaction status inquiry to check the current status of a transaction.
 // This method validates all required parameters and sends the status request to M-Pesa.
+// Required fields: Initiator, SecurityCredential, TransactionID, PartyA, IdentifierType,
+// QueueTimeOutURL, ResultURL. The status itself is delivered asynchronously to the ResultURL;
+// see ParseTransactionStatusResult.
 //
 // Returns:
 //   - map[string]any: The response from the M-Pesa API containing transaction status information
@@ -159,27 +232,46 @@ func (s *TransactionStatusService) Query() (map[string]any, error) {
 	if s.initiator == "" {
 		return nil, errors.New("initiator is required")
 	}
-	if s.transactionID == "" {
-		return nil, errors.New("transaction ID is required")
+	if s.transactionID == "" && s.originalConvID == "" {
+		return nil, errors.New("transaction ID is required; call SetTransactionID or SetOriginalConversationID")
 	}
 	if s.identifierType == "" {
 		return nil, errors.New("identifier type is required")
 	}
+	partyA := chooseString(s.partyA, s.Config.GetBusinessCode())
+	if partyA == "" {
+		return nil, errors.New("business shortcode (PartyA) is required; call SetPartyA or SetBusinessCode on mpesa config")
+	}
+	queueTimeoutURL := chooseString(s.queueTimeoutURL, s.Config.GetQueueTimeoutURL())
+	if queueTimeoutURL == "" {
+		return nil, errors.New("queue timeout URL is required; call SetQueueTimeoutURL on the service or config")
+	}
+	resultURL := chooseString(s.resultURL, s.Config.GetResultURL())
+	if resultURL == "" {
+		return nil, errors.New("result URL is required; call SetResultURL on the service or config")
+	}
+	securityCredential := s.Config.GetSecurityCredential()
+	if securityCredential == "" {
+		return nil, errors.New("security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config")
+	}
 
 	data := map[string]any{
 		"Initiator":          s.initiator,
-		"SecurityCredential": s.Config.GetSecurityCredential(),
+		"SecurityCredential": securityCredential,
 		"CommandID":          "TransactionStatusQuery",
 		"TransactionID":      s.transactionID,
-		"PartyA":             s.Config.GetBusinessCode(),
+		"PartyA":             partyA,
 		"IdentifierType":     s.identifierType,
-		"Remarks":            s.remarks,
-		"QueueTimeOutURL":    s.Config.GetQueueTimeoutURL(),
-		"ResultURL":          s.Config.GetResultURL(),
+		"Remarks":            chooseString(s.remarks, DefaultTransactionStatusRemarks),
+		"QueueTimeOutURL":    queueTimeoutURL,
+		"ResultURL":          resultURL,
 		"Occasion":           s.occasion,
 	}
+	if s.originalConvID != "" {
+		data["OriginalConversationID"] = s.originalConvID
+	}
 
-	response, err := s.Client.ExecuteRequest(data, "/mpesa/transactionstatus/v1/query")
+	response, err := s.Client.ExecuteRequest(data, s.Config.Endpoints.TransactionStatus)
 	if err != nil {
 		return nil, err
 	}
@@ -187,3 +279,46 @@ func (s *TransactionStatusService) Query() (map[string]any, error) {
 	s.setResponse(response)
 	return response, nil
 }
+
+// queryFor returns a copy of the service that queries the request with OriginatorConversationID
+// originatorID, leaving s untouched so that concurrent callers can share it.
+func (s *TransactionStatusService) queryFor(originatorID string) *TransactionStatusService {
+	query := *s
+	query.AbstractService = &AbstractService{BaseService: s.BaseService}
+	query.transactionID = ""
+	query.originalConvID = originatorID
+	query.waiter = resultWaiter{}
+	return &query
+}
+
+// SetResultCorrelator sets the correlator SendAndWait waits for the status result with. The
+// handler of the ResultURL must resolve results in it; see WithResultCorrelator.
+//
+// Parameters:
+//   - correlator: The correlator shared with the result handler
+//
+// Returns:
+//   - *TransactionStatusService: Returns self for method chaining
+func (s *TransactionStatusService) SetResultCorrelator(correlator ResultCorrelator) *TransactionStatusService {
+	s.waiter.correlator = correlator
+	return s
+}
+
+// SendAndWait sends the status query with Query and waits until its result arrives on the
+// ResultURL or QueueTimeOutURL, or ctx is done.
+//
+// Parameters:
+//   - ctx: Bounds the wait for the result
+//
+// Returns:
+//   - *CorrelatedResult: The result, with Result set to a *TransactionStatusResult
+//   - error: An error if the query fails or no result arrives in time (ErrResultTimeout)
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+//	defer cancel()
+//	res, err := statusService.SetResultCorrelator(correlator).SetTransactionID("OEI2AK4Q16").SendAndWait(ctx)
+func (s *TransactionStatusService) SendAndWait(ctx context.Context) (*CorrelatedResult, error) {
+	return s.waiter.sendAndWait(ctx, "", s.Query)
+}
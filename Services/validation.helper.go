@@ -0,0 +1,53 @@
+package Services
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// validateLength checks that value has between min and max characters (inclusive).
+// The error names the field, the limit and the actual length.
+func validateLength(field, value string, min, max int) error {
+	n := utf8.RuneCountInString(value)
+	if min > 0 && n == 0 {
+		return fmt.Errorf("%s is required", field)
+	}
+	if n < min || n > max {
+		return fmt.Errorf("%s must be between %d and %d characters, got %d", field, min, max, n)
+	}
+	return nil
+}
+
+// validateCallbackURL checks that a callback URL is set, absolute and, in production, uses https.
+// hint tells the caller how to set the URL and is appended to the "required" error.
+func validateCallbackURL(field, value, hint string, env abstracts.Environment) error {
+	if value == "" {
+		return fmt.Errorf("%s is required; %s", field, hint)
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%s %q is not a valid absolute URL", field, value)
+	}
+	if env == abstracts.Production && u.Scheme != "https" {
+		return fmt.Errorf("%s must use https in production, got %q", field, value)
+	}
+	return nil
+}
+
+// validateEmail checks that value is a single bare email address; field names it in the error.
+func validateEmail(field, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || !strings.Contains(value[strings.LastIndex(value, "@")+1:], ".") {
+		return fmt.Errorf("invalid %s %q: must be an address like name@example.com", field, value)
+	}
+	return nil
+}
@@ -0,0 +1,515 @@
+package Services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// DefaultMaxWebhookBodyBytes is the default request body limit for webhook handlers.
+const DefaultMaxWebhookBodyBytes int64 = 1 << 20
+
+// DefaultWebhookTimeout is the default time handlers give the callback function before
+// responding to M-Pesa.
+const DefaultWebhookTimeout = 5 * time.Second
+
+// DefaultWebhookReadTimeout is the default time handlers allow for receiving the request body.
+const DefaultWebhookReadTimeout = 10 * time.Second
+
+// ErrCallbackReadTimeout is reported when the request body is not received within the read
+// timeout, as happens when a client trickles it in.
+var ErrCallbackReadTimeout = errors.New("callback body read timed out")
+
+// ErrCallbackTimeout is reported when a callback function does not finish within the handler
+// timeout.
+var ErrCallbackTimeout = errors.New("callback timed out")
+
+// CallbackPanicError is reported when a callback function panics.
+type CallbackPanicError struct {
+	Value any    // The value passed to panic
+	Stack []byte // Stack trace of the panicking goroutine
+}
+
+// Error implements the error interface.
+func (e *CallbackPanicError) Error() string {
+	return fmt.Sprintf("callback panicked: %v", e.Value)
+}
+
+// webhookAck is the acknowledgement body M-Pesa expects from callback endpoints.
+var webhookAck = []byte(`{"ResultCode":0,"ResultDesc":"Accepted"}`)
+
+// AckPolicy controls how webhook handlers answer callbacks they could not handle.
+type AckPolicy int
+
+const (
+	// AlwaysAck acknowledges every callback with 200 and the standard body, even when it cannot
+	// be parsed or the callback function fails, so that M-Pesa does not keep retrying it.
+	// It is the default policy.
+	AlwaysAck AckPolicy = iota
+
+	// StrictAck answers callbacks that cannot be parsed with 400 Bad Request and callbacks whose
+	// function fails with 500 Internal Server Error, so problems are noticed during development.
+	StrictAck
+)
+
+// HandlerOption configures a webhook handler.
+type HandlerOption func(*handlerOptions)
+
+// handlerOptions holds the settings shared by all webhook handlers.
+type handlerOptions struct {
+	maxBodyBytes int64
+	readTimeout  time.Duration
+	timeout      time.Duration
+	noRecover    bool
+	onError      func(err error, r *http.Request)
+	onParseError func(callbackType string, raw []byte, err error)
+	ackPolicy    AckPolicy
+	ackBody      []byte
+	verifyToken  bool
+	formField    string
+	urlTokens    [][]byte
+	processed    ProcessedStore
+	processedTTL time.Duration
+	onDuplicate  func(id string)
+	rawPersist   RawPersistHook
+	rawRequired  bool
+	correlator   ResultCorrelator
+	clock        Abstracts.Clock
+	strict       bool
+}
+
+// WithMaxBodyBytes limits the size of accepted callback bodies. For a larger body handlers
+// report ErrCallbackTooLarge to the error handler and acknowledge the callback, or reject it
+// with 413 Request Entity Too Large under StrictAck; the FromReader and FromRequest parsers
+// return ErrCallbackTooLarge. The default is DefaultMaxWebhookBodyBytes.
+func WithMaxBodyBytes(n int64) HandlerOption {
+	return func(o *handlerOptions) {
+		o.maxBodyBytes = n
+	}
+}
+
+// WithReadTimeout limits how long handlers spend receiving and decoding the request body.
+// When the body is not complete in time, ErrCallbackReadTimeout is reported and the callback
+// is acknowledged, or rejected with 408 Request Timeout under StrictAck. The deadline is also
+// set on the connection where the ResponseWriter supports it, so that a stalled read is
+// interrupted rather than noticed at the next chunk. Zero or a negative duration disables it.
+// The default is DefaultWebhookReadTimeout; together with WithTimeout it bounds how long a
+// handler holds a request.
+func WithReadTimeout(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.readTimeout = d
+	}
+}
+
+// WithTimeout sets how long handlers wait for the callback function before responding; the
+// function then keeps running in the background and ErrCallbackTimeout is reported. The wait
+// also ends when the request is cancelled. Either way the context passed to the functions of
+// the context-aware handlers, such as STKCallbackHandlerContext, is cancelled, so that they can
+// stop their work. Zero or a negative duration disables the timeout.
+// The default is DefaultWebhookTimeout.
+func WithTimeout(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		o.timeout = d
+	}
+}
+
+// WithPanicRecovery controls whether handlers recover panics in the callback function.
+// Recovered panics are reported as a *CallbackPanicError carrying the stack trace, and the
+// callback is answered according to the ack policy. When disabled, the panic is re-raised in
+// the request goroutine and left to net/http. Recovery is enabled by default.
+func WithPanicRecovery(enabled bool) HandlerOption {
+	return func(o *handlerOptions) {
+		o.noRecover = !enabled
+	}
+}
+
+// WithErrorHandler passes payloads that cannot be decoded or parsed, and errors of the
+// callback function, to fn. How the callback is then answered depends on the ack policy;
+// see WithAckPolicy.
+func WithErrorHandler(fn func(err error, r *http.Request)) HandlerOption {
+	return func(o *handlerOptions) {
+		o.onError = fn
+	}
+}
+
+// WithOnParseError calls fn with the callback type, the complete raw body and the error of
+// every callback that cannot be decoded or parsed, e.g. to forward it to an alerting pipeline.
+// fn runs in addition to the error handler, under the handler timeout and panic recovery, and
+// cannot change how the callback is answered; see WithAckPolicy. raw must not be modified.
+// Bodies rejected for their method, URL token, size or read time are not passed to fn.
+func WithOnParseError(fn func(callbackType string, raw []byte, err error)) HandlerOption {
+	return func(o *handlerOptions) {
+		o.onParseError = fn
+	}
+}
+
+// WithFormField accepts callbacks delivered as a form (application/x-www-form-urlencoded)
+// whose field name holds the JSON body, as some proxies in front of M-Pesa do. Bodies that are
+// JSON objects are decoded as usual, whatever their Content-Type.
+func WithFormField(name string) HandlerOption {
+	return func(o *handlerOptions) {
+		o.formField = name
+	}
+}
+
+// WithAckPolicy sets how callbacks that could not be handled are answered. The default is
+// AlwaysAck.
+func WithAckPolicy(policy AckPolicy) HandlerOption {
+	return func(o *handlerOptions) {
+		o.ackPolicy = policy
+	}
+}
+
+// WithAckBody replaces the JSON body handlers acknowledge callbacks with, for receivers that
+// expect a specific acknowledgement. The responses of C2BValidationHandler are decided by its
+// function and are not affected.
+func WithAckBody(body []byte) HandlerOption {
+	return func(o *handlerOptions) {
+		o.ackBody = body
+	}
+}
+
+// WithProcessedStore deduplicates callbacks by their transaction ID using store, so that a
+// callback redelivered by M-Pesa is acknowledged but only handled once. IDs are remembered
+// for ttl (DefaultProcessedTTL when zero or negative); callbacks without a transaction ID are
+// always handled. Supported by C2BConfirmationHandler.
+func WithProcessedStore(store ProcessedStore, ttl time.Duration) HandlerOption {
+	return func(o *handlerOptions) {
+		if ttl <= 0 {
+			ttl = DefaultProcessedTTL
+		}
+		o.processed = store
+		o.processedTTL = ttl
+	}
+}
+
+// WithOnDuplicate calls fn with the transaction ID of each duplicate callback skipped by the
+// processed store, e.g. to count redeliveries.
+func WithOnDuplicate(fn func(id string)) HandlerOption {
+	return func(o *handlerOptions) {
+		o.onDuplicate = fn
+	}
+}
+
+// WithClock sets the clock handlers take the time callbacks are received from, e.g. an
+// Abstracts.FakeClock in tests. The receive time is passed to the raw persist hook; see
+// CallbackReceivedAt. Read and handler timeouts are measured in real time whatever the clock.
+// The default is the system clock.
+func WithClock(clock Abstracts.Clock) HandlerOption {
+	return func(o *handlerOptions) {
+		if clock == nil {
+			clock = Abstracts.SystemClock{}
+		}
+		o.clock = clock
+	}
+}
+
+// newHandlerOptions applies opts over the defaults.
+func newHandlerOptions(opts []HandlerOption) *handlerOptions {
+	o := &handlerOptions{
+		maxBodyBytes: DefaultMaxWebhookBodyBytes,
+		readTimeout:  DefaultWebhookReadTimeout,
+		timeout:      DefaultWebhookTimeout,
+		clock:        Abstracts.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// readWebhookPayload enforces the method, URL token, size and read time limits, passes the raw
+// body to the persist hook and decodes the JSON body with decodeCallback. callback is the type
+// the body is persisted as; for result handlers, timeout is used instead for queue timeout
+// notifications. The raw body is returned when a persist or parse error hook needs it. When
+// the method or URL token is wrong, or the persist hook fails, it writes the error response
+// itself and returns rejected; decode errors, including ErrCallbackTooLarge and
+// ErrCallbackReadTimeout, are returned for the caller to handle.
+func (o *handlerOptions) readWebhookPayload(w http.ResponseWriter, r *http.Request, callback, timeout CallbackType) (payload map[string]any, raw []byte, rejected bool, err error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, nil, true, nil
+	}
+	received := o.clock.Now()
+	if o.verifyToken && !o.hasValidToken(r) {
+		o.report(ErrInvalidCallbackToken, r)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, nil, true, nil
+	}
+
+	body := io.Reader(http.MaxBytesReader(w, r.Body, o.maxBodyBytes))
+	if o.readTimeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), o.readTimeout)
+		defer cancel()
+		// The deadline is left in place: net/http drains what remains of the body after the
+		// handler returns, and would otherwise wait on a stalled client. The server resets it
+		// for the next request on the connection. Connection deadlines are wall-clock times,
+		// whatever the handler clock.
+		_ = http.NewResponseController(w).SetReadDeadline(time.Now().Add(o.readTimeout))
+		body = &contextReader{ctx: ctx, r: body}
+	}
+	if o.rawPersist != nil || o.onParseError != nil {
+		raw, err = io.ReadAll(body)
+		if err == nil {
+			body = bytes.NewReader(raw)
+		}
+	}
+	if err == nil {
+		payload, err = o.decodeCallback(body, r.Header.Get("Content-Type"))
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			// The body was cut off at the limit, so there is nothing complete to persist.
+			return nil, nil, false, fmt.Errorf("%w: over %d bytes", ErrCallbackTooLarge, o.maxBodyBytes)
+		}
+		if isReadTimeout(err) {
+			err = fmt.Errorf("%w: body not received within %s", ErrCallbackReadTimeout, o.readTimeout)
+		}
+	}
+
+	if o.rawPersist != nil {
+		if timeout != "" && IsQueueTimeout(payload) {
+			callback = timeout
+		}
+		if !o.persistRaw(r, callback, raw, received) {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return nil, nil, true, nil
+		}
+	}
+	return payload, raw, false, err
+}
+
+// contextReader fails reads once ctx is done, so that a body trickled in chunks is cut off at
+// the read deadline even when the connection deadline cannot be set.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// isReadTimeout reports whether err comes from the read deadline of the request body.
+func isReadTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// report passes err to the error handler, if one is configured.
+func (o *handlerOptions) report(err error, r *http.Request) {
+	if o.onError != nil {
+		o.onError(err, r)
+	}
+}
+
+// invoke runs fn, waiting at most o.timeout for it to finish and no longer than the request
+// lives. fn is given the request context, bounded by o.timeout; it is cancelled when the wait
+// ends, so that fn can stop its work. A panic in fn is recovered and returned as a
+// *CallbackPanicError, or re-raised when panic recovery is disabled. When the wait ends first,
+// fn keeps running in the background until it returns, completed is false and a later failure
+// of fn is reported to the error handler.
+func (o *handlerOptions) invoke(r *http.Request, fn func(ctx context.Context) error) (completed bool, err error) {
+	ctx, cancel := r.Context(), context.CancelFunc(func() {})
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	}
+	defer cancel()
+
+	done := make(chan error, 1)
+	run := func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- &CallbackPanicError{Value: p, Stack: debug.Stack()}
+			}
+		}()
+		done <- fn(ctx)
+	}
+
+	if o.timeout <= 0 {
+		run()
+		return true, o.checkPanic(<-done)
+	}
+
+	go run()
+	select {
+	case err := <-done:
+		return true, o.checkPanic(err)
+	case <-ctx.Done():
+		go func() {
+			if err := <-done; err != nil {
+				o.report(fmt.Errorf("callback failed after the handler responded: %w", err), r)
+			}
+		}()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return false, fmt.Errorf("%w: did not finish within %s", ErrCallbackTimeout, o.timeout)
+		}
+		return false, ctx.Err()
+	}
+}
+
+// checkPanic re-raises a recovered panic when panic recovery is disabled.
+func (o *handlerOptions) checkPanic(err error) error {
+	var p *CallbackPanicError
+	if o.noRecover && errors.As(err, &p) {
+		panic(p.Value)
+	}
+	return err
+}
+
+// call runs a callback function that returns nothing through invoke.
+func (o *handlerOptions) call(r *http.Request, fn func(ctx context.Context)) error {
+	_, err := o.invoke(r, func(ctx context.Context) error {
+		fn(ctx)
+		return nil
+	})
+	return err
+}
+
+// ignoreContext adapts a callback function without a context for the handlers built on the
+// context-aware variants; nil stays nil.
+func ignoreContext[T any](fn func(T)) func(context.Context, T) {
+	if fn == nil {
+		return nil
+	}
+	return func(_ context.Context, v T) { fn(v) }
+}
+
+// resultHandler returns a handler for the ResultURL and QueueTimeOutURL of an asynchronous API,
+// whose callbacks are persisted and correlated as callback or timeout.
+// Queue timeout notifications, as classified by IsQueueTimeout, are passed to onTimeout as-is;
+// other payloads are parsed with parse and passed to onResult. Either callback may be nil.
+func resultHandler[T any](o *handlerOptions, callback, timeout CallbackType, parse func(map[string]any) (T, error), onResult func(context.Context, T), onTimeout func(context.Context, map[string]any)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, raw, rejected, err := o.readWebhookPayload(w, r, callback, timeout)
+		if rejected {
+			return
+		}
+		if err != nil {
+			o.fail(w, r, callback, raw, err, webhookAck)
+			return
+		}
+
+		if IsQueueTimeout(payload) {
+			o.resolveResult(correlatedTimeout(timeout, payload))
+			if onTimeout != nil {
+				if err := o.call(r, func(ctx context.Context) { onTimeout(ctx, payload) }); err != nil {
+					o.failCallback(w, r, err, webhookAck)
+					return
+				}
+			}
+			o.ack(w, webhookAck)
+			return
+		}
+
+		if o.strict {
+			if _, err := ParseResultEnvelopeStrict(payload); err != nil {
+				o.fail(w, r, callback, raw, err, webhookAck)
+				return
+			}
+		}
+		result, err := parse(payload)
+		if err != nil {
+			o.fail(w, r, callback, raw, err, webhookAck)
+			return
+		}
+		o.resolveResult(correlatedResult(callback, payload, result))
+		if onResult != nil {
+			if err := o.call(r, func(ctx context.Context) { onResult(ctx, result) }); err != nil {
+				o.failCallback(w, r, err, webhookAck)
+				return
+			}
+		}
+		o.ack(w, webhookAck)
+	}
+}
+
+// fail handles a callback of type callback that could not be decoded or parsed: the error is
+// reported, raw is passed to the parse error hook and the callback is acknowledged with ack,
+// or rejected under StrictAck with the status of decodeStatus.
+func (o *handlerOptions) fail(w http.ResponseWriter, r *http.Request, callback CallbackType, raw []byte, err error, ack []byte) {
+	o.parseFailed(r, callback, raw, err)
+	if !o.rejectStrict(w, r, err, decodeStatus(err)) {
+		o.ack(w, ack)
+	}
+}
+
+// parseFailed passes a callback that could not be decoded or parsed to the parse error hook, if
+// one is configured. Bodies that were not received in full, or were over the size limit, are
+// left out. Failures of the hook are reported to the error handler.
+func (o *handlerOptions) parseFailed(r *http.Request, callback CallbackType, raw []byte, err error) {
+	if o.onParseError == nil || errors.Is(err, ErrCallbackReadTimeout) || errors.Is(err, ErrCallbackTooLarge) {
+		return
+	}
+	if hookErr := o.call(r, func(context.Context) { o.onParseError(string(callback), raw, err) }); hookErr != nil {
+		o.report(fmt.Errorf("parse error hook failed: %w", hookErr), r)
+	}
+}
+
+// decodeStatus returns the StrictAck status for a callback that could not be decoded: 408 when
+// the body was not received in time, 413 when it was over the size limit and 400 otherwise.
+func decodeStatus(err error) int {
+	if errors.Is(err, ErrCallbackReadTimeout) {
+		return http.StatusRequestTimeout
+	}
+	if errors.Is(err, ErrCallbackTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// failCallback handles a callback whose function failed: the error is reported and the
+// callback is acknowledged with ack, or rejected with 500 under StrictAck.
+func (o *handlerOptions) failCallback(w http.ResponseWriter, r *http.Request, err error, ack []byte) {
+	if !o.rejectStrict(w, r, err, http.StatusInternalServerError) {
+		o.ack(w, ack)
+	}
+}
+
+// rejectStrict reports err and, under StrictAck, answers the callback with status. It returns
+// false when the callback still has to be answered. The text of callback function errors is
+// not sent back.
+func (o *handlerOptions) rejectStrict(w http.ResponseWriter, r *http.Request, err error, status int) bool {
+	o.report(err, r)
+	if o.ackPolicy != StrictAck {
+		return false
+	}
+	msg := http.StatusText(status)
+	if status == http.StatusBadRequest {
+		msg = err.Error()
+	}
+	http.Error(w, msg, status)
+	return true
+}
+
+// ack acknowledges a callback with body, or with the body set by WithAckBody.
+func (o *handlerOptions) ack(w http.ResponseWriter, body []byte) {
+	if o.ackBody != nil {
+		body = o.ackBody
+	}
+	writeWebhookBody(w, body)
+}
+
+// writeWebhookBody writes a 200 JSON response.
+func writeWebhookBody(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,438 @@
+package mpesatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// eat is the timezone M-Pesa timestamps are expressed in.
+var eat = time.FixedZone("EAT", 3*60*60)
+
+// Fixture is a sample callback body, as M-Pesa posts it, in the two forms tests need.
+type Fixture struct {
+	Payload map[string]any // The body as encoding/json decodes it, for the Services Parse functions
+	JSON    []byte         // The encoded body, for posting to a webhook handler
+}
+
+// newFixture encodes payload, which only holds values encoding/json decodes to (strings,
+// float64, []any and map[string]any), so that decoding JSON gives Payload back.
+func newFixture(payload map[string]any) Fixture {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		panic(fmt.Sprintf("mpesatest: encoding fixture: %v", err))
+	}
+	return Fixture{Payload: payload, JSON: data}
+}
+
+// FixtureOption overrides a value of a generated callback. Options that do not apply to a
+// callback type, such as WithCheckoutRequestID for a B2C result, are ignored.
+type FixtureOption func(*fixtureValues)
+
+// fixtureValues holds the values a fixture builder fills in.
+type fixtureValues struct {
+	amount                   float64
+	phone                    string
+	transactionID            string
+	merchantRequestID        string
+	checkoutRequestID        string
+	originatorConversationID string
+	conversationID           string
+	resultCode               int
+	resultDesc               string
+	accountReference         string
+	shortCode                string
+	completedAt              time.Time
+}
+
+// WithAmount sets the transaction amount.
+func WithAmount(amount float64) FixtureOption {
+	return func(v *fixtureValues) {
+		v.amount = amount
+	}
+}
+
+// WithPhone sets the customer's phone number, e.g. "254708374149".
+func WithPhone(phone string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.phone = phone
+	}
+}
+
+// WithTransactionID sets the M-Pesa transaction ID: the MpesaReceiptNumber of STK Push
+// callbacks, the TransID of C2B confirmations and the TransactionID of results.
+func WithTransactionID(id string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.transactionID = id
+	}
+}
+
+// WithMerchantRequestID sets the MerchantRequestID of STK Push callbacks.
+func WithMerchantRequestID(id string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.merchantRequestID = id
+	}
+}
+
+// WithCheckoutRequestID sets the CheckoutRequestID of STK Push callbacks.
+func WithCheckoutRequestID(id string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.checkoutRequestID = id
+	}
+}
+
+// WithOriginatorConversationID sets the OriginatorConversationID of results.
+func WithOriginatorConversationID(id string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.originatorConversationID = id
+	}
+}
+
+// WithConversationID sets the ConversationID of results.
+func WithConversationID(id string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.conversationID = id
+	}
+}
+
+// WithResultCode sets the result code and description. A non-zero code makes the callback a
+// failure, which carries no CallbackMetadata or ResultParameters.
+func WithResultCode(code int, desc string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.resultCode = code
+		v.resultDesc = desc
+	}
+}
+
+// WithAccountReference sets the BillRefNumber of C2B confirmations and the
+// BillReferenceNumber of B2B results.
+func WithAccountReference(ref string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.accountReference = ref
+	}
+}
+
+// WithShortCode sets the business short code of C2B confirmations and the debit party of
+// results.
+func WithShortCode(code string) FixtureOption {
+	return func(v *fixtureValues) {
+		v.shortCode = code
+	}
+}
+
+// WithCompletedAt sets the transaction time, written in the format of each callback type.
+func WithCompletedAt(t time.Time) FixtureOption {
+	return func(v *fixtureValues) {
+		v.completedAt = t
+	}
+}
+
+// newFixtureValues returns the defaults of the sandbox documentation with opts applied.
+func newFixtureValues(defaults fixtureValues, opts []FixtureOption) fixtureValues {
+	v := fixtureValues{
+		amount:                   10,
+		phone:                    "254708374149",
+		transactionID:            "NLJ41HAY6Q",
+		merchantRequestID:        "29115-34620561-1",
+		checkoutRequestID:        "ws_CO_191220191020363925",
+		originatorConversationID: "10571-7910404-1",
+		conversationID:           "AG_20191219_00004e48cf7e3533f581",
+		resultDesc:               "The service request is processed successfully.",
+		accountReference:         "A123",
+		shortCode:                "600638",
+		completedAt:              time.Date(2019, 12, 19, 11, 45, 50, 0, eat),
+	}
+	if defaults.resultCode != 0 {
+		v.resultCode, v.resultDesc = defaults.resultCode, defaults.resultDesc
+	}
+	for _, opt := range opts {
+		opt(&v)
+	}
+	return v
+}
+
+// compactTime formats t as the yyyyMMddHHmmss timestamps of M-Pesa.
+func compactTime(t time.Time) string {
+	return t.In(eat).Format("20060102150405")
+}
+
+// compactTimeNumber returns the yyyyMMddHHmmss timestamp of t as the number some callbacks
+// send it as.
+func compactTimeNumber(t time.Time) float64 {
+	n, _ := strconv.ParseFloat(compactTime(t), 64)
+	return n
+}
+
+// phoneNumber returns phone as the number STK Push callbacks send it as.
+func phoneNumber(phone string) any {
+	if n, err := strconv.ParseFloat(phone, 64); err == nil {
+		return n
+	}
+	return phone
+}
+
+// item returns a {"Key": ..., "Value": ...} result parameter.
+func item(key string, value any) any {
+	return map[string]any{"Key": key, "Value": value}
+}
+
+// resultPayload returns a result callback with parameters, which are left out of failures.
+func resultPayload(v fixtureValues, queueTimeoutURL string, parameters []any, reference ...any) map[string]any {
+	result := map[string]any{
+		"ResultType":               float64(0),
+		"ResultCode":               float64(v.resultCode),
+		"ResultDesc":               v.resultDesc,
+		"OriginatorConversationID": v.originatorConversationID,
+		"ConversationID":           v.conversationID,
+		"TransactionID":            v.transactionID,
+		"ReferenceData": map[string]any{
+			"ReferenceItem": append([]any{item("QueueTimeoutURL", queueTimeoutURL)}, reference...),
+		},
+	}
+	if v.resultCode == 0 {
+		result["ResultParameters"] = map[string]any{"ResultParameter": parameters}
+	}
+	return map[string]any{"Result": result}
+}
+
+// NewStkSuccessCallback returns a successful STK Push callback, with CallbackMetadata holding
+// the amount, receipt number, transaction date and phone number.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithCheckoutRequestID and WithAmount
+//
+// Returns:
+//   - Fixture: The callback body
+//
+// Example:
+//
+//	fixture := mpesatest.NewStkSuccessCallback(mpesatest.WithCheckoutRequestID(checkoutID), mpesatest.WithAmount(250))
+//	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mpesa/stk", bytes.NewReader(fixture.JSON)))
+func NewStkSuccessCallback(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithTransactionID("NLJ7RT61SV"),
+		WithAmount(1),
+		WithCompletedAt(time.Date(2019, 12, 19, 10, 21, 15, 0, eat)),
+	}, opts...))
+	return stkCallback(v)
+}
+
+// NewStkCancelledCallback returns the STK Push callback sent when the customer dismisses the
+// prompt (ResultCode 1032). Use WithResultCode for other failures, e.g. 1037 when the phone
+// could not be reached.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithCheckoutRequestID
+//
+// Returns:
+//   - Fixture: The callback body
+func NewStkCancelledCallback(opts ...FixtureOption) Fixture {
+	return stkCallback(newFixtureValues(fixtureValues{resultCode: 1032, resultDesc: "Request cancelled by user."}, opts))
+}
+
+// stkCallback builds an STK Push callback body from v.
+func stkCallback(v fixtureValues) Fixture {
+	callback := map[string]any{
+		"MerchantRequestID": v.merchantRequestID,
+		"CheckoutRequestID": v.checkoutRequestID,
+		"ResultCode":        float64(v.resultCode),
+		"ResultDesc":        v.resultDesc,
+	}
+	if v.resultCode == 0 {
+		callback["CallbackMetadata"] = map[string]any{"Item": []any{
+			map[string]any{"Name": "Amount", "Value": v.amount},
+			map[string]any{"Name": "MpesaReceiptNumber", "Value": v.transactionID},
+			map[string]any{"Name": "Balance"},
+			map[string]any{"Name": "TransactionDate", "Value": compactTimeNumber(v.completedAt)},
+			map[string]any{"Name": "PhoneNumber", "Value": phoneNumber(v.phone)},
+		}}
+	}
+	return newFixture(map[string]any{"Body": map[string]any{"stkCallback": callback}})
+}
+
+// NewC2BConfirmation returns a Pay Bill C2B confirmation. The same body is posted to the
+// validation URL.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithTransactionID, WithAmount and WithAccountReference
+//
+// Returns:
+//   - Fixture: The callback body
+func NewC2BConfirmation(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithTransactionID("RKTQDM7W6S"),
+		WithCompletedAt(time.Date(2019, 11, 22, 6, 38, 45, 0, eat)),
+	}, opts...))
+	return newFixture(map[string]any{
+		"TransactionType":   "Pay Bill",
+		"TransID":           v.transactionID,
+		"TransTime":         compactTime(v.completedAt),
+		"TransAmount":       strconv.FormatFloat(v.amount, 'f', -1, 64),
+		"BusinessShortCode": v.shortCode,
+		"BillRefNumber":     v.accountReference,
+		"InvoiceNumber":     "",
+		"OrgAccountBalance": "49197.00",
+		"ThirdPartyTransID": "",
+		"MSISDN":            v.phone,
+		"FirstName":         "John",
+		"MiddleName":        "",
+		"LastName":          "Doe",
+	})
+}
+
+// NewB2CResult returns a successful B2C result. Use WithResultCode for failures, e.g. 2001
+// for a wrong initiator password.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID and WithAmount
+//
+// Returns:
+//   - Fixture: The callback body
+func NewB2CResult(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return newFixture(resultPayload(v, "https://internalsandbox.safaricom.co.ke/mpesa/b2cresults/v1/submit", []any{
+		item("TransactionAmount", v.amount),
+		item("TransactionReceipt", v.transactionID),
+		item("B2CRecipientIsRegisteredCustomer", "Y"),
+		item("B2CChargesPaidAccountAvailableFunds", -4510.00),
+		item("ReceiverPartyPublicName", v.phone+" - John Doe"),
+		item("TransactionCompletedDateTime", v.completedAt.In(eat).Format("02.01.2006 15:04:05")),
+		item("B2CUtilityAccountAvailableFunds", 10116.00),
+		item("B2CWorkingAccountAvailableFunds", 900000.00),
+	}))
+}
+
+// NewB2BResult returns a successful B2B result, as delivered for BusinessPayBill and
+// BusinessBuyGoods requests.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID, WithAmount and WithAccountReference
+//
+// Returns:
+//   - Fixture: The callback body
+func NewB2BResult(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithTransactionID("QKA81LK5CY"),
+		WithOriginatorConversationID("626f6ddf-ab37-4650-b882-b1de92ec9aa4"),
+		WithConversationID("12345677dfdf89099B3"),
+		WithAmount(190),
+		WithAccountReference("19008"),
+		WithCompletedAt(time.Date(2022, 11, 10, 11, 7, 17, 0, eat)),
+	}, opts...))
+	return newFixture(resultPayload(v, "https://internalsandbox.safaricom.co.ke/mpesa/b2bresults/v1/submit", []any{
+		item("DebitAccountBalance", "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"),
+		item("Amount", strconv.FormatFloat(v.amount, 'f', 2, 64)),
+		item("DebitPartyAffectedAccountBalance", "Working Account|KES|346568.83|6186.83|340382.00|0.00"),
+		item("TransCompletedTime", compactTime(v.completedAt)),
+		item("DebitPartyCharges", ""),
+		item("ReceiverPartyPublicName", "000000 - Biller Company"),
+		item("Currency", "KES"),
+		item("InitiatorAccountCurrentBalance", "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"),
+	}, item("BillReferenceNumber", v.accountReference)))
+}
+
+// NewReversalResult returns a successful reversal result. WithTransactionID sets the
+// TransactionID of the reversal itself; the reversed transaction is "NLJ11HAY8Z".
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID and WithAmount
+//
+// Returns:
+//   - Fixture: The callback body
+func NewReversalResult(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithAmount(150.75),
+		WithShortCode("600610"),
+		WithCompletedAt(time.Date(2019, 12, 19, 14, 18, 39, 0, eat)),
+	}, opts...))
+	return newFixture(resultPayload(v, "https://internalsandbox.safaricom.co.ke/mpesa/reversalresults/v1/submit", []any{
+		item("DebitAccountBalance", "Utility Account|KES|51661.00|51661.00|0.00|0.00"),
+		item("Amount", v.amount),
+		item("TransCompletedTime", compactTimeNumber(v.completedAt)),
+		item("OriginalTransactionID", "NLJ11HAY8Z"),
+		item("Charge", float64(0)),
+		item("CreditPartyPublicName", v.phone+" - John Doe"),
+		item("DebitPartyPublicName", v.shortCode+" - Safaricom333"),
+	}))
+}
+
+// NewAccountBalanceResult returns a successful account balance result listing the working,
+// float, utility, charges paid and settlement accounts. WithAmount sets the available balance
+// of the working account.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID and WithAmount
+//
+// Returns:
+//   - Fixture: The callback body
+func NewAccountBalanceResult(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithTransactionID("OA90000000"),
+		WithAmount(700000),
+		WithCompletedAt(time.Date(2020, 1, 9, 12, 57, 10, 0, eat)),
+	}, opts...))
+	working := strconv.FormatFloat(v.amount, 'f', 2, 64)
+	return newFixture(resultPayload(v, "https://internalsandbox.safaricom.co.ke/mpesa/abresults/v1/submit", []any{
+		item("AccountBalance", "Working Account|KES|"+working+"|"+working+"|0.00|0.00"+
+			"&Float Account|KES|0.00|0.00|0.00|0.00"+
+			"&Utility Account|KES|228037.00|228037.00|0.00|0.00"+
+			"&Charges Paid Account|KES|-1540.00|-1540.00|0.00|0.00"+
+			"&Organization Settlement Account|KES|0.00|0.00|0.00|0.00"),
+		item("BOCompletedTime", compactTimeNumber(v.completedAt)),
+	}))
+}
+
+// NewTransactionStatusResult returns the result of a transaction status query for a completed
+// B2C payment. WithTransactionID sets the receipt number of the queried transaction.
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID, WithTransactionID and WithAmount
+//
+// Returns:
+//   - Fixture: The callback body
+func NewTransactionStatusResult(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, append([]FixtureOption{
+		WithAmount(10.5),
+		WithShortCode("600310"),
+		WithCompletedAt(time.Date(2020, 1, 20, 16, 48, 26, 0, eat)),
+	}, opts...))
+	return newFixture(resultPayload(v, "https://internalsandbox.safaricom.co.ke/mpesa/tatresults/v1/submit", []any{
+		item("DebitPartyName", v.shortCode+" - Safaricom333"),
+		item("CreditPartyName", v.phone+" - John Doe"),
+		item("OriginatorConversationID", v.originatorConversationID),
+		item("InitiatedTime", compactTimeNumber(v.completedAt.Add(-time.Second))),
+		item("DebitAccountType", "Utility Account"),
+		item("DebitPartyCharges", ""),
+		item("TransactionReason", ""),
+		item("ReasonType", "Business Payment to Customer via API"),
+		item("TransactionStatus", "Completed"),
+		item("FinalisedTime", compactTimeNumber(v.completedAt)),
+		item("Amount", v.amount),
+		item("ConversationID", v.conversationID),
+		item("ReceiptNo", v.transactionID),
+	}))
+}
+
+// NewQueueTimeout returns the notification M-Pesa posts to the QueueTimeOutURL when a request
+// timed out in its queue (ResultCode SFC_IC0003).
+//
+// Parameters:
+//   - opts: Values to override, e.g. WithOriginatorConversationID
+//
+// Returns:
+//   - Fixture: The callback body
+func NewQueueTimeout(opts ...FixtureOption) Fixture {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return newFixture(map[string]any{"Result": map[string]any{
+		"ResultType":               float64(1),
+		"ResultCode":               "SFC_IC0003",
+		"ResultDesc":               "The service request has timed out.",
+		"OriginatorConversationID": v.originatorConversationID,
+		"ConversationID":           v.conversationID,
+		"TransactionID":            v.transactionID,
+		"ReferenceData": map[string]any{
+			"ReferenceItem": item("QueueTimeoutURL", "https://internalsandbox.safaricom.co.ke/mpesa/abresults/v1/submit"),
+		},
+	}})
+}
@@ -0,0 +1,334 @@
+// Package mpesatest provides utilities for testing applications built on the M-Pesa SDK: a
+// client that records requests and returns programmed responses, a webhook receiver that
+// records the callbacks M-Pesa delivers, builders of sample callback bodies and a local
+// simulator of the Daraja API.
+//
+// Import it from tests only:
+//
+//	import "github.com/venomous-maker/go-mpesa/mpesatest"
+//
+// RecordingClient stands in for Abstracts.MpesaInterface in unit tests; responses such as
+// StkPushAccepted, StkQueryResult and APIFailure give it the answers M-Pesa would:
+//
+//	client := mpesatest.NewRecordingClient().
+//	    Enqueue(cfg.Endpoints.StkPush, mpesatest.StkPushAccepted())
+//	stk := Services.NewStkService(cfg, client)
+package mpesatest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// Callback routes served by a CallbackRecorder. Result and timeout notifications of an API
+// are told apart by their payload, so either may be delivered to either route.
+var callbackPaths = map[Services.CallbackType]string{
+	Services.CallbackSTK:             "/mpesa/stk",
+	Services.CallbackC2BValidation:   "/mpesa/c2b/validation",
+	Services.CallbackC2BConfirmation: "/mpesa/c2b/confirmation",
+	Services.CallbackB2CResult:       "/mpesa/b2c/result",
+	Services.CallbackB2CTimeout:      "/mpesa/b2c/timeout",
+	Services.CallbackB2BResult:       "/mpesa/b2b/result",
+	Services.CallbackB2BTimeout:      "/mpesa/b2b/timeout",
+	Services.CallbackReversalResult:  "/mpesa/reversal/result",
+	Services.CallbackReversalTimeout: "/mpesa/reversal/timeout",
+	Services.CallbackBalanceResult:   "/mpesa/balance/result",
+	Services.CallbackBalanceTimeout:  "/mpesa/balance/timeout",
+	Services.CallbackStatusResult:    "/mpesa/status/result",
+	Services.CallbackStatusTimeout:   "/mpesa/status/timeout",
+}
+
+// resultParsers parse the payloads of the result routes, keyed by the route and its timeout
+// counterpart.
+var resultParsers = map[Services.CallbackType]struct {
+	result, timeout Services.CallbackType
+	parse           func(map[string]any) (any, error)
+}{
+	Services.CallbackB2CResult:       {Services.CallbackB2CResult, Services.CallbackB2CTimeout, parseWith(Services.ParseB2CResult)},
+	Services.CallbackB2CTimeout:      {Services.CallbackB2CResult, Services.CallbackB2CTimeout, parseWith(Services.ParseB2CResult)},
+	Services.CallbackB2BResult:       {Services.CallbackB2BResult, Services.CallbackB2BTimeout, parseWith(Services.ParseB2BCallback)},
+	Services.CallbackB2BTimeout:      {Services.CallbackB2BResult, Services.CallbackB2BTimeout, parseWith(Services.ParseB2BCallback)},
+	Services.CallbackReversalResult:  {Services.CallbackReversalResult, Services.CallbackReversalTimeout, parseWith(Services.ParseReversalResult)},
+	Services.CallbackReversalTimeout: {Services.CallbackReversalResult, Services.CallbackReversalTimeout, parseWith(Services.ParseReversalResult)},
+	Services.CallbackBalanceResult:   {Services.CallbackBalanceResult, Services.CallbackBalanceTimeout, parseWith(Services.ParseAccountBalanceResult)},
+	Services.CallbackBalanceTimeout:  {Services.CallbackBalanceResult, Services.CallbackBalanceTimeout, parseWith(Services.ParseAccountBalanceResult)},
+	Services.CallbackStatusResult:    {Services.CallbackStatusResult, Services.CallbackStatusTimeout, parseWith(Services.ParseTransactionStatusResult)},
+	Services.CallbackStatusTimeout:   {Services.CallbackStatusResult, Services.CallbackStatusTimeout, parseWith(Services.ParseTransactionStatusResult)},
+}
+
+// parseWith adapts a typed Parse function of the Services package. On error it returns an
+// untyped nil rather than a nil pointer, so that RecordedCallback.Parsed compares equal to nil.
+func parseWith[In, T any](parse func(In) (*T, error)) func(In) (any, error) {
+	return func(in In) (any, error) {
+		v, err := parse(in)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// RecordedCallback is a callback received by a CallbackRecorder.
+type RecordedCallback struct {
+	Type       Services.CallbackType // The callback type, e.g. Services.CallbackB2CTimeout for a queue timeout
+	Path       string                // The request path
+	Header     http.Header           // The request headers
+	Raw        []byte                // The body exactly as received
+	Parsed     any                   // The parsed callback, e.g. *Services.STKCallback or *Services.QueueTimeoutResult; nil when parsing failed
+	Err        error                 // The decode or parse error, if any
+	ReceivedAt time.Time
+}
+
+// CallbackRecorder is a webhook receiver for integration tests. It serves the standard
+// callback routes on an httptest.Server, answers each callback through the SDK handlers as a
+// production receiver would, and records every request with its raw body and the value parsed
+// by the SDK parsers. C2B validations are accepted.
+//
+// Against the Daraja sandbox, expose the recorder through a tunnel of your own and set the
+// public URL with SetPublicURL, so that URL returns the callback URLs to send to Daraja.
+type CallbackRecorder struct {
+	server    *httptest.Server
+	mux       *Services.CallbackMux
+	routes    map[string]Services.CallbackType
+	publicURL string
+
+	mu       sync.Mutex
+	received []RecordedCallback
+	changed  chan struct{} // Closed and replaced whenever a callback is recorded
+}
+
+// NewCallbackRecorder starts a CallbackRecorder on a local httptest.Server. Close it when done.
+//
+// Returns:
+//   - *CallbackRecorder: The running recorder
+//
+// Example:
+//
+//	recorder := mpesatest.NewCallbackRecorder()
+//	defer recorder.Close()
+//	stk.SetCallbackUrl(recorder.URL(Services.CallbackSTK))
+//	if _, err := stk.Push(); err != nil {
+//	    t.Fatal(err)
+//	}
+//	checkoutID, _ := stk.GetCheckoutRequestID()
+//	cb, err := recorder.WaitForStkCallback(ctx, checkoutID)
+func NewCallbackRecorder() *CallbackRecorder {
+	c := &CallbackRecorder{routes: make(map[string]Services.CallbackType), changed: make(chan struct{})}
+
+	cfg := Services.CallbackMuxConfig{
+		OnC2BValidation: func(*Services.C2BConfirmation) Services.C2BValidationResponse {
+			return Services.AcceptC2BValidation()
+		},
+		OnC2BConfirmation: func(*Services.C2BConfirmation) error { return nil },
+	}
+	paths := map[Services.CallbackType]*string{
+		Services.CallbackSTK:             &cfg.STKPath,
+		Services.CallbackC2BValidation:   &cfg.C2BValidationPath,
+		Services.CallbackC2BConfirmation: &cfg.C2BConfirmationPath,
+		Services.CallbackB2CResult:       &cfg.B2CResultPath,
+		Services.CallbackB2CTimeout:      &cfg.B2CTimeoutPath,
+		Services.CallbackB2BResult:       &cfg.B2BResultPath,
+		Services.CallbackB2BTimeout:      &cfg.B2BTimeoutPath,
+		Services.CallbackReversalResult:  &cfg.ReversalResultPath,
+		Services.CallbackReversalTimeout: &cfg.ReversalTimeoutPath,
+		Services.CallbackBalanceResult:   &cfg.BalanceResultPath,
+		Services.CallbackBalanceTimeout:  &cfg.BalanceTimeoutPath,
+		Services.CallbackStatusResult:    &cfg.StatusResultPath,
+		Services.CallbackStatusTimeout:   &cfg.StatusTimeoutPath,
+	}
+	for t, path := range callbackPaths {
+		*paths[t] = path
+		c.routes[path] = t
+	}
+	mux, err := Services.NewCallbackMux(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("mpesatest: invalid callback routes: %v", err))
+	}
+	c.mux = mux
+	c.server = httptest.NewServer(c)
+	return c
+}
+
+// ServeHTTP records the callback and answers it with the SDK handler of its route.
+func (c *CallbackRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t, ok := c.routes[r.URL.Path]
+	if !ok || r.Method != http.MethodPost {
+		c.mux.ServeHTTP(w, r)
+		return
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, Services.DefaultMaxWebhookBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	c.record(t, r, raw)
+
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+	c.mux.ServeHTTP(w, r)
+}
+
+// record parses raw as a callback of route t and adds it to the recorded callbacks.
+func (c *CallbackRecorder) record(t Services.CallbackType, r *http.Request, raw []byte) {
+	rec := RecordedCallback{
+		Type:       t,
+		Path:       r.URL.Path,
+		Header:     r.Header.Clone(),
+		Raw:        raw,
+		ReceivedAt: time.Now(),
+	}
+	rec.Type, rec.Parsed, rec.Err = parseCallback(t, raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.received = append(c.received, rec)
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// parseCallback parses the body received on route t with the SDK parser of its callback type,
+// which differs from t when a queue timeout notification arrives on a result route or the
+// other way round.
+func parseCallback(t Services.CallbackType, raw []byte) (Services.CallbackType, any, error) {
+	switch t {
+	case Services.CallbackC2BValidation:
+		parsed, err := parseWith(Services.ParseC2BValidation)(bytes.NewReader(raw))
+		return t, parsed, err
+	case Services.CallbackC2BConfirmation:
+		parsed, err := parseWith(Services.ParseC2BConfirmation)(bytes.NewReader(raw))
+		return t, parsed, err
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return t, nil, fmt.Errorf("invalid callback body: %w", err)
+	}
+	if t == Services.CallbackSTK {
+		parsed, err := parseWith(Services.ParseSTKCallback)(payload)
+		return t, parsed, err
+	}
+	route := resultParsers[t]
+	if Services.IsQueueTimeout(payload) {
+		parsed, err := parseWith(Services.ParseQueueTimeout)(payload)
+		return route.timeout, parsed, err
+	}
+	parsed, err := route.parse(payload)
+	return route.result, parsed, err
+}
+
+// SetPublicURL sets the base URL the recorder is reachable on from M-Pesa, e.g. the URL of a
+// tunnel to BaseURL, used by URL in place of the local address.
+func (c *CallbackRecorder) SetPublicURL(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publicURL = strings.TrimRight(baseURL, "/")
+}
+
+// BaseURL returns the local address the recorder is served on, e.g. "http://127.0.0.1:41234".
+func (c *CallbackRecorder) BaseURL() string {
+	return c.server.URL
+}
+
+// URL returns the callback URL to register for callbacks of type t, built from the public URL
+// when one is set and from BaseURL otherwise.
+func (c *CallbackRecorder) URL(t Services.CallbackType) string {
+	c.mu.Lock()
+	base := c.publicURL
+	c.mu.Unlock()
+	if base == "" {
+		base = c.server.URL
+	}
+	return base + callbackPaths[t]
+}
+
+// Close shuts the server down.
+func (c *CallbackRecorder) Close() {
+	c.server.Close()
+}
+
+// Callbacks returns every callback received so far, in the order they arrived.
+func (c *CallbackRecorder) Callbacks() []RecordedCallback {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]RecordedCallback(nil), c.received...)
+}
+
+// Count returns how many callbacks of type t were received.
+func (c *CallbackRecorder) Count(t Services.CallbackType) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, rec := range c.received {
+		if rec.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset forgets the callbacks received so far.
+func (c *CallbackRecorder) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.received = nil
+}
+
+// WaitFor returns the first recorded callback for which match returns true, waiting until one
+// arrives or ctx is done.
+//
+// Parameters:
+//   - ctx: Bounds the wait
+//   - match: Selects the callback
+//
+// Returns:
+//   - RecordedCallback: The matching callback
+//   - error: ctx.Err() wrapped with context if no callback matched in time
+func (c *CallbackRecorder) WaitFor(ctx context.Context, match func(RecordedCallback) bool) (RecordedCallback, error) {
+	for {
+		c.mu.Lock()
+		for _, rec := range c.received {
+			if match(rec) {
+				c.mu.Unlock()
+				return rec, nil
+			}
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return RecordedCallback{}, fmt.Errorf("no matching callback received: %w", ctx.Err())
+		}
+	}
+}
+
+// WaitForStkCallback waits for the STK Push callback of checkoutRequestID.
+//
+// Parameters:
+//   - ctx: Bounds the wait
+//   - checkoutRequestID: The CheckoutRequestID returned by the STK Push request
+//
+// Returns:
+//   - *Services.STKCallback: The parsed callback
+//   - error: An error if no callback for checkoutRequestID arrived before ctx was done
+func (c *CallbackRecorder) WaitForStkCallback(ctx context.Context, checkoutRequestID string) (*Services.STKCallback, error) {
+	rec, err := c.WaitFor(ctx, func(rec RecordedCallback) bool {
+		cb, ok := rec.Parsed.(*Services.STKCallback)
+		return ok && cb.CheckoutRequestID == checkoutRequestID
+	})
+	if err != nil {
+		return nil, fmt.Errorf("waiting for STK callback %s: %w", checkoutRequestID, err)
+	}
+	return rec.Parsed.(*Services.STKCallback), nil
+}
@@ -0,0 +1,99 @@
+package mpesatest
+
+import (
+	"strconv"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// StkPushAccepted returns the response of an accepted STK Push request, as the API client
+// decodes it. WithMerchantRequestID and WithCheckoutRequestID set its IDs.
+//
+// Example:
+//
+//	client := mpesatest.NewRecordingClient().
+//	    Enqueue(cfg.Endpoints.StkPush, mpesatest.StkPushAccepted(mpesatest.WithCheckoutRequestID("ws_CO_1")))
+func StkPushAccepted(opts ...FixtureOption) Response {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return Response{Body: map[string]any{
+		"MerchantRequestID":   v.merchantRequestID,
+		"CheckoutRequestID":   v.checkoutRequestID,
+		"ResponseCode":        "0",
+		"ResponseDescription": "Success. Request accepted for processing",
+		"CustomerMessage":     "Success. Request accepted for processing",
+	}}
+}
+
+// StkQueryResult returns the response of an STK Push query for a completed transaction.
+// WithResultCode sets the outcome, e.g. WithResultCode(1032, "Request cancelled by user").
+func StkQueryResult(opts ...FixtureOption) Response {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return Response{Body: map[string]any{
+		"ResponseCode":        "0",
+		"ResponseDescription": "The service request has been accepted successsfully",
+		"MerchantRequestID":   v.merchantRequestID,
+		"CheckoutRequestID":   v.checkoutRequestID,
+		"ResultCode":          strconv.Itoa(v.resultCode),
+		"ResultDesc":          v.resultDesc,
+	}}
+}
+
+// StkQueryPending returns the response of an STK Push query while the customer has not yet
+// answered. M-Pesa sends it with status 500, but the API client returns its body without an
+// error, as this response does.
+func StkQueryPending() Response {
+	return Response{Body: darajaErrorBody("500.001.1001", "The transaction is being processed")}
+}
+
+// RequestAccepted returns the acknowledgement of an accepted B2C, B2B, reversal, account
+// balance or transaction status request, whose result is sent later to the ResultURL.
+// WithOriginatorConversationID and WithConversationID set its IDs.
+func RequestAccepted(opts ...FixtureOption) Response {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return Response{Body: map[string]any{
+		"OriginatorConversationID": v.originatorConversationID,
+		"ConversationID":           v.conversationID,
+		"ResponseCode":             "0",
+		"ResponseDescription":      "Accept the service request successfully.",
+	}}
+}
+
+// C2BRegistered returns the response of a successful C2B URL registration.
+// WithOriginatorConversationID sets its ID, which M-Pesa spells OriginatorCoversationID.
+func C2BRegistered(opts ...FixtureOption) Response {
+	v := newFixtureValues(fixtureValues{}, opts)
+	return Response{Body: map[string]any{
+		"OriginatorCoversationID": v.originatorConversationID,
+		"ResponseCode":            "0",
+		"ResponseDescription":     "Success",
+	}}
+}
+
+// APIFailure returns the error the API client returns when M-Pesa rejects a request.
+//
+// Parameters:
+//   - status: The HTTP status, e.g. http.StatusBadRequest
+//   - code: The Daraja error code, e.g. "400.002.02"
+//   - message: The Daraja error message, e.g. "Bad Request - Invalid PhoneNumber"
+//
+// Returns:
+//   - Response: A response whose Err is an *Abstracts.APIError
+func APIFailure(status int, code, message string) Response {
+	body := darajaErrorBody(code, message)
+	return Response{Err: &Abstracts.APIError{
+		StatusCode:   status,
+		RequestID:    body["requestId"].(string),
+		ErrorCode:    code,
+		ErrorMessage: message,
+		Body:         body,
+	}}
+}
+
+// darajaErrorBody returns a Daraja error response with a fixed requestId.
+func darajaErrorBody(code, message string) map[string]any {
+	return map[string]any{
+		"requestId":    "11728-2929992-1",
+		"errorCode":    code,
+		"errorMessage": message,
+	}
+}
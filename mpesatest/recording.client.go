@@ -0,0 +1,266 @@
+package mpesatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// AnyEndpoint stands for every endpoint in the methods of a RecordingClient.
+const AnyEndpoint = ""
+
+// Call is a request made through a RecordingClient.
+type Call struct {
+	Endpoint string // The endpoint path, e.g. "/mpesa/stkpush/v1/processrequest"
+	Payload  any    // The payload exactly as passed by the service
+}
+
+// Response is a programmed answer of a RecordingClient: the decoded response body, or an
+// error such as an *Abstracts.APIError.
+type Response struct {
+	Body map[string]any
+	Err  error
+}
+
+// RecordingClient is an Abstracts.MpesaInterface for unit tests that records every request
+// and answers with programmed responses instead of calling the API. For each request it uses,
+// in order: the next response queued for the endpoint, the next response queued for
+// AnyEndpoint, the response or func set for the endpoint, the one set for AnyEndpoint, and
+// finally {"ResponseCode": "0"}. The zero value is ready to use and it is safe for concurrent
+// use.
+//
+// Example:
+//
+//	client := mpesatest.NewRecordingClient().
+//	    Respond(cfg.Endpoints.StkPush, map[string]any{"ResponseCode": "0", "CheckoutRequestID": "ws_CO_1"})
+//	_, err := Services.NewStkService(cfg, client).SetAmount(10).Push()
+//	client.AssertSent(t, cfg.Endpoints.StkPush, map[string]any{"Amount": 10})
+type RecordingClient struct {
+	mu     sync.Mutex
+	calls  []Call
+	queued map[string][]Response
+	funcs  map[string]func(payload any) (map[string]any, error)
+}
+
+// NewRecordingClient returns a RecordingClient answering every request with
+// {"ResponseCode": "0"} until programmed otherwise.
+//
+// Returns:
+//   - *RecordingClient: The client, to pass to the service under test
+func NewRecordingClient() *RecordingClient {
+	return &RecordingClient{}
+}
+
+// ExecuteRequest implements Abstracts.MpesaInterface.
+func (c *RecordingClient) ExecuteRequest(payload any, endpoint string) (map[string]any, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, Call{Endpoint: endpoint, Payload: payload})
+	for _, key := range []string{endpoint, AnyEndpoint} {
+		if queue := c.queued[key]; len(queue) > 0 {
+			c.queued[key] = queue[1:]
+			c.mu.Unlock()
+			return queue[0].Body, queue[0].Err
+		}
+	}
+	fn, ok := c.funcs[endpoint]
+	if !ok {
+		fn, ok = c.funcs[AnyEndpoint]
+	}
+	c.mu.Unlock()
+
+	// fn runs unlocked so that it may use the client, e.g. to inspect earlier calls.
+	if ok {
+		return fn(payload)
+	}
+	return map[string]any{"ResponseCode": "0"}, nil
+}
+
+// Respond answers every request to endpoint, or to AnyEndpoint, with body.
+func (c *RecordingClient) Respond(endpoint string, body map[string]any) *RecordingClient {
+	return c.RespondWith(endpoint, func(any) (map[string]any, error) {
+		return body, nil
+	})
+}
+
+// Fail answers every request to endpoint, or to AnyEndpoint, with err.
+func (c *RecordingClient) Fail(endpoint string, err error) *RecordingClient {
+	return c.RespondWith(endpoint, func(any) (map[string]any, error) {
+		return nil, err
+	})
+}
+
+// RespondWith answers every request to endpoint, or to AnyEndpoint, by calling fn with the
+// payload.
+func (c *RecordingClient) RespondWith(endpoint string, fn func(payload any) (map[string]any, error)) *RecordingClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.funcs == nil {
+		c.funcs = map[string]func(any) (map[string]any, error){}
+	}
+	c.funcs[endpoint] = fn
+	return c
+}
+
+// Enqueue queues responses for the next requests to endpoint, or to AnyEndpoint, one per
+// request. They take precedence over Respond, Fail and RespondWith.
+func (c *RecordingClient) Enqueue(endpoint string, responses ...Response) *RecordingClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queued == nil {
+		c.queued = map[string][]Response{}
+	}
+	c.queued[endpoint] = append(c.queued[endpoint], responses...)
+	return c
+}
+
+// FailNext makes the next request to endpoint, or to AnyEndpoint, fail with err.
+func (c *RecordingClient) FailNext(endpoint string, err error) *RecordingClient {
+	return c.Enqueue(endpoint, Response{Err: err})
+}
+
+// Calls returns the requests made so far, in order.
+func (c *RecordingClient) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// Count returns the number of requests made to endpoint, or to any endpoint.
+func (c *RecordingClient) Count(endpoint string) int {
+	return len(c.Payloads(endpoint))
+}
+
+// Payloads returns the payloads sent to endpoint, or to any endpoint, in order. Payloads that
+// are not a map[string]any are converted through their JSON encoding.
+func (c *RecordingClient) Payloads(endpoint string) []map[string]any {
+	var payloads []map[string]any
+	for _, call := range c.Calls() {
+		if endpoint == AnyEndpoint || call.Endpoint == endpoint {
+			payloads = append(payloads, payloadMap(call.Payload))
+		}
+	}
+	return payloads
+}
+
+// Payload returns the payload of the i-th request, counting from 0; it panics when fewer
+// requests were made.
+func (c *RecordingClient) Payload(i int) map[string]any {
+	return payloadMap(c.Calls()[i].Payload)
+}
+
+// LastPayload returns the last payload sent to endpoint, or to any endpoint; nil when there is
+// none.
+func (c *RecordingClient) LastPayload(endpoint string) map[string]any {
+	payloads := c.Payloads(endpoint)
+	if len(payloads) == 0 {
+		return nil
+	}
+	return payloads[len(payloads)-1]
+}
+
+// LastEndpoint returns the endpoint of the last request; empty when there is none.
+func (c *RecordingClient) LastEndpoint() string {
+	calls := c.Calls()
+	if len(calls) == 0 {
+		return ""
+	}
+	return calls[len(calls)-1].Endpoint
+}
+
+// Reset forgets the recorded requests; programmed responses are kept.
+func (c *RecordingClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+}
+
+// AssertSent checks that a payload containing every field of partial was sent to endpoint, or
+// to any endpoint, and reports an error on t otherwise. Values are compared as they encode to
+// JSON, so 10 matches 10.0 but not "10".
+//
+// Parameters:
+//   - t: The test to report the failure on
+//   - endpoint: The endpoint path, or AnyEndpoint
+//   - partial: The fields the payload must contain
+//
+// Returns:
+//   - bool: true when a matching payload was sent
+func (c *RecordingClient) AssertSent(t testing.TB, endpoint string, partial map[string]any) bool {
+	t.Helper()
+	payloads := c.Payloads(endpoint)
+	for _, payload := range payloads {
+		if containsFields(payload, partial) {
+			return true
+		}
+	}
+	if endpoint == AnyEndpoint {
+		endpoint = "any endpoint"
+	}
+	t.Errorf("no payload sent to %s contains %s; sent: %v", endpoint, describeFields(partial), payloads)
+	return false
+}
+
+// payloadMap returns payload as a map, converting other types through JSON; nil when it does
+// not encode to a JSON object.
+func payloadMap(payload any) map[string]any {
+	if m, ok := payload.(map[string]any); ok {
+		return m
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// containsFields reports whether payload has every field of partial with an equal value.
+func containsFields(payload, partial map[string]any) bool {
+	for key, want := range partial {
+		got, ok := payload[key]
+		if !ok || !sameValue(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameValue compares a and b directly, then by their JSON encoding.
+func sameValue(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var av, bv any
+	if json.Unmarshal(aj, &av) != nil || json.Unmarshal(bj, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// describeFields formats fields in key order, for failure messages.
+func describeFields(fields map[string]any) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	s := "{"
+	for i, key := range keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s: %#v", key, fields[key])
+	}
+	return s + "}"
+}
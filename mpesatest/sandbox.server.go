@@ -0,0 +1,631 @@
+package mpesatest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+)
+
+// DefaultTokenTTL is the lifetime of the access tokens a Server issues, as in the sandbox.
+const DefaultTokenTTL = time.Hour
+
+// Operation names an API request a Server answers, for scripting scenarios.
+type Operation string
+
+const (
+	OpStkPush           Operation = "stk_push"
+	OpStkQuery          Operation = "stk_query"
+	OpC2BRegister       Operation = "c2b_register"
+	OpC2BSimulate       Operation = "c2b_simulate"
+	OpB2C               Operation = "b2c"
+	OpB2B               Operation = "b2b"
+	OpReversal          Operation = "reversal"
+	OpAccountBalance    Operation = "account_balance"
+	OpTransactionStatus Operation = "transaction_status"
+)
+
+// Scenario scripts how a Server answers one request. The zero value is a successful request
+// whose callback reports success.
+type Scenario struct {
+	ResultCode   int           // Result of the transaction, sent in its callback and STK Push queries; 0 for success
+	ResultDesc   string        // Description of a non-zero ResultCode
+	Pending      bool          // The transaction never completes: no callback is sent and STK Push queries stay pending
+	QueueTimeout bool          // The QueueTimeOutURL is notified instead of the ResultURL
+	NoCallback   bool          // The transaction completes but its callback is not sent
+	Status       int           // Rejects the request with this HTTP status and the error below
+	ErrorCode    string        // Daraja errorCode of a rejected request, e.g. "400.002.02"
+	ErrorMessage string        // Daraja errorMessage of a rejected request
+	Latency      time.Duration // Delay before answering, added to the latency of the Server
+}
+
+// UserCancelled scripts a customer dismissing the STK Push prompt (ResultCode 1032). Queued
+// for OpStkQuery, the next query answers with it.
+func UserCancelled() Scenario {
+	return Scenario{ResultCode: 1032, ResultDesc: "Request cancelled by user."}
+}
+
+// Unreachable scripts an STK Push prompt that could not reach the phone (ResultCode 1037).
+func Unreachable() Scenario {
+	return Scenario{ResultCode: 1037, ResultDesc: "DS timeout user cannot be reached"}
+}
+
+// InsufficientFunds scripts a transaction that failed for lack of funds (ResultCode 1).
+func InsufficientFunds() Scenario {
+	return Scenario{ResultCode: 1, ResultDesc: "The balance is insufficient for the transaction."}
+}
+
+// Pending scripts a transaction that never completes, e.g. a customer who ignores the
+// prompt. Queued for OpStkQuery, the next query answers that the transaction is being
+// processed.
+func Pending() Scenario {
+	return Scenario{Pending: true}
+}
+
+// QueueTimeout scripts a request that timed out in the M-Pesa queue, notified on the
+// QueueTimeOutURL.
+func QueueTimeout() Scenario {
+	return Scenario{QueueTimeout: true}
+}
+
+// Rejected scripts a request that Daraja rejects with an error response.
+//
+// Parameters:
+//   - status: The HTTP status, e.g. http.StatusBadRequest
+//   - code: The Daraja errorCode, e.g. "400.002.02"
+//   - message: The Daraja errorMessage, e.g. "Bad Request - Invalid Amount"
+//
+// Returns:
+//   - Scenario: The scripted rejection
+func Rejected(status int, code, message string) Scenario {
+	return Scenario{Status: status, ErrorCode: code, ErrorMessage: message}
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithTokenTTL sets the lifetime of the access tokens the server issues. Requests with an
+// expired token are answered with 401, as Daraja does.
+func WithTokenTTL(ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithLatency delays every API response by d.
+func WithLatency(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// WithCallbackDelay sets how long after accepting a request its callback is delivered; until
+// then STK Push queries report the transaction as being processed. The default is 0.
+func WithCallbackDelay(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.callbackDelay = d
+	}
+}
+
+// Request is an API request received by a Server.
+type Request struct {
+	Operation Operation
+	Payload   map[string]any // The decoded request body
+	Status    int            // The HTTP status of the response
+	Response  map[string]any // The response body
+}
+
+// Delivery is a callback sent by a Server.
+type Delivery struct {
+	URL    string
+	Body   []byte
+	Status int   // The HTTP status answered by the receiver
+	Err    error // The error sending the callback, if any
+}
+
+// stkTransaction is an STK Push request accepted by a Server.
+type stkTransaction struct {
+	scenario Scenario
+	callback Fixture
+	done     bool
+}
+
+// Server simulates the Daraja API for tests: it issues OAuth tokens, acknowledges STK Push,
+// C2B, B2C, B2B, reversal, account balance and transaction status requests like the sandbox
+// does, and delivers their callbacks to the URLs in the requests. Callback bodies are built
+// with the fixture builders of this package. Point the SDK at it with Mpesa.SetBaseURL or
+// MpesaConfig.SetBaseURL; any consumer key and secret is accepted.
+type Server struct {
+	server        *httptest.Server
+	routes        map[string]Operation
+	oauthPath     string
+	tokenTTL      time.Duration
+	latency       time.Duration
+	callbackDelay time.Duration
+	client        *http.Client
+
+	mu           sync.Mutex
+	seq          int
+	tokens       map[string]time.Time // Issued tokens and their expiry
+	tokensIssued int
+	scenarios    map[Operation][]Scenario
+	stk          map[string]*stkTransaction // By CheckoutRequestID
+	requests     []Request
+	deliveries   []Delivery
+
+	pending   sync.WaitGroup
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewServer starts a Server on a local port.
+//
+// Parameters:
+//   - opts: Options such as WithTokenTTL, WithLatency and WithCallbackDelay
+//
+// Returns:
+//   - *Server: The running server; Close it when done
+//
+// Example:
+//
+//	server := mpesatest.NewServer(mpesatest.WithCallbackDelay(50 * time.Millisecond))
+//	defer server.Close()
+//	mpesa.SetBaseURL(server.BaseURL())
+//	server.Script(mpesatest.OpStkPush, mpesatest.UserCancelled())
+func NewServer(opts ...ServerOption) *Server {
+	endpoints := Abstracts.DefaultEndpoints()
+	s := &Server{
+		routes: map[string]Operation{
+			endpoints.StkPush:           OpStkPush,
+			endpoints.StkQuery:          OpStkQuery,
+			endpoints.C2BRegisterURL:    OpC2BRegister,
+			endpoints.C2BSimulate:       OpC2BSimulate,
+			endpoints.B2CPayment:        OpB2C,
+			endpoints.B2BPayment:        OpB2B,
+			endpoints.Reversal:          OpReversal,
+			endpoints.AccountBalance:    OpAccountBalance,
+			endpoints.TransactionStatus: OpTransactionStatus,
+		},
+		oauthPath: strings.SplitN(endpoints.OAuth, "?", 2)[0],
+		tokenTTL:  DefaultTokenTTL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		tokens:    map[string]time.Time{},
+		scenarios: map[Operation][]Scenario{},
+		stk:       map[string]*stkTransaction{},
+		closed:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// BaseURL returns the URL to configure the SDK with, e.g. "http://127.0.0.1:51234".
+func (s *Server) BaseURL() string {
+	return s.server.URL
+}
+
+// Close stops the server and abandons callbacks that are not delivered yet.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.server.Close()
+		s.pending.Wait()
+	})
+}
+
+// Script queues scenarios for the next requests of op, one scenario per request. Requests
+// without a queued scenario succeed.
+//
+// Example:
+//
+//	// The next push is cancelled by the customer, the one after succeeds
+//	server.Script(mpesatest.OpStkPush, mpesatest.UserCancelled(), mpesatest.Scenario{})
+//	// The next query reports a cancellation, whatever the push did
+//	server.Script(mpesatest.OpStkQuery, mpesatest.UserCancelled())
+func (s *Server) Script(op Operation, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenarios[op] = append(s.scenarios[op], scenarios...)
+}
+
+// Requests returns the API requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Deliveries returns the callbacks sent so far, in order.
+func (s *Server) Deliveries() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Delivery(nil), s.deliveries...)
+}
+
+// TokensIssued returns the number of access tokens issued so far.
+func (s *Server) TokensIssued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokensIssued
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == s.oauthPath {
+		s.serveToken(w, r)
+		return
+	}
+	op, ok := s.routes[r.URL.Path]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, s.errorBody("404.001.01", "Resource not found"))
+		return
+	}
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, s.errorBody("404.001.03", "Invalid Access Token"))
+		return
+	}
+
+	var payload map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, s.errorBody("400.002.01", "Invalid Request Payload"))
+		return
+	}
+	scenario := s.nextScenario(op)
+	if !s.sleep(s.latency + scenario.Latency) {
+		return
+	}
+
+	var status int
+	var response map[string]any
+	if scenario.Status != 0 {
+		status, response = scenario.Status, s.errorBody(scenario.ErrorCode, scenario.ErrorMessage)
+	} else {
+		status, response = s.handle(op, payload, scenario)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Operation: op, Payload: payload, Status: status, Response: response})
+	s.mu.Unlock()
+	writeJSON(w, status, response)
+}
+
+// serveToken issues an access token to any client presenting Basic credentials.
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := r.BasicAuth(); !ok || r.URL.Query().Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, s.errorBody("400.008.01", "Invalid Authentication passed"))
+		return
+	}
+	token := randomHex(14)
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(s.tokenTTL)
+	s.tokensIssued++
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": token,
+		"expires_in":   strconv.Itoa(int(s.tokenTTL / time.Second)),
+	})
+}
+
+// authorized reports whether r carries a token issued by the server that has not expired.
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.tokens[token]
+	return ok && time.Now().Before(expiresAt)
+}
+
+// nextScenario pops the scenario queued for op, if any.
+func (s *Server) nextScenario(op Operation) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.scenarios[op]
+	if len(queue) == 0 {
+		return Scenario{}
+	}
+	s.scenarios[op] = queue[1:]
+	return queue[0]
+}
+
+// handle answers an accepted request of op and schedules its callback.
+func (s *Server) handle(op Operation, payload map[string]any, scenario Scenario) (int, map[string]any) {
+	switch op {
+	case OpStkPush:
+		return s.stkPush(payload, scenario)
+	case OpStkQuery:
+		return s.stkQuery(payload, scenario)
+	case OpC2BRegister:
+		return http.StatusOK, C2BRegistered(WithOriginatorConversationID(s.originatorConversationID())).Body
+	case OpC2BSimulate:
+		return s.c2bSimulate(payload, scenario)
+	default:
+		return s.resultRequest(op, payload, scenario)
+	}
+}
+
+// stkPush accepts an STK Push request and schedules its callback.
+func (s *Server) stkPush(payload map[string]any, scenario Scenario) (int, map[string]any) {
+	callbackURL := stringValue(payload, "CallBackURL")
+	if callbackURL == "" {
+		return http.StatusBadRequest, s.errorBody("400.002.02", "Bad Request - Invalid CallBackURL")
+	}
+	seq := s.nextSeq()
+	now := time.Now().In(eat)
+	merchantRequestID := fmt.Sprintf("%05d-%08d-1", 29115+seq, 34620561+seq)
+	checkoutRequestID := fmt.Sprintf("ws_CO_%s%06d", now.Format("02012006150405"), seq)
+
+	opts := []FixtureOption{
+		WithMerchantRequestID(merchantRequestID),
+		WithCheckoutRequestID(checkoutRequestID),
+		WithTransactionID(receiptNumber(seq)),
+		WithCompletedAt(now),
+	}
+	if amount, ok := floatValue(payload, "Amount"); ok {
+		opts = append(opts, WithAmount(amount))
+	}
+	if phone := stringValue(payload, "PhoneNumber"); phone != "" {
+		opts = append(opts, WithPhone(phone))
+	}
+	tx := &stkTransaction{scenario: scenario}
+	if scenario.ResultCode != 0 {
+		tx.callback = NewStkCancelledCallback(append(opts, WithResultCode(scenario.ResultCode, scenario.ResultDesc))...)
+	} else {
+		tx.callback = NewStkSuccessCallback(opts...)
+	}
+
+	s.mu.Lock()
+	s.stk[checkoutRequestID] = tx
+	s.mu.Unlock()
+	if !scenario.Pending {
+		s.deliver(callbackURL, tx.callback, scenario.NoCallback, func() {
+			s.mu.Lock()
+			tx.done = true
+			s.mu.Unlock()
+		})
+	}
+
+	return http.StatusOK, StkPushAccepted(WithMerchantRequestID(merchantRequestID), WithCheckoutRequestID(checkoutRequestID)).Body
+}
+
+// stkQuery answers an STK Push query with the state of the transaction, or with the queued
+// scenario.
+func (s *Server) stkQuery(payload map[string]any, scenario Scenario) (int, map[string]any) {
+	checkoutRequestID := stringValue(payload, "CheckoutRequestID")
+	s.mu.Lock()
+	tx, ok := s.stk[checkoutRequestID]
+	done := ok && tx.done
+	s.mu.Unlock()
+	if !ok {
+		return http.StatusBadRequest, s.errorBody("400.002.02", "Bad Request - Invalid CheckoutRequestID")
+	}
+
+	code, desc := tx.scenario.ResultCode, tx.scenario.ResultDesc
+	switch {
+	case scenario.Pending || (!done && scenario.ResultCode == 0):
+		return http.StatusInternalServerError, s.errorBody("500.001.1001", "The transaction is being processed")
+	case scenario.ResultCode != 0:
+		code, desc = scenario.ResultCode, scenario.ResultDesc
+	}
+	if code == 0 {
+		desc = "The service request is processed successfully."
+	}
+	callback := tx.callback.Payload["Body"].(map[string]any)["stkCallback"].(map[string]any)
+	return http.StatusOK, StkQueryResult(
+		WithMerchantRequestID(callback["MerchantRequestID"].(string)),
+		WithCheckoutRequestID(checkoutRequestID),
+		WithResultCode(code, desc),
+	).Body
+}
+
+// c2bSimulate accepts a C2B simulation and sends a confirmation to the ConfirmationURL of
+// the shortcode. The server does not keep registrations, so the confirmation goes to the
+// ConfirmationURL of the last registration request for the shortcode.
+func (s *Server) c2bSimulate(payload map[string]any, scenario Scenario) (int, map[string]any) {
+	shortCode := stringValue(payload, "ShortCode")
+	confirmationURL := s.confirmationURL(shortCode)
+	if confirmationURL == "" {
+		return http.StatusBadRequest, s.errorBody("400.002.02", "Bad Request - Invalid ShortCode: no URLs registered")
+	}
+	seq := s.nextSeq()
+	opts := []FixtureOption{
+		WithTransactionID(receiptNumber(seq)),
+		WithShortCode(shortCode),
+		WithAccountReference(stringValue(payload, "BillRefNumber")),
+		WithCompletedAt(time.Now()),
+	}
+	if amount, ok := floatValue(payload, "Amount"); ok {
+		opts = append(opts, WithAmount(amount))
+	}
+	if phone := stringValue(payload, "Msisdn"); phone != "" {
+		opts = append(opts, WithPhone(phone))
+	}
+	if !scenario.Pending {
+		s.deliver(confirmationURL, NewC2BConfirmation(opts...), scenario.NoCallback, nil)
+	}
+	return http.StatusOK, map[string]any{
+		"OriginatorCoversationID": s.originatorConversationID(),
+		"ConversationID":          conversationID(seq),
+		"ResponseDescription":     "Accept the service request successfully.",
+	}
+}
+
+// confirmationURL returns the ConfirmationURL last registered for shortCode.
+func (s *Server) confirmationURL(shortCode string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		req := s.requests[i]
+		if req.Operation == OpC2BRegister && req.Status == http.StatusOK && stringValue(req.Payload, "ShortCode") == shortCode {
+			return stringValue(req.Payload, "ConfirmationURL")
+		}
+	}
+	return ""
+}
+
+// resultRequest accepts a B2C, B2B, reversal, account balance or transaction status request
+// and sends its result to the ResultURL, or a queue timeout to the QueueTimeOutURL.
+func (s *Server) resultRequest(op Operation, payload map[string]any, scenario Scenario) (int, map[string]any) {
+	resultURL, queueTimeoutURL := stringValue(payload, "ResultURL"), stringValue(payload, "QueueTimeOutURL")
+	if resultURL == "" || queueTimeoutURL == "" {
+		return http.StatusBadRequest, s.errorBody("400.002.02", "Bad Request - Invalid ResultURL or QueueTimeOutURL")
+	}
+	seq := s.nextSeq()
+	originatorID := stringValue(payload, "OriginatorConversationID")
+	if originatorID == "" {
+		originatorID = s.originatorConversationID()
+	}
+	opts := []FixtureOption{
+		WithOriginatorConversationID(originatorID),
+		WithConversationID(conversationID(seq)),
+		WithTransactionID(receiptNumber(seq)),
+		WithCompletedAt(time.Now()),
+	}
+	if amount, ok := floatValue(payload, "Amount"); ok {
+		opts = append(opts, WithAmount(amount))
+	}
+	if scenario.ResultCode != 0 {
+		opts = append(opts, WithResultCode(scenario.ResultCode, scenario.ResultDesc))
+	}
+
+	var result Fixture
+	switch op {
+	case OpB2C:
+		result = NewB2CResult(append(opts, WithPhone(stringValue(payload, "PartyB")))...)
+	case OpB2B:
+		result = NewB2BResult(append(opts, WithAccountReference(stringValue(payload, "AccountReference")))...)
+	case OpReversal:
+		result = NewReversalResult(opts...)
+	case OpAccountBalance:
+		result = NewAccountBalanceResult(opts...)
+	case OpTransactionStatus:
+		result = NewTransactionStatusResult(append(opts, WithTransactionID(stringValue(payload, "TransactionID")))...)
+	}
+	if scenario.QueueTimeout {
+		resultURL, result = queueTimeoutURL, NewQueueTimeout(opts...)
+	}
+	if !scenario.Pending {
+		s.deliver(resultURL, result, scenario.NoCallback, nil)
+	}
+
+	return http.StatusOK, RequestAccepted(WithOriginatorConversationID(originatorID), WithConversationID(conversationID(seq))).Body
+}
+
+// deliver posts fixture to url after the callback delay, in the background. done, if set, is
+// called once the delay has passed; skip completes the transaction without posting.
+func (s *Server) deliver(url string, fixture Fixture, skip bool, done func()) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if !s.sleep(s.callbackDelay) {
+			return
+		}
+		if done != nil {
+			done()
+		}
+		if skip {
+			return
+		}
+		delivery := Delivery{URL: url, Body: fixture.JSON}
+		resp, err := s.client.Post(url, "application/json", bytes.NewReader(fixture.JSON))
+		if err != nil {
+			delivery.Err = err
+		} else {
+			delivery.Status = resp.StatusCode
+			resp.Body.Close()
+		}
+		s.mu.Lock()
+		s.deliveries = append(s.deliveries, delivery)
+		s.mu.Unlock()
+	}()
+}
+
+// sleep waits for d, returning false when the server is closed first.
+func (s *Server) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closed:
+		return false
+	}
+}
+
+// nextSeq returns a number unique to the request, used to derive its identifiers.
+func (s *Server) nextSeq() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return s.seq
+}
+
+// originatorConversationID returns a new OriginatorConversationID in the sandbox format.
+func (s *Server) originatorConversationID() string {
+	seq := s.nextSeq()
+	return fmt.Sprintf("%05d-%07d-1", 10571+seq, 7910404+seq)
+}
+
+// errorBody returns a Daraja error response.
+func (s *Server) errorBody(code, message string) map[string]any {
+	return map[string]any{
+		"requestId":    s.originatorConversationID(),
+		"errorCode":    code,
+		"errorMessage": message,
+	}
+}
+
+// conversationID returns the ConversationID M-Pesa assigns to the seq-th request.
+func conversationID(seq int) string {
+	return fmt.Sprintf("AG_%s_%020x", time.Now().In(eat).Format("20060102"), seq)
+}
+
+// receiptNumber returns the M-Pesa receipt number of the seq-th transaction.
+func receiptNumber(seq int) string {
+	return fmt.Sprintf("SIM%07d", seq)
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// stringValue returns payload[key] as a string.
+func stringValue(payload map[string]any, key string) string {
+	v, ok := payload[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// floatValue returns payload[key] as a number; the SDK sends amounts as strings or numbers.
+func floatValue(payload map[string]any, key string) (float64, bool) {
+	f, err := strconv.ParseFloat(stringValue(payload, key), 64)
+	return f, err == nil
+}
+
+// writeJSON writes body as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body map[string]any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
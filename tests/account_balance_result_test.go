@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const accountBalanceResultJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": 0,
+    "ResultDesc": "The service request is processed successfully.",
+    "OriginatorConversationID": "16917-22577599-3",
+    "ConversationID": "AG_20200206_00005e091a8ec6b9eac5",
+    "TransactionID": "OA90000000",
+    "ResultParameters": {
+      "ResultParameter": [
+        {"Key": "AccountBalance", "Value": "Working Account|KES|700000.00|700000.00|0.00|0.00&Float Account|KES|0.00|0.00|0.00|0.00&Utility Account|KES|228037.00|228037.00|0.00|0.00&Charges Paid Account|KES|-1540.00|-1540.00|0.00|0.00&Organization Settlement Account|KES|0.00|0.00|0.00|0.00"},
+        {"Key": "BOCompletedTime", "Value": 20200109125710}
+      ]
+    },
+    "ReferenceData": {
+      "ReferenceItem": {
+        "Key": "QueueTimeoutURL",
+        "Value": "https://internalsandbox.safaricom.co.ke/mpesa/abresults/v1/submit"
+      }
+    }
+  }
+}`
+
+func TestParseAccountBalanceResult_MultipleAccounts(t *testing.T) {
+	res, err := Services.ParseAccountBalanceResult(decodeFixture(t, accountBalanceResultJSON))
+	if err != nil {
+		t.Fatalf("ParseAccountBalanceResult error: %v", err)
+	}
+
+	if !res.Success || res.ConversationID != "AG_20200206_00005e091a8ec6b9eac5" {
+		t.Fatalf("unexpected envelope: %+v", res)
+	}
+	if len(res.Accounts) != 5 {
+		t.Fatalf("expected 5 accounts, got %d: %+v", len(res.Accounts), res.Accounts)
+	}
+
+	working, ok := res.WorkingAccount()
+	if !ok || working.Currency != "KES" || working.Current != 700000 || working.Available != 700000 {
+		t.Errorf("unexpected working account: %+v", working)
+	}
+	utility, ok := res.UtilityAccount()
+	if !ok || utility.Available != 228037 {
+		t.Errorf("unexpected utility account: %+v", utility)
+	}
+	if floatAccount, ok := res.FloatAccount(); !ok || floatAccount.Current != 0 {
+		t.Errorf("unexpected float account: %+v", floatAccount)
+	}
+	charges, ok := res.Account("charges paid account")
+	if !ok || charges.Current != -1540 {
+		t.Errorf("unexpected charges paid account: %+v", charges)
+	}
+	if _, ok := res.Account("Missing Account"); ok {
+		t.Errorf("expected lookup of unknown account to fail")
+	}
+
+	expected := time.Date(2020, 1, 9, 12, 57, 10, 0, time.FixedZone("EAT", 3*60*60))
+	if !res.BOCompletedTime.Equal(expected) {
+		t.Errorf("expected completion time %v, got %v", expected, res.BOCompletedTime)
+	}
+}
+
+func TestParseAccountBalanceResult_MalformedSegment(t *testing.T) {
+	payload := decodeFixture(t, `{
+  "Result": {
+    "ResultCode": 0,
+    "ResultDesc": "The service request is processed successfully.",
+    "ResultParameters": {
+      "ResultParameter": {"Key": "AccountBalance", "Value": "Working Account|KES|100.00|90.00|10.00|0.00&Float Account|KES&&Utility Account|KES|50.00|50.00|0.00|0.00"}
+    }
+  }
+}`)
+
+	res, err := Services.ParseAccountBalanceResult(payload)
+	if err != nil {
+		t.Fatalf("ParseAccountBalanceResult error: %v", err)
+	}
+
+	if len(res.Accounts) != 2 {
+		t.Fatalf("expected malformed segments to be skipped, got %+v", res.Accounts)
+	}
+	if _, ok := res.FloatAccount(); ok {
+		t.Errorf("expected malformed float account to be skipped")
+	}
+	if working, ok := res.WorkingAccount(); !ok || working.Reserved != 10 {
+		t.Errorf("unexpected working account: %+v", working)
+	}
+	if res.ResultParameters["AccountBalance"] == "" {
+		t.Errorf("expected raw AccountBalance parameter to be kept")
+	}
+	if !res.BOCompletedTime.IsZero() {
+		t.Errorf("expected zero completion time, got %v", res.BOCompletedTime)
+	}
+}
+
+func TestParseAccountBalanceResult_MissingResult(t *testing.T) {
+	if _, err := Services.ParseAccountBalanceResult(map[string]any{"foo": "bar"}); err == nil {
+		t.Fatalf("expected error for payload without Result node")
+	}
+}
+
+func TestAccountBalanceResultHandler(t *testing.T) {
+	var got *Services.AccountBalanceResult
+	var timedOut map[string]any
+	handler := Services.AccountBalanceResultHandler(
+		func(res *Services.AccountBalanceResult) { got = res },
+		func(raw map[string]any) { timedOut = raw },
+	)
+
+	rec := postWebhook(handler, accountBalanceResultJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || len(got.Accounts) != 5 {
+		t.Fatalf("expected parsed result, got %+v", got)
+	}
+	if timedOut != nil {
+		t.Errorf("expected result not to be treated as a timeout")
+	}
+
+	got = nil
+	rec = postWebhook(handler, `{"requestId":"11728-2929992-1","errorCode":"500.001.1001","errorMessage":"Request timed out"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected timeout notification to be acknowledged, got %d %s", rec.Code, rec.Body.String())
+	}
+	if timedOut["errorCode"] != "500.001.1001" || got != nil {
+		t.Errorf("expected timeout payload to be passed to onTimeout only, got %v / %+v", timedOut, got)
+	}
+}
+
+func TestAccountBalanceResultHandler_Safeguards(t *testing.T) {
+	handler := Services.AccountBalanceResultHandler(nil, nil, Services.WithMaxBodyBytes(64))
+
+	req := httptest.NewRequest(http.MethodGet, "/mpesa/balance/result", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	rec = postWebhook(handler, `{"Result":{"ResultDesc":"`+strings.Repeat("x", 128)+`"}}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected oversized body to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = postWebhook(handler, `{"Result":`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected invalid body to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	var reported error
+	handler = Services.AccountBalanceResultHandler(nil, nil, Services.WithErrorHandler(func(err error, r *http.Request) { reported = err }))
+	rec = postWebhook(handler, `{"Result":"not an object"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected ack with error handler, got %d %s", rec.Code, rec.Body.String())
+	}
+	if reported == nil {
+		t.Errorf("expected parse error to be reported")
+	}
+}
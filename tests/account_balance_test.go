@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestAccountBalanceService_DefaultIdentifierType(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewAccountBalanceService(buildTestConfig(), client).
+		SetInitiator("apiop37").
+		SetRemarks("Balance inquiry").
+		Query()
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"Initiator":          "apiop37",
+		"SecurityCredential": "FAKE_SECURITY_CREDENTIAL",
+		"CommandID":          "AccountBalance",
+		"PartyA":             "603021",
+		"IdentifierType":     "4",
+		"Remarks":            "Balance inquiry",
+		"QueueTimeOutURL":    "https://example.com/reversal/queue",
+		"ResultURL":          "https://example.com/reversal/result",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+	if client.LastEndpoint() != "/mpesa/accountbalance/v1/query" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+}
+
+func TestAccountBalanceService_ValidationErrors(t *testing.T) {
+	noBusinessCode, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	noBusinessCode.SetQueueTimeoutURL("https://example.com/balance/queue")
+	noBusinessCode.SetResultURL("https://example.com/balance/result")
+	noBusinessCode.OverrideSecurityCredential("FAKE")
+
+	noQueueURL := buildTestConfig()
+	noQueueURL.SetQueueTimeoutURL("")
+	noResultURL := buildTestConfig()
+	noResultURL.SetResultURL("")
+	noCredential := buildTestConfig()
+	noCredential.OverrideSecurityCredential("")
+
+	cases := []struct {
+		name    string
+		cfg     *abstracts.MpesaConfig
+		build   func(*Services.AccountBalanceService) *Services.AccountBalanceService
+		wantErr string
+	}{
+		{"missing initiator", buildTestConfig(), func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s
+		}, "initiator is required"},
+		{"empty identifier type", buildTestConfig(), func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37").SetIdentifierType("")
+		}, "identifier type is required"},
+		{"invalid identifier type", buildTestConfig(), func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37").SetIdentifierType("9")
+		}, `invalid identifier type "9": must be one of 1, 2, 4, 11`},
+		{"missing business code", noBusinessCode, func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37")
+		}, "business shortcode (PartyA) is required; call SetBusinessCode on mpesa config"},
+		{"missing queue timeout URL", noQueueURL, func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37")
+		}, "queue timeout URL is required; call SetQueueTimeoutURL on the service or config"},
+		{"missing result URL", noResultURL, func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37")
+		}, "result URL is required; call SetResultURL on the service or config"},
+		{"missing security credential", noCredential, func(s *Services.AccountBalanceService) *Services.AccountBalanceService {
+			return s.SetInitiator("apiop37")
+		}, "security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := tc.build(Services.NewAccountBalanceService(tc.cfg, client)).Query()
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) > 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
+
+func TestAccountBalanceService_DefaultRemarks(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	if _, err := Services.NewAccountBalanceService(buildTestConfig(), client).SetInitiator("apiop37").Query(); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["Remarks"]; got != Services.DefaultAccountBalanceRemarks {
+		t.Errorf("expected default remarks %q, got %v", Services.DefaultAccountBalanceRemarks, got)
+	}
+
+	client = mpesatest.NewRecordingClient()
+	if _, err := Services.NewAccountBalanceService(buildTestConfig(), client).SetInitiator("apiop37").SetRemarks("Month end").Query(); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["Remarks"]; got != "Month end" {
+		t.Errorf("expected explicit remarks, got %v", got)
+	}
+}
+
+func TestAccountBalanceService_URLOverrides(t *testing.T) {
+	cfg := buildTestConfig()
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewAccountBalanceService(cfg, client).
+		SetInitiator("apiop37").
+		SetQueueTimeoutURL("https://example.com/balance/queue").
+		SetResultURL("https://example.com/balance/result").
+		Query()
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["QueueTimeOutURL"] != "https://example.com/balance/queue" || payload["ResultURL"] != "https://example.com/balance/result" {
+		t.Errorf("expected service URLs to take precedence, got %v / %v", payload["QueueTimeOutURL"], payload["ResultURL"])
+	}
+	if cfg.GetQueueTimeoutURL() != "https://example.com/reversal/queue" || cfg.GetResultURL() != "https://example.com/reversal/result" {
+		t.Errorf("expected config URLs to be left untouched, got %s / %s", cfg.GetQueueTimeoutURL(), cfg.GetResultURL())
+	}
+
+	// Overrides also satisfy validation when the config has no URLs.
+	noURLs := buildTestConfig()
+	noURLs.SetQueueTimeoutURL("")
+	noURLs.SetResultURL("")
+	client = mpesatest.NewRecordingClient()
+	_, err = Services.NewAccountBalanceService(noURLs, client).
+		SetInitiator("apiop37").
+		SetQueueTimeoutURL("https://example.com/balance/queue").
+		SetResultURL("https://example.com/balance/result").
+		Query()
+	if err != nil {
+		t.Fatalf("expected overrides to satisfy validation, got %v", err)
+	}
+}
+
+func TestAccountBalanceService_TypedResponse(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"OriginatorConversationID": "16917-22577599-3",
+		"ConversationID":           "AG_20200206_00005e091a8ec6b9eac5",
+		"ResponseCode":             "0",
+		"ResponseDescription":      "Accept the service request successfully.",
+	})
+	service := Services.NewAccountBalanceService(buildTestConfig(), client)
+
+	if _, err := service.GetConversationID(); err == nil {
+		t.Errorf("expected error before any query")
+	}
+
+	resp, err := service.SetInitiator("apiop37").QueryTyped()
+	if err != nil {
+		t.Fatalf("QueryTyped error: %v", err)
+	}
+	if !resp.Accepted() || resp.OriginatorConversationID != "16917-22577599-3" {
+		t.Errorf("unexpected typed response: %+v", resp)
+	}
+	if service.GetTypedResponse() != resp || service.GetResponse() == nil {
+		t.Errorf("expected responses to be stored on the service")
+	}
+	if id, err := service.GetConversationID(); err != nil || id != "AG_20200206_00005e091a8ec6b9eac5" {
+		t.Errorf("unexpected conversation ID %q, err %v", id, err)
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// ackCase is a webhook handler paired with a payload it accepts and its standard ack body.
+type ackCase struct {
+	handler http.Handler
+	valid   string
+	ack     string
+}
+
+// ackPolicyHandlers builds every webhook handler with opts.
+func ackPolicyHandlers(opts ...Services.HandlerOption) map[string]ackCase {
+	billAck := `{"rescode":"200","resmsg":"Success"}`
+	return map[string]ackCase{
+		"STK":             {Services.STKCallbackHandler(nil, opts...), stkCallbackSuccessJSON, webhookAckBody},
+		"B2C":             {Services.B2CResultHandler(nil, nil, opts...), b2cResultSuccessJSON, webhookAckBody},
+		"B2B":             {Services.B2BCallbackHandler(nil, opts...), reversalResultSuccessJSON, webhookAckBody},
+		"Reversal":        {Services.ReversalResultHandler(nil, nil, opts...), reversalResultSuccessJSON, webhookAckBody},
+		"AccountBalance":  {Services.AccountBalanceResultHandler(nil, nil, opts...), accountBalanceResultJSON, webhookAckBody},
+		"Status":          {Services.TransactionStatusResultHandler(nil, nil, opts...), transactionStatusCompletedJSON, webhookAckBody},
+		"BillManager":     {Services.BillManagerPaymentHandler(nil, opts...), billManagerPaymentJSON, billAck},
+		"C2BConfirmation": {Services.C2BConfirmationHandler(func(*Services.C2BConfirmation) error { return nil }, opts...), c2bConfirmationLegacyJSON, c2bConfirmationAckBody},
+	}
+}
+
+func TestAckPolicy_AlwaysAckByDefault(t *testing.T) {
+	for name, h := range ackPolicyHandlers() {
+		for _, body := range []string{h.valid, `{not json`, `{"Result":"x","Body":"x"}`} {
+			rec := postWebhook(h.handler, body)
+			if rec.Code != http.StatusOK || rec.Body.String() != h.ack {
+				t.Errorf("%s: expected ack for %.20q, got %d %s", name, body, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestAckPolicy_StrictRejectsParseFailures(t *testing.T) {
+	var reported int
+	opts := []Services.HandlerOption{
+		Services.WithAckPolicy(Services.StrictAck),
+		Services.WithErrorHandler(func(error, *http.Request) { reported++ }),
+	}
+	for name, h := range ackPolicyHandlers(opts...) {
+		if rec := postWebhook(h.handler, h.valid); rec.Code != http.StatusOK || rec.Body.String() != h.ack {
+			t.Errorf("%s: expected ack for valid payload, got %d %s", name, rec.Code, rec.Body.String())
+		}
+		if rec := postWebhook(h.handler, `{not json`); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for invalid JSON, got %d", name, rec.Code)
+		}
+	}
+	if reported != len(ackPolicyHandlers()) {
+		t.Errorf("expected every parse failure to be reported, got %d", reported)
+	}
+
+	validation := Services.C2BValidationHandler(func(*Services.C2BConfirmation) Services.C2BValidationResponse {
+		return Services.AcceptC2BValidation()
+	}, opts...)
+	if rec := postWebhook(validation, `{not json`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 from validation handler under StrictAck, got %d", rec.Code)
+	}
+}
+
+func TestAckPolicy_UserErrors(t *testing.T) {
+	failing := func(*Services.C2BConfirmation) error { return errors.New("database down") }
+	panicking := func(*Services.C2BConfirmation) Services.C2BValidationResponse { panic("boom") }
+
+	rec := postWebhook(Services.C2BConfirmationHandler(failing), c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != c2bConfirmationAckBody {
+		t.Errorf("expected confirmation error to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = postWebhook(Services.C2BConfirmationHandler(failing, Services.WithAckPolicy(Services.StrictAck)), c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() == "database down\n" {
+		t.Errorf("expected 500 without error text under StrictAck, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = postWebhook(Services.C2BValidationHandler(panicking), c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected panicking validation to be answered with a rejection by default, got %d", rec.Code)
+	}
+	rec = postWebhook(Services.C2BValidationHandler(panicking, Services.WithAckPolicy(Services.StrictAck)), c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for panicking validation under StrictAck, got %d", rec.Code)
+	}
+}
+
+func TestAckPolicy_CustomAckBody(t *testing.T) {
+	custom := `{"status":"received"}`
+	for name, h := range ackPolicyHandlers(Services.WithAckBody([]byte(custom))) {
+		for _, body := range []string{h.valid, `{not json`} {
+			rec := postWebhook(h.handler, body)
+			if rec.Code != http.StatusOK || rec.Body.String() != custom {
+				t.Errorf("%s: expected custom ack, got %d %s", name, rec.Code, rec.Body.String())
+			}
+		}
+	}
+
+	validation := Services.C2BValidationHandler(func(*Services.C2BConfirmation) Services.C2BValidationResponse {
+		return Services.Reject(Services.RejectInvalidAccountNumber)
+	}, Services.WithAckBody([]byte(custom)))
+	if rec := postWebhook(validation, c2bConfirmationLegacyJSON); rec.Body.String() == custom {
+		t.Errorf("expected validation responses not to be replaced by the custom ack")
+	}
+}
@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestAmount_FromString(t *testing.T) {
+	tests := []struct {
+		input string
+		cents int64
+		err   string
+	}{
+		{"1500", 150000, ""},
+		{"1,500.50", 150050, ""},
+		{"1 500.5", 150050, ""},
+		{"  250.05 ", 25005, ""},
+		{"0.5", 50, ""},
+		{".75", 75, ""},
+		{"10.", 1000, ""},
+		{"007", 700, ""},
+		{"1.500", 150, ""},
+		{"-20", -2000, ""},
+		{"+20", 2000, ""},
+		{"0", 0, ""},
+		{"1.005", 0, "more precise than a cent"},
+		{"0.001", 0, "more precise than a cent"},
+		{"", 0, "not a decimal number"},
+		{".", 0, "not a decimal number"},
+		{"-", 0, "not a decimal number"},
+		{"abc", 0, "not a decimal number"},
+		{"1e3", 0, "not a decimal number"},
+		{"1.2.3", 0, "not a decimal number"},
+		{"--5", 0, "not a decimal number"},
+		{"KES 100", 0, "not a decimal number"},
+		{"99999999999999999", 0, "too large"},
+	}
+	for _, tt := range tests {
+		a, err := Abstracts.FromString(tt.input)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("FromString(%q): expected error %q, got %v (%d cents)", tt.input, tt.err, err, a.Cents())
+			}
+			continue
+		}
+		if err != nil || a.Cents() != tt.cents {
+			t.Errorf("FromString(%q): expected %d cents, got %d (%v)", tt.input, tt.cents, a.Cents(), err)
+		}
+	}
+}
+
+func TestAmount_FromFloat(t *testing.T) {
+	tenth := 0.1 // a variable, so that tenth+0.2 is float64 arithmetic rather than an exact constant
+	tests := []struct {
+		input float64
+		cents int64
+		ok    bool
+	}{
+		{1500, 150000, true},
+		{1500.5, 150050, true},
+		{150.75, 15075, true},
+		{0.01, 1, true},
+		{19.99, 1999, true},
+		{-3.5, -350, true},
+		{tenth + 0.2, 0, false},
+		{150.755, 0, false},
+		{math.NaN(), 0, false},
+		{math.Inf(1), 0, false},
+		{1e20, 0, false},
+	}
+	for _, tt := range tests {
+		a, err := Abstracts.FromFloat(tt.input)
+		if (err == nil) != tt.ok || a.Cents() != tt.cents {
+			t.Errorf("FromFloat(%v): expected %d cents (ok %v), got %d (%v)", tt.input, tt.cents, tt.ok, a.Cents(), err)
+		}
+	}
+
+	if a := Abstracts.FromKES(1500); a.Cents() != 150000 || !a.IsWhole() {
+		t.Errorf("FromKES(1500): got %d cents", a.Cents())
+	}
+	if a := Abstracts.FromCents(150050); a.Float64() != 1500.5 || a.IsWhole() {
+		t.Errorf("FromCents(150050): got %v", a.Float64())
+	}
+}
+
+func TestAmount_Formatting(t *testing.T) {
+	tests := []struct {
+		cents   int64
+		str     string
+		whole   string
+		display string
+	}{
+		{150000, "1500", "1500", "KES 1,500.00"},
+		{150050, "1500.5", "", "KES 1,500.50"},
+		{15075, "150.75", "", "KES 150.75"},
+		{5, "0.05", "", "KES 0.05"},
+		{0, "0", "0", "KES 0.00"},
+		{123456789, "1234567.89", "", "KES 1,234,567.89"},
+		{100000000, "1000000", "1000000", "KES 1,000,000.00"},
+		{-250, "-2.5", "", "KES -2.50"},
+	}
+	for _, tt := range tests {
+		a := Abstracts.FromCents(tt.cents)
+		if got := a.String(); got != tt.str {
+			t.Errorf("String(%d): expected %q, got %q", tt.cents, tt.str, got)
+		}
+		if got := a.Format(); got != tt.display {
+			t.Errorf("Format(%d): expected %q, got %q", tt.cents, tt.display, got)
+		}
+		whole, err := a.WholeString()
+		if tt.whole == "" {
+			if err == nil || !strings.Contains(err.Error(), "fractional part") {
+				t.Errorf("WholeString(%d): expected a fractional part error, got %q %v", tt.cents, whole, err)
+			}
+		} else if err != nil || whole != tt.whole {
+			t.Errorf("WholeString(%d): expected %q, got %q %v", tt.cents, tt.whole, whole, err)
+		}
+	}
+
+	if n, err := Abstracts.FromKES(250).WholeShillings(); err != nil || n != 250 {
+		t.Errorf("WholeShillings: got %d %v", n, err)
+	}
+}
+
+func TestAmount_RoundingAndComparison(t *testing.T) {
+	tests := []struct {
+		cents, round, truncate int64
+	}{
+		{15050, 15100, 15000},
+		{15049, 15000, 15000},
+		{15099, 15100, 15000},
+		{15000, 15000, 15000},
+		{-15050, -15100, -15000},
+	}
+	for _, tt := range tests {
+		a := Abstracts.FromCents(tt.cents)
+		if got := a.Round().Cents(); got != tt.round {
+			t.Errorf("Round(%d): expected %d, got %d", tt.cents, tt.round, got)
+		}
+		if got := a.Truncate().Cents(); got != tt.truncate {
+			t.Errorf("Truncate(%d): expected %d, got %d", tt.cents, tt.truncate, got)
+		}
+	}
+
+	ten, hundred := Abstracts.FromKES(10), Abstracts.FromKES(100)
+	if ten.Cmp(hundred) != -1 || hundred.Cmp(ten) != 1 || ten.Cmp(Abstracts.FromCents(1000)) != 0 {
+		t.Errorf("unexpected comparisons")
+	}
+	if got := hundred.Sub(ten).Add(Abstracts.FromCents(50)); got.Cents() != 9050 {
+		t.Errorf("expected 90.50, got %s", got)
+	}
+	if !ten.Positive() || Abstracts.FromKES(0).Positive() || Abstracts.FromKES(-1).Positive() || !(Abstracts.Amount{}).IsZero() {
+		t.Errorf("unexpected sign checks")
+	}
+
+	limits := []struct {
+		amount, min, max Abstracts.Amount
+		within           bool
+	}{
+		{Abstracts.FromKES(10), ten, hundred, true},
+		{Abstracts.FromKES(100), ten, hundred, true},
+		{Abstracts.FromCents(999), ten, hundred, false},
+		{Abstracts.FromCents(10001), ten, hundred, false},
+		{Abstracts.FromKES(1), Abstracts.Amount{}, hundred, true},
+		{Abstracts.FromKES(1000000), ten, Abstracts.Amount{}, true},
+	}
+	for _, tt := range limits {
+		if got := tt.amount.WithinLimits(tt.min, tt.max); got != tt.within {
+			t.Errorf("WithinLimits(%s, %s, %s): expected %v", tt.amount, tt.min, tt.max, tt.within)
+		}
+	}
+}
+
+func TestServices_SetAmountValue(t *testing.T) {
+	cfg := buildTestConfig()
+
+	client := mpesatest.NewRecordingClient()
+	stk := Services.NewStkService(cfg, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(Abstracts.FromKES(100)).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("254708374149")
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	client.AssertSent(t, cfg.Endpoints.StkPush, map[string]any{"Amount": 100})
+	if _, err := stk.SetAmountValue(Abstracts.FromCents(10050)).Push(); err == nil || !strings.Contains(err.Error(), "fractional part") {
+		t.Errorf("expected STK Push to reject cents, got %v", err)
+	}
+	if _, err := stk.SetAmountValue(Abstracts.FromKES(0)).Push(); err == nil {
+		t.Errorf("expected STK Push to reject a zero amount")
+	}
+
+	b2c := newTestB2CService(client).
+		SetPhoneNumber("254708374149").
+		SetRoundingPolicy(Services.RoundHalfUp).
+		SetAmountValue(Abstracts.FromCents(15050))
+	if _, err := b2c.Send(); err != nil {
+		t.Fatalf("B2C Send error: %v", err)
+	}
+	client.AssertSent(t, cfg.Endpoints.B2CPayment, map[string]any{"Amount": 151})
+
+	reversal := Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetRemarks("Payment reversal").
+		SetAmountValue(Abstracts.FromCents(15075))
+	if _, err := reversal.Reverse(); err != nil {
+		t.Fatalf("Reverse error: %v", err)
+	}
+	client.AssertSent(t, cfg.Endpoints.Reversal, map[string]any{"Amount": "150.75"})
+	if _, err := reversal.SetAmountValue(Abstracts.FromKES(-5)).Reverse(); err == nil {
+		t.Errorf("expected the reversal to reject a negative amount")
+	}
+
+	paybill := Services.NewBusinessToPayBillService(cfg, client).
+		SetInitiator("testapi").
+		SetPartyB("000001").
+		SetAccountReference("INV-001").
+		SetAmountValue(Abstracts.FromKES(2500))
+	if _, err := paybill.Send(); err != nil {
+		t.Fatalf("B2B Send error: %v", err)
+	}
+	client.AssertSent(t, cfg.Endpoints.B2BPayment, map[string]any{"Amount": 2500})
+
+	c2b := Services.NewCustomerToBusinessService(cfg, client).
+		SetTypedCommandID(Services.CommandCustomerPayBillOnline).
+		SetPhoneNumber("254708374149").
+		SetAmountValue(Abstracts.FromKES(300))
+	if _, err := c2b.Simulate(); err != nil {
+		t.Fatalf("C2B Simulate error: %v", err)
+	}
+	client.AssertSent(t, cfg.Endpoints.C2BSimulate, map[string]any{"Amount": 300})
+}
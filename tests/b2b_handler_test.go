@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func marshalFixture(t *testing.T, payload map[string]any) string {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+	return string(body)
+}
+
+func TestB2BCallbackHandler_Results(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      map[string]any
+		expectOK     bool
+		expectedCode string
+	}{
+		{"Success", b2bCallbackSuccessPayload(), true, "0"},
+		{"Failure", b2bCallbackFailurePayload(), false, "2001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Services.B2BCallbackResult
+			handler := Services.B2BCallbackHandler(func(res *Services.B2BCallbackResult) { got = res })
+
+			rec := postWebhook(handler, marshalFixture(t, tt.payload))
+
+			if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+				t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+			}
+			if got == nil {
+				t.Fatalf("expected onResult to be called")
+			}
+			if got.Success != tt.expectOK || got.ResultCode != tt.expectedCode {
+				t.Errorf("expected success %v code %s, got %v %s", tt.expectOK, tt.expectedCode, got.Success, got.ResultCode)
+			}
+		})
+	}
+}
+
+func TestB2BCallbackHandler_ParseFailureAcknowledged(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Invalid JSON", `{"Result":`},
+		{"Missing Result", `{"foo":"bar"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := Services.B2BCallbackHandler(func(*Services.B2BCallbackResult) { called = true })
+
+			rec := postWebhook(handler, tt.body)
+			if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+				t.Fatalf("expected ack by default, got %d %s", rec.Code, rec.Body.String())
+			}
+			if called {
+				t.Errorf("expected onResult not to be called")
+			}
+
+			var reported error
+			handler = Services.B2BCallbackHandler(nil, Services.WithErrorHandler(func(err error, r *http.Request) { reported = err }))
+			rec = postWebhook(handler, tt.body)
+			if rec.Code != http.StatusOK || reported == nil {
+				t.Errorf("expected error to be reported and acknowledged, got %d %v", rec.Code, reported)
+			}
+		})
+	}
+}
+
+func TestB2BCallbackHandler_Limits(t *testing.T) {
+	handler := Services.B2BCallbackHandler(nil, Services.WithMaxBodyBytes(16), Services.WithAckPolicy(Services.StrictAck))
+
+	req := httptest.NewRequest(http.MethodGet, "/mpesa/b2b/result", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	rec = postWebhook(handler, `{"Result":{"ResultCode":"0","ResultDesc":"`+strings.Repeat("x", 64)+`"}}`)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestB2BServicesDoNotMutateSharedConfig(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.SetQueueTimeoutURL("https://example.com/shared/timeout")
+	cfg.SetResultURL("https://example.com/shared/result")
+	cfg.OverrideSecurityCredential("FAKE_SECURITY_CREDENTIAL")
+
+	b2bClient := mpesatest.NewRecordingClient()
+	paybill := Services.NewBusinessToPayBillService(cfg, b2bClient).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyA("600000").
+		SetPartyB("000001").
+		SetAccountReference("INV-001").
+		SetQueueTimeoutURL("https://example.com/b2b/timeout").
+		SetResultURL("https://example.com/b2b/result")
+	Services.NewBusinessBuyGoodsService(cfg, b2bClient).
+		SetPartyA("600001").
+		SetQueueTimeoutURL("https://example.com/buygoods/timeout").
+		SetResultURL("https://example.com/buygoods/result")
+
+	if _, err := paybill.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	b2bPayload := b2bClient.LastPayload(mpesatest.AnyEndpoint)
+	if b2bPayload["PartyA"] != "600000" || b2bPayload["ResultURL"] != "https://example.com/b2b/result" || b2bPayload["QueueTimeOutURL"] != "https://example.com/b2b/timeout" {
+		t.Errorf("expected B2B overrides in payload, got %v", b2bPayload)
+	}
+
+	if cfg.GetBusinessCode() != "174379" {
+		t.Errorf("expected config business code to be untouched, got %s", cfg.GetBusinessCode())
+	}
+	if cfg.GetResultURL() != "https://example.com/shared/result" || cfg.GetQueueTimeoutURL() != "https://example.com/shared/timeout" {
+		t.Errorf("expected config URLs to be untouched, got %s / %s", cfg.GetResultURL(), cfg.GetQueueTimeoutURL())
+	}
+
+	stkClient := mpesatest.NewRecordingClient()
+	stk := Services.NewStkService(cfg, stkClient).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount("100").
+		SetCallbackUrl("https://example.com/callback").
+		SetAccountReference("REF").
+		SetTransactionDesc("Test")
+	stk, _ = stk.SetPhoneNumber("254711223344")
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := stkClient.LastPayload(mpesatest.AnyEndpoint)["BusinessShortCode"]; got != "174379" {
+		t.Errorf("expected STK payload to use the config shortcode, got %v", got)
+	}
+}
+
+func TestB2BServices_PerServiceSecurityCredential(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.SetQueueTimeoutURL("https://example.com/timeout")
+	cfg.SetResultURL("https://example.com/result")
+	cfg.OverrideSecurityCredential("SHARED_CREDENTIAL")
+
+	paybillClient := mpesatest.NewRecordingClient()
+	paybill := Services.NewBusinessToPayBillService(cfg, paybillClient).
+		SetInitiator("paybill_operator").
+		SetEncryptedSecurityCredential("PAYBILL_CREDENTIAL").
+		SetAmount(100).
+		SetPartyB("000001").
+		SetAccountReference("INV-001")
+
+	buyGoodsClient := mpesatest.NewRecordingClient()
+	buyGoods := Services.NewBusinessBuyGoodsService(cfg, buyGoodsClient).
+		SetInitiator("till_operator").
+		SetAmount(100).
+		SetPartyB("000002")
+	if err := buyGoods.SetInitiatorPassword("till_password"); err != nil {
+		t.Fatalf("SetInitiatorPassword error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := paybill.Send()
+		errs <- err
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := buyGoods.Send()
+		errs <- err
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	for _, p := range paybillClient.Payloads(mpesatest.AnyEndpoint) {
+		if p["SecurityCredential"] != "PAYBILL_CREDENTIAL" || p["Initiator"] != "paybill_operator" {
+			t.Errorf("unexpected paybill credential: %v / %v", p["Initiator"], p["SecurityCredential"])
+		}
+	}
+	for _, payload := range buyGoodsClient.Payloads(mpesatest.AnyEndpoint) {
+		cred := payload["SecurityCredential"]
+		if cred == "" || cred == "SHARED_CREDENTIAL" || cred == "PAYBILL_CREDENTIAL" {
+			t.Errorf("expected the buy goods service to use its own credential, got %v", cred)
+		}
+	}
+	if cfg.GetSecurityCredential() != "SHARED_CREDENTIAL" {
+		t.Errorf("expected config credential to be untouched, got %s", cfg.GetSecurityCredential())
+	}
+
+	topUpClient := mpesatest.NewRecordingClient()
+	if _, err := Services.NewB2CAccountTopUpService(cfg, topUpClient).SetInitiator("testapi").SetAmount(100).SetPartyB("600000").Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := topUpClient.LastPayload(mpesatest.AnyEndpoint)["SecurityCredential"]; got != "SHARED_CREDENTIAL" {
+		t.Errorf("expected fallback to the config credential, got %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+const b2bAcceptedJSON = `{
+  "OriginatorConversationID": "5118-111210482-1",
+  "ConversationID": "AG_20230420_2010759fd5662ef6d054",
+  "ResponseCode": "0",
+  "ResponseDescription": "Accept the service request successfully."
+}`
+
+const b2bErrorEnvelopeJSON = `{
+  "requestId": "11728-2929992-1",
+  "errorCode": "401.002.01",
+  "errorMessage": "Error Occurred - Invalid Access Token - BJGFGOXv5aZnw90KkA4TDtu4Xdyf"
+}`
+
+func TestNewB2BSendResponse_Accepted(t *testing.T) {
+	resp := Services.NewB2BSendResponse(decodeFixture(t, b2bAcceptedJSON))
+
+	if !resp.Accepted() {
+		t.Fatalf("expected response to be accepted, got %+v", resp)
+	}
+	if resp.ConversationID != "AG_20230420_2010759fd5662ef6d054" || resp.OriginatorConversationID != "5118-111210482-1" {
+		t.Errorf("unexpected conversation IDs: %s / %s", resp.ConversationID, resp.OriginatorConversationID)
+	}
+	if resp.ResponseDescription != "Accept the service request successfully." {
+		t.Errorf("unexpected description: %s", resp.ResponseDescription)
+	}
+	if resp.ErrorCode != "" {
+		t.Errorf("expected no error code, got %s", resp.ErrorCode)
+	}
+}
+
+func TestNewB2BSendResponse_ErrorEnvelope(t *testing.T) {
+	resp := Services.NewB2BSendResponse(decodeFixture(t, b2bErrorEnvelopeJSON))
+
+	if resp.Accepted() {
+		t.Fatalf("expected error envelope not to be accepted")
+	}
+	if resp.RequestID != "11728-2929992-1" || resp.ErrorCode != "401.002.01" {
+		t.Errorf("unexpected error envelope fields: %+v", resp)
+	}
+	if resp.ConversationID != "" || resp.ResponseCode != "" {
+		t.Errorf("expected empty acknowledgement fields, got %+v", resp)
+	}
+}
+
+func TestNewB2BSendResponse_Tolerant(t *testing.T) {
+	resp := Services.NewB2BSendResponse(map[string]any{
+		"originatorCoversationID": "5118-111210482-1",
+		"responseCode":            float64(0),
+	})
+	if !resp.Accepted() || resp.OriginatorConversationID != "5118-111210482-1" {
+		t.Errorf("expected tolerant decoding, got %+v", resp)
+	}
+
+	var nilResp *Services.B2BSendResponse
+	if nilResp.Accepted() {
+		t.Errorf("expected nil response not to be accepted")
+	}
+}
+
+func TestB2BServices_SendTyped(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, decodeFixture(t, b2bAcceptedJSON))
+	cfg := newTestB2BConfig(abstracts.Sandbox)
+
+	paybill := Services.NewBusinessToPayBillService(cfg, client).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyB("000001").
+		SetAccountReference("INV-001").
+		SetQueueTimeoutURL("https://example.com/timeout").
+		SetResultURL("https://example.com/result")
+	resp, err := paybill.SendTyped()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !resp.Accepted() || resp != paybill.GetTypedResponse() || paybill.GetResponse() == nil {
+		t.Errorf("expected typed and raw responses to be stored, got %+v", resp)
+	}
+
+	buyGoods := Services.NewBusinessBuyGoodsService(cfg, client).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyB("000001").
+		SetQueueTimeoutURL("https://example.com/timeout").
+		SetResultURL("https://example.com/result")
+	resp, err = buyGoods.SendTyped()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if resp.ConversationID != "AG_20230420_2010759fd5662ef6d054" || resp != buyGoods.GetTypedResponse() {
+		t.Errorf("unexpected typed response: %+v", resp)
+	}
+
+	if _, err := Services.NewBusinessBuyGoodsService(cfg, client).SendTyped(); err == nil {
+		t.Errorf("expected validation error for an unconfigured service")
+	}
+}
@@ -1,9 +1,12 @@
 package tests
 
 import (
+	"strings"
 	"testing"
 
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
 )
 
 func TestNewBusinessBuyGoodsService(t *testing.T) {
@@ -12,3 +15,88 @@ func TestNewBusinessBuyGoodsService(t *testing.T) {
 		t.Fatal("expected service not nil")
 	}
 }
+
+func TestBusinessBuyGoodsService_Send_CallbackURLs(t *testing.T) {
+	for _, tt := range b2bURLCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewBusinessBuyGoodsService(newTestB2BConfig(tt.env), client).
+				SetInitiator("testapi").
+				SetAmount(100).
+				SetPartyB("000001").
+				SetQueueTimeoutURL(tt.timeoutURL).
+				SetResultURL(tt.resultURL).
+				Send()
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent, got %d", client.Count(mpesatest.AnyEndpoint))
+			}
+		})
+	}
+}
+
+func TestB2BServices_IdentifierTypes(t *testing.T) {
+	tests := []struct {
+		name             string
+		sender, receiver Services.IdentifierType
+		expectedSender   string
+		expectedReceiver string
+	}{
+		{"Defaults", "", "", "4", "4"},
+		{"Till receiver", Services.IdentifierTypeShortcode, Services.IdentifierTypeTillNumber, "4", "2"},
+		{"MSISDN sender", Services.IdentifierTypeMSISDN, Services.IdentifierTypePaybill, "1", "11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			svc := Services.NewBusinessBuyGoodsService(newTestB2BConfig(abstracts.Sandbox), client).
+				SetInitiator("testapi").
+				SetAmount(100).
+				SetPartyB("000001").
+				SetQueueTimeoutURL("https://example.com/timeout").
+				SetResultURL("https://example.com/result")
+			if tt.sender != "" {
+				svc.SetSenderIdentifierType(tt.sender).SetReceiverIdentifierType(tt.receiver)
+			}
+			if _, err := svc.Send(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			payload := client.LastPayload(mpesatest.AnyEndpoint)
+			if payload["SenderIdentifierType"] != tt.expectedSender || payload["RecieverIdentifierType"] != tt.expectedReceiver {
+				t.Errorf("expected identifier types %s/%s, got %v/%v", tt.expectedSender, tt.expectedReceiver, payload["SenderIdentifierType"], payload["RecieverIdentifierType"])
+			}
+		})
+	}
+}
+
+func TestB2BServices_InvalidIdentifierType(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewBusinessToPayBillService(newTestB2BConfig(abstracts.Sandbox), client).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyB("000001").
+		SetAccountReference("INV-001").
+		SetReceiverIdentifierType("3").
+		SetQueueTimeoutURL("https://example.com/timeout").
+		SetResultURL("https://example.com/result").
+		Send()
+
+	expected := `invalid receiver identifier type "3": must be one of 1, 2, 4, 11`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, got %v", expected, err)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 0 {
+		t.Errorf("expected no request to be sent")
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// concurrencyClient records the peak number of in-flight requests and fails
+// requests for the configured phone numbers.
+type concurrencyClient struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    int32
+	delay    time.Duration
+	failFor  map[string]bool
+}
+
+func (c *concurrencyClient) ExecuteRequest(payload any, endpoint string) (map[string]any, error) {
+	atomic.AddInt32(&c.calls, 1)
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.peak {
+		c.peak = c.inFlight
+	}
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	data := payload.(map[string]any)
+	if c.failFor[data["PartyB"].(string)] {
+		return nil, errors.New("upstream failure")
+	}
+	return map[string]any{
+		"ResponseCode":             "0",
+		"OriginatorConversationID": data["OriginatorConversationID"],
+	}, nil
+}
+
+func batchRecipients(n int) []Services.B2CRecipient {
+	recipients := make([]Services.B2CRecipient, n)
+	for i := range recipients {
+		recipients[i] = Services.B2CRecipient{Phone: fmt.Sprintf("07112233%02d", i), Amount: 100}
+	}
+	return recipients
+}
+
+func TestB2CService_SendBatch_RespectsConcurrencyAndContinuesOnFailure(t *testing.T) {
+	client := &concurrencyClient{delay: 10 * time.Millisecond, failFor: map[string]bool{"254711223303": true}}
+	service := newTestB2CService(client)
+
+	var progressCalls int32
+	results := service.SendBatch(context.Background(), batchRecipients(20), Services.B2CBatchOptions{
+		Concurrency: 3,
+		OnProgress: func(done, total int, result Services.B2CBatchResult) {
+			atomic.AddInt32(&progressCalls, 1)
+			if total != 20 {
+				t.Errorf("expected total 20, got %d", total)
+			}
+		},
+	})
+
+	if client.peak > 3 {
+		t.Errorf("expected at most 3 requests in flight, got %d", client.peak)
+	}
+	if atomic.LoadInt32(&client.calls) != 20 || atomic.LoadInt32(&progressCalls) != 20 {
+		t.Fatalf("expected 20 requests and progress calls, got %d / %d", client.calls, progressCalls)
+	}
+
+	seen := map[string]bool{}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("expected result %d to keep input order, got index %d", i, r.Index)
+		}
+		if r.OriginatorConversationID == "" || seen[r.OriginatorConversationID] {
+			t.Errorf("expected a unique OriginatorConversationID for result %d, got %q", i, r.OriginatorConversationID)
+		}
+		seen[r.OriginatorConversationID] = true
+
+		if i == 3 {
+			if r.Err == nil {
+				t.Errorf("expected result 3 to fail")
+			}
+			continue
+		}
+		if r.Err != nil || !r.Response.Accepted() {
+			t.Errorf("expected result %d to succeed, got %v", i, r.Err)
+		}
+		if r.Response.OriginatorConversationID != r.OriginatorConversationID {
+			t.Errorf("expected response to echo the request ID for result %d", i)
+		}
+	}
+}
+
+func TestB2CService_SendBatch_ContextCancellation(t *testing.T) {
+	client := &concurrencyClient{delay: 5 * time.Millisecond}
+	service := newTestB2CService(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := service.SendBatch(ctx, batchRecipients(50), Services.B2CBatchOptions{
+		Concurrency: 2,
+		OnProgress: func(done, total int, result Services.B2CBatchResult) {
+			if done == 4 {
+				cancel()
+			}
+		},
+	})
+
+	if len(results) != 50 {
+		t.Fatalf("expected a result per recipient, got %d", len(results))
+	}
+	if calls := atomic.LoadInt32(&client.calls); calls >= 50 {
+		t.Fatalf("expected cancellation to stop the batch early, got %d requests", calls)
+	}
+	if !errors.Is(results[49].Err, context.Canceled) {
+		t.Errorf("expected unsent recipients to fail with context.Canceled, got %v", results[49].Err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func TestLoadB2CRecipientsCSV_Fixture(t *testing.T) {
+	f, err := os.Open("testdata/b2c_recipients.csv")
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+
+	recipients, rowErrs := Services.LoadB2CRecipientsCSV(buildTestConfig(), f)
+
+	expected := []Services.B2CRecipient{
+		{Phone: "254711223344", Amount: 1500, Occasion: "PAY-001", IdempotencyKey: "PAY-001"},
+		{Phone: "254722000000", Amount: 250, Occasion: "PAY-002", IdempotencyKey: "PAY-002"},
+		{Phone: "254766778899", Amount: 2000, Occasion: "PAY-006, March", IdempotencyKey: "PAY-006, March"},
+		{Phone: "254777889900", Amount: 75.5},
+	}
+	if len(recipients) != len(expected) {
+		t.Fatalf("expected %d recipients, got %d: %+v", len(expected), len(recipients), recipients)
+	}
+	for i := range expected {
+		if recipients[i] != expected[i] {
+			t.Errorf("recipient %d: expected %+v, got %+v", i, expected[i], recipients[i])
+		}
+	}
+
+	wantErrs := map[int]string{
+		4: "invalid phone number",
+		5: "invalid amount",
+		6: "must be greater than 0",
+		7: `duplicate reference "PAY-001" (first used on line 2)`,
+	}
+	if len(rowErrs) != len(wantErrs) {
+		t.Fatalf("expected %d row errors, got %v", len(wantErrs), rowErrs)
+	}
+	for _, e := range rowErrs {
+		if want, ok := wantErrs[e.Line]; !ok || !strings.Contains(e.Error(), want) {
+			t.Errorf("unexpected row error %v", e)
+		}
+	}
+}
+
+func TestLoadB2CRecipientsCSV_NoHeaderAndColumnOrder(t *testing.T) {
+	recipients, rowErrs := Services.LoadB2CRecipientsCSV(buildTestConfig(), strings.NewReader("0711223344,100,A\n0722000000,200,B\n"))
+	if len(rowErrs) != 0 || len(recipients) != 2 || recipients[1].Amount != 200 {
+		t.Fatalf("unexpected result without header: %+v %v", recipients, rowErrs)
+	}
+
+	recipients, rowErrs = Services.LoadB2CRecipientsCSV(buildTestConfig(), strings.NewReader("reference,amount,msisdn\nX1,\"1,000\",0711223344\n"))
+	if len(rowErrs) != 0 || len(recipients) != 1 {
+		t.Fatalf("unexpected result with reordered header: %+v %v", recipients, rowErrs)
+	}
+	if r := recipients[0]; r.Phone != "254711223344" || r.Amount != 1000 || r.IdempotencyKey != "X1" {
+		t.Errorf("unexpected recipient %+v", r)
+	}
+}
+
+func TestLoadB2CRecipientsCSV_MalformedQuoteReportsLine(t *testing.T) {
+	recipients, rowErrs := Services.LoadB2CRecipientsCSV(buildTestConfig(), strings.NewReader("0711223344,100,A\n0722000000,2\"00,B\n0733445566,300,C\n"))
+	if len(recipients) != 2 {
+		t.Errorf("expected rows around the malformed one to load, got %+v", recipients)
+	}
+	if len(rowErrs) != 1 || rowErrs[0].Line != 2 {
+		t.Errorf("expected a single error on line 2, got %v", rowErrs)
+	}
+}
+
+func TestLoadB2CRecipientsCSV_FeedsSendBatch(t *testing.T) {
+	recipients, _ := Services.LoadB2CRecipientsCSV(buildTestConfig(), strings.NewReader("phone,amount,reference\n0711223344,100,A\n0722000000,200,B\n"))
+
+	client := &concurrencyClient{}
+	results := newTestB2CService(client).SendBatch(context.Background(), recipients, Services.B2CBatchOptions{})
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("recipient %d failed: %v", r.Index, r.Err)
+		}
+	}
+	if client.calls != 2 {
+		t.Errorf("expected 2 requests, got %d", client.calls)
+	}
+}
+
+func TestLoadB2CRecipientsCSV_ConfigCountryCode(t *testing.T) {
+	cfg := buildTestConfig()
+	if err := cfg.SetDefaultCountryCode("255"); err != nil {
+		t.Fatalf("SetDefaultCountryCode error: %v", err)
+	}
+
+	recipients, rowErrs := Services.LoadB2CRecipientsCSV(cfg, strings.NewReader("0754123456,100,A\n254711223344,200,B\n"))
+	if len(recipients) != 1 || recipients[0].Phone != "255754123456" {
+		t.Fatalf("expected the local number converted with country code 255, got %+v", recipients)
+	}
+	if len(rowErrs) != 1 || rowErrs[0].Line != 2 || !strings.Contains(rowErrs[0].Error(), "255") {
+		t.Errorf("expected the Kenyan number to be rejected on line 2, got %v", rowErrs)
+	}
+}
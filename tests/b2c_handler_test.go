@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const webhookAckBody = `{"ResultCode":0,"ResultDesc":"Accepted"}`
+
+func postWebhook(handler http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/b2c/result", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestB2CResultHandler_Results(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectOK    bool
+		expectedTxn string
+	}{
+		{"Success", b2cResultSuccessJSON, true, "NLJ41HAY6Q"},
+		{"Failure", b2cResultInsufficientFundsJSON, false, "NLJ31HAY0Y"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Services.B2CResult
+			handler := Services.B2CResultHandler(
+				func(res *Services.B2CResult) { got = res },
+				func(map[string]any) { t.Errorf("unexpected timeout callback") },
+			)
+
+			rec := postWebhook(handler, tt.body)
+			if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+				t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+			}
+			if rec.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("expected JSON content type, got %s", rec.Header().Get("Content-Type"))
+			}
+			if got == nil {
+				t.Fatalf("expected result callback")
+			}
+			if got.Success != tt.expectOK || got.TransactionID != tt.expectedTxn {
+				t.Errorf("unexpected result %+v", got)
+			}
+		})
+	}
+}
+
+func TestB2CResultHandler_QueueTimeout(t *testing.T) {
+	var timeout map[string]any
+	handler := Services.B2CResultHandler(
+		func(*Services.B2CResult) { t.Errorf("unexpected result callback") },
+		func(raw map[string]any) { timeout = raw },
+	)
+
+	rec := postWebhook(handler, `{"OriginatorConversationID":"16740-34861180-1","ResultDesc":"The request timed out in the queue."}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if timeout["OriginatorConversationID"] != "16740-34861180-1" {
+		t.Errorf("expected raw timeout payload, got %v", timeout)
+	}
+}
+
+func TestB2CResultHandler_Limits(t *testing.T) {
+	handler := Services.B2CResultHandler(nil, nil, Services.WithMaxBodyBytes(64), Services.WithAckPolicy(Services.StrictAck))
+
+	req := httptest.NewRequest(http.MethodGet, "/mpesa/b2c/result", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	if rec := postWebhook(handler, b2cResultSuccessJSON); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", rec.Code)
+	}
+	strict := Services.B2CResultHandler(nil, nil, Services.WithAckPolicy(Services.StrictAck))
+	if rec := postWebhook(strict, `{not json`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid JSON under StrictAck, got %d", rec.Code)
+	}
+}
+
+func TestB2CResultHandler_ErrorHandler(t *testing.T) {
+	var reported error
+	handler := Services.B2CResultHandler(nil, nil, Services.WithErrorHandler(func(err error, r *http.Request) {
+		reported = err
+	}))
+
+	rec := postWebhook(handler, `{"Result": "not an object"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack with error handler, got %d %s", rec.Code, rec.Body.String())
+	}
+	if reported == nil {
+		t.Errorf("expected parse error to be reported")
+	}
+}
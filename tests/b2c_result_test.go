@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const b2cResultSuccessJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": 0,
+    "ResultDesc": "The service request is processed successfully.",
+    "OriginatorConversationID": "10571-7910404-1",
+    "ConversationID": "AG_20191219_00004e48cf7e3533f581",
+    "TransactionID": "NLJ41HAY6Q",
+    "ResultParameters": {
+      "ResultParameter": [
+        {"Key": "TransactionAmount", "Value": 10},
+        {"Key": "TransactionReceipt", "Value": "NLJ41HAY6Q"},
+        {"Key": "B2CRecipientIsRegisteredCustomer", "Value": "Y"},
+        {"Key": "B2CChargesPaidAccountAvailableFunds", "Value": -4510.00},
+        {"Key": "ReceiverPartyPublicName", "Value": "254708374149 - John Doe"},
+        {"Key": "TransactionCompletedDateTime", "Value": "19.12.2019 11:45:50"},
+        {"Key": "B2CUtilityAccountAvailableFunds", "Value": 10116.00},
+        {"Key": "B2CWorkingAccountAvailableFunds", "Value": 900000.00}
+      ]
+    },
+    "ReferenceData": {
+      "ReferenceItem": {
+        "Key": "QueueTimeoutURL",
+        "Value": "https://internalsandbox.safaricom.co.ke/mpesa/b2cresults/v1/submit"
+      }
+    }
+  }
+}`
+
+const b2cResultInsufficientFundsJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": 1,
+    "ResultDesc": "The balance is insufficient for the transaction.",
+    "OriginatorConversationID": "29112-34801843-1",
+    "ConversationID": "AG_20191219_00006c6fddb15123addf",
+    "TransactionID": "NLJ31HAY0Y",
+    "ReferenceData": {
+      "ReferenceItem": {
+        "Key": "QueueTimeoutURL",
+        "Value": "https://internalsandbox.safaricom.co.ke/mpesa/b2cresults/v1/submit"
+      }
+    }
+  }
+}`
+
+func decodeFixture(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("invalid fixture: %v", err)
+	}
+	return payload
+}
+
+func TestParseB2CResult_Success(t *testing.T) {
+	res, err := Services.ParseB2CResult(decodeFixture(t, b2cResultSuccessJSON))
+	if err != nil {
+		t.Fatalf("ParseB2CResult error: %v", err)
+	}
+
+	if !res.Success || res.ResultCode != "0" {
+		t.Fatalf("expected success, got code %s", res.ResultCode)
+	}
+	if res.ConversationID != "AG_20191219_00004e48cf7e3533f581" || res.OriginatorConversationID != "10571-7910404-1" {
+		t.Errorf("unexpected conversation IDs: %s / %s", res.ConversationID, res.OriginatorConversationID)
+	}
+	if res.TransactionAmount != 10 {
+		t.Errorf("expected amount 10, got %v", res.TransactionAmount)
+	}
+	if res.TransactionReceipt != "NLJ41HAY6Q" {
+		t.Errorf("unexpected receipt: %s", res.TransactionReceipt)
+	}
+	if res.ReceiverPartyPublicName != "254708374149 - John Doe" {
+		t.Errorf("unexpected receiver name: %s", res.ReceiverPartyPublicName)
+	}
+	if !res.B2CRecipientIsRegisteredCustomer {
+		t.Errorf("expected registered customer")
+	}
+	if res.B2CUtilityAccountAvailableFunds != 10116 || res.B2CWorkingAccountAvailableFunds != 900000 {
+		t.Errorf("unexpected balances: %v / %v", res.B2CUtilityAccountAvailableFunds, res.B2CWorkingAccountAvailableFunds)
+	}
+	if res.B2CChargesPaidAccountAvailableFunds != -4510 {
+		t.Errorf("unexpected charges paid balance: %v", res.B2CChargesPaidAccountAvailableFunds)
+	}
+
+	expected := time.Date(2019, 12, 19, 11, 45, 50, 0, time.FixedZone("EAT", 3*60*60))
+	if !res.TransactionCompletedDateTime.Equal(expected) {
+		t.Errorf("expected completion time %v, got %v", expected, res.TransactionCompletedDateTime)
+	}
+	if res.Raw == nil {
+		t.Errorf("expected raw payload to be kept")
+	}
+}
+
+func TestParseB2CResult_InsufficientFunds(t *testing.T) {
+	res, err := Services.ParseB2CResult(decodeFixture(t, b2cResultInsufficientFundsJSON))
+	if err != nil {
+		t.Fatalf("ParseB2CResult error: %v", err)
+	}
+
+	if res.Success {
+		t.Fatalf("expected failure, got success")
+	}
+	if res.ResultCode != "1" {
+		t.Errorf("expected code 1, got %s", res.ResultCode)
+	}
+	if res.ResultDesc != "The balance is insufficient for the transaction." {
+		t.Errorf("unexpected description: %s", res.ResultDesc)
+	}
+	if res.TransactionAmount != 0 || !res.TransactionCompletedDateTime.IsZero() || res.B2CRecipientIsRegisteredCustomer {
+		t.Errorf("expected zero values for missing parameters, got %+v", res)
+	}
+	if res.ReferenceData["QueueTimeoutURL"] == "" {
+		t.Errorf("expected reference data to be parsed")
+	}
+}
+
+func TestParseB2CResult_MissingResult(t *testing.T) {
+	if _, err := Services.ParseB2CResult(map[string]any{"foo": "bar"}); err == nil {
+		t.Fatalf("expected error for payload without Result node")
+	}
+}
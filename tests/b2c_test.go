@@ -0,0 +1,735 @@
+package tests
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func newTestB2CService(client abstracts.MpesaInterface) *Services.BusinessToCustomerService {
+	return Services.NewBusinessToCustomerService(buildTestConfig(), client).
+		SetInitiatorName("testapi").
+		SetCommandID("BusinessPayment").
+		SetAmount(1000).
+		SetRemarks("Test payment")
+}
+
+func TestB2CService_SetPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		expected    string
+		expectError bool
+	}{
+		{"Valid phone number with country code", "254711223344", "254711223344", false},
+		{"Valid phone number without country code", "0711223344", "254711223344", false},
+		{"Valid international phone number", "+254711223344", "254711223344", false},
+		{"Valid bare phone number", "711223344", "254711223344", false},
+		{"Valid bare 01 phone number", "110123456", "254110123456", false},
+		{"Ten digits without a leading zero", "7112233445", "", true},
+		{"11-digit phone number", "25471122334", "", true},
+		{"13-digit phone number", "2547112233445", "", true},
+		{"11-digit international phone number", "+25471122334", "", true},
+		{"Empty phone number", "", "", true},
+		{"Short phone number", "123", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client)
+
+			result := service.SetPhoneNumber(tt.phoneNumber)
+			if result != service {
+				t.Fatalf("expected SetPhoneNumber to return the service for chaining")
+			}
+
+			_, err := service.Send()
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid phone number") {
+					t.Fatalf("expected invalid phone number error, got %v", err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) > 0 {
+					t.Fatalf("expected no request to be sent for an invalid phone number")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			payload := client.LastPayload(mpesatest.AnyEndpoint)
+			if payload["PartyB"] != tt.expected {
+				t.Errorf("expected PartyB %s, got %v", tt.expected, payload["PartyB"])
+			}
+		})
+	}
+}
+
+func TestB2CService_SetPhoneNumber_LaterValidNumberClearsError(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).
+		SetPhoneNumber("123").
+		SetPhoneNumber("0711223344")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestB2CService_SetRawPhoneNumber(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).SetRawPhoneNumber("0711223344")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["PartyB"] != "0711223344" {
+		t.Errorf("expected raw PartyB to be sent verbatim, got %v", payload["PartyB"])
+	}
+}
+
+func TestB2CService_Send_AcceptedResponse(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"ConversationID":           "AG_20191219_00005797af5d7d75f652",
+		"OriginatorConversationID": "16740-34861180-1",
+		"ResponseCode":             "0",
+		"ResponseDescription":      "Accept the service request successfully.",
+	})
+	service := Services.NewBusinessToCustomerService(buildTestConfig(), client).
+		SetInitiatorName("testapi").
+		SetCommandID("BusinessPayment").
+		SetAmount(1000).
+		SetRemarks("Test payment").
+		SetPhoneNumber("0711223344")
+
+	if service.GetResponse() != nil || service.GetTypedResponse() != nil {
+		t.Fatalf("expected no response before Send")
+	}
+	if _, err := service.GetConversationID(); err == nil {
+		t.Fatalf("expected error for ConversationID before Send")
+	}
+
+	raw, err := service.Send()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if raw["ConversationID"] != "AG_20191219_00005797af5d7d75f652" {
+		t.Errorf("unexpected raw response: %v", raw)
+	}
+	if got := service.GetResponse(); got["ResponseCode"] != "0" {
+		t.Errorf("expected stored raw response, got %v", got)
+	}
+
+	typed := service.GetTypedResponse()
+	if !typed.Accepted() {
+		t.Errorf("expected response to be accepted")
+	}
+	if typed.ResponseDescription != "Accept the service request successfully." {
+		t.Errorf("unexpected description: %s", typed.ResponseDescription)
+	}
+
+	conversationID, err := service.GetConversationID()
+	if err != nil || conversationID != "AG_20191219_00005797af5d7d75f652" {
+		t.Errorf("unexpected ConversationID %q (err %v)", conversationID, err)
+	}
+	if typed.OriginatorConversationID != "16740-34861180-1" {
+		t.Errorf("unexpected OriginatorConversationID %q", typed.OriginatorConversationID)
+	}
+}
+
+func TestB2CService_Send_RejectedResponse(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"ConversationID":          "",
+		"OriginatorCoversationID": "16740-34861180-2",
+		"ResponseCode":            1,
+		"ResponseDescription":     "The initiator information is invalid.",
+	})
+	service := newTestB2CService(client).SetPhoneNumber("0711223344")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no transport error, got %v", err)
+	}
+
+	typed := service.GetTypedResponse()
+	if typed.Accepted() {
+		t.Errorf("expected response to be rejected")
+	}
+	if typed.ResponseCode != "1" {
+		t.Errorf("expected numeric ResponseCode decoded as \"1\", got %q", typed.ResponseCode)
+	}
+	if typed.OriginatorConversationID != "16740-34861180-2" {
+		t.Errorf("expected misspelled OriginatorCoversationID to be decoded, got %q", typed.OriginatorConversationID)
+	}
+	if _, err := service.GetConversationID(); err == nil {
+		t.Errorf("expected error for empty ConversationID")
+	}
+}
+
+func TestB2CService_PerServiceURLs(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	cfg := buildTestConfig()
+	service := Services.NewBusinessToCustomerService(cfg, client).
+		SetInitiatorName("testapi").
+		SetCommandID("BusinessPayment").
+		SetAmount(1000).
+		SetRemarks("Test payment").
+		SetPhoneNumber("0711223344").
+		SetResultURL("https://override.example.com/result").
+		SetQueueTimeoutURL("https://override.example.com/timeout")
+
+	configResultURL := cfg.GetResultURL()
+	configTimeoutURL := cfg.GetQueueTimeoutURL()
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["ResultURL"] != "https://override.example.com/result" {
+		t.Errorf("expected overridden ResultURL, got %v", payload["ResultURL"])
+	}
+	if payload["QueueTimeOutURL"] != "https://override.example.com/timeout" {
+		t.Errorf("expected overridden QueueTimeOutURL, got %v", payload["QueueTimeOutURL"])
+	}
+	if cfg.GetResultURL() != configResultURL || cfg.GetQueueTimeoutURL() != configTimeoutURL {
+		t.Errorf("expected config URLs to be untouched, got %s / %s", cfg.GetResultURL(), cfg.GetQueueTimeoutURL())
+	}
+
+	other := newTestB2CService(client).SetPhoneNumber("0711223344")
+	if _, err := other.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastPayload(mpesatest.AnyEndpoint)["ResultURL"] != configResultURL {
+		t.Errorf("expected service without override to use the config ResultURL, got %v", client.LastPayload(mpesatest.AnyEndpoint)["ResultURL"])
+	}
+}
+
+func TestB2CService_CommandIDValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		commandID   string
+		expectError bool
+	}{
+		{"Salary payment", Services.CommandSalaryPayment, false},
+		{"Business payment", Services.CommandBusinessPayment, false},
+		{"Promotion payment", Services.CommandPromotionPayment, false},
+		{"Plural typo", "SalaryPayments", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client).
+				SetPhoneNumber("0711223344").
+				SetCommandID(tt.commandID)
+
+			_, err := service.Send()
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error for command ID %q", tt.commandID)
+				}
+				for _, valid := range []string{"SalaryPayment", "BusinessPayment", "PromotionPayment"} {
+					if !strings.Contains(err.Error(), valid) {
+						t.Errorf("expected error to list %s, got %v", valid, err)
+					}
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent for an invalid command ID")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if client.LastPayload(mpesatest.AnyEndpoint)["CommandID"] != tt.commandID {
+				t.Errorf("expected CommandID %s, got %v", tt.commandID, client.LastPayload(mpesatest.AnyEndpoint)["CommandID"])
+			}
+		})
+	}
+}
+
+func TestB2CService_SetRawCommandID(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetRawCommandID("FuturePayment")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected raw command ID to bypass validation, got %v", err)
+	}
+	if client.LastPayload(mpesatest.AnyEndpoint)["CommandID"] != "FuturePayment" {
+		t.Errorf("expected raw CommandID to be sent verbatim, got %v", client.LastPayload(mpesatest.AnyEndpoint)["CommandID"])
+	}
+}
+
+func TestB2CService_OriginatorConversationID_Generated(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).SetPhoneNumber("0711223344")
+
+	id, err := service.GetOriginatorConversationID()
+	if err != nil {
+		t.Fatalf("expected ID before Send, got error %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Fatalf("expected a UUID v4, got %q", id)
+	}
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"]; got != id {
+		t.Errorf("expected the ID returned before Send, got %v", got)
+	}
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second := client.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"]
+	if second == id || second == "" {
+		t.Errorf("expected a new OriginatorConversationID for the second request, got %v", second)
+	}
+	next, _ := service.GetOriginatorConversationID()
+	if next == second || next == id {
+		t.Errorf("expected the ID of the next request after a successful one, got %s", next)
+	}
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"]; got != next {
+		t.Errorf("expected the ID returned by the getter %s, got %v", next, got)
+	}
+
+	other := newTestB2CService(client).SetPhoneNumber("0711223344")
+	otherID, _ := other.GetOriginatorConversationID()
+	if otherID == id {
+		t.Errorf("expected each service to generate its own ID")
+	}
+}
+
+func TestB2CService_OriginatorConversationID_RoundTrip(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"ConversationID":           "AG_20191219_00005797af5d7d75f652",
+		"OriginatorConversationID": "payout-0001",
+		"ResponseCode":             "0",
+	})
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetOriginatorConversationID("payout-0001")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"] != "payout-0001" {
+		t.Fatalf("expected override in payload, got %v", client.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"])
+	}
+
+	callback := strings.Replace(b2cResultSuccessJSON, "10571-7910404-1", "payout-0001", 1)
+	result, err := Services.ParseB2CResult(decodeFixture(t, callback))
+	if err != nil {
+		t.Fatalf("ParseB2CResult error: %v", err)
+	}
+	if id := service.GetTypedResponse().OriginatorConversationID; result.OriginatorConversationID != id {
+		t.Errorf("expected result to correlate with request ID %s, got %s", id, result.OriginatorConversationID)
+	}
+}
+
+func TestB2CService_OriginatorConversationID_KeptForRetry(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).SetPhoneNumber("0711223344")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	id, _ := service.GetOriginatorConversationID()
+
+	client.FailNext(mpesatest.AnyEndpoint, errors.New("connection reset by peer"))
+	if _, err := service.Send(); err == nil {
+		t.Fatalf("expected the failed request to return an error")
+	}
+	if got, _ := service.GetOriginatorConversationID(); got != id {
+		t.Errorf("expected the ID of the failed request to be kept, got %s", got)
+	}
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+
+	failed, retried := client.Payload(1)["OriginatorConversationID"], client.Payload(2)["OriginatorConversationID"]
+	if failed != id || retried != id || client.Payload(0)["OriginatorConversationID"] == id {
+		t.Errorf("expected the failed request and its retry to send %s, got %v and %v", id, failed, retried)
+	}
+}
+
+func TestB2CService_OriginatorConversationID_PerRequest(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetOriginatorConversationID("payout-0001")
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Send(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	first := client.Payload(0)["OriginatorConversationID"]
+	second := client.Payload(1)["OriginatorConversationID"]
+	if first != "payout-0001" || second == first || second == "" {
+		t.Errorf("expected the override for the first request only and a fresh ID after it, got %v and %v", first, second)
+	}
+
+	if _, err := service.SetOriginatorConversationID("payout-0002").Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.Payload(2)["OriginatorConversationID"]; got != "payout-0002" {
+		t.Errorf("expected a new override to apply to the next request, got %v", got)
+	}
+}
+
+func TestB2CService_DecimalAmounts(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(*Services.BusinessToCustomerService)
+		expected    int
+		expectError bool
+	}{
+		{"Whole float", func(s *Services.BusinessToCustomerService) { s.SetAmountFloat(100.0) }, 100, false},
+		{"Fractional cents rejected by default", func(s *Services.BusinessToCustomerService) { s.SetAmountFloat(99.999) }, 0, true},
+		{"Fractional cents rounded half up", func(s *Services.BusinessToCustomerService) {
+			s.SetRoundingPolicy(Services.RoundHalfUp).SetAmountFloat(99.999)
+		}, 100, false},
+		{"Decimal string rejected by default", func(s *Services.BusinessToCustomerService) { s.SetAmountString("150.50") }, 0, true},
+		{"Decimal string rounded half up", func(s *Services.BusinessToCustomerService) {
+			s.SetRoundingPolicy(Services.RoundHalfUp).SetAmountString("150.50")
+		}, 151, false},
+		{"Whole string with separator", func(s *Services.BusinessToCustomerService) { s.SetAmountString("1,500.00") }, 1500, false},
+		{"Invalid string", func(s *Services.BusinessToCustomerService) { s.SetAmountString("abc") }, 0, true},
+		{"Negative float", func(s *Services.BusinessToCustomerService) { s.SetAmountFloat(-50) }, 0, true},
+		{"Negative string", func(s *Services.BusinessToCustomerService) {
+			s.SetRoundingPolicy(Services.RoundHalfUp).SetAmountString("-150.50")
+		}, 0, true},
+		{"Zero after rounding", func(s *Services.BusinessToCustomerService) {
+			s.SetRoundingPolicy(Services.RoundHalfUp).SetAmountFloat(0.4)
+		}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client).SetPhoneNumber("0711223344")
+			tt.configure(service)
+
+			_, err := service.Send()
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got payload %v", client.LastPayload(mpesatest.AnyEndpoint))
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := client.LastPayload(mpesatest.AnyEndpoint)["Amount"]; got != tt.expected {
+				t.Errorf("expected Amount %d, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestB2CService_AmountLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		min, max    int
+		amount      int
+		expectError bool
+	}{
+		{"Below default minimum", Services.DefaultB2CMinAmount, Services.DefaultB2CMaxAmount, 9, true},
+		{"At default minimum", Services.DefaultB2CMinAmount, Services.DefaultB2CMaxAmount, 10, false},
+		{"At default maximum", Services.DefaultB2CMinAmount, Services.DefaultB2CMaxAmount, 150000, false},
+		{"Above default maximum", Services.DefaultB2CMinAmount, Services.DefaultB2CMaxAmount, 150001, true},
+		{"Custom maximum", 10, 250000, 250000, false},
+		{"Above custom maximum", 10, 250000, 250001, true},
+		{"Minimum disabled", 0, 150000, 1, false},
+		{"Maximum disabled", 10, 0, 1000000, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client).
+				SetPhoneNumber("0711223344").
+				SetAmountLimits(tt.min, tt.max).
+				SetAmount(tt.amount)
+
+			_, err := service.Send()
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), strconv.Itoa(tt.amount)) {
+					t.Fatalf("expected descriptive limit error, got %v", err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestB2CService_DefaultAmountLimitsApplyToPaymentRequest(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).SetPhoneNumber("0711223344").SetAmount(5)
+
+	if _, err := service.PaymentRequest(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil); err == nil {
+		t.Fatalf("expected minimum amount error")
+	}
+}
+
+func TestB2CService_PaymentRequestWithOpts_Partial(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client)
+
+	if _, err := service.PaymentRequestWithOpts(Services.PaymentRequestOpts{PhoneNumber: "0711223344"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["PartyB"] != "254711223344" || payload["Amount"] != 1000 || payload["InitiatorName"] != "testapi" {
+		t.Errorf("expected unset options to keep service values, got %v", payload)
+	}
+	if payload["PartyA"] != "603021" {
+		t.Errorf("expected config business code, got %v", payload["PartyA"])
+	}
+}
+
+func TestB2CService_PaymentRequestWithOpts_Full(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	cfg := buildTestConfig()
+	service := Services.NewBusinessToCustomerService(cfg, client)
+
+	_, err := service.PaymentRequestWithOpts(Services.PaymentRequestOpts{
+		InitiatorName:     "apiop",
+		InitiatorPassword: "secret",
+		CommandID:         Services.CommandSalaryPayment,
+		Amount:            5000,
+		PartyA:            "600000",
+		PhoneNumber:       "+254711223344",
+		Remarks:           "Salary",
+		QueueTimeoutURL:   "https://example.com/timeout",
+		ResultURL:         "https://example.com/result",
+		Occasion:          "December",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"InitiatorName":   "apiop",
+		"CommandID":       "SalaryPayment",
+		"Amount":          5000,
+		"PartyA":          "600000",
+		"PartyB":          "254711223344",
+		"Remarks":         "Salary",
+		"QueueTimeOutURL": "https://example.com/timeout",
+		"ResultURL":       "https://example.com/result",
+		"Occasion":        "December",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s %v, got %v", key, want, payload[key])
+		}
+	}
+	if payload["SecurityCredential"] == "" || payload["SecurityCredential"] == nil {
+		t.Errorf("expected security credential from initiator password")
+	}
+	if cfg.GetBusinessCode() != "603021" {
+		t.Errorf("expected config business code to be untouched, got %s", cfg.GetBusinessCode())
+	}
+}
+
+func TestB2CService_PaymentRequestWithOpts_KeepsConfigCredential(t *testing.T) {
+	cfg := buildTestConfig()
+	cfg.OverrideSecurityCredential("SHARED_CREDENTIAL")
+
+	client := mpesatest.NewRecordingClient()
+	service := Services.NewBusinessToCustomerService(cfg, client).
+		SetInitiatorName("testapi").
+		SetCommandID(Services.CommandBusinessPayment).
+		SetAmount(1000).
+		SetRemarks("Test payment")
+	if _, err := service.PaymentRequestWithOpts(Services.PaymentRequestOpts{InitiatorPassword: "secret", PhoneNumber: "0711223344"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cred := client.LastPayload(mpesatest.AnyEndpoint)["SecurityCredential"]; cred == "" || cred == "SHARED_CREDENTIAL" {
+		t.Errorf("expected the credential encrypted from the initiator password, got %v", cred)
+	}
+	if cfg.GetSecurityCredential() != "SHARED_CREDENTIAL" {
+		t.Errorf("expected config credential to be untouched, got %s", cfg.GetSecurityCredential())
+	}
+
+	other := mpesatest.NewRecordingClient()
+	if _, err := Services.NewBusinessToCustomerService(cfg, other).
+		SetInitiatorName("testapi").
+		SetCommandID(Services.CommandBusinessPayment).
+		SetAmount(1000).
+		SetRemarks("Test payment").
+		SetPhoneNumber("0711223344").
+		Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cred := other.LastPayload(mpesatest.AnyEndpoint)["SecurityCredential"]; cred != "SHARED_CREDENTIAL" {
+		t.Errorf("expected other services to keep the config credential, got %v", cred)
+	}
+
+	if _, err := service.SetEncryptedSecurityCredential("SERVICE_CREDENTIAL").Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cred := client.LastPayload(mpesatest.AnyEndpoint)["SecurityCredential"]; cred != "SERVICE_CREDENTIAL" {
+		t.Errorf("expected the encrypted service credential, got %v", cred)
+	}
+}
+
+func TestB2CService_PaymentRequest_Deprecated(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := Services.NewBusinessToCustomerService(buildTestConfig(), client)
+
+	initiator, command, phone, remarks := "testapi", Services.CommandBusinessPayment, "0711223344", "Refund"
+	timeoutURL, resultURL := "https://example.com/timeout", "https://example.com/result"
+	amount := 200
+
+	_, err := service.PaymentRequest(&initiator, nil, &command, &amount, nil, &phone, &remarks, &timeoutURL, &resultURL, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["QueueTimeOutURL"] != timeoutURL || payload["ResultURL"] != resultURL {
+		t.Errorf("expected URLs in their positional slots, got %v / %v", payload["QueueTimeOutURL"], payload["ResultURL"])
+	}
+	if payload["Amount"] != 200 || payload["PartyB"] != "254711223344" {
+		t.Errorf("unexpected payload %v", payload)
+	}
+}
+
+func TestB2CService_RemarksAndOccasionLengths(t *testing.T) {
+	tests := []struct {
+		name        string
+		remarks     string
+		occasion    string
+		expectError string
+	}{
+		{"Empty remarks", "", "", "remarks is required"},
+		{"Single character remarks", "x", "", ""},
+		{"Remarks at limit", strings.Repeat("r", 100), "", ""},
+		{"Remarks over limit", strings.Repeat("r", 101), "", "remarks must be between 1 and 100 characters, got 101"},
+		{"Multibyte remarks at limit", strings.Repeat("é", 100), "", ""},
+		{"Occasion at limit", "Salary", strings.Repeat("o", 100), ""},
+		{"Occasion over limit", "Salary", strings.Repeat("o", 101), "occasion must be between 0 and 100 characters, got 101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client).
+				SetPhoneNumber("0711223344").
+				SetRemarks(tt.remarks).
+				SetOccasion(tt.occasion)
+
+			_, err := service.Send()
+			if tt.expectError != "" {
+				if err == nil || err.Error() != tt.expectError {
+					t.Fatalf("expected error %q, got %v", tt.expectError, err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestB2CService_SetDefaultRemarks(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetRemarks("").
+		SetDefaultRemarks("December payroll")
+
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected default remarks to satisfy validation, got %v", err)
+	}
+	if client.LastPayload(mpesatest.AnyEndpoint)["Remarks"] != "December payroll" {
+		t.Errorf("expected default remarks in payload, got %v", client.LastPayload(mpesatest.AnyEndpoint)["Remarks"])
+	}
+
+	service.SetRemarks("Bonus")
+	if _, err := service.Send(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastPayload(mpesatest.AnyEndpoint)["Remarks"] != "Bonus" {
+		t.Errorf("expected explicit remarks to win, got %v", client.LastPayload(mpesatest.AnyEndpoint)["Remarks"])
+	}
+}
+
+func TestB2CService_SendValidationErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*abstracts.MpesaConfig, *Services.BusinessToCustomerService)
+		expected  string
+	}{
+		{"Missing initiator", func(_ *abstracts.MpesaConfig, s *Services.BusinessToCustomerService) {
+			s.SetInitiatorName("")
+		}, "initiator name is required"},
+		{"Missing command ID", func(_ *abstracts.MpesaConfig, s *Services.BusinessToCustomerService) {
+			s.SetCommandID("")
+		}, "command ID is required"},
+		{"Missing amount", func(_ *abstracts.MpesaConfig, s *Services.BusinessToCustomerService) {
+			s.SetAmount(0)
+		}, "amount must be greater than 0"},
+		{"Missing phone number", func(_ *abstracts.MpesaConfig, s *Services.BusinessToCustomerService) {
+			s.SetRawPhoneNumber("")
+		}, "phone number is required"},
+		{"Missing business code", func(cfg *abstracts.MpesaConfig, _ *Services.BusinessToCustomerService) {
+			cfg.SetBusinessCode("")
+		}, "business shortcode (PartyA) is required; call SetBusinessCode on mpesa config"},
+		{"Missing security credential", func(cfg *abstracts.MpesaConfig, _ *Services.BusinessToCustomerService) {
+			cfg.OverrideSecurityCredential("")
+		}, "security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config"},
+		{"Missing queue timeout URL", func(cfg *abstracts.MpesaConfig, _ *Services.BusinessToCustomerService) {
+			cfg.SetQueueTimeoutURL("")
+		}, "queue timeout URL is required; call SetQueueTimeoutURL on the service or config"},
+		{"Missing result URL", func(cfg *abstracts.MpesaConfig, _ *Services.BusinessToCustomerService) {
+			cfg.SetResultURL("")
+		}, "result URL is required; call SetResultURL on the service or config"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestB2CService(client).SetPhoneNumber("0711223344")
+			tt.configure(service.Config, service)
+
+			_, err := service.Send()
+			if err == nil || err.Error() != tt.expected {
+				t.Fatalf("expected error %q, got %v", tt.expected, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
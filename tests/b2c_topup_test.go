@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestB2CAccountTopUp_Payload(t *testing.T) {
+	cfg := buildTestConfig()
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewB2CAccountTopUpService(cfg, client).
+		SetInitiator("testapi").
+		SetAmount(50000).
+		SetPartyA("600979").
+		SetPartyB("600000").
+		SetAccountReference("Payroll float").
+		SetRequester("254708374149").
+		SetRemarks("B2C top up").
+		SetQueueTimeoutURL("https://example.com/topup/timeout").
+		SetResultURL("https://example.com/topup/result").
+		Send()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if client.LastEndpoint() != "/mpesa/b2b/v1/paymentrequest" {
+		t.Errorf("unexpected endpoint %s", client.LastEndpoint())
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"Initiator":              "testapi",
+		"CommandID":              "BusinessPayToBulk",
+		"SenderIdentifierType":   "4",
+		"RecieverIdentifierType": "4",
+		"Amount":                 50000,
+		"PartyA":                 "600979",
+		"PartyB":                 "600000",
+		"AccountReference":       "Payroll float",
+		"Requester":              "254708374149",
+		"Remarks":                "B2C top up",
+		"QueueTimeOutURL":        "https://example.com/topup/timeout",
+		"ResultURL":              "https://example.com/topup/result",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s %v, got %v", key, want, payload[key])
+		}
+	}
+	if cfg.GetBusinessCode() != "603021" {
+		t.Errorf("expected config business code to be untouched, got %s", cfg.GetBusinessCode())
+	}
+}
+
+func TestB2CAccountTopUp_DefaultsToConfig(t *testing.T) {
+	cfg := buildTestConfig()
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewB2CAccountTopUpService(cfg, client).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyB("600000").
+		Send()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["PartyA"] != cfg.GetBusinessCode() || payload["ResultURL"] != cfg.GetResultURL() {
+		t.Errorf("expected config fallbacks, got PartyA %v ResultURL %v", payload["PartyA"], payload["ResultURL"])
+	}
+}
+
+func TestB2CAccountTopUp_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Services.B2CAccountTopUpService)
+		expected  string
+	}{
+		{"Missing initiator", func(s *Services.B2CAccountTopUpService) { s.SetAmount(100).SetPartyB("600000") }, "initiator is required"},
+		{"Missing amount", func(s *Services.B2CAccountTopUpService) { s.SetInitiator("testapi").SetPartyB("600000") }, "amount must be greater than 0"},
+		{"Missing partyB", func(s *Services.B2CAccountTopUpService) { s.SetInitiator("testapi").SetAmount(100) }, "partyB (B2C shortcode) is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			svc := Services.NewB2CAccountTopUpService(buildTestConfig(), client)
+			tt.configure(svc)
+
+			_, err := svc.Send()
+			if err == nil || err.Error() != tt.expected {
+				t.Fatalf("expected error %q, got %v", tt.expected, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) > 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
@@ -1,14 +1,21 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
 )
 
-func TestParseCallback_Success(t *testing.T) {
-	svc := Services.NewBusinessToPayBillService(nil, nil)
-	payload := map[string]any{
+// b2bCallbackSuccessPayload returns a successful BusinessPayBill result callback.
+func b2bCallbackSuccessPayload() map[string]any {
+	return map[string]any{
 		"Result": map[string]any{
 			"ResultType":               "0",
 			"ResultCode":               "0",
@@ -29,6 +36,31 @@ func TestParseCallback_Success(t *testing.T) {
 			},
 		},
 	}
+}
+
+// b2bCallbackFailurePayload returns a failed B2B result callback with single-object parameters.
+func b2bCallbackFailurePayload() map[string]any {
+	return map[string]any{
+		"Result": map[string]any{
+			"ResultType":               0,
+			"ResultCode":               2001,
+			"ResultDesc":               "The initiator information is invalid.",
+			"OriginatorConversationID": "12337-23509183-5",
+			"ConversationID":           "AG_20200120_0000657265d5fa9ae5c0",
+			"TransactionID":            "OAK0000000",
+			"ResultParameters": map[string]any{
+				"ResultParameter": map[string]any{"Key": "BOCompletedTime", "Value": 20200120164825},
+			},
+			"ReferenceData": map[string]any{
+				"ReferenceItem": map[string]any{"Key": "QueueTimeoutURL", "Value": "https://internalapi.safaricom.co.ke/mpesa/abresults/v1/submit"},
+			},
+		},
+	}
+}
+
+func TestParseCallback_Success(t *testing.T) {
+	svc := Services.NewBusinessToPayBillService(nil, nil)
+	payload := b2bCallbackSuccessPayload()
 	res, err := svc.ParseCallback(payload)
 	if err != nil {
 		t.Fatalf("ParseCallback error: %v", err)
@@ -49,22 +81,7 @@ func TestParseCallback_Success(t *testing.T) {
 
 func TestParseCallback_Failure(t *testing.T) {
 	svc := Services.NewBusinessToPayBillService(nil, nil)
-	payload := map[string]any{
-		"Result": map[string]any{
-			"ResultType":               0,
-			"ResultCode":               2001,
-			"ResultDesc":               "The initiator information is invalid.",
-			"OriginatorConversationID": "12337-23509183-5",
-			"ConversationID":           "AG_20200120_0000657265d5fa9ae5c0",
-			"TransactionID":            "OAK0000000",
-			"ResultParameters": map[string]any{
-				"ResultParameter": map[string]any{"Key": "BOCompletedTime", "Value": 20200120164825},
-			},
-			"ReferenceData": map[string]any{
-				"ReferenceItem": map[string]any{"Key": "QueueTimeoutURL", "Value": "https://internalapi.safaricom.co.ke/mpesa/abresults/v1/submit"},
-			},
-		},
-	}
+	payload := b2bCallbackFailurePayload()
 	res, err := svc.ParseCallback(payload)
 	if err != nil {
 		t.Fatalf("ParseCallback error: %v", err)
@@ -79,3 +96,283 @@ func TestParseCallback_Failure(t *testing.T) {
 		t.Fatalf("BOCompletedTime not parsed: %v", res.ResultParameters)
 	}
 }
+
+// newTestB2BConfig returns a config with a business code and security credential but no callback URLs.
+func newTestB2BConfig(env abstracts.Environment) *abstracts.MpesaConfig {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", env, nil, nil, nil, nil, nil)
+	cfg.SetBusinessCode("600000")
+	cfg.OverrideSecurityCredential("FAKE_SECURITY_CREDENTIAL")
+	return cfg
+}
+
+// b2bURLCases are the callback URL validation cases shared by the B2B service tests.
+var b2bURLCases = []struct {
+	name       string
+	env        abstracts.Environment
+	timeoutURL string
+	resultURL  string
+	wantErr    string
+}{
+	{"missing timeout URL", abstracts.Sandbox, "", "https://example.com/result", "call SetQueueTimeoutURL"},
+	{"missing result URL", abstracts.Sandbox, "https://example.com/timeout", "", "call SetResultURL"},
+	{"relative URL", abstracts.Sandbox, "/timeout", "https://example.com/result", "not a valid absolute URL"},
+	{"http in production", abstracts.Production, "https://example.com/timeout", "http://example.com/result", "must use https in production"},
+	{"http in sandbox", abstracts.Sandbox, "http://example.com/timeout", "http://example.com/result", ""},
+	{"https in production", abstracts.Production, "https://example.com/timeout", "https://example.com/result", ""},
+}
+
+func TestBusinessToPayBillService_Send_CallbackURLs(t *testing.T) {
+	for _, tt := range b2bURLCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewBusinessToPayBillService(newTestB2BConfig(tt.env), client).
+				SetInitiator("testapi").
+				SetAmount(100).
+				SetPartyB("000001").
+				SetAccountReference("INV-001").
+				SetQueueTimeoutURL(tt.timeoutURL).
+				SetResultURL(tt.resultURL).
+				Send()
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent, got %d", client.Count(mpesatest.AnyEndpoint))
+			}
+		})
+	}
+}
+
+func TestExecuteB2BRequest_CallbackURLs(t *testing.T) {
+	req := Services.B2BRequest{
+		Initiator:          "testapi",
+		SecurityCredential: "FAKE_SECURITY_CREDENTIAL",
+		Amount:             100,
+		PartyB:             "000001",
+	}
+
+	client := mpesatest.NewRecordingClient()
+	cfg := newTestB2BConfig(abstracts.Sandbox)
+	if _, err := Services.ExecuteB2BRequest(cfg, client, req); err == nil || !strings.Contains(err.Error(), "QueueTimeOutURL") {
+		t.Fatalf("expected missing queue timeout URL error, got %v", err)
+	}
+
+	cfg.SetQueueTimeoutURL("https://example.com/timeout")
+	if _, err := Services.ExecuteB2BRequest(cfg, client, req); err == nil || !strings.Contains(err.Error(), "ResultURL") {
+		t.Fatalf("expected missing result URL error, got %v", err)
+	}
+
+	req.ResultURL = "https://example.com/result"
+	if _, err := Services.ExecuteB2BRequest(cfg, client, req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["QueueTimeOutURL"]; got != "https://example.com/timeout" {
+		t.Errorf("expected config queue timeout URL in payload, got %v", got)
+	}
+}
+
+func TestBusinessToPayBillService_Send_PayloadGolden(t *testing.T) {
+	tests := []struct {
+		golden    string
+		configure func(*Services.BusinessToPayBillService)
+	}{
+		{"b2b_paybill_minimal.golden.json", func(*Services.BusinessToPayBillService) {}},
+		{"b2b_paybill_full.golden.json", func(s *Services.BusinessToPayBillService) {
+			s.SetAccountReference("INV-001").
+				SetRequester("254708374149").
+				SetRemarks("Supplier payment").
+				SetOccasion("Restock")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", tt.golden))
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+
+			client := mpesatest.NewRecordingClient()
+			svc := Services.NewBusinessToPayBillService(newTestB2BConfig(abstracts.Sandbox), client).
+				SetInitiator("testapi").
+				SetAmount(100).
+				SetPartyB("000001").
+				SetAccountReference("INV-001").
+				SetQueueTimeoutURL("https://example.com/timeout").
+				SetResultURL("https://example.com/result").
+				SetOriginatorConversationID("b2b-0001")
+			tt.configure(svc)
+			if _, err := svc.Send(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			got, err := json.Marshal(client.LastPayload(mpesatest.AnyEndpoint))
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+			if !bytes.Equal(got, bytes.TrimSpace(want)) {
+				t.Errorf("expected %s, got %s", bytes.TrimSpace(want), got)
+			}
+		})
+	}
+}
+
+func TestBusinessToPayBillService_Send_Rounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Services.RoundingPolicy
+		amount   float64
+		expected int
+		wantErr  bool
+	}{
+		{"Strict integral", Services.RoundStrict, 100, 100, false},
+		{"Strict half", Services.RoundStrict, 100.5, 0, true},
+		{"Strict below half", Services.RoundStrict, 100.49, 0, true},
+		{"Half up integral", Services.RoundHalfUp, 100, 100, false},
+		{"Half up half", Services.RoundHalfUp, 100.5, 101, false},
+		{"Half up below half", Services.RoundHalfUp, 100.49, 100, false},
+		{"Down integral", Services.RoundDown, 100, 100, false},
+		{"Down half", Services.RoundDown, 100.5, 100, false},
+		{"Down below half", Services.RoundDown, 100.49, 100, false},
+		{"Down to zero", Services.RoundDown, 0.5, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewBusinessToPayBillService(newTestB2BConfig(abstracts.Sandbox), client).
+				SetInitiator("testapi").
+				SetAmount(tt.amount).
+				SetRoundingPolicy(tt.policy).
+				SetPartyB("000001").
+				SetAccountReference("INV-001").
+				SetQueueTimeoutURL("https://example.com/timeout").
+				SetResultURL("https://example.com/result").
+				Send()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for amount %v, got nil", tt.amount)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := client.LastPayload(mpesatest.AnyEndpoint)["Amount"]; got != tt.expected {
+				t.Errorf("expected amount %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestExecuteB2BRequest_RejectsFractionalAmount(t *testing.T) {
+	cfg := newTestB2BConfig(abstracts.Sandbox)
+	req := Services.B2BRequest{
+		Initiator:          "testapi",
+		SecurityCredential: "FAKE_SECURITY_CREDENTIAL",
+		Amount:             100.5,
+		PartyB:             "000001",
+		QueueTimeOutURL:    "https://example.com/timeout",
+		ResultURL:          "https://example.com/result",
+	}
+
+	client := mpesatest.NewRecordingClient()
+	if _, err := Services.ExecuteB2BRequest(cfg, client, req); err == nil || !strings.Contains(err.Error(), "fractional part") {
+		t.Fatalf("expected fractional amount error, got %v", err)
+	}
+
+	req.Rounding = Services.RoundHalfUp
+	if _, err := Services.ExecuteB2BRequest(cfg, client, req); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["Amount"]; got != 101 {
+		t.Errorf("expected rounded amount 101, got %v", got)
+	}
+}
+
+func TestB2BServices_AccountReference(t *testing.T) {
+	thirteen := "ABCDEFGHIJKLM"
+	fourteen := thirteen + "N"
+
+	tests := []struct {
+		name     string
+		paybill  bool
+		ref      string
+		truncate bool
+		expected string // payload value, when no error is expected
+		wantErr  string
+	}{
+		{"PayBill 13 chars", true, thirteen, false, thirteen, ""},
+		{"PayBill 14 chars", true, fourteen, false, "", "account reference must be between 1 and 13 characters, got 14"},
+		{"PayBill missing", true, "", false, "", "account reference is required"},
+		{"PayBill truncated", true, fourteen, true, thirteen, ""},
+		{"BuyGoods 13 chars", false, thirteen, false, thirteen, ""},
+		{"BuyGoods 14 chars", false, fourteen, false, "", "account reference must be between 0 and 13 characters, got 14"},
+		{"BuyGoods missing", false, "", false, "", ""},
+		{"BuyGoods truncated", false, fourteen, true, thirteen, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			cfg := newTestB2BConfig(abstracts.Sandbox)
+			cfg.SetQueueTimeoutURL("https://example.com/timeout")
+			cfg.SetResultURL("https://example.com/result")
+
+			var err error
+			if tt.paybill {
+				svc := Services.NewBusinessToPayBillService(cfg, client).
+					SetInitiator("testapi").
+					SetAmount(100).
+					SetPartyB("000001").
+					SetAccountReference(tt.ref)
+				if tt.truncate {
+					svc.TruncateAccountReference()
+				}
+				_, err = svc.Send()
+			} else {
+				svc := Services.NewBusinessBuyGoodsService(cfg, client).
+					SetInitiator("testapi").
+					SetAmount(100).
+					SetPartyB("000001").
+					SetAccountReference(tt.ref)
+				if tt.truncate {
+					svc.TruncateAccountReference()
+				}
+				_, err = svc.Send()
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			got, ok := client.LastPayload(mpesatest.AnyEndpoint)["AccountReference"]
+			if tt.expected == "" {
+				if ok {
+					t.Errorf("expected no AccountReference in payload, got %v", got)
+				}
+			} else if got != tt.expected {
+				t.Errorf("expected AccountReference %q, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func testInvoices(n int) []Services.Invoice {
+	invoices := make([]Services.Invoice, n)
+	for i := range invoices {
+		invoices[i] = Services.Invoice{
+			ExternalReference: fmt.Sprintf("INV-%05d", i),
+			BilledFullName:    "John Doe",
+			BilledPhoneNumber: "0722000000",
+			BilledPeriod:      "August 2021",
+			InvoiceName:       "Rent",
+			DueDate:           time.Date(2021, 9, 15, 0, 0, 0, 0, time.FixedZone("EAT", 3*60*60)),
+			AccountReference:  fmt.Sprintf("A%d", i),
+			Amount:            800,
+		}
+	}
+	return invoices
+}
+
+var billManagerOK = mpesatest.Response{Body: map[string]any{"rescode": "200", "resmsg": "Success"}}
+
+func TestBillManagerService_SendInvoicesPayload(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, billManagerOK)
+	invoices := testInvoices(1)
+	invoices[0].Amount = 800.5
+	invoices[0].InvoiceItems = []Services.InvoiceItem{{ItemName: "Water", Amount: 300.5}, {ItemName: "Rent", Amount: 500}}
+
+	responses, err := Services.NewBillManagerService(createTestConfig(), client).SendInvoices(context.Background(), invoices)
+	if err != nil {
+		t.Fatalf("SendInvoices error: %v", err)
+	}
+	if len(responses) != 1 || !responses[0].Succeeded() {
+		t.Fatalf("unexpected responses: %+v", responses)
+	}
+
+	batch := client.Calls()[0].Payload.([]any)
+	if len(batch) != 1 {
+		t.Fatalf("expected a batch of 1 invoice, got %d", len(batch))
+	}
+	invoice := batch[0].(map[string]any)
+	expected := map[string]any{
+		"externalReference": "INV-00000",
+		"billedFullName":    "John Doe",
+		"billedPhoneNumber": "254722000000",
+		"billedPeriod":      "August 2021",
+		"invoiceName":       "Rent",
+		"dueDate":           "2021-09-15 00:00:00.00",
+		"accountReference":  "A0",
+		"amount":            "800.5",
+	}
+	for key, want := range expected {
+		if invoice[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, invoice[key])
+		}
+	}
+	items := invoice["invoiceItems"].([]map[string]any)
+	if len(items) != 2 || items[0]["itemName"] != "Water" || items[0]["amount"] != "300.5" {
+		t.Errorf("unexpected invoice items: %v", items)
+	}
+}
+
+func TestBillManagerService_SendInvoicesChunking(t *testing.T) {
+	cases := []struct {
+		invoices int
+		chunks   []int
+	}{
+		{1, []int{1}},
+		{Services.MaxInvoicesPerRequest, []int{Services.MaxInvoicesPerRequest}},
+		{Services.MaxInvoicesPerRequest + 1, []int{Services.MaxInvoicesPerRequest, 1}},
+		{2*Services.MaxInvoicesPerRequest + 37, []int{Services.MaxInvoicesPerRequest, Services.MaxInvoicesPerRequest, 37}},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.invoices), func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			for range tc.chunks {
+				client.Enqueue(mpesatest.AnyEndpoint, billManagerOK)
+			}
+
+			responses, err := Services.NewBillManagerService(createTestConfig(), client).SendInvoices(context.Background(), testInvoices(tc.invoices))
+			if err != nil {
+				t.Fatalf("SendInvoices error: %v", err)
+			}
+			if len(responses) != len(tc.chunks) || client.Count(mpesatest.AnyEndpoint) != len(tc.chunks) {
+				t.Fatalf("expected %d chunks, got %d responses and %d requests", len(tc.chunks), len(responses), client.Count(mpesatest.AnyEndpoint))
+			}
+
+			next := 0
+			for i, size := range tc.chunks {
+				batch := client.Calls()[i].Payload.([]any)
+				if len(batch) != size {
+					t.Errorf("chunk %d: expected %d invoices, got %d", i, size, len(batch))
+				}
+				if ref := batch[0].(map[string]any)["externalReference"]; ref != fmt.Sprintf("INV-%05d", next) {
+					t.Errorf("chunk %d: expected to start at invoice %d, got %v", i, next, ref)
+				}
+				next += size
+			}
+		})
+	}
+}
+
+func TestBillManagerService_SendInvoicesMiddleChunkFails(t *testing.T) {
+	apiErr := errors.New("upstream unavailable")
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, billManagerOK, mpesatest.Response{Err: apiErr}, billManagerOK)
+
+	responses, err := Services.NewBillManagerService(createTestConfig(), client).
+		SendInvoices(context.Background(), testInvoices(2*Services.MaxInvoicesPerRequest+37))
+	if !errors.Is(err, apiErr) {
+		t.Fatalf("expected the chunk error to be returned, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "invoice chunk 2 (invoices 1000-1999)") {
+		t.Errorf("expected the failed chunk to be identified, got %v", err)
+	}
+	if len(responses) != 1 || client.Count(mpesatest.AnyEndpoint) != 2 {
+		t.Errorf("expected sending to stop after the failed chunk, got %d responses and %d requests", len(responses), client.Count(mpesatest.AnyEndpoint))
+	}
+
+	// A chunk rejected by Bill Manager stops sending as well.
+	rejected := mpesatest.Response{Body: map[string]any{"rescode": "400", "resmsg": "Invalid request"}}
+	client = mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, billManagerOK, rejected, billManagerOK)
+	responses, err = Services.NewBillManagerService(createTestConfig(), client).
+		SendInvoices(context.Background(), testInvoices(2*Services.MaxInvoicesPerRequest+37))
+	if err == nil || err.Error() != "invoice chunk 2 (invoices 1000-1999) was rejected: rescode 400: Invalid request" {
+		t.Fatalf("expected rejection error, got %v", err)
+	}
+	if len(responses) != 2 || responses[1].Succeeded() {
+		t.Errorf("expected the rejected response to be returned, got %+v", responses)
+	}
+}
+
+func TestBillManagerService_SendInvoicesValidation(t *testing.T) {
+	invoices := testInvoices(5)
+	invoices[1].BilledPhoneNumber = "12345"
+	invoices[3].Amount = 0
+	invoices[3].InvoiceName = ""
+	invoices[4].ExternalReference = "INV-00000"
+
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewBillManagerService(createTestConfig(), client).SendInvoices(context.Background(), invoices)
+	if !errors.Is(err, Services.ErrInvalidInvoice) {
+		t.Fatalf("expected ErrInvalidInvoice, got %v", err)
+	}
+	for _, want := range []string{
+		"invoice 1: invalid invoice: billed phone number: phone number is too short",
+		"invoice 3: invalid invoice: invoice name is required",
+		"invoice 3: invalid invoice: amount must be greater than 0",
+		`invoice 4: invalid invoice: external reference "INV-00000" is also used by invoice 0`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "invoice 0:") || strings.Contains(err.Error(), "invoice 2:") {
+		t.Errorf("expected valid invoices not to be reported, got:\n%v", err)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 0 {
+		t.Errorf("expected nothing to be sent")
+	}
+
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).SendInvoices(context.Background(), nil); err == nil {
+		t.Errorf("expected error for an empty batch")
+	}
+}
+
+func TestBillManagerService_SendInvoicesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// The context is cancelled as soon as the first chunk is sent.
+	client := mpesatest.NewRecordingClient().RespondWith(mpesatest.AnyEndpoint, func(any) (map[string]any, error) {
+		cancel()
+		return billManagerOK.Body, nil
+	})
+
+	responses, err := Services.NewBillManagerService(createTestConfig(), client).
+		SendInvoices(ctx, testInvoices(Services.MaxInvoicesPerRequest+1))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(responses) != 1 || client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Errorf("expected cancellation between chunks, got %d responses and %d requests", len(responses), client.Count(mpesatest.AnyEndpoint))
+	}
+}
+
+func TestBillManagerService_CancelInvoice(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "200", "resmsg": "Success"})
+	resp, err := Services.NewBillManagerService(createTestConfig(), client).CancelInvoice(" INV-00001 ")
+	if err != nil || !resp.Succeeded() {
+		t.Fatalf("expected success, got %+v, %v", resp, err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["externalReference"]; got != "INV-00001" {
+		t.Errorf("unexpected external reference: %v", got)
+	}
+	if client.LastEndpoint() != "/v1/billmanager-invoice/cancel-single-invoice" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	client = mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "404", "resmsg": "Invoice not found"})
+	resp, err = Services.NewBillManagerService(createTestConfig(), client).CancelInvoice("INV-99999")
+	if !errors.Is(err, Services.ErrInvoiceNotFound) || resp == nil || resp.ResMsg != "Invoice not found" {
+		t.Errorf("expected ErrInvoiceNotFound with response, got %+v, %v", resp, err)
+	}
+
+	client = mpesatest.NewRecordingClient()
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).CancelInvoice("  "); err == nil || err.Error() != "external reference is required" {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 0 {
+		t.Errorf("expected no request to be sent")
+	}
+}
+
+func TestBillManagerService_UpdateInvoice(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "200", "resmsg": "Success"})
+	due := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := Services.NewBillManagerService(createTestConfig(), client).
+		UpdateInvoice("INV-00001", Services.InvoiceUpdate{Amount: 950, DueDate: due})
+	if err != nil || !resp.Succeeded() {
+		t.Fatalf("expected success, got %+v, %v", resp, err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["externalReference"] != "INV-00001" || payload["amount"] != "950" || payload["dueDate"] != "2021-10-01 03:00:00.00" {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+	if client.LastEndpoint() != "/v1/billmanager-invoice/update-single-invoice" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	// Only the changed fields are sent.
+	client = mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "200"})
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).UpdateInvoice("INV-00001", Services.InvoiceUpdate{Amount: 1000}); err != nil {
+		t.Fatalf("UpdateInvoice error: %v", err)
+	}
+	if _, ok := client.LastPayload(mpesatest.AnyEndpoint)["dueDate"]; ok {
+		t.Errorf("expected unchanged due date to be omitted")
+	}
+
+	client = mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "404", "resmsg": "Invoice not found"})
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).UpdateInvoice("INV-99999", Services.InvoiceUpdate{Amount: 1000}); !errors.Is(err, Services.ErrInvoiceNotFound) {
+		t.Errorf("expected ErrInvoiceNotFound, got %v", err)
+	}
+}
+
+func TestBillManagerService_UpdateInvoiceValidation(t *testing.T) {
+	cases := []struct {
+		name    string
+		ref     string
+		changes Services.InvoiceUpdate
+		wantErr string
+	}{
+		{"missing reference", "", Services.InvoiceUpdate{Amount: 100}, "external reference is required"},
+		{"no changes", "INV-00001", Services.InvoiceUpdate{}, "no changes to apply; set InvoiceUpdate.Amount or InvoiceUpdate.DueDate"},
+		{"negative amount", "INV-00001", Services.InvoiceUpdate{Amount: -5}, "invalid amount: amount must be greater than 0"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewBillManagerService(createTestConfig(), client).UpdateInvoice(tc.ref, tc.changes)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+const billManagerPaymentJSON = `{
+  "transactionId": "RJB53MYR1N",
+  "paidAmount": "5000",
+  "msisdn": "254710119383",
+  "dateCreated": "2021-09-15",
+  "accountReference": "LGHJIO789",
+  "shortCode": "718003"
+}`
+
+func TestParseBillManagerPayment(t *testing.T) {
+	p, err := Services.ParseBillManagerPayment(decodeFixture(t, billManagerPaymentJSON))
+	if err != nil {
+		t.Fatalf("ParseBillManagerPayment error: %v", err)
+	}
+
+	if p.TransactionID != "RJB53MYR1N" || p.PaidAmount != 5000 || p.Msisdn != "254710119383" {
+		t.Errorf("unexpected payment: %+v", p)
+	}
+	if p.AccountReference != "LGHJIO789" || p.ShortCode != "718003" {
+		t.Errorf("unexpected references: %+v", p)
+	}
+	if !p.DateCreated.Equal(time.Date(2021, 9, 15, 0, 0, 0, 0, time.FixedZone("EAT", 3*60*60))) {
+		t.Errorf("unexpected payment date: %v", p.DateCreated)
+	}
+	if p.Raw == nil {
+		t.Errorf("expected raw payload to be kept")
+	}
+
+	if _, err := Services.ParseBillManagerPayment(map[string]any{"paidAmount": "5000"}); err == nil {
+		t.Errorf("expected error for notification without transactionId")
+	}
+}
+
+func TestBillManagerPaymentHandler(t *testing.T) {
+	var got *Services.BillManagerPayment
+	handler := Services.BillManagerPaymentHandler(func(p *Services.BillManagerPayment) { got = p })
+
+	rec := postWebhook(handler, billManagerPaymentJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"rescode":"200","resmsg":"Success"}` {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.TransactionID != "RJB53MYR1N" {
+		t.Fatalf("expected parsed payment, got %+v", got)
+	}
+
+	rec = postWebhook(handler, `{"paidAmount":"5000"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"rescode":"200","resmsg":"Success"}` {
+		t.Errorf("expected notification without transactionId to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	limited := Services.BillManagerPaymentHandler(nil, Services.WithMaxBodyBytes(32), Services.WithAckPolicy(Services.StrictAck))
+	if rec = postWebhook(limited, billManagerPaymentJSON); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", rec.Code)
+	}
+}
+
+func TestBillManagerService_AcknowledgePayment(t *testing.T) {
+	p, err := Services.ParseBillManagerPayment(decodeFixture(t, billManagerPaymentJSON))
+	if err != nil {
+		t.Fatalf("ParseBillManagerPayment error: %v", err)
+	}
+	p.FullName = "John Doe"
+	p.InvoiceName = "School Fees"
+	p.ExternalReference = "955"
+
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "200", "resmsg": "Success"})
+	resp, err := Services.NewBillManagerService(createTestConfig(), client).AcknowledgePayment(*p)
+	if err != nil || !resp.Succeeded() {
+		t.Fatalf("expected success, got %+v, %v", resp, err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"transactionId":     "RJB53MYR1N",
+		"paidAmount":        "5000",
+		"phoneNumber":       "254710119383",
+		"paymentDate":       "2021-09-15",
+		"accountReference":  "LGHJIO789",
+		"fullName":          "John Doe",
+		"invoiceName":       "School Fees",
+		"externalReference": "955",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+	if client.LastEndpoint() != "/v1/billmanager-invoice/reconciliation" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	client = mpesatest.NewRecordingClient()
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).AcknowledgePayment(Services.BillManagerPayment{}); err == nil {
+		t.Errorf("expected validation error for an empty payment")
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 0 {
+		t.Errorf("expected no request to be sent")
+	}
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func validOnboardParams() Services.OnboardParams {
+	return Services.OnboardParams{
+		ShortCode:       "718003",
+		Email:           "billing@example.com",
+		OfficialContact: "0710000000",
+		SendReminders:   true,
+		Logo:            "https://example.com/logo.png",
+		CallbackURL:     "https://example.com/mpesa/billmanager",
+	}
+}
+
+func TestBillManagerService_Onboard(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"app_key": "AG_2376487236_126732989KJ",
+		"resmsg":  "Success",
+		"rescode": "200",
+	})
+	service := Services.NewBillManagerService(createTestConfig(), client)
+
+	resp, err := service.Onboard(validOnboardParams())
+	if err != nil {
+		t.Fatalf("Onboard error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"shortcode":       "718003",
+		"email":           "billing@example.com",
+		"officialContact": "254710000000",
+		"sendReminders":   "1",
+		"logo":            "https://example.com/logo.png",
+		"callbackurl":     "https://example.com/mpesa/billmanager",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+	if client.LastEndpoint() != "/v1/billmanager-invoice/optin" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	if !resp.Succeeded() || resp.AppKey != "AG_2376487236_126732989KJ" || resp.ResMsg != "Success" {
+		t.Errorf("unexpected typed response: %+v", resp)
+	}
+	if service.GetTypedResponse() != resp || service.GetResponse() == nil {
+		t.Errorf("expected responses to be stored on the service")
+	}
+}
+
+func TestBillManagerService_OnboardDefaults(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"rescode": "200"})
+	params := validOnboardParams()
+	params.ShortCode = ""
+	params.SendReminders = false
+
+	if _, err := Services.NewBillManagerService(createTestConfig(), client).Onboard(params); err != nil {
+		t.Fatalf("Onboard error: %v", err)
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["shortcode"] != "174379" || payload["sendReminders"] != "0" {
+		t.Errorf("expected config shortcode and reminders off, got %v / %v", payload["shortcode"], payload["sendReminders"])
+	}
+}
+
+func TestBillManagerService_OnboardValidation(t *testing.T) {
+	noBusinessCode, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		name    string
+		cfg     *abstracts.MpesaConfig
+		modify  func(*Services.OnboardParams)
+		wantErr string
+	}{
+		{"missing shortcode", noBusinessCode, func(p *Services.OnboardParams) { p.ShortCode = "" },
+			"shortcode is required; set OnboardParams.ShortCode or call SetBusinessCode on mpesa config"},
+		{"non-numeric shortcode", createTestConfig(), func(p *Services.OnboardParams) { p.ShortCode = "71800A" },
+			`invalid shortcode "71800A": must be numeric`},
+		{"missing email", createTestConfig(), func(p *Services.OnboardParams) { p.Email = "" },
+			"email is required"},
+		{"email without domain", createTestConfig(), func(p *Services.OnboardParams) { p.Email = "billing@" },
+			`invalid email "billing@": must be an address like name@example.com`},
+		{"email with display name", createTestConfig(), func(p *Services.OnboardParams) { p.Email = "Billing <billing@example.com>" },
+			`invalid email "Billing <billing@example.com>": must be an address like name@example.com`},
+		{"email without top level domain", createTestConfig(), func(p *Services.OnboardParams) { p.Email = "billing@localhost" },
+			`invalid email "billing@localhost": must be an address like name@example.com`},
+		{"missing official contact", createTestConfig(), func(p *Services.OnboardParams) { p.OfficialContact = "" },
+			"official contact is required; set OnboardParams.OfficialContact"},
+		{"invalid official contact", createTestConfig(), func(p *Services.OnboardParams) { p.OfficialContact = "0510000000" },
+			"invalid official contact: phone number must be a Kenyan mobile number in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+		{"missing callback URL", createTestConfig(), func(p *Services.OnboardParams) { p.CallbackURL = "" },
+			"callback URL is required; set OnboardParams.CallbackURL"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			params := validOnboardParams()
+			tc.modify(&params)
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewBillManagerService(tc.cfg, client).Onboard(params)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
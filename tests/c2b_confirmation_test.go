@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const c2bConfirmationLegacyJSON = `{
+  "TransactionType": "Pay Bill",
+  "TransID": "RKTQDM7W6S",
+  "TransTime": "20191122063845",
+  "TransAmount": "10",
+  "BusinessShortCode": "600638",
+  "BillRefNumber": "A123",
+  "InvoiceNumber": "",
+  "OrgAccountBalance": "49197.00",
+  "ThirdPartyTransID": "",
+  "MSISDN": "254708374149",
+  "FirstName": "John",
+  "MiddleName": "",
+  "LastName": "Doe"
+}`
+
+const c2bConfirmationHashedJSON = `{
+  "TransactionType": "Pay Bill",
+  "TransID": "SHK7A8Z9XY",
+  "TransTime": "20240815143022",
+  "TransAmount": 1500.50,
+  "BusinessShortCode": 600638,
+  "BillRefNumber": "INV-42",
+  "InvoiceNumber": "",
+  "OrgAccountBalance": "",
+  "ThirdPartyTransID": "",
+  "MSISDN": "2ef8f7a8c6a2d3f0e4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7",
+  "FirstName": "JANE"
+}`
+
+func TestParseC2BConfirmation_LegacyClearMSISDN(t *testing.T) {
+	c, err := Services.ParseC2BConfirmation(strings.NewReader(c2bConfirmationLegacyJSON))
+	if err != nil {
+		t.Fatalf("ParseC2BConfirmation error: %v", err)
+	}
+
+	if c.TransID != "RKTQDM7W6S" || c.TransactionType != "Pay Bill" || c.BillRefNumber != "A123" {
+		t.Errorf("unexpected fields: %+v", c)
+	}
+	if c.TransAmount != 10 || c.OrgAccountBalance != 49197 {
+		t.Errorf("unexpected amounts: %v / %v", c.TransAmount, c.OrgAccountBalance)
+	}
+	expected := time.Date(2019, 11, 22, 6, 38, 45, 0, time.FixedZone("EAT", 3*60*60))
+	if !c.TransTime.Equal(expected) {
+		t.Errorf("expected TransTime %v, got %v", expected, c.TransTime)
+	}
+	if c.MSISDN != "254708374149" || c.MSISDNHashed {
+		t.Errorf("expected clear MSISDN, got %q (hashed=%v)", c.MSISDN, c.MSISDNHashed)
+	}
+	if c.FirstName != "John" || c.LastName != "Doe" {
+		t.Errorf("unexpected names: %s %s", c.FirstName, c.LastName)
+	}
+}
+
+func TestParseC2BConfirmation_HashedMSISDN(t *testing.T) {
+	c, err := Services.ParseC2BConfirmation(strings.NewReader(c2bConfirmationHashedJSON))
+	if err != nil {
+		t.Fatalf("ParseC2BConfirmation error: %v", err)
+	}
+
+	if c.TransAmount != 1500.50 {
+		t.Errorf("expected numeric amount 1500.50, got %v", c.TransAmount)
+	}
+	if c.BusinessShortCode != "600638" {
+		t.Errorf("expected numeric shortcode as string, got %q", c.BusinessShortCode)
+	}
+	if !c.MSISDNHashed {
+		t.Errorf("expected MSISDN to be flagged as hashed")
+	}
+	if c.OrgAccountBalance != 0 {
+		t.Errorf("expected empty balance to decode as 0, got %v", c.OrgAccountBalance)
+	}
+}
+
+func TestParseC2BConfirmation_Invalid(t *testing.T) {
+	for _, body := range []string{`not json`, `{"TransAmount": "10"}`, `{"TransID": "X", "TransTime": "22-11-2019"}`} {
+		if _, err := Services.ParseC2BConfirmation(strings.NewReader(body)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
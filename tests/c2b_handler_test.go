@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const c2bConfirmationAckBody = `{"ResultCode":0,"ResultDesc":"Success"}`
+
+func TestC2BValidationHandler_Accept(t *testing.T) {
+	handler := Services.C2BValidationHandler(func(c *Services.C2BConfirmation) Services.C2BValidationResponse {
+		return Services.AcceptWithThirdPartyID("ORDER-" + c.BillRefNumber)
+	})
+
+	rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != `{"ResultCode":"0","ResultDesc":"Accepted","ThirdPartyTransID":"ORDER-A123"}` {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+}
+
+func TestC2BValidationHandler_RejectWithCode(t *testing.T) {
+	handler := Services.C2BValidationHandler(func(c *Services.C2BConfirmation) Services.C2BValidationResponse {
+		return Services.Reject(Services.RejectInvalidAccountNumber)
+	})
+
+	rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+	if rec.Body.String() != `{"ResultCode":"C2B00012","ResultDesc":"Rejected"}` {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+}
+
+func TestC2BValidationHandler_Panic(t *testing.T) {
+	var reported error
+	handler := Services.C2BValidationHandler(
+		func(c *Services.C2BConfirmation) Services.C2BValidationResponse {
+			panic("database password is hunter2")
+		},
+		Services.WithErrorHandler(func(err error, r *http.Request) { reported = err }),
+	)
+
+	rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"ResultCode":"C2B00016","ResultDesc":"Rejected"}` {
+		t.Fatalf("expected generic rejection, got %d %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "hunter2") {
+		t.Errorf("internal error text leaked to M-Pesa")
+	}
+	if reported == nil || !strings.Contains(reported.Error(), "hunter2") {
+		t.Errorf("expected panic to be reported to the error handler, got %v", reported)
+	}
+}
+
+func TestC2BValidationHandler_Timeout(t *testing.T) {
+	handler := Services.C2BValidationHandler(
+		func(c *Services.C2BConfirmation) Services.C2BValidationResponse {
+			time.Sleep(200 * time.Millisecond)
+			return Services.AcceptC2BValidation()
+		},
+		Services.WithTimeout(20*time.Millisecond),
+	)
+
+	rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+	if rec.Body.String() != `{"ResultCode":"C2B00016","ResultDesc":"Rejected"}` {
+		t.Errorf("expected rejection on timeout, got %s", rec.Body.String())
+	}
+}
+
+func TestC2BConfirmationHandler_Acknowledges(t *testing.T) {
+	var got *Services.C2BConfirmation
+	handler := Services.C2BConfirmationHandler(func(c *Services.C2BConfirmation) error {
+		got = c
+		return nil
+	})
+
+	rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != c2bConfirmationAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.TransID != "RKTQDM7W6S" {
+		t.Errorf("expected parsed confirmation, got %+v", got)
+	}
+}
+
+func TestC2BConfirmationHandler_ErrorsAndPanicsAreNotLeaked(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(*Services.C2BConfirmation) error
+	}{
+		{"Error", func(*Services.C2BConfirmation) error { return errors.New("pq: duplicate key value") }},
+		{"Panic", func(*Services.C2BConfirmation) error { panic("pq: connection refused") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported error
+			handler := Services.C2BConfirmationHandler(tt.fn,
+				Services.WithErrorHandler(func(err error, r *http.Request) { reported = err }))
+
+			rec := postWebhook(handler, c2bConfirmationLegacyJSON)
+			if rec.Code != http.StatusOK || rec.Body.String() != c2bConfirmationAckBody {
+				t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+			}
+			if reported == nil || !strings.Contains(reported.Error(), "pq:") {
+				t.Errorf("expected error to be reported, got %v", reported)
+			}
+		})
+	}
+}
+
+func TestC2BHandlers_Limits(t *testing.T) {
+	handler := Services.C2BConfirmationHandler(func(*Services.C2BConfirmation) error { return nil },
+		Services.WithMaxBodyBytes(32), Services.WithAckPolicy(Services.StrictAck))
+
+	req := httptest.NewRequest(http.MethodGet, "/mpesa/c2b/confirmation", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+	if rec := postWebhook(handler, c2bConfirmationLegacyJSON); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", rec.Code)
+	}
+}
+
+func TestC2BConfirmationHandler_Deduplicates(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		invocations int
+		duplicates  int32
+	)
+	release := make(chan struct{})
+	handler := Services.C2BConfirmationHandler(
+		func(c *Services.C2BConfirmation) error {
+			mu.Lock()
+			invocations++
+			mu.Unlock()
+			<-release
+			return nil
+		},
+		Services.WithProcessedStore(Services.NewMemoryProcessedStore(), time.Hour),
+		Services.WithOnDuplicate(func(id string) { atomic.AddInt32(&duplicates, 1) }),
+	)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = postWebhook(handler, c2bConfirmationLegacyJSON).Code
+		}(i)
+	}
+	// Let the winning delivery finish once the duplicate has been skipped.
+	for atomic.LoadInt32(&duplicates) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Errorf("expected both deliveries to be acknowledged, got %v", codes)
+	}
+	if invocations != 1 || duplicates != 1 {
+		t.Errorf("expected exactly one invocation and one duplicate, got %d / %d", invocations, duplicates)
+	}
+
+	// A later redelivery is still deduplicated.
+	postWebhook(handler, c2bConfirmationLegacyJSON)
+	if invocations != 1 {
+		t.Errorf("expected redelivery to be skipped, got %d invocations", invocations)
+	}
+}
+
+func TestC2BConfirmationHandler_NoTransIDIsNotDeduplicated(t *testing.T) {
+	calls := 0
+	store := Services.NewMemoryProcessedStore()
+	handler := Services.C2BConfirmationHandler(
+		func(c *Services.C2BConfirmation) error { calls++; return nil },
+		Services.WithProcessedStore(store, time.Hour),
+	)
+
+	body := strings.Replace(c2bConfirmationLegacyJSON, `"RKTQDM7W6S"`, `" "`, 1)
+	postWebhook(handler, body)
+	postWebhook(handler, strings.Replace(body, `"10"`, `"25"`, 1))
+	if calls != 2 || store.Len() != 0 {
+		t.Errorf("expected confirmations without a TransID to bypass deduplication, got %d calls and %d stored IDs", calls, store.Len())
+	}
+	postWebhook(handler, c2bConfirmationLegacyJSON)
+	if calls != 3 {
+		t.Errorf("expected a confirmation with a TransID to be handled after them, got %d calls", calls)
+	}
+}
+
+func TestC2BConfirmationHandler_RetriesAfterFailure(t *testing.T) {
+	calls := 0
+	handler := Services.C2BConfirmationHandler(
+		func(c *Services.C2BConfirmation) error {
+			calls++
+			if calls == 1 {
+				return errors.New("temporary failure")
+			}
+			return nil
+		},
+		Services.WithProcessedStore(Services.NewMemoryProcessedStore(), time.Hour),
+	)
+
+	postWebhook(handler, c2bConfirmationLegacyJSON)
+	postWebhook(handler, c2bConfirmationLegacyJSON)
+	if calls != 2 {
+		t.Errorf("expected failed confirmation to be processed again, got %d calls", calls)
+	}
+}
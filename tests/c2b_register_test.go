@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func newTestC2BService(client abstracts.MpesaInterface) *Services.CustomerToBusinessService {
+	return Services.NewCustomerToBusinessService(buildTestConfig(), client).
+		SetConfirmationURL("https://example.com/c2b/confirmation").
+		SetValidationURL("https://example.com/c2b/validation")
+}
+
+func TestC2BRegisterURLs_Success(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"OriginatorCoversationID": "7619-37765134-1",
+		"ResponseCode":            "0",
+		"ResponseDescription":     "success",
+	})
+	service := newTestC2BService(client)
+
+	if err := service.RegisterURLs(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp := service.GetRegisterResponse()
+	if resp == nil || !resp.Success() {
+		t.Fatalf("expected successful register response, got %+v", resp)
+	}
+	if resp.OriginatorCoversationID != "7619-37765134-1" {
+		t.Errorf("unexpected OriginatorCoversationID %q", resp.OriginatorCoversationID)
+	}
+}
+
+func TestC2BRegisterURLs_AlreadyRegistered(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *mpesatest.RecordingClient
+	}{
+		{"HTTP error", mpesatest.NewRecordingClient().Fail(mpesatest.AnyEndpoint, &abstracts.APIError{
+			StatusCode:   500,
+			ErrorCode:    "500.003.1001",
+			ErrorMessage: "Urls are already registered",
+		})},
+		{"Error body", mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+			"requestId":    "11728-2929992-1",
+			"errorCode":    "500.003.1001",
+			"errorMessage": "Urls are already registered",
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newTestC2BService(tt.client).RegisterURLs()
+			if !errors.Is(err, Services.ErrURLsAlreadyRegistered) {
+				t.Fatalf("expected ErrURLsAlreadyRegistered, got %v", err)
+			}
+			var apiErr *abstracts.APIError
+			if !errors.As(err, &apiErr) || apiErr.ErrorCode != "500.003.1001" {
+				t.Errorf("expected wrapped APIError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestC2BRegisterURLs_Failure(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"OriginatorCoversationID": "7619-37765134-2",
+		"ResponseCode":            "1",
+		"ResponseDescription":     "Invalid ShortCode",
+	})
+
+	err := newTestC2BService(client).RegisterURLs()
+	if err == nil || errors.Is(err, Services.ErrURLsAlreadyRegistered) {
+		t.Fatalf("expected generic failure, got %v", err)
+	}
+	var apiErr *abstracts.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected APIError, got %T", err)
+	}
+	if apiErr.ErrorCode != "1" || apiErr.ErrorMessage != "Invalid ShortCode" {
+		t.Errorf("unexpected APIError %+v", apiErr)
+	}
+	if err.Error() != "URL registration failed: API error (200): Invalid ShortCode" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestC2B_SetShortCode(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ResponseCode": "0", "ResponseDescription": "Success"})
+	service := newTestC2BService(client).SetShortCode("600999")
+
+	if err := service.RegisterURLs(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["ShortCode"]; got != "600999" {
+		t.Errorf("expected overridden ShortCode in register payload, got %v", got)
+	}
+
+	service.SetCommandID("CustomerPayBillOnline").SetAmount("100").SetPhoneNumber("0708374149")
+	if _, err := service.Simulate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["ShortCode"]; got != "600999" {
+		t.Errorf("expected overridden ShortCode in simulate payload, got %v", got)
+	}
+	if service.Config.GetBusinessCode() != "603021" {
+		t.Errorf("expected config business code to be untouched, got %s", service.Config.GetBusinessCode())
+	}
+}
+
+func TestC2B_SetShortCode_Validation(t *testing.T) {
+	tests := []struct {
+		code        string
+		expectError bool
+	}{
+		{"12345", false},
+		{"1234567", false},
+		{"1234", true},
+		{"12345678", true},
+		{"60A999", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			err := newTestC2BService(client).SetShortCode(tt.code).RegisterURLs()
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error=%v, got %v", tt.expectError, err)
+			}
+			if tt.expectError && client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
+
+func TestC2BEnsureURLsRegistered(t *testing.T) {
+	tests := []struct {
+		name           string
+		client         *mpesatest.RecordingClient
+		expectRegister bool
+		expectSkip     bool
+		expectError    bool
+	}{
+		{"Fresh registration", mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+			"OriginatorCoversationID": "7619-37765134-1",
+			"ResponseCode":            "0",
+			"ResponseDescription":     "success",
+		}), true, false, false},
+		{"Already registered", mpesatest.NewRecordingClient().Fail(mpesatest.AnyEndpoint, &abstracts.APIError{
+			StatusCode:   500,
+			ErrorCode:    "500.003.1001",
+			ErrorMessage: "Urls are already registered",
+		}), false, true, false},
+		{"Hard failure", mpesatest.NewRecordingClient().Fail(mpesatest.AnyEndpoint, &abstracts.APIError{
+			StatusCode:   400,
+			ErrorCode:    "400.002.02",
+			ErrorMessage: "Bad Request - Invalid ShortCode",
+		}), false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := newTestC2BService(tt.client).EnsureURLsRegistered()
+			if tt.expectError != (err != nil) {
+				t.Fatalf("expected error=%v, got %v", tt.expectError, err)
+			}
+			if state.Registered != tt.expectRegister || state.Skipped != tt.expectSkip {
+				t.Errorf("unexpected state %+v", state)
+			}
+			if state.ShortCode != "603021" || state.ConfirmationURL != "https://example.com/c2b/confirmation" {
+				t.Errorf("expected requested values in state, got %+v", state)
+			}
+			if tt.expectRegister && (state.Response == nil || !state.Response.Success()) {
+				t.Errorf("expected register response in state")
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func newTestC2BSimulation(env abstracts.Environment, client abstracts.MpesaInterface) *Services.CustomerToBusinessService {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", env, nil, nil, nil, nil, nil)
+	cfg.SetBusinessCode("600638")
+	return Services.NewCustomerToBusinessService(cfg, client).
+		SetCommandID("CustomerPayBillOnline").
+		SetAmount("100").
+		SetPhoneNumber("254708374149").
+		SetBillRefNumber("INVOICE123")
+}
+
+func TestC2BSimulate_Environments(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         abstracts.Environment
+		allow       bool
+		expectError error
+	}{
+		{"Sandbox", abstracts.Sandbox, false, nil},
+		{"Production", abstracts.Production, false, Services.ErrSandboxOnly},
+		{"Production with override", abstracts.Production, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestC2BSimulation(tt.env, client)
+			if tt.allow {
+				service.AllowInProduction()
+			}
+
+			_, err := service.Simulate()
+			if !errors.Is(err, tt.expectError) {
+				t.Fatalf("expected error %v, got %v", tt.expectError, err)
+			}
+			expectedCalls := 1
+			if tt.expectError != nil {
+				expectedCalls = 0
+			}
+			if client.Count(mpesatest.AnyEndpoint) != expectedCalls {
+				t.Errorf("expected %d requests, got %d", expectedCalls, client.Count(mpesatest.AnyEndpoint))
+			}
+		})
+	}
+}
+
+func TestC2BSimulate_SetPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		expected    string
+		expectError bool
+	}{
+		{"Valid phone number with country code", "254111844429", "254111844429", false},
+		{"Valid phone number without country code", "0111844429", "254111844429", false},
+		{"Valid international phone number", "+254711223344", "254711223344", false},
+		{"Valid bare phone number", "711223344", "254711223344", false},
+		{"Valid bare 01 phone number", "110123456", "254110123456", false},
+		{"Bare number not starting with 7 or 1", "812345678", "", true},
+		{"Empty phone number", "", "", true},
+		{"Short phone number", "123", "", true},
+		{"Landline number", "0201234567", "", true},
+		{"Too long phone number", "2547112233445", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestC2BSimulation(abstracts.Sandbox, client).SetPhoneNumber(tt.phoneNumber)
+
+			_, err := service.Simulate()
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error for %q", tt.phoneNumber)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := client.LastPayload(mpesatest.AnyEndpoint)["Msisdn"]; got != tt.expected {
+				t.Errorf("expected Msisdn %s, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestC2BSimulate_SetRawPhoneNumber(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	service := newTestC2BSimulation(abstracts.Sandbox, client).SetRawPhoneNumber("15551234567")
+
+	if _, err := service.Simulate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["Msisdn"]; got != "15551234567" {
+		t.Errorf("expected raw Msisdn, got %v", got)
+	}
+}
+
+func TestC2BSimulate_AmountValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(*Services.CustomerToBusinessService)
+		expected    int
+		expectError string
+	}{
+		{"Plain amount", func(s *Services.CustomerToBusinessService) { s.SetAmount("100") }, 100, ""},
+		{"Comma separated", func(s *Services.CustomerToBusinessService) { s.SetAmount("1,000") }, 1000, ""},
+		{"Space separated", func(s *Services.CustomerToBusinessService) { s.SetAmount(" 25 000 ") }, 25000, ""},
+		{"Int setter", func(s *Services.CustomerToBusinessService) { s.SetAmountInt(250) }, 250, ""},
+		{"Float setter", func(s *Services.CustomerToBusinessService) { s.SetAmountFloat(99.0) }, 99, ""},
+		{"Cents", func(s *Services.CustomerToBusinessService) { s.SetAmountFloat(99.5) }, 0, "fractional part"},
+		{"At minimum", func(s *Services.CustomerToBusinessService) { s.SetAmount("1") }, 1, ""},
+		{"At maximum", func(s *Services.CustomerToBusinessService) { s.SetAmount("250000") }, 250000, ""},
+		{"Above maximum", func(s *Services.CustomerToBusinessService) { s.SetAmount("250001") }, 0, `"250001"`},
+		{"Below custom minimum", func(s *Services.CustomerToBusinessService) {
+			s.SetAmountLimits(10, 0).SetAmount("9")
+		}, 0, `"9"`},
+		{"Maximum disabled", func(s *Services.CustomerToBusinessService) {
+			s.SetAmountLimits(10, 0).SetAmount("1000000")
+		}, 1000000, ""},
+		{"Zero", func(s *Services.CustomerToBusinessService) { s.SetAmount("0") }, 0, `"0"`},
+		{"Negative", func(s *Services.CustomerToBusinessService) { s.SetAmount("-5") }, 0, `"-5"`},
+		{"Letters", func(s *Services.CustomerToBusinessService) { s.SetAmount("abc") }, 0, `"abc"`},
+		{"Mixed", func(s *Services.CustomerToBusinessService) { s.SetAmount("10KES") }, 0, `"10KES"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			service := newTestC2BSimulation(abstracts.Sandbox, client)
+			tt.configure(service)
+
+			_, err := service.Simulate()
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("expected error naming %s, got %v", tt.expectError, err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) != 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := client.LastPayload(mpesatest.AnyEndpoint)["Amount"]; got != tt.expected {
+				t.Errorf("expected Amount %d, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestC2BSimulateTyped(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, decodeFixture(t, `{
+	  "OriginatorCoversationID": "53e3-4aa8-9fe0-8fb5e4092cdd3405976",
+	  "ConversationID": "AG_20191219_00004e48cf7e3533f581",
+	  "ResponseCode": "0",
+	  "ResponseDescription": "Accept the service request successfully."
+	}`))
+	service := newTestC2BSimulation(abstracts.Sandbox, client)
+
+	resp, err := service.SimulateTyped()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !resp.Accepted() {
+		t.Errorf("expected accepted response")
+	}
+	if resp.OriginatorCoversationID != "53e3-4aa8-9fe0-8fb5e4092cdd3405976" {
+		t.Errorf("expected misspelled key to be decoded, got %q", resp.OriginatorCoversationID)
+	}
+	if resp.ConversationID != "AG_20191219_00004e48cf7e3533f581" || resp.ResponseDescription != "Accept the service request successfully." {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if service.GetResponse()["ConversationID"] != resp.ConversationID {
+		t.Errorf("expected raw response to be stored")
+	}
+}
+
+func TestC2B_CommandIDAndResponseTypeValidation(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+
+	for _, cmd := range []Services.C2BCommandID{Services.CommandCustomerPayBillOnline, Services.CommandCustomerBuyGoodsOnline} {
+		if _, err := newTestC2BSimulation(abstracts.Sandbox, client).SetTypedCommandID(cmd).Simulate(); err != nil {
+			t.Errorf("expected %s to be valid, got %v", cmd, err)
+		}
+	}
+	if _, err := newTestC2BSimulation(abstracts.Sandbox, client).SetCommandID("CustomerBuyGoodsOnline").Simulate(); err != nil {
+		t.Errorf("expected the plain string command ID to be valid, got %v", err)
+	}
+
+	_, err := newTestC2BSimulation(abstracts.Sandbox, client).SetCommandID("CustomerPaybillOnline").Simulate()
+	if err == nil || !strings.Contains(err.Error(), "CustomerPayBillOnline") {
+		t.Errorf("expected invalid command ID error listing valid values, got %v", err)
+	}
+	if _, err := newTestC2BSimulation(abstracts.Sandbox, client).SetRawCommandID("FutureCommand").Simulate(); err != nil {
+		t.Errorf("expected raw command ID to bypass validation, got %v", err)
+	}
+
+	register := mpesatest.NewRecordingClient()
+	if err := newTestC2BService(register).RegisterURLs(); err != nil {
+		t.Fatalf("expected default response type to be valid, got %v", err)
+	}
+	if got := register.LastPayload(mpesatest.AnyEndpoint)["ResponseType"]; got != "Completed" {
+		t.Errorf("expected default ResponseType Completed, got %v", got)
+	}
+	if err := newTestC2BService(register).SetTypedResponseType(Services.ResponseTypeCancelled).RegisterURLs(); err != nil {
+		t.Errorf("expected typed response type to be valid, got %v", err)
+	}
+	if got := register.LastPayload(mpesatest.AnyEndpoint)["ResponseType"]; got != "Cancelled" {
+		t.Errorf("expected ResponseType Cancelled, got %v", got)
+	}
+	if err := newTestC2BService(register).SetResponseType("completed").RegisterURLs(); err == nil {
+		t.Errorf("expected invalid response type error")
+	}
+	if err := newTestC2BService(register).SetRawResponseType("completed").RegisterURLs(); err != nil {
+		t.Errorf("expected raw response type to bypass validation, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func TestC2BValidationResponse_Golden(t *testing.T) {
+	tests := []struct {
+		golden   string
+		response Services.C2BValidationResponse
+	}{
+		{"c2b_validation_accept.golden.json", Services.AcceptC2BValidation()},
+		{"c2b_validation_accept_third_party.golden.json", Services.AcceptWithThirdPartyID("ORDER-1234")},
+		{"c2b_validation_reject_account.golden.json", Services.Reject(Services.RejectInvalidAccountNumber)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", tt.golden))
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			got, err := json.Marshal(tt.response)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+			if !bytes.Equal(got, bytes.TrimSpace(want)) {
+				t.Errorf("expected %s, got %s", bytes.TrimSpace(want), got)
+			}
+		})
+	}
+}
+
+func TestC2BRejectionCodes(t *testing.T) {
+	codes := map[Services.RejectionCode]string{
+		Services.RejectInvalidMSISDN:        "C2B00011",
+		Services.RejectInvalidAccountNumber: "C2B00012",
+		Services.RejectInvalidAmount:        "C2B00013",
+		Services.RejectInvalidKYCDetails:    "C2B00014",
+		Services.RejectInvalidShortcode:     "C2B00015",
+		Services.RejectOtherError:           "C2B00016",
+	}
+	for code, want := range codes {
+		resp := Services.Reject(code)
+		if resp.ResultCode != want || resp.Accepted() {
+			t.Errorf("expected rejection %s, got %+v", want, resp)
+		}
+	}
+}
+
+func TestParseC2BValidation(t *testing.T) {
+	v, err := Services.ParseC2BValidation(strings.NewReader(c2bConfirmationLegacyJSON))
+	if err != nil {
+		t.Fatalf("ParseC2BValidation error: %v", err)
+	}
+	if v.BillRefNumber != "A123" || v.TransAmount != 10 {
+		t.Errorf("unexpected validation payload %+v", v)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func postWithContentType(handler http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/stk", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCallbackContentTypes(t *testing.T) {
+	form := url.Values{"payload": {stkCallbackSuccessJSON}}.Encode()
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"JSON", "application/json", stkCallbackSuccessJSON},
+		{"JSON with charset", "application/json;charset=UTF-8", stkCallbackSuccessJSON},
+		{"Plain text", "text/plain", stkCallbackSuccessJSON},
+		{"Plain text with charset", "text/plain; charset=ISO-8859-1", stkCallbackSuccessJSON},
+		{"Form content type", "application/x-www-form-urlencoded", stkCallbackSuccessJSON},
+		{"Missing", "", stkCallbackSuccessJSON},
+		{"Byte order mark", "application/json", "\xef\xbb\xbf\r\n" + stkCallbackSuccessJSON},
+		{"Form field", "application/x-www-form-urlencoded", form},
+		{"Form field with charset", "application/x-www-form-urlencoded; charset=UTF-8", form},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Services.STKCallback
+			handler := Services.STKCallbackHandler(func(cb *Services.STKCallback) { got = cb },
+				Services.WithFormField("payload"), Services.WithAckPolicy(Services.StrictAck))
+
+			rec := postWithContentType(handler, tt.contentType, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+			}
+			if got == nil || got.CheckoutRequestID != "ws_CO_191220191020363925" {
+				t.Errorf("unexpected callback %+v", got)
+			}
+		})
+	}
+}
+
+func TestCallbackContentTypes_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []Services.HandlerOption
+	}{
+		{"Form without field option", "application/x-www-form-urlencoded", url.Values{"payload": {stkCallbackSuccessJSON}}.Encode(), nil},
+		{"Missing form field", "application/x-www-form-urlencoded", "other=1", []Services.HandlerOption{Services.WithFormField("payload")}},
+		{"Malformed form field", "application/x-www-form-urlencoded", "payload=%7Bnot-json", []Services.HandlerOption{Services.WithFormField("payload")}},
+		{"Unsupported", "application/xml", "<Body/>", nil},
+		{"Malformed JSON", "text/plain", `{"Body":`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorder errorRecorder
+			called := false
+			onCallback := func(*Services.STKCallback) { called = true }
+
+			opts := append([]Services.HandlerOption{Services.WithErrorHandler(recorder.hook)}, tt.opts...)
+			rec := postWithContentType(Services.STKCallbackHandler(onCallback, opts...), tt.contentType, tt.body)
+			if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+				t.Errorf("expected malformed body to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+			}
+			if len(recorder.list()) != 1 || called {
+				t.Errorf("expected the error to be reported without a callback, got %v called=%v", recorder.list(), called)
+			}
+
+			strict := append(opts, Services.WithAckPolicy(Services.StrictAck))
+			rec = postWithContentType(Services.STKCallbackHandler(onCallback, strict...), tt.contentType, tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 under StrictAck, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func TestDispatcher_FanOut(t *testing.T) {
+	events := Services.NewDispatcher()
+	var got []string
+	events.OnStkResult(func(cb *Services.STKCallback) { got = append(got, "stk-1:"+cb.CheckoutRequestID) })
+	events.OnStkResult(func(cb *Services.STKCallback) { got = append(got, "stk-2:"+cb.CheckoutRequestID) })
+	events.OnC2BConfirmation(func(c *Services.C2BConfirmation) { got = append(got, "c2b:"+c.TransID) })
+	events.OnB2CResult(func(res *Services.B2CResult) { got = append(got, "b2c") })
+	events.OnB2CResult(func(res *Services.B2CResult) { got = append(got, "b2c") })
+	handler := events.Handler()
+
+	if rec := postWebhookTo(handler, Services.DefaultSTKCallbackPath, stkCallbackSuccessJSON); rec.Body.String() != webhookAckBody {
+		t.Errorf("unexpected STK response %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := postWebhookTo(handler, Services.DefaultC2BConfirmationPath, c2bConfirmationLegacyJSON); rec.Body.String() != c2bConfirmationAckBody {
+		t.Errorf("unexpected C2B response %d %s", rec.Code, rec.Body.String())
+	}
+	postWebhookTo(handler, Services.DefaultB2CResultPath, b2cResultSuccessJSON)
+
+	want := []string{"stk-1:ws_CO_191220191020363925", "stk-2:ws_CO_191220191020363925", "c2b:RKTQDM7W6S", "b2c", "b2c"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestDispatcher_CustomPath(t *testing.T) {
+	events := Services.NewDispatcher(Services.WithDispatcherPath(Services.CallbackSTK, "/hooks/stk"))
+	received := 0
+	events.OnStkResult(func(*Services.STKCallback) { received++ })
+
+	postWebhookTo(events.Handler(), "/hooks/stk", stkCallbackSuccessJSON)
+	if rec := postWebhookTo(events.Handler(), Services.DefaultSTKCallbackPath, stkCallbackSuccessJSON); rec.Code != http.StatusNotFound {
+		t.Errorf("expected default path to be unmounted, got %d", rec.Code)
+	}
+	if received != 1 {
+		t.Errorf("expected one callback on the custom path, got %d", received)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Handler to panic for an invalid path")
+		}
+	}()
+	Services.NewDispatcher(Services.WithDispatcherPath(Services.CallbackB2CResult, "b2c")).Handler()
+}
+
+func TestDispatcher_AsyncDropWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var delivered int
+	var dropped []Services.CallbackType
+
+	events := Services.NewDispatcher(
+		Services.WithAsyncDelivery(1, Services.DropWhenFull),
+		Services.WithOnDropped(func(t Services.CallbackType) {
+			mu.Lock()
+			defer mu.Unlock()
+			dropped = append(dropped, t)
+		}),
+	)
+	started := make(chan struct{}, 1)
+	events.OnStkResult(func(*Services.STKCallback) {
+		started <- struct{}{}
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		delivered++
+	})
+	handler := events.Handler()
+
+	// The first event is being delivered, the second waits in the queue, the third is dropped.
+	postWebhookTo(handler, Services.DefaultSTKCallbackPath, stkCallbackSuccessJSON)
+	<-started
+	for i := 0; i < 2; i++ {
+		if rec := postWebhookTo(handler, Services.DefaultSTKCallbackPath, stkCallbackSuccessJSON); rec.Body.String() != webhookAckBody {
+			t.Errorf("expected callback to be acknowledged without waiting, got %d %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	close(release)
+	events.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 2 || len(dropped) != 1 || dropped[0] != Services.CallbackSTK {
+		t.Errorf("expected 2 delivered and 1 dropped event, got %d and %v", delivered, dropped)
+	}
+}
+
+func TestDispatcher_AsyncBlockWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	events := Services.NewDispatcher(
+		Services.WithAsyncDelivery(1, Services.BlockWhenFull),
+		Services.WithDispatcherHandlerOptions(Services.WithTimeout(20*time.Millisecond)),
+	)
+	started := make(chan struct{}, 1)
+	delivered := 0
+	events.OnB2CResult(func(*Services.B2CResult) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		delivered++
+	})
+	handler := events.Handler()
+
+	postWebhookTo(handler, Services.DefaultB2CResultPath, b2cResultSuccessJSON)
+	<-started
+	postWebhookTo(handler, Services.DefaultB2CResultPath, b2cResultSuccessJSON)
+
+	// The queue is full, so the next callback waits until the handler timeout acknowledges it.
+	start := time.Now()
+	rec := postWebhookTo(handler, Services.DefaultB2CResultPath, b2cResultSuccessJSON)
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the handler to wait for room until its timeout, took %s", elapsed)
+	}
+	if rec.Body.String() != webhookAckBody {
+		t.Errorf("expected blocked callback to be acknowledged, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	close(release)
+	events.Close()
+	if delivered != 3 {
+		t.Errorf("expected the blocked event to be delivered once there was room, got %d", delivered)
+	}
+}
+
+func TestDispatcher_CloseDrainsQueue(t *testing.T) {
+	var dropped int
+	var deliveryErrs []error
+	events := Services.NewDispatcher(
+		Services.WithAsyncDelivery(16, Services.BlockWhenFull),
+		Services.WithOnDropped(func(Services.CallbackType) { dropped++ }),
+		Services.WithOnDeliveryError(func(t Services.CallbackType, err error) { deliveryErrs = append(deliveryErrs, err) }),
+	)
+	var confirmed []string
+	events.OnC2BConfirmation(func(c *Services.C2BConfirmation) {
+		time.Sleep(5 * time.Millisecond)
+		if len(confirmed) == 2 {
+			confirmed = append(confirmed, "panicked")
+			panic("ledger unavailable")
+		}
+		confirmed = append(confirmed, c.TransID)
+	})
+	handler := events.Handler()
+
+	for i := 0; i < 5; i++ {
+		postWebhookTo(handler, Services.DefaultC2BConfirmationPath, c2bConfirmationLegacyJSON)
+	}
+	if err := events.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if len(confirmed) != 5 {
+		t.Fatalf("expected Close to wait for all queued events, got %v", confirmed)
+	}
+	var panicErr *Services.CallbackPanicError
+	if len(deliveryErrs) != 1 || !errors.As(deliveryErrs[0], &panicErr) {
+		t.Errorf("expected the subscriber panic to be reported, got %v", deliveryErrs)
+	}
+
+	if rec := postWebhookTo(handler, Services.DefaultC2BConfirmationPath, c2bConfirmationLegacyJSON); rec.Body.String() != c2bConfirmationAckBody {
+		t.Errorf("expected callback after Close to be acknowledged, got %d %s", rec.Code, rec.Body.String())
+	}
+	if dropped != 1 || len(confirmed) != 5 {
+		t.Errorf("expected event after Close to be dropped, got dropped=%d delivered=%d", dropped, len(confirmed))
+	}
+	if err := events.Close(); err != nil {
+		t.Errorf("expected repeated Close to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// unmarshalFixture decodes raw straight into v, as an application reading a stored callback would.
+func unmarshalFixture(t *testing.T, raw string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		t.Fatalf("json.Unmarshal into %T: %v", v, err)
+	}
+}
+
+func TestUnmarshalSTKCallback(t *testing.T) {
+	var cb Services.STKCallback
+	unmarshalFixture(t, stkCallbackSuccessJSON, &cb)
+
+	if !cb.Success || cb.ResultCode != "0" || cb.CheckoutRequestID != "ws_CO_191220191020363925" {
+		t.Fatalf("unexpected callback: %+v", cb)
+	}
+	if cb.Amount != 1 || cb.MpesaReceiptNumber != "NLJ7RT61SV" || cb.PhoneNumber != "254708374149" {
+		t.Errorf("unexpected metadata: %+v", cb)
+	}
+	if want := time.Date(2019, 12, 19, 10, 21, 15, 0, time.FixedZone("EAT", 3*60*60)); !cb.TransactionDate.Equal(want) {
+		t.Errorf("expected transaction date %v, got %v", want, cb.TransactionDate)
+	}
+	if cb.Raw["Body"] == nil {
+		t.Errorf("expected the raw payload to be kept")
+	}
+
+	parsed, err := Services.ParseSTKCallback(decodeFixture(t, stkCallbackSuccessJSON))
+	if err != nil {
+		t.Fatalf("ParseSTKCallback error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, &cb) {
+		t.Errorf("json.Unmarshal and ParseSTKCallback differ:\n%+v\n%+v", cb, *parsed)
+	}
+
+	var cancelled Services.STKCallback
+	unmarshalFixture(t, stkCallbackCancelledJSON, &cancelled)
+	if cancelled.Success || cancelled.ResultCode != "1032" || len(cancelled.Metadata) != 0 {
+		t.Errorf("unexpected cancelled callback: %+v", cancelled)
+	}
+}
+
+func TestUnmarshalB2CResult(t *testing.T) {
+	var res Services.B2CResult
+	unmarshalFixture(t, b2cResultSuccessJSON, &res)
+
+	if !res.Success || res.OriginatorConversationID != "10571-7910404-1" || res.TransactionReceipt == "" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.TransactionAmount == 0 || res.TransactionCompletedDateTime.IsZero() {
+		t.Errorf("expected typed result parameters, got %+v", res)
+	}
+
+	parsed, err := Services.ParseB2CResult(decodeFixture(t, b2cResultSuccessJSON))
+	if err != nil {
+		t.Fatalf("ParseB2CResult error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, &res) {
+		t.Errorf("json.Unmarshal and ParseB2CResult differ:\n%+v\n%+v", res, *parsed)
+	}
+}
+
+func TestUnmarshalB2BCallbackResult(t *testing.T) {
+	// A single ResultParameter object, string codes and numeric values.
+	const raw = `{
+	  "Result": {
+	    "ResultType": "0",
+	    "ResultCode": "0",
+	    "ResultDesc": "The service request is processed successfully.",
+	    "OriginatorConversationID": "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+	    "ConversationID": "12345677dfdf89099B3",
+	    "TransactionID": "QKA81LK5CY",
+	    "ResultParameters": {"ResultParameter": {"Key": "Amount", "Value": 190.00}},
+	    "ReferenceData": {"ReferenceItem": [{"Key": "BillReferenceNumber", "Value": "19008"}]}
+	  }
+	}`
+	var res Services.B2BCallbackResult
+	unmarshalFixture(t, raw, &res)
+
+	if !res.Success || res.TransactionID != "QKA81LK5CY" || res.ConversationID != "12345677dfdf89099B3" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.ResultParameters["Amount"] != "190" || res.ReferenceData["BillReferenceNumber"] != "19008" {
+		t.Errorf("unexpected key/value items: %v %v", res.ResultParameters, res.ReferenceData)
+	}
+
+	parsed, err := Services.ParseB2BCallback(decodeFixture(t, raw))
+	if err != nil {
+		t.Fatalf("ParseB2BCallback error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, &res) {
+		t.Errorf("json.Unmarshal and ParseB2BCallback differ:\n%+v\n%+v", res, *parsed)
+	}
+}
+
+func TestUnmarshalAccountBalanceResult(t *testing.T) {
+	var res Services.AccountBalanceResult
+	unmarshalFixture(t, accountBalanceResultJSON, &res)
+
+	if !res.Success || len(res.Accounts) != 5 || res.BOCompletedTime.IsZero() {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if utility, ok := res.UtilityAccount(); !ok || utility.Available != 228037 {
+		t.Errorf("unexpected utility account: %+v", utility)
+	}
+
+	parsed, err := Services.ParseAccountBalanceResult(decodeFixture(t, accountBalanceResultJSON))
+	if err != nil {
+		t.Fatalf("ParseAccountBalanceResult error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, &res) {
+		t.Errorf("json.Unmarshal and ParseAccountBalanceResult differ:\n%+v\n%+v", res, *parsed)
+	}
+}
+
+func TestUnmarshalTransactionStatusResult(t *testing.T) {
+	var res Services.TransactionStatusResult
+	unmarshalFixture(t, transactionStatusCompletedJSON, &res)
+
+	if res.Status != Services.TransactionStateCompleted || res.ConversationID != "AG_20200120_0000657265d5fa9ae5c0" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Amount == 0 || res.ReceiptNo == "" || res.FinalisedTime.IsZero() {
+		t.Errorf("expected typed result parameters, got %+v", res)
+	}
+
+	parsed, err := Services.ParseTransactionStatusResult(decodeFixture(t, transactionStatusCompletedJSON))
+	if err != nil {
+		t.Fatalf("ParseTransactionStatusResult error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, &res) {
+		t.Errorf("json.Unmarshal and ParseTransactionStatusResult differ:\n%+v\n%+v", res, *parsed)
+	}
+}
+
+func TestUnmarshalC2BConfirmation(t *testing.T) {
+	for _, raw := range []string{c2bConfirmationLegacyJSON, c2bConfirmationHashedJSON} {
+		var c Services.C2BConfirmation
+		unmarshalFixture(t, raw, &c)
+
+		parsed, err := Services.ParseC2BConfirmation(strings.NewReader(raw))
+		if err != nil {
+			t.Fatalf("ParseC2BConfirmation error: %v", err)
+		}
+		if c.Raw == nil {
+			t.Errorf("expected the raw payload to be kept")
+		}
+		// ParseC2BConfirmation keeps numbers in Raw as json.Number.
+		c.Raw, parsed.Raw = nil, nil
+		if !reflect.DeepEqual(parsed, &c) {
+			t.Errorf("json.Unmarshal and ParseC2BConfirmation differ:\n%+v\n%+v", c, *parsed)
+		}
+	}
+
+	var c Services.C2BConfirmation
+	unmarshalFixture(t, c2bConfirmationLegacyJSON, &c)
+	if c.TransID != "RKTQDM7W6S" || c.TransAmount != 10 || c.OrgAccountBalance != 49197 || c.TransTime.IsZero() {
+		t.Errorf("unexpected confirmation: %+v", c)
+	}
+
+	// A confirmation encoded with json.Marshal decodes back to the same values.
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal error: %v", err)
+	}
+	var again Services.C2BConfirmation
+	unmarshalFixture(t, string(encoded), &again)
+	if again.TransID != c.TransID || again.TransAmount != c.TransAmount || !again.TransTime.Equal(c.TransTime) {
+		t.Errorf("expected %+v after re-decoding, got %+v", c, again)
+	}
+}
+
+func TestUnmarshalCallback_Errors(t *testing.T) {
+	var res Services.B2CResult
+	if err := json.Unmarshal([]byte(`{"foo":"bar"}`), &res); err == nil || !strings.Contains(err.Error(), "missing Result") {
+		t.Errorf("expected missing Result error, got %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"Result":"oops"}`), &res); err == nil {
+		t.Errorf("expected error for a Result node that is not an object")
+	}
+
+	var cb Services.STKCallback
+	if err := json.Unmarshal([]byte(`{"Body":{}}`), &cb); err == nil || !strings.Contains(err.Error(), "stkCallback") {
+		t.Errorf("expected missing stkCallback error, got %v", err)
+	}
+
+	var c Services.C2BConfirmation
+	if err := json.Unmarshal([]byte(`{"TransAmount":"10"}`), &c); err == nil || !strings.Contains(err.Error(), "TransID") {
+		t.Errorf("expected missing TransID error, got %v", err)
+	}
+}
+
+func TestUnmarshalCallback_Nested(t *testing.T) {
+	// A stored row holding the callback body next to other columns.
+	row := `{"id": 7, "payload": ` + reversalResultSuccessJSON + `}`
+	var stored struct {
+		ID      int                     `json:"id"`
+		Payload Services.ReversalResult `json:"payload"`
+	}
+	unmarshalFixture(t, row, &stored)
+	if stored.ID != 7 || stored.Payload.ConversationID != "AG_20191219_00004e48cf7e3533f581" {
+		t.Errorf("unexpected stored row: %+v", stored)
+	}
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// b2bResultJSON is a B2B PayBill result callback.
+const b2bResultJSON = `{
+  "Result": {
+    "ResultType": "0",
+    "ResultCode": "0",
+    "ResultDesc": "The service request is processed successfully",
+    "OriginatorConversationID": "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+    "ConversationID": "12345677dfdf89099B3",
+    "TransactionID": "QKA81LK5CY",
+    "ResultParameters": {
+      "ResultParameter": [
+        {"Key": "DebitAccountBalance", "Value": "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"},
+        {"Key": "Amount", "Value": "190.00"},
+        {"Key": "DebitPartyAffectedAccountBalance", "Value": "Working Account|KES|346568.83|6186.83|340382.00|0.00"},
+        {"Key": "TransCompletedTime", "Value": "20221110110717"},
+        {"Key": "DebitPartyCharges", "Value": ""},
+        {"Key": "ReceiverPartyPublicName", "Value": "000000– Biller Companty"},
+        {"Key": "Currency", "Value": "KES"},
+        {"Key": "InitiatorAccountCurrentBalance", "Value": "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"}
+      ]
+    },
+    "ReferenceData": {
+      "ReferenceItem": [
+        {"Key": "BillReferenceNumber", "Value": "19008"},
+        {"Key": "QueueTimeoutURL", "Value": "https://mydomain.com/b2b/businessbuygoods/queue/"}
+      ]
+    }
+  }
+}`
+
+// callbackFixtures are the raw callback bodies the marshalling tests decode, keyed by the
+// golden file of their normalized JSON.
+var callbackFixtures = []struct {
+	golden string
+	raw    string
+	typed  func() any
+}{
+	{"stk_callback.golden.json", stkCallbackSuccessJSON, func() any { return &Services.STKCallback{} }},
+	{"stk_callback_cancelled.golden.json", stkCallbackCancelledJSON, func() any { return &Services.STKCallback{} }},
+	{"c2b_confirmation.golden.json", c2bConfirmationLegacyJSON, func() any { return &Services.C2BConfirmation{} }},
+	{"c2b_confirmation_hashed.golden.json", c2bConfirmationHashedJSON, func() any { return &Services.C2BConfirmation{} }},
+	{"b2c_result.golden.json", b2cResultSuccessJSON, func() any { return &Services.B2CResult{} }},
+	{"b2b_result.golden.json", b2bResultJSON, func() any { return &Services.B2BCallbackResult{} }},
+	{"reversal_result.golden.json", reversalResultSuccessJSON, func() any { return &Services.ReversalResult{} }},
+	{"account_balance_result.golden.json", accountBalanceResultJSON, func() any { return &Services.AccountBalanceResult{} }},
+	{"transaction_status_result.golden.json", transactionStatusCompletedJSON, func() any { return &Services.TransactionStatusResult{} }},
+	{"transaction_status_not_found.golden.json", transactionStatusNotFoundJSON, func() any { return &Services.TransactionStatusResult{} }},
+}
+
+// withMarshalRaw sets Services.MarshalCallbackRaw for the duration of the test.
+func withMarshalRaw(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := Services.MarshalCallbackRaw
+	Services.MarshalCallbackRaw = enabled
+	t.Cleanup(func() { Services.MarshalCallbackRaw = previous })
+}
+
+// clearRaw sets the Raw field of a decoded callback to nil.
+func clearRaw(v any) {
+	reflect.ValueOf(v).Elem().FieldByName("Raw").Set(reflect.Zero(reflect.TypeOf(map[string]any{})))
+}
+
+func TestCallbackMarshalJSON_Golden(t *testing.T) {
+	withMarshalRaw(t, false)
+	for _, tt := range callbackFixtures {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", tt.golden))
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+
+			typed := tt.typed()
+			unmarshalFixture(t, tt.raw, typed)
+			got, err := json.Marshal(typed)
+			if err != nil {
+				t.Fatalf("marshal error: %v", err)
+			}
+			if !bytes.Equal(got, bytes.TrimSpace(want)) {
+				t.Errorf("expected %s, got %s", bytes.TrimSpace(want), got)
+			}
+		})
+	}
+}
+
+func TestCallbackMarshalJSON_RoundTrip(t *testing.T) {
+	for _, includeRaw := range []bool{false, true} {
+		withMarshalRaw(t, includeRaw)
+		for _, tt := range callbackFixtures {
+			original := tt.typed()
+			unmarshalFixture(t, tt.raw, original)
+			if !includeRaw {
+				clearRaw(original)
+			}
+
+			encoded, err := json.Marshal(original)
+			if err != nil {
+				t.Fatalf("%s: marshal error: %v", tt.golden, err)
+			}
+			decoded := tt.typed()
+			unmarshalFixture(t, string(encoded), decoded)
+			if !reflect.DeepEqual(original, decoded) {
+				t.Errorf("%s (raw %v): round trip changed the value:\n%+v\n%+v", tt.golden, includeRaw, original, decoded)
+			}
+
+			again, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatalf("%s: marshal error: %v", tt.golden, err)
+			}
+			if !bytes.Equal(encoded, again) {
+				t.Errorf("%s (raw %v): expected stable encoding, got\n%s\n%s", tt.golden, includeRaw, encoded, again)
+			}
+		}
+	}
+}
+
+func TestCallbackMarshalJSON_Raw(t *testing.T) {
+	var cb Services.STKCallback
+	unmarshalFixture(t, stkCallbackCancelledJSON, &cb)
+
+	withMarshalRaw(t, false)
+	encoded, _ := json.Marshal(cb)
+	if strings.Contains(string(encoded), `"Raw"`) {
+		t.Errorf("expected no raw envelope by default, got %s", encoded)
+	}
+
+	Services.MarshalCallbackRaw = true
+	encoded, _ = json.Marshal(&cb)
+	var decoded Services.STKCallback
+	unmarshalFixture(t, string(encoded), &decoded)
+	if !reflect.DeepEqual(decoded.Raw, decodeFixture(t, stkCallbackCancelledJSON)) {
+		t.Errorf("expected the raw envelope to be restored, got %v", decoded.Raw)
+	}
+}
+
+func TestCallbackUnmarshalJSON_OtherSchema(t *testing.T) {
+	var b2c Services.B2CResult
+	unmarshalFixture(t, b2cResultSuccessJSON, &b2c)
+	encoded, _ := json.Marshal(b2c)
+
+	// A B2C result is not a reversal result, even though both are flat objects.
+	var reversal Services.ReversalResult
+	if err := json.Unmarshal(encoded, &reversal); err == nil {
+		t.Errorf("expected error decoding another schema, got %+v", reversal)
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func postWebhookTo(handler http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCallbackMux_RoutesEachCallbackType(t *testing.T) {
+	fired := map[string]int{}
+	mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{
+		STKPath: "/stk",
+		OnSTK:   func(*Services.STKCallback) { fired["stk"]++ },
+
+		C2BValidationPath: "/c2b/validation",
+		OnC2BValidation: func(*Services.C2BConfirmation) Services.C2BValidationResponse {
+			fired["c2b_validation"]++
+			return Services.AcceptC2BValidation()
+		},
+		C2BConfirmationPath: "/c2b/confirmation",
+		OnC2BConfirmation:   func(*Services.C2BConfirmation) error { fired["c2b_confirmation"]++; return nil },
+
+		B2CResultPath:  "/b2c/result",
+		B2CTimeoutPath: "/b2c/timeout",
+		OnB2CResult:    func(*Services.B2CResult) { fired["b2c_result"]++ },
+		OnB2CTimeout:   func(map[string]any) { fired["b2c_timeout"]++ },
+
+		B2BResultPath: "/b2b/result",
+		OnB2BResult:   func(*Services.B2BCallbackResult) { fired["b2b_result"]++ },
+
+		ReversalResultPath: "/reversal",
+		OnReversalResult:   func(*Services.ReversalResult) { fired["reversal_result"]++ },
+
+		BalanceResultPath:  "/balance",
+		BalanceTimeoutPath: "/balance",
+		OnBalanceResult:    func(*Services.AccountBalanceResult) { fired["balance_result"]++ },
+		OnBalanceTimeout:   func(map[string]any) { fired["balance_timeout"]++ },
+
+		StatusResultPath: "/status",
+		OnStatusResult:   func(*Services.TransactionStatusResult) { fired["status_result"]++ },
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackMux error: %v", err)
+	}
+
+	timeoutJSON := `{"requestId":"11728-2929992-1","errorCode":"500.001.1001","errorMessage":"Request timed out"}`
+	cases := []struct {
+		path, body, callback string
+	}{
+		{"/stk", stkCallbackSuccessJSON, "stk"},
+		{"/c2b/validation", c2bConfirmationLegacyJSON, "c2b_validation"},
+		{"/c2b/confirmation", c2bConfirmationLegacyJSON, "c2b_confirmation"},
+		{"/b2c/result", b2cResultSuccessJSON, "b2c_result"},
+		{"/b2c/timeout", timeoutJSON, "b2c_timeout"},
+		{"/b2b/result", reversalResultSuccessJSON, "b2b_result"},
+		{"/reversal", reversalResultSuccessJSON, "reversal_result"},
+		{"/balance", accountBalanceResultJSON, "balance_result"},
+		{"/balance", timeoutJSON, "balance_timeout"},
+		{"/status", transactionStatusCompletedJSON, "status_result"},
+	}
+	for _, tc := range cases {
+		before := fired[tc.callback]
+		rec := postWebhookTo(mux, tc.path, tc.body)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d %s", tc.path, rec.Code, rec.Body.String())
+		}
+		if fired[tc.callback] != before+1 {
+			t.Errorf("%s: expected %s callback to fire, fired %v", tc.path, tc.callback, fired)
+		}
+	}
+	if total := len(fired); total != len(cases) {
+		t.Errorf("expected each callback to fire once, got %v", fired)
+	}
+
+	if rec := postWebhookTo(mux, "/unknown", stkCallbackSuccessJSON); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown path, got %d", rec.Code)
+	}
+}
+
+func TestCallbackMux_PathsAndURLs(t *testing.T) {
+	mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{
+		STKPath:        "/mpesa/stk",
+		B2CResultPath:  "/mpesa/b2c",
+		B2CTimeoutPath: "/mpesa/b2c",
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackMux error: %v", err)
+	}
+
+	paths := mux.Paths()
+	if len(paths) != 3 || paths[Services.CallbackSTK] != "/mpesa/stk" || paths[Services.CallbackB2CTimeout] != "/mpesa/b2c" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+	if got := mux.URL("https://example.com/", Services.CallbackSTK); got != "https://example.com/mpesa/stk" {
+		t.Errorf("unexpected STK URL %q", got)
+	}
+	if got := mux.URL("https://example.com", Services.CallbackReversalResult); got != "" {
+		t.Errorf("expected no URL for unmounted route, got %q", got)
+	}
+}
+
+func TestCallbackMux_InvalidConfig(t *testing.T) {
+	cases := map[string]Services.CallbackMuxConfig{
+		"relative path":           {STKPath: "mpesa/stk"},
+		"path shared by APIs":     {STKPath: "/mpesa", B2CResultPath: "/mpesa"},
+		"validation without fn":   {C2BValidationPath: "/c2b/validation"},
+		"confirmation without fn": {C2BConfirmationPath: "/c2b/confirmation"},
+	}
+	for name, cfg := range cases {
+		if _, err := Services.NewCallbackMux(cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCallbackMux_SharedOptions(t *testing.T) {
+	var reported []string
+	mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{
+		STKPath:       "/stk",
+		B2CResultPath: "/b2c",
+		Options: []Services.HandlerOption{
+			Services.WithMaxBodyBytes(4096),
+			Services.WithErrorHandler(func(err error, r *http.Request) { reported = append(reported, r.URL.Path) }),
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCallbackMux error: %v", err)
+	}
+
+	for _, path := range []string{"/stk", "/b2c"} {
+		if rec := postWebhookTo(mux, path, `{"Body":`); rec.Code != http.StatusOK {
+			t.Errorf("%s: expected ack with error handler, got %d", path, rec.Code)
+		}
+		if rec := postWebhookTo(mux, path, `{"x":"`+strings.Repeat("y", 5000)+`"}`); rec.Code != http.StatusOK {
+			t.Errorf("%s: expected ack for a body over the shared limit, got %d", path, rec.Code)
+		}
+	}
+	if strings.Join(reported, ",") != "/stk,/stk,/b2c,/b2c" {
+		t.Errorf("expected errors from both routes to be reported, got %v", reported)
+	}
+}
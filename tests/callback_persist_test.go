@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// persistedCallback is a callback received by a recording raw persist hook.
+type persistedCallback struct {
+	callbackType string
+	raw          []byte
+	headers      http.Header
+}
+
+func recordingPersistHook(into *[]persistedCallback, err error) Services.RawPersistHook {
+	return func(ctx context.Context, callbackType string, raw []byte, headers http.Header) error {
+		*into = append(*into, persistedCallback{callbackType, append([]byte(nil), raw...), headers})
+		return err
+	}
+}
+
+func TestRawPersistHook_ByteIdentical(t *testing.T) {
+	// Unusual spacing, a byte order mark and trailing data must all reach the hook unchanged.
+	body := "\xef\xbb\xbf  " + strings.ReplaceAll(stkCallbackSuccessJSON, "\n", "\r\n") + "\n\n"
+
+	var persisted []persistedCallback
+	handled := false
+	handler := Services.STKCallbackHandler(func(*Services.STKCallback) { handled = true },
+		Services.WithRawPersistHook(recordingPersistHook(&persisted, nil)))
+
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/stk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Request-Id", "abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !handled {
+		t.Fatalf("expected callback to be handled, got %d", rec.Code)
+	}
+	if len(persisted) != 1 {
+		t.Fatalf("expected one persisted callback, got %d", len(persisted))
+	}
+	got := persisted[0]
+	if string(got.raw) != body {
+		t.Errorf("expected byte-identical body, got %q", got.raw)
+	}
+	if got.callbackType != string(Services.CallbackSTK) || got.headers.Get("X-Request-Id") != "abc" {
+		t.Errorf("unexpected type %q or headers %v", got.callbackType, got.headers)
+	}
+}
+
+func TestRawPersistHook_MalformedAndTimeouts(t *testing.T) {
+	var persisted []persistedCallback
+	handler := Services.B2CResultHandler(nil, nil, Services.WithRawPersistHook(recordingPersistHook(&persisted, nil)))
+
+	postWebhook(handler, b2cResultSuccessJSON)
+	postWebhook(handler, `{"ConversationID":"AG_1"}`)
+	postWebhook(handler, `{"Result":`)
+
+	want := []string{"b2c_result", "b2c_timeout", "b2c_result"}
+	if len(persisted) != len(want) {
+		t.Fatalf("expected %d persisted callbacks, got %d", len(want), len(persisted))
+	}
+	for i, w := range want {
+		if persisted[i].callbackType != w {
+			t.Errorf("callback %d: expected type %q, got %q", i, w, persisted[i].callbackType)
+		}
+	}
+	if string(persisted[2].raw) != `{"Result":` {
+		t.Errorf("expected malformed body to be persisted as-is, got %q", persisted[2].raw)
+	}
+}
+
+func TestRawPersistHook_Failure(t *testing.T) {
+	var persisted []persistedCallback
+	var recorder errorRecorder
+	handled := 0
+	hook := recordingPersistHook(&persisted, errors.New("disk full"))
+	onCallback := func(*Services.STKCallback) { handled++ }
+
+	rec := postWebhook(Services.STKCallbackHandler(onCallback,
+		Services.WithRawPersistHook(hook), Services.WithErrorHandler(recorder.hook)), stkCallbackSuccessJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody || handled != 1 {
+		t.Errorf("expected persist failure not to change the response, got %d %s handled=%d", rec.Code, rec.Body.String(), handled)
+	}
+	if errs := recorder.list(); len(errs) != 1 || !errors.Is(errs[0], Services.ErrRawPersistFailed) {
+		t.Errorf("expected ErrRawPersistFailed to be reported, got %v", errs)
+	}
+
+	rec = postWebhook(Services.STKCallbackHandler(onCallback,
+		Services.WithRawPersistHook(hook), Services.WithRawPersistRequired(true)), stkCallbackSuccessJSON)
+	if rec.Code != http.StatusInternalServerError || handled != 1 {
+		t.Errorf("expected 500 without handling when persistence is required, got %d handled=%d", rec.Code, handled)
+	}
+}
+
+func TestFileRawPersistHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "callbacks")
+	handler := Services.BillManagerPaymentHandler(nil, Services.WithRawPersistHook(Services.FileRawPersistHook(dir)))
+
+	if rec := postWebhook(handler, billManagerPaymentJSON); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	postWebhook(handler, billManagerPaymentJSON)
+
+	bodies, _ := filepath.Glob(filepath.Join(dir, "*-bill_manager_payment-*.json"))
+	headers, _ := filepath.Glob(filepath.Join(dir, "*.headers"))
+	if len(bodies) != 2 || len(headers) != 2 {
+		t.Fatalf("expected a body and a headers file per callback, got %v %v", bodies, headers)
+	}
+	raw, err := os.ReadFile(bodies[0])
+	if err != nil || string(raw) != billManagerPaymentJSON {
+		t.Errorf("expected persisted body to match, got %q (%v)", raw, err)
+	}
+	head, _ := os.ReadFile(headers[0])
+	if !strings.Contains(string(head), "Content-Type: application/json") {
+		t.Errorf("expected headers to be persisted, got %q", head)
+	}
+}
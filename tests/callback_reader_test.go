@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func newCallbackRequest(body io.Reader, contentType string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/b2b/result", body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func TestParseB2BCallbackFromRequest_ContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		wantErr     bool
+	}{
+		{"JSON", "application/json", false},
+		{"JSON with charset", "application/json; charset=utf-8", false},
+		{"Upper case", "Application/JSON", false},
+		{"Missing", "", false},
+		{"Plain text", "text/plain", false},
+		{"Form", "application/x-www-form-urlencoded", false},
+		{"Malformed", "application/json; charset", false},
+		{"Unsupported", "application/xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := marshalFixture(t, b2bCallbackSuccessPayload())
+			if tt.wantErr {
+				body = "<Result><TransactionID>QKA81LK5CY</TransactionID></Result>"
+			}
+			res, err := Services.ParseB2BCallbackFromRequest(newCallbackRequest(strings.NewReader(body), tt.contentType))
+
+			if tt.wantErr {
+				if !errors.Is(err, Services.ErrUnsupportedContentType) {
+					t.Fatalf("expected ErrUnsupportedContentType, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if res.TransactionID != "QKA81LK5CY" || res.ResultParameters["Amount"] != "190.00" {
+				t.Errorf("unexpected result: %+v", res)
+			}
+		})
+	}
+}
+
+func TestParseB2BCallbackFromRequest_Chunked(t *testing.T) {
+	body := marshalFixture(t, b2bCallbackFailurePayload())
+	req := newCallbackRequest(iotest.OneByteReader(strings.NewReader(body)), "application/json")
+	req.ContentLength = -1
+	req.TransferEncoding = []string{"chunked"}
+
+	res, err := Services.ParseB2BCallbackFromRequest(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if res.ResultCode != "2001" || res.ResultParameters["BOCompletedTime"] != "20200120164825" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestParseB2BCallbackFromReader_SizeLimit(t *testing.T) {
+	body := marshalFixture(t, b2bCallbackSuccessPayload())
+
+	if _, err := Services.ParseB2BCallbackFromReader(strings.NewReader(body), Services.WithMaxBodyBytes(int64(len(body)))); err != nil {
+		t.Fatalf("expected body at the limit to parse, got %v", err)
+	}
+
+	_, err := Services.ParseB2BCallbackFromReader(iotest.HalfReader(strings.NewReader(body+strings.Repeat(" ", 8))), Services.WithMaxBodyBytes(int64(len(body))))
+	if err != nil {
+		t.Fatalf("expected trailing whitespace after a complete object to be ignored, got %v", err)
+	}
+
+	_, err = Services.ParseB2BCallbackFromReader(strings.NewReader(body), Services.WithMaxBodyBytes(32))
+	if !errors.Is(err, Services.ErrCallbackTooLarge) {
+		t.Fatalf("expected ErrCallbackTooLarge, got %v", err)
+	}
+
+	oversized := `{"Result":{"ResultCode":0,"ResultDesc":"` + strings.Repeat("x", int(Services.DefaultMaxWebhookBodyBytes)) + `"}}`
+	_, err = Services.ParseB2BCallbackFromRequest(newCallbackRequest(strings.NewReader(oversized), "application/json"))
+	if !errors.Is(err, Services.ErrCallbackTooLarge) {
+		t.Fatalf("expected default limit to apply, got %v", err)
+	}
+}
+
+func TestParseB2BCallbackFromReader_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Empty", ""},
+		{"Truncated", `{"Result":`},
+		{"Missing Result", `{"foo":"bar"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Services.ParseB2BCallbackFromReader(strings.NewReader(tt.body)); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestParseB2CResultFromRequest(t *testing.T) {
+	res, err := Services.ParseB2CResultFromRequest(newCallbackRequest(strings.NewReader(b2cResultSuccessJSON), "application/json; charset=UTF-8"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !res.Success || res.TransactionReceipt != "NLJ41HAY6Q" || res.TransactionAmount != 10 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	if _, err := Services.ParseB2CResultFromReader(strings.NewReader(b2cResultInsufficientFundsJSON), Services.WithMaxBodyBytes(16)); !errors.Is(err, Services.ErrCallbackTooLarge) {
+		t.Errorf("expected ErrCallbackTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// deliver posts body to url as M-Pesa would and returns the response body.
+func deliver(t *testing.T, url, body string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("delivering callback: %v", err)
+	}
+	defer resp.Body.Close()
+	ack, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(ack)
+}
+
+func TestCallbackRecorder_RecordsTypedAndRaw(t *testing.T) {
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	if status, ack := deliver(t, recorder.URL(Services.CallbackSTK), stkCallbackSuccessJSON); status != http.StatusOK || ack != webhookAckBody {
+		t.Errorf("expected the STK callback to be acknowledged, got %d %s", status, ack)
+	}
+	if status, ack := deliver(t, recorder.URL(Services.CallbackC2BConfirmation), c2bConfirmationLegacyJSON); status != http.StatusOK || ack != c2bConfirmationAckBody {
+		t.Errorf("expected the confirmation to be acknowledged, got %d %s", status, ack)
+	}
+	deliver(t, recorder.URL(Services.CallbackB2CResult), b2cResultSuccessJSON)
+
+	callbacks := recorder.Callbacks()
+	if len(callbacks) != 3 {
+		t.Fatalf("expected 3 recorded callbacks, got %d", len(callbacks))
+	}
+	stk := callbacks[0]
+	if stk.Type != Services.CallbackSTK || stk.Path != "/mpesa/stk" || string(stk.Raw) != stkCallbackSuccessJSON || stk.Err != nil {
+		t.Errorf("unexpected STK record: %+v", stk)
+	}
+	if cb, ok := stk.Parsed.(*Services.STKCallback); !ok || cb.MpesaReceiptNumber != "NLJ7RT61SV" {
+		t.Errorf("expected a parsed STK callback, got %#v", stk.Parsed)
+	}
+	if c, ok := callbacks[1].Parsed.(*Services.C2BConfirmation); !ok || c.TransID != "RKTQDM7W6S" {
+		t.Errorf("expected a parsed confirmation, got %#v", callbacks[1].Parsed)
+	}
+	if res, ok := callbacks[2].Parsed.(*Services.B2CResult); !ok || !res.Success {
+		t.Errorf("expected a parsed B2C result, got %#v", callbacks[2].Parsed)
+	}
+	if recorder.Count(Services.CallbackSTK) != 1 || recorder.Count(Services.CallbackB2CResult) != 1 || recorder.Count(Services.CallbackB2CTimeout) != 0 {
+		t.Errorf("unexpected counts: %+v", callbacks)
+	}
+
+	recorder.Reset()
+	if len(recorder.Callbacks()) != 0 {
+		t.Errorf("expected Reset to forget the callbacks")
+	}
+}
+
+func TestCallbackRecorder_TimeoutsAndFailures(t *testing.T) {
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	// A queue timeout delivered to the result route is recorded as a timeout.
+	deliver(t, recorder.URL(Services.CallbackB2CResult), queueTimeoutErrorJSON)
+	if recorder.Count(Services.CallbackB2CTimeout) != 1 {
+		t.Fatalf("expected a B2C timeout, got %+v", recorder.Callbacks())
+	}
+	if timeout, ok := recorder.Callbacks()[0].Parsed.(*Services.QueueTimeoutResult); !ok || timeout.RequestID != "11728-2929992-1" {
+		t.Errorf("expected a parsed queue timeout, got %#v", recorder.Callbacks()[0].Parsed)
+	}
+
+	// Malformed bodies are recorded with their error and still acknowledged.
+	status, _ := deliver(t, recorder.URL(Services.CallbackReversalResult), `{"Result":`)
+	rec := recorder.Callbacks()[1]
+	if status != http.StatusOK || rec.Type != Services.CallbackReversalResult || rec.Err == nil || rec.Parsed != nil || string(rec.Raw) != `{"Result":` {
+		t.Errorf("unexpected record of a malformed callback: %d %+v", status, rec)
+	}
+
+	// C2B validations are accepted.
+	if _, ack := deliver(t, recorder.URL(Services.CallbackC2BValidation), c2bConfirmationLegacyJSON); !strings.Contains(ack, `"ResultCode":"0"`) {
+		t.Errorf("expected the validation to be accepted, got %s", ack)
+	}
+}
+
+func TestCallbackRecorder_WaitForStkCallback(t *testing.T) {
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	other := strings.ReplaceAll(stkCallbackCancelledJSON, "ws_CO_191220191020363925", "ws_CO_other")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for _, body := range []string{other, stkCallbackSuccessJSON} {
+			if resp, err := http.Post(recorder.URL(Services.CallbackSTK), "application/json", strings.NewReader(body)); err == nil {
+				resp.Body.Close()
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cb, err := recorder.WaitForStkCallback(ctx, "ws_CO_191220191020363925")
+	if err != nil {
+		t.Fatalf("WaitForStkCallback error: %v", err)
+	}
+	if !cb.Success || recorder.Count(Services.CallbackSTK) != 2 {
+		t.Errorf("expected the successful callback after the other one, got %+v", cb)
+	}
+
+	// A callback that already arrived is returned straight away.
+	if cb, err := recorder.WaitForStkCallback(ctx, "ws_CO_other"); err != nil || cb.Success {
+		t.Errorf("expected the cancelled callback, got %+v %v", cb, err)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelShort()
+	if _, err := recorder.WaitForStkCallback(short, "ws_CO_missing"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to time out, got %v", err)
+	}
+}
+
+func TestCallbackRecorder_PublicURL(t *testing.T) {
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	if got := recorder.URL(Services.CallbackB2BResult); got != recorder.BaseURL()+"/mpesa/b2b/result" {
+		t.Errorf("expected a local URL, got %s", got)
+	}
+	recorder.SetPublicURL("https://abc123.tunnel.example/")
+	if got := recorder.URL(Services.CallbackSTK); got != "https://abc123.tunnel.example/mpesa/stk" {
+		t.Errorf("expected the public URL, got %s", got)
+	}
+}
@@ -0,0 +1,243 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func TestParseB2BCallbackStrict_Valid(t *testing.T) {
+	for name, payload := range map[string]map[string]any{
+		"Success": b2bCallbackSuccessPayload(),
+		"Failure": b2bCallbackFailurePayload(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := Services.ParseB2BCallbackStrict(payload)
+			if err != nil {
+				t.Fatalf("expected valid payload, got %v", err)
+			}
+			if res.ConversationID == "" || res.OriginatorConversationID == "" {
+				t.Errorf("unexpected result: %+v", res)
+			}
+		})
+	}
+}
+
+func TestParseB2BCallbackStrict_MissingFields(t *testing.T) {
+	for _, field := range []string{"ResultCode", "ResultDesc", "ConversationID", "OriginatorConversationID"} {
+		t.Run(field, func(t *testing.T) {
+			payload := b2bCallbackSuccessPayload()
+			delete(payload["Result"].(map[string]any), field)
+
+			if _, err := Services.ParseB2BCallback(payload); err != nil {
+				t.Fatalf("expected lenient parser to accept the payload, got %v", err)
+			}
+			_, err := Services.ParseB2BCallbackStrict(payload)
+			if !errors.Is(err, Services.ErrInvalidCallback) || !strings.Contains(err.Error(), field+" is missing") {
+				t.Fatalf("expected missing %s error, got %v", field, err)
+			}
+		})
+	}
+}
+
+func TestParseB2BCallbackStrict_MixedCaseKeys(t *testing.T) {
+	if _, err := Services.ParseB2BCallbackStrict(decodeFixture(t, b2bResultMixedCaseJSON)); err != nil {
+		t.Fatalf("expected mixed-case keys to satisfy the strict parser, got %v", err)
+	}
+
+	payload := decodeFixture(t, b2bResultMixedCaseJSON)
+	delete(payload["RESULT"].(map[string]any), "conversationId")
+	_, err := Services.ParseB2BCallbackStrict(payload)
+	if !errors.Is(err, Services.ErrInvalidCallback) || !strings.Contains(err.Error(), "ConversationID is missing") {
+		t.Fatalf("expected missing ConversationID error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "OriginatorConversationID") {
+		t.Errorf("expected only the missing field to be reported, got %v", err)
+	}
+}
+
+func TestParseB2BCallbackStrict_NullParameters(t *testing.T) {
+	for name, parameters := range map[string]any{
+		"Null node":          nil,
+		"Null wrapper":       map[string]any{"ResultParameter": nil},
+		"Null array element": map[string]any{"ResultParameter": []any{nil, map[string]any{"Key": "Amount", "Value": "10"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			payload := b2bCallbackFailurePayload()
+			payload["Result"].(map[string]any)["ResultParameters"] = parameters
+			payload["Result"].(map[string]any)["ReferenceData"] = nil
+			if _, err := Services.ParseB2BCallbackStrict(payload); err != nil {
+				t.Fatalf("expected null parameters to be accepted, got %v", err)
+			}
+		})
+	}
+
+	payload := b2bCallbackFailurePayload()
+	payload["Result"].(map[string]any)["ResultParameters"] = map[string]any{"ResultParameter": []any{nil, float64(5)}}
+	_, err := Services.ParseB2BCallbackStrict(payload)
+	if !errors.Is(err, Services.ErrInvalidCallback) || !strings.Contains(err.Error(), "ResultParameters.ResultParameter[1] has unexpected type float64") {
+		t.Errorf("expected the numeric item to be reported, got %v", err)
+	}
+}
+
+func TestParseB2BCallbackStrict_ReportsEveryProblem(t *testing.T) {
+	payload := map[string]any{
+		"Result": map[string]any{
+			"ResultCode":       true,
+			"ResultDesc":       "  ",
+			"ConversationID":   float64(12345),
+			"ResultParameters": "oops",
+		},
+	}
+
+	_, err := Services.ParseB2BCallbackStrict(payload)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, want := range []string{
+		"ResultCode has unexpected type bool",
+		"ResultDesc is empty",
+		"ConversationID has unexpected type number",
+		"OriginatorConversationID is missing",
+		"ResultParameters has unexpected type string",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestParseB2CResultStrict(t *testing.T) {
+	if _, err := Services.ParseB2CResultStrict(decodeFixture(t, b2cResultSuccessJSON)); err != nil {
+		t.Fatalf("expected valid payload, got %v", err)
+	}
+
+	payload := decodeFixture(t, b2cResultSuccessJSON)
+	delete(payload["Result"].(map[string]any), "ConversationID")
+	if _, err := Services.ParseB2CResultStrict(payload); !errors.Is(err, Services.ErrInvalidCallback) {
+		t.Fatalf("expected ErrInvalidCallback, got %v", err)
+	}
+}
+
+func TestParseC2BConfirmationStrict(t *testing.T) {
+	for name, body := range map[string]string{"Legacy": c2bConfirmationLegacyJSON, "Hashed": c2bConfirmationHashedJSON} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Services.ParseC2BConfirmationStrict(strings.NewReader(body)); err != nil {
+				t.Fatalf("expected valid payload, got %v", err)
+			}
+		})
+	}
+
+	body := strings.Replace(c2bConfirmationLegacyJSON, `"MSISDN": "254708374149",`, ``, 1)
+	body = strings.Replace(body, `"TransAmount": "10"`, `"TransAmount": ""`, 1)
+	_, err := Services.ParseC2BConfirmationStrict(strings.NewReader(body))
+	if !errors.Is(err, Services.ErrInvalidCallback) {
+		t.Fatalf("expected ErrInvalidCallback, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "MSISDN is missing") || !strings.Contains(err.Error(), "TransAmount is empty") {
+		t.Errorf("expected both problems to be reported, got %v", err)
+	}
+}
+
+func TestParseSTKCallbackStrict(t *testing.T) {
+	for name, body := range map[string]string{"Success": stkCallbackSuccessJSON, "Cancelled": stkCallbackCancelledJSON} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Services.ParseSTKCallbackStrict(decodeFixture(t, body)); err != nil {
+				t.Fatalf("expected valid payload, got %v", err)
+			}
+		})
+	}
+
+	for _, field := range []string{"MerchantRequestID", "CheckoutRequestID", "ResultCode", "ResultDesc"} {
+		t.Run(field, func(t *testing.T) {
+			payload := decodeFixture(t, stkCallbackCancelledJSON)
+			delete(payload["Body"].(map[string]any)["stkCallback"].(map[string]any), field)
+
+			if _, err := Services.ParseSTKCallback(payload); err != nil {
+				t.Fatalf("expected lenient parser to accept the payload, got %v", err)
+			}
+			_, err := Services.ParseSTKCallbackStrict(payload)
+			if !errors.Is(err, Services.ErrInvalidCallback) || !strings.Contains(err.Error(), field+" is missing") {
+				t.Fatalf("expected missing %s error, got %v", field, err)
+			}
+		})
+	}
+
+	body := strings.Replace(stkCallbackSuccessJSON, `{"Name": "MpesaReceiptNumber", "Value": "NLJ7RT61SV"},`, ``, 1)
+	body = strings.Replace(body, `{"Name": "PhoneNumber", "Value": 254708374149}`, `{"Name": "PhoneNumber"}`, 1)
+	_, err := Services.ParseSTKCallbackStrict(decodeFixture(t, body))
+	if !errors.Is(err, Services.ErrInvalidCallback) {
+		t.Fatalf("expected ErrInvalidCallback, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "MpesaReceiptNumber is missing") || !strings.Contains(err.Error(), "PhoneNumber is missing") {
+		t.Errorf("expected both missing metadata items to be reported, got %v", err)
+	}
+
+	body = strings.Replace(stkCallbackCancelledJSON, `"ResultDesc"`, `"CallbackMetadata": "none", "ResultDesc"`, 1)
+	if _, err := Services.ParseSTKCallbackStrict(decodeFixture(t, body)); err == nil || !strings.Contains(err.Error(), "CallbackMetadata has unexpected type") {
+		t.Errorf("expected a malformed CallbackMetadata to be rejected, got %v", err)
+	}
+}
+
+func TestWithStrictParsing(t *testing.T) {
+	incompleteSTK := strings.Replace(stkCallbackCancelledJSON, `"CheckoutRequestID": "ws_CO_191220191020363925",`, ``, 1)
+	incompleteB2C := strings.Replace(b2cResultSuccessJSON, `"ConversationID": "AG_20191219_00004e48cf7e3533f581",`, ``, 1)
+	incompleteC2B := strings.Replace(c2bConfirmationLegacyJSON, `"MSISDN": "254708374149",`, ``, 1)
+	if incompleteB2C == b2cResultSuccessJSON {
+		t.Fatalf("fixture changed: ConversationID not found")
+	}
+
+	tests := []struct {
+		name    string
+		handler func(called *int, opts ...Services.HandlerOption) http.Handler
+		body    string
+	}{
+		{"STK", func(called *int, opts ...Services.HandlerOption) http.Handler {
+			return Services.STKCallbackHandler(func(*Services.STKCallback) { *called++ }, opts...)
+		}, incompleteSTK},
+		{"B2C", func(called *int, opts ...Services.HandlerOption) http.Handler {
+			return Services.B2CResultHandler(func(*Services.B2CResult) { *called++ }, nil, opts...)
+		}, incompleteB2C},
+		{"C2B confirmation", func(called *int, opts ...Services.HandlerOption) http.Handler {
+			return Services.C2BConfirmationHandler(func(*Services.C2BConfirmation) error { *called++; return nil }, opts...)
+		}, incompleteC2B},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called int
+			if rec := postWebhook(tt.handler(&called), tt.body); rec.Code != http.StatusOK || called != 1 {
+				t.Fatalf("expected the lenient handler to accept the callback, got %d called=%d", rec.Code, called)
+			}
+
+			var recorder errorRecorder
+			called = 0
+			rec := postWebhook(tt.handler(&called, Services.WithStrictParsing(), Services.WithErrorHandler(recorder.hook)), tt.body)
+			if rec.Code != http.StatusOK || called != 0 {
+				t.Errorf("expected the strict handler to acknowledge without calling the function, got %d called=%d", rec.Code, called)
+			}
+			if errs := recorder.list(); len(errs) != 1 || !errors.Is(errs[0], Services.ErrInvalidCallback) {
+				t.Errorf("expected ErrInvalidCallback to be reported, got %v", errs)
+			}
+
+			rec = postWebhook(tt.handler(&called, Services.WithStrictParsing(), Services.WithAckPolicy(Services.StrictAck)), tt.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 under StrictAck, got %d", rec.Code)
+			}
+		})
+	}
+
+	mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{
+		STKPath: "/stk",
+		OnSTK:   func(*Services.STKCallback) { t.Errorf("expected the incomplete callback not to be delivered") },
+		Options: []Services.HandlerOption{Services.WithStrictParsing(), Services.WithAckPolicy(Services.StrictAck)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := postWebhookTo(mux, "/stk", incompleteSTK); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected the mux to parse strictly, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestGenerateCallbackToken(t *testing.T) {
+	first, err := Services.GenerateCallbackToken()
+	if err != nil {
+		t.Fatalf("GenerateCallbackToken error: %v", err)
+	}
+	second, _ := Services.GenerateCallbackToken()
+
+	if len(first) < 40 || first == second {
+		t.Errorf("expected long unique tokens, got %q and %q", first, second)
+	}
+	if url.QueryEscape(first) != first {
+		t.Errorf("expected URL-safe token, got %q", first)
+	}
+}
+
+func TestAppendCallbackToken(t *testing.T) {
+	got, err := Services.AppendCallbackToken("https://example.com/mpesa/stk?shop=1&token=old", "abc-123")
+	if err != nil {
+		t.Fatalf("AppendCallbackToken error: %v", err)
+	}
+	if got != "https://example.com/mpesa/stk?shop=1&token=abc-123" {
+		t.Errorf("unexpected URL %q", got)
+	}
+
+	if _, err := Services.AppendCallbackToken("https://example.com", ""); err == nil {
+		t.Errorf("expected error for empty token")
+	}
+	if _, err := Services.AppendCallbackToken("://bad", "abc"); err == nil {
+		t.Errorf("expected error for invalid URL")
+	}
+}
+
+func TestVerifyURLToken(t *testing.T) {
+	var reported []error
+	var received int
+	handler := Services.STKCallbackHandler(
+		func(*Services.STKCallback) { received++ },
+		Services.VerifyURLToken("old-token", "new-token"),
+		Services.WithErrorHandler(func(err error, r *http.Request) { reported = append(reported, err) }),
+	)
+
+	cases := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"valid", "/mpesa/stk?token=new-token", http.StatusOK},
+		{"rotated", "/mpesa/stk?token=old-token", http.StatusOK},
+		{"missing", "/mpesa/stk", http.StatusForbidden},
+		{"wrong", "/mpesa/stk?token=guess", http.StatusForbidden},
+		{"prefix", "/mpesa/stk?token=new-toke", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if rec := postWebhookTo(handler, tc.path, stkCallbackSuccessJSON); rec.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.status, rec.Code)
+		}
+	}
+
+	if received != 2 {
+		t.Errorf("expected only callbacks with a valid token to be handled, got %d", received)
+	}
+	if len(reported) != 3 || !errors.Is(reported[0], Services.ErrInvalidCallbackToken) {
+		t.Errorf("expected rejected callbacks to be reported, got %v", reported)
+	}
+}
+
+func TestVerifyURLToken_NoTokensRejectsAll(t *testing.T) {
+	handler := Services.B2CResultHandler(nil, nil, Services.VerifyURLToken(""))
+	if rec := postWebhookTo(handler, "/mpesa/b2c?token=", b2cResultSuccessJSON); rec.Code != http.StatusForbidden {
+		t.Errorf("expected callback to be rejected without configured tokens, got %d", rec.Code)
+	}
+}
+
+func TestStkService_SetCallbackUrlWithToken(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"CheckoutRequestID": "ws_CO_1"})
+
+	service := Services.NewStkService(createTestConfig(), client)
+	service.SetAmount(100).SetTransactionType("CustomerPayBillOnline")
+	_, _ = service.SetPhoneNumber("0711223344")
+
+	if _, err := service.SetCallbackUrlWithToken("https://example.com/stk", "").Push(); err == nil {
+		t.Fatalf("expected error for empty token")
+	}
+
+	if _, err := service.SetCallbackUrlWithToken("https://example.com/stk", "secret").Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Fatalf("expected a single request, got %d", client.Count(mpesatest.AnyEndpoint))
+	}
+	if payload := client.LastPayload(mpesatest.AnyEndpoint); payload["CallBackURL"] != "https://example.com/stk?token=secret" {
+		t.Errorf("unexpected CallBackURL %v", payload["CallBackURL"])
+	}
+}
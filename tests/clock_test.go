@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 8, 12, 14, 30, 22, 0, time.UTC)
+	clock := Abstracts.NewFakeClock(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("expected the start time, got %v", clock.Now())
+	}
+	clock.Advance(90 * time.Second)
+	if want := start.Add(90 * time.Second); !clock.Now().Equal(want) {
+		t.Errorf("expected %v, got %v", want, clock.Now())
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("expected Set to move the clock back, got %v", clock.Now())
+	}
+}
+
+func TestBaseService_ClockTimestampAndPassword(t *testing.T) {
+	clock := Abstracts.NewFakeClock(time.Date(2024, 8, 12, 14, 30, 22, 0, time.Local))
+	client := mpesatest.NewRecordingClient()
+	stk := Services.NewStkService(createTestConfig(), client).
+		SetClock(clock).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("254708374149")
+
+	if got := stk.GenerateTimestamp(); got != "20240812143022" {
+		t.Errorf("expected the fake clock timestamp, got %s", got)
+	}
+	want := base64.StdEncoding.EncodeToString([]byte("174379test_passkey20240812143022"))
+	if got := stk.GeneratePassword(); got != want {
+		t.Errorf("expected password %s, got %s", want, got)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	want = base64.StdEncoding.EncodeToString([]byte("174379test_passkey20240812143122"))
+	client.AssertSent(t, stk.Config.Endpoints.StkPush, map[string]any{"Timestamp": "20240812143122", "Password": want})
+}
+
+func TestTokenManager_ClockExpiry(t *testing.T) {
+	server := mpesatest.NewServer()
+	defer server.Close()
+	cfg := createTestConfig()
+	cfg.SetBaseURL(server.BaseURL())
+	cachePath := filepath.Join(t.TempDir(), "token.json")
+	clock := Abstracts.NewFakeClock(time.Now())
+	manager := Abstracts.NewTokenManager(cfg).SetCachePath(cachePath).SetClock(clock)
+
+	first, err := manager.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken error: %v", err)
+	}
+	// Tokens are renewed a minute before the expiry M-Pesa gives.
+	clock.Advance(mpesatest.DefaultTokenTTL - 61*time.Second)
+	if token, _ := manager.GetToken(); token != first || server.TokensIssued() != 1 {
+		t.Errorf("expected the cached token before expiry, got %s after %d tokens", token, server.TokensIssued())
+	}
+
+	// A second manager reads the file cache with its own clock.
+	fresh := Abstracts.NewTokenManager(cfg).SetCachePath(cachePath).SetClock(clock)
+	if token, _ := fresh.GetToken(); token != first || server.TokensIssued() != 1 {
+		t.Errorf("expected the file cached token, got %s after %d tokens", token, server.TokensIssued())
+	}
+
+	clock.Advance(2 * time.Second)
+	second, err := manager.GetToken()
+	if err != nil {
+		t.Fatalf("GetToken error: %v", err)
+	}
+	if second == first || server.TokensIssued() != 2 {
+		t.Errorf("expected a new token after expiry, got %s after %d tokens", second, server.TokensIssued())
+	}
+}
+
+func TestMemoryStores_Clock(t *testing.T) {
+	clock := Abstracts.NewFakeClock(time.Now())
+
+	idempotency := Services.NewMemoryIdempotencyStore().SetClock(clock)
+	idempotency.Remember("order-1", time.Hour)
+	clock.Advance(time.Hour - time.Second)
+	if !idempotency.Seen("order-1") {
+		t.Errorf("expected the key to be remembered within its TTL")
+	}
+	clock.Advance(2 * time.Second)
+	if idempotency.Seen("order-1") {
+		t.Errorf("expected the key to expire with the clock")
+	}
+
+	processed := Services.NewMemoryProcessedStore().SetClock(clock)
+	processed.MarkProcessed("RKTQDM7W6S", time.Minute)
+	if processed.MarkProcessed("RKTQDM7W6S", time.Minute) {
+		t.Errorf("expected a duplicate within the TTL")
+	}
+	clock.Advance(time.Minute)
+	if !processed.MarkProcessed("RKTQDM7W6S", time.Minute) {
+		t.Errorf("expected the ID to expire with the clock")
+	}
+	for _, id := range []string{"SHK7A8Z9XY", "RJB53MYR1N"} {
+		processed.MarkProcessed(id, time.Minute)
+	}
+	clock.Advance(time.Hour)
+	processed.MarkProcessed("NLJ41HAY6Q", time.Minute)
+	if got := processed.Len(); got != 1 {
+		t.Errorf("expected expired IDs to be dropped, got %d IDs", got)
+	}
+
+	correlator := Services.NewMemoryResultCorrelator(time.Minute).SetClock(clock)
+	correlator.Resolve("AG_1", Services.CorrelatedResult{ResultCode: "0"})
+	clock.Advance(time.Minute)
+	select {
+	case res := <-correlator.Register("AG_1"):
+		t.Errorf("expected the pending result to expire with the clock, got %+v", res)
+	default:
+	}
+}
+
+func TestHandler_ClockReceiveTime(t *testing.T) {
+	received := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	dir := t.TempDir()
+	var hookTime time.Time
+	handler := Services.STKCallbackHandler(nil,
+		Services.WithClock(Abstracts.NewFakeClock(received)),
+		Services.WithRawPersistHook(func(ctx context.Context, callbackType string, raw []byte, headers http.Header) error {
+			hookTime, _ = Services.CallbackReceivedAt(ctx)
+			return Services.FileRawPersistHook(dir)(ctx, callbackType, raw, headers)
+		}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mpesa/stk", strings.NewReader(stkCallbackSuccessJSON)))
+	if rec.Code != http.StatusOK || !hookTime.Equal(received) {
+		t.Errorf("expected the hook to see the fake receive time, got %d %v", rec.Code, hookTime)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "20240115T103000.000000000Z-stk-*.json"))
+	if len(files) != 1 {
+		entries, _ := os.ReadDir(dir)
+		t.Errorf("expected the file to be named after the fake receive time, got %v", entries)
+	}
+
+	if _, ok := Services.CallbackReceivedAt(context.Background()); ok {
+		t.Errorf("expected no receive time outside a handler")
+	}
+}
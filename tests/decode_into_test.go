@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+type decodedB2CParameters struct {
+	TransactionAmount  float64
+	TransactionReceipt string
+	Registered         bool      `mpesa:"B2CRecipientIsRegisteredCustomer"`
+	CompletedAt        time.Time `mpesa:"TransactionCompletedDateTime"`
+	UtilityFunds       int64     `mpesa:"B2CUtilityAccountAvailableFunds"`
+}
+
+type decodedB2CResult struct {
+	Result struct {
+		ResultType     int
+		ResultCode     string
+		ConversationID string
+		OriginatorID   string               `mpesa:"OriginatorConversationID|OriginatorCoversationID"`
+		Parameters     decodedB2CParameters `mpesa:"ResultParameters,kv"`
+		ReferenceData  map[string]string    `mpesa:",kv"`
+	}
+}
+
+func TestDecodeInto_NestedKeyValues(t *testing.T) {
+	res, err := abstracts.DecodeInto[decodedB2CResult](decodeFixture(t, b2cResultSuccessJSON))
+	if err != nil {
+		t.Fatalf("DecodeInto error: %v", err)
+	}
+
+	r := res.Result
+	if r.ResultType != 0 || r.ResultCode != "0" || r.OriginatorID != "10571-7910404-1" || r.ConversationID != "AG_20191219_00004e48cf7e3533f581" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	p := r.Parameters
+	if p.TransactionAmount != 10 || p.TransactionReceipt != "NLJ41HAY6Q" || !p.Registered || p.UtilityFunds != 10116 {
+		t.Errorf("unexpected parameters: %+v", p)
+	}
+	if want := time.Date(2019, 12, 19, 11, 45, 50, 0, time.FixedZone("EAT", 3*60*60)); !p.CompletedAt.Equal(want) {
+		t.Errorf("expected completion time %v, got %v", want, p.CompletedAt)
+	}
+	if r.ReferenceData["QueueTimeoutURL"] == "" {
+		t.Errorf("expected single reference item to be flattened, got %v", r.ReferenceData)
+	}
+}
+
+func TestDecodeInto_STKMetadata(t *testing.T) {
+	type stk struct {
+		Body struct {
+			StkCallback struct {
+				ResultCode int
+				Metadata   struct {
+					Amount      float64
+					PhoneNumber string
+					Balance     *float64
+				} `mpesa:"CallbackMetadata,kv"`
+			} `mpesa:"stkCallback"`
+		}
+	}
+	res, err := abstracts.DecodeInto[stk](decodeFixture(t, stkCallbackSuccessJSON))
+	if err != nil {
+		t.Fatalf("DecodeInto error: %v", err)
+	}
+	cb := res.Body.StkCallback
+	if cb.ResultCode != 0 || cb.Metadata.Amount != 1 || cb.Metadata.PhoneNumber != "254708374149" || cb.Metadata.Balance != nil {
+		t.Errorf("unexpected callback: %+v", cb)
+	}
+}
+
+func TestDecodeInto_Coercion(t *testing.T) {
+	type target struct {
+		Amount   float64
+		Count    int
+		Code     string
+		Flag     bool
+		Enabled  bool
+		IDs      []string
+		Tags     []string
+		Balances map[string]float64
+		Extra    any
+		Optional *int
+		Skipped  string `mpesa:"-"`
+		Lower    string `json:"lower_key"`
+	}
+	res, err := abstracts.DecodeInto[target](map[string]any{
+		"amount":    "1,500.50",
+		"Count":     "42",
+		"Code":      float64(1032),
+		"Flag":      "Y",
+		"Enabled":   float64(1),
+		"IDs":       []any{"a", float64(7)},
+		"Tags":      "single",
+		"Balances":  map[string]any{"Working": "100.25", "Utility": float64(5)},
+		"Extra":     map[string]any{"nested": true},
+		"Optional":  "3",
+		"Skipped":   "ignored",
+		"lower_key": "json",
+	})
+	if err != nil {
+		t.Fatalf("DecodeInto error: %v", err)
+	}
+	if res.Amount != 1500.5 || res.Count != 42 || res.Code != "1032" || !res.Flag || !res.Enabled {
+		t.Errorf("unexpected scalars: %+v", res)
+	}
+	if len(res.IDs) != 2 || res.IDs[1] != "7" || len(res.Tags) != 1 || res.Tags[0] != "single" {
+		t.Errorf("unexpected slices: %v %v", res.IDs, res.Tags)
+	}
+	if res.Balances["Working"] != 100.25 || res.Balances["Utility"] != 5 {
+		t.Errorf("unexpected map: %v", res.Balances)
+	}
+	if res.Extra.(map[string]any)["nested"] != true || res.Optional == nil || *res.Optional != 3 {
+		t.Errorf("unexpected interface or pointer: %v %v", res.Extra, res.Optional)
+	}
+	if res.Skipped != "" || res.Lower != "json" {
+		t.Errorf("unexpected tagged fields: %+v", res)
+	}
+}
+
+func TestDecodeInto_Unmarshaler(t *testing.T) {
+	type stored struct {
+		ID      string
+		Payload Services.ReversalResult
+	}
+	res, err := abstracts.DecodeInto[stored](map[string]any{
+		"ID":      "row-1",
+		"Payload": decodeFixture(t, reversalResultSuccessJSON),
+	})
+	if err != nil {
+		t.Fatalf("DecodeInto error: %v", err)
+	}
+	if res.Payload.OriginalTransactionID != "NLJ11HAY8Z" || res.Payload.Amount != 150.75 {
+		t.Errorf("expected the reversal result to decode itself, got %+v", res.Payload)
+	}
+}
+
+func TestDecodeInto_Errors(t *testing.T) {
+	type item struct {
+		Value int
+	}
+	type target struct {
+		Code   string
+		Amount float64
+		Result struct {
+			Items []item
+			When  time.Time
+		}
+	}
+	res, err := abstracts.DecodeInto[target](map[string]any{
+		"Code":   "0",
+		"Amount": "abc",
+		"Result": map[string]any{
+			"Items": []any{map[string]any{"Value": 1}, map[string]any{"Value": "1.5"}},
+			"When":  "yesterday",
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected decode errors")
+	}
+	for _, path := range []string{"decode Amount:", "decode Result.Items[1].Value:", "decode Result.When:"} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("expected error for %q, got %v", path, err)
+		}
+	}
+	var decodeErr *abstracts.DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Path != "Amount" {
+		t.Errorf("expected a DecodeError for Amount, got %v", decodeErr)
+	}
+	if res.Code != "0" || len(res.Result.Items) != 2 || res.Result.Items[0].Value != 1 {
+		t.Errorf("expected the valid fields to be decoded, got %+v", res)
+	}
+
+	if _, err := abstracts.DecodeInto[target](map[string]any{"Result": "flat"}); err == nil || !strings.Contains(err.Error(), "decode Result: expected an object") {
+		t.Errorf("expected object error, got %v", err)
+	}
+	if _, err := abstracts.DecodeInto[target](nil); err != nil {
+		t.Errorf("expected nil map to decode to the zero value, got %v", err)
+	}
+}
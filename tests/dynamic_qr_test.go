@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func newQRRequest(client *mpesatest.RecordingClient, code Services.TrxCode, cpi string) *Services.DynamicQRService {
+	return Services.NewDynamicQRService(createTestConfig(), client).
+		SetMerchantName("TEST SUPERMARKET").
+		SetRefNo("Invoice Test").
+		SetAmount(1).
+		SetTrxCode(code).
+		SetCPI(cpi)
+}
+
+func TestDynamicQRService_TrxCodes(t *testing.T) {
+	cases := []struct {
+		name    string
+		code    Services.TrxCode
+		cpi     string
+		wantCPI string
+		wantErr string
+	}{
+		{"buy goods till", Services.TrxCodeBuyGoods, "373132", "373132", ""},
+		{"buy goods non-numeric till", Services.TrxCodeBuyGoods, "till-1", "", `invalid CPI "till-1" for TrxCode BG: must be a numeric till number`},
+		{"withdraw agent", Services.TrxCodeWithdrawAgent, "17408", "17408", ""},
+		{"withdraw agent non-numeric", Services.TrxCodeWithdrawAgent, "agent", "", `invalid CPI "agent" for TrxCode WA: must be a numeric agent number`},
+		{"paybill with account", Services.TrxCodePayBill, "12345|INV-001", "12345|INV-001", ""},
+		{"paybill without account", Services.TrxCodePayBill, "12345", "", `invalid CPI "12345" for TrxCode PB: must be formatted as "paybill|account", e.g. "12345|account"`},
+		{"paybill empty account", Services.TrxCodePayBill, "12345|", "", `invalid CPI "12345|" for TrxCode PB: must be formatted as "paybill|account", e.g. "12345|account"`},
+		{"send money", Services.TrxCodeSendMoney, "0711223344", "254711223344", ""},
+		{"send money invalid phone", Services.TrxCodeSendMoney, "12345678901", "", `invalid CPI for TrxCode SM: phone number has the wrong number of digits: the number has 11 digits; expected 0XXXXXXXXX, 7XXXXXXXX, 1XXXXXXXX or 254XXXXXXXXX`},
+		{"send to business", Services.TrxCodeSendToBusiness, "600000", "600000", ""},
+		{"send to business non-numeric", Services.TrxCodeSendToBusiness, "ACME", "", `invalid CPI "ACME" for TrxCode SB: must be a numeric business number`},
+		{"missing CPI", Services.TrxCodeBuyGoods, "", "", "CPI is required for TrxCode BG; call SetCPI"},
+		{"unknown code", Services.TrxCode("XX"), "373132", "", `invalid TrxCode "XX": must be one of BG, WA, PB, SM, SB`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := newQRRequest(client, tc.code, tc.cpi).Generate()
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) > 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			payload := client.LastPayload(mpesatest.AnyEndpoint)
+			if payload["TrxCode"] != string(tc.code) || payload["CPI"] != tc.wantCPI {
+				t.Errorf("unexpected payload: %v", payload)
+			}
+			if payload["Size"] != "300" || payload["Amount"] != 1 {
+				t.Errorf("unexpected defaults: size %v, amount %v", payload["Size"], payload["Amount"])
+			}
+			if client.LastEndpoint() != "/mpesa/qrcode/v1/generate" {
+				t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+			}
+		})
+	}
+}
+
+func TestDynamicQRService_Size(t *testing.T) {
+	cases := []struct {
+		size    string
+		wantErr bool
+	}{
+		{"1", false},
+		{"300", false},
+		{"500", false},
+		{"0", true},
+		{"501", true},
+		{"large", true},
+		{"", true},
+	}
+
+	for _, tc := range cases {
+		client := mpesatest.NewRecordingClient()
+		_, err := newQRRequest(client, Services.TrxCodeBuyGoods, "373132").SetSize(tc.size).Generate()
+		if tc.wantErr {
+			want := `invalid Size "` + tc.size + `": must be a number between 1 and 500`
+			if err == nil || err.Error() != want {
+				t.Errorf("size %q: expected error %q, got %v", tc.size, want, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("size %q: unexpected error %v", tc.size, err)
+			continue
+		}
+		if got := client.LastPayload(mpesatest.AnyEndpoint)["Size"]; got != tc.size {
+			t.Errorf("size %q: expected it to be sent, got %v", tc.size, got)
+		}
+	}
+}
+
+func TestDynamicQRService_QRCode(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"ResponseCode":        "AG_20191219_000043fdf61864fe9ff5",
+		"RequestID":           "16738-27456357-1",
+		"ResponseDescription": "QR Code Successfully Generated.",
+		"QRCode":              "iVBORw0KGgoAAAANSUhEUgAAASwAAAEsCAIAAAD2HxkiAAA",
+	})
+	service := Services.NewDynamicQRService(createTestConfig(), client).
+		SetMerchantName("TEST SUPERMARKET").
+		SetRefNo("Invoice Test").
+		SetAmount(1).
+		SetTrxCode(Services.TrxCodeBuyGoods).
+		SetCPI("373132")
+
+	if _, err := service.GetQRCode(); err == nil {
+		t.Errorf("expected error before generating")
+	}
+	if _, err := service.Generate(); err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	if code, err := service.GetQRCode(); err != nil || code != "iVBORw0KGgoAAAANSUhEUgAAASwAAAEsCAIAAAD2HxkiAAA" {
+		t.Errorf("unexpected QR code %q, err %v", code, err)
+	}
+}
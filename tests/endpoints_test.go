@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestDefaultEndpoints_AttachedToConfig(t *testing.T) {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+
+	if cfg.Endpoints != abstracts.DefaultEndpoints() {
+		t.Fatalf("expected default endpoints, got %+v", cfg.Endpoints)
+	}
+	if cfg.Endpoints.StkPush != "/mpesa/stkpush/v1/processrequest" {
+		t.Errorf("unexpected default STK push path: %s", cfg.Endpoints.StkPush)
+	}
+}
+
+func TestEndpointOverride_StkPush(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Endpoints.StkPush = "/mpesa/stkpush/v3/processrequest"
+	client := mpesatest.NewRecordingClient()
+
+	service := Services.NewStkService(cfg, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(100).
+		SetCallbackUrl("https://example.com/callback")
+	service, _ = service.SetPhoneNumber("254711223344")
+
+	if _, err := service.Push(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastEndpoint() != "/mpesa/stkpush/v3/processrequest" {
+		t.Errorf("expected overridden endpoint, got %s", client.LastEndpoint())
+	}
+}
+
+func TestEndpointOverride_Reversal(t *testing.T) {
+	cfg := buildTestConfig()
+	cfg.Endpoints.Reversal = "/mpesa/reversal/v2/request"
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetReceiverIdentifierType("11").
+		SetRemarks("Payment reversal").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastEndpoint() != "/mpesa/reversal/v2/request" {
+		t.Errorf("expected overridden endpoint, got %s", client.LastEndpoint())
+	}
+}
+
+func TestEndpointOverride_B2B(t *testing.T) {
+	cfg := buildTestConfig()
+	cfg.Endpoints.B2BPayment = "/mpesa/b2b/v2/paymentrequest"
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.ExecuteB2BRequest(cfg, client, Services.B2BRequest{
+		Initiator:          "testapi",
+		SecurityCredential: "FAKE",
+		CommandID:          "BusinessPayBill",
+		Amount:             100,
+		PartyB:             "600000",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if client.LastEndpoint() != "/mpesa/b2b/v2/paymentrequest" {
+		t.Errorf("expected overridden endpoint, got %s", client.LastEndpoint())
+	}
+}
+
+func TestEndpointOverride_TokenManager(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		_, _ = w.Write([]byte(`{"access_token":"token","expires_in":"3599"}`))
+	}))
+	defer server.Close()
+
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	cfg.SetBaseURL(server.URL)
+	tm := abstracts.NewTokenManager(cfg).SetCachePath(filepath.Join(t.TempDir(), "token.json"))
+
+	// Overriding the path after the token manager was created still takes effect.
+	cfg.Endpoints.OAuth = "/oauth/v2/generate?grant_type=client_credentials"
+	if _, err := tm.GetToken(); err != nil {
+		t.Fatalf("GetToken error: %v", err)
+	}
+	if len(requested) != 1 || requested[0] != cfg.Endpoints.OAuth {
+		t.Errorf("expected the token to be requested from the overridden OAuth path, got %v", requested)
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// errorRecorder collects the errors passed to a handler's error hook.
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (e *errorRecorder) hook(err error, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = append(e.errs, err)
+}
+
+func (e *errorRecorder) list() []error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]error(nil), e.errs...)
+}
+
+func TestHandlerRecovery_Panic(t *testing.T) {
+	panicking := func(*Services.B2CResult) { panic("nil ledger") }
+
+	var recorder errorRecorder
+	rec := postWebhook(Services.B2CResultHandler(panicking, nil, Services.WithErrorHandler(recorder.hook)), b2cResultSuccessJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected panic to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+	errs := recorder.list()
+	var panicErr *Services.CallbackPanicError
+	if len(errs) != 1 || !errors.As(errs[0], &panicErr) {
+		t.Fatalf("expected a CallbackPanicError to be reported, got %v", errs)
+	}
+	if panicErr.Value != "nil ledger" || !strings.Contains(string(panicErr.Stack), "handler_recovery_test.go") {
+		t.Errorf("expected panic value and stack, got %v\n%s", panicErr.Value, panicErr.Stack)
+	}
+
+	rec = postWebhook(Services.B2CResultHandler(panicking, nil, Services.WithAckPolicy(Services.StrictAck)), b2cResultSuccessJSON)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for panic under StrictAck, got %d", rec.Code)
+	}
+}
+
+func TestHandlerRecovery_Disabled(t *testing.T) {
+	handler := Services.STKCallbackHandler(func(*Services.STKCallback) { panic("boom") }, Services.WithPanicRecovery(false))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("expected panic to be re-raised, got %v", p)
+		}
+	}()
+	postWebhook(handler, stkCallbackSuccessJSON)
+	t.Errorf("expected handler to panic")
+}
+
+func TestHandlerRecovery_SlowCallback(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	slow := func(*Services.STKCallback) {
+		<-release
+		close(finished)
+		panic("late failure")
+	}
+
+	var recorder errorRecorder
+	handler := Services.STKCallbackHandler(slow, Services.WithTimeout(20*time.Millisecond), Services.WithErrorHandler(recorder.hook))
+
+	start := time.Now()
+	rec := postWebhook(handler, stkCallbackSuccessJSON)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected handler to respond at the timeout, took %s", elapsed)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected slow callback to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+	if errs := recorder.list(); len(errs) != 1 || !errors.Is(errs[0], Services.ErrCallbackTimeout) {
+		t.Fatalf("expected ErrCallbackTimeout to be reported, got %v", errs)
+	}
+
+	close(release)
+	<-finished
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.list()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	var panicErr *Services.CallbackPanicError
+	if errs := recorder.list(); len(errs) != 2 || !errors.As(errs[1], &panicErr) {
+		t.Errorf("expected the late panic to be reported, got %v", errs)
+	}
+
+	strict := Services.STKCallbackHandler(func(*Services.STKCallback) { time.Sleep(200 * time.Millisecond) },
+		Services.WithTimeout(20*time.Millisecond), Services.WithAckPolicy(Services.StrictAck))
+	if rec := postWebhook(strict, stkCallbackSuccessJSON); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for slow callback under StrictAck, got %d", rec.Code)
+	}
+}
+
+func TestHandlerRecovery_SlowCallbackContext(t *testing.T) {
+	cancelled := make(chan error, 1)
+	slow := func(ctx context.Context, cb *Services.STKCallback) {
+		select {
+		case <-ctx.Done():
+			cancelled <- ctx.Err()
+		case <-time.After(5 * time.Second):
+			cancelled <- nil
+		}
+	}
+
+	handler := Services.STKCallbackHandlerContext(slow, Services.WithTimeout(20*time.Millisecond))
+	if rec := postWebhook(handler, stkCallbackSuccessJSON); rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected slow callback to be acknowledged, got %d %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the callback context to expire, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the callback context to be cancelled at the timeout")
+	}
+
+	var seen context.Context
+	confirm := Services.C2BConfirmationHandlerContext(func(ctx context.Context, c *Services.C2BConfirmation) error {
+		seen = ctx
+		return nil
+	}, Services.WithTimeout(time.Minute))
+	postWebhook(confirm, c2bConfirmationLegacyJSON)
+	if seen == nil {
+		t.Fatalf("expected the confirmation function to be called")
+	}
+	if _, ok := seen.Deadline(); !ok || seen.Err() == nil {
+		t.Errorf("expected a context bounded by the timeout and cancelled after the handler returned, got %v", seen.Err())
+	}
+}
+
+func TestHandlerRecovery_TimeoutDisabled(t *testing.T) {
+	done := false
+	handler := Services.BillManagerPaymentHandler(func(*Services.BillManagerPayment) {
+		time.Sleep(50 * time.Millisecond)
+		done = true
+	}, Services.WithTimeout(0))
+
+	if rec := postWebhook(handler, billManagerPaymentJSON); rec.Code != http.StatusOK || !done {
+		t.Errorf("expected handler to wait for the callback without a timeout, got %d done=%v", rec.Code, done)
+	}
+}
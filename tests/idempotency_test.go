@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestB2CService_IdempotencyDuplicateDetection(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ResponseCode": "0", "ConversationID": "AG_1"})
+	store := Services.NewMemoryIdempotencyStore()
+
+	first := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetIdempotencyStore(store, time.Hour).
+		SetIdempotencyKey("payroll-line-42")
+	if _, err := first.Send(); err != nil {
+		t.Fatalf("expected first submission to succeed, got %v", err)
+	}
+
+	retry := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetIdempotencyStore(store, time.Hour).
+		SetIdempotencyKey("payroll-line-42")
+	previous, err := retry.Send()
+	if !errors.Is(err, Services.ErrDuplicateSubmission) {
+		t.Fatalf("expected ErrDuplicateSubmission, got %v", err)
+	}
+	if previous["ConversationID"] != "AG_1" {
+		t.Errorf("expected previously recorded response, got %v", previous)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Errorf("expected a single request, got %d", client.Count(mpesatest.AnyEndpoint))
+	}
+
+	other := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetIdempotencyStore(store, time.Hour).
+		SetIdempotencyKey("payroll-line-43")
+	if _, err := other.Send(); err != nil {
+		t.Fatalf("expected a different key to be sent, got %v", err)
+	}
+}
+
+func TestB2CService_IdempotencyFailedRequestStaysRemembered(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Fail(mpesatest.AnyEndpoint, errors.New("connection reset"))
+	store := Services.NewMemoryIdempotencyStore()
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetIdempotencyStore(store, time.Hour).
+		SetIdempotencyKey("payroll-line-42")
+
+	if _, err := service.Send(); err == nil {
+		t.Fatalf("expected transport error")
+	}
+	resp, err := service.Send()
+	if !errors.Is(err, Services.ErrDuplicateSubmission) || resp != nil {
+		t.Fatalf("expected ErrDuplicateSubmission without response, got %v / %v", resp, err)
+	}
+}
+
+func TestB2CService_IdempotencyRejectedRequestIsReleased(t *testing.T) {
+	rejected := &Abstracts.APIError{StatusCode: http.StatusBadRequest, ErrorCode: "400.002.02", ErrorMessage: "Bad Request - Invalid Amount"}
+	client := mpesatest.NewRecordingClient().FailNext(mpesatest.AnyEndpoint, rejected)
+	store := Services.NewMemoryIdempotencyStore()
+	service := newTestB2CService(client).
+		SetPhoneNumber("0711223344").
+		SetIdempotencyStore(store, time.Hour).
+		SetIdempotencyKey("payroll-line-42")
+
+	if _, err := service.Send(); !errors.Is(err, rejected) {
+		t.Fatalf("expected the API error, got %v", err)
+	}
+	if store.Seen("payroll-line-42") {
+		t.Errorf("expected the key of a rejected request to be released")
+	}
+	if _, err := service.SetAmount(1500).Send(); err != nil {
+		t.Fatalf("expected the corrected payment to be sent with the same key, got %v", err)
+	}
+
+	client.FailNext(mpesatest.AnyEndpoint, &Abstracts.APIError{StatusCode: http.StatusServiceUnavailable})
+	service.SetIdempotencyKey("payroll-line-43")
+	if _, err := service.Send(); err == nil {
+		t.Fatalf("expected the server error")
+	}
+	if !store.Seen("payroll-line-43") {
+		t.Errorf("expected the key to stay claimed after a server error")
+	}
+}
+
+func TestMemoryIdempotencyStore_DropsExpiredKeys(t *testing.T) {
+	clock := Abstracts.NewFakeClock(time.Now())
+	store := Services.NewMemoryIdempotencyStore().SetClock(clock)
+	for _, key := range []string{"payroll-line-1", "payroll-line-2", "payroll-line-3"} {
+		store.Claim(key, time.Hour)
+	}
+	clock.Advance(time.Hour)
+	if store.Seen("payroll-line-1") {
+		t.Errorf("expected a key to expire at the end of its TTL")
+	}
+	store.Remember("payroll-line-4", time.Hour)
+	if got := store.Len(); got != 1 {
+		t.Errorf("expected expired keys to be dropped, got %d keys", got)
+	}
+}
+
+func TestMemoryIdempotencyStore_TTLExpiry(t *testing.T) {
+	store := Services.NewMemoryIdempotencyStore()
+	store.Remember("key", 20*time.Millisecond)
+	if !store.Seen("key") {
+		t.Fatalf("expected key to be seen before expiry")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if store.Seen("key") {
+		t.Fatalf("expected key to expire")
+	}
+	if !store.Claim("key", time.Hour) {
+		t.Errorf("expected expired key to be claimable again")
+	}
+}
+
+func TestB2CService_IdempotencyConcurrentSubmissions(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	store := Services.NewMemoryIdempotencyStore()
+
+	var (
+		wg         sync.WaitGroup
+		sent       int32
+		duplicates int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := newTestB2CService(client).
+				SetPhoneNumber("0711223344").
+				SetIdempotencyStore(store, time.Hour).
+				SetIdempotencyKey("payroll-line-42").
+				Send()
+			switch {
+			case err == nil:
+				atomic.AddInt32(&sent, 1)
+			case errors.Is(err, Services.ErrDuplicateSubmission):
+				atomic.AddInt32(&duplicates, 1)
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sent != 1 || duplicates != 19 || client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Errorf("expected exactly one submission, got sent=%d duplicates=%d calls=%d", sent, duplicates, client.Count(mpesatest.AnyEndpoint))
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+func allowlistRequest(handler http.Handler, remoteAddr string, forwardedFor ...string) int {
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/stk", strings.NewReader(stkCallbackSuccessJSON))
+	req.RemoteAddr = remoteAddr
+	for _, xff := range forwardedFor {
+		req.Header.Add("X-Forwarded-For", xff)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestIPAllowlistMiddleware_DirectConnections(t *testing.T) {
+	var rejected []string
+	handler := Services.IPAllowlistMiddleware(Services.STKCallbackHandler(nil),
+		Services.WithOnRejected(func(ip string, r *http.Request) { rejected = append(rejected, ip) }))
+
+	if code := allowlistRequest(handler, "196.201.214.200:443"); code != http.StatusOK {
+		t.Errorf("expected published Safaricom address to be allowed, got %d", code)
+	}
+	if code := allowlistRequest(handler, "[::ffff:196.201.212.69]:443"); code != http.StatusOK {
+		t.Errorf("expected IPv4-mapped Safaricom address to be allowed, got %d", code)
+	}
+	if code := allowlistRequest(handler, "203.0.113.9:443"); code != http.StatusForbidden {
+		t.Errorf("expected unknown address to be rejected, got %d", code)
+	}
+	if code := allowlistRequest(handler, "203.0.113.9:443", "196.201.214.200"); code != http.StatusForbidden {
+		t.Errorf("expected X-Forwarded-For to be ignored without trusted hops, got %d", code)
+	}
+	if strings.Join(rejected, ",") != "203.0.113.9,203.0.113.9" {
+		t.Errorf("unexpected rejection hook calls %v", rejected)
+	}
+}
+
+func TestIPAllowlistMiddleware_TrustedProxyHops(t *testing.T) {
+	var rejected []string
+	handler := Services.IPAllowlistMiddleware(Services.STKCallbackHandler(nil),
+		Services.WithTrustedProxyHops(2),
+		Services.WithOnRejected(func(ip string, r *http.Request) { rejected = append(rejected, ip) }))
+
+	// Two proxies: the edge appends the caller, the internal balancer appends the edge.
+	if code := allowlistRequest(handler, "10.0.0.2:80", "196.201.213.44, 10.0.0.1"); code != http.StatusOK {
+		t.Errorf("expected proxied Safaricom address to be allowed, got %d", code)
+	}
+	if code := allowlistRequest(handler, "10.0.0.2:80", "196.201.213.44", "10.0.0.1"); code != http.StatusOK {
+		t.Errorf("expected repeated X-Forwarded-For headers to be combined, got %d", code)
+	}
+	if code := allowlistRequest(handler, "10.0.0.2:80", "10.0.0.1"); code != http.StatusForbidden {
+		t.Errorf("expected too few hops to be rejected, got %d", code)
+	}
+	if len(rejected) != 1 || rejected[0] != "" {
+		t.Errorf("expected rejection without a known address, got %v", rejected)
+	}
+}
+
+func TestIPAllowlistMiddleware_SpoofedForwardedFor(t *testing.T) {
+	handler := Services.IPAllowlistMiddleware(Services.STKCallbackHandler(nil), Services.WithTrustedProxyHops(1))
+
+	// The caller claims to be Safaricom; the trusted balancer appends the real address.
+	if code := allowlistRequest(handler, "10.0.0.1:80", "196.201.214.200, 203.0.113.9"); code != http.StatusForbidden {
+		t.Errorf("expected spoofed X-Forwarded-For to be rejected, got %d", code)
+	}
+	if code := allowlistRequest(handler, "10.0.0.1:80", "not-an-ip"); code != http.StatusForbidden {
+		t.Errorf("expected malformed X-Forwarded-For to be rejected, got %d", code)
+	}
+}
+
+func TestIPAllowlist_ReplaceAtRuntime(t *testing.T) {
+	allowlist, err := Services.NewIPAllowlist([]string{"203.0.113.0/24"})
+	if err != nil {
+		t.Fatalf("NewIPAllowlist error: %v", err)
+	}
+	handler := Services.IPAllowlistMiddleware(Services.STKCallbackHandler(nil), Services.WithAllowlist(allowlist))
+
+	if code := allowlistRequest(handler, "203.0.113.9:443"); code != http.StatusOK {
+		t.Errorf("expected custom range to be allowed, got %d", code)
+	}
+	if err := allowlist.SetCIDRs([]string{"198.51.100.7"}); err != nil {
+		t.Fatalf("SetCIDRs error: %v", err)
+	}
+	if code := allowlistRequest(handler, "203.0.113.9:443"); code != http.StatusForbidden {
+		t.Errorf("expected replaced range to be rejected, got %d", code)
+	}
+	if code := allowlistRequest(handler, "198.51.100.7:443"); code != http.StatusOK {
+		t.Errorf("expected single address entry to be allowed, got %d", code)
+	}
+
+	if err := allowlist.SetCIDRs([]string{"198.51.100.0/24", "bogus"}); err == nil {
+		t.Errorf("expected invalid CIDR to be rejected")
+	}
+	if code := allowlistRequest(handler, "198.51.100.7:443"); code != http.StatusOK {
+		t.Errorf("expected allowlist to be unchanged after invalid update, got %d", code)
+	}
+}
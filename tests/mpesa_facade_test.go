@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Mpesa"
+)
+
+func TestMpesaFacade_ServicesShareClient(t *testing.T) {
+	mpesa, err := Mpesa.New("test_key", "test_secret", "sandbox")
+	if err != nil {
+		t.Fatalf("Mpesa.New error: %v", err)
+	}
+
+	clients := map[string]Abstracts.MpesaInterface{
+		"STK":              mpesa.STK().Client,
+		"B2PayBill":        mpesa.B2PayBill().Client,
+		"B2CTopUp":         mpesa.B2CTopUp().Client,
+		"TaxRemittance":    mpesa.TaxRemittance().Client,
+		"DynamicQR":        mpesa.DynamicQR().Client,
+		"PullTransactions": mpesa.PullTransactions().Client,
+		"BillManager":      mpesa.BillManager().Client,
+	}
+	for name, client := range clients {
+		if client != Abstracts.MpesaInterface(mpesa.Client) {
+			t.Errorf("expected %s to use the facade's client", name)
+		}
+	}
+
+	if mpesa.DynamicQR().Config != mpesa.BillManager().Config {
+		t.Errorf("expected services to share the facade's config")
+	}
+}
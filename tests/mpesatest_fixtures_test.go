@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestFixtures_ParseWithTheirParseFunction(t *testing.T) {
+	parsers := []struct {
+		name    string
+		fixture mpesatest.Fixture
+		parse   func(mpesatest.Fixture) (success bool, err error)
+	}{
+		{"stk success", mpesatest.NewStkSuccessCallback(), func(f mpesatest.Fixture) (bool, error) {
+			cb, err := Services.ParseSTKCallback(f.Payload)
+			return err == nil && cb.Success && cb.MpesaReceiptNumber != "", err
+		}},
+		{"stk cancelled", mpesatest.NewStkCancelledCallback(), func(f mpesatest.Fixture) (bool, error) {
+			cb, err := Services.ParseSTKCallback(f.Payload)
+			return err == nil && !cb.Success && cb.ResultCode == "1032" && len(cb.Metadata) == 0, err
+		}},
+		{"c2b confirmation", mpesatest.NewC2BConfirmation(), func(f mpesatest.Fixture) (bool, error) {
+			c, err := Services.ParseC2BConfirmation(bytes.NewReader(f.JSON))
+			return err == nil && c.TransAmount == 10 && !c.TransTime.IsZero(), err
+		}},
+		{"c2b confirmation strict", mpesatest.NewC2BConfirmation(), func(f mpesatest.Fixture) (bool, error) {
+			_, err := Services.ParseC2BConfirmationStrict(bytes.NewReader(f.JSON))
+			return err == nil, err
+		}},
+		{"b2c", mpesatest.NewB2CResult(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseB2CResultStrict(f.Payload)
+			return err == nil && res.Success && res.B2CRecipientIsRegisteredCustomer && !res.TransactionCompletedDateTime.IsZero(), err
+		}},
+		{"b2b", mpesatest.NewB2BResult(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseB2BCallbackStrict(f.Payload)
+			return err == nil && res.Success && res.ResultParameters["Amount"] == "190.00" && res.ReferenceData["BillReferenceNumber"] == "19008", err
+		}},
+		{"reversal", mpesatest.NewReversalResult(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseReversalResult(f.Payload)
+			return err == nil && res.Success && res.DebitAccountBalance.Account == "Utility Account" && !res.TransCompletedTime.IsZero(), err
+		}},
+		{"account balance", mpesatest.NewAccountBalanceResult(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseAccountBalanceResult(f.Payload)
+			return err == nil && res.Success && len(res.Accounts) == 5 && res.Accounts[0].Available == 700000, err
+		}},
+		{"transaction status", mpesatest.NewTransactionStatusResult(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseTransactionStatusResult(f.Payload)
+			return err == nil && res.Success && res.TransactionStatus == "Completed" && res.InitiatedTime.Before(res.FinalisedTime), err
+		}},
+		{"queue timeout", mpesatest.NewQueueTimeout(), func(f mpesatest.Fixture) (bool, error) {
+			res, err := Services.ParseQueueTimeout(f.Payload)
+			return err == nil && Services.IsQueueTimeout(f.Payload) && res.ResultCode == "SFC_IC0003", err
+		}},
+	}
+
+	for _, tc := range parsers {
+		t.Run(tc.name, func(t *testing.T) {
+			if ok, err := tc.parse(tc.fixture); !ok {
+				t.Errorf("fixture did not parse as expected (err %v): %s", err, tc.fixture.JSON)
+			}
+			var decoded map[string]any
+			if err := json.Unmarshal(tc.fixture.JSON, &decoded); err != nil {
+				t.Fatalf("fixture JSON does not decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tc.fixture.Payload) {
+				t.Errorf("decoded JSON differs from Payload:\n%v\n%v", decoded, tc.fixture.Payload)
+			}
+		})
+	}
+}
+
+func TestFixtures_Options(t *testing.T) {
+	at := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	stk, err := Services.ParseSTKCallback(mpesatest.NewStkSuccessCallback(
+		mpesatest.WithCheckoutRequestID("ws_CO_custom"),
+		mpesatest.WithMerchantRequestID("merchant-1"),
+		mpesatest.WithAmount(250.5),
+		mpesatest.WithPhone("254712345678"),
+		mpesatest.WithTransactionID("SAB1CD2EF3"),
+		mpesatest.WithCompletedAt(at),
+	).Payload)
+	if err != nil {
+		t.Fatalf("ParseSTKCallback error: %v", err)
+	}
+	if stk.CheckoutRequestID != "ws_CO_custom" || stk.MerchantRequestID != "merchant-1" || stk.Amount != 250.5 ||
+		stk.PhoneNumber != "254712345678" || stk.MpesaReceiptNumber != "SAB1CD2EF3" || !stk.TransactionDate.Equal(at) {
+		t.Errorf("options not applied to the STK callback: %+v", stk)
+	}
+
+	failed, err := Services.ParseSTKCallback(mpesatest.NewStkCancelledCallback(mpesatest.WithResultCode(1037, "DS timeout user cannot be reached")).Payload)
+	if err != nil || failed.Success || failed.ResultCode != "1037" {
+		t.Errorf("expected a 1037 failure, got %+v %v", failed, err)
+	}
+
+	c2b, err := Services.ParseC2BConfirmation(bytes.NewReader(mpesatest.NewC2BConfirmation(
+		mpesatest.WithAmount(1500),
+		mpesatest.WithAccountReference("INV-42"),
+		mpesatest.WithShortCode("174379"),
+		mpesatest.WithPhone("254712345678"),
+		mpesatest.WithCompletedAt(at),
+	).JSON))
+	if err != nil {
+		t.Fatalf("ParseC2BConfirmation error: %v", err)
+	}
+	if c2b.TransAmount != 1500 || c2b.BillRefNumber != "INV-42" || c2b.BusinessShortCode != "174379" || c2b.MSISDN != "254712345678" || !c2b.TransTime.Equal(at) {
+		t.Errorf("options not applied to the confirmation: %+v", c2b)
+	}
+
+	b2c, err := Services.ParseB2CResult(mpesatest.NewB2CResult(
+		mpesatest.WithOriginatorConversationID("orig-1"),
+		mpesatest.WithConversationID("AG_custom"),
+		mpesatest.WithAmount(99.99),
+		mpesatest.WithCompletedAt(at),
+	).Payload)
+	if err != nil {
+		t.Fatalf("ParseB2CResult error: %v", err)
+	}
+	if b2c.OriginatorConversationID != "orig-1" || b2c.ConversationID != "AG_custom" || b2c.TransactionAmount != 99.99 || !b2c.TransactionCompletedDateTime.Equal(at) {
+		t.Errorf("options not applied to the B2C result: %+v", b2c)
+	}
+
+	// Failed results carry no parameters.
+	b2cFailed, err := Services.ParseB2CResultStrict(mpesatest.NewB2CResult(mpesatest.WithResultCode(2001, "The initiator information is invalid.")).Payload)
+	if err != nil || b2cFailed.Success || b2cFailed.ResultCode != "2001" || len(b2cFailed.ResultParameters) != 0 {
+		t.Errorf("expected a 2001 failure without parameters, got %+v %v", b2cFailed, err)
+	}
+
+	status, err := Services.ParseTransactionStatusResult(mpesatest.NewTransactionStatusResult(mpesatest.WithTransactionID("SAB1CD2EF3"), mpesatest.WithAmount(42)).Payload)
+	if err != nil || status.ReceiptNo != "SAB1CD2EF3" || status.Amount != 42 {
+		t.Errorf("options not applied to the status result: %+v %v", status, err)
+	}
+}
+
+func TestFixtures_DeliveredToRecorder(t *testing.T) {
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+
+	fixture := mpesatest.NewStkSuccessCallback(mpesatest.WithCheckoutRequestID("ws_CO_fixture"))
+	if status, ack := deliver(t, recorder.URL(Services.CallbackSTK), string(fixture.JSON)); status != http.StatusOK || ack != webhookAckBody {
+		t.Fatalf("expected the fixture to be acknowledged, got %d %s", status, ack)
+	}
+	if cb, ok := recorder.Callbacks()[0].Parsed.(*Services.STKCallback); !ok || cb.CheckoutRequestID != "ws_CO_fixture" || !cb.Success {
+		t.Errorf("expected the recorded fixture, got %#v", recorder.Callbacks()[0].Parsed)
+	}
+}
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// capturingTB records the errors reported through it instead of failing the test.
+type capturingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (c *capturingTB) Helper() {}
+
+func (c *capturingTB) Errorf(format string, args ...any) {
+	c.errors = append(c.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecordingClient_Responses(t *testing.T) {
+	apiErr := errors.New("upstream unavailable")
+	client := mpesatest.NewRecordingClient().
+		Respond("/a", map[string]any{"from": "a"}).
+		Respond(mpesatest.AnyEndpoint, map[string]any{"from": "any"}).
+		Enqueue("/a", mpesatest.Response{Body: map[string]any{"from": "queue a"}}).
+		FailNext(mpesatest.AnyEndpoint, apiErr)
+
+	steps := []struct {
+		endpoint string
+		want     string
+		err      error
+	}{
+		{"/a", "queue a", nil}, // The endpoint queue comes first,
+		{"/a", "", apiErr},     // then the queue for any endpoint,
+		{"/a", "a", nil},       // then the response for the endpoint
+		{"/b", "any", nil},     // and the one for any endpoint.
+		{"/a", "a", nil},       // Standing responses are reused.
+		{"/b", "any", nil},
+	}
+	for i, step := range steps {
+		resp, err := client.ExecuteRequest(map[string]any{"step": i}, step.endpoint)
+		if err != step.err || (step.err == nil && resp["from"] != step.want) {
+			t.Errorf("step %d: expected %q %v, got %v %v", i, step.want, step.err, resp, err)
+		}
+	}
+
+	if resp, err := mpesatest.NewRecordingClient().ExecuteRequest(nil, "/a"); err != nil || resp["ResponseCode"] != "0" {
+		t.Errorf("expected the default response, got %v %v", resp, err)
+	}
+
+	failing := mpesatest.NewRecordingClient().Fail("/a", apiErr)
+	if _, err := failing.ExecuteRequest(nil, "/a"); err != apiErr {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+	if _, err := failing.ExecuteRequest(nil, "/b"); err != nil {
+		t.Errorf("expected other endpoints to succeed, got %v", err)
+	}
+
+	echo := mpesatest.NewRecordingClient().RespondWith(mpesatest.AnyEndpoint, func(payload any) (map[string]any, error) {
+		return map[string]any{"echo": payload.(map[string]any)["Amount"]}, nil
+	})
+	if resp, _ := echo.ExecuteRequest(map[string]any{"Amount": "10"}, "/a"); resp["echo"] != "10" {
+		t.Errorf("expected the func response, got %v", resp)
+	}
+}
+
+func TestRecordingClient_Captures(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	cfg := createTestConfig()
+	stk := Services.NewStkService(cfg, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("0712345678")
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	if _, err := stk.Query("ws_CO_1"); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	// Payloads that are not maps are converted through JSON.
+	_, _ = client.ExecuteRequest(struct{ Size int }{3}, "/custom")
+
+	if client.Count(mpesatest.AnyEndpoint) != 3 || client.Count(cfg.Endpoints.StkPush) != 1 || client.LastEndpoint() != "/custom" {
+		t.Errorf("unexpected calls: %+v", client.Calls())
+	}
+	if client.LastPayload(cfg.Endpoints.StkQuery)["CheckoutRequestID"] != "ws_CO_1" || client.Payload(0)["PhoneNumber"] != "254712345678" {
+		t.Errorf("unexpected payloads: %v", client.Payloads(mpesatest.AnyEndpoint))
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["Size"]; got != float64(3) {
+		t.Errorf("expected the converted payload, got %v", got)
+	}
+	if client.LastPayload("/never") != nil {
+		t.Errorf("expected no payload for an endpoint without requests")
+	}
+
+	if !client.AssertSent(t, cfg.Endpoints.StkPush, map[string]any{"Amount": 10, "CallBackURL": "https://example.com/stk"}) {
+		t.Errorf("expected the push payload to match")
+	}
+	if !client.AssertSent(t, mpesatest.AnyEndpoint, map[string]any{"Size": 3}) {
+		t.Errorf("expected numbers to match across types")
+	}
+
+	tb := &capturingTB{TB: t}
+	if client.AssertSent(tb, cfg.Endpoints.StkPush, map[string]any{"Amount": 11}) || client.AssertSent(tb, cfg.Endpoints.StkQuery, map[string]any{"Amount": 10}) {
+		t.Errorf("expected mismatching payloads not to match")
+	}
+	if len(tb.errors) != 2 || !strings.Contains(tb.errors[0], "{Amount: 11}") {
+		t.Errorf("unexpected failure reports: %v", tb.errors)
+	}
+
+	client.Reset()
+	if client.Count(mpesatest.AnyEndpoint) != 0 {
+		t.Errorf("expected Reset to forget the calls")
+	}
+}
+
+func TestRecordingClient_CannedResponses(t *testing.T) {
+	cfg := createTestConfig()
+	client := mpesatest.NewRecordingClient().
+		Enqueue(cfg.Endpoints.StkPush, mpesatest.StkPushAccepted(mpesatest.WithCheckoutRequestID("ws_CO_1"))).
+		Enqueue(cfg.Endpoints.StkQuery,
+			mpesatest.StkQueryPending(),
+			mpesatest.StkQueryResult(mpesatest.WithCheckoutRequestID("ws_CO_1"), mpesatest.WithResultCode(1032, "Request cancelled by user")),
+		)
+	stk := Services.NewStkService(cfg, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("254708374149")
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	if id, _ := stk.GetCheckoutRequestID(); id != "ws_CO_1" {
+		t.Errorf("expected the canned CheckoutRequestID, got %q", id)
+	}
+	if resp, err := stk.Query("ws_CO_1"); err != nil || resp["errorCode"] != "500.001.1001" {
+		t.Errorf("expected the pending response, got %v %v", resp, err)
+	}
+	if resp, err := stk.Query("ws_CO_1"); err != nil || resp["ResultCode"] != "1032" || resp["CheckoutRequestID"] != "ws_CO_1" {
+		t.Errorf("expected the cancelled result, got %v %v", resp, err)
+	}
+
+	accepted := mpesatest.RequestAccepted(mpesatest.WithConversationID("AG_1")).Body
+	if accepted["ConversationID"] != "AG_1" || accepted["OriginatorConversationID"] == "" || accepted["ResponseCode"] != "0" {
+		t.Errorf("unexpected acknowledgement %v", accepted)
+	}
+
+	b2c := newTestB2CService(mpesatest.NewRecordingClient().
+		Enqueue(mpesatest.AnyEndpoint, mpesatest.APIFailure(http.StatusBadRequest, "400.002.02", "Bad Request - Invalid PartyB")))
+	_, err := b2c.SetPhoneNumber("254708374149").Send()
+	var apiErr *Abstracts.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorCode != "400.002.02" {
+		t.Errorf("expected the canned API error, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Mpesa"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// newSimulatedMpesa returns a client pointed at server, with its own token cache.
+func newSimulatedMpesa(t *testing.T, server *mpesatest.Server) *Mpesa.Mpesa {
+	t.Helper()
+	m, err := Mpesa.New("simulator_key", "simulator_secret", "sandbox")
+	if err != nil {
+		t.Fatalf("Mpesa.New error: %v", err)
+	}
+	m.SetBaseURL(server.BaseURL())
+	m.Client.TokenManager.SetCachePath(filepath.Join(t.TempDir(), "token.json"))
+	m.SetBusinessCode("174379")
+	m.SetPassKey("bfb279f9aa9bdbcf158e97dd71a467cd2e0c893059b10f78e6b72ada1ed2c919")
+	m.Config.OverrideSecurityCredential("c2ltdWxhdG9y")
+	return m
+}
+
+// pushSTK sends an STK Push for amount to callbackURL and returns its CheckoutRequestID.
+func pushSTK(t *testing.T, m *Mpesa.Mpesa, amount, callbackURL string) string {
+	t.Helper()
+	stk := m.STK().SetTransactionType("CustomerPayBillOnline").SetAmount(amount).SetCallbackUrl(callbackURL)
+	if _, err := stk.SetPhoneNumber("254712345678"); err != nil {
+		t.Fatalf("SetPhoneNumber error: %v", err)
+	}
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	checkoutID, err := stk.GetCheckoutRequestID()
+	if err != nil {
+		t.Fatalf("GetCheckoutRequestID error: %v", err)
+	}
+	return checkoutID
+}
+
+func TestServer_StkPushQueryCallbackFlow(t *testing.T) {
+	server := mpesatest.NewServer(mpesatest.WithCallbackDelay(100 * time.Millisecond))
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	m := newSimulatedMpesa(t, server)
+
+	checkoutID := pushSTK(t, m, "250", recorder.URL(Services.CallbackSTK))
+
+	// Until the callback is delivered the transaction is being processed.
+	status, err := m.STK().Query(checkoutID)
+	if err != nil || status["errorCode"] != "500.001.1001" {
+		t.Fatalf("expected a pending query, got %v %v", status, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cb, err := recorder.WaitForStkCallback(ctx, checkoutID)
+	if err != nil {
+		t.Fatalf("WaitForStkCallback error: %v", err)
+	}
+	if !cb.Success || cb.Amount != 250 || cb.PhoneNumber != "254712345678" || cb.MpesaReceiptNumber == "" {
+		t.Errorf("unexpected callback: %+v", cb)
+	}
+
+	status, err = m.STK().Query(checkoutID)
+	if err != nil || status["ResultCode"] != "0" || status["MerchantRequestID"] != cb.MerchantRequestID {
+		t.Errorf("expected a completed query, got %v %v", status, err)
+	}
+
+	// The next query is scripted to report a cancellation.
+	server.Script(mpesatest.OpStkQuery, mpesatest.UserCancelled())
+	if status, err := m.STK().Query(checkoutID); err != nil || status["ResultCode"] != "1032" {
+		t.Errorf("expected the scripted cancellation, got %v %v", status, err)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 4 || requests[0].Operation != mpesatest.OpStkPush || requests[0].Payload["Amount"] != float64(250) {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+	if deliveries := server.Deliveries(); len(deliveries) != 1 || deliveries[0].Status != http.StatusOK || deliveries[0].Err != nil {
+		t.Errorf("unexpected deliveries: %+v", deliveries)
+	}
+}
+
+func TestServer_ScriptedStkScenarios(t *testing.T) {
+	server := mpesatest.NewServer()
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	m := newSimulatedMpesa(t, server)
+
+	server.Script(mpesatest.OpStkPush, mpesatest.UserCancelled(), mpesatest.Pending())
+	cancelled := pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+	ignored := pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cb, err := recorder.WaitForStkCallback(ctx, cancelled)
+	if err != nil || cb.Success || cb.ResultCode != "1032" || len(cb.Metadata) != 0 {
+		t.Errorf("expected a cancelled callback, got %+v %v", cb, err)
+	}
+	if status, err := m.STK().Query(cancelled); err != nil || status["ResultCode"] != "1032" {
+		t.Errorf("expected the query to report the cancellation, got %v %v", status, err)
+	}
+	if status, err := m.STK().Query(ignored); err != nil || status["errorCode"] != "500.001.1001" {
+		t.Errorf("expected the ignored prompt to stay pending, got %v %v", status, err)
+	}
+	if recorder.Count(Services.CallbackSTK) != 1 {
+		t.Errorf("expected no callback for the ignored prompt, got %+v", recorder.Callbacks())
+	}
+
+	// Rejected requests answer with a Daraja error, after the scripted latency.
+	server.Script(mpesatest.OpStkPush, mpesatest.Scenario{
+		Status: http.StatusBadRequest, ErrorCode: "400.002.02", ErrorMessage: "Bad Request - Invalid Amount", Latency: 50 * time.Millisecond,
+	})
+	stk := m.STK().SetTransactionType("CustomerPayBillOnline").SetAmount("10").SetCallbackUrl(recorder.URL(Services.CallbackSTK))
+	_, _ = stk.SetPhoneNumber("254712345678")
+	start := time.Now()
+	_, err = stk.Push()
+	var apiErr *abstracts.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorCode != "400.002.02" {
+		t.Errorf("expected the scripted rejection, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the scripted latency, answered after %s", elapsed)
+	}
+}
+
+func TestServer_ResultCallbacks(t *testing.T) {
+	server := mpesatest.NewServer()
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	m := newSimulatedMpesa(t, server)
+
+	b2c := m.B2C().
+		SetInitiatorName("testapi").
+		SetCommandID("BusinessPayment").
+		SetAmount(1500).
+		SetRemarks("Payout").
+		SetPhoneNumber("254712345678").
+		SetOriginatorConversationID("payout-0001").
+		SetResultURL(recorder.URL(Services.CallbackB2CResult)).
+		SetQueueTimeoutURL(recorder.URL(Services.CallbackB2CTimeout))
+	if _, err := b2c.Send(); err != nil {
+		t.Fatalf("B2C Send error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rec, err := recorder.WaitFor(ctx, func(c mpesatest.RecordedCallback) bool { return c.Type == Services.CallbackB2CResult })
+	if err != nil {
+		t.Fatalf("no B2C result: %v", err)
+	}
+	if res, ok := rec.Parsed.(*Services.B2CResult); !ok || !res.Success || res.OriginatorConversationID != "payout-0001" || res.TransactionAmount != 1500 {
+		t.Errorf("unexpected B2C result: %#v", rec.Parsed)
+	}
+
+	// A scripted queue timeout is sent to the QueueTimeOutURL.
+	server.Script(mpesatest.OpAccountBalance, mpesatest.QueueTimeout())
+	balance := m.AccountBalance().
+		SetInitiator("testapi").
+		SetResultURL(recorder.URL(Services.CallbackBalanceResult)).
+		SetQueueTimeoutURL(recorder.URL(Services.CallbackBalanceTimeout))
+	if _, err := balance.Query(); err != nil {
+		t.Fatalf("balance Query error: %v", err)
+	}
+	if _, err := recorder.WaitFor(ctx, func(c mpesatest.RecordedCallback) bool { return c.Type == Services.CallbackBalanceTimeout }); err != nil {
+		t.Errorf("no balance timeout: %v", err)
+	}
+}
+
+func TestServer_C2BRegisterAndSimulate(t *testing.T) {
+	server := mpesatest.NewServer()
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	m := newSimulatedMpesa(t, server)
+
+	c2b := m.C2B().
+		SetShortCode("600638").
+		SetConfirmationURL(recorder.URL(Services.CallbackC2BConfirmation)).
+		SetValidationURL(recorder.URL(Services.CallbackC2BValidation))
+	if err := c2b.RegisterURLs(); err != nil {
+		t.Fatalf("RegisterURLs error: %v", err)
+	}
+	c2b.SetTypedCommandID(Services.CommandCustomerPayBillOnline).SetAmount("100").SetPhoneNumber("254712345678").SetBillRefNumber("INV-42")
+	if _, err := c2b.Simulate(); err != nil {
+		t.Fatalf("Simulate error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rec, err := recorder.WaitFor(ctx, func(c mpesatest.RecordedCallback) bool { return c.Type == Services.CallbackC2BConfirmation })
+	if err != nil {
+		t.Fatalf("no confirmation: %v", err)
+	}
+	if c, ok := rec.Parsed.(*Services.C2BConfirmation); !ok || c.TransAmount != 100 || c.BillRefNumber != "INV-42" || c.BusinessShortCode != "600638" || c.MSISDN != "254712345678" {
+		t.Errorf("unexpected confirmation: %#v", rec.Parsed)
+	}
+}
+
+func TestServer_Tokens(t *testing.T) {
+	server := mpesatest.NewServer(mpesatest.WithTokenTTL(time.Second))
+	defer server.Close()
+	recorder := mpesatest.NewCallbackRecorder()
+	defer recorder.Close()
+	m := newSimulatedMpesa(t, server)
+
+	pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+	pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+	if n := server.TokensIssued(); n != 1 {
+		t.Errorf("expected the token to be reused, %d issued", n)
+	}
+
+	// Once the token expires a new one is requested.
+	time.Sleep(1100 * time.Millisecond)
+	pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+	if n := server.TokensIssued(); n != 2 {
+		t.Errorf("expected a new token after expiry, %d issued", n)
+	}
+
+	// Tokens of another server are rejected with 401, and the client requests a new one.
+	other := mpesatest.NewServer()
+	defer other.Close()
+	m.SetBaseURL(other.BaseURL())
+	pushSTK(t, m, "10", recorder.URL(Services.CallbackSTK))
+	if n := other.TokensIssued(); n != 1 {
+		t.Errorf("expected the client to refresh its token, %d issued", n)
+	}
+
+	resp, err := http.Post(other.BaseURL()+"/mpesa/stkpush/v1/processrequest", "application/json", nil)
+	if err != nil {
+		t.Fatalf("post error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+}
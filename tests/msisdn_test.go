@@ -0,0 +1,422 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestNormalizeMSISDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		phone    string
+		expected string
+		err      error  // Sentinel the error must wrap
+		message  string // Text the error must contain
+	}{
+		{"Valid phone number with country code", "254711223344", "254711223344", nil, ""},
+		{"Valid phone number without country code", "0711223344", "254711223344", nil, ""},
+		{"Valid international phone number", "+254711223344", "254711223344", nil, ""},
+		{"Valid Safaricom 011 number", "0111844429", "254111844429", nil, ""},
+		{"Surrounding spaces", "  0711223344 ", "254711223344", nil, ""},
+		{"Grouped with spaces", "+254 711 223 344", "254711223344", nil, ""},
+		{"Grouped local number", "0711 223 344", "254711223344", nil, ""},
+		{"Dashes and parentheses", "(0711) 223-344", "254711223344", nil, ""},
+		{"Country code in parentheses", " (254)711223344", "254711223344", nil, ""},
+		{"International in parentheses", "+(254) 711 223344", "254711223344", nil, ""},
+		{"Dots", "0711.223.344", "254711223344", nil, ""},
+		{"Dotted international", "+254.711.223.344", "254711223344", nil, ""},
+		{"International call prefix", "00254711223344", "254711223344", nil, ""},
+		{"Foreign international number", "+1 (555) 123-4567", "15551234567", nil, ""},
+		{"Bare subscriber number", "711223344", "254711223344", nil, ""},
+		{"Bare 01 subscriber number", "110123456", "254110123456", nil, ""},
+		{"Bare number with spaces", "712 345 678", "254712345678", nil, ""},
+		{"Empty phone number", "", "", Abstracts.ErrMSISDNEmpty, ""},
+		{"Blank phone number", "   ", "", Abstracts.ErrMSISDNEmpty, ""},
+		{"Short phone number", "123", "", Abstracts.ErrMSISDNTooShort, "3 digits"},
+		{"Short after separators", "07-11-22", "", Abstracts.ErrMSISDNTooShort, "6 digits"},
+		{"Only separators", "( ) - .", "", Abstracts.ErrMSISDNTooShort, "0 digits"},
+		{"Plus only", "+", "", Abstracts.ErrMSISDNTooShort, "0 digits"},
+		{"Word", "phone", "", Abstracts.ErrMSISDNInvalidCharacter, "'p'"},
+		{"Letters", "0711ABC344", "", Abstracts.ErrMSISDNInvalidCharacter, "'A'"},
+		{"Only letters", "abc-def-ghi", "", Abstracts.ErrMSISDNInvalidCharacter, "'a'"},
+		{"Letter O for zero", "O711223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'O'"},
+		{"Double plus", "++254711223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'+'"},
+		{"Plus in the middle", "254+711223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'+'"},
+		{"Extension", "0711223344 ext 2", "", Abstracts.ErrMSISDNInvalidCharacter, "'e'"},
+		{"Slash", "0711/223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'/'"},
+		{"Brackets", "[0711]223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'['"},
+		{"Tab inside", "0711\t223344", "", Abstracts.ErrMSISDNInvalidCharacter, "'\\t'"},
+		{"Arabic-Indic digits", "٠٧١١٢٢٣٣٤٤", "", Abstracts.ErrMSISDNInvalidCharacter, "'٠'"},
+		{"Fullwidth digits", "０７１１２２３３４４", "", Abstracts.ErrMSISDNInvalidCharacter, "'０'"},
+		{"Bare number not starting with 7 or 1", "212345678", "", Abstracts.ErrMSISDNLength, "has 9 digits"},
+		{"Local number too long", "07112233445", "", Abstracts.ErrMSISDNLength, "has 11 digits"},
+		{"Ten digits without a leading 0", "7112233445", "", Abstracts.ErrMSISDNLength, "has 10 digits"},
+		{"Country code too short", "25471122334", "", Abstracts.ErrMSISDNLength, "has 11 digits"},
+		{"Country code and leading 0", "2540711223344", "", Abstracts.ErrMSISDNLength, "has 13 digits"},
+		{"Foreign number without +", "15551234567", "", Abstracts.ErrMSISDNLength, "has 11 digits"},
+		{"International too short", "+25471122334", "", Abstracts.ErrMSISDNLength, "8 digits after the country code"},
+		{"International too long", "+254 7112 233 445", "", Abstracts.ErrMSISDNLength, "10 digits after the country code"},
+		{"Call prefix too long", "002547112233445", "", Abstracts.ErrMSISDNLength, "10 digits after the country code"},
+		{"International 11 digits", "+254 7112 2334", "", Abstracts.ErrMSISDNLength, "is 11 digits long"},
+		{"International 13 digits", "+2547112233445", "", Abstracts.ErrMSISDNLength, "is 13 digits long"},
+		{"Country code 13 digits", "2547112233445", "", Abstracts.ErrMSISDNLength, "has 13 digits"},
+		{"Call prefix 11 digits", "0025471122334", "", Abstracts.ErrMSISDNLength, "is 11 digits long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Abstracts.NormalizeMSISDN(tt.phone, "254")
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || !strings.Contains(err.Error(), tt.message) {
+					t.Fatalf("expected %q with %q, got %q %v", tt.err, tt.message, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("expected %s, got %q %v", tt.expected, got, err)
+			}
+		})
+	}
+
+	if got, _ := Abstracts.NormalizeMSISDN("0712345678", "+255"); got != "255712345678" {
+		t.Errorf("expected the country code without +, got %s", got)
+	}
+}
+
+func TestValidateMSISDN(t *testing.T) {
+	tests := []struct {
+		msisdn string
+		err    string
+	}{
+		{"254711223344", ""},
+		{"254111844429", ""},
+		{"255712345678", ""},
+		{"15551234567", ""},
+		{"", "cannot be empty"},
+		{"+254711223344", "only contain digits"},
+		{"254 711 223 344", "only contain digits"},
+		{"0711223344", "without a leading 0"},
+		{"711223344", "between 10 and 15 digits"},
+		{"1234567890123456", "between 10 and 15 digits"},
+		{"254201234567", "Kenyan mobile number"},
+		{"2547112233445", "Kenyan mobile number"},
+		{"25471122334", "Kenyan mobile number"},
+	}
+
+	for _, tt := range tests {
+		err := Abstracts.ValidateMSISDN(tt.msisdn)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("ValidateMSISDN(%q): expected no error, got %v", tt.msisdn, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ValidateMSISDN(%q): expected error %q, got %v", tt.msisdn, tt.err, err)
+		}
+	}
+}
+
+func TestMSISDN_ConcurrentUse(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				msisdn, err := Abstracts.NormalizeMSISDN("+254 711-223-344", "254")
+				if err == nil {
+					err = Abstracts.ValidateMSISDN(msisdn)
+				}
+				if err != nil || msisdn != "254711223344" {
+					t.Errorf("expected 254711223344, got %q %v", msisdn, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMSISDN_LenientAndStrict(t *testing.T) {
+	tests := []struct {
+		name        string
+		phone       string
+		expected    string
+		lenient     bool // Accepted by ValidateMSISDN
+		strict      bool // Accepted by ValidateMSISDNStrict with the default prefixes
+		wrongPrefix bool // Rejected by strict mode with ErrUnsupportedPrefix
+	}{
+		{"Local 07", "0712345678", "254712345678", true, true, false},
+		{"International 07", "+254712345678", "254712345678", true, true, false},
+		{"Local 0110", "0110123456", "254110123456", true, true, false},
+		{"Local 0115", "0115123456", "254115123456", true, true, false},
+		{"Local 0116", "0116123456", "254116123456", true, true, false},
+		{"International 01 with dashes", "+254-110-123-456", "254110123456", true, true, false},
+		{"Country code 01", "254111844429", "254111844429", true, true, false},
+		{"Kenyan landline", "0201234567", "254201234567", false, false, false},
+		{"Tanzanian", "+255712345678", "255712345678", true, false, true},
+		{"Ugandan", "+256772123456", "256772123456", true, false, true},
+		{"US", "+1 555 123 4567", "15551234567", true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msisdn, err := Abstracts.NormalizeMSISDN(tt.phone, "254")
+			if err != nil || msisdn != tt.expected {
+				t.Fatalf("expected %s, got %q %v", tt.expected, msisdn, err)
+			}
+			if err := Abstracts.ValidateMSISDN(msisdn); (err == nil) != tt.lenient {
+				t.Errorf("lenient: expected valid %v, got %v", tt.lenient, err)
+			}
+			err = Abstracts.ValidateMSISDNStrict(msisdn)
+			if (err == nil) != tt.strict {
+				t.Errorf("strict: expected valid %v, got %v", tt.strict, err)
+			}
+			if errors.Is(err, Abstracts.ErrUnsupportedPrefix) != tt.wrongPrefix {
+				t.Errorf("strict: expected ErrUnsupportedPrefix %v, got %v", tt.wrongPrefix, err)
+			}
+		})
+	}
+
+	if err := Abstracts.ValidateMSISDNStrict("255712345678", "2547", "+255"); err != nil {
+		t.Errorf("expected a configured prefix to be allowed, got %v", err)
+	}
+	if err := Abstracts.ValidateMSISDNStrict("254110123456", "2547"); !errors.Is(err, Abstracts.ErrUnsupportedPrefix) {
+		t.Errorf("expected 01 numbers to be rejected when only 2547 is allowed, got %v", err)
+	}
+}
+
+func TestServices_StrictPhonePrefixes(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+
+	b2c := newTestB2CService(client).SetPhoneNumber("+255712345678")
+	if _, err := b2c.Send(); err != nil {
+		t.Fatalf("expected lenient B2C to accept a foreign number, got %v", err)
+	}
+	if _, err := b2c.SetStrictPhonePrefixes().Send(); !errors.Is(err, Abstracts.ErrUnsupportedPrefix) {
+		t.Errorf("expected strict B2C to reject a foreign number, got %v", err)
+	}
+	if _, err := b2c.SetPhoneNumber("0110123456").Send(); err != nil {
+		t.Errorf("expected strict B2C to accept an 01 number, got %v", err)
+	}
+	if _, err := b2c.SetRawPhoneNumber("255712345678").Send(); err != nil {
+		t.Errorf("expected raw numbers to skip strict validation, got %v", err)
+	}
+
+	c2b := newTestC2BSimulation(Abstracts.Sandbox, client).SetPhoneNumber("0110123456").SetStrictPhonePrefixes("2547")
+	if _, err := c2b.Simulate(); !errors.Is(err, Abstracts.ErrUnsupportedPrefix) {
+		t.Errorf("expected strict C2B to reject a prefix outside the allowed ones, got %v", err)
+	}
+
+	stk := Services.NewStkService(createTestConfig(), client).
+		SetStrictPhonePrefixes().
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("+255712345678")
+	if _, err := stk.Push(); !errors.Is(err, Abstracts.ErrUnsupportedPrefix) {
+		t.Errorf("expected strict STK Push to reject a foreign number, got %v", err)
+	}
+	_, _ = stk.SetPhoneNumber("0115123456")
+	if _, err := stk.Push(); err != nil {
+		t.Errorf("expected strict STK Push to accept an 01 number, got %v", err)
+	}
+}
+
+func TestConfig_DefaultCountryCode(t *testing.T) {
+	cfg := createTestConfig()
+	if got := cfg.GetDefaultCountryCode(); got != "254" {
+		t.Errorf("expected the default 254, got %s", got)
+	}
+	for _, cc := range []string{"", "2550", "+", "25a", "++255", " "} {
+		if err := cfg.SetDefaultCountryCode(cc); err == nil {
+			t.Errorf("SetDefaultCountryCode(%q): expected an error", cc)
+		}
+	}
+	if got := cfg.GetDefaultCountryCode(); got != "254" {
+		t.Errorf("expected invalid codes to leave 254, got %s", got)
+	}
+	for cc, want := range map[string]string{"1": "1", "+258": "258", "255": "255"} {
+		if err := cfg.SetDefaultCountryCode(cc); err != nil || cfg.GetDefaultCountryCode() != want {
+			t.Errorf("SetDefaultCountryCode(%q): expected %s, got %s %v", cc, want, cfg.GetDefaultCountryCode(), err)
+		}
+	}
+}
+
+func TestServices_DefaultCountryCode(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+
+	stkConfig := createTestConfig()
+	if err := stkConfig.SetDefaultCountryCode("255"); err != nil {
+		t.Fatal(err)
+	}
+	stk := Services.NewStkService(stkConfig, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	if _, err := stk.SetPhoneNumber("0712345678"); err != nil {
+		t.Fatalf("SetPhoneNumber error: %v", err)
+	}
+	if _, err := stk.Push(); err != nil {
+		t.Fatalf("Push error: %v", err)
+	}
+	client.AssertSent(t, stkConfig.Endpoints.StkPush, map[string]any{"PartyA": "255712345678", "PhoneNumber": "255712345678"})
+	if got, _ := stk.CleanPhoneNumber("0712345678", "254"); got != "254712345678" {
+		t.Errorf("expected an explicit country code to win, got %s", got)
+	}
+
+	b2cConfig := buildTestConfig()
+	if err := b2cConfig.SetDefaultCountryCode("+258"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Services.NewBusinessToCustomerService(b2cConfig, client).
+		SetInitiatorName("testapi").
+		SetCommandID(Services.CommandBusinessPayment).
+		SetAmount(100).
+		SetPhoneNumber("841234567").
+		SetRemarks("Salary").
+		SetOccasion("June").
+		Send()
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	client.AssertSent(t, b2cConfig.Endpoints.B2CPayment, map[string]any{"PartyB": "258841234567"})
+
+	// Services that only take numbers in the configured country reject Kenyan ones.
+	c2b := Services.NewCustomerToBusinessService(stkConfig, client).SetPhoneNumber("+254712345678")
+	if _, err := c2b.SetTypedCommandID(Services.CommandCustomerPayBillOnline).SetAmount("10").SetBillRefNumber("INV-1").Simulate(); err == nil || !strings.Contains(err.Error(), "country code 255") {
+		t.Errorf("expected a number outside 255 to be rejected, got %v", err)
+	}
+}
+
+func TestMSISDN_Format(t *testing.T) {
+	tests := []struct {
+		msisdn  string
+		e164    string
+		display string
+	}{
+		{"254712345678", "+254712345678", "+254 712 345 678"},
+		{"254111844429", "+254111844429", "+254 111 844 429"},
+		{"255712345678", "+255712345678", "+255 712 345 678"},
+		{"15551234567", "+15551234567", "+15551234567"},
+		{"4915123456789", "+4915123456789", "+4915123456789"},
+	}
+	for _, tt := range tests {
+		if got, err := Abstracts.FormatE164(tt.msisdn); err != nil || got != tt.e164 {
+			t.Errorf("FormatE164(%q): expected %s, got %q %v", tt.msisdn, tt.e164, got, err)
+		}
+		if got, err := Abstracts.FormatDisplay(tt.msisdn); err != nil || got != tt.display {
+			t.Errorf("FormatDisplay(%q): expected %s, got %q %v", tt.msisdn, tt.display, got, err)
+		}
+	}
+
+	// Only normalized numbers are formatted.
+	for _, msisdn := range []string{"", "0712345678", "712345678", "+254712345678", "254 712 345 678", "254201234567", "25471234567"} {
+		if got, err := Abstracts.FormatE164(msisdn); err == nil {
+			t.Errorf("FormatE164(%q): expected an error, got %q", msisdn, got)
+		}
+		if got, err := Abstracts.FormatDisplay(msisdn); err == nil {
+			t.Errorf("FormatDisplay(%q): expected an error, got %q", msisdn, got)
+		}
+	}
+}
+
+func TestMaskMSISDN(t *testing.T) {
+	tests := []struct {
+		name     string
+		msisdn   string
+		expected string
+	}{
+		{"Safaricom 07", "254712344312", "2547****4312"},
+		{"Safaricom 01", "254110123456", "2541****3456"},
+		{"Tanzanian", "255712344312", "2557****4312"},
+		{"Eleven digits", "15551234567", "155****4567"},
+		{"Fifteen digits", "491512345678901", "4915123****8901"},
+		{"Already masked", "2547****4312", "2547****4312"},
+		{"Local format", "0712344312", "0712344312"},
+		{"International format", "+254712344312", "+254712344312"},
+		{"Short", "4312", "4312"},
+		{"Empty", "", ""},
+		{"Garbage", "not a phone", "not a phone"},
+		{"Kenyan landline", "254201234567", "254201234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Abstracts.MaskMSISDN(tt.msisdn); got != tt.expected {
+				t.Errorf("MaskMSISDN(%q): expected %q, got %q", tt.msisdn, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMSISDN_ErrorsAreMasked(t *testing.T) {
+	phoneDigits := regexp.MustCompile(`\d{7,}`)
+	errs := map[string]error{}
+	for _, phone := range []string{"0712x44312", "07123443120", "+2547123443123", "0025471234431"} {
+		_, errs["normalize "+phone] = Abstracts.NormalizeMSISDN(phone, "254")
+	}
+	for _, msisdn := range []string{"25471234431a", "0712344312", "2547123443123456", "254201234567"} {
+		errs["validate "+msisdn] = Abstracts.ValidateMSISDN(msisdn)
+	}
+	strict := Abstracts.ValidateMSISDNStrict("255712344312")
+	errs["strict 255712344312"] = strict
+
+	for name, err := range errs {
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if number := phoneDigits.FindString(err.Error()); number != "" {
+			t.Errorf("%s: expected the error to leave out the number, got %q", name, err)
+		}
+	}
+	if strict == nil || !strings.Contains(strict.Error(), "2557****4312 must start with one of") {
+		t.Errorf("expected the prefix error to show the masked number, got %v", strict)
+	}
+}
+
+func TestApiClient_MasksPhoneNumbersInLogs(t *testing.T) {
+	server := mpesatest.NewServer()
+	defer server.Close()
+	m := newSimulatedMpesa(t, server)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == m.Config.Endpoints.StkPush {
+			_, _ = io.WriteString(w, "<html>blocked: 254712344312 (ref 20240812143022)</html>")
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer gateway.Close()
+	m.SetBaseURL(gateway.URL)
+
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+	stk := m.STK().SetTransactionType("CustomerPayBillOnline").SetAmount("10").SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("254712344312")
+	_, err = stk.Push()
+	os.Stdout = stdout
+	writer.Close()
+	logged, _ := io.ReadAll(reader)
+
+	if err == nil {
+		t.Fatalf("expected a decode error for the HTML response")
+	}
+	if strings.Contains(string(logged), "254712344312") || !strings.Contains(string(logged), "blocked: 2547****4312 (ref 20240812143022)") {
+		t.Errorf("expected the logged body to mask the phone number, got %q", logged)
+	}
+}
@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// parseFailure is one call of the parse error hook.
+type parseFailure struct {
+	callbackType string
+	raw          string
+	err          error
+}
+
+// recordParseErrors returns a parse error hook option appending every call to failures.
+func recordParseErrors(failures *[]parseFailure) Services.HandlerOption {
+	return Services.WithOnParseError(func(callbackType string, raw []byte, err error) {
+		*failures = append(*failures, parseFailure{callbackType, string(raw), err})
+	})
+}
+
+func TestOnParseError_MalformedJSON(t *testing.T) {
+	var failures []parseFailure
+	handler := Services.STKCallbackHandler(func(*Services.STKCallback) {
+		t.Error("callback must not be called for a malformed body")
+	}, recordParseErrors(&failures))
+
+	const body = `{"Body": {"stkCallback": {"ResultCode": 0,`
+	rec := postWebhook(handler, body)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected the malformed callback to be acknowledged, got %d %s", rec.Code, rec.Body)
+	}
+	if len(failures) != 1 {
+		t.Fatalf("expected one parse error, got %v", failures)
+	}
+	f := failures[0]
+	if f.callbackType != string(Services.CallbackSTK) || f.raw != body || !strings.Contains(f.err.Error(), "invalid callback body") {
+		t.Errorf("unexpected parse error call: %+v", f)
+	}
+}
+
+func TestOnParseError_FullRawAfterPartialDecode(t *testing.T) {
+	var failures []parseFailure
+	handler := Services.STKCallbackHandler(nil, recordParseErrors(&failures))
+
+	// The first object decodes but has no stkCallback; the bytes after it are never decoded.
+	const body = `{"Body": {}} {"trailing": true}`
+	postWebhook(handler, body)
+	if len(failures) != 1 || failures[0].raw != body || !strings.Contains(failures[0].err.Error(), "stkCallback") {
+		t.Errorf("expected the complete raw body with the parse error, got %+v", failures)
+	}
+}
+
+func TestOnParseError_StrictFieldFailures(t *testing.T) {
+	// Each body is a well-formed callback the lenient parsers accept; only WithStrictParsing
+	// rejects it for the missing fields.
+	b2b, err := json.Marshal(b2bCallbackSuccessPayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		handler      func(opts ...Services.HandlerOption) http.Handler
+		callbackType Services.CallbackType
+		body         string
+		missing      []string
+	}{
+		{
+			name: "stk callback",
+			handler: func(opts ...Services.HandlerOption) http.Handler {
+				return Services.STKCallbackHandler(func(*Services.STKCallback) {}, opts...)
+			},
+			callbackType: Services.CallbackSTK,
+			body:         withoutFields(stkCallbackCancelledJSON, "CheckoutRequestID", "ResultDesc"),
+			missing:      []string{"CheckoutRequestID", "ResultDesc"},
+		},
+		{
+			name: "c2b confirmation",
+			handler: func(opts ...Services.HandlerOption) http.Handler {
+				return Services.C2BConfirmationHandler(func(*Services.C2BConfirmation) error { return nil }, opts...)
+			},
+			callbackType: Services.CallbackC2BConfirmation,
+			body:         withoutFields(c2bConfirmationLegacyJSON, "TransAmount", "MSISDN"),
+			missing:      []string{"TransAmount", "MSISDN"},
+		},
+		{
+			name: "c2b validation",
+			handler: func(opts ...Services.HandlerOption) http.Handler {
+				return Services.C2BValidationHandler(func(*Services.C2BConfirmation) Services.C2BValidationResponse {
+					return Services.AcceptC2BValidation()
+				}, opts...)
+			},
+			callbackType: Services.CallbackC2BValidation,
+			body:         withoutFields(c2bConfirmationLegacyJSON, "TransTime", "BusinessShortCode"),
+			missing:      []string{"TransTime", "BusinessShortCode"},
+		},
+		{
+			name: "b2c result",
+			handler: func(opts ...Services.HandlerOption) http.Handler {
+				return Services.B2CResultHandler(func(*Services.B2CResult) {}, nil, opts...)
+			},
+			callbackType: Services.CallbackB2CResult,
+			body:         withoutFields(b2cResultSuccessJSON, "ResultCode", "ConversationID"),
+			missing:      []string{"ResultCode", "ConversationID"},
+		},
+		{
+			name: "b2b result",
+			handler: func(opts ...Services.HandlerOption) http.Handler {
+				return Services.B2BResultHandler(func(*Services.B2BCallbackResult) {}, nil, opts...)
+			},
+			callbackType: Services.CallbackB2BResult,
+			body:         withoutFields(string(b2b), "ResultCode", "ConversationID"),
+			missing:      []string{"ResultCode", "ConversationID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failures []parseFailure
+			if rec := postWebhook(tt.handler(recordParseErrors(&failures), Services.WithAckPolicy(Services.StrictAck)), tt.body); rec.Code != http.StatusOK || len(failures) != 0 {
+				t.Fatalf("expected the lenient handler to accept the callback, got %d %v", rec.Code, failures)
+			}
+
+			var reported []error
+			handler := tt.handler(
+				Services.WithStrictParsing(),
+				recordParseErrors(&failures),
+				Services.WithAckPolicy(Services.StrictAck),
+				Services.WithErrorHandler(func(err error, r *http.Request) { reported = append(reported, err) }),
+			)
+
+			if rec := postWebhook(handler, tt.body); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 under StrictAck, got %d", rec.Code)
+			}
+			if len(failures) != 1 {
+				t.Fatalf("expected one parse error, got %v", failures)
+			}
+			f := failures[0]
+			if f.callbackType != string(tt.callbackType) || f.raw != tt.body {
+				t.Errorf("expected the callback type and complete raw body, got %q %q", f.callbackType, f.raw)
+			}
+			if !errors.Is(f.err, Services.ErrInvalidCallback) {
+				t.Errorf("expected ErrInvalidCallback, got %v", f.err)
+			}
+			if got := joinedErrors(f.err); len(got) != len(tt.missing) {
+				t.Errorf("expected one joined error per missing field, got %v", f.err)
+			}
+			for _, field := range tt.missing {
+				if !strings.Contains(f.err.Error(), field+" is missing") {
+					t.Errorf("expected %s to be reported missing, got %v", field, f.err)
+				}
+			}
+			if len(reported) != 1 || reported[0] != f.err {
+				t.Errorf("expected the error handler to get the same error, got %v", reported)
+			}
+		})
+	}
+}
+
+// joinedErrors returns the errors joined in err, however deeply nested.
+func joinedErrors(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, joinedErrors(e)...)
+	}
+	return errs
+}
+
+// withoutFields returns the JSON body with the top-level or nested fields named removed.
+func withoutFields(body string, fields ...string) string {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		panic(err)
+	}
+	var remove func(node map[string]any)
+	remove = func(node map[string]any) {
+		for _, field := range fields {
+			delete(node, field)
+		}
+		for _, value := range node {
+			if child, ok := value.(map[string]any); ok {
+				remove(child)
+			}
+		}
+	}
+	remove(payload)
+	out, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	return string(out)
+}
+
+func TestOnParseError_CannotAlterResponse(t *testing.T) {
+	var reported []error
+	handler := Services.B2CResultHandler(nil, nil,
+		Services.WithOnParseError(func(string, []byte, error) { panic("alerting is down") }),
+		Services.WithErrorHandler(func(err error, r *http.Request) { reported = append(reported, err) }),
+	)
+
+	rec := postWebhook(handler, `{"Result":`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected the callback to be acknowledged despite the hook, got %d %s", rec.Code, rec.Body)
+	}
+	var panicErr *Services.CallbackPanicError
+	if len(reported) != 2 || !errors.As(reported[0], &panicErr) {
+		t.Errorf("expected the parse error and the hook panic to be reported, got %v", reported)
+	}
+}
+
+func TestOnParseError_NilAndValidCallbacks(t *testing.T) {
+	handler := Services.STKCallbackHandler(nil, Services.WithOnParseError(nil))
+	if rec := postWebhook(handler, `not json`); rec.Code != http.StatusOK {
+		t.Errorf("expected a nil hook to be ignored, got %d", rec.Code)
+	}
+
+	var failures []parseFailure
+	handler = Services.STKCallbackHandler(nil, recordParseErrors(&failures))
+	postWebhook(handler, stkCallbackSuccessJSON)
+	if len(failures) != 0 {
+		t.Errorf("expected no parse errors for a valid callback, got %v", failures)
+	}
+}
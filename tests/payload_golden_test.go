@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// TestServices_RequestPayloadGolden pins the request each service sends, so that a change to
+// how a field is serialized (a string amount becoming a number, say) shows up as a diff.
+func TestServices_RequestPayloadGolden(t *testing.T) {
+	clock := Abstracts.NewFakeClock(time.Date(2024, 8, 12, 14, 30, 22, 0, time.UTC))
+
+	tests := []struct {
+		golden string
+		send   func(client *mpesatest.RecordingClient) error
+	}{
+		{"stk_push_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			stk := Services.NewStkService(createTestConfig(), client).
+				SetClock(clock).
+				SetTransactionType("CustomerPayBillOnline").
+				SetAmount("1,500").
+				SetCallbackUrl("https://example.com/stk").
+				SetAccountReference("INV-001").
+				SetTransactionDesc("Invoice payment")
+			if _, err := stk.SetPhoneNumber("254708374149"); err != nil {
+				return err
+			}
+			_, err := stk.Push()
+			return err
+		}},
+		{"c2b_simulate_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := Services.NewCustomerToBusinessService(buildTestConfig(), client).
+				SetTypedCommandID(Services.CommandCustomerPayBillOnline).
+				SetPhoneNumber("254708374149").
+				SetBillRefNumber("INV-001").
+				SetAmount("1,500").
+				Simulate()
+			return err
+		}},
+		{"b2c_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := newTestB2CService(client).
+				SetPhoneNumber("254708374149").
+				SetAmountFloat(1500.5).
+				SetRoundingPolicy(Services.RoundHalfUp).
+				SetOriginatorConversationID("feb5e3f2-fbbc-4745-844c-ee37b546f627").
+				Send()
+			return err
+		}},
+		{"b2b_buygoods_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := Services.NewBusinessBuyGoodsService(newTestB2BConfig(Abstracts.Sandbox), client).
+				SetInitiator("testapi").
+				SetAmount(1500).
+				SetPartyB("000002").
+				SetQueueTimeoutURL("https://example.com/timeout").
+				SetResultURL("https://example.com/result").
+				SetOriginatorConversationID("b2b-0001").
+				Send()
+			return err
+		}},
+		{"b2c_topup_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := Services.NewB2CAccountTopUpService(buildTestConfig(), client).
+				SetInitiator("testapi").
+				SetAmount(50000).
+				SetPartyB("600000").
+				SetRequester("254708374149").
+				Send()
+			return err
+		}},
+		{"tax_remittance_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := Services.NewTaxRemittanceService(buildTestConfig(), client).
+				SetInitiator("testapi").
+				SetAmount(2390).
+				SetPRN("353353").
+				Send()
+			return err
+		}},
+		{"reversal_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := Services.NewReversalService(buildTestConfig(), client).
+				SetInitiator("apiop37").
+				SetTransactionID("PDU91HIVIT").
+				SetAmountString("1,500.50").
+				SetRemarks("Payment reversal").
+				Reverse()
+			return err
+		}},
+		{"dynamic_qr_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			_, err := newQRRequest(client, Services.TrxCodeBuyGoods, "373132").SetAmount(1500).Generate()
+			return err
+		}},
+		{"bill_manager_invoice_request.golden.json", func(client *mpesatest.RecordingClient) error {
+			client.Enqueue(mpesatest.AnyEndpoint, billManagerOK)
+			invoices := testInvoices(1)
+			invoices[0].Amount = 1500.5
+			invoices[0].InvoiceItems = []Services.InvoiceItem{{ItemName: "Water", Amount: 300.5}, {ItemName: "Rent", Amount: 1200}}
+			_, err := Services.NewBillManagerService(createTestConfig(), client).SendInvoices(context.Background(), invoices)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("testdata", tt.golden))
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+
+			client := mpesatest.NewRecordingClient()
+			if err := tt.send(client); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			calls := client.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(calls))
+			}
+
+			got, err := json.Marshal(calls[0].Payload)
+			if err != nil {
+				t.Fatalf("marshal payload: %v", err)
+			}
+			if !bytes.Equal(got, bytes.TrimSpace(want)) {
+				t.Errorf("payload mismatch:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
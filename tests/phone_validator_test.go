@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+var errDenied = errors.New("number is on the denylist")
+
+// denylist is a PhoneValidator rejecting the numbers in it.
+type denylist map[string]bool
+
+func (d denylist) Validate(msisdn string) error {
+	if d[msisdn] {
+		return fmt.Errorf("%w: %s", errDenied, msisdn)
+	}
+	return nil
+}
+
+func TestChainPhoneValidators(t *testing.T) {
+	var calls []string
+	record := func(name string, err error) Abstracts.PhoneValidator {
+		return Abstracts.PhoneValidatorFunc(func(msisdn string) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+	landline := errors.New("landline")
+
+	chain := Abstracts.ChainPhoneValidators(record("first", nil), nil, record("second", landline), record("third", nil))
+	if err := chain.Validate("254712345678"); err != landline {
+		t.Errorf("expected the second validator's error, got %v", err)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("expected the chain to stop at the first error, got %v", calls)
+	}
+	if err := Abstracts.ChainPhoneValidators().Validate("254712345678"); err != nil {
+		t.Errorf("expected an empty chain to accept any number, got %v", err)
+	}
+}
+
+func TestServices_PhoneValidator(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	denied := denylist{"254712345678": true}
+
+	cfg := createTestConfig()
+	cfg.SetPhoneValidator(denied)
+	stk := Services.NewStkService(cfg, client).
+		SetTransactionType("CustomerPayBillOnline").
+		SetAmount(10).
+		SetCallbackUrl("https://example.com/stk")
+	_, _ = stk.SetPhoneNumber("0712 345 678")
+	if _, err := stk.Push(); !errors.Is(err, errDenied) || !strings.Contains(err.Error(), "invalid phone number") {
+		t.Errorf("expected STK Push to reject the denied number after normalizing it, got %v", err)
+	}
+	_, _ = stk.SetPhoneNumber("0712345679")
+	if _, err := stk.Push(); err != nil {
+		t.Errorf("expected STK Push to accept other numbers, got %v", err)
+	}
+	_, _ = stk.SetPhoneNumber("0712345678")
+	if _, err := stk.SetPhoneValidator(Abstracts.ChainPhoneValidators()).Push(); err != nil {
+		t.Errorf("expected the service validator to replace the config one, got %v", err)
+	}
+
+	b2c := newTestB2CService(client).SetPhoneValidator(denied).SetPhoneNumber("+254712345678")
+	if _, err := b2c.Send(); !errors.Is(err, errDenied) {
+		t.Errorf("expected B2C Send to reject the denied number, got %v", err)
+	}
+	if _, err := b2c.SetRawPhoneNumber("254712345678").Send(); !errors.Is(err, errDenied) {
+		t.Errorf("expected raw numbers to be checked too, got %v", err)
+	}
+	if _, err := b2c.SetPhoneNumber("0722000000").Send(); err != nil {
+		t.Errorf("expected B2C Send to accept other numbers, got %v", err)
+	}
+
+	c2bConfig := buildTestConfig()
+	c2bConfig.SetPhoneValidator(denied)
+	c2b := Services.NewCustomerToBusinessService(c2bConfig, client).
+		SetTypedCommandID(Services.CommandCustomerPayBillOnline).
+		SetAmount("10").
+		SetBillRefNumber("INV-1").
+		SetPhoneNumber("712345678")
+	if _, err := c2b.Simulate(); !errors.Is(err, errDenied) {
+		t.Errorf("expected C2B Simulate to reject the denied number, got %v", err)
+	}
+
+	if got := client.Count(mpesatest.AnyEndpoint); got != 3 {
+		t.Errorf("expected only the accepted numbers to be sent, got %d requests", got)
+	}
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// pullPage builds a query response with n transactions numbered from first.
+func pullPage(first, n int) mpesatest.Response {
+	items := make([]any, n)
+	for i := range items {
+		items[i] = map[string]any{"transactionId": fmt.Sprintf("TX%05d", first+i), "amount": "10"}
+	}
+	return mpesatest.Response{Body: map[string]any{"ResponseCode": "1000", "Response": []any{items}}}
+}
+
+func TestPullIterator_Pages(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, pullPage(0, 100), pullPage(100, 100), pullPage(200, 37))
+	start := time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)
+
+	var ids []string
+	err := Services.NewPullTransactionsService(createTestConfig(), client).
+		Iterate(context.Background(), start, start.Add(24*time.Hour)).
+		ForEach(func(tx *Services.PulledTransaction) error {
+			ids = append(ids, tx.TransactionID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ForEach error: %v", err)
+	}
+
+	if len(ids) != 237 || ids[0] != "TX00000" || ids[236] != "TX00236" {
+		t.Fatalf("expected 237 transactions in order, got %d", len(ids))
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 3 {
+		t.Fatalf("expected 3 queries, got %d", client.Count(mpesatest.AnyEndpoint))
+	}
+	for i, want := range []string{"0", "100", "200"} {
+		if got := client.Payload(i)["OffSetValue"]; got != want {
+			t.Errorf("query %d: expected offset %s, got %v", i, want, got)
+		}
+	}
+}
+
+func TestPullIterator_ErrorMidIteration(t *testing.T) {
+	apiErr := errors.New("upstream unavailable")
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, pullPage(0, 100), mpesatest.Response{Err: apiErr}, pullPage(100, 37))
+	start := time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)
+	it := Services.NewPullTransactionsService(createTestConfig(), client).Iterate(context.Background(), start, start.Add(time.Hour))
+
+	count := 0
+	var err error
+	for {
+		var tx *Services.PulledTransaction
+		if tx, err = it.Next(); err != nil {
+			break
+		}
+		if tx.TransactionID != fmt.Sprintf("TX%05d", count) {
+			t.Fatalf("unexpected transaction %s at position %d", tx.TransactionID, count)
+		}
+		count++
+	}
+	if !errors.Is(err, apiErr) || count != 100 {
+		t.Fatalf("expected API error after 100 transactions, got %v after %d", err, count)
+	}
+
+	// Calling Next again retries the failed page.
+	err = it.ForEach(func(tx *Services.PulledTransaction) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 137 {
+		t.Fatalf("expected retry to yield the remaining 37 transactions, got %v after %d", err, count)
+	}
+	if got := client.Payload(2)["OffSetValue"]; got != "100" {
+		t.Errorf("expected retry at offset 100, got %v", got)
+	}
+	if _, err := it.Next(); !errors.Is(err, Services.ErrPullDone) {
+		t.Errorf("expected ErrPullDone, got %v", err)
+	}
+}
+
+func TestPullIterator_SplitsLongWindows(t *testing.T) {
+	// The first window's last transaction is returned again by the second window.
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, pullPage(0, 3), pullPage(2, 2))
+	start := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	var ids []string
+	err := Services.NewPullTransactionsService(createTestConfig(), client).
+		Iterate(context.Background(), start, start.Add(72*time.Hour)).
+		ForEach(func(tx *Services.PulledTransaction) error {
+			ids = append(ids, tx.TransactionID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ForEach error: %v", err)
+	}
+
+	if len(ids) != 4 {
+		t.Errorf("expected duplicates across windows to be yielded once, got %v", ids)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 2 {
+		t.Fatalf("expected 2 queries, got %d", client.Count(mpesatest.AnyEndpoint))
+	}
+	if client.Payload(0)["EndDate"] != client.Payload(1)["StartDate"] || client.Payload(1)["OffSetValue"] != "0" {
+		t.Errorf("expected consecutive windows, got %v and %v", client.Payload(0), client.Payload(1))
+	}
+}
+
+func TestPullIterator_DeduplicatesPageBoundaries(t *testing.T) {
+	// timedPage builds a query response of "ID@time" items.
+	timedPage := func(items ...string) mpesatest.Response {
+		list := make([]any, len(items))
+		for i, item := range items {
+			id, at, _ := strings.Cut(item, "@")
+			list[i] = map[string]any{"transactionId": id, "trxDate": "2020-08-05T10:" + at + ":00Z", "amount": "10"}
+		}
+		return mpesatest.Response{Body: map[string]any{"ResponseCode": "1000", "Response": []any{list}}}
+	}
+	// Each window's query repeats the transactions at the last timestamp of the previous one.
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint,
+		timedPage("TX1@00", "TX2@05", "TX3@05"),
+		timedPage("TX2@05", "TX3@05", "TX4@07"),
+		timedPage("TX4@07", "TX5@09"),
+	)
+	start := time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	var ids []string
+	err := Services.NewPullTransactionsService(createTestConfig(), client).
+		Iterate(context.Background(), start, start.Add(3*Services.MaxPullWindow)).
+		ForEach(func(tx *Services.PulledTransaction) error {
+			ids = append(ids, tx.TransactionID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ForEach error: %v", err)
+	}
+	if got := strings.Join(ids, ","); got != "TX1,TX2,TX3,TX4,TX5" {
+		t.Errorf("expected the boundary transactions to be yielded once, got %s", got)
+	}
+}
+
+func TestPullIterator_ContextCancelled(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, pullPage(0, 100), pullPage(100, 100))
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)
+
+	count := 0
+	err := Services.NewPullTransactionsService(createTestConfig(), client).
+		Iterate(ctx, start, start.Add(time.Hour)).
+		ForEach(func(tx *Services.PulledTransaction) error {
+			count++
+			if count == 50 {
+				cancel()
+			}
+			return nil
+		})
+	if !errors.Is(err, context.Canceled) || count != 50 {
+		t.Fatalf("expected cancellation after 50 transactions, got %v after %d", err, count)
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Errorf("expected no further queries after cancellation, got %d", client.Count(mpesatest.AnyEndpoint))
+	}
+}
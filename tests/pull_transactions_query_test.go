@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+const pullQueryResponseJSON = `{
+  "ResponseRefID": "26178-42530161-2",
+  "ResponseCode": "1000",
+  "ResponseMessage": "Success",
+  "Response": [
+    [
+      {
+        "transactionId": "OHR7RLBXR1",
+        "trxDate": "2020-08-05T10:13:00Z",
+        "msisdn": 722000000,
+        "sender": "UTILITY",
+        "transactiontype": "c2b-pay-bill-debit",
+        "billreference": "19",
+        "amount": "400",
+        "organizationname": "Daraja Pull API Test"
+      },
+      {
+        "transactionId": "OHR7RLBXR2",
+        "trxDate": "2020-08-05 13:20:15",
+        "msisdn": "254722000001",
+        "sender": "CUSTOMER",
+        "transactiontype": "c2b-buy-goods-credit",
+        "billreference": "",
+        "amount": 1250.5,
+        "organizationname": "Daraja Pull API Test"
+      },
+      "unexpected"
+    ]
+  ]
+}`
+
+func TestPullTransactionsService_Query(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, decodeFixture(t, pullQueryResponseJSON))
+	eat := time.FixedZone("EAT", 3*60*60)
+	start := time.Date(2020, 8, 4, 8, 36, 0, 0, eat)
+	end := time.Date(2020, 8, 5, 10, 10, 0, 0, eat)
+
+	res, err := Services.NewPullTransactionsService(createTestConfig(), client).Query(start, end, 100)
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"ShortCode":   "174379",
+		"StartDate":   "2020-08-04 08:36:00",
+		"EndDate":     "2020-08-05 10:10:00",
+		"OffSetValue": "100",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+	if client.LastEndpoint() != "/pulltransactions/v1/query" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	if res.RequestID != "26178-42530161-2" || res.ResponseCode != "1000" {
+		t.Errorf("unexpected response fields: %+v", res)
+	}
+	if len(res.Transactions) != 2 {
+		t.Fatalf("expected 2 transactions, got %d: %+v", len(res.Transactions), res.Transactions)
+	}
+
+	first := res.Transactions[0]
+	if first.TransactionID != "OHR7RLBXR1" || first.Msisdn != "722000000" || first.Amount != 400 {
+		t.Errorf("unexpected first transaction: %+v", first)
+	}
+	if first.TransactionType != "c2b-pay-bill-debit" || first.BillReference != "19" || first.OrganizationName != "Daraja Pull API Test" {
+		t.Errorf("unexpected first transaction details: %+v", first)
+	}
+	if !first.TrxDate.Equal(time.Date(2020, 8, 5, 10, 13, 0, 0, time.UTC)) {
+		t.Errorf("unexpected first transaction date: %v", first.TrxDate)
+	}
+
+	second := res.Transactions[1]
+	if second.Sender != "CUSTOMER" || second.Amount != 1250.5 {
+		t.Errorf("unexpected second transaction: %+v", second)
+	}
+	if !second.TrxDate.Equal(time.Date(2020, 8, 5, 13, 20, 15, 0, eat)) {
+		t.Errorf("unexpected second transaction date: %v", second.TrxDate)
+	}
+}
+
+func TestPullTransactionsService_QueryEmpty(t *testing.T) {
+	for _, body := range []string{
+		`{"ResponseRefID":"1","ResponseCode":"1000","ResponseMessage":"No transactions found","Response":[[]]}`,
+		`{"ResponseRefID":"1","ResponseCode":"1000","ResponseMessage":"No transactions found","Response":[]}`,
+		`{"ResponseRefID":"1","ResponseCode":"1000","ResponseMessage":"No transactions found"}`,
+	} {
+		client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, decodeFixture(t, body))
+		start := time.Now().Add(-time.Hour)
+		res, err := Services.NewPullTransactionsService(createTestConfig(), client).Query(start, start.Add(time.Hour), 0)
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		if len(res.Transactions) != 0 || res.ResponseMessage != "No transactions found" {
+			t.Errorf("expected empty result for %s, got %+v", body, res)
+		}
+	}
+}
+
+func TestPullTransactionsService_QueryValidation(t *testing.T) {
+	start := time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		start   time.Time
+		end     time.Time
+		offset  int
+		wantErr string
+	}{
+		{"missing dates", time.Time{}, start, 0, "start and end dates are required"},
+		{"end before start", start, start.Add(-time.Minute), 0, "end date 2020-08-03 23:59:00 must be after start date 2020-08-04 00:00:00"},
+		{"window too long", start, start.Add(49 * time.Hour), 0, "date window must not exceed 48h0m0s, got 49h0m0s"},
+		{"negative offset", start, start.Add(time.Hour), -1, "offset must not be negative, got -1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewPullTransactionsService(createTestConfig(), client).Query(tc.start, tc.end, tc.offset)
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{})
+	if _, err := Services.NewPullTransactionsService(createTestConfig(), client).Query(start, start.Add(Services.MaxPullWindow), 0); err != nil {
+		t.Errorf("expected a 48h window to be accepted, got %v", err)
+	}
+}
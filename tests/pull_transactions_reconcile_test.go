@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// memoryLedger is an in-memory ledger implementing LedgerLookup and LedgerIterator.
+type memoryLedger struct {
+	ids     []string
+	failFor map[string]bool
+	next    int
+}
+
+func newMemoryLedger(ids ...string) *memoryLedger {
+	return &memoryLedger{ids: ids}
+}
+
+func (l *memoryLedger) Exists(transactionID string) (bool, error) {
+	if l.failFor[transactionID] {
+		return false, errors.New("ledger unavailable")
+	}
+	for _, id := range l.ids {
+		if id == transactionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (l *memoryLedger) Next() (string, error) {
+	if l.next >= len(l.ids) {
+		return "", Services.ErrLedgerDone
+	}
+	l.next++
+	return l.ids[l.next-1], nil
+}
+
+func reconcileIterator(ctx context.Context, steps ...mpesatest.Response) *Services.PullIterator {
+	start := time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)
+	client := mpesatest.NewRecordingClient().Enqueue(mpesatest.AnyEndpoint, steps...)
+	return Services.NewPullTransactionsService(createTestConfig(), client).Iterate(ctx, start, start.Add(time.Hour))
+}
+
+func transactionIDs(txs []Services.PulledTransaction) []string {
+	ids := make([]string, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.TransactionID
+	}
+	return ids
+}
+
+func TestReconciler_OverlappingSets(t *testing.T) {
+	ledger := newMemoryLedger("TX00001", "TX00003", "LEDGER-ONLY")
+	ledger.failFor = map[string]bool{"TX00004": true}
+
+	report, err := Services.NewReconciler(ledger).
+		SetLedgerIterator(ledger).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 5)))
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	if got := fmt.Sprint(transactionIDs(report.Matched)); got != "[TX00001 TX00003]" {
+		t.Errorf("unexpected matched transactions %s", got)
+	}
+	if got := fmt.Sprint(transactionIDs(report.Missing)); got != "[TX00000 TX00002]" {
+		t.Errorf("unexpected missing transactions %s", got)
+	}
+	if got := fmt.Sprint(report.LedgerOnly); got != "[LEDGER-ONLY]" {
+		t.Errorf("unexpected ledger-only entries %s", got)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("expected the failed lookup to be reported, got %v", report.Errors)
+	}
+}
+
+func TestReconciler_DisjointSets(t *testing.T) {
+	ledger := newMemoryLedger("A", "B")
+
+	report, err := Services.NewReconciler(ledger).
+		SetLedgerIterator(ledger).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 3)))
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	if len(report.Matched) != 0 || len(report.Missing) != 3 {
+		t.Errorf("expected every pulled transaction to be missing, got %+v", report)
+	}
+	if fmt.Sprint(report.LedgerOnly) != "[A B]" {
+		t.Errorf("expected every ledger entry to be ledger-only, got %v", report.LedgerOnly)
+	}
+}
+
+func TestReconciler_WithoutLedgerIterator(t *testing.T) {
+	report, err := Services.NewReconciler(newMemoryLedger("TX00000")).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 2)))
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+	if len(report.Matched) != 1 || len(report.Missing) != 1 || report.LedgerOnly != nil {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestReconciler_StopsOnQueryErrorAndCancellation(t *testing.T) {
+	apiErr := errors.New("upstream unavailable")
+	report, err := Services.NewReconciler(newMemoryLedger()).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 100), mpesatest.Response{Err: apiErr}))
+	if !errors.Is(err, apiErr) {
+		t.Fatalf("expected query error, got %v", err)
+	}
+	if len(report.Missing) != 100 {
+		t.Errorf("expected partial report with the first page, got %d", len(report.Missing))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Services.NewReconciler(newMemoryLedger()).Reconcile(ctx, reconcileIterator(context.Background(), pullPage(0, 1))); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestReconciler_StreamsThroughCallbacks(t *testing.T) {
+	ledger := newMemoryLedger("TX00001", "TX00003")
+	var matched, missing []string
+
+	report, err := Services.NewReconciler(ledger).
+		OnMatched(func(tx *Services.PulledTransaction) error {
+			matched = append(matched, tx.TransactionID)
+			return nil
+		}).
+		OnMissing(func(tx *Services.PulledTransaction) error {
+			missing = append(missing, tx.TransactionID)
+			return nil
+		}).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 5)))
+	if err != nil {
+		t.Fatalf("Reconcile error: %v", err)
+	}
+
+	if got := fmt.Sprint(matched); got != "[TX00001 TX00003]" {
+		t.Errorf("unexpected matched transactions %s", got)
+	}
+	if got := fmt.Sprint(missing); got != "[TX00000 TX00002 TX00004]" {
+		t.Errorf("unexpected missing transactions %s", got)
+	}
+	if report.Matched != nil || report.Missing != nil {
+		t.Errorf("streamed transactions should not be collected, got %+v", report)
+	}
+	if report.MatchedCount != 2 || report.MissingCount != 3 {
+		t.Errorf("unexpected counts %d matched, %d missing", report.MatchedCount, report.MissingCount)
+	}
+
+	sinkErr := errors.New("sink full")
+	report, err = Services.NewReconciler(ledger).
+		OnMissing(func(*Services.PulledTransaction) error { return sinkErr }).
+		Reconcile(context.Background(), reconcileIterator(context.Background(), pullPage(0, 5)))
+	if !errors.Is(err, sinkErr) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	if report.MissingCount != 1 {
+		t.Errorf("expected reconciliation to stop at the first missing transaction, got %d", report.MissingCount)
+	}
+}
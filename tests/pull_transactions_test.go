@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestPullTransactionsService_Register(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{
+		"ResponseRefID":        "18633-7271215-1",
+		"Response Status":      "1001",
+		"ShortCode":            "174379",
+		"Response Description": "MSISDN already exists",
+	})
+	service := Services.NewPullTransactionsService(createTestConfig(), client).
+		SetNominatedNumber("0722000000").
+		SetCallbackURL("https://example.com/mpesa/pull")
+
+	resp, err := service.RegisterTyped()
+	if err != nil {
+		t.Fatalf("RegisterTyped error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"ShortCode":       "174379",
+		"RequestType":     "Pull",
+		"NominatedNumber": "254722000000",
+		"CallBackURL":     "https://example.com/mpesa/pull",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+	if client.LastEndpoint() != "/pulltransactions/v1/register" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
+	}
+
+	if resp.ResponseRefID != "18633-7271215-1" || resp.ResponseStatus != "1001" || resp.ShortCode != "174379" {
+		t.Errorf("unexpected typed response: %+v", resp)
+	}
+	if resp.ResponseDescription != "MSISDN already exists" || service.GetTypedResponse() != resp {
+		t.Errorf("expected typed response to be stored, got %+v", service.GetTypedResponse())
+	}
+}
+
+func TestPullTransactionsService_ShortCodeOverride(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ResponseRefID": "1"})
+	cfg := createTestConfig()
+	_, err := Services.NewPullTransactionsService(cfg, client).
+		SetShortCode("600000").
+		SetNominatedNumber("254722000000").
+		SetCallbackURL("https://example.com/mpesa/pull").
+		Register()
+	if err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["ShortCode"]; got != "600000" {
+		t.Errorf("expected overridden shortcode, got %v", got)
+	}
+	if cfg.GetBusinessCode() != "174379" {
+		t.Errorf("expected config to be left untouched")
+	}
+}
+
+func TestPullTransactionsService_ValidationErrors(t *testing.T) {
+	noBusinessCode, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		name    string
+		cfg     *abstracts.MpesaConfig
+		build   func(*Services.PullTransactionsService) *Services.PullTransactionsService
+		wantErr string
+	}{
+		{"missing shortcode", noBusinessCode, func(s *Services.PullTransactionsService) *Services.PullTransactionsService {
+			return s.SetNominatedNumber("0722000000").SetCallbackURL("https://example.com/mpesa/pull")
+		}, "shortcode is required; call SetShortCode or SetBusinessCode on mpesa config"},
+		{"missing nominated number", createTestConfig(), func(s *Services.PullTransactionsService) *Services.PullTransactionsService {
+			return s.SetCallbackURL("https://example.com/mpesa/pull")
+		}, "nominated number is required; call SetNominatedNumber"},
+		{"invalid nominated number", createTestConfig(), func(s *Services.PullTransactionsService) *Services.PullTransactionsService {
+			return s.SetNominatedNumber("0522000000").SetCallbackURL("https://example.com/mpesa/pull")
+		}, "phone number must be a Kenyan mobile number in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+		{"missing callback URL", createTestConfig(), func(s *Services.PullTransactionsService) *Services.PullTransactionsService {
+			return s.SetNominatedNumber("0722000000")
+		}, "callback URL is required; call SetCallbackURL"},
+		{"relative callback URL", createTestConfig(), func(s *Services.PullTransactionsService) *Services.PullTransactionsService {
+			return s.SetNominatedNumber("0722000000").SetCallbackURL("/mpesa/pull")
+		}, `callback URL "/mpesa/pull" is not a valid absolute URL`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := tc.build(Services.NewPullTransactionsService(tc.cfg, client)).Register()
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) != 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// queueTimeoutResultJSON is a timeout notification wrapped in a Result node like a result.
+const queueTimeoutResultJSON = `{
+  "Result": {
+    "ResultType": 1,
+    "ResultCode": "SFC_IC0003",
+    "ResultDesc": "The service request has timed out.",
+    "OriginatorConversationID": "8521-4298025-1",
+    "ConversationID": "AG_20181005_00004d7ee675c0c7ee0b",
+    "TransactionID": "MJ561H6X5O",
+    "ReferenceData": {
+      "ReferenceItem": {"Key": "QueueTimeoutURL", "Value": "https://internalsandbox.safaricom.co.ke/mpesa/abresults/v1/submit"}
+    }
+  }
+}`
+
+// queueTimeoutEchoJSON is a flat timeout notification echoing the original request.
+const queueTimeoutEchoJSON = `{
+  "OriginatorConversationID": "16740-34861180-1",
+  "ConversationID": "AG_20191219_00005797af5d7d75f652",
+  "ResultCode": "1",
+  "ResultDesc": "The request timed out in the queue.",
+  "CommandID": "BusinessPayment",
+  "PartyA": "600997",
+  "PartyB": "254708374149",
+  "Amount": 1500
+}`
+
+// queueTimeoutErrorJSON is a timeout notification in the error shape.
+const queueTimeoutErrorJSON = `{"requestId":"11728-2929992-1","errorCode":"500.001.1001","errorMessage":"Request timed out"}`
+
+func TestParseQueueTimeout(t *testing.T) {
+	wrapped, err := Services.ParseQueueTimeout(decodeFixture(t, queueTimeoutResultJSON))
+	if err != nil {
+		t.Fatalf("ParseQueueTimeout error: %v", err)
+	}
+	if wrapped.OriginatorConversationID != "8521-4298025-1" || wrapped.ConversationID != "AG_20181005_00004d7ee675c0c7ee0b" ||
+		wrapped.TransactionID != "MJ561H6X5O" || wrapped.ResultCode != "SFC_IC0003" {
+		t.Errorf("unexpected wrapped timeout %+v", wrapped)
+	}
+	if wrapped.ReferenceData["QueueTimeoutURL"] == "" {
+		t.Errorf("expected reference data, got %v", wrapped.ReferenceData)
+	}
+
+	echo, err := Services.ParseQueueTimeout(decodeFixture(t, queueTimeoutEchoJSON))
+	if err != nil {
+		t.Fatalf("ParseQueueTimeout error: %v", err)
+	}
+	if echo.OriginatorConversationID != "16740-34861180-1" || echo.ResultDesc != "The request timed out in the queue." {
+		t.Errorf("unexpected flat timeout %+v", echo)
+	}
+	if echo.Request["CommandID"] != "BusinessPayment" || echo.Request["PartyB"] != "254708374149" || echo.Request["Amount"] != "1500" {
+		t.Errorf("expected original request identifiers, got %v", echo.Request)
+	}
+
+	errShape, err := Services.ParseQueueTimeout(decodeFixture(t, queueTimeoutErrorJSON))
+	if err != nil {
+		t.Fatalf("ParseQueueTimeout error: %v", err)
+	}
+	if errShape.RequestID != "11728-2929992-1" || errShape.ResultCode != "500.001.1001" || errShape.ResultDesc != "Request timed out" {
+		t.Errorf("unexpected error shaped timeout %+v", errShape)
+	}
+
+	if _, err := Services.ParseQueueTimeout(decodeFixture(t, b2cResultSuccessJSON)); err == nil {
+		t.Errorf("expected error for a result callback")
+	}
+}
+
+func TestIsQueueTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"Wrapped timeout", queueTimeoutResultJSON, true},
+		{"Flat timeout", queueTimeoutEchoJSON, true},
+		{"Error shape", queueTimeoutErrorJSON, true},
+		{"B2C result", b2cResultSuccessJSON, false},
+		{"Failed reversal", reversalResultSuccessJSON, false},
+		{"Balance result", accountBalanceResultJSON, false},
+		{"Status result", transactionStatusCompletedJSON, false},
+		{"Unrelated", `{"foo":"bar"}`, false},
+	}
+	for _, tt := range tests {
+		if got := Services.IsQueueTimeout(decodeFixture(t, tt.body)); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestResultHandlers_RouteQueueTimeouts(t *testing.T) {
+	var results, timeouts int
+	onTimeout := func(map[string]any) { timeouts++ }
+	handlers := map[string]struct {
+		handler http.Handler
+		result  string
+	}{
+		"B2C":      {Services.B2CResultHandler(func(*Services.B2CResult) { results++ }, onTimeout), b2cResultSuccessJSON},
+		"B2B":      {Services.B2BResultHandler(func(*Services.B2BCallbackResult) { results++ }, onTimeout), reversalResultSuccessJSON},
+		"Reversal": {Services.ReversalResultHandler(func(*Services.ReversalResult) { results++ }, onTimeout), reversalResultSuccessJSON},
+		"Balance":  {Services.AccountBalanceResultHandler(func(*Services.AccountBalanceResult) { results++ }, onTimeout), accountBalanceResultJSON},
+		"Status":   {Services.TransactionStatusResultHandler(func(*Services.TransactionStatusResult) { results++ }, onTimeout), transactionStatusCompletedJSON},
+	}
+
+	for name, h := range handlers {
+		results, timeouts = 0, 0
+		for _, body := range []string{queueTimeoutResultJSON, queueTimeoutEchoJSON, queueTimeoutErrorJSON, h.result} {
+			if rec := postWebhook(h.handler, body); rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+				t.Errorf("%s: expected ack, got %d %s", name, rec.Code, rec.Body.String())
+			}
+		}
+		if results != 1 || timeouts != 3 {
+			t.Errorf("%s: expected 1 result and 3 timeouts, got %d and %d", name, results, timeouts)
+		}
+	}
+}
+
+func TestB2BCallbackHandler_IgnoresQueueTimeouts(t *testing.T) {
+	called := false
+	var reported error
+	handler := Services.B2BCallbackHandler(func(*Services.B2BCallbackResult) { called = true },
+		Services.WithErrorHandler(func(err error, r *http.Request) { reported = err }))
+
+	if rec := postWebhook(handler, queueTimeoutResultJSON); rec.Body.String() != webhookAckBody || called || reported != nil {
+		t.Errorf("expected timeout to be acknowledged without a result, got %s called=%v err=%v", rec.Body.String(), called, reported)
+	}
+}
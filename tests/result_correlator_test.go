@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+// signallingCorrelator is a MemoryResultCorrelator that calls onRegister after each Register.
+type signallingCorrelator struct {
+	*Services.MemoryResultCorrelator
+	onRegister func(id string)
+}
+
+func (c *signallingCorrelator) Register(id string) <-chan Services.CorrelatedResult {
+	ch := c.MemoryResultCorrelator.Register(id)
+	if c.onRegister != nil {
+		go c.onRegister(id)
+	}
+	return ch
+}
+
+func TestMemoryResultCorrelator(t *testing.T) {
+	correlator := Services.NewMemoryResultCorrelator(0)
+
+	waiting := correlator.Register("AG_1")
+	correlator.Resolve("AG_1", Services.CorrelatedResult{ResultCode: "0"})
+	if res := <-waiting; res.ResultCode != "0" {
+		t.Errorf("expected result for registered ID, got %+v", res)
+	}
+
+	correlator.Resolve("AG_2", Services.CorrelatedResult{ResultCode: "1"})
+	select {
+	case res := <-correlator.Register("AG_2"):
+		if res.ResultCode != "1" {
+			t.Errorf("unexpected early result %+v", res)
+		}
+	default:
+		t.Errorf("expected result resolved before Register to be delivered")
+	}
+
+	cancelled := correlator.Register("AG_3")
+	correlator.Cancel("AG_3")
+	correlator.Resolve("AG_3", Services.CorrelatedResult{})
+	select {
+	case <-cancelled:
+		t.Errorf("expected cancelled registration not to receive the result")
+	default:
+	}
+}
+
+func TestSendAndWait_CallbackBeforeWait(t *testing.T) {
+	correlator := Services.NewMemoryResultCorrelator(0)
+	handler := Services.B2CResultHandler(nil, nil, Services.WithResultCorrelator(correlator))
+
+	// The result arrives before Daraja's acknowledgement is returned.
+	client := mpesatest.NewRecordingClient().RespondWith(mpesatest.AnyEndpoint, func(any) (map[string]any, error) {
+		postWebhook(handler, b2cResultSuccessJSON)
+		return map[string]any{"ConversationID": "AG_20191219_00004e48cf7e3533f581", "ResponseCode": "0"}, nil
+	})
+	service := newTestB2CService(client).
+		SetPhoneNumber("254708374149").
+		SetOriginatorConversationID("10571-7910404-1").
+		SetResultCorrelator(correlator)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := service.SendAndWait(ctx)
+	if err != nil {
+		t.Fatalf("SendAndWait error: %v", err)
+	}
+	b2c, ok := res.Result.(*Services.B2CResult)
+	if !ok || !res.Success || res.Type != Services.CallbackB2CResult || b2c.TransactionReceipt == "" {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestSendAndWait_WaitBeforeCallback(t *testing.T) {
+	correlator := &signallingCorrelator{MemoryResultCorrelator: Services.NewMemoryResultCorrelator(0)}
+	handler := Services.ReversalResultHandler(nil, nil, Services.WithResultCorrelator(correlator))
+	correlator.onRegister = func(id string) {
+		if id == "AG_20191219_00004e48cf7e3533f581" {
+			postWebhook(handler, reversalResultSuccessJSON)
+		}
+	}
+
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_20191219_00004e48cf7e3533f581", "ResponseCode": "0"})
+	service := Services.NewReversalService(buildTestConfig(), client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		SetResultCorrelator(correlator)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := service.SendAndWait(ctx)
+	if err != nil {
+		t.Fatalf("SendAndWait error: %v", err)
+	}
+	if _, ok := res.Result.(*Services.ReversalResult); !ok || res.Type != Services.CallbackReversalResult {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
+
+func TestSendAndWait_QueueTimeout(t *testing.T) {
+	correlator := &signallingCorrelator{MemoryResultCorrelator: Services.NewMemoryResultCorrelator(0)}
+	handler := Services.AccountBalanceResultHandler(nil, nil, Services.WithResultCorrelator(correlator))
+	correlator.onRegister = func(id string) {
+		postWebhook(handler, `{"OriginatorConversationID":"16917-22577599-3","ConversationID":"AG_BAL","ResultDesc":"The request timed out in the queue."}`)
+	}
+
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_BAL", "ResponseCode": "0"})
+	service := Services.NewAccountBalanceService(buildTestConfig(), client).
+		SetInitiator("testapi").
+		SetResultCorrelator(correlator)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := service.SendAndWait(ctx)
+	if err != nil {
+		t.Fatalf("SendAndWait error: %v", err)
+	}
+	if _, ok := res.Result.(*Services.QueueTimeoutResult); !ok || !res.QueueTimeout || res.Type != Services.CallbackBalanceTimeout {
+		t.Errorf("expected queue timeout result, got %+v", res)
+	}
+}
+
+func TestSendAndWait_TimeoutWithStatusFallback(t *testing.T) {
+	correlator := &signallingCorrelator{MemoryResultCorrelator: Services.NewMemoryResultCorrelator(0)}
+	statusHandler := Services.TransactionStatusResultHandler(nil, nil, Services.WithResultCorrelator(correlator))
+	correlator.onRegister = func(id string) {
+		if id == "AG_20200120_0000657265d5fa9ae5c0" {
+			postWebhookTo(statusHandler, "/mpesa/status/result", transactionStatusCompletedJSON)
+		}
+	}
+
+	// No B2C result ever arrives; the status query finds the payment.
+	b2cClient := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_LOST", "OriginatorConversationID": "payout-7"})
+	statusClient := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_20200120_0000657265d5fa9ae5c0", "ResponseCode": "0"})
+	status := Services.NewTransactionStatusService(buildTestConfig(), statusClient).
+		SetInitiator("testapi").
+		SetIdentifierType("4")
+	service := newTestB2CService(b2cClient).
+		SetPhoneNumber("254708374149").
+		SetOriginatorConversationID("payout-7").
+		SetResultCorrelator(correlator).
+		SetStatusFallback(status, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res, err := service.SendAndWait(ctx)
+	if err != nil {
+		t.Fatalf("SendAndWait error: %v", err)
+	}
+	if _, ok := res.Result.(*Services.TransactionStatusResult); !ok || res.Type != Services.CallbackStatusResult {
+		t.Errorf("expected the status result, got %+v", res)
+	}
+	query := statusClient.LastPayload(mpesatest.AnyEndpoint)
+	if query["OriginalConversationID"] != "payout-7" || query["TransactionID"] != "" {
+		t.Errorf("expected status query by OriginatorConversationID, got %v", query)
+	}
+}
+
+func TestSendAndWait_B2BStatusFallback(t *testing.T) {
+	correlator := &signallingCorrelator{MemoryResultCorrelator: Services.NewMemoryResultCorrelator(0)}
+	statusHandler := Services.TransactionStatusResultHandler(nil, nil, Services.WithResultCorrelator(correlator))
+	correlator.onRegister = func(id string) {
+		if id == "AG_20200120_0000657265d5fa9ae5c0" {
+			postWebhookTo(statusHandler, "/mpesa/status/result", transactionStatusCompletedJSON)
+		}
+	}
+
+	b2bClient := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_LOST", "ResponseCode": "0"})
+	statusClient := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_20200120_0000657265d5fa9ae5c0", "ResponseCode": "0"})
+	status := Services.NewTransactionStatusService(buildTestConfig(), statusClient).
+		SetInitiator("testapi").
+		SetIdentifierType("4").
+		SetTransactionID("OEI2AK4Q16")
+	service := Services.NewBusinessToPayBillService(newTestB2BConfig(abstracts.Sandbox), b2bClient).
+		SetInitiator("testapi").
+		SetAmount(100).
+		SetPartyB("000001").
+		SetAccountReference("INV-001").
+		SetQueueTimeoutURL("https://example.com/timeout").
+		SetResultURL("https://example.com/result").
+		SetResultCorrelator(correlator).
+		SetStatusFallback(status, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res, err := service.SendAndWait(ctx)
+	if err != nil {
+		t.Fatalf("SendAndWait error: %v", err)
+	}
+	if res.Type != Services.CallbackStatusResult {
+		t.Errorf("expected the status result, got %+v", res)
+	}
+	sent := b2bClient.LastPayload(mpesatest.AnyEndpoint)["OriginatorConversationID"]
+	if sent == nil || sent == "" {
+		t.Fatalf("expected the B2B request to carry an OriginatorConversationID")
+	}
+	if query := statusClient.LastPayload(mpesatest.AnyEndpoint); query["OriginalConversationID"] != sent || query["TransactionID"] != "" {
+		t.Errorf("expected status query by OriginatorConversationID %v, got %v", sent, query)
+	}
+
+	// The fallback queried a copy; the shared status service is unchanged.
+	if _, err := status.Query(); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if query := statusClient.LastPayload(mpesatest.AnyEndpoint); query["TransactionID"] != "OEI2AK4Q16" || query["OriginalConversationID"] != nil {
+		t.Errorf("expected the status service to be left untouched, got %v", query)
+	}
+}
+
+func TestSendAndWait_Timeout(t *testing.T) {
+	client := mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, map[string]any{"ConversationID": "AG_LOST"})
+	service := newTestB2CService(client).
+		SetPhoneNumber("254708374149").
+		SetResultCorrelator(Services.NewMemoryResultCorrelator(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := service.SendAndWait(ctx); !errors.Is(err, Services.ErrResultTimeout) {
+		t.Errorf("expected ErrResultTimeout, got %v", err)
+	}
+
+	if _, err := newTestB2CService(client).SetPhoneNumber("254708374149").SendAndWait(ctx); err == nil {
+		t.Errorf("expected error without a correlator")
+	}
+	if client.Count(mpesatest.AnyEndpoint) != 1 {
+		t.Errorf("expected no request without a correlator, got %d requests", client.Count(mpesatest.AnyEndpoint))
+	}
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// b2bResultMixedCaseJSON is a B2B result relayed by an intermediary that rewrote the case of
+// every key and padded some of them with whitespace.
+const b2bResultMixedCaseJSON = `{
+  "RESULT": {
+    "resultType": 0,
+    "resultCode": "0",
+    " ResultDesc ": "The service request is processed successfully",
+    "originatorConversationID": "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+    "conversationId": "12345677dfdf89099B3",
+    "transactionID": "QKA81LK5CY",
+    "resultParameters": {
+      "resultParameter": [
+        {"key": "Amount", "value": "190.00"},
+        {" KEY": "TransCompletedTime", "VALUE ": "20221110110717"}
+      ]
+    },
+    "referenceData": {
+      "referenceItem": {"Key": "BillReferenceNumber", "value": "19008"}
+    }
+  }
+}`
+
+func TestParseResultEnvelope_Arrays(t *testing.T) {
+	env, err := Services.ParseResultEnvelope(b2bCallbackSuccessPayload())
+	if err != nil {
+		t.Fatalf("ParseResultEnvelope error: %v", err)
+	}
+	if !env.Success || env.ResultType != "0" || env.TransactionID != "QKA81LK5CY" {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if env.ResultParameters["Amount"] != "190.00" || env.ResultParameters["TransCompletedTime"] != "20221110110717" {
+		t.Errorf("unexpected result parameters: %v", env.ResultParameters)
+	}
+	if env.ReferenceData["BillReferenceNumber"] != "19008" {
+		t.Errorf("unexpected reference data: %v", env.ReferenceData)
+	}
+}
+
+func TestParseResultEnvelope_SingleObjectsAndNumbers(t *testing.T) {
+	env, err := Services.ParseResultEnvelope(b2bCallbackFailurePayload())
+	if err != nil {
+		t.Fatalf("ParseResultEnvelope error: %v", err)
+	}
+	if env.Success || env.ResultCode != "2001" {
+		t.Errorf("expected failure code 2001, got success %v code %s", env.Success, env.ResultCode)
+	}
+	if env.ResultParameters["BOCompletedTime"] != "20200120164825" {
+		t.Errorf("expected numeric value to be formatted without exponent, got %v", env.ResultParameters)
+	}
+	if env.ReferenceData["QueueTimeoutURL"] == "" {
+		t.Errorf("expected single reference item to be parsed, got %v", env.ReferenceData)
+	}
+}
+
+func TestParseResultEnvelope_EdgeCases(t *testing.T) {
+	env, err := Services.ParseResultEnvelope(map[string]any{
+		"result": map[string]any{
+			"ResultCode": float64(0),
+			"ResultParameters": []any{
+				map[string]any{"Key": "Amount", "Value": 10.5},
+				map[string]any{"Key": float64(42), "Value": "numeric key"},
+				map[string]any{"Value": "no key"},
+				"not an item",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseResultEnvelope error: %v", err)
+	}
+	if !env.Success {
+		t.Errorf("expected numeric 0 result code to be a success")
+	}
+	if env.ResultParameters["Amount"] != "10.5" || env.ResultParameters["42"] != "numeric key" || len(env.ResultParameters) != 2 {
+		t.Errorf("unexpected result parameters: %v", env.ResultParameters)
+	}
+	if env.ReferenceData == nil || len(env.ReferenceData) != 0 {
+		t.Errorf("expected empty reference data for a missing node, got %v", env.ReferenceData)
+	}
+}
+
+func TestParseResultEnvelope_MixedCaseKeys(t *testing.T) {
+	env, err := Services.ParseResultEnvelope(decodeFixture(t, b2bResultMixedCaseJSON))
+	if err != nil {
+		t.Fatalf("ParseResultEnvelope error: %v", err)
+	}
+	if !env.Success || env.ResultType != "0" || env.ResultDesc == "" || env.TransactionID != "QKA81LK5CY" {
+		t.Errorf("unexpected envelope: %+v", env)
+	}
+	if env.OriginatorConversationID != "626f6ddf-ab37-4650-b882-b1de92ec9aa4" || env.ConversationID != "12345677dfdf89099B3" {
+		t.Errorf("unexpected conversation IDs: %+v", env)
+	}
+	if env.ResultParameters["Amount"] != "190.00" || env.ResultParameters["TransCompletedTime"] != "20221110110717" {
+		t.Errorf("unexpected result parameters: %v", env.ResultParameters)
+	}
+	if env.ReferenceData["BillReferenceNumber"] != "19008" {
+		t.Errorf("unexpected reference data: %v", env.ReferenceData)
+	}
+
+	res, err := Services.ParseB2BCallback(decodeFixture(t, b2bResultMixedCaseJSON))
+	if err != nil {
+		t.Fatalf("ParseB2BCallback error: %v", err)
+	}
+	var unmarshaled Services.B2BCallbackResult
+	if err := json.Unmarshal([]byte(b2bResultMixedCaseJSON), &unmarshaled); err != nil {
+		t.Fatalf("json.Unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(res, &unmarshaled) || res.TransactionID != "QKA81LK5CY" {
+		t.Errorf("json.Unmarshal and ParseB2BCallback differ:\n%+v\n%+v", unmarshaled, *res)
+	}
+}
+
+func TestParseResultEnvelope_NullAndUnexpectedTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters any
+		want       map[string]string
+	}{
+		{"Null node", nil, map[string]string{}},
+		{"Null wrapper", map[string]any{"ResultParameter": nil}, map[string]string{}},
+		{"Null array element", map[string]any{"ResultParameter": []any{nil, map[string]any{"Key": "Amount", "Value": "10"}}}, map[string]string{"Amount": "10"}},
+		{"Null value", map[string]any{"ResultParameter": map[string]any{"Key": "Amount", "Value": nil}}, map[string]string{"Amount": ""}},
+		{"Number for the node", float64(5), map[string]string{}},
+		{"Number for the wrapper", map[string]any{"ResultParameter": float64(5)}, map[string]string{}},
+		{"Number for an item", []any{float64(5), "text", true, map[string]any{"Key": "Amount", "Value": float64(10)}}, map[string]string{"Amount": "10"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := map[string]any{"Result": map[string]any{
+				"ResultCode":       "2001",
+				"ResultParameters": tt.parameters,
+				"ReferenceData":    map[string]any{"ReferenceItem": nil},
+			}}
+			env, err := Services.ParseResultEnvelope(payload)
+			if err != nil {
+				t.Fatalf("ParseResultEnvelope error: %v", err)
+			}
+			if !reflect.DeepEqual(env.ResultParameters, tt.want) || env.ReferenceData == nil || len(env.ReferenceData) != 0 {
+				t.Errorf("expected %v and no reference data, got %v %v", tt.want, env.ResultParameters, env.ReferenceData)
+			}
+		})
+	}
+}
+
+func TestParseResultEnvelope_MissingNodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]any
+	}{
+		{"Missing Result", map[string]any{"foo": "bar"}},
+		{"Nil payload", nil},
+		{"Result not an object", map[string]any{"Result": "oops"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Services.ParseResultEnvelope(tt.payload); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
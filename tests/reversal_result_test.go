@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const reversalResultSuccessJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": 0,
+    "ResultDesc": "The service request is processed successfully.",
+    "OriginatorConversationID": "10571-7910404-1",
+    "ConversationID": "AG_20191219_00004e48cf7e3533f581",
+    "TransactionID": "NLJ41HAY6Q",
+    "ResultParameters": {
+      "ResultParameter": [
+        {"Key": "DebitAccountBalance", "Value": "Utility Account|KES|51661.00|51661.00|0.00|0.00"},
+        {"Key": "Amount", "Value": 150.75},
+        {"Key": "TransCompletedTime", "Value": 20191219141839},
+        {"Key": "OriginalTransactionID", "Value": "NLJ11HAY8Z"},
+        {"Key": "Charge", "Value": 0},
+        {"Key": "CreditPartyPublicName", "Value": "254708374149 - John Doe"},
+        {"Key": "DebitPartyPublicName", "Value": "600610 - Safaricom333"}
+      ]
+    },
+    "ReferenceData": {
+      "ReferenceItem": {
+        "Key": "QueueTimeoutURL",
+        "Value": "https://internalsandbox.safaricom.co.ke/mpesa/reversalresults/v1/submit"
+      }
+    }
+  }
+}`
+
+const reversalResultAlreadyReversedJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": "R000001",
+    "ResultDesc": "The transaction has already been reversed.",
+    "OriginatorConversationID": "8521-4298025-1",
+    "ConversationID": "AG_20181005_00004d7ee675c0c7ee0b",
+    "TransactionID": "MJ561H6X5O",
+    "ReferenceData": {
+      "ReferenceItem": {
+        "Key": "QueueTimeoutURL",
+        "Value": "https://internalsandbox.safaricom.co.ke/mpesa/reversalresults/v1/submit"
+      }
+    }
+  }
+}`
+
+func TestParseReversalResult_Success(t *testing.T) {
+	res, err := Services.ParseReversalResult(decodeFixture(t, reversalResultSuccessJSON))
+	if err != nil {
+		t.Fatalf("ParseReversalResult error: %v", err)
+	}
+
+	if !res.Success || res.ResultCode != "0" {
+		t.Fatalf("expected success, got code %s", res.ResultCode)
+	}
+	if res.Amount != 150.75 || res.Charge != 0 {
+		t.Errorf("unexpected amount/charge: %v / %v", res.Amount, res.Charge)
+	}
+	if res.OriginalTransactionID != "NLJ11HAY8Z" {
+		t.Errorf("unexpected original transaction: %s", res.OriginalTransactionID)
+	}
+	if res.CreditPartyPublicName != "254708374149 - John Doe" || res.DebitPartyPublicName != "600610 - Safaricom333" {
+		t.Errorf("unexpected party names: %s / %s", res.CreditPartyPublicName, res.DebitPartyPublicName)
+	}
+
+	expectedBalance := Services.AccountBalanceEntry{Account: "Utility Account", Currency: "KES", Current: 51661, Available: 51661}
+	if res.DebitAccountBalance != expectedBalance {
+		t.Errorf("expected balance %+v, got %+v", expectedBalance, res.DebitAccountBalance)
+	}
+
+	expected := time.Date(2019, 12, 19, 14, 18, 39, 0, time.FixedZone("EAT", 3*60*60))
+	if !res.TransCompletedTime.Equal(expected) {
+		t.Errorf("expected completion time %v, got %v", expected, res.TransCompletedTime)
+	}
+	if res.Raw == nil {
+		t.Errorf("expected raw payload to be kept")
+	}
+}
+
+func TestParseReversalResult_AlreadyReversed(t *testing.T) {
+	res, err := Services.ParseReversalResult(decodeFixture(t, reversalResultAlreadyReversedJSON))
+	if err != nil {
+		t.Fatalf("ParseReversalResult error: %v", err)
+	}
+
+	if res.Success {
+		t.Fatalf("expected failure, got success")
+	}
+	if res.ResultCode != "R000001" || res.ResultDesc != "The transaction has already been reversed." {
+		t.Errorf("unexpected result: %s %s", res.ResultCode, res.ResultDesc)
+	}
+	if res.Amount != 0 || res.DebitAccountBalance != (Services.AccountBalanceEntry{}) || !res.TransCompletedTime.IsZero() {
+		t.Errorf("expected zero values for missing parameters, got %+v", res)
+	}
+}
+
+func TestReversalResultHandler(t *testing.T) {
+	var got *Services.ReversalResult
+	var timedOut map[string]any
+	handler := Services.ReversalResultHandler(
+		func(res *Services.ReversalResult) { got = res },
+		func(raw map[string]any) { timedOut = raw },
+	)
+
+	rec := postWebhook(handler, reversalResultAlreadyReversedJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.ResultCode != "R000001" {
+		t.Fatalf("expected parsed result, got %+v", got)
+	}
+
+	rec = postWebhook(handler, `{"requestId":"11728-2929992-1","errorCode":"500.001.1001"}`)
+	if rec.Code != http.StatusOK || timedOut == nil {
+		t.Errorf("expected timeout notification to be acknowledged and passed on, got %d %v", rec.Code, timedOut)
+	}
+
+	rec = postWebhook(handler, `{"Result":`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected invalid body to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+}
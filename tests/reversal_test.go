@@ -2,24 +2,14 @@ package tests
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
 	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
 )
 
-type mockClient struct {
-	capturedPayload  any
-	capturedEndpoint string
-}
-
-func (m *mockClient) ExecuteRequest(payload any, endpoint string) (map[string]any, error) {
-	m.capturedPayload = payload
-	m.capturedEndpoint = endpoint
-	// Simulate success response
-	return map[string]any{"ResponseCode": "0"}, nil
-}
-
 func buildTestConfig() *abstracts.MpesaConfig {
 	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
 	cfg.SetBusinessCode("603021")
@@ -31,7 +21,7 @@ func buildTestConfig() *abstracts.MpesaConfig {
 
 func TestReversalService_SuccessReverse(t *testing.T) {
 	cfg := buildTestConfig()
-	client := &mockClient{}
+	client := mpesatest.NewRecordingClient()
 	service := Services.NewReversalService(cfg, client).
 		SetInitiator("apiop37").
 		SetTransactionID("PDU91HIVIT").
@@ -46,12 +36,12 @@ func TestReversalService_SuccessReverse(t *testing.T) {
 	if resp == nil {
 		t.Fatalf("expected response, got nil")
 	}
-	if client.capturedEndpoint != "/mpesa/reversal/v1/request" {
-		t.Errorf("unexpected endpoint: %s", client.capturedEndpoint)
+	if client.LastEndpoint() != "/mpesa/reversal/v1/request" {
+		t.Errorf("unexpected endpoint: %s", client.LastEndpoint())
 	}
-	payloadMap, ok := client.capturedPayload.(map[string]interface{})
-	if !ok {
-		t.Fatalf("expected payload map, got %T", client.capturedPayload)
+	payloadMap := client.LastPayload(mpesatest.AnyEndpoint)
+	if payloadMap == nil {
+		t.Fatalf("expected a request to be sent")
 	}
 	// Basic field assertions
 	if payloadMap["Amount"] != "200" {
@@ -70,7 +60,7 @@ func TestReversalService_SuccessReverse(t *testing.T) {
 
 func TestReversalService_ValidationErrors(t *testing.T) {
 	cfg := buildTestConfig()
-	client := &mockClient{}
+	client := mpesatest.NewRecordingClient()
 	service := Services.NewReversalService(cfg, client)
 
 	// Missing initiator
@@ -109,7 +99,7 @@ func TestReversalService_ValidationErrors(t *testing.T) {
 	cfgNoBiz.OverrideSecurityCredential("FAKE")
 	service = Services.NewReversalService(cfgNoBiz, client)
 	_, err = service.SetInitiator("user").SetTransactionID("TX123").SetAmount(10).SetReceiverIdentifierType("11").SetRemarks("Test").Reverse()
-	if err == nil || err.Error() != "business shortcode (ReceiverParty) is required; call SetBusinessCode on mpesa config" {
+	if err == nil || err.Error() != "business shortcode (ReceiverParty) is required; call SetReceiverParty or SetBusinessCode on mpesa config" {
 		t.Errorf("expected business code validation error, got %v", err)
 	}
 
@@ -118,7 +108,7 @@ func TestReversalService_ValidationErrors(t *testing.T) {
 	cfgNoQueue.SetQueueTimeoutURL("")
 	service = Services.NewReversalService(cfgNoQueue, client)
 	_, err = service.SetInitiator("user").SetTransactionID("TX123").SetAmount(10).SetReceiverIdentifierType("11").SetRemarks("Test").Reverse()
-	if err == nil || err.Error() != "queue timeout URL is required; call SetQueueTimeoutURL on config" {
+	if err == nil || err.Error() != "queue timeout URL is required; call SetQueueTimeoutURL on the service or config" {
 		t.Errorf("expected queue timeout URL validation error, got %v", err)
 	}
 
@@ -127,14 +117,14 @@ func TestReversalService_ValidationErrors(t *testing.T) {
 	cfgNoResult.SetResultURL("")
 	service = Services.NewReversalService(cfgNoResult, client)
 	_, err = service.SetInitiator("user").SetTransactionID("TX123").SetAmount(10).SetReceiverIdentifierType("11").SetRemarks("Test").Reverse()
-	if err == nil || err.Error() != "result URL is required; call SetResultURL on config" {
+	if err == nil || err.Error() != "result URL is required; call SetResultURL on the service or config" {
 		t.Errorf("expected result URL validation error, got %v", err)
 	}
 
 	// Receiver identifier type missing
 	cfgOK := buildTestConfig()
 	service = Services.NewReversalService(cfgOK, client)
-	_, err = service.SetInitiator("user").SetTransactionID("TX123").SetAmount(10).SetRemarks("Test").Reverse()
+	_, err = service.SetInitiator("user").SetTransactionID("TX123").SetAmount(10).SetReceiverIdentifierType("").SetRemarks("Test").Reverse()
 	if err == nil || !errors.Is(err, errors.New("receiver identifier type is required")) {
 		// compare string due to distinct error instances
 		if err == nil || err.Error() != "receiver identifier type is required" {
@@ -142,3 +132,262 @@ func TestReversalService_ValidationErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestReversalService_DefaultReceiverIdentifierType(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewReversalService(buildTestConfig(), client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["RecieverIdentifierType"] != string(Services.IdentifierTypePaybill) {
+		t.Errorf("expected default receiver identifier type 11, got %v", payload["RecieverIdentifierType"])
+	}
+}
+
+func TestReversalService_InvalidReceiverIdentifierType(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewReversalService(buildTestConfig(), client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetReceiverIdentifierType("9").
+		SetRemarks("Payment reversal").
+		Reverse()
+
+	expected := `invalid receiver identifier type "9": must be one of 1, 2, 4, 11`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, got %v", expected, err)
+	}
+	if client.Count(mpesatest.AnyEndpoint) > 0 {
+		t.Errorf("expected no request to be sent")
+	}
+}
+
+func TestReversalService_TextLengths(t *testing.T) {
+	cases := []struct {
+		name     string
+		remarks  string
+		occasion string
+		wantErr  string
+	}{
+		{"1-char remarks", "R", "", "remarks must be between 2 and 100 characters, got 1"},
+		{"2-char remarks", "Re", "", ""},
+		{"100-char remarks", strings.Repeat("r", 100), "", ""},
+		{"101-char remarks", strings.Repeat("r", 101), "", "remarks must be between 2 and 100 characters, got 101"},
+		{"100-char occasion", "Refund", strings.Repeat("o", 100), ""},
+		{"101-char occasion", "Refund", strings.Repeat("o", 101), "occasion must be between 0 and 100 characters, got 101"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := Services.NewReversalService(buildTestConfig(), client).
+				SetInitiator("apiop37").
+				SetTransactionID("PDU91HIVIT").
+				SetAmount(200).
+				SetRemarks(tc.remarks).
+				SetOccasion(tc.occasion).
+				Reverse()
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) > 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
+
+func TestReversalService_Amounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Services.ReversalService)
+		expected  string
+		wantErr   string
+	}{
+		{"String with cents", func(s *Services.ReversalService) { s.SetAmountString("150.75") }, "150.75", ""},
+		{"String with separator", func(s *Services.ReversalService) { s.SetAmountString("1,500.50") }, "1500.5", ""},
+		{"Float with cents", func(s *Services.ReversalService) { s.SetAmountFloat(150.75) }, "150.75", ""},
+		{"Float integral", func(s *Services.ReversalService) { s.SetAmountFloat(200) }, "200", ""},
+		{"Int", func(s *Services.ReversalService) { s.SetAmount(200) }, "200", ""},
+		{"Int overrides string", func(s *Services.ReversalService) { s.SetAmountString("abc").SetAmount(200) }, "200", ""},
+		{"Invalid string", func(s *Services.ReversalService) { s.SetAmountString("abc") }, "", `invalid amount: amount "abc" is not a valid number`},
+		{"Zero float", func(s *Services.ReversalService) { s.SetAmountFloat(0) }, "", "invalid amount: amount must be greater than 0"},
+		{"Negative string", func(s *Services.ReversalService) { s.SetAmountString("-10") }, "", "invalid amount: amount must be greater than 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			svc := Services.NewReversalService(buildTestConfig(), client).
+				SetInitiator("apiop37").
+				SetTransactionID("PDU91HIVIT").
+				SetRemarks("Payment reversal")
+			tt.configure(svc)
+
+			_, err := svc.Reverse()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if client.Count(mpesatest.AnyEndpoint) > 0 {
+					t.Errorf("expected no request to be sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got := client.LastPayload(mpesatest.AnyEndpoint)["Amount"]; got != tt.expected {
+				t.Errorf("expected amount %q, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReversalService_Overrides(t *testing.T) {
+	cfg := buildTestConfig()
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		SetReceiverParty("600999").
+		SetQueueTimeoutURL("https://example.com/second/queue").
+		SetResultURL("https://example.com/second/result").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["ReceiverParty"] != "600999" || payload["QueueTimeOutURL"] != "https://example.com/second/queue" || payload["ResultURL"] != "https://example.com/second/result" {
+		t.Errorf("expected overrides in payload, got %v", payload)
+	}
+	if cfg.GetBusinessCode() != "603021" || cfg.GetQueueTimeoutURL() != "https://example.com/reversal/queue" || cfg.GetResultURL() != "https://example.com/reversal/result" {
+		t.Errorf("expected config to be untouched, got %s %s %s", cfg.GetBusinessCode(), cfg.GetQueueTimeoutURL(), cfg.GetResultURL())
+	}
+}
+
+func TestReversalService_OverridesWithoutConfigValues(t *testing.T) {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	cfg.OverrideSecurityCredential("FAKE")
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		SetReceiverParty("600999").
+		SetQueueTimeoutURL("https://example.com/second/queue").
+		SetResultURL("https://example.com/second/result").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected overrides to satisfy validation, got %v", err)
+	}
+	if cfg.GetBusinessCode() != "" || cfg.GetResultURL() != "" {
+		t.Errorf("expected config to stay empty")
+	}
+}
+
+func TestReversalService_ReceiverPartyWithoutConfigBusinessCode(t *testing.T) {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	cfg.SetQueueTimeoutURL("https://example.com/reversal/queue")
+	cfg.SetResultURL("https://example.com/reversal/result")
+	cfg.OverrideSecurityCredential("FAKE")
+
+	client := mpesatest.NewRecordingClient()
+	_, err := Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		SetReceiverParty("600999").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected receiver party override to satisfy validation, got %v", err)
+	}
+	if got := client.LastPayload(mpesatest.AnyEndpoint)["ReceiverParty"]; got != "600999" {
+		t.Errorf("expected receiver party 600999, got %v", got)
+	}
+
+	client = mpesatest.NewRecordingClient()
+	_, err = Services.NewReversalService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		Reverse()
+	if err == nil || !strings.Contains(err.Error(), "SetReceiverParty") || !strings.Contains(err.Error(), "SetBusinessCode") {
+		t.Errorf("expected error mentioning both SetReceiverParty and SetBusinessCode, got %v", err)
+	}
+}
+
+const reversalAcceptedJSON = `{
+  "OriginatorConversationID": "f1e2-4b95-a71d-b30d3cdbb7a7735297",
+  "ConversationID": "AG_20210706_20106e9209f64bebd05b",
+  "ResponseCode": "0",
+  "ResponseDescription": "Accept the service request successfully."
+}`
+
+func TestReversalService_TypedResponse(t *testing.T) {
+	service := Services.NewReversalService(buildTestConfig(), mpesatest.NewRecordingClient().Respond(mpesatest.AnyEndpoint, decodeFixture(t, reversalAcceptedJSON)))
+
+	if _, err := service.GetConversationID(); err == nil {
+		t.Errorf("expected error before any reversal was sent")
+	}
+
+	_, err := service.
+		SetInitiator("apiop37").
+		SetTransactionID("PDU91HIVIT").
+		SetAmount(200).
+		SetRemarks("Payment reversal").
+		Reverse()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	resp := service.GetTypedResponse()
+	if !resp.Accepted() || resp.ResponseDescription != "Accept the service request successfully." {
+		t.Errorf("unexpected typed response: %+v", resp)
+	}
+	if id, err := service.GetConversationID(); err != nil || id != "AG_20210706_20106e9209f64bebd05b" {
+		t.Errorf("unexpected ConversationID %q (%v)", id, err)
+	}
+	if id, err := service.GetOriginatorConversationID(); err != nil || id != "f1e2-4b95-a71d-b30d3cdbb7a7735297" {
+		t.Errorf("unexpected OriginatorConversationID %q (%v)", id, err)
+	}
+}
+
+func TestNewReversalResponse_Tolerant(t *testing.T) {
+	resp := Services.NewReversalResponse(map[string]any{
+		"conversationID":          "AG_1",
+		"OriginatorCoversationID": "ORIG_1",
+		"ResponseCode":            float64(1),
+	})
+	if resp.Accepted() || resp.ConversationID != "AG_1" || resp.OriginatorConversationID != "ORIG_1" || resp.ResponseCode != "1" {
+		t.Errorf("unexpected decoding: %+v", resp)
+	}
+
+	var nilResp *Services.ReversalResponse
+	if nilResp.Accepted() {
+		t.Errorf("expected nil response not to be accepted")
+	}
+}
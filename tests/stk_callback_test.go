@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const stkCallbackSuccessJSON = `{
+  "Body": {
+    "stkCallback": {
+      "MerchantRequestID": "29115-34620561-1",
+      "CheckoutRequestID": "ws_CO_191220191020363925",
+      "ResultCode": 0,
+      "ResultDesc": "The service request is processed successfully.",
+      "CallbackMetadata": {
+        "Item": [
+          {"Name": "Amount", "Value": 1.00},
+          {"Name": "MpesaReceiptNumber", "Value": "NLJ7RT61SV"},
+          {"Name": "Balance"},
+          {"Name": "TransactionDate", "Value": 20191219102115},
+          {"Name": "PhoneNumber", "Value": 254708374149}
+        ]
+      }
+    }
+  }
+}`
+
+const stkCallbackCancelledJSON = `{
+  "Body": {
+    "stkCallback": {
+      "MerchantRequestID": "29115-34620561-1",
+      "CheckoutRequestID": "ws_CO_191220191020363925",
+      "ResultCode": 1032,
+      "ResultDesc": "Request cancelled by user."
+    }
+  }
+}`
+
+func TestParseSTKCallback_Success(t *testing.T) {
+	cb, err := Services.ParseSTKCallback(decodeFixture(t, stkCallbackSuccessJSON))
+	if err != nil {
+		t.Fatalf("ParseSTKCallback error: %v", err)
+	}
+
+	if !cb.Success || cb.ResultCode != "0" || cb.CheckoutRequestID != "ws_CO_191220191020363925" {
+		t.Fatalf("unexpected callback: %+v", cb)
+	}
+	if cb.Amount != 1 || cb.MpesaReceiptNumber != "NLJ7RT61SV" || cb.PhoneNumber != "254708374149" {
+		t.Errorf("unexpected metadata: %+v", cb)
+	}
+	expected := time.Date(2019, 12, 19, 10, 21, 15, 0, time.FixedZone("EAT", 3*60*60))
+	if !cb.TransactionDate.Equal(expected) {
+		t.Errorf("expected transaction date %v, got %v", expected, cb.TransactionDate)
+	}
+	if balance, ok := cb.Metadata["Balance"]; !ok || balance != "" {
+		t.Errorf("expected empty Balance item to be kept, got %q", balance)
+	}
+}
+
+func TestParseSTKCallback_Cancelled(t *testing.T) {
+	cb, err := Services.ParseSTKCallback(decodeFixture(t, stkCallbackCancelledJSON))
+	if err != nil {
+		t.Fatalf("ParseSTKCallback error: %v", err)
+	}
+	if cb.Success || cb.ResultCode != "1032" || cb.MpesaReceiptNumber != "" || len(cb.Metadata) != 0 {
+		t.Errorf("unexpected cancelled callback: %+v", cb)
+	}
+}
+
+func TestParseSTKCallback_MissingBody(t *testing.T) {
+	for _, body := range []string{`{"foo":"bar"}`, `{"Body":{"other":{}}}`} {
+		if _, err := Services.ParseSTKCallback(decodeFixture(t, body)); err == nil {
+			t.Errorf("expected error for %s", body)
+		}
+	}
+}
+
+func TestSTKCallbackHandler(t *testing.T) {
+	var got *Services.STKCallback
+	handler := Services.STKCallbackHandler(func(cb *Services.STKCallback) { got = cb })
+
+	rec := postWebhook(handler, stkCallbackSuccessJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.MpesaReceiptNumber != "NLJ7RT61SV" {
+		t.Fatalf("expected parsed callback, got %+v", got)
+	}
+
+	rec = postWebhook(handler, `{"Body":"not an object"}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected invalid callback to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+}
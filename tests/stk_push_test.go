@@ -5,26 +5,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/venomous-maker/go-mpesa/Abstracts"
 	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
 )
 
-// MockMpesaInterface is a mock implementation of MpesaInterface
-type MockMpesaInterface struct {
-	mock.Mock
-}
-
-func (m *MockMpesaInterface) ExecuteRequest(payload any, endpoint string) (map[string]any, error) {
-	args := m.Called(payload, endpoint)
-	return args.Get(0).(map[string]any), args.Error(1)
-}
-
-func (m *MockMpesaInterface) GetAccessToken() (string, error) {
-	args := m.Called()
-	return args.String(0), args.Error(1)
-}
-
 // Helper function to create test config
 func createTestConfig() *Abstracts.MpesaConfig {
 	cfg, _ := Abstracts.NewMpesaConfig(
@@ -44,7 +29,7 @@ func ptr(s string) *string {
 
 func TestNewStkService(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 
 	service := Services.NewStkService(cfg, mockClient)
 
@@ -55,7 +40,7 @@ func TestNewStkService(t *testing.T) {
 
 func TestStkService_SetTransactionType(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	result := service.SetTransactionType("CustomerPayBillOnline")
@@ -65,7 +50,7 @@ func TestStkService_SetTransactionType(t *testing.T) {
 
 func TestStkService_SetAmount(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	tests := []struct {
@@ -89,7 +74,7 @@ func TestStkService_SetAmount(t *testing.T) {
 
 func TestStkService_SetPhoneNumber(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	tests := []struct {
@@ -99,6 +84,12 @@ func TestStkService_SetPhoneNumber(t *testing.T) {
 	}{
 		{"Valid phone number with country code", "254111844429", false},
 		{"Valid phone number without country code", "0111844429", false},
+		{"Valid bare phone number", "712345678", false},
+		{"Valid bare 01 phone number", "111844429", false},
+		{"Wrong length phone number", "07123456789", true},
+		{"11-digit phone number", "25471122334", true},
+		{"13-digit phone number", "2547112233445", true},
+		{"13-digit international phone number", "+2547112233445", true},
 		{"Empty phone number", "", true},
 		{"Short phone number", "123", true},
 	}
@@ -119,7 +110,7 @@ func TestStkService_SetPhoneNumber(t *testing.T) {
 
 func TestStkService_SetCallbackUrl(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	url := "https://example.com/callback"
@@ -130,7 +121,7 @@ func TestStkService_SetCallbackUrl(t *testing.T) {
 
 func TestStkService_SetAccountReference(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	ref := "TEST_REF_123"
@@ -141,7 +132,7 @@ func TestStkService_SetAccountReference(t *testing.T) {
 
 func TestStkService_SetTransactionDesc(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	desc := "Test transaction description"
@@ -152,18 +143,11 @@ func TestStkService_SetTransactionDesc(t *testing.T) {
 
 func TestStkService_Push_Success(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Setup mock response
-	mockResponse := map[string]any{
-		"CheckoutRequestID":   "ws_CO_12345678",
-		"ResponseCode":        "0",
-		"ResponseDescription": "Success",
-		"MerchantRequestID":   "29115-34620561-1",
-	}
-
-	mockClient.On("ExecuteRequest", mock.AnythingOfType("map[string]interface {}"), "/mpesa/stkpush/v1/processrequest").Return(mockResponse, nil)
+	mockClient.Enqueue(cfg.Endpoints.StkPush, mpesatest.StkPushAccepted(mpesatest.WithCheckoutRequestID("ws_CO_12345678")))
 
 	// Configure service
 	service.SetTransactionType("CustomerPayBillOnline")
@@ -178,12 +162,13 @@ func TestStkService_Push_Success(t *testing.T) {
 
 	assert.NoError(t, err)
 	assert.Equal(t, service, result)
-	mockClient.AssertExpectations(t)
+	assert.Equal(t, 1, mockClient.Count("/mpesa/stkpush/v1/processrequest"))
+	mockClient.AssertSent(t, cfg.Endpoints.StkPush, map[string]any{"Amount": 100, "AccountReference": "TEST_REF"})
 }
 
 func TestStkService_Push_ValidationErrors(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 
 	tests := []struct {
 		name          string
@@ -247,11 +232,11 @@ func TestStkService_Push_ValidationErrors(t *testing.T) {
 
 func TestStkService_Push_APIError(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Setup mock to return error
-	mockClient.On("ExecuteRequest", mock.AnythingOfType("map[string]interface {}"), "/mpesa/stkpush/v1/processrequest").Return(map[string]any{}, errors.New("API error"))
+	mockClient.Fail(cfg.Endpoints.StkPush, errors.New("API error"))
 
 	// Configure service
 	service.SetTransactionType("CustomerPayBillOnline")
@@ -264,21 +249,16 @@ func TestStkService_Push_APIError(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "API error")
-	mockClient.AssertExpectations(t)
+	assert.Equal(t, 1, mockClient.Count("/mpesa/stkpush/v1/processrequest"))
 }
 
 func TestStkService_GetCheckoutRequestID_Success(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Setup mock response
-	mockResponse := map[string]any{
-		"CheckoutRequestID": "ws_CO_12345678",
-		"ResponseCode":      "0",
-	}
-
-	mockClient.On("ExecuteRequest", mock.AnythingOfType("map[string]interface {}"), "/mpesa/stkpush/v1/processrequest").Return(mockResponse, nil)
+	mockClient.Enqueue(cfg.Endpoints.StkPush, mpesatest.StkPushAccepted(mpesatest.WithCheckoutRequestID("ws_CO_12345678")))
 
 	// Configure and execute push first
 	service.SetTransactionType("CustomerPayBillOnline")
@@ -297,7 +277,7 @@ func TestStkService_GetCheckoutRequestID_Success(t *testing.T) {
 
 func TestStkService_GetCheckoutRequestID_NoResponse(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Try to get checkout request ID without pushing first
@@ -309,43 +289,35 @@ func TestStkService_GetCheckoutRequestID_NoResponse(t *testing.T) {
 
 func TestStkService_Query_Success(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Setup mock response for query
-	mockQueryResponse := map[string]any{
-		"ResponseCode":        "0",
-		"ResponseDescription": "The service request has been accepted successfully",
-		"MerchantRequestID":   "29115-34620561-1",
-		"CheckoutRequestID":   "ws_CO_12345678",
-		"ResultCode":          "0",
-		"ResultDesc":          "The service request is processed successfully.",
-	}
-
-	mockClient.On("ExecuteRequest", mock.AnythingOfType("map[string]interface {}"), "/mpesa/stkpushquery/v1/query").Return(mockQueryResponse, nil)
+	mockQueryResponse := mpesatest.StkQueryResult(mpesatest.WithCheckoutRequestID("ws_CO_12345678"))
+	mockClient.Enqueue(cfg.Endpoints.StkQuery, mockQueryResponse)
 
 	// Execute query
 	result, err := service.Query("ws_CO_12345678")
 
 	assert.NoError(t, err)
-	assert.Equal(t, mockQueryResponse, result)
-	mockClient.AssertExpectations(t)
+	assert.Equal(t, mockQueryResponse.Body, result)
+	mockClient.AssertSent(t, "/mpesa/stkpushquery/v1/query", map[string]any{"CheckoutRequestID": "ws_CO_12345678"})
 }
 
 func TestStkService_Query_APIError(t *testing.T) {
 	cfg := createTestConfig()
-	mockClient := &MockMpesaInterface{}
+	mockClient := mpesatest.NewRecordingClient()
 	service := Services.NewStkService(cfg, mockClient)
 
 	// Setup mock to return error
-	mockClient.On("ExecuteRequest", mock.AnythingOfType("map[string]interface {}"), "/mpesa/stkpushquery/v1/query").Return(map[string]any{}, errors.New("Query API error"))
+	mockClient.Fail(cfg.Endpoints.StkQuery, errors.New("Query API error"))
 
 	// Execute query
 	_, err := service.Query("ws_CO_12345678")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Query API error")
-	mockClient.AssertExpectations(t)
+	assert.Equal(t, 1, mockClient.Count("/mpesa/stkpushquery/v1/query"))
 }
 
 // Integration test example (would require actual API credentials)
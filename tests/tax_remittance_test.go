@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func TestTaxRemittance_Payload(t *testing.T) {
+	cfg := buildTestConfig()
+	client := mpesatest.NewRecordingClient()
+
+	_, err := Services.NewTaxRemittanceService(cfg, client).
+		SetInitiator("testapi").
+		SetAmount(2390).
+		SetPartyA("600979").
+		SetPRN(" 353353 ").
+		SetRemarks("VAT for March").
+		Send()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if client.LastEndpoint() != "/mpesa/b2b/v1/remittax" {
+		t.Errorf("unexpected endpoint %s", client.LastEndpoint())
+	}
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"Initiator":              "testapi",
+		"SecurityCredential":     "FAKE_SECURITY_CREDENTIAL",
+		"CommandID":              "PayTaxToKRA",
+		"SenderIdentifierType":   "4",
+		"RecieverIdentifierType": "4",
+		"Amount":                 2390,
+		"PartyA":                 "600979",
+		"PartyB":                 Services.KRAShortCode,
+		"AccountReference":       "353353",
+		"Remarks":                "VAT for March",
+		"QueueTimeOutURL":        cfg.GetQueueTimeoutURL(),
+		"ResultURL":              cfg.GetResultURL(),
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s %v, got %v", key, want, payload[key])
+		}
+	}
+	if _, ok := payload["Requester"]; ok {
+		t.Errorf("expected no Requester in tax remittance payload")
+	}
+}
+
+func TestTaxRemittance_Validation(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Services.TaxRemittanceService)
+		expected  string
+	}{
+		{"Missing initiator", func(s *Services.TaxRemittanceService) { s.SetAmount(100).SetPRN("353353") }, "initiator is required"},
+		{"Missing amount", func(s *Services.TaxRemittanceService) { s.SetInitiator("testapi").SetPRN("353353") }, "amount must be greater than 0"},
+		{"Missing PRN", func(s *Services.TaxRemittanceService) { s.SetInitiator("testapi").SetAmount(100) }, "payment registration number (PRN) is required; call SetPRN"},
+		{"Blank PRN", func(s *Services.TaxRemittanceService) { s.SetInitiator("testapi").SetAmount(100).SetPRN("   ") }, "payment registration number (PRN) is required; call SetPRN"},
+		{"Relative result URL", func(s *Services.TaxRemittanceService) {
+			s.SetInitiator("testapi").SetAmount(100).SetPRN("353353").SetResultURL("/result")
+		}, `result URL "/result" is not a valid absolute URL`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			svc := Services.NewTaxRemittanceService(buildTestConfig(), client)
+			tt.configure(svc)
+
+			_, err := svc.Send()
+			if err == nil || err.Error() != tt.expected {
+				t.Fatalf("expected error %q, got %v", tt.expected, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) > 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+const transactionStatusCompletedJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": 0,
+    "ResultDesc": "The service request is processed successfully.",
+    "OriginatorConversationID": "10816-694520-2",
+    "ConversationID": "AG_20200120_0000657265d5fa9ae5c0",
+    "TransactionID": "NLK0000000",
+    "ResultParameters": {
+      "ResultParameter": [
+        {"Key": "DebitPartyName", "Value": "600310 - Safaricom333"},
+        {"Key": "CreditPartyName", "Value": "254708374149 - John Doe"},
+        {"Key": "OriginatorConversationID", "Value": "3211-416020-3"},
+        {"Key": "InitiatedTime", "Value": 20200120164825},
+        {"Key": "DebitAccountType", "Value": "Utility Account"},
+        {"Key": "DebitPartyCharges", "Value": ""},
+        {"Key": "TransactionReason", "Value": ""},
+        {"Key": "ReasonType", "Value": "Business Payment to Customer via API"},
+        {"Key": "TransactionStatus", "Value": "Completed"},
+        {"Key": "FinalisedTime", "Value": 20200120164826},
+        {"Key": "Amount", "Value": 10.5},
+        {"Key": "ConversationID", "Value": "AG_20200120_0000657265d5fa9ae5c1"},
+        {"Key": "ReceiptNo", "Value": "NLK0000001"}
+      ]
+    },
+    "ReferenceData": {
+      "ReferenceItem": {"Key": "Occasion", "Value": "Reconciliation"}
+    }
+  }
+}`
+
+const transactionStatusNotFoundJSON = `{
+  "Result": {
+    "ResultType": 0,
+    "ResultCode": "R000002",
+    "ResultDesc": "The OriginatorConversationID or TransactionID does not exist.",
+    "OriginatorConversationID": "10816-694520-3",
+    "ConversationID": "AG_20200120_0000657265d5fa9ae5c2",
+    "TransactionID": "NLK0000000"
+  }
+}`
+
+func TestParseTransactionStatusResult_Completed(t *testing.T) {
+	res, err := Services.ParseTransactionStatusResult(decodeFixture(t, transactionStatusCompletedJSON))
+	if err != nil {
+		t.Fatalf("ParseTransactionStatusResult error: %v", err)
+	}
+
+	if !res.Success || res.Status != Services.TransactionStateCompleted {
+		t.Fatalf("expected completed transaction, got %+v", res)
+	}
+	if res.OriginatorConversationID != "10816-694520-2" || res.ConversationID != "AG_20200120_0000657265d5fa9ae5c0" {
+		t.Errorf("expected envelope conversation IDs, got %s / %s", res.OriginatorConversationID, res.ConversationID)
+	}
+	if res.DebitPartyName != "600310 - Safaricom333" || res.CreditPartyName != "254708374149 - John Doe" {
+		t.Errorf("unexpected parties: %s / %s", res.DebitPartyName, res.CreditPartyName)
+	}
+	if res.Amount != 10.5 || res.ReceiptNo != "NLK0000001" || res.DebitAccountType != "Utility Account" {
+		t.Errorf("unexpected transaction details: %+v", res)
+	}
+	if res.TransactionStatus != "Completed" || res.ReasonType != "Business Payment to Customer via API" {
+		t.Errorf("unexpected status fields: %s / %s", res.TransactionStatus, res.ReasonType)
+	}
+
+	eat := time.FixedZone("EAT", 3*60*60)
+	if !res.InitiatedTime.Equal(time.Date(2020, 1, 20, 16, 48, 25, 0, eat)) {
+		t.Errorf("unexpected initiated time: %v", res.InitiatedTime)
+	}
+	if !res.FinalisedTime.Equal(time.Date(2020, 1, 20, 16, 48, 26, 0, eat)) {
+		t.Errorf("unexpected finalised time: %v", res.FinalisedTime)
+	}
+	if res.Raw == nil || res.ReferenceData["Occasion"] != "Reconciliation" {
+		t.Errorf("expected raw payload and reference data to be kept")
+	}
+}
+
+func TestParseTransactionStatusResult_NotFound(t *testing.T) {
+	res, err := Services.ParseTransactionStatusResult(decodeFixture(t, transactionStatusNotFoundJSON))
+	if err != nil {
+		t.Fatalf("ParseTransactionStatusResult error: %v", err)
+	}
+
+	if res.Success || res.ResultCode != "R000002" {
+		t.Fatalf("expected failed query, got code %s", res.ResultCode)
+	}
+	if res.Status != Services.TransactionStateUnknown {
+		t.Errorf("expected unknown state, got %s", res.Status)
+	}
+	if res.Amount != 0 || res.ReceiptNo != "" || !res.InitiatedTime.IsZero() || !res.FinalisedTime.IsZero() {
+		t.Errorf("expected zero values for missing parameters, got %+v", res)
+	}
+}
+
+func TestParseTransactionStatusResult_States(t *testing.T) {
+	cases := map[string]Services.TransactionState{
+		"Completed": Services.TransactionStateCompleted,
+		"Pending":   Services.TransactionStatePending,
+		"Failed":    Services.TransactionStateFailed,
+		"Declined":  Services.TransactionStateFailed,
+		"Reversed":  Services.TransactionStateReversed,
+		"Something": Services.TransactionStateUnknown,
+	}
+	for status, want := range cases {
+		payload := map[string]any{"Result": map[string]any{
+			"ResultCode":       0,
+			"ResultParameters": map[string]any{"ResultParameter": map[string]any{"Key": "TransactionStatus", "Value": status}},
+		}}
+		res, err := Services.ParseTransactionStatusResult(payload)
+		if err != nil {
+			t.Fatalf("ParseTransactionStatusResult error: %v", err)
+		}
+		if res.Status != want {
+			t.Errorf("status %q: expected %s, got %s", status, want, res.Status)
+		}
+	}
+}
+
+func TestTransactionStatusResult_Classification(t *testing.T) {
+	completed, _ := Services.ParseTransactionStatusResult(decodeFixture(t, transactionStatusCompletedJSON))
+	if !completed.IsTerminal() || completed.IsReversed() || completed.IsNotFound() {
+		t.Errorf("unexpected classification for completed result: %+v", completed)
+	}
+
+	notFound, _ := Services.ParseTransactionStatusResult(decodeFixture(t, transactionStatusNotFoundJSON))
+	if notFound.IsTerminal() || notFound.IsReversed() || !notFound.IsNotFound() {
+		t.Errorf("unexpected classification for not found result: %+v", notFound)
+	}
+
+	cases := []struct {
+		status   string
+		terminal bool
+		reversed bool
+	}{
+		{"Pending", false, false},
+		{"Failed", true, false},
+		{"Reversed", true, true},
+	}
+	for _, tc := range cases {
+		res, _ := Services.ParseTransactionStatusResult(map[string]any{"Result": map[string]any{
+			"ResultCode":       0,
+			"ResultParameters": map[string]any{"ResultParameter": map[string]any{"Key": "TransactionStatus", "Value": tc.status}},
+		}})
+		if res.IsTerminal() != tc.terminal || res.IsReversed() != tc.reversed || res.IsNotFound() {
+			t.Errorf("status %q: unexpected classification terminal=%v reversed=%v", tc.status, res.IsTerminal(), res.IsReversed())
+		}
+	}
+}
+
+func TestTransactionStatusResultHandler(t *testing.T) {
+	var got *Services.TransactionStatusResult
+	var timedOut map[string]any
+	handler := Services.TransactionStatusResultHandler(
+		func(res *Services.TransactionStatusResult) { got = res },
+		func(raw map[string]any) { timedOut = raw },
+	)
+
+	rec := postWebhook(handler, transactionStatusCompletedJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.ReceiptNo != "NLK0000001" || !got.IsTerminal() {
+		t.Fatalf("expected parsed result, got %+v", got)
+	}
+
+	rec = postWebhook(handler, transactionStatusNotFoundJSON)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Fatalf("expected ack for not found result, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got == nil || !got.IsNotFound() {
+		t.Fatalf("expected not found result, got %+v", got)
+	}
+
+	rec = postWebhook(handler, `{"requestId":"11728-2929992-1","errorCode":"500.001.1001"}`)
+	if rec.Code != http.StatusOK || timedOut == nil {
+		t.Errorf("expected timeout notification to be acknowledged and passed on, got %d %v", rec.Code, timedOut)
+	}
+
+	rec = postWebhook(handler, `{"Result":`)
+	if rec.Code != http.StatusOK || rec.Body.String() != webhookAckBody {
+		t.Errorf("expected invalid body to be acknowledged by default, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/mpesa/status/result", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	limited := Services.TransactionStatusResultHandler(nil, nil, Services.WithMaxBodyBytes(64), Services.WithAckPolicy(Services.StrictAck))
+	if rec = postWebhook(limited, transactionStatusCompletedJSON); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for oversized body, got %d", rec.Code)
+	}
+}
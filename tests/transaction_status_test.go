@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"testing"
+
+	abstracts "github.com/venomous-maker/go-mpesa/Abstracts"
+	"github.com/venomous-maker/go-mpesa/Services"
+	"github.com/venomous-maker/go-mpesa/mpesatest"
+)
+
+func newStatusQuery(cfg *abstracts.MpesaConfig, client abstracts.MpesaInterface) *Services.TransactionStatusService {
+	return Services.NewTransactionStatusService(cfg, client).
+		SetInitiator("apiop37").
+		SetTransactionID("NLK0000001").
+		SetIdentifierType(string(Services.IdentifierTypeShortcode))
+}
+
+func TestTransactionStatusService_Payload(t *testing.T) {
+	client := mpesatest.NewRecordingClient()
+	if _, err := newStatusQuery(buildTestConfig(), client).SetOccasion("Reconciliation").Query(); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	expected := map[string]any{
+		"Initiator":          "apiop37",
+		"SecurityCredential": "FAKE_SECURITY_CREDENTIAL",
+		"CommandID":          "TransactionStatusQuery",
+		"TransactionID":      "NLK0000001",
+		"PartyA":             "603021",
+		"IdentifierType":     "4",
+		"Remarks":            Services.DefaultTransactionStatusRemarks,
+		"QueueTimeOutURL":    "https://example.com/reversal/queue",
+		"ResultURL":          "https://example.com/reversal/result",
+		"Occasion":           "Reconciliation",
+	}
+	for key, want := range expected {
+		if payload[key] != want {
+			t.Errorf("expected %s=%v, got %v", key, want, payload[key])
+		}
+	}
+}
+
+func TestTransactionStatusService_ValidationErrors(t *testing.T) {
+	noBusinessCode, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	noBusinessCode.SetQueueTimeoutURL("https://example.com/status/queue")
+	noBusinessCode.SetResultURL("https://example.com/status/result")
+	noBusinessCode.OverrideSecurityCredential("FAKE")
+
+	noQueueURL := buildTestConfig()
+	noQueueURL.SetQueueTimeoutURL("")
+	noResultURL := buildTestConfig()
+	noResultURL.SetResultURL("")
+	noCredential := buildTestConfig()
+	noCredential.OverrideSecurityCredential("")
+
+	cases := []struct {
+		name    string
+		cfg     *abstracts.MpesaConfig
+		wantErr string
+	}{
+		{"missing business code", noBusinessCode, "business shortcode (PartyA) is required; call SetPartyA or SetBusinessCode on mpesa config"},
+		{"missing queue timeout URL", noQueueURL, "queue timeout URL is required; call SetQueueTimeoutURL on the service or config"},
+		{"missing result URL", noResultURL, "result URL is required; call SetResultURL on the service or config"},
+		{"missing security credential", noCredential, "security credential is required; set via SetSecurityCredential or OverrideSecurityCredential on config"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := mpesatest.NewRecordingClient()
+			_, err := newStatusQuery(tc.cfg, client).Query()
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.Count(mpesatest.AnyEndpoint) > 0 {
+				t.Errorf("expected no request to be sent")
+			}
+		})
+	}
+}
+
+func TestTransactionStatusService_Overrides(t *testing.T) {
+	cfg, _ := abstracts.NewMpesaConfig("ck", "cs", abstracts.Sandbox, nil, nil, nil, nil, nil)
+	cfg.OverrideSecurityCredential("FAKE")
+	client := mpesatest.NewRecordingClient()
+	_, err := newStatusQuery(cfg, client).
+		SetPartyA("600997").
+		SetQueueTimeoutURL("https://example.com/status/queue").
+		SetResultURL("https://example.com/status/result").
+		SetRemarks("Customer inquiry").
+		Query()
+	if err != nil {
+		t.Fatalf("expected overrides to satisfy validation, got %v", err)
+	}
+
+	payload := client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["PartyA"] != "600997" || payload["QueueTimeOutURL"] != "https://example.com/status/queue" || payload["ResultURL"] != "https://example.com/status/result" {
+		t.Errorf("unexpected overridden fields: %v", payload)
+	}
+	if payload["Remarks"] != "Customer inquiry" {
+		t.Errorf("expected explicit remarks, got %v", payload["Remarks"])
+	}
+
+	// Service values take precedence over the config and leave it untouched.
+	shared := buildTestConfig()
+	client = mpesatest.NewRecordingClient()
+	if _, err := newStatusQuery(shared, client).SetPartyA("600997").SetResultURL("https://example.com/status/result").Query(); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	payload = client.LastPayload(mpesatest.AnyEndpoint)
+	if payload["PartyA"] != "600997" || payload["ResultURL"] != "https://example.com/status/result" {
+		t.Errorf("expected service values to take precedence, got %v / %v", payload["PartyA"], payload["ResultURL"])
+	}
+	if payload["QueueTimeOutURL"] != "https://example.com/reversal/queue" {
+		t.Errorf("expected config queue timeout URL as fallback, got %v", payload["QueueTimeOutURL"])
+	}
+	if shared.GetBusinessCode() != "603021" || shared.GetResultURL() != "https://example.com/reversal/result" {
+		t.Errorf("expected config to be left untouched")
+	}
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venomous-maker/go-mpesa/Services"
+)
+
+// trickleReader returns one byte of body per read, sleeping delay before each.
+type trickleReader struct {
+	body  string
+	delay time.Duration
+}
+
+func (t *trickleReader) Read(p []byte) (int, error) {
+	if len(t.body) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(t.delay)
+	n := copy(p[:1], t.body)
+	t.body = t.body[n:]
+	return n, nil
+}
+
+// limitedHandlers returns every webhook handler built with opts, keyed by name.
+func limitedHandlers(opts ...Services.HandlerOption) map[string]http.Handler {
+	return map[string]http.Handler{
+		"stk":              Services.STKCallbackHandler(nil, opts...),
+		"c2b validation":   Services.C2BValidationHandler(func(*Services.C2BConfirmation) Services.C2BValidationResponse { return Services.AcceptC2BValidation() }, opts...),
+		"c2b confirmation": Services.C2BConfirmationHandler(func(*Services.C2BConfirmation) error { return nil }, opts...),
+		"b2c":              Services.B2CResultHandler(nil, nil, opts...),
+		"b2b":              Services.B2BResultHandler(nil, nil, opts...),
+		"reversal":         Services.ReversalResultHandler(nil, nil, opts...),
+		"balance":          Services.AccountBalanceResultHandler(nil, nil, opts...),
+	}
+}
+
+func TestWebhookHandlers_DefaultBodyLimit(t *testing.T) {
+	oversized := `{"x":"` + strings.Repeat("y", int(Services.DefaultMaxWebhookBodyBytes)) + `"}`
+	for _, policy := range []Services.AckPolicy{Services.AlwaysAck, Services.StrictAck} {
+		var reported []error
+		var failures []parseFailure
+		opts := []Services.HandlerOption{
+			Services.WithAckPolicy(policy),
+			Services.WithErrorHandler(func(err error, r *http.Request) { reported = append(reported, err) }),
+			recordParseErrors(&failures),
+		}
+		want := http.StatusOK
+		if policy == Services.StrictAck {
+			want = http.StatusRequestEntityTooLarge
+		}
+
+		handlers := limitedHandlers(opts...)
+		for name, handler := range handlers {
+			if rec := postWebhook(handler, oversized); rec.Code != want {
+				t.Errorf("%s (policy %d): expected %d for a body over the default limit, got %d", name, policy, want, rec.Code)
+			}
+		}
+
+		mux, err := Services.NewCallbackMux(Services.CallbackMuxConfig{STKPath: "/stk", B2BResultPath: "/b2b", Options: opts})
+		if err != nil {
+			t.Fatalf("NewCallbackMux error: %v", err)
+		}
+		for _, path := range []string{"/stk", "/b2b"} {
+			if rec := postWebhookTo(mux, path, oversized); rec.Code != want {
+				t.Errorf("%s (policy %d): expected %d from the mux, got %d", path, policy, want, rec.Code)
+			}
+		}
+
+		if len(reported) != len(handlers)+2 {
+			t.Errorf("policy %d: expected one error per request, got %v", policy, reported)
+		}
+		for _, err := range reported {
+			if !errors.Is(err, Services.ErrCallbackTooLarge) {
+				t.Errorf("policy %d: expected ErrCallbackTooLarge, got %v", policy, err)
+			}
+		}
+		if len(failures) != 0 {
+			t.Errorf("policy %d: expected truncated bodies to be kept from the parse error hook, got %d calls", policy, len(failures))
+		}
+	}
+}
+
+func TestWebhookHandlers_TrickledBody(t *testing.T) {
+	for _, policy := range []Services.AckPolicy{Services.AlwaysAck, Services.StrictAck} {
+		var mu sync.Mutex
+		var reported []error
+		handlers := limitedHandlers(
+			Services.WithReadTimeout(30*time.Millisecond),
+			Services.WithAckPolicy(policy),
+			Services.WithErrorHandler(func(err error, r *http.Request) {
+				mu.Lock()
+				reported = append(reported, err)
+				mu.Unlock()
+			}),
+		)
+		for name, handler := range handlers {
+			req := httptest.NewRequest(http.MethodPost, "/callback", &trickleReader{body: stkCallbackSuccessJSON, delay: 5 * time.Millisecond})
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			handler.ServeHTTP(rec, req)
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("%s: expected the read to be cut off, took %s", name, elapsed)
+			}
+			want := http.StatusOK
+			if policy == Services.StrictAck {
+				want = http.StatusRequestTimeout
+			}
+			if rec.Code != want {
+				t.Errorf("%s (policy %d): expected %d, got %d", name, policy, want, rec.Code)
+			}
+		}
+
+		mu.Lock()
+		if len(reported) != len(handlers) {
+			t.Errorf("policy %d: expected one error per handler, got %v", policy, reported)
+		}
+		for _, err := range reported {
+			if !errors.Is(err, Services.ErrCallbackReadTimeout) {
+				t.Errorf("policy %d: expected ErrCallbackReadTimeout, got %v", policy, err)
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+func TestWebhookHandlers_ReadTimeoutDisabled(t *testing.T) {
+	handler := Services.STKCallbackHandler(nil, Services.WithReadTimeout(0), Services.WithAckPolicy(Services.StrictAck))
+	req := httptest.NewRequest(http.MethodPost, "/mpesa/stk", &trickleReader{body: stkCallbackCancelledJSON, delay: 50 * time.Microsecond})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the trickled body to be accepted without a read timeout, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestWebhookHandlers_StalledConnection(t *testing.T) {
+	reported := make(chan error, 1)
+	server := httptest.NewServer(Services.STKCallbackHandler(nil,
+		Services.WithReadTimeout(50*time.Millisecond),
+		Services.WithAckPolicy(Services.StrictAck),
+		Services.WithErrorHandler(func(err error, r *http.Request) { reported <- err }),
+	))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer conn.Close()
+
+	// Announce the whole body but send only its first bytes, then stall.
+	fmt.Fprintf(conn, "POST /mpesa/stk HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s",
+		len(stkCallbackSuccessJSON), stkCallbackSuccessJSON[:10])
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("expected a response before the body was complete: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("expected 408, got %d", resp.StatusCode)
+	}
+	if err := <-reported; !errors.Is(err, Services.ErrCallbackReadTimeout) {
+		t.Errorf("expected ErrCallbackReadTimeout, got %v", err)
+	}
+}